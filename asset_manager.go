@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
 )
 
@@ -12,6 +14,16 @@ type AssetManager struct {
 	materials map[string]IMaterial
 	mu        sync.RWMutex
 
+	// Repair settings applied to meshes as they load (nil: used as loaded)
+	Repair *RepairSettings
+
+	// Index and vertex reordering applied to meshes as they load (nil: kept
+	// in file order)
+	Optimize *MeshOptimizationSettings
+
+	// Meshlets built for meshes as they load (nil: culled whole)
+	Meshlets *MeshletSettings
+
 	// Statistics
 	loadedMeshes   int
 	loadedTextures int
@@ -40,12 +52,38 @@ func (am *AssetManager) LoadMesh(path string) (*Mesh, error) {
 
 	am.cacheMisses++
 
-	// Load the mesh (currently only supports OBJ)
-	mesh, err := LoadOBJ(path)
+	mesh, err := loadMeshFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load mesh %s: %w", path, err)
 	}
 
+	if am.Repair != nil {
+		mesh, _ = RepairMesh(mesh, *am.Repair)
+	}
+
+	// Attach a baked lightmap saved next to the mesh, if there is one
+	if lightmapExists(path) {
+		lightmap, err := am.LoadTexture(LightmapPath(path))
+		if err != nil {
+			return nil, err
+		}
+		layout, err := LoadLightmapLayout(LightmapLayoutPath(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load lightmap layout of %s: %w", path, err)
+		}
+		if err := AttachLightmap(mesh, lightmap, layout); err != nil {
+			return nil, fmt.Errorf("failed to attach lightmap to %s: %w", path, err)
+		}
+	}
+
+	// After the lightmap, whose charts are laid out in file order
+	if am.Optimize != nil {
+		mesh, _ = OptimizeMesh(mesh, *am.Optimize)
+	}
+	if am.Meshlets != nil {
+		mesh.BuildMeshlets(*am.Meshlets)
+	}
+
 	am.mu.Lock()
 	am.meshes[path] = mesh
 	am.loadedMeshes++
@@ -54,6 +92,20 @@ func (am *AssetManager) LoadMesh(path string) (*Mesh, error) {
 	return mesh, nil
 }
 
+// loadMeshFile picks a loader from the file extension (OBJ by default)
+func loadMeshFile(path string) (*Mesh, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ply":
+		return LoadPLY(path)
+	case ".gltf", ".glb":
+		return LoadGLTFMesh(path)
+	case ".stl":
+		return LoadSTL(path)
+	default:
+		return LoadOBJ(path)
+	}
+}
+
 // LoadMeshAsync loads a mesh asynchronously
 func (am *AssetManager) LoadMeshAsync(path string, callback func(*Mesh, error)) {
 	go func() {
@@ -190,9 +190,22 @@ func (aabb *AABB) IntersectsRay(ray Ray) (bool, float64) {
 	if tzMin > tMin {
 		tMin = tzMin
 	}
+	if tzMax < tMax {
+		tMax = tzMax
+	}
+
+	// Box entirely behind the ray
+	if tMax < 0 {
+		return false, 0
+	}
+
+	// Ray starts inside the box
+	if tMin < 0 {
+		return true, 0
+	}
 
 	// tMin is the distance to intersection
-	return tMin >= 0, tMin
+	return true, tMin
 }
 
 func (aabb *AABB) GetCenter() Point {
@@ -379,3 +392,17 @@ func TransformAABB(aabb *AABB, transform *Transform) *AABB {
 	// Create new AABB from transformed corners
 	return NewAABBFromPoints(transformed)
 }
+
+// TransformAABBByMatrix returns the box around an AABB's corners under a
+// world matrix
+func TransformAABBByMatrix(aabb *AABB, worldMatrix Matrix4x4) *AABB {
+	corners := make([]Point, 0, 8)
+	for _, x := range []float64{aabb.Min.X, aabb.Max.X} {
+		for _, y := range []float64{aabb.Min.Y, aabb.Max.Y} {
+			for _, z := range []float64{aabb.Min.Z, aabb.Max.Z} {
+				corners = append(corners, worldMatrix.TransformPoint(Point{X: x, Y: y, Z: z}))
+			}
+		}
+	}
+	return NewAABBFromPoints(corners)
+}
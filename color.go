@@ -40,6 +40,15 @@ func ColorReset() string {
 	return "\033[0m"
 }
 
+// Multiply modulates a color by another, channel by channel (white leaves it unchanged)
+func (c Color) Multiply(other Color) Color {
+	return Color{
+		R: uint8((uint16(c.R)*uint16(other.R) + 127) / 255),
+		G: uint8((uint16(c.G)*uint16(other.G) + 127) / 255),
+		B: uint8((uint16(c.B)*uint16(other.B) + 127) / 255),
+	}
+}
+
 // Lerp linearly interpolates between two colors
 func (c Color) Lerp(other Color, t float64) Color {
 	if t < 0 {
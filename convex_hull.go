@@ -0,0 +1,779 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// CONVEX HULLS
+// ============================================================================
+// ConvexHull computes the smallest convex polyhedron around a point set
+// with quickhull: start from a tetrahedron of extreme points, then keep
+// adding the point farthest outside a face, replacing the faces it can see
+// with a fan from the horizon. Faces know their neighbours, so the visible
+// faces are found by walking out from that face, and only the points
+// outside removed faces are sorted onto the new ones. A hull is a BoundingVolume, so it can stand
+// in for an AABB or OBB wherever a tighter fit is worth the extra planes;
+// intersection tests against other volumes use GJK on support points.
+//
+// ConvexDecomposition approximates a concave mesh by several hulls, for
+// collision proxies: parts are cut by the axis-aligned plane that most
+// reduces the volume their hulls add beyond the surface (the concavity),
+// until every part is convex enough or the hull budget is used up.
+// ============================================================================
+
+// ErrDegenerateHull is returned for point sets without volume
+var ErrDegenerateHull = errors.New("convex hull: points do not span a volume")
+
+// ConvexHull is a closed convex polyhedron
+type ConvexHull struct {
+	Vertices []Point
+	Faces    [][3]int       // Triangles, wound so cross(p1-p0, p2-p0) points outward
+	Planes   []FrustumPlane // One per face: Normal·p + Distance <= 0 inside
+}
+
+// hullFace is a face while the hull is being built
+type hullFace struct {
+	V         [3]int
+	Neighbors [3]*hullFace // Across edge V[k] -> V[k+1]
+	Normal    Point
+	Offset    float64 // Normal·p for points on the face
+	Outside   []int   // Points in front of the face
+	Alive     bool
+}
+
+func newHullFace(points []Point, a, b, c int) *hullFace {
+	n := crossPoints(subPoints(points[b], points[a]), subPoints(points[c], points[a]))
+	if l := pointLength(n); l > 0 {
+		n = scalePoint(n, 1/l)
+	}
+	return &hullFace{V: [3]int{a, b, c}, Normal: n, Offset: dotPoints(n, points[a]), Alive: true}
+}
+
+func (f *hullFace) distance(p Point) float64 {
+	return dotPoints(f.Normal, p) - f.Offset
+}
+
+// linkHullFaces connects faces that share an edge
+func linkHullFaces(faces []*hullFace) {
+	edges := make(map[[2]int]*hullFace, len(faces)*3)
+	for _, f := range faces {
+		for k := 0; k < 3; k++ {
+			edges[[2]int{f.V[k], f.V[(k+1)%3]}] = f
+		}
+	}
+	for _, f := range faces {
+		for k := 0; k < 3; k++ {
+			if g, ok := edges[[2]int{f.V[(k+1)%3], f.V[k]}]; ok {
+				f.Neighbors[k] = g
+			}
+		}
+	}
+}
+
+// ComputeConvexHull builds the convex hull of a point set
+func ComputeConvexHull(points []Point) (*ConvexHull, error) {
+	// Duplicates only slow things down
+	unique := make([]Point, 0, len(points))
+	seen := make(map[Point]bool)
+	for _, p := range points {
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+	if len(unique) < 4 {
+		return nil, ErrDegenerateHull
+	}
+	points = unique
+
+	aabb := NewAABBFromPoints(points)
+	size := aabb.GetSize()
+	eps := 1e-10 * math.Max(math.Max(size.X, size.Y), math.Max(size.Z, 1e-12))
+
+	// Initial tetrahedron: the farthest pair of axis extremes, then the
+	// farthest point from their line and from the plane of the three
+	extremes := [6]int{}
+	for i, p := range points {
+		for axis := 0; axis < 3; axis++ {
+			if pointAxis(p, axis) < pointAxis(points[extremes[axis*2]], axis) {
+				extremes[axis*2] = i
+			}
+			if pointAxis(p, axis) > pointAxis(points[extremes[axis*2+1]], axis) {
+				extremes[axis*2+1] = i
+			}
+		}
+	}
+	v0, v1, best := 0, 0, -1.0
+	for _, a := range extremes {
+		for _, b := range extremes {
+			if d := pointLength(subPoints(points[a], points[b])); d > best {
+				v0, v1, best = a, b, d
+			}
+		}
+	}
+	if best <= eps {
+		return nil, ErrDegenerateHull
+	}
+
+	line := normalizePoint(subPoints(points[v1], points[v0]))
+	v2, best := -1, eps
+	for i, p := range points {
+		d := subPoints(p, points[v0])
+		if dist := pointLength(subPoints(d, scalePoint(line, dotPoints(d, line)))); dist > best {
+			v2, best = i, dist
+		}
+	}
+	if v2 < 0 {
+		return nil, ErrDegenerateHull
+	}
+
+	base := newHullFace(points, v0, v1, v2)
+	v3, best := -1, eps
+	for i, p := range points {
+		if dist := math.Abs(base.distance(p)); dist > best {
+			v3, best = i, dist
+		}
+	}
+	if v3 < 0 {
+		return nil, ErrDegenerateHull
+	}
+
+	// Wind the tetrahedron outward
+	if base.distance(points[v3]) > 0 {
+		v1, v2 = v2, v1
+	}
+	faces := []*hullFace{
+		newHullFace(points, v0, v1, v2),
+		newHullFace(points, v0, v3, v1),
+		newHullFace(points, v1, v3, v2),
+		newHullFace(points, v2, v3, v0),
+	}
+	linkHullFaces(faces)
+
+	assign := func(candidates []int, targets []*hullFace) {
+		for _, i := range candidates {
+			far, farFace := eps, (*hullFace)(nil)
+			for _, f := range targets {
+				if d := f.distance(points[i]); d > far {
+					far, farFace = d, f
+				}
+			}
+			if farFace != nil {
+				farFace.Outside = append(farFace.Outside, i)
+			}
+		}
+	}
+	all := make([]int, 0, len(points))
+	for i := range points {
+		if i != v0 && i != v1 && i != v2 && i != v3 {
+			all = append(all, i)
+		}
+	}
+	assign(all, faces)
+
+	// Faces that may still have points outside them
+	pending := append([]*hullFace(nil), faces...)
+	for len(pending) > 0 {
+		face := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if !face.Alive || len(face.Outside) == 0 {
+			continue
+		}
+
+		// Farthest outside point of the face
+		eye, far := -1, 0.0
+		for _, i := range face.Outside {
+			if d := face.distance(points[i]); d > far {
+				eye, far = i, d
+			}
+		}
+
+		// The faces the eye sees are connected, so walk out from this one.
+		// Edges to faces that don't see it make up the horizon.
+		type horizonEdge struct {
+			A, B   int
+			Across *hullFace
+		}
+		var horizon []horizonEdge
+		visible := []*hullFace{face}
+		face.Alive = false
+		for n := 0; n < len(visible); n++ {
+			f := visible[n]
+			for k, g := range f.Neighbors {
+				switch {
+				case !g.Alive:
+					// Already found visible
+				case g.distance(points[eye]) > eps:
+					g.Alive = false
+					visible = append(visible, g)
+				default:
+					horizon = append(horizon, horizonEdge{A: f.V[k], B: f.V[(k+1)%3], Across: g})
+				}
+			}
+		}
+
+		// Fan from the horizon to the eye, stitched to the faces beyond it
+		created := make([]*hullFace, len(horizon))
+		for i, e := range horizon {
+			f := newHullFace(points, e.A, e.B, eye)
+			f.Neighbors[0] = e.Across
+			for k := 0; k < 3; k++ {
+				if e.Across.V[k] == e.B {
+					e.Across.Neighbors[k] = f
+				}
+			}
+			created[i] = f
+		}
+		linkHullFaces(created)
+
+		// Only points outside a removed face can be outside the new ones
+		var orphans []int
+		for _, f := range visible {
+			for _, i := range f.Outside {
+				if i != eye {
+					orphans = append(orphans, i)
+				}
+			}
+			f.Outside = nil
+		}
+		assign(orphans, created)
+		faces = append(faces, created...)
+		pending = append(pending, created...)
+	}
+
+	// Keep only the points the hull uses
+	hull := &ConvexHull{}
+	remap := make(map[int]int)
+	for _, f := range faces {
+		if !f.Alive {
+			continue
+		}
+		var tri [3]int
+		for k, i := range f.V {
+			idx, ok := remap[i]
+			if !ok {
+				idx = len(hull.Vertices)
+				remap[i] = idx
+				hull.Vertices = append(hull.Vertices, points[i])
+			}
+			tri[k] = idx
+		}
+		hull.Faces = append(hull.Faces, tri)
+	}
+	hull.computePlanes()
+	return hull, nil
+}
+
+// ComputeMeshConvexHull builds the convex hull of a mesh's vertices,
+// including the mesh position as ComputeMeshBounds does
+func ComputeMeshConvexHull(mesh *Mesh) (*ConvexHull, error) {
+	points := make([]Point, len(mesh.Vertices))
+	for i, v := range mesh.Vertices {
+		points[i] = addPoints(v, mesh.Position)
+	}
+	return ComputeConvexHull(points)
+}
+
+// pointAxis returns one coordinate of a point
+func pointAxis(p Point, axis int) float64 {
+	switch axis {
+	case 0:
+		return p.X
+	case 1:
+		return p.Y
+	}
+	return p.Z
+}
+
+// computePlanes derives the face planes from the faces
+func (h *ConvexHull) computePlanes() {
+	h.Planes = make([]FrustumPlane, len(h.Faces))
+	for i, f := range h.Faces {
+		p0 := h.Vertices[f[0]]
+		n := crossPoints(subPoints(h.Vertices[f[1]], p0), subPoints(h.Vertices[f[2]], p0))
+		if l := pointLength(n); l > 0 {
+			n = scalePoint(n, 1/l)
+		}
+		h.Planes[i] = FrustumPlane{Normal: n, Distance: -dotPoints(n, p0)}
+	}
+}
+
+// ToMesh returns the hull as a mesh. Vertices are shared and there are no
+// normals, so it renders flat shaded.
+func (h *ConvexHull) ToMesh() *Mesh {
+	mesh := NewMesh()
+	mesh.Vertices = append(mesh.Vertices, h.Vertices...)
+	for _, f := range h.Faces {
+		mesh.AddTriangleIndices(f[0], f[1], f[2])
+	}
+	return mesh
+}
+
+// Transformed returns the hull moved by a transform
+func (h *ConvexHull) Transformed(transform *Transform) *ConvexHull {
+	out := &ConvexHull{Vertices: make([]Point, len(h.Vertices)), Faces: make([][3]int, len(h.Faces))}
+	for i, v := range h.Vertices {
+		out.Vertices[i] = transform.TransformPoint(v)
+	}
+	copy(out.Faces, h.Faces)
+
+	// A mirroring transform turns the faces inside out
+	if transform.Scale.X*transform.Scale.Y*transform.Scale.Z < 0 {
+		for i := range out.Faces {
+			out.Faces[i][1], out.Faces[i][2] = out.Faces[i][2], out.Faces[i][1]
+		}
+	}
+	out.computePlanes()
+	return out
+}
+
+// Volume returns the hull's volume
+func (h *ConvexHull) Volume() float64 {
+	return meshTriangleVolume(h.Vertices, h.Faces)
+}
+
+// meshTriangleVolume returns the signed volume enclosed by triangles
+func meshTriangleVolume(vertices []Point, faces [][3]int) float64 {
+	v := 0.0
+	for _, f := range faces {
+		v += dotPoints(vertices[f[0]], crossPoints(vertices[f[1]], vertices[f[2]])) / 6
+	}
+	return v
+}
+
+// Support returns the hull vertex farthest along a direction
+func (h *ConvexHull) Support(dir Point) Point {
+	best, bestDot := Point{}, math.Inf(-1)
+	for _, v := range h.Vertices {
+		if d := dotPoints(v, dir); d > bestDot {
+			best, bestDot = v, d
+		}
+	}
+	return best
+}
+
+// Contains reports whether a point is inside the hull
+func (h *ConvexHull) Contains(p Point) bool {
+	for _, plane := range h.Planes {
+		if dotPoints(plane.Normal, p)+plane.Distance > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// GetCenter returns the average of the hull's vertices
+func (h *ConvexHull) GetCenter() Point {
+	c := Point{}
+	for _, v := range h.Vertices {
+		c = addPoints(c, v)
+	}
+	if len(h.Vertices) > 0 {
+		c = scalePoint(c, 1/float64(len(h.Vertices)))
+	}
+	return c
+}
+
+// GetRadius returns the radius of a sphere around GetCenter enclosing the
+// hull
+func (h *ConvexHull) GetRadius() float64 {
+	c := h.GetCenter()
+	r := 0.0
+	for _, v := range h.Vertices {
+		r = math.Max(r, pointLength(subPoints(v, c)))
+	}
+	return r
+}
+
+// IntersectsRay clips the ray against every face plane (Cyrus-Beck)
+func (h *ConvexHull) IntersectsRay(ray Ray) (bool, float64) {
+	tMin, tMax := 0.0, math.Inf(1)
+	for _, plane := range h.Planes {
+		denom := dotPoints(plane.Normal, ray.Direction)
+		dist := dotPoints(plane.Normal, ray.Origin) + plane.Distance
+		if math.Abs(denom) < 1e-12 {
+			if dist > 0 {
+				return false, 0 // Parallel and outside
+			}
+			continue
+		}
+		t := -dist / denom
+		if denom < 0 {
+			tMin = math.Max(tMin, t) // Entering
+		} else {
+			tMax = math.Min(tMax, t) // Leaving
+		}
+		if tMin > tMax {
+			return false, 0
+		}
+	}
+	return true, tMin
+}
+
+// Intersects tests the hull against another bounding volume
+func (h *ConvexHull) Intersects(other BoundingVolume) bool {
+	support := volumeSupport(other)
+	if support == nil {
+		return false
+	}
+	return gjkIntersect(h.Support, support, subPoints(h.GetCenter(), other.GetCenter()))
+}
+
+// IntersectsHull tests two hulls for overlap
+func (h *ConvexHull) IntersectsHull(other *ConvexHull) bool {
+	return h.Intersects(other)
+}
+
+// SilhouetteEdges returns the edges between faces facing towards and away
+// from a direction, in the order they run around the front faces. These
+// are the edges a shadow volume extrudes for a light shining along -dir.
+func (h *ConvexHull) SilhouetteEdges(dir Point) [][2]Point {
+	front := make(map[[2]int]bool)
+	for i, f := range h.Faces {
+		if dotPoints(h.Planes[i].Normal, dir) > 0 {
+			for k := 0; k < 3; k++ {
+				front[[2]int{f[k], f[(k+1)%3]}] = true
+			}
+		}
+	}
+
+	var keys [][2]int
+	for e := range front {
+		if !front[[2]int{e[1], e[0]}] {
+			keys = append(keys, e)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+
+	edges := make([][2]Point, len(keys))
+	for i, e := range keys {
+		edges[i] = [2]Point{h.Vertices[e[0]], h.Vertices[e[1]]}
+	}
+	return edges
+}
+
+// ============================================================================
+// GJK
+// ============================================================================
+
+// volumeSupport returns the support function of a bounding volume
+func volumeSupport(volume BoundingVolume) func(Point) Point {
+	switch v := volume.(type) {
+	case *ConvexHull:
+		return v.Support
+	case *AABB:
+		return func(d Point) Point {
+			p := v.Min
+			if d.X > 0 {
+				p.X = v.Max.X
+			}
+			if d.Y > 0 {
+				p.Y = v.Max.Y
+			}
+			if d.Z > 0 {
+				p.Z = v.Max.Z
+			}
+			return p
+		}
+	case *OBB:
+		return func(d Point) Point {
+			p := v.Center
+			for i, axis := range v.Axes {
+				half := v.getHalfExtent(i)
+				if dotPoints(d, axis) < 0 {
+					half = -half
+				}
+				p = addPoints(p, scalePoint(axis, half))
+			}
+			return p
+		}
+	case *BoundingSphere:
+		return func(d Point) Point {
+			if l := pointLength(d); l > 1e-12 {
+				return addPoints(v.Center, scalePoint(d, v.Radius/l))
+			}
+			return v.Center
+		}
+	}
+	return nil
+}
+
+// gjkIntersect reports whether two convex shapes overlap, searching their
+// Minkowski difference for the origin with a simplex of up to four points
+func gjkIntersect(supportA, supportB func(Point) Point, dir Point) bool {
+	support := func(d Point) Point {
+		return subPoints(supportA(d), supportB(scalePoint(d, -1)))
+	}
+	if pointLength(dir) < 1e-12 {
+		dir = Point{X: 1}
+	}
+
+	c := support(dir)
+	dir = scalePoint(c, -1)
+	b := support(dir)
+	if dotPoints(b, dir) < 0 {
+		return false
+	}
+
+	cb := subPoints(c, b)
+	dir = crossPoints(crossPoints(cb, scalePoint(b, -1)), cb)
+	if pointLength(dir) < 1e-12 {
+		// The origin is on the segment's line
+		dir = crossPoints(cb, Point{X: 1})
+		if pointLength(dir) < 1e-12 {
+			dir = crossPoints(cb, Point{Z: -1})
+		}
+	}
+
+	var d Point
+	dim := 2
+	for iteration := 0; iteration < 64; iteration++ {
+		a := support(dir)
+		if dotPoints(a, dir) < 0 {
+			return false
+		}
+		dim++
+		ao := scalePoint(a, -1)
+
+		if dim == 3 {
+			ab, ac := subPoints(b, a), subPoints(c, a)
+			n := crossPoints(ab, ac)
+			dim = 2
+			switch {
+			case dotPoints(crossPoints(ab, n), ao) > 0:
+				c = a
+				dir = crossPoints(crossPoints(ab, ao), ab)
+			case dotPoints(crossPoints(n, ac), ao) > 0:
+				b = a
+				dir = crossPoints(crossPoints(ac, ao), ac)
+			case dotPoints(n, ao) > 0:
+				dim = 3
+				d, c, b = c, b, a
+				dir = n
+			default:
+				dim = 3
+				d, b = b, a
+				dir = scalePoint(n, -1)
+			}
+			continue
+		}
+
+		ab, ac, ad := subPoints(b, a), subPoints(c, a), subPoints(d, a)
+		abc, acd, adb := crossPoints(ab, ac), crossPoints(ac, ad), crossPoints(ad, ab)
+		dim = 3
+		switch {
+		case dotPoints(abc, ao) > 0:
+			d, c, b = c, b, a
+			dir = abc
+		case dotPoints(acd, ao) > 0:
+			b = a
+			dir = acd
+		case dotPoints(adb, ao) > 0:
+			c, d, b = d, b, a
+			dir = adb
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// CONVEX DECOMPOSITION
+// ============================================================================
+
+// ConvexDecompositionSettings controls approximate convex decomposition
+type ConvexDecompositionSettings struct {
+	MaxHulls     int     // Upper limit on the number of hulls
+	MaxConcavity float64 // Parts whose hull adds less than this fraction of the model's hull volume stay whole
+	PlaneSamples int     // Candidate cutting planes tried per axis
+	MaxDepth     int     // Deepest a part can be cut
+}
+
+// DefaultConvexDecompositionSettings returns settings for collision proxies
+func DefaultConvexDecompositionSettings() ConvexDecompositionSettings {
+	return ConvexDecompositionSettings{
+		MaxHulls:     16,
+		MaxConcavity: 0.01,
+		PlaneSamples: 8,
+		MaxDepth:     8,
+	}
+}
+
+// decompositionPart is a closed piece of the model as triangles
+type decompositionPart struct {
+	Triangles [][3]Point
+	Hull      *ConvexHull
+	Concavity float64 // Hull volume beyond the part's volume
+	Depth     int
+}
+
+func newDecompositionPart(triangles [][3]Point, depth int) *decompositionPart {
+	part := &decompositionPart{Triangles: triangles, Depth: depth}
+	points := make([]Point, 0, len(triangles)*3)
+	volume := 0.0
+	for _, t := range triangles {
+		points = append(points, t[0], t[1], t[2])
+		volume += dotPoints(t[0], crossPoints(t[1], t[2])) / 6
+	}
+	hull, err := ComputeConvexHull(points)
+	if err != nil {
+		return nil
+	}
+	part.Hull = hull
+	part.Concavity = math.Max(hull.Volume()-volume, 0)
+	return part
+}
+
+// splitTriangles cuts closed triangles by the plane normal·p = offset. Each
+// side is closed again with a cap: a fan from one cut point to every cut
+// segment. The fan overlaps itself on concave or split cuts, but its signed
+// volume is still exact and it adds no new points, which is all a part's
+// hull and concavity need.
+func splitTriangles(triangles [][3]Point, normal Point, offset float64) (below, above [][3]Point) {
+	type segment struct{ A, B Point }
+	var belowCut, aboveCut []segment
+
+	clip := func(t [3]Point, sign float64) ([][3]Point, []segment) {
+		type corner struct {
+			P   Point
+			New bool // Created by the cut rather than a triangle corner
+			On  bool // On the plane
+		}
+		var poly []corner
+		for k := 0; k < 3; k++ {
+			p, q := t[k], t[(k+1)%3]
+			dp := sign * (dotPoints(normal, p) - offset)
+			dq := sign * (dotPoints(normal, q) - offset)
+			if dp <= 0 {
+				poly = append(poly, corner{P: p, On: dp == 0})
+			}
+			if (dp < 0 && dq > 0) || (dp > 0 && dq < 0) {
+				poly = append(poly, corner{P: lerpPoint(p, q, dp/(dp-dq)), New: true, On: true})
+			}
+		}
+		if len(poly) < 3 {
+			return nil, nil
+		}
+		var tris [][3]Point
+		for k := 1; k+1 < len(poly); k++ {
+			tris = append(tris, [3]Point{poly[0].P, poly[k].P, poly[k+1].P})
+		}
+		// The cap runs against the polygon along the cut. Edges between two
+		// corners already on the plane belong to the original surface.
+		var cut []segment
+		for k := range poly {
+			a, b := poly[k], poly[(k+1)%len(poly)]
+			if a.On && b.On && (a.New || b.New) {
+				cut = append(cut, segment{A: b.P, B: a.P})
+			}
+		}
+		return tris, cut
+	}
+
+	for _, t := range triangles {
+		tris, cut := clip(t, 1)
+		below = append(below, tris...)
+		belowCut = append(belowCut, cut...)
+		tris, cut = clip(t, -1)
+		above = append(above, tris...)
+		aboveCut = append(aboveCut, cut...)
+	}
+
+	capWith := func(tris [][3]Point, cut []segment) [][3]Point {
+		if len(cut) == 0 {
+			return tris
+		}
+		center := cut[0].A
+		for _, s := range cut[1:] {
+			tris = append(tris, [3]Point{center, s.A, s.B})
+		}
+		return tris
+	}
+	return capWith(below, belowCut), capWith(above, aboveCut)
+}
+
+// ConvexDecomposition approximates a closed mesh by convex hulls. A mesh that
+// is already convex enough comes back as its own hull.
+func ConvexDecomposition(mesh *Mesh, settings ConvexDecompositionSettings) ([]*ConvexHull, error) {
+	triangles := make([][3]Point, 0, len(mesh.Indices)/3)
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		triangles = append(triangles, [3]Point{
+			addPoints(mesh.Vertices[mesh.Indices[i]], mesh.Position),
+			addPoints(mesh.Vertices[mesh.Indices[i+1]], mesh.Position),
+			addPoints(mesh.Vertices[mesh.Indices[i+2]], mesh.Position),
+		})
+	}
+	root := newDecompositionPart(triangles, 0)
+	if root == nil {
+		return nil, ErrDegenerateHull
+	}
+
+	threshold := settings.MaxConcavity * root.Hull.Volume()
+	samples := maxInt(settings.PlaneSamples, 1)
+	parts := []*decompositionPart{root}
+
+	for len(parts) < settings.MaxHulls {
+		// Cut the most concave part next
+		worst := -1
+		for i, p := range parts {
+			if p.Concavity > threshold && p.Depth < settings.MaxDepth && (worst < 0 || p.Concavity > parts[worst].Concavity) {
+				worst = i
+			}
+		}
+		if worst < 0 {
+			break
+		}
+		part := parts[worst]
+
+		// Try evenly spaced planes across the part's hull on each axis. The
+		// best cut is taken even if it doesn't lower the concavity yet:
+		// halving a ring leaves two arcs just as concave, which the next
+		// cuts fix.
+		var bestBelow, bestAbove *decompositionPart
+		bestCost := math.Inf(1)
+		bounds := NewAABBFromPoints(part.Hull.Vertices)
+		for axis := 0; axis < 3; axis++ {
+			normal := Point{}
+			switch axis {
+			case 0:
+				normal.X = 1
+			case 1:
+				normal.Y = 1
+			default:
+				normal.Z = 1
+			}
+			lo, hi := pointAxis(bounds.Min, axis), pointAxis(bounds.Max, axis)
+			for s := 1; s <= samples; s++ {
+				offset := lo + (hi-lo)*float64(s)/float64(samples+1)
+				belowTris, aboveTris := splitTriangles(part.Triangles, normal, offset)
+				below := newDecompositionPart(belowTris, part.Depth+1)
+				above := newDecompositionPart(aboveTris, part.Depth+1)
+				if below == nil || above == nil {
+					continue
+				}
+				if cost := below.Concavity + above.Concavity; cost < bestCost {
+					bestBelow, bestAbove, bestCost = below, above, cost
+				}
+			}
+		}
+
+		if bestBelow == nil {
+			part.Depth = settings.MaxDepth // Nothing to cut; leave it
+			continue
+		}
+		parts[worst] = bestBelow
+		parts = append(parts, bestAbove)
+	}
+
+	hulls := make([]*ConvexHull, len(parts))
+	for i, p := range parts {
+		hulls[i] = p.Hull
+	}
+	return hulls, nil
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// CONSTRUCTIVE SOLID GEOMETRY
+// ============================================================================
+// Union, difference and intersection of two closed meshes using BSP trees:
+// each mesh becomes a tree of its polygons, the polygons of one solid are
+// clipped against the tree of the other, and the surviving pieces are joined.
+// Polygons are split exactly along the other solid's planes, interpolating
+// UVs, normals and colors, and every piece keeps the material of the mesh it
+// came from. Results mixing two materials use Mesh.FaceMaterials.
+//
+// Splitting a polygon leaves its new corners in the middle of its
+// neighbours' edges (T-junctions), which would crack when rasterized and
+// leave open edges for repair and simplification. Before triangulating,
+// nearby corners are welded and every edge is split at the corners lying on
+// it, so the result is closed.
+// ============================================================================
+
+// csgEpsilon is the plane thickness used to classify vertices
+const csgEpsilon = 1e-5
+
+// CSGOperation selects a boolean operation
+type CSGOperation int
+
+const (
+	CSGUnion CSGOperation = iota
+	CSGDifference
+	CSGIntersection
+)
+
+// String returns the operation name
+func (op CSGOperation) String() string {
+	switch op {
+	case CSGUnion:
+		return "union"
+	case CSGDifference:
+		return "difference"
+	case CSGIntersection:
+		return "intersection"
+	}
+	return fmt.Sprintf("CSGOperation(%d)", int(op))
+}
+
+// csgVertex is a polygon corner with the attributes carried through splits
+type csgVertex struct {
+	Pos    Point
+	Normal Point
+	UV     TextureCoord
+	Color  Color
+}
+
+// lerp interpolates every attribute towards another vertex
+func (v csgVertex) lerp(other csgVertex, t float64) csgVertex {
+	return csgVertex{
+		Pos:    lerpPoint(v.Pos, other.Pos, t),
+		Normal: lerpPoint(v.Normal, other.Normal, t),
+		UV: TextureCoord{
+			U: v.UV.U + (other.UV.U-v.UV.U)*t,
+			V: v.UV.V + (other.UV.V-v.UV.V)*t,
+		},
+		Color: v.Color.Lerp(other.Color, t),
+	}
+}
+
+// csgPlane is the plane dot(Normal, p) = W
+type csgPlane struct {
+	Normal Point
+	W      float64
+}
+
+func (p *csgPlane) flip() {
+	p.Normal = scalePoint(p.Normal, -1)
+	p.W = -p.W
+}
+
+// csgPolygon is a convex planar polygon of one input mesh
+type csgPolygon struct {
+	Vertices []csgVertex
+	Plane    csgPlane
+	Material IMaterial
+}
+
+// newCSGPolygon creates a polygon, or returns nil if it is degenerate
+func newCSGPolygon(vertices []csgVertex, material IMaterial) *csgPolygon {
+	n := crossPoints(subPoints(vertices[1].Pos, vertices[0].Pos), subPoints(vertices[2].Pos, vertices[0].Pos))
+	if pointLength(n) < 1e-12 {
+		return nil
+	}
+	n = normalizePoint(n)
+	return &csgPolygon{
+		Vertices: vertices,
+		Plane:    csgPlane{Normal: n, W: n.X*vertices[0].Pos.X + n.Y*vertices[0].Pos.Y + n.Z*vertices[0].Pos.Z},
+		Material: material,
+	}
+}
+
+// flip reverses the polygon's winding and facing
+func (p *csgPolygon) flip() {
+	for i, j := 0, len(p.Vertices)-1; i < j; i, j = i+1, j-1 {
+		p.Vertices[i], p.Vertices[j] = p.Vertices[j], p.Vertices[i]
+	}
+	for i := range p.Vertices {
+		p.Vertices[i].Normal = scalePoint(p.Vertices[i].Normal, -1)
+	}
+	p.Plane.flip()
+}
+
+// Vertex classification against a plane
+const (
+	csgCoplanar = 0
+	csgFront    = 1
+	csgBack     = 2
+	csgSpanning = 3
+)
+
+// splitPolygon sorts a polygon into the lists for the plane, splitting it in
+// two if it crosses the plane
+func (plane *csgPlane) splitPolygon(polygon *csgPolygon, coplanarFront, coplanarBack, front, back *[]*csgPolygon) {
+	polygonType := 0
+	types := make([]int, len(polygon.Vertices))
+	for i, v := range polygon.Vertices {
+		t := plane.Normal.X*v.Pos.X + plane.Normal.Y*v.Pos.Y + plane.Normal.Z*v.Pos.Z - plane.W
+		switch {
+		case t < -csgEpsilon:
+			types[i] = csgBack
+		case t > csgEpsilon:
+			types[i] = csgFront
+		default:
+			types[i] = csgCoplanar
+		}
+		polygonType |= types[i]
+	}
+
+	switch polygonType {
+	case csgCoplanar:
+		n := polygon.Plane.Normal
+		if plane.Normal.X*n.X+plane.Normal.Y*n.Y+plane.Normal.Z*n.Z > 0 {
+			*coplanarFront = append(*coplanarFront, polygon)
+		} else {
+			*coplanarBack = append(*coplanarBack, polygon)
+		}
+	case csgFront:
+		*front = append(*front, polygon)
+	case csgBack:
+		*back = append(*back, polygon)
+	case csgSpanning:
+		var f, b []csgVertex
+		count := len(polygon.Vertices)
+		for i := 0; i < count; i++ {
+			j := (i + 1) % count
+			ti, tj := types[i], types[j]
+			vi, vj := polygon.Vertices[i], polygon.Vertices[j]
+			if ti != csgBack {
+				f = append(f, vi)
+			}
+			if ti != csgFront {
+				b = append(b, vi)
+			}
+			if ti|tj == csgSpanning {
+				d := subPoints(vj.Pos, vi.Pos)
+				denom := plane.Normal.X*d.X + plane.Normal.Y*d.Y + plane.Normal.Z*d.Z
+				t := (plane.W - (plane.Normal.X*vi.Pos.X + plane.Normal.Y*vi.Pos.Y + plane.Normal.Z*vi.Pos.Z)) / denom
+				v := vi.lerp(vj, t)
+				f = append(f, v)
+				b = append(b, v)
+			}
+		}
+		// Pieces keep the parent plane so slivers don't drift
+		if len(f) >= 3 {
+			*front = append(*front, &csgPolygon{Vertices: f, Plane: polygon.Plane, Material: polygon.Material})
+		}
+		if len(b) >= 3 {
+			*back = append(*back, &csgPolygon{Vertices: b, Plane: polygon.Plane, Material: polygon.Material})
+		}
+	}
+}
+
+// csgNode is a BSP tree node; polygons lie in its plane, front and back hold
+// the polygons on either side
+type csgNode struct {
+	Plane    *csgPlane
+	Front    *csgNode
+	Back     *csgNode
+	Polygons []*csgPolygon
+}
+
+func newCSGNode(polygons []*csgPolygon) *csgNode {
+	node := &csgNode{}
+	if len(polygons) > 0 {
+		node.build(polygons)
+	}
+	return node
+}
+
+// invert turns the solid inside out
+func (n *csgNode) invert() {
+	for _, p := range n.Polygons {
+		p.flip()
+	}
+	if n.Plane != nil {
+		n.Plane.flip()
+	}
+	if n.Front != nil {
+		n.Front.invert()
+	}
+	if n.Back != nil {
+		n.Back.invert()
+	}
+	n.Front, n.Back = n.Back, n.Front
+}
+
+// clipPolygons removes the parts of polygons inside this solid
+func (n *csgNode) clipPolygons(polygons []*csgPolygon) []*csgPolygon {
+	if n.Plane == nil {
+		return append([]*csgPolygon(nil), polygons...)
+	}
+
+	var front, back []*csgPolygon
+	for _, p := range polygons {
+		n.Plane.splitPolygon(p, &front, &back, &front, &back)
+	}
+	if n.Front != nil {
+		front = n.Front.clipPolygons(front)
+	}
+	if n.Back != nil {
+		back = n.Back.clipPolygons(back)
+	} else {
+		back = nil
+	}
+	return append(front, back...)
+}
+
+// clipTo removes the parts of this tree's polygons inside another solid
+func (n *csgNode) clipTo(other *csgNode) {
+	n.Polygons = other.clipPolygons(n.Polygons)
+	if n.Front != nil {
+		n.Front.clipTo(other)
+	}
+	if n.Back != nil {
+		n.Back.clipTo(other)
+	}
+}
+
+// allPolygons returns every polygon in the tree
+func (n *csgNode) allPolygons() []*csgPolygon {
+	polygons := append([]*csgPolygon(nil), n.Polygons...)
+	if n.Front != nil {
+		polygons = append(polygons, n.Front.allPolygons()...)
+	}
+	if n.Back != nil {
+		polygons = append(polygons, n.Back.allPolygons()...)
+	}
+	return polygons
+}
+
+// build adds polygons to the tree, splitting them by the node planes
+func (n *csgNode) build(polygons []*csgPolygon) {
+	if len(polygons) == 0 {
+		return
+	}
+	if n.Plane == nil {
+		plane := polygons[0].Plane
+		n.Plane = &plane
+	}
+
+	var front, back []*csgPolygon
+	for _, p := range polygons {
+		n.Plane.splitPolygon(p, &n.Polygons, &n.Polygons, &front, &back)
+	}
+	if len(front) > 0 {
+		if n.Front == nil {
+			n.Front = &csgNode{}
+		}
+		n.Front.build(front)
+	}
+	if len(back) > 0 {
+		if n.Back == nil {
+			n.Back = &csgNode{}
+		}
+		n.Back.build(back)
+	}
+}
+
+// meshToCSGPolygons converts a mesh's triangles to polygons, applying its
+// position offset. Meshes without normals get face normals.
+func meshToCSGPolygons(mesh *Mesh) []*csgPolygon {
+	hasUVs := len(mesh.UVs) == len(mesh.Vertices)
+	hasNormals := mesh.HasNormals()
+	hasColors := mesh.HasColors()
+
+	polygons := make([]*csgPolygon, 0, len(mesh.Indices)/3)
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		vertices := make([]csgVertex, 3)
+		for k := 0; k < 3; k++ {
+			idx := mesh.Indices[i+k]
+			v := csgVertex{Pos: addPoints(mesh.Vertices[idx], mesh.Position), Color: ColorWhite}
+			if hasUVs {
+				v.UV = mesh.UVs[idx]
+			}
+			if hasNormals {
+				v.Normal = mesh.Normals[idx]
+			}
+			if hasColors {
+				v.Color = mesh.Colors[idx]
+			}
+			vertices[k] = v
+		}
+
+		polygon := newCSGPolygon(vertices, mesh.MaterialForFace(i/3))
+		if polygon == nil {
+			continue
+		}
+		if !hasNormals {
+			for k := range polygon.Vertices {
+				polygon.Vertices[k].Normal = polygon.Plane.Normal
+			}
+		}
+		polygons = append(polygons, polygon)
+	}
+	return polygons
+}
+
+// weldCSGPolygons snaps corners closer than csgEpsilon to one position and
+// splits every polygon edge at the corners of other polygons lying on it.
+// Inserted corners are collinear, so polygons stay convex.
+func weldCSGPolygons(polygons []*csgPolygon) []*csgPolygon {
+	type cell [3]int64
+	cellOf := func(p Point) cell {
+		return cell{int64(math.Floor(p.X / csgEpsilon)), int64(math.Floor(p.Y / csgEpsilon)), int64(math.Floor(p.Z / csgEpsilon))}
+	}
+	grid := make(map[cell][]Point)
+	var points []Point
+	snap := func(p Point) Point {
+		c := cellOf(p)
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dz := int64(-1); dz <= 1; dz++ {
+					for _, q := range grid[cell{c[0] + dx, c[1] + dy, c[2] + dz}] {
+						if pointLength(subPoints(p, q)) < csgEpsilon {
+							return q
+						}
+					}
+				}
+			}
+		}
+		grid[c] = append(grid[c], p)
+		points = append(points, p)
+		return p
+	}
+
+	welded := make([]*csgPolygon, 0, len(polygons))
+	for _, polygon := range polygons {
+		vertices := make([]csgVertex, 0, len(polygon.Vertices))
+		for _, v := range polygon.Vertices {
+			v.Pos = snap(v.Pos)
+			if len(vertices) == 0 || vertices[len(vertices)-1].Pos != v.Pos {
+				vertices = append(vertices, v)
+			}
+		}
+		for len(vertices) > 1 && vertices[0].Pos == vertices[len(vertices)-1].Pos {
+			vertices = vertices[:len(vertices)-1]
+		}
+		if len(vertices) >= 3 {
+			polygon.Vertices = vertices
+			welded = append(welded, polygon)
+		}
+	}
+
+	// Points sorted by X, so each edge only checks those in its X range
+	sort.Slice(points, func(i, j int) bool { return points[i].X < points[j].X })
+	for _, polygon := range welded {
+		var vertices []csgVertex
+		for i, a := range polygon.Vertices {
+			b := polygon.Vertices[(i+1)%len(polygon.Vertices)]
+			vertices = append(vertices, a)
+
+			edge := subPoints(b.Pos, a.Pos)
+			lengthSq := dotPoints(edge, edge)
+			lo, hi := math.Min(a.Pos.X, b.Pos.X)-csgEpsilon, math.Max(a.Pos.X, b.Pos.X)+csgEpsilon
+			type onEdge struct {
+				t float64
+				p Point
+			}
+			var inserted []onEdge
+			for k := sort.Search(len(points), func(k int) bool { return points[k].X >= lo }); k < len(points) && points[k].X <= hi; k++ {
+				p := points[k]
+				if p == a.Pos || p == b.Pos {
+					continue
+				}
+				t := dotPoints(subPoints(p, a.Pos), edge) / lengthSq
+				if t <= 0 || t >= 1 {
+					continue
+				}
+				if pointLength(subPoints(p, addPoints(a.Pos, scalePoint(edge, t)))) < csgEpsilon {
+					inserted = append(inserted, onEdge{t: t, p: p})
+				}
+			}
+			sort.Slice(inserted, func(i, j int) bool { return inserted[i].t < inserted[j].t })
+			for _, in := range inserted {
+				v := a.lerp(b, in.t)
+				v.Pos = in.p
+				vertices = append(vertices, v)
+			}
+		}
+		polygon.Vertices = vertices
+	}
+	return welded
+}
+
+// csgFanApex returns a corner a polygon can be fanned from without zero-area
+// triangles, or -1 if collinear corners leave none
+func csgFanApex(p *csgPolygon) int {
+	n := len(p.Vertices)
+	for apex := 0; apex < n; apex++ {
+		ok := true
+		for k := 1; k+1 < n && ok; k++ {
+			a, b, c := p.Vertices[apex].Pos, p.Vertices[(apex+k)%n].Pos, p.Vertices[(apex+k+1)%n].Pos
+			ok = dotPoints(crossPoints(subPoints(b, a), subPoints(c, a)), p.Plane.Normal) > csgEpsilon*csgEpsilon
+		}
+		if ok {
+			return apex
+		}
+	}
+	return -1
+}
+
+// csgPolygonsToMesh triangulates polygons into an indexed mesh, sharing
+// vertices whose attributes all match
+func csgPolygonsToMesh(polygons []*csgPolygon, hasUVs, hasColors bool, fallback IMaterial) *Mesh {
+	mesh := NewMesh()
+	mesh.Material = fallback
+	mesh.Normals = make([]Point, 0)
+
+	type vertexKey struct {
+		Pos, Normal Point
+		UV          TextureCoord
+		Color       Color
+	}
+	shared := make(map[vertexKey]int)
+	vertexIndex := func(v csgVertex) int {
+		key := vertexKey{Pos: v.Pos, Normal: v.Normal, UV: v.UV, Color: v.Color}
+		if idx, ok := shared[key]; ok {
+			return idx
+		}
+		idx := len(mesh.Vertices)
+		shared[key] = idx
+		mesh.Vertices = append(mesh.Vertices, v.Pos)
+		mesh.Normals = append(mesh.Normals, normalizePoint(v.Normal))
+		if hasUVs {
+			mesh.UVs = append(mesh.UVs, v.UV)
+		}
+		if hasColors {
+			mesh.Colors = append(mesh.Colors, v.Color)
+		}
+		return idx
+	}
+
+	var faceMaterials []IMaterial
+	mixed := false
+	addTriangle := func(a, b, c int, material IMaterial) {
+		if a == b || b == c || a == c {
+			return
+		}
+		mesh.AddTriangleIndices(a, b, c)
+		faceMaterials = append(faceMaterials, material)
+		if material != faceMaterials[0] {
+			mixed = true
+		}
+	}
+	for _, p := range weldCSGPolygons(polygons) {
+		n := len(p.Vertices)
+		if apex := csgFanApex(p); apex >= 0 {
+			for k := 1; k+1 < n; k++ {
+				addTriangle(vertexIndex(p.Vertices[apex]), vertexIndex(p.Vertices[(apex+k)%n]), vertexIndex(p.Vertices[(apex+k+1)%n]), p.Material)
+			}
+			continue
+		}
+
+		// Every corner has collinear neighbours: fan from the centre instead
+		center := p.Vertices[0]
+		for k := 1; k < n; k++ {
+			center = center.lerp(p.Vertices[k], 1/float64(k+1))
+		}
+		c := vertexIndex(center)
+		for k := 0; k < n; k++ {
+			addTriangle(c, vertexIndex(p.Vertices[k]), vertexIndex(p.Vertices[(k+1)%n]), p.Material)
+		}
+	}
+
+	if len(faceMaterials) > 0 {
+		mesh.Material = faceMaterials[0]
+	}
+	if mixed {
+		mesh.FaceMaterials = faceMaterials
+	}
+	return mesh
+}
+
+// CSG combines two closed meshes. Both are read in their own local space
+// (plus Mesh.Position); use CSGNodes to combine scene nodes in world space.
+func CSG(op CSGOperation, a, b *Mesh) *Mesh {
+	nodeA := newCSGNode(meshToCSGPolygons(a))
+	nodeB := newCSGNode(meshToCSGPolygons(b))
+
+	switch op {
+	case CSGUnion:
+		nodeA.clipTo(nodeB)
+		nodeB.clipTo(nodeA)
+		nodeB.invert()
+		nodeB.clipTo(nodeA)
+		nodeB.invert()
+		nodeA.build(nodeB.allPolygons())
+
+	case CSGDifference:
+		nodeA.invert()
+		nodeA.clipTo(nodeB)
+		nodeB.clipTo(nodeA)
+		nodeB.invert()
+		nodeB.clipTo(nodeA)
+		nodeB.invert()
+		nodeA.build(nodeB.allPolygons())
+		nodeA.invert()
+
+	case CSGIntersection:
+		nodeA.invert()
+		nodeB.clipTo(nodeA)
+		nodeB.invert()
+		nodeA.clipTo(nodeB)
+		nodeB.clipTo(nodeA)
+		nodeA.build(nodeB.allPolygons())
+		nodeA.invert()
+	}
+
+	hasUVs := len(a.UVs) == len(a.Vertices) || len(b.UVs) == len(b.Vertices)
+	hasColors := a.HasColors() || b.HasColors()
+	return csgPolygonsToMesh(nodeA.allPolygons(), hasUVs, hasColors, a.Material)
+}
+
+// MeshUnion returns the space inside either mesh
+func MeshUnion(a, b *Mesh) *Mesh {
+	return CSG(CSGUnion, a, b)
+}
+
+// MeshDifference returns the space inside a but not inside b
+func MeshDifference(a, b *Mesh) *Mesh {
+	return CSG(CSGDifference, a, b)
+}
+
+// MeshIntersection returns the space inside both meshes
+func MeshIntersection(a, b *Mesh) *Mesh {
+	return CSG(CSGIntersection, a, b)
+}
+
+// CSGNodes combines the meshes of two scene nodes in world space. The result
+// is in world space, ready for a node with an identity transform.
+func CSGNodes(op CSGOperation, a, b *SceneNode) (*Mesh, error) {
+	meshA, err := csgWorldMesh(a)
+	if err != nil {
+		return nil, err
+	}
+	meshB, err := csgWorldMesh(b)
+	if err != nil {
+		return nil, err
+	}
+	return CSG(op, meshA, meshB), nil
+}
+
+// csgWorldMesh returns a world-space copy of a node's mesh
+func csgWorldMesh(node *SceneNode) (*Mesh, error) {
+	mesh, ok := node.Object.(*Mesh)
+	if !ok {
+		return nil, fmt.Errorf("node %q has no mesh", node.Name)
+	}
+	world := node.TransformSceneObject().(*Mesh)
+	// The renderers add the position offset after the transform
+	world.Position = mesh.Position
+	return world, nil
+}
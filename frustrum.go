@@ -191,6 +191,24 @@ func (f *ViewFrustum) TestOBB(obb *OBB) bool {
 	return true
 }
 
+// TestConvexHull tests if a convex hull intersects the frustum
+func (f *ViewFrustum) TestConvexHull(hull *ConvexHull) bool {
+	for i := 0; i < 6; i++ {
+		plane := f.Planes[i]
+		inside := false
+		for _, v := range hull.Vertices {
+			if dotProduct(plane.Normal.X, plane.Normal.Y, plane.Normal.Z, v.X, v.Y, v.Z)+plane.Distance >= 0 {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	return true
+}
+
 // FrustumCullNode recursively culls scene nodes against frustum
 func FrustumCullNode(node *SceneNode, frustum *ViewFrustum, visible *[]*SceneNode) {
 	if !node.IsEnabled() {
@@ -254,6 +272,9 @@ func ComputeNodeBounds(node *SceneNode) *AABB {
 			transformedPoints[i] = worldTransform.TransformPoint(p)
 		}
 		return NewAABBFromPoints(transformedPoints)
+
+	case Bounded:
+		return TransformAABB(obj.Bounds(), worldTransform)
 	}
 
 	return nil
@@ -73,6 +73,30 @@ type Triangle struct {
 	char         byte
 	UseSetNormal bool
 	HasUVs       bool
+
+	// Per-vertex normals for smooth shading (see Mesh.Normals)
+	N0               Point
+	N1               Point
+	N2               Point
+	HasVertexNormals bool
+
+	// Per-vertex tangents for normal mapping (see Mesh.Tangents)
+	T0          Tangent
+	T1          Tangent
+	T2          Tangent
+	HasTangents bool
+
+	// Per-vertex colors multiplied with the material diffuse (see Mesh.Colors)
+	C0              Color
+	C1              Color
+	C2              Color
+	HasVertexColors bool
+
+	// Baked lighting (see LightmapBaker); Lightmap is nil when not baked
+	LightmapUV0 TextureCoord
+	LightmapUV1 TextureCoord
+	LightmapUV2 TextureCoord
+	Lightmap    *Texture
 }
 
 // NewTriangle creates a new triangle
@@ -99,6 +123,33 @@ func (t *Triangle) SetUVs(uv0, uv1, uv2 TextureCoord) *Triangle {
 	return t
 }
 
+// SetVertexNormals sets per-vertex normals, interpolated across the face when shading
+func (t *Triangle) SetVertexNormals(n0, n1, n2 Point) *Triangle {
+	t.N0 = n0
+	t.N1 = n1
+	t.N2 = n2
+	t.HasVertexNormals = true
+	return t
+}
+
+// SetTangents sets per-vertex tangents used to apply tangent-space normal maps
+func (t *Triangle) SetTangents(t0, t1, t2 Tangent) *Triangle {
+	t.T0 = t0
+	t.T1 = t1
+	t.T2 = t2
+	t.HasTangents = true
+	return t
+}
+
+// SetVertexColors sets per-vertex colors, interpolated across the face
+func (t *Triangle) SetVertexColors(c0, c1, c2 Color) *Triangle {
+	t.C0 = c0
+	t.C1 = c1
+	t.C2 = c2
+	t.HasVertexColors = true
+	return t
+}
+
 // SetMaterial sets the material
 func (t *Triangle) SetMaterial(material IMaterial) *Triangle {
 	t.Material = material
@@ -160,6 +211,16 @@ func (t *Triangle) RotateLocal(axis byte, angle float64) {
 	if t.UseSetNormal && t.Normal != nil {
 		t.Normal.RotateFast(axis, c, s)
 	}
+	if t.HasVertexNormals {
+		t.N0.RotateFast(axis, c, s)
+		t.N1.RotateFast(axis, c, s)
+		t.N2.RotateFast(axis, c, s)
+	}
+	if t.HasTangents {
+		t.T0.RotateFast(axis, c, s)
+		t.T1.RotateFast(axis, c, s)
+		t.T2.RotateFast(axis, c, s)
+	}
 }
 
 // RotateGlobal rotates triangle around world origin
@@ -174,6 +235,16 @@ func (t *Triangle) RotateGlobal(axis byte, angle float64) {
 	if t.UseSetNormal && t.Normal != nil {
 		t.Normal.RotateFast(axis, c, s)
 	}
+	if t.HasVertexNormals {
+		t.N0.RotateFast(axis, c, s)
+		t.N1.RotateFast(axis, c, s)
+		t.N2.RotateFast(axis, c, s)
+	}
+	if t.HasTangents {
+		t.T0.RotateFast(axis, c, s)
+		t.T1.RotateFast(axis, c, s)
+		t.T2.RotateFast(axis, c, s)
+	}
 }
 
 // ============================================================================
@@ -362,9 +433,29 @@ func (c *Circle) RotateLocal(axis byte, angle float64) {
 type Mesh struct {
 	Vertices []Point
 	UVs      []TextureCoord // UV coordinates per vertex
+	Normals  []Point        // Normals per vertex (empty: flat shaded)
+	Tangents []Tangent      // Tangents per vertex (empty: no normal mapping)
+	Colors   []Color        // Colors per vertex (empty: material color only)
 	Indices  []int
 	Position Point
 	Material IMaterial // Added to store material for the whole mesh
+
+	// Material per triangle, overriding Material (empty: Material everywhere)
+	FaceMaterials []IMaterial
+
+	// Corner count of each source polygon, in order; polygon k is stored as
+	// FaceSizes[k]-2 consecutive fan triangles (empty: plain triangles)
+	FaceSizes []int
+
+	// Second UV set for baked lighting, one entry per index: lightmap charts
+	// never share corners, so they are stored per triangle corner
+	LightmapUVs []TextureCoord
+	Lightmap    *Texture
+
+	// Triangle clusters for culling, each a run of Indices (empty: the mesh
+	// is culled as a whole)
+	Meshlets        []Meshlet
+	meshletVertices int // Vertex count the meshlets were built for
 }
 
 // NewMesh creates a new mesh
@@ -379,6 +470,23 @@ func NewMesh() *Mesh {
 	}
 }
 
+// Clone returns a copy of the mesh that shares only its materials and
+// lightmap texture
+func (m *Mesh) Clone() *Mesh {
+	clone := *m
+	clone.Vertices = append([]Point(nil), m.Vertices...)
+	clone.UVs = append([]TextureCoord(nil), m.UVs...)
+	clone.Normals = append([]Point(nil), m.Normals...)
+	clone.Tangents = append([]Tangent(nil), m.Tangents...)
+	clone.Colors = append([]Color(nil), m.Colors...)
+	clone.Indices = append([]int(nil), m.Indices...)
+	clone.FaceMaterials = append([]IMaterial(nil), m.FaceMaterials...)
+	clone.FaceSizes = append([]int(nil), m.FaceSizes...)
+	clone.LightmapUVs = append([]TextureCoord(nil), m.LightmapUVs...)
+	clone.Meshlets = append([]Meshlet(nil), m.Meshlets...)
+	return &clone
+}
+
 // SetPosition sets the mesh position
 func (m *Mesh) SetPosition(x, y, z float64) {
 	m.Position = *NewPoint(x, y, z)
@@ -402,6 +510,13 @@ func (m *Mesh) AddVertexWithUV(x, y, z, u, v float64) int {
 	return len(m.Vertices) - 1
 }
 
+// AddVertexWithColor adds a vertex with a vertex color
+func (m *Mesh) AddVertexWithColor(x, y, z float64, color Color) int {
+	m.Vertices = append(m.Vertices, Point{X: x, Y: y, Z: z})
+	m.Colors = append(m.Colors, color)
+	return len(m.Vertices) - 1
+}
+
 // AddIndex adds a single index to the mesh
 func (m *Mesh) AddIndex(i int) {
 	m.Indices = append(m.Indices, i)
@@ -420,6 +535,46 @@ func (m *Mesh) AddQuadIndices(i1, i2, i3, i4 int) {
 	m.Indices = append(m.Indices, i1, i3, i4)
 }
 
+// Polygons returns the vertex loops of the source polygons described by
+// FaceSizes, or one loop per triangle when FaceSizes doesn't match the
+// triangles
+func (m *Mesh) Polygons() [][]int {
+	triCount := len(m.Indices) / 3
+	polygons := make([][]int, 0, len(m.FaceSizes))
+
+	tri := 0
+	for _, size := range m.FaceSizes {
+		if size < 3 || tri+size-2 > triCount {
+			polygons = nil
+			break
+		}
+		loop := []int{m.Indices[tri*3], m.Indices[tri*3+1], m.Indices[tri*3+2]}
+		for k := 1; k < size-2; k++ {
+			t := (tri + k) * 3
+			if m.Indices[t] != loop[0] || m.Indices[t+1] != loop[len(loop)-1] {
+				return m.trianglePolygons()
+			}
+			loop = append(loop, m.Indices[t+2])
+		}
+		polygons = append(polygons, loop)
+		tri += size - 2
+	}
+
+	if polygons == nil || tri != triCount {
+		return m.trianglePolygons()
+	}
+	return polygons
+}
+
+// trianglePolygons returns one loop per triangle
+func (m *Mesh) trianglePolygons() [][]int {
+	polygons := make([][]int, len(m.Indices)/3)
+	for t := range polygons {
+		polygons[t] = []int{m.Indices[t*3], m.Indices[t*3+1], m.Indices[t*3+2]}
+	}
+	return polygons
+}
+
 // RotateGlobal rotates all geometry around world origin
 func (m *Mesh) RotateGlobal(axis byte, angle float64) {
 	c := math.Cos(angle)
@@ -427,6 +582,12 @@ func (m *Mesh) RotateGlobal(axis byte, angle float64) {
 	for i := range m.Vertices {
 		m.Vertices[i].RotateFast(axis, c, s)
 	}
+	for i := range m.Normals {
+		m.Normals[i].RotateFast(axis, c, s)
+	}
+	for i := range m.Tangents {
+		m.Tangents[i].RotateFast(axis, c, s)
+	}
 }
 
 // RotateLocal rotates all geometry around local origin
@@ -437,6 +598,12 @@ func (m *Mesh) RotateLocal(axis byte, angle float64) {
 	for i := range m.Vertices {
 		m.Vertices[i].RotateFast(axis, c, s)
 	}
+	for i := range m.Normals {
+		m.Normals[i].RotateFast(axis, c, s)
+	}
+	for i := range m.Tangents {
+		m.Tangents[i].RotateFast(axis, c, s)
+	}
 }
 
 // ============================================================================
@@ -0,0 +1,740 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// GLTF EXPORTER
+// ============================================================================
+// Writes a scene as glTF 2.0, to .gltf (JSON plus a .bin file next to it) or
+// .glb (one file). Objects are written as they are currently drawn:
+//   - meshes, each material a primitive, with normals, UVs, tangents and
+//     vertex colors
+//   - LOD groups as their current level; terrain, voxel worlds and
+//     parametric surfaces as their visible chunks, one primitive per chunk
+//   - instanced meshes as a child node per instance sharing one mesh
+//     (instance colors are not kept)
+//   - materials in the metallic-roughness model: PBRMaterial maps are packed
+//     back into glTF's textures, Phong materials get a roughness matching
+//     their shininess
+//   - the scene camera and the given lights (KHR_lights_punctual)
+//
+// Disabled nodes and lights are left out, as are lines, points, circles and
+// loose triangles and quads. Images are PNG, stored in the binary buffer.
+// The inverse conventions of the loader are used, so exported files load back
+// as they were saved.
+// ============================================================================
+
+// Buffer view targets
+const (
+	gltfArrayBuffer        = 34962
+	gltfElementArrayBuffer = 34963
+)
+
+// gltfLinear is the linear sampler filter
+const gltfLinear = 9729
+
+// gltfWriter collects a document and its binary buffer while a scene is
+// exported
+type gltfWriter struct {
+	doc       gltfDocument
+	bin       []byte
+	meshes    map[any]int // By the object they were made from
+	materials map[IMaterial]int
+	images    map[*Texture]int
+	textures  map[[2]int]int // Image and sampler
+	samplers  map[gltfSampler]int
+	packed    map[[2]*Texture]*Texture // Metallic and roughness maps
+}
+
+// SaveGLTF writes a scene to a .gltf or .glb file, with its camera and the
+// given lights
+func SaveGLTF(scene *Scene, path string, lights ...*Light) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".gltf" && ext != ".glb" {
+		return fmt.Errorf("unsupported glTF extension %q", ext)
+	}
+
+	w := &gltfWriter{
+		doc:       gltfDocument{Asset: gltfAsset{Version: "2.0", Generator: "Go 3D Graphics Engine"}},
+		meshes:    make(map[any]int),
+		materials: make(map[IMaterial]int),
+		images:    make(map[*Texture]int),
+		textures:  make(map[[2]int]int),
+		samplers:  make(map[gltfSampler]int),
+		packed:    make(map[[2]*Texture]*Texture),
+	}
+
+	var roots []int
+	for _, child := range scene.Root.Children {
+		index, ok, err := w.node(child)
+		if err != nil {
+			return err
+		}
+		if ok {
+			roots = append(roots, index)
+		}
+	}
+	if scene.Camera != nil {
+		roots = append(roots, w.camera(scene.Camera))
+	}
+	for _, light := range lights {
+		if light != nil && light.IsEnabled {
+			roots = append(roots, w.light(light))
+		}
+	}
+
+	zero := 0
+	w.doc.Scene = &zero
+	w.doc.Scenes = []gltfScene{{Nodes: roots}}
+
+	if ext == ".glb" {
+		return w.writeGLB(path)
+	}
+	return w.writeGLTF(path)
+}
+
+// writeGLTF writes the JSON file and the buffer next to it
+func (w *gltfWriter) writeGLTF(path string) error {
+	if len(w.bin) > 0 {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".bin"
+		if err := os.WriteFile(filepath.Join(filepath.Dir(path), name), w.bin, 0644); err != nil {
+			return fmt.Errorf("cannot create file: %w", err)
+		}
+		w.doc.Buffers = []gltfBuffer{{URI: url.PathEscape(name), ByteLength: len(w.bin)}}
+	}
+
+	text, err := json.MarshalIndent(&w.doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, text, 0644); err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	return nil
+}
+
+// writeGLB writes the JSON and the buffer as the chunks of one file
+func (w *gltfWriter) writeGLB(path string) error {
+	if len(w.bin) > 0 {
+		w.doc.Buffers = []gltfBuffer{{ByteLength: len(w.bin)}}
+	}
+	text, err := json.Marshal(&w.doc)
+	if err != nil {
+		return err
+	}
+
+	// Chunks are 4-byte aligned: JSON with spaces, binary with zeros
+	for len(text)%4 != 0 {
+		text = append(text, ' ')
+	}
+	bin := w.bin
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	length := 12 + 8 + len(text)
+	if len(bin) > 0 {
+		length += 8 + len(bin)
+	}
+	out := make([]byte, 0, length)
+	out = binary.LittleEndian.AppendUint32(out, glbMagic)
+	out = binary.LittleEndian.AppendUint32(out, 2)
+	out = binary.LittleEndian.AppendUint32(out, uint32(length))
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(text)))
+	out = binary.LittleEndian.AppendUint32(out, glbChunkJSON)
+	out = append(out, text...)
+	if len(bin) > 0 {
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(bin)))
+		out = binary.LittleEndian.AppendUint32(out, glbChunkBIN)
+		out = append(out, bin...)
+	}
+
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// NODES
+// ============================================================================
+
+// node exports an enabled scene node and its subtree, returning its index
+func (w *gltfWriter) node(node *SceneNode) (int, bool, error) {
+	if !node.Enabled {
+		return 0, false, nil
+	}
+
+	index := len(w.doc.Nodes)
+	w.doc.Nodes = append(w.doc.Nodes, gltfNode{Name: node.Name})
+	dst := gltfNode{Name: node.Name}
+	setGLTFNodeTRS(&dst, node.Transform.Position, node.Transform.Rotation, node.Transform.Scale)
+
+	switch obj := node.Object.(type) {
+	case *InstancedMesh:
+		if obj.Enabled && obj.BaseMesh != nil {
+			mesh, ok, err := w.mesh(obj.BaseMesh, []*Mesh{obj.BaseMesh})
+			if err != nil {
+				return 0, false, fmt.Errorf("node %s: %w", node.Name, err)
+			}
+			for i, instance := range obj.Instances {
+				if !ok {
+					break // Nothing to draw
+				}
+				child := gltfNode{Name: fmt.Sprintf("%s.instance%d", node.Name, i), Mesh: &mesh}
+				pos, rot, scale := DecomposeMatrix(instance.Transform)
+				setGLTFNodeTRS(&child, pos, rot, scale)
+				dst.Children = append(dst.Children, len(w.doc.Nodes))
+				w.doc.Nodes = append(w.doc.Nodes, child)
+			}
+		}
+	default:
+		if meshes := exportMeshes(node.Object); len(meshes) > 0 {
+			mesh, ok, err := w.mesh(node.Object, meshes)
+			if err != nil {
+				return 0, false, fmt.Errorf("node %s: %w", node.Name, err)
+			}
+			if ok {
+				dst.Mesh = &mesh
+			}
+		}
+	}
+
+	for _, child := range node.Children {
+		childIndex, ok, err := w.node(child)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			dst.Children = append(dst.Children, childIndex)
+		}
+	}
+
+	w.doc.Nodes[index] = dst
+	return index, true, nil
+}
+
+// exportMeshes returns the meshes an object is currently drawn with
+func exportMeshes(object any) []*Mesh {
+	switch obj := object.(type) {
+	case *Mesh:
+		return []*Mesh{obj}
+	case *LODGroup:
+		if mesh := obj.GetCurrentMesh(); mesh != nil {
+			return []*Mesh{mesh}
+		}
+	case *LODGroupWithTransitions:
+		if mesh := obj.GetCurrentMesh(); mesh != nil {
+			return []*Mesh{mesh}
+		}
+	case MeshProvider:
+		return obj.VisibleMeshes()
+	}
+	return nil
+}
+
+// setGLTFNodeTRS sets a node's transform, leaving out identity parts
+func setGLTFNodeTRS(node *gltfNode, pos Point, rot Quaternion, scale Point) {
+	if pos != (Point{}) {
+		node.Translation = []float64{pos.X, pos.Y, pos.Z}
+	}
+	rot = rot.Normalize()
+	if rot.W < 0 {
+		rot = Quaternion{W: -rot.W, X: -rot.X, Y: -rot.Y, Z: -rot.Z}
+	}
+	if rot != (Quaternion{W: 1}) {
+		node.Rotation = []float64{rot.X, rot.Y, rot.Z, rot.W}
+	}
+	if scale != (Point{X: 1, Y: 1, Z: 1}) {
+		node.Scale = []float64{scale.X, scale.Y, scale.Z}
+	}
+}
+
+// camera exports a camera as a root node. Ours look down +Z and glTF's down
+// -Z, so the camera is turned around Y as on import.
+func (w *gltfWriter) camera(camera *Camera) int {
+	fovX, fovY := camera.FOV.X*DegToRad, camera.FOV.Y*DegToRad
+	perspective := &gltfPerspective{
+		YFov:  fovY,
+		ZNear: camera.Near,
+		ZFar:  camera.Far,
+	}
+	if fovX > 0 && fovY > 0 {
+		perspective.AspectRatio = math.Tan(fovX/2) / math.Tan(fovY/2)
+	}
+	w.doc.Cameras = append(w.doc.Cameras, gltfCamera{Type: "perspective", Perspective: perspective})
+
+	index := len(w.doc.Cameras) - 1
+	node := gltfNode{Name: "Camera", Camera: &index}
+	pos, rot, _ := DecomposeMatrix(camera.Transform.GetWorldMatrix())
+	setGLTFNodeTRS(&node, pos, rot.Multiply(QuaternionFromAxisAngle(Point{Y: 1}, math.Pi)), Point{X: 1, Y: 1, Z: 1})
+	w.doc.Nodes = append(w.doc.Nodes, node)
+	return len(w.doc.Nodes) - 1
+}
+
+// light exports a light as a root node. Directional lights shine from their
+// position towards the origin; the node is placed gltfDirectionalDistance
+// along that direction so the loader puts the light back where it was.
+func (w *gltfWriter) light(light *Light) int {
+	ext := w.doc.Extensions
+	if ext == nil || ext.LightsPunctual == nil {
+		w.doc.Extensions = &gltfRootExtended{LightsPunctual: &struct {
+			Lights []gltfLight `json:"lights"`
+		}{}}
+		w.doc.ExtensionsUsed = append(w.doc.ExtensionsUsed, "KHR_lights_punctual")
+	}
+	lights := &w.doc.Extensions.LightsPunctual.Lights
+
+	intensity := light.Intensity
+	def := gltfLight{
+		Color:     []float64{float64(light.Color.R) / 255, float64(light.Color.G) / 255, float64(light.Color.B) / 255},
+		Intensity: &intensity,
+	}
+	pos, rot := light.Position, Quaternion{W: 1}
+	if light.Type == LightTypePoint {
+		def.Type = "point"
+		def.Range = light.Range
+	} else {
+		def.Type = "directional"
+		back := normalizePoint(light.Position)
+		if pointLength(back) == 0 {
+			back = Point{Y: 1}
+		}
+		pos = subPoints(pos, scalePoint(back, gltfDirectionalDistance))
+		rot = quaternionFromTo(Point{Z: 1}, back)
+	}
+	*lights = append(*lights, def)
+
+	node := gltfNode{Name: fmt.Sprintf("Light%d", len(*lights)-1)}
+	node.Extensions = &gltfNodeLink{LightsPunctual: &struct {
+		Light int `json:"light"`
+	}{Light: len(*lights) - 1}}
+	setGLTFNodeTRS(&node, pos, rot, Point{X: 1, Y: 1, Z: 1})
+	w.doc.Nodes = append(w.doc.Nodes, node)
+	return len(w.doc.Nodes) - 1
+}
+
+// quaternionFromTo returns the shortest rotation turning unit vector from
+// onto unit vector to
+func quaternionFromTo(from, to Point) Quaternion {
+	d := dotPoints(from, to)
+	if d < -1+1e-9 {
+		// Opposite: half a turn around any perpendicular axis
+		axis := crossPoints(Point{X: 1}, from)
+		if pointLength(axis) < 1e-6 {
+			axis = crossPoints(Point{Y: 1}, from)
+		}
+		return QuaternionFromAxisAngle(normalizePoint(axis), math.Pi)
+	}
+	c := crossPoints(from, to)
+	return Quaternion{W: 1 + d, X: c.X, Y: c.Y, Z: c.Z}.Normalize()
+}
+
+// ============================================================================
+// MESHES
+// ============================================================================
+
+// mesh exports the meshes an object is drawn with as one glTF mesh, once
+// per object. Meshes without triangles are skipped (ok false: none left).
+func (w *gltfWriter) mesh(object any, meshes []*Mesh) (int, bool, error) {
+	if index, ok := w.meshes[object]; ok {
+		return index, true, nil
+	}
+
+	var dst gltfMesh
+	for _, mesh := range meshes {
+		prims, err := w.primitives(mesh)
+		if err != nil {
+			return 0, false, err
+		}
+		dst.Primitives = append(dst.Primitives, prims...)
+	}
+	if len(dst.Primitives) == 0 {
+		return 0, false, nil
+	}
+
+	w.doc.Meshes = append(w.doc.Meshes, dst)
+	index := len(w.doc.Meshes) - 1
+	w.meshes[object] = index
+	return index, true, nil
+}
+
+// primitives splits a mesh into one primitive per material, each with only
+// the vertices its triangles use (in their original order)
+func (w *gltfWriter) primitives(mesh *Mesh) ([]gltfPrimitive, error) {
+	var order []IMaterial
+	groups := make(map[IMaterial][]int)
+	for t := 0; t+2 < len(mesh.Indices); t += 3 {
+		material := mesh.MaterialForFace(t / 3)
+		if _, ok := groups[material]; !ok {
+			order = append(order, material)
+		}
+		groups[material] = append(groups[material], t)
+	}
+
+	hasNormals := mesh.HasNormals()
+	hasUVs := mesh.HasUVs()
+	hasTangents := mesh.HasTangents() && hasNormals
+	hasColors := mesh.HasColors()
+
+	var prims []gltfPrimitive
+	for _, material := range order {
+		used := make(map[int]int)
+		for _, t := range groups[material] {
+			for k := 0; k < 3; k++ {
+				used[mesh.Indices[t+k]] = 0
+			}
+		}
+		vertices := make([]int, 0, len(used))
+		for v := range used {
+			vertices = append(vertices, v)
+		}
+		sort.Ints(vertices)
+		for i, v := range vertices {
+			used[v] = i
+		}
+
+		positions := make([]float64, 0, len(vertices)*3)
+		var normals, uvs, tangents, colors []float64
+		for _, v := range vertices {
+			p := addPoints(mesh.Vertices[v], mesh.Position)
+			positions = append(positions, p.X, p.Y, p.Z)
+			if hasNormals {
+				n := normalizePoint(mesh.Normals[v])
+				normals = append(normals, n.X, n.Y, n.Z)
+			}
+			if hasUVs {
+				uvs = append(uvs, mesh.UVs[v].U, mesh.UVs[v].V)
+			}
+			if hasTangents {
+				t := mesh.Tangents[v]
+				dir := normalizePoint(Point{X: t.X, Y: t.Y, Z: t.Z})
+				sign := 1.0
+				if t.W < 0 {
+					sign = -1
+				}
+				tangents = append(tangents, dir.X, dir.Y, dir.Z, sign)
+			}
+			if hasColors {
+				c := mesh.Colors[v]
+				colors = append(colors, float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+			}
+		}
+
+		indices := make([]int, 0, len(groups[material])*3)
+		for _, t := range groups[material] {
+			for k := 0; k < 3; k++ {
+				indices = append(indices, used[mesh.Indices[t+k]])
+			}
+		}
+
+		prim := gltfPrimitive{Attributes: map[string]int{
+			"POSITION": w.floatAccessor(positions, 3, true),
+		}}
+		if hasNormals {
+			prim.Attributes["NORMAL"] = w.floatAccessor(normals, 3, false)
+		}
+		if hasUVs {
+			prim.Attributes["TEXCOORD_0"] = w.floatAccessor(uvs, 2, false)
+		}
+		if hasTangents {
+			prim.Attributes["TANGENT"] = w.floatAccessor(tangents, 4, false)
+		}
+		if hasColors {
+			prim.Attributes["COLOR_0"] = w.floatAccessor(colors, 3, false)
+		}
+		indexAccessor := w.indexAccessor(indices, len(vertices))
+		prim.Indices = &indexAccessor
+
+		if material != nil {
+			index, err := w.material(material)
+			if err != nil {
+				return nil, err
+			}
+			prim.Material = &index
+		}
+		prims = append(prims, prim)
+	}
+	return prims, nil
+}
+
+// view appends data to the buffer as a buffer view, 4-byte aligned
+func (w *gltfWriter) view(data []byte, target int) int {
+	for len(w.bin)%4 != 0 {
+		w.bin = append(w.bin, 0)
+	}
+	w.doc.BufferViews = append(w.doc.BufferViews, gltfBufferView{
+		ByteOffset: len(w.bin),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	w.bin = append(w.bin, data...)
+	return len(w.doc.BufferViews) - 1
+}
+
+// floatAccessor writes a float vertex attribute; positions need bounds
+func (w *gltfWriter) floatAccessor(values []float64, components int, bounds bool) int {
+	data := make([]byte, 0, len(values)*4)
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(float32(v)))
+	}
+	view := w.view(data, gltfArrayBuffer)
+	accessor := gltfAccessor{
+		BufferView:    &view,
+		ComponentType: gltfFloat,
+		Count:         len(values) / components,
+		Type:          [...]string{"", "SCALAR", "VEC2", "VEC3", "VEC4"}[components],
+	}
+
+	if bounds && len(values) > 0 {
+		accessor.Min = make([]float64, components)
+		accessor.Max = make([]float64, components)
+		for c := 0; c < components; c++ {
+			accessor.Min[c], accessor.Max[c] = math.Inf(1), math.Inf(-1)
+		}
+		for i, v := range values {
+			// Bounds of the stored float32 values, as validators check them
+			v = float64(float32(v))
+			accessor.Min[i%components] = math.Min(accessor.Min[i%components], v)
+			accessor.Max[i%components] = math.Max(accessor.Max[i%components], v)
+		}
+	}
+
+	w.doc.Accessors = append(w.doc.Accessors, accessor)
+	return len(w.doc.Accessors) - 1
+}
+
+// indexAccessor writes triangle indices, as 16-bit values when they fit
+func (w *gltfWriter) indexAccessor(indices []int, vertexCount int) int {
+	componentType := gltfUnsignedShort
+	if vertexCount > math.MaxUint16 {
+		componentType = gltfUnsignedInt
+	}
+	data := make([]byte, 0, len(indices)*gltfComponentSize(componentType))
+	for _, i := range indices {
+		if componentType == gltfUnsignedShort {
+			data = binary.LittleEndian.AppendUint16(data, uint16(i))
+		} else {
+			data = binary.LittleEndian.AppendUint32(data, uint32(i))
+		}
+	}
+
+	view := w.view(data, gltfElementArrayBuffer)
+	w.doc.Accessors = append(w.doc.Accessors, gltfAccessor{
+		BufferView:    &view,
+		ComponentType: componentType,
+		Count:         len(indices),
+		Type:          "SCALAR",
+	})
+	return len(w.doc.Accessors) - 1
+}
+
+// ============================================================================
+// MATERIALS
+// ============================================================================
+
+// material exports a material once, converted to metallic-roughness
+func (w *gltfWriter) material(material IMaterial) (int, error) {
+	if index, ok := w.materials[material]; ok {
+		return index, nil
+	}
+
+	var dst gltfMaterial
+	var err error
+	switch m := material.(type) {
+	case *PBRMaterial:
+		dst, err = w.pbrMaterial(m)
+	case *Material:
+		dst = gltfMaterial{PBRMetallicRoughness: gltfFactors(m.DiffuseColor, 0, shininessRoughness(m.Shininess))}
+	case *TexturedMaterialExt:
+		dst = gltfMaterial{PBRMetallicRoughness: gltfFactors(m.DiffuseColor, 0, shininessRoughness(m.Shininess))}
+		if m.UseTextures && m.DiffuseTexture != nil {
+			dst.PBRMetallicRoughness.BaseColorFactor = nil
+			if dst.PBRMetallicRoughness.BaseColorTexture, err = w.texture(m.DiffuseTexture, m.TextureFilter, m.TextureWrap); err != nil {
+				return 0, err
+			}
+		}
+		if m.UseTextures && m.NormalMap != nil {
+			if dst.NormalTexture, err = w.texture(m.NormalMap, m.TextureFilter, m.TextureWrap); err != nil {
+				return 0, err
+			}
+		}
+	default:
+		dst = gltfMaterial{PBRMetallicRoughness: gltfFactors(material.GetDiffuseColor(0, 0), material.GetMetallic(), material.GetRoughness())}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	w.doc.Materials = append(w.doc.Materials, dst)
+	index := len(w.doc.Materials) - 1
+	w.materials[material] = index
+	return index, nil
+}
+
+// pbrMaterial converts a PBRMaterial. Maps replace the scalar values they
+// stand for, so the matching factors are 1; metallic and roughness maps are
+// packed into glTF's one texture (blue and green).
+func (w *gltfWriter) pbrMaterial(m *PBRMaterial) (gltfMaterial, error) {
+	dst := gltfMaterial{PBRMetallicRoughness: gltfFactors(m.Albedo, m.Metallic, m.Roughness)}
+	pbr := dst.PBRMetallicRoughness
+	if m.Emissive != ColorBlack {
+		dst.EmissiveFactor = []float64{float64(m.Emissive.R) / 255, float64(m.Emissive.G) / 255, float64(m.Emissive.B) / 255}
+	}
+
+	var err error
+	if m.UseTextures && m.AlbedoMap != nil {
+		pbr.BaseColorFactor = nil
+		if pbr.BaseColorTexture, err = w.texture(m.AlbedoMap, m.TextureFilter, m.TextureWrap); err != nil {
+			return dst, err
+		}
+	}
+	if m.UseTextures && (m.MetallicMap != nil || m.RoughnessMap != nil) {
+		pbr.MetallicFactor, pbr.RoughnessFactor = nil, nil
+		packed := w.packMetallicRoughness(m)
+		if pbr.MetallicRoughnessTexture, err = w.texture(packed, m.TextureFilter, m.TextureWrap); err != nil {
+			return dst, err
+		}
+	}
+	if m.UseTextures && m.NormalMap != nil {
+		if dst.NormalTexture, err = w.texture(m.NormalMap, m.TextureFilter, m.TextureWrap); err != nil {
+			return dst, err
+		}
+	}
+
+	// glTF has no ambient occlusion factor; a constant one becomes a 1x1 map
+	occlusion := m.AOMap
+	if !m.UseTextures || occlusion == nil {
+		occlusion = nil
+		if m.AO < 1 {
+			occlusion = NewTexture(1, 1)
+			occlusion.Data[0] = grayColor(m.AO)
+		}
+	}
+	if occlusion != nil {
+		if dst.OcclusionTexture, err = w.texture(occlusion, m.TextureFilter, m.TextureWrap); err != nil {
+			return dst, err
+		}
+	}
+
+	if m.UseTextures && m.EmissiveMap != nil && m.Emissive != ColorBlack {
+		if dst.EmissiveTexture, err = w.texture(m.EmissiveMap, m.TextureFilter, m.TextureWrap); err != nil {
+			return dst, err
+		}
+	}
+	return dst, nil
+}
+
+// gltfFactors returns the scalar part of a metallic-roughness material,
+// leaving out glTF's defaults
+func gltfFactors(base Color, metallic, roughness float64) *gltfPBR {
+	pbr := &gltfPBR{}
+	if base != ColorWhite {
+		pbr.BaseColorFactor = []float64{float64(base.R) / 255, float64(base.G) / 255, float64(base.B) / 255, 1}
+	}
+	if metallic != 1 {
+		pbr.MetallicFactor = &metallic
+	}
+	if roughness != 1 {
+		pbr.RoughnessFactor = &roughness
+	}
+	return pbr
+}
+
+// shininessRoughness maps a Blinn-Phong exponent to the GGX roughness with
+// about the same highlight size
+func shininessRoughness(shininess float64) float64 {
+	return clampFloat(math.Sqrt(2/(math.Max(shininess, 0)+2)), 0, 1)
+}
+
+// packMetallicRoughness builds glTF's combined texture from a material's
+// maps, at the larger map's size; a missing map is filled with its scalar
+func (w *gltfWriter) packMetallicRoughness(m *PBRMaterial) *Texture {
+	key := [2]*Texture{m.MetallicMap, m.RoughnessMap}
+	if tex, ok := w.packed[key]; ok {
+		return tex
+	}
+
+	width, height := 1, 1
+	for _, tex := range key {
+		if tex != nil && tex.Width*tex.Height > width*height {
+			width, height = tex.Width, tex.Height
+		}
+	}
+	packed := NewTexture(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			u, v := (float64(x)+0.5)/float64(width), (float64(y)+0.5)/float64(height)
+			packed.Data[y*width+x] = Color{
+				R: 255,
+				G: grayColor(m.SampleRoughness(u, v)).G,
+				B: grayColor(m.SampleMetallic(u, v)).B,
+			}
+		}
+	}
+	w.packed[key] = packed
+	return packed
+}
+
+// texture exports a texture with a sampler for the filter and wrap
+func (w *gltfWriter) texture(tex *Texture, filter TextureFilter, wrap TextureWrap) (*gltfTextureReference, error) {
+	imageIndex, err := w.image(tex)
+	if err != nil {
+		return nil, err
+	}
+
+	sampler := gltfSampler{MagFilter: gltfLinear, MinFilter: gltfLinear, WrapS: gltfRepeat, WrapT: gltfRepeat}
+	if filter == FilterNearest {
+		sampler.MagFilter, sampler.MinFilter = gltfNearest, gltfNearest
+	}
+	switch wrap {
+	case WrapClamp:
+		sampler.WrapS, sampler.WrapT = gltfClampToEdge, gltfClampToEdge
+	case WrapMirror:
+		sampler.WrapS, sampler.WrapT = gltfMirroredRepeat, gltfMirroredRepeat
+	}
+	samplerIndex, ok := w.samplers[sampler]
+	if !ok {
+		w.doc.Samplers = append(w.doc.Samplers, sampler)
+		samplerIndex = len(w.doc.Samplers) - 1
+		w.samplers[sampler] = samplerIndex
+	}
+
+	key := [2]int{imageIndex, samplerIndex}
+	index, ok := w.textures[key]
+	if !ok {
+		w.doc.Textures = append(w.doc.Textures, gltfTexture{Sampler: &samplerIndex, Source: &imageIndex})
+		index = len(w.doc.Textures) - 1
+		w.textures[key] = index
+	}
+	return &gltfTextureReference{Index: index}, nil
+}
+
+// image stores a texture once as a PNG in the buffer
+func (w *gltfWriter) image(tex *Texture) (int, error) {
+	if index, ok := w.images[tex]; ok {
+		return index, nil
+	}
+	var data bytes.Buffer
+	if err := png.Encode(&data, tex.ToImage()); err != nil {
+		return 0, fmt.Errorf("cannot encode image: %w", err)
+	}
+
+	view := w.view(data.Bytes(), 0)
+	w.doc.Images = append(w.doc.Images, gltfImage{MimeType: "image/png", BufferView: &view})
+	index := len(w.doc.Images) - 1
+	w.images[tex] = index
+	return index, nil
+}
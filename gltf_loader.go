@@ -0,0 +1,1266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ============================================================================
+// GLTF LOADER
+// ============================================================================
+// glTF 2.0 scenes, as .gltf (JSON with external or data: URI buffers and
+// images) or .glb (JSON plus one binary chunk). What is imported:
+//   - the default scene's node hierarchy as SceneNodes, translation, rotation
+//     and scale (or a matrix) going into each node's Transform
+//   - meshes with normals, UVs (TEXCOORD_0), tangents and vertex colors; a
+//     mesh's primitives become one Mesh with a material per face, and meshes
+//     used by several nodes are shared
+//   - metallic-roughness materials as PBRMaterials with every texture map.
+//     glTF packs metallic (B) and roughness (G) in one texture and scales
+//     maps by factors; both are baked into the separate single-channel maps
+//     PBRMaterial samples
+//   - perspective cameras and KHR_lights_punctual lights, placed where their
+//     nodes are in the scene
+//
+// Skins, morph targets and animations are not imported. glTF cameras look
+// down -Z and ours down +Z, so cameras are turned around Y; positions and UVs
+// are used as they are, as for OBJ files.
+// ============================================================================
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\0"
+)
+
+// gltfDirectionalDistance is how far behind its node a directional light is
+// placed: our lights have a position, glTF's directional lights only a
+// direction
+const gltfDirectionalDistance = 100.0
+
+// Accessor component types
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+)
+
+// Primitive modes that produce triangles
+const (
+	gltfTriangles     = 4
+	gltfTriangleStrip = 5
+	gltfTriangleFan   = 6
+)
+
+// Sampler filters and wraps
+const (
+	gltfNearest        = 9728
+	gltfClampToEdge    = 33071
+	gltfMirroredRepeat = 33648
+	gltfRepeat         = 10497
+)
+
+// gltfSupportedExtensions can appear in extensionsRequired
+var gltfSupportedExtensions = map[string]bool{
+	"KHR_lights_punctual": true,
+}
+
+// gltfDocument is the JSON part of a glTF file (only what we read or write)
+type gltfDocument struct {
+	Asset              gltfAsset         `json:"asset"`
+	ExtensionsUsed     []string          `json:"extensionsUsed,omitempty"`
+	ExtensionsRequired []string          `json:"extensionsRequired,omitempty"`
+	Scene              *int              `json:"scene,omitempty"`
+	Scenes             []gltfScene       `json:"scenes,omitempty"`
+	Nodes              []gltfNode        `json:"nodes,omitempty"`
+	Meshes             []gltfMesh        `json:"meshes,omitempty"`
+	Materials          []gltfMaterial    `json:"materials,omitempty"`
+	Textures           []gltfTexture     `json:"textures,omitempty"`
+	Images             []gltfImage       `json:"images,omitempty"`
+	Samplers           []gltfSampler     `json:"samplers,omitempty"`
+	Accessors          []gltfAccessor    `json:"accessors,omitempty"`
+	BufferViews        []gltfBufferView  `json:"bufferViews,omitempty"`
+	Buffers            []gltfBuffer      `json:"buffers,omitempty"`
+	Cameras            []gltfCamera      `json:"cameras,omitempty"`
+	Extensions         *gltfRootExtended `json:"extensions,omitempty"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type gltfRootExtended struct {
+	LightsPunctual *struct {
+		Lights []gltfLight `json:"lights"`
+	} `json:"KHR_lights_punctual,omitempty"`
+}
+
+type gltfScene struct {
+	Name  string `json:"name,omitempty"`
+	Nodes []int  `json:"nodes,omitempty"`
+}
+
+type gltfNode struct {
+	Name        string        `json:"name,omitempty"`
+	Children    []int         `json:"children,omitempty"`
+	Mesh        *int          `json:"mesh,omitempty"`
+	Camera      *int          `json:"camera,omitempty"`
+	Matrix      []float64     `json:"matrix,omitempty"` // Column-major
+	Translation []float64     `json:"translation,omitempty"`
+	Rotation    []float64     `json:"rotation,omitempty"` // x, y, z, w
+	Scale       []float64     `json:"scale,omitempty"`
+	Extensions  *gltfNodeLink `json:"extensions,omitempty"`
+}
+
+type gltfNodeLink struct {
+	LightsPunctual *struct {
+		Light int `json:"light"`
+	} `json:"KHR_lights_punctual,omitempty"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Material   *int           `json:"material,omitempty"`
+	Mode       *int           `json:"mode,omitempty"`
+}
+
+type gltfMaterial struct {
+	Name                 string                `json:"name,omitempty"`
+	PBRMetallicRoughness *gltfPBR              `json:"pbrMetallicRoughness,omitempty"`
+	NormalTexture        *gltfTextureReference `json:"normalTexture,omitempty"`
+	OcclusionTexture     *gltfTextureReference `json:"occlusionTexture,omitempty"`
+	EmissiveTexture      *gltfTextureReference `json:"emissiveTexture,omitempty"`
+	EmissiveFactor       []float64             `json:"emissiveFactor,omitempty"`
+}
+
+type gltfPBR struct {
+	BaseColorFactor          []float64             `json:"baseColorFactor,omitempty"`
+	BaseColorTexture         *gltfTextureReference `json:"baseColorTexture,omitempty"`
+	MetallicFactor           *float64              `json:"metallicFactor,omitempty"`
+	RoughnessFactor          *float64              `json:"roughnessFactor,omitempty"`
+	MetallicRoughnessTexture *gltfTextureReference `json:"metallicRoughnessTexture,omitempty"`
+}
+
+// gltfTextureReference is a textureInfo; Scale is the normal texture's scale
+// and Strength the occlusion texture's
+type gltfTextureReference struct {
+	Index    int      `json:"index"`
+	TexCoord int      `json:"texCoord,omitempty"`
+	Scale    *float64 `json:"scale,omitempty"`
+	Strength *float64 `json:"strength,omitempty"`
+}
+
+type gltfTexture struct {
+	Sampler *int `json:"sampler,omitempty"`
+	Source  *int `json:"source,omitempty"`
+}
+
+type gltfImage struct {
+	Name       string `json:"name,omitempty"`
+	URI        string `json:"uri,omitempty"`
+	MimeType   string `json:"mimeType,omitempty"`
+	BufferView *int   `json:"bufferView,omitempty"`
+}
+
+type gltfSampler struct {
+	MagFilter int `json:"magFilter,omitempty"`
+	MinFilter int `json:"minFilter,omitempty"`
+	WrapS     int `json:"wrapS,omitempty"`
+	WrapT     int `json:"wrapT,omitempty"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int        `json:"bufferView,omitempty"`
+	ByteOffset    int         `json:"byteOffset,omitempty"`
+	ComponentType int         `json:"componentType"`
+	Normalized    bool        `json:"normalized,omitempty"`
+	Count         int         `json:"count"`
+	Type          string      `json:"type"`
+	Min           []float64   `json:"min,omitempty"`
+	Max           []float64   `json:"max,omitempty"`
+	Sparse        *gltfSparse `json:"sparse,omitempty"`
+}
+
+type gltfSparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset,omitempty"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset,omitempty"`
+	} `json:"values"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset,omitempty"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride,omitempty"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri,omitempty"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfCamera struct {
+	Name        string           `json:"name,omitempty"`
+	Type        string           `json:"type"`
+	Perspective *gltfPerspective `json:"perspective,omitempty"`
+}
+
+type gltfPerspective struct {
+	AspectRatio float64 `json:"aspectRatio,omitempty"`
+	YFov        float64 `json:"yfov"`
+	ZNear       float64 `json:"znear"`
+	ZFar        float64 `json:"zfar,omitempty"`
+}
+
+type gltfLight struct {
+	Name      string    `json:"name,omitempty"`
+	Type      string    `json:"type"`
+	Color     []float64 `json:"color,omitempty"`
+	Intensity *float64  `json:"intensity,omitempty"`
+	Range     float64   `json:"range,omitempty"`
+}
+
+// GLTFScene is what LoadGLTF imports
+type GLTFScene struct {
+	Root      *SceneNode     // Parent of the scene's root nodes, named after the file
+	Nodes     []*SceneNode   // By glTF node index (nil: not in the scene)
+	Meshes    []*Mesh        // By glTF mesh index (nil: not used by the scene)
+	Materials []*PBRMaterial // By glTF material index
+	Cameras   []*Camera      // One per camera node, in node order
+	Lights    []*Light       // One per light node, in node order
+}
+
+// gltfReader holds a parsed file while it is imported
+type gltfReader struct {
+	doc       gltfDocument
+	dir       string
+	buffers   [][]byte
+	images    map[int]*Texture
+	materials map[int]*PBRMaterial
+	meshes    map[int]*Mesh
+}
+
+// LoadGLTF imports a .gltf or .glb file
+func LoadGLTF(path string) (*GLTFScene, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file: %w", err)
+	}
+
+	r := &gltfReader{
+		dir:       filepath.Dir(path),
+		images:    make(map[int]*Texture),
+		materials: make(map[int]*PBRMaterial),
+		meshes:    make(map[int]*Mesh),
+	}
+
+	jsonChunk, binChunk := data, []byte(nil)
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		jsonChunk, binChunk, err = splitGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(jsonChunk, &r.doc); err != nil {
+		return nil, fmt.Errorf("invalid glTF JSON: %w", err)
+	}
+	if !strings.HasPrefix(r.doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("unsupported glTF version %q", r.doc.Asset.Version)
+	}
+	for _, ext := range r.doc.ExtensionsRequired {
+		if !gltfSupportedExtensions[ext] {
+			return nil, fmt.Errorf("unsupported required extension %s", ext)
+		}
+	}
+	if err := r.loadBuffers(binChunk); err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return r.importScene(name)
+}
+
+// splitGLB returns the JSON and binary chunks of a .glb file
+func splitGLB(data []byte) ([]byte, []byte, error) {
+	if len(data) < 12 {
+		return nil, nil, fmt.Errorf("truncated GLB header")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported GLB version %d", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, fmt.Errorf("GLB is %d bytes, header says %d", len(data), length)
+	}
+
+	var jsonChunk, binChunk []byte
+	for offset := 12; offset+8 <= length; {
+		size := int(binary.LittleEndian.Uint32(data[offset:]))
+		kind := binary.LittleEndian.Uint32(data[offset+4:])
+		start := offset + 8
+		if size < 0 || start+size > length {
+			return nil, nil, fmt.Errorf("GLB chunk at byte %d overruns the file", offset)
+		}
+		switch {
+		case kind == glbChunkJSON && jsonChunk == nil:
+			jsonChunk = data[start : start+size]
+		case kind == glbChunkBIN && binChunk == nil:
+			binChunk = data[start : start+size]
+		}
+		offset = start + size
+	}
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("GLB has no JSON chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+// loadBuffers reads every buffer: data URIs, files next to the glTF, or the
+// GLB binary chunk for a buffer without a URI
+func (r *gltfReader) loadBuffers(binChunk []byte) error {
+	r.buffers = make([][]byte, len(r.doc.Buffers))
+	for i, buffer := range r.doc.Buffers {
+		var data []byte
+		if buffer.URI == "" {
+			if i != 0 || binChunk == nil {
+				return fmt.Errorf("buffer %d has no data", i)
+			}
+			data = binChunk
+		} else {
+			var err error
+			if data, err = r.readURI(buffer.URI); err != nil {
+				return fmt.Errorf("buffer %d: %w", i, err)
+			}
+		}
+		if len(data) < buffer.ByteLength {
+			return fmt.Errorf("buffer %d has %d bytes, expected %d", i, len(data), buffer.ByteLength)
+		}
+		r.buffers[i] = data[:buffer.ByteLength]
+	}
+	return nil
+}
+
+// readURI returns the bytes of a data URI or of a file relative to the glTF
+func (r *gltfReader) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("unsupported data URI")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI %q: %w", uri, err)
+	}
+	return os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(name)))
+}
+
+// bufferView returns the bytes of a buffer view
+func (r *gltfReader) bufferView(index int) ([]byte, *gltfBufferView, error) {
+	if index < 0 || index >= len(r.doc.BufferViews) {
+		return nil, nil, fmt.Errorf("buffer view %d does not exist", index)
+	}
+	view := &r.doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(r.buffers) {
+		return nil, nil, fmt.Errorf("buffer view %d uses missing buffer %d", index, view.Buffer)
+	}
+	buffer := r.buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset+view.ByteLength > len(buffer) {
+		return nil, nil, fmt.Errorf("buffer view %d overruns buffer %d", index, view.Buffer)
+	}
+	return buffer[view.ByteOffset : view.ByteOffset+view.ByteLength], view, nil
+}
+
+// ============================================================================
+// ACCESSORS
+// ============================================================================
+
+// gltfComponents is the number of components of each accessor type
+var gltfComponents = map[string]int{
+	"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT2": 4, "MAT3": 9, "MAT4": 16,
+}
+
+// gltfComponentSize returns the byte size of a component type
+func gltfComponentSize(componentType int) int {
+	switch componentType {
+	case gltfByte, gltfUnsignedByte:
+		return 1
+	case gltfShort, gltfUnsignedShort:
+		return 2
+	case gltfUnsignedInt, gltfFloat:
+		return 4
+	}
+	return 0
+}
+
+// readComponent decodes one component, mapping normalized integers to
+// [0, 1] or [-1, 1]
+func readComponent(data []byte, componentType int, normalized bool) float64 {
+	switch componentType {
+	case gltfByte:
+		v := float64(int8(data[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case gltfUnsignedByte:
+		if normalized {
+			return float64(data[0]) / 255
+		}
+		return float64(data[0])
+	case gltfShort:
+		v := float64(int16(binary.LittleEndian.Uint16(data)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case gltfUnsignedShort:
+		v := float64(binary.LittleEndian.Uint16(data))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case gltfUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(data))
+	default:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	}
+}
+
+// accessor returns an accessor's elements flattened, and how many
+// components each has. Sparse values replace the dense ones.
+func (r *gltfReader) accessor(index int) ([]float64, int, error) {
+	if index < 0 || index >= len(r.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d does not exist", index)
+	}
+	acc := &r.doc.Accessors[index]
+	components := gltfComponents[acc.Type]
+	size := gltfComponentSize(acc.ComponentType)
+	if components == 0 || size == 0 || acc.Count < 0 {
+		return nil, 0, fmt.Errorf("accessor %d has invalid type %s/%d", index, acc.Type, acc.ComponentType)
+	}
+
+	values := make([]float64, acc.Count*components)
+	if acc.BufferView != nil {
+		data, view, err := r.bufferView(*acc.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", index, err)
+		}
+		stride := view.ByteStride
+		if stride == 0 {
+			stride = components * size
+		}
+		if acc.Count > 0 && acc.ByteOffset+(acc.Count-1)*stride+components*size > len(data) {
+			return nil, 0, fmt.Errorf("accessor %d overruns buffer view %d", index, *acc.BufferView)
+		}
+		for i := 0; i < acc.Count; i++ {
+			base := acc.ByteOffset + i*stride
+			for c := 0; c < components; c++ {
+				values[i*components+c] = readComponent(data[base+c*size:], acc.ComponentType, acc.Normalized)
+			}
+		}
+	}
+
+	if sparse := acc.Sparse; sparse != nil {
+		indexData, _, err := r.bufferView(sparse.Indices.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse indices: %w", index, err)
+		}
+		valueData, _, err := r.bufferView(sparse.Values.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse values: %w", index, err)
+		}
+		indexSize := gltfComponentSize(sparse.Indices.ComponentType)
+		if indexSize == 0 || sparse.Indices.ByteOffset+sparse.Count*indexSize > len(indexData) ||
+			sparse.Values.ByteOffset+sparse.Count*components*size > len(valueData) {
+			return nil, 0, fmt.Errorf("accessor %d has invalid sparse data", index)
+		}
+		for s := 0; s < sparse.Count; s++ {
+			target := int(readComponent(indexData[sparse.Indices.ByteOffset+s*indexSize:], sparse.Indices.ComponentType, false))
+			if target < 0 || target >= acc.Count {
+				return nil, 0, fmt.Errorf("accessor %d sparse index %d out of range", index, target)
+			}
+			for c := 0; c < components; c++ {
+				offset := sparse.Values.ByteOffset + (s*components+c)*size
+				values[target*components+c] = readComponent(valueData[offset:], acc.ComponentType, acc.Normalized)
+			}
+		}
+	}
+
+	return values, components, nil
+}
+
+// vectorAccessor reads an accessor that must have the given component count
+func (r *gltfReader) vectorAccessor(index, components int) ([]float64, error) {
+	values, got, err := r.accessor(index)
+	if err != nil {
+		return nil, err
+	}
+	if got != components {
+		return nil, fmt.Errorf("accessor %d has %d components, expected %d", index, got, components)
+	}
+	return values, nil
+}
+
+// ============================================================================
+// SCENE
+// ============================================================================
+
+// importScene builds the default scene (or the first, or every root node if
+// there are no scenes) under a root named after the file
+func (r *gltfReader) importScene(name string) (*GLTFScene, error) {
+	result := &GLTFScene{
+		Root:      NewSceneNode(name),
+		Nodes:     make([]*SceneNode, len(r.doc.Nodes)),
+		Meshes:    make([]*Mesh, len(r.doc.Meshes)),
+		Materials: make([]*PBRMaterial, len(r.doc.Materials)),
+	}
+
+	var roots []int
+	switch {
+	case r.doc.Scene != nil:
+		if *r.doc.Scene < 0 || *r.doc.Scene >= len(r.doc.Scenes) {
+			return nil, fmt.Errorf("default scene %d does not exist", *r.doc.Scene)
+		}
+		roots = r.doc.Scenes[*r.doc.Scene].Nodes
+	case len(r.doc.Scenes) > 0:
+		roots = r.doc.Scenes[0].Nodes
+	default:
+		hasParent := make([]bool, len(r.doc.Nodes))
+		for _, node := range r.doc.Nodes {
+			for _, child := range node.Children {
+				if child >= 0 && child < len(hasParent) {
+					hasParent[child] = true
+				}
+			}
+		}
+		for i := range r.doc.Nodes {
+			if !hasParent[i] {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	names := map[string]bool{name: true}
+	for _, index := range roots {
+		if err := r.importNode(index, result.Root, result, names); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, mesh := range r.meshes {
+		result.Meshes[i] = mesh
+	}
+	for i, material := range r.materials {
+		if i >= 0 && i < len(result.Materials) {
+			result.Materials[i] = material
+		}
+	}
+
+	// Cameras and lights once every transform is in place
+	for i, node := range result.Nodes {
+		if node == nil {
+			continue
+		}
+		world := node.Transform.GetWorldMatrix()
+		if err := r.importCamera(i, world, result); err != nil {
+			return nil, err
+		}
+		if err := r.importLight(i, world, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// importNode adds node index and its subtree under parent
+func (r *gltfReader) importNode(index int, parent *SceneNode, result *GLTFScene, names map[string]bool) error {
+	if index < 0 || index >= len(r.doc.Nodes) {
+		return fmt.Errorf("node %d does not exist", index)
+	}
+	if result.Nodes[index] != nil {
+		return fmt.Errorf("node %d has more than one parent", index)
+	}
+	src := &r.doc.Nodes[index]
+
+	// Names key Scene.AllNodes, so they must be unique
+	name := src.Name
+	if name == "" {
+		name = fmt.Sprintf("node%d", index)
+	}
+	if names[name] {
+		name = fmt.Sprintf("%s.%d", name, index)
+	}
+	names[name] = true
+
+	node := NewSceneNode(name)
+	result.Nodes[index] = node
+	parent.AddChild(node)
+
+	if err := setGLTFTransform(node.Transform, src); err != nil {
+		return fmt.Errorf("node %d: %w", index, err)
+	}
+	if src.Mesh != nil {
+		mesh, err := r.mesh(*src.Mesh)
+		if err != nil {
+			return fmt.Errorf("node %d: %w", index, err)
+		}
+		node.Object = mesh
+	}
+
+	for _, child := range src.Children {
+		if err := r.importNode(child, node, result, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setGLTFTransform sets a transform from a node's matrix or TRS properties
+func setGLTFTransform(t *Transform, node *gltfNode) error {
+	if len(node.Matrix) > 0 {
+		if len(node.Matrix) != 16 {
+			return fmt.Errorf("matrix has %d values", len(node.Matrix))
+		}
+		var m Matrix4x4
+		for col := 0; col < 4; col++ {
+			for row := 0; row < 4; row++ {
+				m.M[row*4+col] = node.Matrix[col*4+row]
+			}
+		}
+		pos, rot, scale := DecomposeMatrix(m)
+		t.Position, t.Rotation, t.Scale = pos, rot, scale
+		t.MarkDirty()
+		return nil
+	}
+
+	if len(node.Translation) > 0 {
+		if len(node.Translation) != 3 {
+			return fmt.Errorf("translation has %d values", len(node.Translation))
+		}
+		t.SetPosition(node.Translation[0], node.Translation[1], node.Translation[2])
+	}
+	if len(node.Rotation) > 0 {
+		if len(node.Rotation) != 4 {
+			return fmt.Errorf("rotation has %d values", len(node.Rotation))
+		}
+		q := node.Rotation
+		t.SetRotationQuaternion(Quaternion{W: q[3], X: q[0], Y: q[1], Z: q[2]}.Normalize())
+	}
+	if len(node.Scale) > 0 {
+		if len(node.Scale) != 3 {
+			return fmt.Errorf("scale has %d values", len(node.Scale))
+		}
+		t.SetScale(node.Scale[0], node.Scale[1], node.Scale[2])
+	}
+	return nil
+}
+
+// importCamera adds the node's perspective camera, if it has one. Cameras
+// get the default FOV ratio unless the file gives an aspect ratio;
+// orthographic cameras keep the default FOV.
+func (r *gltfReader) importCamera(index int, world Matrix4x4, result *GLTFScene) error {
+	src := r.doc.Nodes[index].Camera
+	if src == nil {
+		return nil
+	}
+	if *src < 0 || *src >= len(r.doc.Cameras) {
+		return fmt.Errorf("node %d uses missing camera %d", index, *src)
+	}
+
+	camera := NewCamera()
+	if p := r.doc.Cameras[*src].Perspective; p != nil && p.YFov > 0 {
+		fovY := p.YFov / DegToRad
+		fovX := fovY * FOV_X / FOV_Y
+		if p.AspectRatio > 0 {
+			fovX = 2 * math.Atan(math.Tan(p.YFov/2)*p.AspectRatio) / DegToRad
+		}
+		camera.SetFOV(fovX, fovY)
+		if p.ZNear > 0 {
+			camera.Near = p.ZNear
+		}
+		if p.ZFar > 0 {
+			camera.Far = p.ZFar
+		}
+	}
+
+	pos, rot, _ := DecomposeMatrix(world)
+	camera.Transform.SetPosition(pos.X, pos.Y, pos.Z)
+	camera.Transform.SetRotationQuaternion(rot.Multiply(QuaternionFromAxisAngle(Point{Y: 1}, math.Pi)).Normalize())
+	result.Cameras = append(result.Cameras, camera)
+	return nil
+}
+
+// importLight adds the node's KHR_lights_punctual light, if it has one.
+// Spot lights become point lights (our lights have no cone); directional
+// lights are placed gltfDirectionalDistance behind the node.
+func (r *gltfReader) importLight(index int, world Matrix4x4, result *GLTFScene) error {
+	ext := r.doc.Nodes[index].Extensions
+	if ext == nil || ext.LightsPunctual == nil {
+		return nil
+	}
+	src := ext.LightsPunctual.Light
+	if r.doc.Extensions == nil || r.doc.Extensions.LightsPunctual == nil ||
+		src < 0 || src >= len(r.doc.Extensions.LightsPunctual.Lights) {
+		return fmt.Errorf("node %d uses missing light %d", index, src)
+	}
+	def := r.doc.Extensions.LightsPunctual.Lights[src]
+
+	color := ColorWhite
+	if len(def.Color) == 3 {
+		color = gltfColor(def.Color)
+	}
+	intensity := 1.0
+	if def.Intensity != nil {
+		intensity = *def.Intensity
+	}
+
+	pos := Point{X: world.M[3], Y: world.M[7], Z: world.M[11]}
+	var light *Light
+	switch def.Type {
+	case "directional":
+		// The light shines down the node's -Z
+		back := normalizePoint(world.TransformDirection(Point{Z: 1}))
+		pos = addPoints(pos, scalePoint(back, gltfDirectionalDistance))
+		light = NewLight(pos.X, pos.Y, pos.Z, color, intensity)
+	case "point", "spot":
+		light = NewPointLight(pos.X, pos.Y, pos.Z, color, intensity)
+		light.Range = def.Range
+	default:
+		return fmt.Errorf("light %d has unknown type %q", src, def.Type)
+	}
+	result.Lights = append(result.Lights, light)
+	return nil
+}
+
+// gltfColor converts linear [0, 1] factors to a Color
+func gltfColor(factors []float64) Color {
+	channel := func(i int) uint8 {
+		if i >= len(factors) {
+			return 255
+		}
+		return uint8(math.Round(clampFloat(factors[i], 0, 1) * 255))
+	}
+	return Color{R: channel(0), G: channel(1), B: channel(2)}
+}
+
+// ============================================================================
+// MESHES
+// ============================================================================
+
+// mesh imports a glTF mesh once, merging its triangle primitives
+func (r *gltfReader) mesh(index int) (*Mesh, error) {
+	if mesh, ok := r.meshes[index]; ok {
+		return mesh, nil
+	}
+	if index < 0 || index >= len(r.doc.Meshes) {
+		return nil, fmt.Errorf("mesh %d does not exist", index)
+	}
+
+	mesh := NewMesh()
+	var faceMaterials []IMaterial
+	hasNormals, hasUVs, hasTangents, hasColors := true, true, true, true
+	needsTangents := false
+
+	for p, prim := range r.doc.Meshes[index].Primitives {
+		mode := gltfTriangles
+		if prim.Mode != nil {
+			mode = *prim.Mode
+		}
+		if mode != gltfTriangles && mode != gltfTriangleStrip && mode != gltfTriangleFan {
+			continue // Points and lines
+		}
+
+		position, ok := prim.Attributes["POSITION"]
+		if !ok {
+			return nil, fmt.Errorf("mesh %d primitive %d has no positions", index, p)
+		}
+		positions, err := r.vectorAccessor(position, 3)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d primitive %d: %w", index, p, err)
+		}
+		count := len(positions) / 3
+
+		// Optional attributes, each read only while every primitive so far
+		// has had it
+		optional := func(name string, components []int, keep *bool) ([]float64, int, error) {
+			accessor, ok := prim.Attributes[name]
+			if !ok || !*keep {
+				*keep = false
+				return nil, 0, nil
+			}
+			values, got, err := r.accessor(accessor)
+			if err != nil {
+				return nil, 0, err
+			}
+			valid := false
+			for _, c := range components {
+				valid = valid || got == c
+			}
+			if !valid || len(values) != count*got {
+				return nil, 0, fmt.Errorf("attribute %s does not match the positions", name)
+			}
+			return values, got, nil
+		}
+		normals, _, err := optional("NORMAL", []int{3}, &hasNormals)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d primitive %d: %w", index, p, err)
+		}
+		uvs, _, err := optional("TEXCOORD_0", []int{2}, &hasUVs)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d primitive %d: %w", index, p, err)
+		}
+		tangents, _, err := optional("TANGENT", []int{4}, &hasTangents)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d primitive %d: %w", index, p, err)
+		}
+		colors, colorComponents, err := optional("COLOR_0", []int{3, 4}, &hasColors)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d primitive %d: %w", index, p, err)
+		}
+
+		var indices []int
+		if prim.Indices != nil {
+			values, err := r.vectorAccessor(*prim.Indices, 1)
+			if err != nil {
+				return nil, fmt.Errorf("mesh %d primitive %d: %w", index, p, err)
+			}
+			indices = make([]int, len(values))
+			for i, v := range values {
+				if indices[i] = int(v); indices[i] < 0 || indices[i] >= count {
+					return nil, fmt.Errorf("mesh %d primitive %d index %d out of range", index, p, indices[i])
+				}
+			}
+		} else {
+			indices = make([]int, count)
+			for i := range indices {
+				indices[i] = i
+			}
+		}
+
+		var material IMaterial
+		if prim.Material != nil {
+			pbr, err := r.material(*prim.Material)
+			if err != nil {
+				return nil, err
+			}
+			material = pbr
+			needsTangents = needsTangents || pbr.NormalMap != nil
+		} else {
+			material = r.defaultMaterial()
+		}
+
+		base := len(mesh.Vertices)
+		for i := 0; i < count; i++ {
+			mesh.Vertices = append(mesh.Vertices, Point{X: positions[i*3], Y: positions[i*3+1], Z: positions[i*3+2]})
+			if hasNormals {
+				mesh.Normals = append(mesh.Normals, normalizePoint(Point{X: normals[i*3], Y: normals[i*3+1], Z: normals[i*3+2]}))
+			}
+			if hasUVs {
+				mesh.UVs = append(mesh.UVs, TextureCoord{U: uvs[i*2], V: uvs[i*2+1]})
+			}
+			if hasTangents {
+				mesh.Tangents = append(mesh.Tangents, Tangent{X: tangents[i*4], Y: tangents[i*4+1], Z: tangents[i*4+2], W: tangents[i*4+3]})
+			}
+			if hasColors {
+				mesh.Colors = append(mesh.Colors, gltfColor(colors[i*colorComponents:i*colorComponents+3]))
+			}
+		}
+
+		for _, tri := range gltfTriangleList(indices, mode) {
+			mesh.AddTriangleIndices(base+tri[0], base+tri[1], base+tri[2])
+			faceMaterials = append(faceMaterials, material)
+		}
+	}
+
+	// Attributes some primitive lacked are dropped
+	if !hasNormals {
+		mesh.Normals = nil
+	}
+	if !hasUVs {
+		mesh.UVs = nil
+	}
+	if !hasTangents || !hasNormals {
+		mesh.Tangents = nil
+	}
+	if !hasColors {
+		mesh.Colors = nil
+	}
+
+	if len(faceMaterials) > 0 {
+		mesh.Material = faceMaterials[0]
+		for _, m := range faceMaterials {
+			if m != mesh.Material {
+				mesh.FaceMaterials = faceMaterials
+				break
+			}
+		}
+	}
+
+	// Normal maps need tangents; glTF asks for MikkTSpace ones when the file
+	// has none
+	if needsTangents && !mesh.HasTangents() && mesh.HasUVs() {
+		if err := mesh.GenerateTangents(); err != nil {
+			return nil, fmt.Errorf("mesh %d: %w", index, err)
+		}
+	}
+
+	r.meshes[index] = mesh
+	return mesh, nil
+}
+
+// gltfTriangleList turns strip or fan indices into triangles
+func gltfTriangleList(indices []int, mode int) [][3]int {
+	var tris [][3]int
+	switch mode {
+	case gltfTriangleStrip:
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				tris = append(tris, [3]int{indices[i], indices[i+1], indices[i+2]})
+			} else {
+				tris = append(tris, [3]int{indices[i+1], indices[i], indices[i+2]})
+			}
+		}
+	case gltfTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			tris = append(tris, [3]int{indices[i], indices[i+1], indices[0]})
+		}
+	default:
+		for i := 0; i+2 < len(indices); i += 3 {
+			tris = append(tris, [3]int{indices[i], indices[i+1], indices[i+2]})
+		}
+	}
+	return tris
+}
+
+// ============================================================================
+// MATERIALS
+// ============================================================================
+
+// defaultMaterial is the material of primitives without one (glTF's default:
+// white, fully metallic and rough)
+func (r *gltfReader) defaultMaterial() *PBRMaterial {
+	if material, ok := r.materials[-1]; ok {
+		return material
+	}
+	material := NewPBRMaterial()
+	material.Metallic = 1
+	material.Roughness = 1
+	r.materials[-1] = material
+	return material
+}
+
+// material imports a glTF material once
+func (r *gltfReader) material(index int) (*PBRMaterial, error) {
+	if material, ok := r.materials[index]; ok {
+		return material, nil
+	}
+	if index < 0 || index >= len(r.doc.Materials) {
+		return nil, fmt.Errorf("material %d does not exist", index)
+	}
+	src := &r.doc.Materials[index]
+	material := NewPBRMaterial()
+	material.Metallic, material.Roughness = 1, 1
+
+	pbr := src.PBRMetallicRoughness
+	if pbr == nil {
+		pbr = &gltfPBR{}
+	}
+	if len(pbr.BaseColorFactor) >= 3 {
+		material.Albedo = gltfColor(pbr.BaseColorFactor)
+	}
+	if pbr.MetallicFactor != nil {
+		material.Metallic = clampFloat(*pbr.MetallicFactor, 0, 1)
+	}
+	if pbr.RoughnessFactor != nil {
+		material.Roughness = clampFloat(*pbr.RoughnessFactor, 0, 1)
+	}
+	if len(src.EmissiveFactor) == 3 {
+		material.Emissive = gltfColor(src.EmissiveFactor)
+	}
+
+	// The sampler of the first map sets the material's filter and wrap
+	var sampler *gltfSampler
+	texture := func(ref *gltfTextureReference) (*Texture, error) {
+		if ref == nil {
+			return nil, nil
+		}
+		tex, s, err := r.texture(ref.Index)
+		if err != nil {
+			return nil, fmt.Errorf("material %d: %w", index, err)
+		}
+		if sampler == nil {
+			sampler = s
+		}
+		return tex, nil
+	}
+
+	if tex, err := texture(pbr.BaseColorTexture); err != nil {
+		return nil, err
+	} else if tex != nil {
+		// Textures are scaled by the factor; bake it in
+		albedo := material.Albedo
+		material.AlbedoMap = mapTexture(tex, func(c Color) Color { return c.Multiply(albedo) })
+		material.Albedo = ColorWhite
+	}
+	if tex, err := texture(pbr.MetallicRoughnessTexture); err != nil {
+		return nil, err
+	} else if tex != nil {
+		metallic, roughness := material.Metallic, material.Roughness
+		material.MetallicMap = mapTexture(tex, func(c Color) Color { return grayColor(float64(c.B) / 255 * metallic) })
+		material.RoughnessMap = mapTexture(tex, func(c Color) Color { return grayColor(float64(c.G) / 255 * roughness) })
+	}
+	if tex, err := texture(src.NormalTexture); err != nil {
+		return nil, err
+	} else if tex != nil {
+		material.NormalMap = tex
+		if scale := src.NormalTexture.Scale; scale != nil && *scale != 1 {
+			material.NormalMap = mapTexture(tex, func(c Color) Color {
+				n := UnpackNormalMap(c)
+				return packNormalColor(normalizePoint(Point{X: n.X * *scale, Y: n.Y * *scale, Z: n.Z}))
+			})
+		}
+	}
+	if tex, err := texture(src.OcclusionTexture); err != nil {
+		return nil, err
+	} else if tex != nil {
+		strength := 1.0
+		if src.OcclusionTexture.Strength != nil {
+			strength = *src.OcclusionTexture.Strength
+		}
+		material.AOMap = mapTexture(tex, func(c Color) Color { return grayColor(1 + strength*(float64(c.R)/255-1)) })
+	}
+	if tex, err := texture(src.EmissiveTexture); err != nil {
+		return nil, err
+	} else if tex != nil {
+		material.EmissiveMap = tex
+	}
+
+	material.UseTextures = material.AlbedoMap != nil || material.MetallicMap != nil ||
+		material.NormalMap != nil || material.AOMap != nil || material.EmissiveMap != nil
+	if sampler != nil {
+		if sampler.MagFilter == gltfNearest {
+			material.TextureFilter = FilterNearest
+		}
+		switch sampler.WrapS {
+		case gltfClampToEdge:
+			material.TextureWrap = WrapClamp
+		case gltfMirroredRepeat:
+			material.TextureWrap = WrapMirror
+		}
+	}
+
+	r.materials[index] = material
+	return material, nil
+}
+
+// texture returns a glTF texture's image and sampler (nil: the default)
+func (r *gltfReader) texture(index int) (*Texture, *gltfSampler, error) {
+	if index < 0 || index >= len(r.doc.Textures) {
+		return nil, nil, fmt.Errorf("texture %d does not exist", index)
+	}
+	src := r.doc.Textures[index]
+	if src.Source == nil {
+		return nil, nil, fmt.Errorf("texture %d has no image", index)
+	}
+	tex, err := r.image(*src.Source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sampler := &gltfSampler{}
+	if src.Sampler != nil {
+		if *src.Sampler < 0 || *src.Sampler >= len(r.doc.Samplers) {
+			return nil, nil, fmt.Errorf("texture %d uses missing sampler %d", index, *src.Sampler)
+		}
+		sampler = &r.doc.Samplers[*src.Sampler]
+	}
+	return tex, sampler, nil
+}
+
+// image decodes a glTF image once (PNG or JPEG, from a URI or buffer view)
+func (r *gltfReader) image(index int) (*Texture, error) {
+	if tex, ok := r.images[index]; ok {
+		return tex, nil
+	}
+	if index < 0 || index >= len(r.doc.Images) {
+		return nil, fmt.Errorf("image %d does not exist", index)
+	}
+	src := r.doc.Images[index]
+
+	var data []byte
+	var err error
+	if src.BufferView != nil {
+		data, _, err = r.bufferView(*src.BufferView)
+	} else {
+		data, err = r.readURI(src.URI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", index, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", index, err)
+	}
+
+	tex := NewTextureFromImage(img)
+	r.images[index] = tex
+	return tex, nil
+}
+
+// mapTexture returns a copy of a texture with every texel passed through fn
+func mapTexture(tex *Texture, fn func(Color) Color) *Texture {
+	result := NewTexture(tex.Width, tex.Height)
+	for i, c := range tex.Data {
+		result.Data[i] = fn(c)
+	}
+	return result
+}
+
+// grayColor returns a gray level for a [0, 1] value
+func grayColor(v float64) Color {
+	g := uint8(math.Round(clampFloat(v, 0, 1) * 255))
+	return Color{R: g, G: g, B: g}
+}
+
+// packNormalColor encodes a unit tangent-space normal as a normal map texel,
+// the inverse of UnpackNormalMap
+func packNormalColor(n Point) Color {
+	channel := func(v float64) uint8 {
+		return uint8(math.Round(clampFloat((v+1)/2, 0, 1) * 255))
+	}
+	return Color{R: channel(n.X), G: channel(n.Y), B: channel(n.Z)}
+}
+
+// ============================================================================
+// SCENE AND ASSET INTEGRATION
+// ============================================================================
+
+// ImportGLTF loads a glTF file and adds its nodes under the scene root. The
+// cameras and lights are returned for the caller to use.
+func (s *Scene) ImportGLTF(path string) (*GLTFScene, error) {
+	gltf, err := LoadGLTF(path)
+	if err != nil {
+		return nil, err
+	}
+	s.AddNode(gltf.Root)
+	var register func(node *SceneNode)
+	register = func(node *SceneNode) {
+		for _, child := range node.Children {
+			s.AllNodes[child.Name] = child
+			register(child)
+		}
+	}
+	register(gltf.Root)
+	return gltf, nil
+}
+
+// LoadGLTFMesh loads a glTF file as one mesh, with every mesh node's
+// geometry moved to where the node puts it (for AssetManager.LoadMesh)
+func LoadGLTFMesh(path string) (*Mesh, error) {
+	gltf, err := LoadGLTF(path)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := NewMesh()
+	var faceMaterials []IMaterial
+	hasNormals, hasUVs, hasTangents, hasColors := true, true, true, true
+	for _, node := range gltf.Nodes {
+		mesh, ok := nodeMesh(node)
+		if !ok {
+			continue
+		}
+		hasNormals = hasNormals && mesh.HasNormals()
+		hasUVs = hasUVs && mesh.HasUVs()
+		hasTangents = hasTangents && mesh.HasTangents()
+		hasColors = hasColors && mesh.HasColors()
+	}
+
+	for _, node := range gltf.Nodes {
+		mesh, ok := nodeMesh(node)
+		if !ok {
+			continue
+		}
+		world := node.Transform.GetWorldMatrix()
+		normalMatrix := world.NormalMatrix()
+		base := len(merged.Vertices)
+
+		for i, v := range mesh.Vertices {
+			merged.Vertices = append(merged.Vertices, world.TransformPoint(v))
+			if hasNormals {
+				merged.Normals = append(merged.Normals, normalMatrix.TransformNormal(mesh.Normals[i]))
+			}
+			if hasUVs {
+				merged.UVs = append(merged.UVs, mesh.UVs[i])
+			}
+			if hasTangents {
+				merged.Tangents = append(merged.Tangents, world.TransformTangent(mesh.Tangents[i]))
+			}
+			if hasColors {
+				merged.Colors = append(merged.Colors, mesh.Colors[i])
+			}
+		}
+
+		// Mirroring nodes turn the triangles inside out; flip them back
+		mirrored := world.Determinant3() < 0
+		for t := 0; t+2 < len(mesh.Indices); t += 3 {
+			a, b, c := mesh.Indices[t], mesh.Indices[t+1], mesh.Indices[t+2]
+			if mirrored {
+				b, c = c, b
+			}
+			merged.AddTriangleIndices(base+a, base+b, base+c)
+			faceMaterials = append(faceMaterials, mesh.MaterialForFace(t/3))
+		}
+	}
+	if len(merged.Indices) == 0 {
+		return nil, fmt.Errorf("glTF file has no triangle meshes")
+	}
+
+	merged.Material = faceMaterials[0]
+	for _, m := range faceMaterials {
+		if m != merged.Material {
+			merged.FaceMaterials = faceMaterials
+			break
+		}
+	}
+	return merged, nil
+}
+
+// nodeMesh returns the mesh a node holds
+func nodeMesh(node *SceneNode) (*Mesh, bool) {
+	if node == nil {
+		return nil, false
+	}
+	mesh, ok := node.Object.(*Mesh)
+	return mesh, ok && len(mesh.Indices) > 0
+}
@@ -112,6 +112,9 @@ func (gim *GLFWInputManager) GetInputState() InputState {
 	// Reset
 	state.Reset = gim.window.GetKey(glfw.KeyR) == glfw.Press
 
+	// Debug overlays
+	state.ToggleHeatmap = gim.window.GetKey(glfw.KeyH) == glfw.Press
+
 	// Quit
 	state.Quit = gim.window.GetKey(glfw.KeyX) == glfw.Press ||
 		gim.window.GetKey(glfw.KeyEscape) == glfw.Press
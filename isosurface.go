@@ -0,0 +1,635 @@
+package main
+
+import "math"
+
+// ============================================================================
+// ISOSURFACES
+// ============================================================================
+// Signed distance functions describe a shape by the distance to its surface
+// (negative inside), and compose with min/max: union, subtraction and their
+// smooth variants blend primitives into organic shapes. Metaballs are a
+// field of soft spheres whose sum is cut at a threshold.
+//
+// Both are turned into meshes by dual contouring on a density grid: every
+// sheet of surface passing through a cell gets one vertex, placed where the
+// planes through its edge crossings (from the field's gradient) best meet,
+// so sharp box edges survive; every grid edge the surface crosses becomes a
+// quad joining the four cells around it. Cells cut by thin walls or holes
+// hold several sheets, and keeping their vertices apart keeps the mesh
+// manifold. Vertices are shared by construction and normals come from the
+// gradient, so the result is a smooth indexed mesh.
+// ============================================================================
+
+// SDF is a signed distance function: negative inside, zero on the surface
+type SDF func(p Point) float64
+
+// SDFSphere is a sphere
+func SDFSphere(center Point, radius float64) SDF {
+	return func(p Point) float64 {
+		return pointLength(subPoints(p, center)) - radius
+	}
+}
+
+// SDFBox is an axis-aligned box
+func SDFBox(center, halfExtents Point) SDF {
+	return func(p Point) float64 {
+		q := subPoints(p, center)
+		dx := math.Abs(q.X) - halfExtents.X
+		dy := math.Abs(q.Y) - halfExtents.Y
+		dz := math.Abs(q.Z) - halfExtents.Z
+		outside := pointLength(Point{X: math.Max(dx, 0), Y: math.Max(dy, 0), Z: math.Max(dz, 0)})
+		return outside + math.Min(math.Max(dx, math.Max(dy, dz)), 0)
+	}
+}
+
+// SDFTorus is a ring around the Y axis
+func SDFTorus(center Point, majorRadius, minorRadius float64) SDF {
+	return func(p Point) float64 {
+		q := subPoints(p, center)
+		ring := math.Hypot(q.X, q.Z) - majorRadius
+		return math.Hypot(ring, q.Y) - minorRadius
+	}
+}
+
+// SDFCapsule is a segment from a to b with rounded ends
+func SDFCapsule(a, b Point, radius float64) SDF {
+	ab := subPoints(b, a)
+	length2 := dotPoints(ab, ab)
+	return func(p Point) float64 {
+		t := 0.0
+		if length2 > 0 {
+			t = clamp(dotPoints(subPoints(p, a), ab)/length2, 0, 1)
+		}
+		return pointLength(subPoints(p, addPoints(a, scalePoint(ab, t)))) - radius
+	}
+}
+
+// SDFUnion is the space inside either shape
+func SDFUnion(a, b SDF) SDF {
+	return func(p Point) float64 {
+		return math.Min(a(p), b(p))
+	}
+}
+
+// SDFIntersect is the space inside both shapes
+func SDFIntersect(a, b SDF) SDF {
+	return func(p Point) float64 {
+		return math.Max(a(p), b(p))
+	}
+}
+
+// SDFSubtract cuts b out of a
+func SDFSubtract(a, b SDF) SDF {
+	return func(p Point) float64 {
+		return math.Max(a(p), -b(p))
+	}
+}
+
+// SDFSmoothUnion blends two shapes together over distance k
+func SDFSmoothUnion(a, b SDF, k float64) SDF {
+	return func(p Point) float64 {
+		da, db := a(p), b(p)
+		h := clamp(0.5+0.5*(db-da)/k, 0, 1)
+		return db + (da-db)*h - k*h*(1-h)
+	}
+}
+
+// SDFSmoothSubtract cuts b out of a with a fillet of size k
+func SDFSmoothSubtract(a, b SDF, k float64) SDF {
+	return func(p Point) float64 {
+		da, db := a(p), b(p)
+		h := clamp(0.5-0.5*(da+db)/k, 0, 1)
+		return da + (-db-da)*h + k*h*(1-h)
+	}
+}
+
+// SDFTranslate moves a shape by offset
+func SDFTranslate(sdf SDF, offset Point) SDF {
+	return func(p Point) float64 {
+		return sdf(subPoints(p, offset))
+	}
+}
+
+// Metaball is one soft sphere of a metaball field
+type Metaball struct {
+	Center Point
+	Radius float64 // Influence falls to zero at this distance
+}
+
+// MetaballSDF returns the surface where the metaballs' summed influence
+// reaches threshold (0.5 gives a ball of about half the radius on its own).
+// Influence falls off as (1 - d²/r²)³, so distant balls cost nothing and
+// nearby ones merge smoothly. The value is negative inside but is not a true
+// distance.
+func MetaballSDF(balls []Metaball, threshold float64) SDF {
+	return func(p Point) float64 {
+		sum := 0.0
+		for _, ball := range balls {
+			d := subPoints(p, ball.Center)
+			if x := dotPoints(d, d) / (ball.Radius * ball.Radius); x < 1 {
+				sum += (1 - x) * (1 - x) * (1 - x)
+			}
+		}
+		return threshold - sum
+	}
+}
+
+// MetaballBounds returns a box enclosing every metaball's influence
+func MetaballBounds(balls []Metaball) *AABB {
+	bounds := NewAABB(Point{}, Point{})
+	for i, ball := range balls {
+		r := Point{X: ball.Radius, Y: ball.Radius, Z: ball.Radius}
+		ballBounds := NewAABB(subPoints(ball.Center, r), addPoints(ball.Center, r))
+		if i == 0 {
+			bounds = ballBounds
+		} else {
+			bounds = bounds.Merge(ballBounds)
+		}
+	}
+	return bounds
+}
+
+// ============================================================================
+// DENSITY GRID
+// ============================================================================
+
+// DensityGrid samples a scalar field on a regular grid. Space is solid where
+// the density is above the iso level.
+type DensityGrid struct {
+	Min        Point     // Position of sample (0, 0, 0)
+	CellSize   float64   // Spacing between samples
+	NX, NY, NZ int       // Samples along each axis
+	Values     []float64 // X varies fastest, then Y, then Z
+}
+
+// NewDensityGrid creates an empty grid covering bounds
+func NewDensityGrid(bounds *AABB, cellSize float64) *DensityGrid {
+	size := bounds.GetSize()
+	g := &DensityGrid{
+		Min:      bounds.Min,
+		CellSize: cellSize,
+		NX:       int(math.Ceil(size.X/cellSize)) + 1,
+		NY:       int(math.Ceil(size.Y/cellSize)) + 1,
+		NZ:       int(math.Ceil(size.Z/cellSize)) + 1,
+	}
+	g.Values = make([]float64, g.NX*g.NY*g.NZ)
+	return g
+}
+
+// Index returns the position of a sample in Values
+func (g *DensityGrid) Index(x, y, z int) int {
+	return (z*g.NY+y)*g.NX + x
+}
+
+// At returns the sample at grid coordinates
+func (g *DensityGrid) At(x, y, z int) float64 {
+	return g.Values[g.Index(x, y, z)]
+}
+
+// Set sets the sample at grid coordinates
+func (g *DensityGrid) Set(x, y, z int, value float64) {
+	g.Values[g.Index(x, y, z)] = value
+}
+
+// Position returns the world position of a sample
+func (g *DensityGrid) Position(x, y, z int) Point {
+	return Point{
+		X: g.Min.X + float64(x)*g.CellSize,
+		Y: g.Min.Y + float64(y)*g.CellSize,
+		Z: g.Min.Z + float64(z)*g.CellSize,
+	}
+}
+
+// Sample interpolates the density at a point, clamped to the grid
+func (g *DensityGrid) Sample(p Point) float64 {
+	fx := clamp((p.X-g.Min.X)/g.CellSize, 0, float64(g.NX-1))
+	fy := clamp((p.Y-g.Min.Y)/g.CellSize, 0, float64(g.NY-1))
+	fz := clamp((p.Z-g.Min.Z)/g.CellSize, 0, float64(g.NZ-1))
+	x0, y0, z0 := minInt(int(fx), g.NX-2), minInt(int(fy), g.NY-2), minInt(int(fz), g.NZ-2)
+	x0, y0, z0 = maxInt(x0, 0), maxInt(y0, 0), maxInt(z0, 0)
+	x1, y1, z1 := minInt(x0+1, g.NX-1), minInt(y0+1, g.NY-1), minInt(z0+1, g.NZ-1)
+	tx, ty, tz := fx-float64(x0), fy-float64(y0), fz-float64(z0)
+
+	lerp := func(a, b, t float64) float64 { return a + (b-a)*t }
+	c00 := lerp(g.At(x0, y0, z0), g.At(x1, y0, z0), tx)
+	c10 := lerp(g.At(x0, y1, z0), g.At(x1, y1, z0), tx)
+	c01 := lerp(g.At(x0, y0, z1), g.At(x1, y0, z1), tx)
+	c11 := lerp(g.At(x0, y1, z1), g.At(x1, y1, z1), tx)
+	return lerp(lerp(c00, c10, ty), lerp(c01, c11, ty), tz)
+}
+
+// FillSDF samples a signed distance function; density is the negated
+// distance, so the surface is at iso level 0
+func (g *DensityGrid) FillSDF(sdf SDF) {
+	i := 0
+	for z := 0; z < g.NZ; z++ {
+		for y := 0; y < g.NY; y++ {
+			for x := 0; x < g.NX; x++ {
+				g.Values[i] = -sdf(g.Position(x, y, z))
+				i++
+			}
+		}
+	}
+}
+
+// Polygonize extracts the surface where the density crosses isoLevel.
+// Normals come from the grid's own gradient.
+func (g *DensityGrid) Polygonize(isoLevel float64) *Mesh {
+	h := g.CellSize * 0.5
+	return g.contour(isoLevel, func(p Point) Point {
+		// Density rises inward, so the outward normal is its negated gradient
+		return Point{
+			X: g.Sample(Point{X: p.X - h, Y: p.Y, Z: p.Z}) - g.Sample(Point{X: p.X + h, Y: p.Y, Z: p.Z}),
+			Y: g.Sample(Point{X: p.X, Y: p.Y - h, Z: p.Z}) - g.Sample(Point{X: p.X, Y: p.Y + h, Z: p.Z}),
+			Z: g.Sample(Point{X: p.X, Y: p.Y, Z: p.Z - h}) - g.Sample(Point{X: p.X, Y: p.Y, Z: p.Z + h}),
+		}
+	})
+}
+
+// PolygonizeSDF meshes the surface of an SDF inside bounds, with resolution
+// cells along the longest side. Normals come from the SDF itself.
+func PolygonizeSDF(sdf SDF, bounds *AABB, resolution int) *Mesh {
+	size := bounds.GetSize()
+	cellSize := math.Max(size.X, math.Max(size.Y, size.Z)) / float64(maxInt(resolution, 1))
+
+	// One cell of margin so surfaces touching the bounds are still closed
+	margin := Point{X: cellSize, Y: cellSize, Z: cellSize}
+	grid := NewDensityGrid(NewAABB(subPoints(bounds.Min, margin), addPoints(bounds.Max, margin)), cellSize)
+	grid.FillSDF(sdf)
+
+	h := cellSize * 0.1
+	return grid.contour(0, func(p Point) Point {
+		return Point{
+			X: sdf(Point{X: p.X + h, Y: p.Y, Z: p.Z}) - sdf(Point{X: p.X - h, Y: p.Y, Z: p.Z}),
+			Y: sdf(Point{X: p.X, Y: p.Y + h, Z: p.Z}) - sdf(Point{X: p.X, Y: p.Y - h, Z: p.Z}),
+			Z: sdf(Point{X: p.X, Y: p.Y, Z: p.Z + h}) - sdf(Point{X: p.X, Y: p.Y, Z: p.Z - h}),
+		}
+	})
+}
+
+// ============================================================================
+// DUAL CONTOURING
+// ============================================================================
+
+// cellCorners are the offsets of a cell's corners, bit i of a corner index
+// selecting +1 on axis i
+var cellCorners = [8][3]int{
+	{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0},
+	{0, 0, 1}, {1, 0, 1}, {0, 1, 1}, {1, 1, 1},
+}
+
+// cellEdges are a cell's 12 edges as corner pairs
+var cellEdges = [12][2]int{
+	{0, 1}, {2, 3}, {4, 5}, {6, 7}, // Along X
+	{0, 2}, {1, 3}, {4, 6}, {5, 7}, // Along Y
+	{0, 4}, {1, 5}, {2, 6}, {3, 7}, // Along Z
+}
+
+// cellFaces are a cell's 6 faces as corners in order around the face
+var cellFaces = [6][4]int{
+	{0, 2, 6, 4}, {1, 3, 7, 5}, // X = 0, 1
+	{0, 1, 5, 4}, {2, 3, 7, 6}, // Y = 0, 1
+	{0, 1, 3, 2}, {4, 5, 7, 6}, // Z = 0, 1
+}
+
+// cellFaceEdges are the edges around each face, edge i joining the face's
+// corners i and i+1
+var cellFaceEdges = [6][4]int{
+	{4, 10, 6, 8}, {5, 11, 7, 9},
+	{0, 9, 2, 8}, {1, 11, 3, 10},
+	{0, 5, 1, 4}, {2, 7, 3, 6},
+}
+
+// cellSurfaceComponents groups a cell's crossed edges by the sheet of
+// surface passing through them, returning a group id per edge. On each face
+// the surface joins crossings next to the same corner; a face with all four
+// edges crossed is ambiguous and is settled by the value at its centre,
+// which both cells sharing the face see the same way, unless its bit in
+// flipped (one per cellFaces entry) asks for the other pairing. doubled
+// reports the ambiguous faces whose two crossings still end up in one sheet.
+func cellSurfaceComponents(values [8]float64, isoLevel float64, flipped uint8) (group [12]int, crossed [12]bool, doubled uint8) {
+	for i, e := range cellEdges {
+		group[i] = i
+		crossed[i] = (values[e[0]] > isoLevel) != (values[e[1]] > isoLevel)
+	}
+	find := func(i int) int {
+		for group[i] != i {
+			i = group[i]
+		}
+		return i
+	}
+	join := func(a, b int) { group[find(a)] = find(b) }
+
+	for f, face := range cellFaces {
+		edges := cellFaceEdges[f]
+		count := 0
+		center := 0.0
+		for i := 0; i < 4; i++ {
+			if crossed[edges[i]] {
+				count++
+			}
+			center += values[face[i]] / 4
+		}
+
+		switch count {
+		case 2:
+			first := -1
+			for _, e := range edges {
+				if !crossed[e] {
+					continue
+				}
+				if first < 0 {
+					first = e
+				} else {
+					join(first, e)
+				}
+			}
+		case 4:
+			// Solid corners are connected through the centre when it is
+			// solid, so the surface cuts off the empty corners, and the
+			// other way round
+			cutSolid := center <= isoLevel
+			if flipped&(1<<f) != 0 {
+				cutSolid = !cutSolid
+			}
+			for i := 0; i < 4; i++ {
+				if (values[face[i]] > isoLevel) == cutSolid {
+					join(edges[(i+3)%4], edges[i])
+				}
+			}
+		}
+	}
+
+	for i := range group {
+		group[i] = find(i)
+	}
+	for f, edges := range cellFaceEdges {
+		if crossed[edges[0]] && crossed[edges[1]] && crossed[edges[2]] && crossed[edges[3]] &&
+			group[edges[0]] == group[edges[1]] && group[edges[0]] == group[edges[2]] {
+			doubled |= 1 << f
+		}
+	}
+	return group, crossed, doubled
+}
+
+// contour runs dual contouring. normalAt returns an outward (not
+// necessarily unit) normal at a point.
+func (g *DensityGrid) contour(isoLevel float64, normalAt func(Point) Point) *Mesh {
+	mesh := NewMesh()
+	mesh.Normals = make([]Point, 0)
+	cx, cy, cz := g.NX-1, g.NY-1, g.NZ-1
+	if cx < 1 || cy < 1 || cz < 1 {
+		return mesh
+	}
+
+	cellIndex := func(x, y, z int) int { return (z*cy+y)*cx + x }
+	// Per cell, an index into edgeVertices, which gives the vertex used for
+	// each of the cell's crossed edges
+	cellVertex := make([]int, cx*cy*cz)
+	var edgeVertices [][12]int
+
+	cornerValues := func(x, y, z int) (values [8]float64, active bool) {
+		solid := 0
+		for i, c := range cellCorners {
+			values[i] = g.At(x+c[0], y+c[1], z+c[2])
+			if values[i] > isoLevel {
+				solid++
+			}
+		}
+		return values, solid > 0 && solid < 8
+	}
+
+	// An ambiguous face whose crossings fall in one sheet on both sides
+	// would join the same two vertices twice, making those edges
+	// non-manifold, so both cells take the face's other pairing instead.
+	// Faces are settled one at a time since a cell's sheets depend on all
+	// of its faces.
+	flipped := make(map[int]uint8)
+	doubledFaces := func(x, y, z int) uint8 {
+		values, active := cornerValues(x, y, z)
+		if !active {
+			return 0
+		}
+		_, _, doubled := cellSurfaceComponents(values, isoLevel, flipped[cellIndex(x, y, z)])
+		return doubled
+	}
+	for z := 0; z < cz; z++ {
+		for y := 0; y < cy; y++ {
+			for x := 0; x < cx; x++ {
+				doubled := doubledFaces(x, y, z)
+				for axis := 0; axis < 3 && doubled != 0; axis++ {
+					lower, upper := uint8(1)<<(2*axis), uint8(1)<<(2*axis+1)
+					if doubled&upper == 0 {
+						continue
+					}
+					n := [3]int{x, y, z}
+					n[axis]++
+					if n[axis] >= [3]int{cx, cy, cz}[axis] || doubledFaces(n[0], n[1], n[2])&lower == 0 {
+						continue
+					}
+					flipped[cellIndex(x, y, z)] ^= upper
+					flipped[cellIndex(n[0], n[1], n[2])] ^= lower
+					doubled = doubledFaces(x, y, z)
+				}
+			}
+		}
+	}
+
+	var crossings, crossingNormals [12]Point
+	var points, normals []Point
+	for z := 0; z < cz; z++ {
+		for y := 0; y < cy; y++ {
+			for x := 0; x < cx; x++ {
+				cellVertex[cellIndex(x, y, z)] = -1
+
+				values, active := cornerValues(x, y, z)
+				if !active {
+					continue
+				}
+				group, crossed, _ := cellSurfaceComponents(values, isoLevel, flipped[cellIndex(x, y, z)])
+
+				// Where the surface crosses the cell's edges, and its facing there
+				for i, e := range cellEdges {
+					if !crossed[i] {
+						continue
+					}
+					v0, v1 := values[e[0]], values[e[1]]
+					c0, c1 := cellCorners[e[0]], cellCorners[e[1]]
+					p0 := g.Position(x+c0[0], y+c0[1], z+c0[2])
+					p1 := g.Position(x+c1[0], y+c1[1], z+c1[2])
+					crossings[i] = lerpPoint(p0, p1, (isoLevel-v0)/(v1-v0))
+					n := normalAt(crossings[i])
+					if l := pointLength(n); l > 0 {
+						n = scalePoint(n, 1/l)
+					}
+					crossingNormals[i] = n
+				}
+
+				// Vertices stay just inside the cell, so one clamped towards a
+				// shared face doesn't meet its neighbour's and pinch the mesh
+				inset := Point{X: g.CellSize * 1e-3, Y: g.CellSize * 1e-3, Z: g.CellSize * 1e-3}
+				cellMin := addPoints(g.Position(x, y, z), inset)
+				cellMax := subPoints(g.Position(x+1, y+1, z+1), inset)
+
+				// The surface may pass through the cell as several separate
+				// sheets; each gets its own vertex or the mesh pinches there
+				var vertexOf [12]int
+				for i := range vertexOf {
+					vertexOf[i] = -1
+				}
+				for first := range cellEdges {
+					if !crossed[first] || vertexOf[first] >= 0 {
+						continue
+					}
+					points, normals = points[:0], normals[:0]
+					for i := range cellEdges {
+						if crossed[i] && group[i] == group[first] {
+							points = append(points, crossings[i])
+							normals = append(normals, crossingNormals[i])
+						}
+					}
+
+					vertex := solveContourVertex(points, normals, cellMin, cellMax)
+					// Between two close sheets the gradient may be the other
+					// one's, so it has to agree with this sheet's crossings
+					facing := Point{}
+					for _, n := range normals {
+						facing = addPoints(facing, n)
+					}
+					normal := normalAt(vertex)
+					if pointLength(normal) == 0 || dotPoints(normal, facing) < 0 {
+						normal = facing
+					}
+					if pointLength(normal) > 0 {
+						normal = normalizePoint(normal)
+					}
+
+					for i := range cellEdges {
+						if crossed[i] && group[i] == group[first] {
+							vertexOf[i] = len(mesh.Vertices)
+						}
+					}
+					mesh.Vertices = append(mesh.Vertices, vertex)
+					mesh.Normals = append(mesh.Normals, normal)
+				}
+
+				cellVertex[cellIndex(x, y, z)] = len(edgeVertices)
+				edgeVertices = append(edgeVertices, vertexOf)
+			}
+		}
+	}
+
+	// One quad per crossed grid edge, joining the four cells sharing it.
+	// For an edge along axis a the cells are walked around the other two
+	// axes in order, which faces the quad along +a. Each cell contributes
+	// the vertex of the sheet crossing that edge, found by the edge's index
+	// within the cell.
+	vertexAt := func(x, y, z, edge int) int {
+		return edgeVertices[cellVertex[cellIndex(x, y, z)]][edge]
+	}
+	// Sheets in one cell can still be clamped onto the same corner,
+	// collapsing a triangle to a line; those are dropped, leaving the mesh
+	// closed once the coincident vertices are welded
+	degenerate := g.CellSize * 1e-9
+	addTriangle := func(i0, i1, i2 int) {
+		if !triangleIsDegenerate(mesh.Vertices[i0], mesh.Vertices[i1], mesh.Vertices[i2], degenerate) {
+			mesh.AddTriangleIndices(i0, i1, i2)
+		}
+	}
+	emit := func(q [4]int, flip bool) {
+		if flip {
+			q[1], q[3] = q[3], q[1]
+		}
+		a, b, c, d := mesh.Vertices[q[0]], mesh.Vertices[q[1]], mesh.Vertices[q[2]], mesh.Vertices[q[3]]
+		// Split along the shorter diagonal
+		if pointLength(subPoints(b, d)) < pointLength(subPoints(a, c)) {
+			addTriangle(q[0], q[1], q[3])
+			addTriangle(q[1], q[2], q[3])
+		} else {
+			addTriangle(q[0], q[1], q[2])
+			addTriangle(q[0], q[2], q[3])
+		}
+	}
+	for z := 0; z < g.NZ; z++ {
+		for y := 0; y < g.NY; y++ {
+			for x := 0; x < g.NX; x++ {
+				inside := g.At(x, y, z) > isoLevel
+
+				// Along X: cells around it in Y then Z
+				if x < cx && y > 0 && y < cy && z > 0 && z < cz && inside != (g.At(x+1, y, z) > isoLevel) {
+					emit([4]int{
+						vertexAt(x, y-1, z-1, 3), vertexAt(x, y, z-1, 2),
+						vertexAt(x, y, z, 0), vertexAt(x, y-1, z, 1),
+					}, !inside)
+				}
+				// Along Y: cells around it in Z then X
+				if y < cy && x > 0 && x < cx && z > 0 && z < cz && inside != (g.At(x, y+1, z) > isoLevel) {
+					emit([4]int{
+						vertexAt(x-1, y, z-1, 7), vertexAt(x-1, y, z, 5),
+						vertexAt(x, y, z, 4), vertexAt(x, y, z-1, 6),
+					}, !inside)
+				}
+				// Along Z: cells around it in X then Y
+				if z < cz && x > 0 && x < cx && y > 0 && y < cy && inside != (g.At(x, y, z+1) > isoLevel) {
+					emit([4]int{
+						vertexAt(x-1, y-1, z, 11), vertexAt(x, y-1, z, 10),
+						vertexAt(x, y, z, 8), vertexAt(x-1, y, z, 9),
+					}, !inside)
+				}
+			}
+		}
+	}
+
+	return mesh
+}
+
+// solveContourVertex finds the point closest to every plane through the
+// edge crossings, pulled slightly towards their average so flat and
+// degenerate cells stay well behaved, and kept inside the cell
+func solveContourVertex(points, normals []Point, cellMin, cellMax Point) Point {
+	mass := Point{}
+	for _, p := range points {
+		mass = addPoints(mass, p)
+	}
+	mass = scalePoint(mass, 1/float64(len(points)))
+
+	// Least squares on the planes, relative to the mass point:
+	// (AᵀA + λI) x = Aᵀb
+	const bias = 0.05
+	var ata [3][3]float64
+	var atb [3]float64
+	for i, n := range normals {
+		nv := [3]float64{n.X, n.Y, n.Z}
+		d := dotPoints(n, subPoints(points[i], mass))
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				ata[r][c] += nv[r] * nv[c]
+			}
+			atb[r] += nv[r] * d
+		}
+	}
+	for i := 0; i < 3; i++ {
+		ata[i][i] += bias
+	}
+
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+	d := det(ata)
+	var x [3]float64
+	for col := 0; col < 3; col++ {
+		m := ata
+		for r := 0; r < 3; r++ {
+			m[r][col] = atb[r]
+		}
+		x[col] = det(m) / d
+	}
+
+	return Point{
+		X: clamp(mass.X+x[0], cellMin.X, cellMax.X),
+		Y: clamp(mass.Y+x[1], cellMin.Y, cellMax.Y),
+		Z: clamp(mass.Z+x[2], cellMin.Z, cellMax.Z),
+	}
+}
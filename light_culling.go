@@ -0,0 +1,145 @@
+package main
+
+import "math"
+
+// ============================================================================
+// TILED LIGHT CULLING
+// ============================================================================
+// The screen is split into square tiles and every light with a Range is
+// assigned to the tiles its bounding sphere covers. Shading a pixel then only
+// evaluates the lights of its tile. Lights without a range reach every tile.
+// ============================================================================
+
+const DefaultLightTileSize = 8 // Tile edge in screen cells
+
+// LightGrid holds the lights affecting each screen tile
+type LightGrid struct {
+	TileSize      int
+	Width, Height int
+	Cols, Rows    int
+	Tiles         [][]*Light // Row-major, Cols*Rows entries
+}
+
+// NewLightGrid creates a light grid covering a screen of the given size
+func NewLightGrid(width, height, tileSize int) *LightGrid {
+	tileSize = maxInt(tileSize, 1)
+	cols := maxInt((width+tileSize-1)/tileSize, 1)
+	rows := maxInt((height+tileSize-1)/tileSize, 1)
+
+	tiles := make([][]*Light, cols*rows)
+	for i := range tiles {
+		tiles[i] = make([]*Light, 0, 4)
+	}
+
+	return &LightGrid{
+		TileSize: tileSize,
+		Width:    width,
+		Height:   height,
+		Cols:     cols,
+		Rows:     rows,
+		Tiles:    tiles,
+	}
+}
+
+// Build assigns the enabled lights to the tiles they can reach from the camera
+func (g *LightGrid) Build(lights []*Light, camera *Camera) {
+	for i := range g.Tiles {
+		g.Tiles[i] = g.Tiles[i][:0]
+	}
+
+	for _, light := range lights {
+		if !light.IsEnabled {
+			continue
+		}
+
+		minX, minY, maxX, maxY := 0, 0, g.Width-1, g.Height-1
+		if light.Range > 0 && camera != nil {
+			var visible bool
+			minX, minY, maxX, maxY, visible = g.screenBounds(light, camera)
+			if !visible {
+				continue
+			}
+		}
+
+		for ty := minY / g.TileSize; ty <= maxY/g.TileSize; ty++ {
+			for tx := minX / g.TileSize; tx <= maxX/g.TileSize; tx++ {
+				tile := ty*g.Cols + tx
+				g.Tiles[tile] = append(g.Tiles[tile], light)
+			}
+		}
+	}
+}
+
+// screenBounds returns the clamped screen rectangle covered by a light's range
+// sphere, using the same projection as Camera.ProjectPoint. The rectangle is
+// conservative: it bounds the projection of the sphere's view-space box.
+func (g *LightGrid) screenBounds(light *Light, camera *Camera) (int, int, int, int, bool) {
+	center := camera.TransformToViewSpace(light.Position)
+	r := light.Range
+
+	if center.Z+r <= camera.Near {
+		return 0, 0, 0, 0, false
+	}
+	if center.Z-r <= camera.Near {
+		// Sphere reaches the camera plane: it may cover any pixel
+		return 0, 0, g.Width - 1, g.Height - 1, true
+	}
+
+	minPX, maxPX := math.Inf(1), math.Inf(-1)
+	minPY, maxPY := math.Inf(1), math.Inf(-1)
+	for _, z := range [2]float64{center.Z - r, center.Z + r} {
+		for _, sign := range [2]float64{-1, 1} {
+			px := (center.X + sign*r) * camera.FOV.X / z
+			py := (center.Y + sign*r) * camera.FOV.Y / z
+			minPX, maxPX = math.Min(minPX, px), math.Max(maxPX, px)
+			minPY, maxPY = math.Min(minPY, py), math.Max(maxPY, py)
+		}
+	}
+
+	// Screen Y grows downward; one cell of margin covers integer truncation
+	minX := g.Width/2 + int(math.Floor(minPX))*ASPECT_RATIO - 1
+	maxX := g.Width/2 + int(math.Ceil(maxPX))*ASPECT_RATIO + 1
+	minY := g.Height/2 - int(math.Ceil(maxPY)) - 1
+	maxY := g.Height/2 - int(math.Floor(minPY)) + 1
+
+	if maxX < 0 || maxY < 0 || minX >= g.Width || minY >= g.Height {
+		return 0, 0, 0, 0, false
+	}
+
+	return clampInt(minX, 0, g.Width-1), clampInt(minY, 0, g.Height-1),
+		clampInt(maxX, 0, g.Width-1), clampInt(maxY, 0, g.Height-1), true
+}
+
+// LightsAt returns the lights assigned to the tile containing a screen cell
+func (g *LightGrid) LightsAt(x, y int) []*Light {
+	tx := clampInt(x/g.TileSize, 0, g.Cols-1)
+	ty := clampInt(y/g.TileSize, 0, g.Rows-1)
+	return g.Tiles[ty*g.Cols+tx]
+}
+
+// Stats returns the largest and average number of lights per tile
+func (g *LightGrid) Stats() (maxLights int, avgLights float64) {
+	total := 0
+	for _, tile := range g.Tiles {
+		total += len(tile)
+		maxLights = maxInt(maxLights, len(tile))
+	}
+	return maxLights, float64(total) / float64(len(g.Tiles))
+}
+
+// HeatmapColor maps a light count to a blue (none) to red (maxLights) ramp
+func HeatmapColor(count, maxLights int) Color {
+	if count == 0 || maxLights == 0 {
+		return Color{0, 0, 80}
+	}
+
+	t := clampFloat(float64(count)/float64(maxLights), 0, 1)
+	switch {
+	case t < 0.33:
+		return ColorBlue.Lerp(ColorGreen, t/0.33)
+	case t < 0.66:
+		return ColorGreen.Lerp(ColorYellow, (t-0.33)/0.33)
+	default:
+		return ColorYellow.Lerp(ColorRed, (t-0.66)/0.34)
+	}
+}
@@ -2,12 +2,25 @@ package main
 
 import "math"
 
+// LightType selects how a light casts shadows
+type LightType int
+
+const (
+	LightTypeDirectional LightType = iota // Single orthographic shadow map aimed at the scene
+	LightTypePoint                        // Omnidirectional cube shadow map around the light
+)
+
 // Light represents a light source in 3D space
 type Light struct {
-	Position  Point   // Position in world space
-	Color     Color   // Light color
-	Intensity float64 // Light intensity (0.0 to 1.0+)
-	IsEnabled bool    // Whether this light is active
+	Position  Point     // Position in world space
+	Color     Color     // Light color
+	Intensity float64   // Light intensity (0.0 to 1.0+)
+	IsEnabled bool      // Whether this light is active
+	Type      LightType // Shadow projection used for this light
+	Range     float64   // Distance beyond which the light has no effect (0 = unbounded)
+
+	CastShadows bool           // Whether this light renders a shadow map
+	Shadow      ShadowSettings // Bias, normal offset and filter for this light's shadows
 }
 
 // LightingSystem manages all lights and performs lighting calculations
@@ -31,13 +44,22 @@ type cacheKey struct {
 // NewLight creates a new light source
 func NewLight(x, y, z float64, color Color, intensity float64) *Light {
 	return &Light{
-		Position:  Point{X: x, Y: y, Z: z},
-		Color:     color,
-		Intensity: intensity,
-		IsEnabled: true,
+		Position:    Point{X: x, Y: y, Z: z},
+		Color:       color,
+		Intensity:   intensity,
+		IsEnabled:   true,
+		CastShadows: true,
+		Shadow:      DefaultShadowSettings(),
 	}
 }
 
+// NewPointLight creates a light that casts shadows in all directions
+func NewPointLight(x, y, z float64, color Color, intensity float64) *Light {
+	light := NewLight(x, y, z, color, intensity)
+	light.Type = LightTypePoint
+	return light
+}
+
 func NewWireframeMaterial(color Color) Material {
 	m := NewMaterial()
 	m.Wireframe = true
@@ -73,6 +95,32 @@ func (ls *LightingSystem) CalculateLighting(
 	normal Point,
 	material IMaterial,
 	ambientOcclusion float64,
+) Color {
+	return ls.CalculateLightingWithShadows(surfacePoint, normal, material, ambientOcclusion, nil)
+}
+
+// CalculateLightingWithShadows is CalculateLighting with a per-light shadow
+// factor (0 = fully shadowed, 1 = lit) applied to each light's diffuse and
+// specular terms. A nil callback leaves every light unshadowed.
+func (ls *LightingSystem) CalculateLightingWithShadows(
+	surfacePoint Point,
+	normal Point,
+	material IMaterial,
+	ambientOcclusion float64,
+	shadowCallback func(*Light, Point) float64,
+) Color {
+	return ls.CalculateLightingForLights(ls.Lights, surfacePoint, normal, material, ambientOcclusion, shadowCallback)
+}
+
+// CalculateLightingForLights shades a point with only the given lights (for
+// example the lights a LightGrid assigned to its screen tile) plus ambient
+func (ls *LightingSystem) CalculateLightingForLights(
+	lights []*Light,
+	surfacePoint Point,
+	normal Point,
+	material IMaterial,
+	ambientOcclusion float64,
+	shadowCallback func(*Light, Point) float64,
 ) Color {
 	// Clamp AO to valid range
 	if ambientOcclusion < 0 {
@@ -107,7 +155,7 @@ func (ls *LightingSystem) CalculateLighting(
 	}
 
 	// Accumulate contributions from each light
-	for _, light := range ls.Lights {
+	for _, light := range lights {
 		if !light.IsEnabled {
 			continue
 		}
@@ -119,6 +167,9 @@ func (ls *LightingSystem) CalculateLighting(
 
 		// Calculate distance for attenuation
 		distance := math.Sqrt(lightDirX*lightDirX + lightDirY*lightDirY + lightDirZ*lightDirZ)
+		if light.Range > 0 && distance >= light.Range {
+			continue
+		}
 
 		// Prevent division by zero
 		if distance < 0.001 {
@@ -136,14 +187,14 @@ func (ls *LightingSystem) CalculateLighting(
 		}
 
 		// Apply light attenuation (inverse square law)
-		attenuation := 1.0 / (ATTENUATION_CONSTANT + ATTENUATION_LINEAR*distance + ATTENUATION_QUADRATIC*distance*distance)
-
-		// Clamp attenuation to reasonable range
-		if attenuation > 1.0 {
-			attenuation = 1.0
-		}
-		if attenuation < 0 {
-			attenuation = 0
+		attenuation := light.Attenuation(distance)
+
+		// Occluded lights only contribute ambient
+		if shadowCallback != nil {
+			attenuation *= shadowCallback(light, surfacePoint)
+			if attenuation <= 0 {
+				continue
+			}
 		}
 
 		diffuseIntensity *= light.Intensity * attenuation
@@ -234,6 +285,31 @@ func (ls *LightingSystem) CalculateLighting(
 	}
 }
 
+// lightAttenuation returns the distance falloff of a light, clamped to [0, 1]
+func lightAttenuation(distance float64) float64 {
+	attenuation := 1.0 / (ATTENUATION_CONSTANT + ATTENUATION_LINEAR*distance + ATTENUATION_QUADRATIC*distance*distance)
+	return clampFloat(attenuation, 0, 1)
+}
+
+// Attenuation returns the light's falloff at a distance, faded to zero at its Range
+func (l *Light) Attenuation(distance float64) float64 {
+	return lightAttenuation(distance) * rangeWindow(distance, l.Range)
+}
+
+// rangeWindow smoothly fades a light to zero at its range so culling it
+// beyond that distance causes no visible cutoff (1 for unbounded lights)
+func rangeWindow(distance, lightRange float64) float64 {
+	if lightRange <= 0 {
+		return 1.0
+	}
+	x := distance / lightRange
+	if x >= 1.0 {
+		return 0.0
+	}
+	w := 1.0 - x*x*x*x
+	return w * w
+}
+
 // CalculateSimpleAO calculates a simple ambient occlusion term
 // based on the angle between the normal and "up" direction
 // This is a very simplified approximation - real AO would require ray tracing
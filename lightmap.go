@@ -0,0 +1,734 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// LIGHTMAP BAKING
+// ============================================================================
+// Static meshes get a second UV set (one chart per triangle, packed into a
+// square texture) and a lightmap holding the light arriving at each texel:
+// direct light with soft shadows from jittered shadow rays, plus indirect
+// light gathered with cosine-weighted hemisphere rays. Rays are traced with
+// Scene.RaycastBVH. At runtime the renderer multiplies the surface albedo by
+// the lightmap instead of evaluating the lights.
+// ============================================================================
+
+// lightmapPadding is the gutter in texels around every chart
+const lightmapPadding = 2
+
+// LightmapSettings controls bake quality
+type LightmapSettings struct {
+	Resolution      int     // Lightmap width and height in texels
+	DirectSamples   int     // Shadow rays per light, spread over the light's Shadow.LightSize
+	IndirectSamples int     // Hemisphere rays per texel
+	Bounces         int     // Indirect bounces (0 = direct light and sky only)
+	MaxDistance     float64 // Longest ray; misses see the ambient light
+	RayBias         float64 // Offset along the normal to avoid self-intersection
+	Workers         int     // Parallel bake workers
+	Seed            int64   // Random seed, bakes are reproducible
+}
+
+// DefaultLightmapSettings returns settings suited to small static scenes
+func DefaultLightmapSettings() LightmapSettings {
+	return LightmapSettings{
+		Resolution:      64,
+		DirectSamples:   8,
+		IndirectSamples: 32,
+		Bounces:         1,
+		MaxDistance:     500.0,
+		RayBias:         0.01,
+		Workers:         4,
+		Seed:            1,
+	}
+}
+
+// LightmapBaker bakes the lighting of a scene into per-mesh lightmaps
+type LightmapBaker struct {
+	Scene    *Scene
+	Lighting *LightingSystem
+	Settings LightmapSettings
+
+	bvh *BVH
+}
+
+// NewLightmapBaker creates a baker for a scene lit by a lighting system
+func NewLightmapBaker(scene *Scene, lighting *LightingSystem, settings LightmapSettings) *LightmapBaker {
+	return &LightmapBaker{
+		Scene:    scene,
+		Lighting: lighting,
+		Settings: settings,
+	}
+}
+
+// BakeScene bakes every mesh in the scene. Nodes sharing a mesh share its
+// lightmap, so only the first of them is baked.
+func (b *LightmapBaker) BakeScene() (map[*Mesh]*Texture, error) {
+	b.bvh = b.Scene.BuildBVH()
+	baked := make(map[*Mesh]*Texture)
+
+	for _, node := range b.Scene.GetRenderableNodes() {
+		mesh, ok := node.Object.(*Mesh)
+		if !ok || baked[mesh] != nil {
+			continue
+		}
+		tex, err := b.bakeNode(node, mesh)
+		if err != nil {
+			return baked, fmt.Errorf("bake %s: %w", node.Name, err)
+		}
+		baked[mesh] = tex
+	}
+
+	return baked, nil
+}
+
+// BakeNode bakes a single mesh node against the rest of the scene
+func (b *LightmapBaker) BakeNode(node *SceneNode) (*Texture, error) {
+	mesh, ok := node.Object.(*Mesh)
+	if !ok {
+		return nil, fmt.Errorf("node %s is not a mesh", node.Name)
+	}
+	b.bvh = b.Scene.BuildBVH()
+	return b.bakeNode(node, mesh)
+}
+
+// bakeNode unwraps the mesh, fills every texel covered by a chart and
+// attaches the result to the mesh
+func (b *LightmapBaker) bakeNode(node *SceneNode, mesh *Mesh) (*Texture, error) {
+	res := b.Settings.Resolution
+	if err := GenerateLightmapUVs(mesh, res); err != nil {
+		return nil, err
+	}
+
+	// World-space triangles, transformed the same way Scene.Raycast does
+	worldMatrix := node.Transform.GetWorldMatrix()
+	world := make([]Point, len(mesh.Vertices))
+	for i, v := range mesh.Vertices {
+		world[i] = worldMatrix.TransformPoint(Point{
+			X: v.X + mesh.Position.X,
+			Y: v.Y + mesh.Position.Y,
+			Z: v.Z + mesh.Position.Z,
+		})
+	}
+
+	light := make([]Point, res*res) // Linear light per texel
+	covered := make([]bool, res*res)
+
+	triangles := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < maxInt(b.Settings.Workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tri := range triangles {
+				b.bakeTriangle(mesh, world, tri, light, covered)
+			}
+		}()
+	}
+	for tri := 0; tri+2 < len(mesh.Indices); tri += 3 {
+		triangles <- tri
+	}
+	close(triangles)
+	wg.Wait()
+
+	dilateLightmap(light, covered, res, lightmapPadding)
+
+	tex := NewTexture(res, res)
+	for i, l := range light {
+		tex.Data[i] = Color{
+			R: uint8(clampFloat(l.X, 0, 1)*255 + 0.5),
+			G: uint8(clampFloat(l.Y, 0, 1)*255 + 0.5),
+			B: uint8(clampFloat(l.Z, 0, 1)*255 + 0.5),
+		}
+	}
+
+	mesh.Lightmap = tex
+	return tex, nil
+}
+
+// bakeTriangle evaluates lighting for every texel of one triangle's chart.
+// Texels within a texel of the chart (the gutter) take the nearest point on
+// the triangle, so bilinear filtering never reads unlit texels at edges.
+func (b *LightmapBaker) bakeTriangle(mesh *Mesh, world []Point, tri int, light []Point, covered []bool) {
+	res := b.Settings.Resolution
+	fres := float64(res)
+	p0, p1, p2 := world[mesh.Indices[tri]], world[mesh.Indices[tri+1]], world[mesh.Indices[tri+2]]
+	normal := CalculateSurfaceNormal(&p0, &p1, &p2, nil, false)
+
+	// Chart corners in texel space
+	uv := mesh.LightmapUVs[tri : tri+3]
+	ax, ay := uv[0].U*fres, uv[0].V*fres
+	bx, by := uv[1].U*fres, uv[1].V*fres
+	cx, cy := uv[2].U*fres, uv[2].V*fres
+
+	minX := clampInt(int(math.Floor(math.Min(ax, math.Min(bx, cx))))-1, 0, res-1)
+	maxX := clampInt(int(math.Ceil(math.Max(ax, math.Max(bx, cx))))+1, 0, res-1)
+	minY := clampInt(int(math.Floor(math.Min(ay, math.Min(by, cy))))-1, 0, res-1)
+	maxY := clampInt(int(math.Ceil(math.Max(ay, math.Max(by, cy))))+1, 0, res-1)
+
+	rng := rand.New(rand.NewSource(b.Settings.Seed + int64(tri)))
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			w0, w1, w2 := closestBarycentric2D(px, py, ax, ay, bx, by, cx, cy)
+
+			// Skip texels farther than one texel from the chart
+			qx := w0*ax + w1*bx + w2*cx
+			qy := w0*ay + w1*by + w2*cy
+			if (qx-px)*(qx-px)+(qy-py)*(qy-py) > 1.0 {
+				continue
+			}
+
+			pos := Point{
+				X: w0*p0.X + w1*p1.X + w2*p2.X,
+				Y: w0*p0.Y + w1*p1.Y + w2*p2.Y,
+				Z: w0*p0.Z + w1*p1.Z + w2*p2.Z,
+			}
+			light[y*res+x] = b.irradiance(pos, normal, b.Settings.Bounces, b.Settings.IndirectSamples, rng)
+			covered[y*res+x] = true
+		}
+	}
+}
+
+// irradiance returns the light reaching a surface point, in the same units
+// as CalculateLighting's diffuse term (multiply by albedo for the final color)
+func (b *LightmapBaker) irradiance(pos, normal Point, bounces, samples int, rng *rand.Rand) Point {
+	origin := offsetAlongNormal(pos, normal, b.Settings.RayBias)
+	total := b.directLight(origin, normal, rng)
+
+	if samples <= 0 {
+		return total
+	}
+
+	ambient := Point{}
+	if b.Lighting != nil {
+		ambient = Point{
+			X: float64(b.Lighting.AmbientLight.R) / 255.0 * b.Lighting.AmbientIntensity,
+			Y: float64(b.Lighting.AmbientLight.G) / 255.0 * b.Lighting.AmbientIntensity,
+			Z: float64(b.Lighting.AmbientLight.B) / 255.0 * b.Lighting.AmbientIntensity,
+		}
+	}
+
+	// Cosine-weighted sampling: the cosine and 1/pi of a Lambert surface
+	// cancel, so each sample contributes the incoming light directly
+	var gathered Point
+	for i := 0; i < samples; i++ {
+		dir := cosineSampleHemisphere(normal, rng)
+		hit := b.Scene.RaycastBVH(b.bvh, NewRay(origin, dir), b.Settings.MaxDistance)
+		if !hit.Hit {
+			gathered = addPoints(gathered, ambient)
+			continue
+		}
+		if bounces <= 0 {
+			continue
+		}
+
+		// Raycast normals follow the winding; face them back along the ray
+		hitNormal := hit.Normal
+		if dotProduct(hitNormal.X, hitNormal.Y, hitNormal.Z, dir.X, dir.Y, dir.Z) > 0 {
+			hitNormal = Point{X: -hitNormal.X, Y: -hitNormal.Y, Z: -hitNormal.Z}
+		}
+
+		// Deeper bounces use a single ray to keep the cost linear
+		albedo := surfaceAlbedo(hit.Node)
+		incoming := b.irradiance(hit.Point, hitNormal, bounces-1, minInt(samples, 1), rng)
+		gathered.X += albedo.X * incoming.X
+		gathered.Y += albedo.Y * incoming.Y
+		gathered.Z += albedo.Z * incoming.Z
+	}
+
+	inv := 1.0 / float64(samples)
+	total.X += gathered.X * inv
+	total.Y += gathered.Y * inv
+	total.Z += gathered.Z * inv
+	return total
+}
+
+// directLight sums the unshadowed part of every light's diffuse contribution.
+// Shadow rays target random points within the light's size for soft shadows.
+func (b *LightmapBaker) directLight(origin, normal Point, rng *rand.Rand) Point {
+	var total Point
+	if b.Lighting == nil {
+		return total
+	}
+
+	samples := maxInt(b.Settings.DirectSamples, 1)
+	for _, light := range b.Lighting.Lights {
+		if !light.IsEnabled {
+			continue
+		}
+
+		sum := 0.0
+		for s := 0; s < samples; s++ {
+			target := light.Position
+			if samples > 1 && light.Shadow.LightSize > 0 {
+				j := randomInUnitSphere(rng)
+				radius := light.Shadow.LightSize * 0.5
+				target = Point{X: target.X + j.X*radius, Y: target.Y + j.Y*radius, Z: target.Z + j.Z*radius}
+			}
+
+			dx, dy, dz := target.X-origin.X, target.Y-origin.Y, target.Z-origin.Z
+			distance := math.Sqrt(dx*dx + dy*dy + dz*dz)
+			if distance < 1e-6 || (light.Range > 0 && distance >= light.Range) {
+				continue
+			}
+			lx, ly, lz := dx/distance, dy/distance, dz/distance
+
+			nDotL := dotProduct(normal.X, normal.Y, normal.Z, lx, ly, lz)
+			if nDotL <= 0 {
+				continue
+			}
+
+			hit := b.Scene.RaycastBVH(b.bvh, Ray{Origin: origin, Direction: Point{X: lx, Y: ly, Z: lz}}, distance)
+			if hit.Hit {
+				continue
+			}
+			sum += nDotL * light.Intensity * light.Attenuation(distance)
+		}
+
+		sum /= float64(samples)
+		total.X += sum * float64(light.Color.R) / 255.0
+		total.Y += sum * float64(light.Color.G) / 255.0
+		total.Z += sum * float64(light.Color.B) / 255.0
+	}
+
+	return total
+}
+
+// SampleLightmap returns the baked light at a world-space point on a
+// triangle with a lightmap
+func SampleLightmap(t *Triangle, p Point) Color {
+	w0, w1, w2 := barycentric3D(p, t.P0, t.P1, t.P2)
+	u := w0*t.LightmapUV0.U + w1*t.LightmapUV1.U + w2*t.LightmapUV2.U
+	v := w0*t.LightmapUV0.V + w1*t.LightmapUV1.V + w2*t.LightmapUV2.V
+	return t.Lightmap.Sample(u, v, FilterLinear, WrapClamp)
+}
+
+// ApplyLightmap modulates a surface color by baked light
+func ApplyLightmap(albedo, light Color) Color {
+	return Color{
+		R: uint8(float64(albedo.R) * float64(light.R) / 255.0),
+		G: uint8(float64(albedo.G) * float64(light.G) / 255.0),
+		B: uint8(float64(albedo.B) * float64(light.B) / 255.0),
+	}
+}
+
+// ============================================================================
+// LIGHTMAP UV GENERATION
+// ============================================================================
+
+// GenerateLightmapUVs fills mesh.LightmapUVs with one chart per triangle,
+// flattened in its own plane at a uniform texel density and shelf-packed into
+// a resolution x resolution lightmap. The layout only depends on the mesh and
+// the resolution.
+func GenerateLightmapUVs(mesh *Mesh, resolution int) error {
+	triCount := len(mesh.Indices) / 3
+	if triCount == 0 {
+		return fmt.Errorf("mesh has no triangles")
+	}
+
+	type chart struct {
+		tri           int
+		corners       [3][2]float64 // Flattened corner positions (min corner at 0, 0)
+		width, height float64
+	}
+
+	charts := make([]chart, triCount)
+	area := 0.0
+	for t := 0; t < triCount; t++ {
+		a := mesh.Vertices[mesh.Indices[t*3]]
+		b := mesh.Vertices[mesh.Indices[t*3+1]]
+		c := mesh.Vertices[mesh.Indices[t*3+2]]
+
+		// Plane basis: u along the first edge, v towards the third corner
+		ex, ey, ez := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+		fx, fy, fz := c.X-a.X, c.Y-a.Y, c.Z-a.Z
+		length := math.Sqrt(ex*ex + ey*ey + ez*ez)
+
+		var cu, cv float64
+		if length > 1e-12 {
+			ux, uy, uz := ex/length, ey/length, ez/length
+			cu = dotProduct(fx, fy, fz, ux, uy, uz)
+			px, py, pz := fx-cu*ux, fy-cu*uy, fz-cu*uz
+			cv = math.Sqrt(px*px + py*py + pz*pz)
+		}
+
+		minU := math.Min(0, cu)
+		ch := chart{tri: t}
+		ch.corners = [3][2]float64{{-minU, 0}, {length - minU, 0}, {cu - minU, cv}}
+		ch.width = math.Max(length, cu) - minU
+		ch.height = cv
+		charts[t] = ch
+		area += ch.width * ch.height
+	}
+
+	sizes := make([][2]float64, triCount)
+	for t, ch := range charts {
+		sizes[t] = [2]float64{ch.width, ch.height}
+	}
+	scale, origins, ok := packCharts(sizes, area, resolution, lightmapPadding)
+	if !ok {
+		return fmt.Errorf("%d triangles do not fit a %dx%d lightmap", triCount, resolution, resolution)
+	}
+
+	uvs := make([]TextureCoord, len(mesh.Indices))
+	res := float64(resolution)
+	for t, ch := range charts {
+		for k := 0; k < 3; k++ {
+			uvs[t*3+k] = TextureCoord{
+				U: (float64(origins[t][0]) + ch.corners[k][0]*scale) / res,
+				V: (float64(origins[t][1]) + ch.corners[k][1]*scale) / res,
+			}
+		}
+	}
+	mesh.LightmapUVs = uvs
+
+	return nil
+}
+
+// packCharts shelf-packs charts of the given sizes (in world units, area their
+// total) into a resolution x resolution texture with pad texels around each.
+// It returns the texels per unit and each chart's texel origin, or false if
+// they do not fit at any scale.
+func packCharts(sizes [][2]float64, area float64, resolution, pad int) (float64, [][2]int, bool) {
+	// Tallest charts first packs shelves tightly
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return sizes[order[i]][1] > sizes[order[j]][1]
+	})
+
+	// Texels per unit; start from the area estimate and shrink until it fits
+	scale := math.Sqrt(0.5 * float64(resolution*resolution) / math.Max(area, 1e-12))
+	origins := make([][2]int, len(sizes))
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 200 || scale <= 0 {
+			return 0, nil, false
+		}
+
+		x, y, shelf := 0, 0, 0
+		fits := true
+		for _, idx := range order {
+			w := int(math.Ceil(sizes[idx][0]*scale)) + 2*pad
+			h := int(math.Ceil(sizes[idx][1]*scale)) + 2*pad
+			if x+w > resolution {
+				x, y, shelf = 0, y+shelf, 0
+			}
+			if w > resolution || y+h > resolution {
+				fits = false
+				break
+			}
+			origins[idx] = [2]int{x + pad, y + pad}
+			x += w
+			shelf = maxInt(shelf, h)
+		}
+		if fits {
+			return scale, origins, true
+		}
+		scale *= 0.9
+	}
+}
+
+// ============================================================================
+// LIGHTMAP FILES
+// ============================================================================
+
+// LightmapPath returns where the lightmap of a mesh file is stored
+// ("models/room.obj" -> "models/room_lightmap.png")
+func LightmapPath(meshPath string) string {
+	return strings.TrimSuffix(meshPath, filepath.Ext(meshPath)) + "_lightmap.png"
+}
+
+// LightmapLayoutPath returns where the UV layout of a mesh file's lightmap is
+// stored ("models/room.obj" -> "models/room_lightmap.uv")
+func LightmapLayoutPath(meshPath string) string {
+	return strings.TrimSuffix(meshPath, filepath.Ext(meshPath)) + "_lightmap.uv"
+}
+
+// lightmapLayoutMagic starts every lightmap layout file
+const lightmapLayoutMagic = "LMUV"
+
+// LightmapLayout is the lightmap UV layout a lightmap was baked with, and the
+// triangles it was baked for
+type LightmapLayout struct {
+	Centroids []Point        // Centre of each triangle in mesh space
+	UVs       []TextureCoord // Three per triangle, as in Mesh.LightmapUVs
+}
+
+// SaveLightmap writes a mesh's baked lightmap next to its mesh file, with the
+// UV layout it was baked with
+func SaveLightmap(mesh *Mesh, meshPath string) error {
+	if mesh.Lightmap == nil {
+		return fmt.Errorf("mesh has no lightmap")
+	}
+	triCount := len(mesh.Indices) / 3
+	if len(mesh.LightmapUVs) != triCount*3 {
+		return fmt.Errorf("mesh has %d lightmap UVs for %d triangles", len(mesh.LightmapUVs), triCount)
+	}
+
+	out := []byte(lightmapLayoutMagic)
+	out = binary.LittleEndian.AppendUint32(out, uint32(triCount))
+	putFloat := func(v float64) {
+		out = binary.LittleEndian.AppendUint64(out, math.Float64bits(v))
+	}
+	for t := 0; t < triCount; t++ {
+		c := triangleCentroid(mesh, t)
+		putFloat(c.X)
+		putFloat(c.Y)
+		putFloat(c.Z)
+		for _, uv := range mesh.LightmapUVs[t*3 : t*3+3] {
+			putFloat(uv.U)
+			putFloat(uv.V)
+		}
+	}
+	if err := os.WriteFile(LightmapLayoutPath(meshPath), out, 0644); err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	return SaveTextureToFile(mesh.Lightmap, LightmapPath(meshPath))
+}
+
+// LoadLightmapLayout reads a layout written by SaveLightmap
+func LoadLightmapLayout(path string) (*LightmapLayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file: %w", err)
+	}
+	if len(data) < 8 || string(data[:4]) != lightmapLayoutMagic {
+		return nil, fmt.Errorf("not a lightmap layout file")
+	}
+	triCount := int(binary.LittleEndian.Uint32(data[4:]))
+	const triangleSize = 9 * 8
+	if len(data)-8 != triCount*triangleSize {
+		return nil, fmt.Errorf("lightmap layout declares %d triangles but has %d bytes", triCount, len(data))
+	}
+
+	pos := 8
+	getFloat := func() float64 {
+		v := math.Float64frombits(binary.LittleEndian.Uint64(data[pos:]))
+		pos += 8
+		return v
+	}
+	layout := &LightmapLayout{
+		Centroids: make([]Point, triCount),
+		UVs:       make([]TextureCoord, triCount*3),
+	}
+	for t := 0; t < triCount; t++ {
+		layout.Centroids[t] = Point{X: getFloat(), Y: getFloat(), Z: getFloat()}
+		for k := 0; k < 3; k++ {
+			layout.UVs[t*3+k] = TextureCoord{U: getFloat(), V: getFloat()}
+		}
+	}
+	return layout, nil
+}
+
+// AttachLightmap gives a mesh a loaded lightmap and the UV layout it was baked
+// with. It refuses a layout baked for different triangles, since the lightmap
+// would be looked up at the wrong texels.
+func AttachLightmap(mesh *Mesh, lightmap *Texture, layout *LightmapLayout) error {
+	triCount := len(mesh.Indices) / 3
+	if len(layout.Centroids) != triCount {
+		return fmt.Errorf("lightmap was baked for %d triangles, mesh has %d", len(layout.Centroids), triCount)
+	}
+
+	// Saved meshes lose some precision; anything more is a different mesh
+	size := NewAABBFromPoints(mesh.Vertices).GetSize()
+	tolerance := 1e-5 * math.Max(math.Max(size.X, size.Y), math.Max(size.Z, 1))
+	for t, want := range layout.Centroids {
+		if pointLength(subPoints(triangleCentroid(mesh, t), want)) > tolerance {
+			return fmt.Errorf("lightmap was baked for a different mesh: triangle %d does not match", t)
+		}
+	}
+
+	mesh.LightmapUVs = append([]TextureCoord(nil), layout.UVs...)
+	mesh.Lightmap = lightmap
+	return nil
+}
+
+// triangleCentroid returns the centre of triangle t of a mesh
+func triangleCentroid(mesh *Mesh, t int) Point {
+	a, b, c := mesh.Vertices[mesh.Indices[t*3]], mesh.Vertices[mesh.Indices[t*3+1]], mesh.Vertices[mesh.Indices[t*3+2]]
+	return scalePoint(addPoints(addPoints(a, b), c), 1.0/3)
+}
+
+// lightmapExists reports whether a baked lightmap is stored for a mesh file
+func lightmapExists(meshPath string) bool {
+	_, err := os.Stat(LightmapPath(meshPath))
+	return err == nil
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// dilateLightmap grows covered texels into empty neighbours so filtering
+// across chart borders never blends in black
+func dilateLightmap(light []Point, covered []bool, res, passes int) {
+	for pass := 0; pass < passes; pass++ {
+		grown := append([]bool(nil), covered...)
+		for y := 0; y < res; y++ {
+			for x := 0; x < res; x++ {
+				if covered[y*res+x] {
+					continue
+				}
+				var sum Point
+				count := 0
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						nx, ny := x+dx, y+dy
+						if nx < 0 || ny < 0 || nx >= res || ny >= res || !covered[ny*res+nx] {
+							continue
+						}
+						sum = addPoints(sum, light[ny*res+nx])
+						count++
+					}
+				}
+				if count > 0 {
+					inv := 1.0 / float64(count)
+					light[y*res+x] = Point{X: sum.X * inv, Y: sum.Y * inv, Z: sum.Z * inv}
+					grown[y*res+x] = true
+				}
+			}
+		}
+		copy(covered, grown)
+	}
+}
+
+// closestBarycentric2D returns the barycentric coordinates of the point of
+// triangle abc closest to p
+func closestBarycentric2D(px, py, ax, ay, bx, by, cx, cy float64) (float64, float64, float64) {
+	area := (bx-ax)*(cy-ay) - (by-ay)*(cx-ax)
+	if math.Abs(area) > 1e-12 {
+		w0 := ((bx-px)*(cy-py) - (by-py)*(cx-px)) / area
+		w1 := ((cx-px)*(ay-py) - (cy-py)*(ax-px)) / area
+		w2 := 1.0 - w0 - w1
+		if w0 >= 0 && w1 >= 0 && w2 >= 0 {
+			return w0, w1, w2
+		}
+	}
+
+	// Outside (or degenerate): closest point on the nearest edge
+	best := math.Inf(1)
+	var r0, r1, r2 float64
+	edges := [3][4]float64{{ax, ay, bx, by}, {bx, by, cx, cy}, {cx, cy, ax, ay}}
+	for e, edge := range edges {
+		dx, dy := edge[2]-edge[0], edge[3]-edge[1]
+		t := 0.0
+		if lenSq := dx*dx + dy*dy; lenSq > 1e-24 {
+			t = clampFloat(((px-edge[0])*dx+(py-edge[1])*dy)/lenSq, 0, 1)
+		}
+		qx, qy := edge[0]+t*dx, edge[1]+t*dy
+		if d := (qx-px)*(qx-px) + (qy-py)*(qy-py); d < best {
+			best = d
+			switch e {
+			case 0:
+				r0, r1, r2 = 1-t, t, 0
+			case 1:
+				r0, r1, r2 = 0, 1-t, t
+			case 2:
+				r0, r1, r2 = t, 0, 1-t
+			}
+		}
+	}
+	return r0, r1, r2
+}
+
+// barycentric3D returns the barycentric coordinates of p projected onto the
+// plane of triangle abc
+func barycentric3D(p, a, b, c Point) (float64, float64, float64) {
+	v0x, v0y, v0z := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+	v1x, v1y, v1z := c.X-a.X, c.Y-a.Y, c.Z-a.Z
+	v2x, v2y, v2z := p.X-a.X, p.Y-a.Y, p.Z-a.Z
+
+	d00 := dotProduct(v0x, v0y, v0z, v0x, v0y, v0z)
+	d01 := dotProduct(v0x, v0y, v0z, v1x, v1y, v1z)
+	d11 := dotProduct(v1x, v1y, v1z, v1x, v1y, v1z)
+	d20 := dotProduct(v2x, v2y, v2z, v0x, v0y, v0z)
+	d21 := dotProduct(v2x, v2y, v2z, v1x, v1y, v1z)
+
+	denom := d00*d11 - d01*d01
+	if math.Abs(denom) < 1e-12 {
+		return 1, 0, 0
+	}
+	w1 := (d11*d20 - d01*d21) / denom
+	w2 := (d00*d21 - d01*d20) / denom
+	return 1 - w1 - w2, w1, w2
+}
+
+// cosineSampleHemisphere returns a random direction around a normal with
+// probability proportional to the cosine of its angle to the normal
+func cosineSampleHemisphere(normal Point, rng *rand.Rand) Point {
+	r := math.Sqrt(rng.Float64())
+	phi := 2 * math.Pi * rng.Float64()
+	lx, ly := r*math.Cos(phi), r*math.Sin(phi)
+	lz := math.Sqrt(math.Max(0, 1-lx*lx-ly*ly))
+
+	// Orthonormal basis around the normal
+	nx, ny, nz := normalizeVector(normal.X, normal.Y, normal.Z)
+	ax, ay, az := 1.0, 0.0, 0.0
+	if math.Abs(nx) > 0.9 {
+		ax, ay, az = 0, 1, 0
+	}
+	tx, ty, tz := normalizeVector(crossProduct(nx, ny, nz, ax, ay, az))
+	bx, by, bz := crossProduct(nx, ny, nz, tx, ty, tz)
+
+	return Point{
+		X: tx*lx + bx*ly + nx*lz,
+		Y: ty*lx + by*ly + ny*lz,
+		Z: tz*lx + bz*ly + nz*lz,
+	}
+}
+
+// randomInUnitSphere returns a uniformly distributed point inside the unit sphere
+func randomInUnitSphere(rng *rand.Rand) Point {
+	for {
+		p := Point{X: rng.Float64()*2 - 1, Y: rng.Float64()*2 - 1, Z: rng.Float64()*2 - 1}
+		if p.X*p.X+p.Y*p.Y+p.Z*p.Z <= 1 {
+			return p
+		}
+	}
+}
+
+// offsetAlongNormal moves a point a small distance along a normal
+func offsetAlongNormal(p, normal Point, distance float64) Point {
+	return Point{X: p.X + normal.X*distance, Y: p.Y + normal.Y*distance, Z: p.Z + normal.Z*distance}
+}
+
+// addPoints returns the component-wise sum of two points
+func addPoints(a, b Point) Point {
+	return Point{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
+// surfaceAlbedo returns a node's diffuse color as 0-1 reflectance
+func surfaceAlbedo(node *SceneNode) Point {
+	var material IMaterial
+	if node != nil {
+		switch obj := node.Object.(type) {
+		case *Mesh:
+			material = obj.Material
+		case *Triangle:
+			material = obj.Material
+		case MeshProvider:
+			if meshes := obj.VisibleMeshes(); len(meshes) > 0 {
+				material = meshes[0].Material
+			}
+		}
+	}
+	if material == nil {
+		return Point{X: 0.5, Y: 0.5, Z: 0.5}
+	}
+	c := material.GetDiffuseColor(0, 0)
+	return Point{X: float64(c.R) / 255.0, Y: float64(c.G) / 255.0, Z: float64(c.B) / 255.0}
+}
@@ -7,6 +7,7 @@ type LODLevel struct {
 	Mesh           *Mesh
 	MaxDistance    float64
 	ScreenCoverage float64
+	Error          float64 // Geometric error against the full-detail mesh, in world units
 }
 
 // LODGroup manages multiple LOD levels for an object
@@ -36,10 +37,14 @@ func NewLODGroup() *LODGroup {
 
 // AddLOD adds a level of detail
 func (lg *LODGroup) AddLOD(mesh *Mesh, maxDistance float64) {
-	level := LODLevel{
+	lg.addLevel(LODLevel{
 		Mesh:        mesh,
 		MaxDistance: maxDistance,
-	}
+	})
+}
+
+// addLevel inserts a level, keeping levels sorted by distance
+func (lg *LODGroup) addLevel(level LODLevel) {
 	lg.Levels = append(lg.Levels, level)
 	lg.sortLODs()
 
@@ -58,6 +63,37 @@ func (lg *LODGroup) AddLODWithScreenCoverage(mesh *Mesh, screenCoverage float64)
 	lg.UseScreenSpace = true
 }
 
+// AddLODWithError adds a level of detail along with its geometric error,
+// for SetDistancesFromError
+func (lg *LODGroup) AddLODWithError(mesh *Mesh, maxDistance, geometricError float64) {
+	lg.addLevel(LODLevel{
+		Mesh:        mesh,
+		MaxDistance: maxDistance,
+		Error:       geometricError,
+	})
+}
+
+// SetDistancesFromError derives switch distances from the levels' errors.
+// An error e at distance d covers e*projectionScale/d screen cells (the
+// camera's FOV, as in ProjectPoint), so each level is kept until the next
+// one's error falls below maxScreenError. Levels must be ordered from most
+// to least detailed.
+func (lg *LODGroup) SetDistancesFromError(projectionScale, maxScreenError float64) {
+	if maxScreenError <= 0 {
+		return
+	}
+	distance := 0.0
+	for i := range lg.Levels {
+		if i == len(lg.Levels)-1 {
+			lg.Levels[i].MaxDistance = math.Inf(1)
+			break
+		}
+		switchAt := lg.Levels[i+1].Error * projectionScale / maxScreenError
+		distance = math.Max(distance, switchAt)
+		lg.Levels[i].MaxDistance = distance
+	}
+}
+
 // sortLODs sorts LOD levels by distance
 func (lg *LODGroup) sortLODs() {
 	n := len(lg.Levels)
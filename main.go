@@ -23,6 +23,10 @@ const (
 	DemoAdvancedFeatures
 	DemoTextureShowcase
 	DemoShadowMapping
+	DemoMetaballs
+	DemoVoxelWorld
+	DemoCurvedSurfaces
+	DemoTerrain
 )
 
 // RenderMode specifies the rendering approach
@@ -120,14 +124,18 @@ func main() {
 	fmt.Println("  10 - Performance Test (Stress test with many objects)")
 	fmt.Println("  11 - Advanced Features (PBR, Textures, Shadows, Instancing)")
 	fmt.Println("  12 - Texture Showcase (UV mapping, procedural textures)")
-	fmt.Println("  13 - Shadow Mapping (Real-time shadows with PCF)")
+	fmt.Println("  13 - Shadow Mapping (Point lamp with cube shadow maps)")
+	fmt.Println("  14 - SDF & Metaballs (Dual contouring, animated metaballs)")
+	fmt.Println("  15 - Voxel Sandbox (Greedy meshing, block picking, chunk streaming)")
+	fmt.Println("  16 - Curved Surfaces (Utah teapot, NURBS, adaptive tessellation)")
+	fmt.Println("  17 - Terrain (Heightmap with quadtree chunk LOD)")
 	fmt.Println()
-	fmt.Print("Enter choice (1-13): ")
+	fmt.Print("Enter choice (1-17): ")
 
 	var choice int
 	fmt.Scanln(&choice)
 
-	if choice < 1 || choice > 13 {
+	if choice < 1 || choice > 17 {
 		fmt.Println("Invalid choice, using Basic Geometry demo")
 		choice = 1
 	}
@@ -227,6 +235,7 @@ func main() {
 	fmt.Println("  Q/E      - Move up/down")
 	fmt.Println("  IJKL     - Rotate camera")
 	fmt.Println("  R        - Reset camera")
+	fmt.Println("  H        - Toggle light culling heatmap (Terminal)")
 	fmt.Println("  +/-      - Speed control")
 	fmt.Println("  X or ESC - Quit")
 	fmt.Println()
@@ -289,6 +298,7 @@ func getAAModeName(mode AAMode) string {
 func runEngine(demoType int, config EngineConfig) {
 	// 1. Select Base Renderer
 	var baseRenderer Renderer
+	var termRenderer *TerminalRenderer
 	var orientation OrientationType
 	var inputManager InputManager
 
@@ -301,7 +311,7 @@ func runEngine(demoType int, config EngineConfig) {
 
 		// Use Terminal Renderer
 		writer := bufio.NewWriter(os.Stdout)
-		termRenderer := NewTerminalRenderer(writer, config.Height, config.Width)
+		termRenderer = NewTerminalRenderer(writer, config.Height, config.Width)
 		termRenderer.SetUseColor(config.UseColor)
 		termRenderer.SetShowDebugInfo(config.ShowDebugInfo)
 		baseRenderer = termRenderer
@@ -435,6 +445,9 @@ func runEngine(demoType int, config EngineConfig) {
 		}
 
 		cameraController.Update(input, orientation)
+		if input.ToggleHeatmap && termRenderer != nil {
+			termRenderer.SetShowLightHeatmap(!termRenderer.ShowLightHeatmap)
+		}
 		elapsedTime := time.Since(startTime).Seconds()
 		animateSceneDemo(scene, demoType, elapsedTime)
 
@@ -585,6 +598,26 @@ func configureCamera(camera *Camera, demoType int, orientation OrientationType)
 		camera.Transform.SetRotation(0, 0, 0)
 		camera.Far = 200.0
 
+	case DemoMetaballs:
+		camera.Transform.SetPosition(14, 10, -60*float64(orientation))
+		camera.Transform.SetRotation(0, 0, 0)
+		camera.Far = 200.0
+
+	case DemoVoxelWorld:
+		camera.Transform.SetPosition(0, 40, -50*float64(orientation))
+		camera.Transform.SetRotation(0, 0, 0)
+		camera.Far = 250.0
+
+	case DemoCurvedSurfaces:
+		camera.Transform.SetPosition(-10, 15, -70*float64(orientation))
+		camera.Transform.SetRotation(0, 0, 0)
+		camera.Far = 250.0
+
+	case DemoTerrain:
+		camera.Transform.SetPosition(0, 60, -140*float64(orientation))
+		camera.Transform.SetRotation(0, 0, 0)
+		camera.Far = 600.0
+
 	default:
 		camera.Transform.SetPosition(0, 10, -60)
 		camera.Transform.SetRotation(0, 0, 0)
@@ -659,6 +692,26 @@ func configureCameraController(controller *CameraController, demoType int) {
 		controller.SetOrbitCenter(0, 0, 0)
 		controller.SetOrbitHeight(20.0)
 
+	case DemoMetaballs:
+		controller.SetOrbitRadius(60.0)
+		controller.SetOrbitCenter(14, 0, 0)
+		controller.SetOrbitHeight(15.0)
+
+	case DemoVoxelWorld:
+		controller.SetOrbitRadius(50.0)
+		controller.SetOrbitCenter(0, 12, 0)
+		controller.SetOrbitHeight(30.0)
+
+	case DemoCurvedSurfaces:
+		controller.SetOrbitRadius(70.0)
+		controller.SetOrbitCenter(-10, 8, 0)
+		controller.SetOrbitHeight(15.0)
+
+	case DemoTerrain:
+		controller.SetOrbitRadius(110.0)
+		controller.SetOrbitCenter(0, 10, 0)
+		controller.SetOrbitHeight(45.0)
+
 	default:
 		controller.SetOrbitRadius(80.0)
 		controller.SetOrbitCenter(0, 0, 0)
@@ -707,6 +760,14 @@ func buildScene(scene *Scene, demoType int, material Material) {
 		TextureShowcaseDemo(scene)
 	case DemoShadowMapping:
 		ShadowMappingDemo(scene)
+	case DemoMetaballs:
+		MetaballsDemo(scene)
+	case DemoVoxelWorld:
+		VoxelWorldDemo(scene)
+	case DemoCurvedSurfaces:
+		CurvedSurfacesDemo(scene)
+	case DemoTerrain:
+		TerrainDemo(scene)
 	default:
 		BasicGeometryDemo(scene)
 	}
@@ -739,7 +800,15 @@ func animateSceneDemo(scene *Scene, demoType int, time float64) {
 	case DemoTextureShowcase:
 		AnimateAdvancedFeatures(scene, time) // Reuse advanced features animation
 	case DemoShadowMapping:
-		AnimateAdvancedFeatures(scene, time) // Reuse animation for shadow casters
+		AnimateShadowMapping(scene, time)
+	case DemoMetaballs:
+		AnimateMetaballs(scene, time)
+	case DemoVoxelWorld:
+		AnimateVoxelWorld(scene)
+	case DemoCurvedSurfaces:
+		AnimateCurvedSurfaces(scene)
+	case DemoTerrain:
+		AnimateTerrain(scene)
 	}
 
 	/*
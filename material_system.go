@@ -1,5 +1,7 @@
 package main
 
+import "math"
+
 // MaterialType specifies the type of material
 type MaterialType int
 
@@ -218,7 +220,7 @@ func UnpackNormalMap(color Color) Point {
 	z := (float64(color.B)/255.0)*2.0 - 1.0
 
 	// Normalize
-	length := (x*x + y*y + z*z)
+	length := math.Sqrt(x*x + y*y + z*z)
 	if length > 0 {
 		invLength := 1.0 / length
 		x *= invLength
@@ -86,6 +86,38 @@ func dotProduct(nx, ny, nz, lx, ly, lz float64) float64 {
 	return nx*lx + ny*ly + nz*lz
 }
 
+// subPoints returns a - b
+func subPoints(a, b Point) Point {
+	return Point{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}
+}
+
+// scalePoint returns p * s
+func scalePoint(p Point, s float64) Point {
+	return Point{X: p.X * s, Y: p.Y * s, Z: p.Z * s}
+}
+
+// crossPoints returns the cross product a x b
+func crossPoints(a, b Point) Point {
+	x, y, z := crossProduct(a.X, a.Y, a.Z, b.X, b.Y, b.Z)
+	return Point{X: x, Y: y, Z: z}
+}
+
+// dotPoints returns the dot product a · b
+func dotPoints(a, b Point) float64 {
+	return dotProduct(a.X, a.Y, a.Z, b.X, b.Y, b.Z)
+}
+
+// pointLength returns the length of p as a vector
+func pointLength(p Point) float64 {
+	return math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+}
+
+// normalizePoint returns p scaled to unit length (up for zero vectors)
+func normalizePoint(p Point) Point {
+	x, y, z := normalizeVector(p.X, p.Y, p.Z)
+	return Point{X: x, Y: y, Z: z}
+}
+
 // normalizeVector normalizes a 3D vector with safety checks
 func normalizeVector(x, y, z float64) (float64, float64, float64) {
 	length := math.Sqrt(x*x + y*y + z*z)
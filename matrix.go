@@ -62,25 +62,48 @@ func (m *Matrix4x4) TransformDirection(d Point) Point {
 	return Point{X: x, Y: y, Z: z}
 }
 
+// NormalMatrix returns the inverse transpose of this matrix. Normals
+// transformed by it (with TransformNormal) stay perpendicular to their surface
+// under non-uniform scale.
+func (m *Matrix4x4) NormalMatrix() Matrix4x4 {
+	inv := m.Invert()
+	var result Matrix4x4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result.M[i*4+j] = inv.M[j*4+i]
+		}
+	}
+	return result
+}
+
+// TransformNormal transforms a normal by this matrix, which should be a
+// normal matrix, and renormalizes it
+func (m *Matrix4x4) TransformNormal(n Point) Point {
+	d := m.TransformDirection(n)
+	x, y, z := normalizeVector(d.X, d.Y, d.Z)
+	return Point{X: x, Y: y, Z: z}
+}
+
 // ComposeMatrix creates a transformation matrix from position, rotation, scale
+// (scale first, then rotation, then translation)
 func ComposeMatrix(pos Point, rot Quaternion, scale Point) Matrix4x4 {
 	// Convert quaternion to rotation matrix
 	rotMatrix := rot.ToMatrix()
 
-	// Apply scale
+	// Apply scale to the rotation's columns
 	var result Matrix4x4
 	result.M[0] = rotMatrix.M[0] * scale.X
-	result.M[1] = rotMatrix.M[1] * scale.X
-	result.M[2] = rotMatrix.M[2] * scale.X
+	result.M[1] = rotMatrix.M[1] * scale.Y
+	result.M[2] = rotMatrix.M[2] * scale.Z
 	result.M[3] = pos.X
 
-	result.M[4] = rotMatrix.M[4] * scale.Y
+	result.M[4] = rotMatrix.M[4] * scale.X
 	result.M[5] = rotMatrix.M[5] * scale.Y
-	result.M[6] = rotMatrix.M[6] * scale.Y
+	result.M[6] = rotMatrix.M[6] * scale.Z
 	result.M[7] = pos.Y
 
-	result.M[8] = rotMatrix.M[8] * scale.Z
-	result.M[9] = rotMatrix.M[9] * scale.Z
+	result.M[8] = rotMatrix.M[8] * scale.X
+	result.M[9] = rotMatrix.M[9] * scale.Y
 	result.M[10] = rotMatrix.M[10] * scale.Z
 	result.M[11] = pos.Z
 
@@ -92,6 +115,40 @@ func ComposeMatrix(pos Point, rot Quaternion, scale Point) Matrix4x4 {
 	return result
 }
 
+// DecomposeMatrix splits an affine matrix into position, rotation and scale,
+// the inverse of ComposeMatrix. A mirroring matrix gets a negative X scale.
+// Shear is lost.
+func DecomposeMatrix(m Matrix4x4) (Point, Quaternion, Point) {
+	pos := Point{X: m.M[3], Y: m.M[7], Z: m.M[11]}
+	scale := Point{
+		X: math.Sqrt(m.M[0]*m.M[0] + m.M[4]*m.M[4] + m.M[8]*m.M[8]),
+		Y: math.Sqrt(m.M[1]*m.M[1] + m.M[5]*m.M[5] + m.M[9]*m.M[9]),
+		Z: math.Sqrt(m.M[2]*m.M[2] + m.M[6]*m.M[6] + m.M[10]*m.M[10]),
+	}
+	if m.Determinant3() < 0 {
+		scale.X = -scale.X
+	}
+
+	rot := IdentityMatrix()
+	for col, s := range [3]float64{scale.X, scale.Y, scale.Z} {
+		if math.Abs(s) < 1e-12 {
+			continue
+		}
+		for row := 0; row < 3; row++ {
+			rot.M[row*4+col] = m.M[row*4+col] / s
+		}
+	}
+	return pos, MatrixToQuaternion(rot), scale
+}
+
+// Determinant3 returns the determinant of the matrix's 3x3 part (negative
+// for mirroring transforms)
+func (m *Matrix4x4) Determinant3() float64 {
+	return m.M[0]*(m.M[5]*m.M[10]-m.M[6]*m.M[9]) -
+		m.M[1]*(m.M[4]*m.M[10]-m.M[6]*m.M[8]) +
+		m.M[2]*(m.M[4]*m.M[9]-m.M[5]*m.M[8])
+}
+
 // Invert returns the inverse matrix
 func (m *Matrix4x4) Invert() Matrix4x4 {
 	// Using adjugate method - full implementation
@@ -176,3 +233,17 @@ func CreateOrthographicMatrix(left, right, bottom, top, near, far float64) Matri
 
 	return mat
 }
+
+// CreatePerspectiveMatrix creates a perspective projection matrix (fovY in radians)
+func CreatePerspectiveMatrix(fovY, aspect, near, far float64) Matrix4x4 {
+	mat := Matrix4x4{}
+	f := 1.0 / math.Tan(fovY/2.0)
+
+	mat.M[0] = f / aspect
+	mat.M[5] = f
+	mat.M[10] = (far + near) / (near - far)
+	mat.M[11] = (2.0 * far * near) / (near - far)
+	mat.M[14] = -1.0
+
+	return mat
+}
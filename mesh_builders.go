@@ -0,0 +1,431 @@
+package main
+
+import "math"
+
+// ============================================================================
+// MESH BUILDERS
+// ============================================================================
+// Generic builders sweep a 2D shape into a mesh:
+//   - lathe: a profile revolved around the Y axis
+//   - extrusion: a closed shape swept along a path
+//   - tube: a circle swept along a spline
+// Sweeps carry rotation-minimizing frames along the path, so the shape does
+// not twist around sharp bends the way a fixed up vector would.
+// ============================================================================
+
+// ============================================================================
+// SPLINES
+// ============================================================================
+
+// CatmullRomSpline is a curve passing through all of its control points
+type CatmullRomSpline struct {
+	Points []Point
+	Closed bool // Joins the last point back to the first
+}
+
+// NewCatmullRomSpline creates a spline through the given points
+func NewCatmullRomSpline(points []Point, closed bool) *CatmullRomSpline {
+	return &CatmullRomSpline{Points: points, Closed: closed}
+}
+
+// segment returns the four control points around parameter t and the local
+// parameter within that segment
+func (s *CatmullRomSpline) segment(t float64) (p0, p1, p2, p3 Point, local float64) {
+	n := len(s.Points)
+	segments := n - 1
+	if s.Closed {
+		segments = n
+	}
+
+	f := clampFloat(t, 0, 1) * float64(segments)
+	i := minInt(int(f), segments-1)
+	local = f - float64(i)
+
+	at := func(k int) Point {
+		if s.Closed {
+			return s.Points[((k%n)+n)%n]
+		}
+		return s.Points[clampInt(k, 0, n-1)]
+	}
+	return at(i - 1), at(i), at(i + 1), at(i + 2), local
+}
+
+// Evaluate returns the point at t in [0, 1]
+func (s *CatmullRomSpline) Evaluate(t float64) Point {
+	switch len(s.Points) {
+	case 0:
+		return Point{}
+	case 1:
+		return s.Points[0]
+	}
+
+	p0, p1, p2, p3, u := s.segment(t)
+	u2, u3 := u*u, u*u*u
+	blend := func(a, b, c, d float64) float64 {
+		return 0.5 * (2*b + (c-a)*u + (2*a-5*b+4*c-d)*u2 + (3*b-a-3*c+d)*u3)
+	}
+	return Point{
+		X: blend(p0.X, p1.X, p2.X, p3.X),
+		Y: blend(p0.Y, p1.Y, p2.Y, p3.Y),
+		Z: blend(p0.Z, p1.Z, p2.Z, p3.Z),
+	}
+}
+
+// Tangent returns the unit direction of the curve at t
+func (s *CatmullRomSpline) Tangent(t float64) Point {
+	if len(s.Points) < 2 {
+		return Point{Z: 1}
+	}
+
+	p0, p1, p2, p3, u := s.segment(t)
+	u2 := u * u
+	derive := func(a, b, c, d float64) float64 {
+		return 0.5 * ((c - a) + 2*(2*a-5*b+4*c-d)*u + 3*(3*b-a-3*c+d)*u2)
+	}
+	return normalizePoint(Point{
+		X: derive(p0.X, p1.X, p2.X, p3.X),
+		Y: derive(p0.Y, p1.Y, p2.Y, p3.Y),
+		Z: derive(p0.Z, p1.Z, p2.Z, p3.Z),
+	})
+}
+
+// Sample returns segments+1 points evenly spaced in t; on a closed spline the
+// last point repeats the first
+func (s *CatmullRomSpline) Sample(segments int) []Point {
+	segments = maxInt(segments, 1)
+	points := make([]Point, segments+1)
+	for i := range points {
+		points[i] = s.Evaluate(float64(i) / float64(segments))
+	}
+	return points
+}
+
+// ============================================================================
+// LATHE
+// ============================================================================
+
+// GenerateLathe revolves a profile around the Y axis. Profile points give the
+// radius in X and the height in Y, running bottom to top for an outward
+// surface. Profile corners sharper than DefaultCreaseAngle stay hard.
+func GenerateLathe(profile []Point, segments int) *Mesh {
+	mesh := NewMesh()
+	if len(profile) < 2 {
+		return mesh
+	}
+
+	// Normal of each profile edge, to the right of its direction
+	edgeNormal := func(a, b Point) (float64, float64) {
+		dr, dy := b.X-a.X, b.Y-a.Y
+		length := math.Hypot(dr, dy)
+		if length < 1e-12 {
+			return 0, 0
+		}
+		return dy / length, -dr / length
+	}
+
+	cosCrease := math.Cos(DefaultCreaseAngle)
+	rows := make([]revolveRow, 0, len(profile)+4)
+	for i, p := range profile {
+		var prevR, prevY, nextR, nextY float64
+		if i > 0 {
+			prevR, prevY = edgeNormal(profile[i-1], p)
+		}
+		if i < len(profile)-1 {
+			nextR, nextY = edgeNormal(p, profile[i+1])
+		}
+
+		row := revolveRow{Radius: math.Max(p.X, 0), Y: p.Y}
+		switch {
+		case i == 0:
+			row.NormalR, row.NormalY = nextR, nextY
+		case i == len(profile)-1:
+			row.NormalR, row.NormalY = prevR, prevY
+		case prevR*nextR+prevY*nextY < cosCrease:
+			// Hard corner: end the strip and start a new one here
+			row.NormalR, row.NormalY = prevR, prevY
+			rows = append(rows, row)
+			row.NormalR, row.NormalY = nextR, nextY
+			row.Split = true
+		default:
+			row.NormalR, row.NormalY = prevR+nextR, prevY+nextY
+		}
+		rows = append(rows, row)
+	}
+	assignRowV(rows)
+
+	addRevolvedRows(mesh, rows, segments)
+	return mesh
+}
+
+// ============================================================================
+// EXTRUSION
+// ============================================================================
+
+// sweepFrame is an orthonormal frame along a path: shapes are laid out in
+// the Normal/Binormal plane, with Normal x Binormal = Tangent
+type sweepFrame struct {
+	Position Point
+	Tangent  Point
+	Normal   Point
+	Binormal Point
+}
+
+// sweepFrames computes rotation-minimizing frames along a path by carrying
+// the first frame's normal forward. For closed paths the leftover twist is
+// spread over the whole path so the seam lines up.
+func sweepFrames(path []Point, closed bool) []sweepFrame {
+	n := len(path)
+	frames := make([]sweepFrame, n)
+
+	for i := range path {
+		var d Point
+		switch {
+		case closed && (i == 0 || i == n-1):
+			// The first and last points coincide on a closed path
+			d = subPoints(path[1], path[n-2])
+		case i == 0:
+			d = subPoints(path[1], path[0])
+		case i == n-1:
+			d = subPoints(path[n-1], path[n-2])
+		default:
+			d = subPoints(path[i+1], path[i-1])
+		}
+		frames[i] = sweepFrame{Position: path[i], Tangent: normalizePoint(d)}
+	}
+
+	// Start from the axis least aligned with the first tangent
+	t0 := frames[0].Tangent
+	axis := Point{X: 1}
+	if math.Abs(t0.Y) < math.Abs(t0.X) && math.Abs(t0.Y) <= math.Abs(t0.Z) {
+		axis = Point{Y: 1}
+	} else if math.Abs(t0.Z) < math.Abs(t0.X) {
+		axis = Point{Z: 1}
+	}
+	frames[0].Normal = projectOntoPlane(axis, t0)
+
+	for i := 1; i < n; i++ {
+		normal := projectOntoPlane(frames[i-1].Normal, frames[i].Tangent)
+		if pointLength(normal) < 1e-12 {
+			normal = frames[i-1].Normal
+		}
+		frames[i].Normal = normal
+	}
+
+	if closed && n > 2 {
+		// Angle between the transported and the starting normal at the seam
+		last, start := frames[n-1], frames[0].Normal
+		c := crossPoints(last.Normal, start)
+		twist := math.Atan2(
+			dotProduct(c.X, c.Y, c.Z, last.Tangent.X, last.Tangent.Y, last.Tangent.Z),
+			dotProduct(last.Normal.X, last.Normal.Y, last.Normal.Z, start.X, start.Y, start.Z),
+		)
+		for i := range frames {
+			angle := twist * float64(i) / float64(n-1)
+			f := &frames[i]
+			b := crossPoints(f.Tangent, f.Normal)
+			f.Normal = addScaled(scalePoint(f.Normal, math.Cos(angle)), b, math.Sin(angle))
+		}
+	}
+
+	for i := range frames {
+		frames[i].Binormal = crossPoints(frames[i].Tangent, frames[i].Normal)
+	}
+	return frames
+}
+
+// GenerateExtrusion sweeps a closed 2D shape (X and Y of each point) along a
+// path. Shape corners sharper than DefaultCreaseAngle stay hard; capped
+// extrusions close both ends.
+func GenerateExtrusion(shape []Point, path []Point, capped bool) *Mesh {
+	mesh := NewMesh()
+	if len(shape) < 3 || len(path) < 2 {
+		return mesh
+	}
+
+	// Work with a counter-clockwise shape so the walls face outward
+	shape = append([]Point(nil), shape...)
+	if polygonArea2D(shape) < 0 {
+		for i, j := 0, len(shape)-1; i < j; i, j = i+1, j-1 {
+			shape[i], shape[j] = shape[j], shape[i]
+		}
+	}
+
+	frames := sweepFrames(path, false)
+	place := func(f sweepFrame, p Point) Point {
+		return addScaled(addScaled(f.Position, f.Normal, p.X), f.Binormal, p.Y)
+	}
+
+	// U runs around the shape by perimeter, V along the path by length
+	ring := append(shape, shape[0])
+	perimeter := make([]float64, len(ring))
+	for j := 1; j < len(ring); j++ {
+		perimeter[j] = perimeter[j-1] + pointLength(subPoints(ring[j], ring[j-1]))
+	}
+	pathLength := make([]float64, len(path))
+	for i := 1; i < len(path); i++ {
+		pathLength[i] = pathLength[i-1] + pointLength(subPoints(path[i], path[i-1]))
+	}
+
+	stride := len(ring)
+	for i, f := range frames {
+		for j, p := range ring {
+			w := place(f, p)
+			mesh.AddVertexWithUV(w.X, w.Y, w.Z, perimeter[j]/perimeter[len(ring)-1], pathLength[i]/pathLength[len(path)-1])
+		}
+	}
+	for i := 0; i < len(path)-1; i++ {
+		for j := 0; j < len(shape); j++ {
+			a := i*stride + j
+			mesh.AddTriangleIndices(a, a+1, a+stride)
+			mesh.AddTriangleIndices(a+1, a+stride+1, a+stride)
+		}
+	}
+
+	if capped {
+		triangles := triangulatePolygon2D(shape)
+		minX, minY, maxX, maxY := polygonBounds2D(shape)
+		for end, f := range []sweepFrame{frames[0], frames[len(frames)-1]} {
+			first := len(mesh.Vertices)
+			for _, p := range shape {
+				w := place(f, p)
+				mesh.AddVertexWithUV(w.X, w.Y, w.Z, (p.X-minX)/math.Max(maxX-minX, 1e-12), (p.Y-minY)/math.Max(maxY-minY, 1e-12))
+			}
+			for _, tri := range triangles {
+				if end == 0 {
+					// The start cap faces back along the path
+					mesh.AddTriangleIndices(first+tri[0], first+tri[2], first+tri[1])
+				} else {
+					mesh.AddTriangleIndices(first+tri[0], first+tri[1], first+tri[2])
+				}
+			}
+		}
+	}
+
+	mesh.GenerateNormals(DefaultCreaseAngle)
+	return mesh
+}
+
+// ============================================================================
+// TUBE
+// ============================================================================
+
+// GenerateTube sweeps a circle of the given radius along a spline. Closed
+// splines give a seamless ring; open tubes are left uncapped.
+func GenerateTube(spline *CatmullRomSpline, radius float64, tubularSegments, radialSegments int) *Mesh {
+	mesh := NewMesh()
+	if len(spline.Points) < 2 {
+		return mesh
+	}
+	radialSegments = maxInt(radialSegments, 3)
+
+	path := spline.Sample(tubularSegments)
+	frames := sweepFrames(path, spline.Closed)
+	for i, f := range frames {
+		// Spline tangents are exact; the frame only needs a consistent normal
+		f.Tangent = spline.Tangent(float64(i) / float64(len(frames)-1))
+		f.Normal = normalizePoint(projectOntoPlane(f.Normal, f.Tangent))
+		f.Binormal = crossPoints(f.Tangent, f.Normal)
+
+		for j := 0; j <= radialSegments; j++ {
+			phi := 2 * math.Pi * float64(j) / float64(radialSegments)
+			n := addScaled(scalePoint(f.Normal, math.Cos(phi)), f.Binormal, math.Sin(phi))
+			p := addScaled(f.Position, n, radius)
+			mesh.AddVertexWithUV(p.X, p.Y, p.Z, float64(i)/float64(len(frames)-1), float64(j)/float64(radialSegments))
+			mesh.Normals = append(mesh.Normals, n)
+		}
+	}
+
+	stride := radialSegments + 1
+	for i := 0; i < len(frames)-1; i++ {
+		for j := 0; j < radialSegments; j++ {
+			a := i*stride + j
+			mesh.AddTriangleIndices(a, a+1, a+stride)
+			mesh.AddTriangleIndices(a+1, a+stride+1, a+stride)
+		}
+	}
+
+	return mesh
+}
+
+// ============================================================================
+// POLYGON HELPERS
+// ============================================================================
+
+// polygonArea2D returns the signed area of a polygon in XY (positive when
+// counter-clockwise)
+func polygonArea2D(poly []Point) float64 {
+	area := 0.0
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area / 2
+}
+
+// polygonBounds2D returns the XY bounding rectangle of a polygon
+func polygonBounds2D(poly []Point) (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, p := range poly {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	return minX, minY, maxX, maxY
+}
+
+// triangulatePolygon2D triangulates a simple counter-clockwise polygon in XY
+// by ear clipping, returning counter-clockwise triangles of polygon indices
+func triangulatePolygon2D(poly []Point) [][3]int {
+	remaining := make([]int, len(poly))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	triangles := make([][3]int, 0, len(poly)-2)
+
+	cross := func(a, b, c Point) float64 {
+		return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+	}
+	inside := func(p, a, b, c Point) bool {
+		return cross(a, b, p) >= 0 && cross(b, c, p) >= 0 && cross(c, a, p) >= 0
+	}
+
+	for len(remaining) > 3 {
+		clipped := false
+		for i := range remaining {
+			prev := remaining[(i+len(remaining)-1)%len(remaining)]
+			curr := remaining[i]
+			next := remaining[(i+1)%len(remaining)]
+			a, b, c := poly[prev], poly[curr], poly[next]
+
+			// Ears are convex corners with no other vertex inside
+			if cross(a, b, c) <= 1e-12 {
+				continue
+			}
+			ear := true
+			for _, other := range remaining {
+				if other != prev && other != curr && other != next && inside(poly[other], a, b, c) {
+					ear = false
+					break
+				}
+			}
+			if !ear {
+				continue
+			}
+
+			triangles = append(triangles, [3]int{prev, curr, next})
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			clipped = true
+			break
+		}
+
+		// Degenerate input (collinear or self-intersecting): fan the rest
+		if !clipped {
+			for i := 1; i < len(remaining)-1; i++ {
+				triangles = append(triangles, [3]int{remaining[0], remaining[i], remaining[i+1]})
+			}
+			return triangles
+		}
+	}
+
+	return append(triangles, [3]int{remaining[0], remaining[1], remaining[2]})
+}
@@ -0,0 +1,74 @@
+package main
+
+import "math"
+
+// ============================================================================
+// VERTEX COLORS
+// ============================================================================
+// Meshes can carry one color per vertex. Colors are interpolated across each
+// triangle and multiply the material's diffuse color, so a single material can
+// shade heatmaps, height-colored terrain or scanned data.
+// ============================================================================
+
+// HasColors reports whether the mesh has a color for every vertex
+func (m *Mesh) HasColors() bool {
+	return len(m.Vertices) > 0 && len(m.Colors) == len(m.Vertices)
+}
+
+// SetColors assigns a color to every vertex from a function of its position
+func (m *Mesh) SetColors(colorAt func(p Point) Color) {
+	m.Colors = make([]Color, len(m.Vertices))
+	for i, v := range m.Vertices {
+		m.Colors[i] = colorAt(v)
+	}
+}
+
+// ColorByHeight colors vertices with a gradient from low (lowest Y) to high
+// (highest Y)
+func (m *Mesh) ColorByHeight(low, high Color) {
+	if len(m.Vertices) == 0 {
+		return
+	}
+
+	minY, maxY := m.Vertices[0].Y, m.Vertices[0].Y
+	for _, v := range m.Vertices {
+		minY = math.Min(minY, v.Y)
+		maxY = math.Max(maxY, v.Y)
+	}
+
+	m.SetColors(func(p Point) Color {
+		if maxY-minY < 1e-12 {
+			return low
+		}
+		return low.Lerp(high, (p.Y-minY)/(maxY-minY))
+	})
+}
+
+// InterpolateColor returns the vertex color at a point on a triangle, or white
+// when it has none
+func InterpolateColor(t *Triangle, p Point) Color {
+	if !t.HasVertexColors {
+		return ColorWhite
+	}
+
+	w0, w1, w2 := barycentric3D(p, t.P0, t.P1, t.P2)
+	return Color{
+		R: uint8(clampFloat(w0*float64(t.C0.R)+w1*float64(t.C1.R)+w2*float64(t.C2.R)+0.5, 0, 255)),
+		G: uint8(clampFloat(w0*float64(t.C0.G)+w1*float64(t.C1.G)+w2*float64(t.C2.G)+0.5, 0, 255)),
+		B: uint8(clampFloat(w0*float64(t.C0.B)+w1*float64(t.C1.B)+w2*float64(t.C2.B)+0.5, 0, 255)),
+	}
+}
+
+// tintedMaterial multiplies a material's diffuse color by a vertex color
+type tintedMaterial struct {
+	IMaterial
+	tint Color
+}
+
+func (m *tintedMaterial) GetDiffuseColor(u, v float64) Color {
+	return m.IMaterial.GetDiffuseColor(u, v).Multiply(m.tint)
+}
+
+func (m *tintedMaterial) SampleDiffuse(u, v float64) Color {
+	return m.IMaterial.SampleDiffuse(u, v).Multiply(m.tint)
+}
@@ -34,6 +34,7 @@ func GenerateSphere(radius float64, rings, sectors int) *Mesh {
 			// Add the single unique vertex to the list
 			// Note: We are no longer making Quads here. Just points.
 			mesh.AddVertex(x, y, z)
+			mesh.Normals = append(mesh.Normals, Point{X: x / radius, Y: y / radius, Z: z / radius})
 			
 			// Add UV coordinates
 			mesh.AddUV(u, 1.0-v) // Flip V for OpenGL convention
@@ -55,15 +56,16 @@ func GenerateSphere(radius float64, rings, sectors int) *Mesh {
 			bottom := (r+1)*stride + s           // Bottom-Left
 			bottomNext := (r+1)*stride + (s + 1) // Bottom-Right
 
-			// First Triangle (Top-Left, Top-Right, Bottom-Left)
+			// Wound so face normals point outward, matching the vertex normals
+			// First Triangle (Top-Left, Bottom-Left, Top-Right)
 			mesh.AddIndex(curr)
-			mesh.AddIndex(next)
 			mesh.AddIndex(bottom)
+			mesh.AddIndex(next)
 
-			// Second Triangle (Top-Right, Bottom-Right, Bottom-Left)
+			// Second Triangle (Top-Right, Bottom-Left, Bottom-Right)
 			mesh.AddIndex(next)
-			mesh.AddIndex(bottomNext)
 			mesh.AddIndex(bottom)
+			mesh.AddIndex(bottomNext)
 		}
 	}
 
@@ -107,6 +109,9 @@ func GenerateTorus(majorRadius, minorRadius float64, majorSegments, minorSegment
 
 			mesh.AddVertex(x, y, z)
 			mesh.AddUV(u, v)
+
+			// The normal points away from the tube's center line
+			mesh.Normals = append(mesh.Normals, Point{X: cosPhi * cosTheta, Y: sinPhi, Z: cosPhi * sinTheta})
 		}
 	}
 
@@ -137,3 +142,408 @@ func GenerateTorus(majorRadius, minorRadius float64, majorSegments, minorSegment
 
 	return mesh
 }
+
+// ============================================================================
+// PRIMITIVES
+// ============================================================================
+// All generators build indexed meshes centered on the origin with UVs and
+// vertex normals. Triangles are wound so cross(p1-p0, p2-p0) points outward,
+// and V runs from 0 at the top of a surface to 1 at the bottom, as in
+// GenerateSphere.
+// ============================================================================
+
+// GeneratePlane generates a grid on the XZ plane facing +Y
+func GeneratePlane(width, depth float64, segmentsX, segmentsZ int) *Mesh {
+	mesh := NewMesh()
+	addGridFace(mesh, Point{}, Point{X: 1}, Point{Z: -1},
+		linspace(-width/2, width/2, maxInt(segmentsX, 1)),
+		linspace(-depth/2, depth/2, maxInt(segmentsZ, 1)))
+	return mesh
+}
+
+// GenerateBox generates a box with each face split into a grid. Faces do not
+// share vertices, so edges stay hard.
+func GenerateBox(width, height, depth float64, segmentsX, segmentsY, segmentsZ int) *Mesh {
+	half := Point{X: width / 2, Y: height / 2, Z: depth / 2}
+	coords := [3][]float64{
+		linspace(-half.X, half.X, maxInt(segmentsX, 1)),
+		linspace(-half.Y, half.Y, maxInt(segmentsY, 1)),
+		linspace(-half.Z, half.Z, maxInt(segmentsZ, 1)),
+	}
+	return generateBoxFaces(half, coords)
+}
+
+// GenerateRoundedBox generates a box whose edges and corners are rounded with
+// the given radius, using segments subdivisions across each rounded edge
+func GenerateRoundedBox(width, height, depth, radius float64, segments int) *Mesh {
+	half := Point{X: width / 2, Y: height / 2, Z: depth / 2}
+	radius = clampFloat(radius, 0, math.Min(half.X, math.Min(half.Y, half.Z)))
+	segments = maxInt(segments, 1)
+	inner := Point{X: half.X - radius, Y: half.Y - radius, Z: half.Z - radius}
+
+	// Grid lines are spaced so each rounded band gets evenly spaced angles
+	coords := [3][]float64{
+		roundedBoxCoords(inner.X, radius, segments),
+		roundedBoxCoords(inner.Y, radius, segments),
+		roundedBoxCoords(inner.Z, radius, segments),
+	}
+	mesh := generateBoxFaces(half, coords)
+
+	// Push every vertex outside the inner box onto a sphere of the radius
+	// around its closest inner point
+	for i, p := range mesh.Vertices {
+		q := Point{
+			X: clampFloat(p.X, -inner.X, inner.X),
+			Y: clampFloat(p.Y, -inner.Y, inner.Y),
+			Z: clampFloat(p.Z, -inner.Z, inner.Z),
+		}
+		d := subPoints(p, q)
+		if pointLength(d) < 1e-12 {
+			continue
+		}
+		n := normalizePoint(d)
+		mesh.Vertices[i] = addScaled(q, n, radius)
+		mesh.Normals[i] = n
+	}
+
+	return mesh
+}
+
+// GenerateCylinder generates a capped cylinder along Y
+func GenerateCylinder(radius, height float64, radialSegments, heightSegments int) *Mesh {
+	return generateFrustum(radius, radius, height, radialSegments, heightSegments)
+}
+
+// GenerateCone generates a cone along Y with its base cap at the bottom
+func GenerateCone(radius, height float64, radialSegments, heightSegments int) *Mesh {
+	return generateFrustum(radius, 0, height, radialSegments, heightSegments)
+}
+
+// GenerateCapsule generates a cylinder of the given height with hemispherical
+// ends, so its total height is height + 2*radius
+func GenerateCapsule(radius, height float64, radialSegments, capRings int) *Mesh {
+	capRings = maxInt(capRings, 1)
+	rows := make([]revolveRow, 0, 2*capRings+2)
+
+	// Bottom hemisphere, cylinder wall, top hemisphere
+	for k := 0; k <= capRings; k++ {
+		lat := -math.Pi/2 + math.Pi/2*float64(k)/float64(capRings)
+		rows = append(rows, revolveRow{
+			Radius: radius * math.Cos(lat), Y: -height/2 + radius*math.Sin(lat),
+			NormalR: math.Cos(lat), NormalY: math.Sin(lat),
+		})
+	}
+	for k := 0; k <= capRings; k++ {
+		lat := math.Pi / 2 * float64(k) / float64(capRings)
+		rows = append(rows, revolveRow{
+			Radius: radius * math.Cos(lat), Y: height/2 + radius*math.Sin(lat),
+			NormalR: math.Cos(lat), NormalY: math.Sin(lat),
+		})
+	}
+	assignRowV(rows)
+
+	mesh := NewMesh()
+	addRevolvedRows(mesh, rows, radialSegments)
+	return mesh
+}
+
+// GenerateIcosphere generates a sphere by subdividing an icosahedron, which
+// spreads vertices far more evenly than GenerateSphere. Vertices along the UV
+// seam and at the poles are duplicated.
+func GenerateIcosphere(radius float64, subdivisions int) *Mesh {
+	t := (1 + math.Sqrt(5)) / 2
+	positions := []Point{
+		{X: -1, Y: t}, {X: 1, Y: t}, {X: -1, Y: -t}, {X: 1, Y: -t},
+		{Y: -1, Z: t}, {Y: 1, Z: t}, {Y: -1, Z: -t}, {Y: 1, Z: -t},
+		{X: t, Z: -1}, {X: t, Z: 1}, {X: -t, Z: -1}, {X: -t, Z: 1},
+	}
+	for i := range positions {
+		positions[i] = normalizePoint(positions[i])
+	}
+	faces := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	// Split every triangle into four, sharing edge midpoints
+	for s := 0; s < subdivisions; s++ {
+		midpoints := make(map[[2]int]int)
+		midpoint := func(a, b int) int {
+			key := [2]int{minInt(a, b), maxInt(a, b)}
+			if idx, ok := midpoints[key]; ok {
+				return idx
+			}
+			positions = append(positions, normalizePoint(lerpPoint(positions[a], positions[b], 0.5)))
+			midpoints[key] = len(positions) - 1
+			return len(positions) - 1
+		}
+
+		next := make([][3]int, 0, len(faces)*4)
+		for _, f := range faces {
+			ab, bc, ca := midpoint(f[0], f[1]), midpoint(f[1], f[2]), midpoint(f[2], f[0])
+			next = append(next, [3]int{f[0], ab, ca}, [3]int{f[1], bc, ab}, [3]int{f[2], ca, bc}, [3]int{ab, bc, ca})
+		}
+		faces = next
+	}
+
+	// Spherical UVs per corner; corners with the same position and UV share
+	// a vertex
+	mesh := NewMesh()
+	type cornerKey struct {
+		position int
+		uv       TextureCoord
+	}
+	vertices := make(map[cornerKey]int)
+	sphericalUV := func(p Point) TextureCoord {
+		u := math.Atan2(p.Z, p.X) / (2 * math.Pi)
+		if u < 0 {
+			u++
+		}
+		return TextureCoord{U: u, V: math.Acos(clampFloat(p.Y, -1, 1)) / math.Pi}
+	}
+
+	for _, f := range faces {
+		var uvs [3]TextureCoord
+		for k, idx := range f {
+			uvs[k] = sphericalUV(positions[idx])
+		}
+
+		// Triangles crossing the seam wrap their small U values past 1
+		maxU := math.Max(uvs[0].U, math.Max(uvs[1].U, uvs[2].U))
+		for k := range uvs {
+			if maxU-uvs[k].U > 0.5 {
+				uvs[k].U++
+			}
+		}
+		// Pole vertices take the U of the rest of their triangle
+		for k, idx := range f {
+			if math.Abs(positions[idx].Y) > 1-1e-9 {
+				uvs[k].U = (uvs[(k+1)%3].U + uvs[(k+2)%3].U) / 2
+			}
+		}
+
+		var corner [3]int
+		for k, idx := range f {
+			key := cornerKey{position: idx, uv: uvs[k]}
+			v, ok := vertices[key]
+			if !ok {
+				p := positions[idx]
+				v = mesh.AddVertexWithUV(p.X*radius, p.Y*radius, p.Z*radius, uvs[k].U, uvs[k].V)
+				mesh.Normals = append(mesh.Normals, p)
+				vertices[key] = v
+			}
+			corner[k] = v
+		}
+		mesh.AddTriangleIndices(corner[0], corner[1], corner[2])
+	}
+
+	return mesh
+}
+
+// generateFrustum generates a capped truncated cone along Y; a zero radius
+// closes that end to a point instead of capping it
+func generateFrustum(radiusBottom, radiusTop, height float64, radialSegments, heightSegments int) *Mesh {
+	heightSegments = maxInt(heightSegments, 1)
+
+	// Side normals tilt with the slope
+	slope := math.Hypot(height, radiusBottom-radiusTop)
+	normalR, normalY := height/slope, (radiusBottom-radiusTop)/slope
+
+	rows := make([]revolveRow, heightSegments+1)
+	for k := range rows {
+		f := float64(k) / float64(heightSegments)
+		rows[k] = revolveRow{
+			Radius:  radiusBottom + (radiusTop-radiusBottom)*f,
+			Y:       -height/2 + height*f,
+			NormalR: normalR,
+			NormalY: normalY,
+			V:       1 - f,
+		}
+	}
+
+	mesh := NewMesh()
+	addRevolvedRows(mesh, rows, radialSegments)
+	if radiusBottom > 0 {
+		addDiskCap(mesh, -height/2, radiusBottom, radialSegments, false)
+	}
+	if radiusTop > 0 {
+		addDiskCap(mesh, height/2, radiusTop, radialSegments, true)
+	}
+	return mesh
+}
+
+// generateBoxFaces builds the six faces of a box with the given half extents,
+// placing grid lines at the given coordinates along each axis
+func generateBoxFaces(half Point, coords [3][]float64) *Mesh {
+	mesh := NewMesh()
+	axis := func(p Point, i int) float64 {
+		return [3]float64{p.X, p.Y, p.Z}[i]
+	}
+	unit := [3]Point{{X: 1}, {Y: 1}, {Z: 1}}
+
+	// For each face: normal axis and sign, then the U and V axes and signs,
+	// chosen so cross(U, V) is the outward normal
+	faces := [6]struct {
+		normal, u, v        int
+		nSign, uSign, vSign float64
+	}{
+		{0, 2, 1, 1, -1, 1},  // +X
+		{0, 2, 1, -1, 1, 1},  // -X
+		{1, 0, 2, 1, 1, -1},  // +Y
+		{1, 0, 2, -1, 1, 1},  // -Y
+		{2, 0, 1, 1, 1, 1},   // +Z
+		{2, 0, 1, -1, -1, 1}, // -Z
+	}
+
+	for _, f := range faces {
+		center := scalePoint(unit[f.normal], f.nSign*axis(half, f.normal))
+		addGridFace(mesh, center, scalePoint(unit[f.u], f.uSign), scalePoint(unit[f.v], f.vSign), coords[f.u], coords[f.v])
+	}
+	return mesh
+}
+
+// roundedBoxCoords returns grid coordinates along one axis of a rounded box:
+// the flat middle plus segments steps through each rounded band, spaced by
+// equal angles once projected onto the rounding
+func roundedBoxCoords(inner, radius float64, segments int) []float64 {
+	band := make([]float64, segments+1)
+	for k := range band {
+		band[k] = radius * math.Tan(math.Pi/4*float64(k)/float64(segments))
+	}
+
+	coords := make([]float64, 0, 2*segments+2)
+	for k := segments; k >= 1; k-- {
+		coords = append(coords, -inner-band[k])
+	}
+	coords = append(coords, -inner)
+	if inner > 1e-9 {
+		coords = append(coords, inner)
+	}
+	for k := 1; k <= segments; k++ {
+		coords = append(coords, inner+band[k])
+	}
+	return coords
+}
+
+// addGridFace adds a grid of quads around center spanning uCoords along uDir
+// and vCoords along vDir. The face normal is cross(uDir, vDir).
+func addGridFace(mesh *Mesh, center, uDir, vDir Point, uCoords, vCoords []float64) {
+	normal := crossPoints(uDir, vDir)
+	first := len(mesh.Vertices)
+	cols := len(uCoords)
+
+	uSpan := uCoords[len(uCoords)-1] - uCoords[0]
+	vSpan := vCoords[len(vCoords)-1] - vCoords[0]
+	for _, cv := range vCoords {
+		for _, cu := range uCoords {
+			p := addScaled(addScaled(center, uDir, cu), vDir, cv)
+			mesh.AddVertexWithUV(p.X, p.Y, p.Z, (cu-uCoords[0])/uSpan, 1-(cv-vCoords[0])/vSpan)
+			mesh.Normals = append(mesh.Normals, normal)
+		}
+	}
+
+	for j := 0; j < len(vCoords)-1; j++ {
+		for i := 0; i < cols-1; i++ {
+			a := first + j*cols + i
+			mesh.AddQuadIndices(a, a+1, a+cols+1, a+cols)
+		}
+	}
+}
+
+// revolveRow is one ring of a surface of revolution around Y
+type revolveRow struct {
+	Radius, Y        float64
+	NormalR, NormalY float64 // Normal in the (radius, height) plane
+	V                float64
+	Split            bool // Not joined to the previous row (hard edge)
+}
+
+// addRevolvedRows sweeps rows around the Y axis. Rows run bottom to top for
+// outward-facing triangles; rows on the axis produce no degenerate triangles.
+func addRevolvedRows(mesh *Mesh, rows []revolveRow, segments int) {
+	segments = maxInt(segments, 3)
+	first := len(mesh.Vertices)
+	stride := segments + 1
+
+	for _, row := range rows {
+		for s := 0; s <= segments; s++ {
+			u := float64(s) / float64(segments)
+			theta := 2 * math.Pi * u
+			cos, sin := math.Cos(theta), math.Sin(theta)
+			mesh.AddVertexWithUV(row.Radius*cos, row.Y, row.Radius*sin, u, row.V)
+			mesh.Normals = append(mesh.Normals, normalizePoint(Point{X: row.NormalR * cos, Y: row.NormalY, Z: row.NormalR * sin}))
+		}
+	}
+
+	for r := 0; r < len(rows)-1; r++ {
+		if rows[r+1].Split {
+			continue
+		}
+		for s := 0; s < segments; s++ {
+			curr := first + r*stride + s
+			next := curr + 1
+			above := curr + stride
+			aboveNext := above + 1
+
+			if rows[r].Radius > 1e-12 {
+				mesh.AddTriangleIndices(curr, above, next)
+			}
+			if rows[r+1].Radius > 1e-12 {
+				mesh.AddTriangleIndices(next, above, aboveNext)
+			}
+		}
+	}
+}
+
+// addDiskCap adds a flat disk at height y facing +Y (top) or -Y
+func addDiskCap(mesh *Mesh, y, radius float64, segments int, top bool) {
+	segments = maxInt(segments, 3)
+	normal := Point{Y: -1}
+	if top {
+		normal = Point{Y: 1}
+	}
+
+	center := mesh.AddVertexWithUV(0, y, 0, 0.5, 0.5)
+	mesh.Normals = append(mesh.Normals, normal)
+	for s := 0; s <= segments; s++ {
+		theta := 2 * math.Pi * float64(s) / float64(segments)
+		cos, sin := math.Cos(theta), math.Sin(theta)
+		mesh.AddVertexWithUV(radius*cos, y, radius*sin, 0.5+0.5*cos, 0.5+0.5*sin)
+		mesh.Normals = append(mesh.Normals, normal)
+	}
+
+	for s := 0; s < segments; s++ {
+		a, b := center+1+s, center+2+s
+		if top {
+			mesh.AddTriangleIndices(center, b, a)
+		} else {
+			mesh.AddTriangleIndices(center, a, b)
+		}
+	}
+}
+
+// assignRowV sets each row's V from the distance along the profile, 0 at the
+// top row and 1 at the bottom
+func assignRowV(rows []revolveRow) {
+	lengths := make([]float64, len(rows))
+	for i := 1; i < len(rows); i++ {
+		lengths[i] = lengths[i-1] + math.Hypot(rows[i].Radius-rows[i-1].Radius, rows[i].Y-rows[i-1].Y)
+	}
+	total := lengths[len(lengths)-1]
+	for i := range rows {
+		if total > 0 {
+			rows[i].V = 1 - lengths[i]/total
+		}
+	}
+}
+
+// linspace returns segments+1 evenly spaced values from a to b
+func linspace(a, b float64, segments int) []float64 {
+	values := make([]float64, segments+1)
+	for i := range values {
+		values[i] = a + (b-a)*float64(i)/float64(segments)
+	}
+	return values
+}
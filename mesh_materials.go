@@ -0,0 +1,81 @@
+package main
+
+// ============================================================================
+// PER-FACE MATERIALS
+// ============================================================================
+// A mesh normally has one material. Meshes assembled from several sources
+// (CSG results, merged imports) can instead give every triangle its own
+// material in FaceMaterials. Backends that bind one material per draw split
+// such meshes with SplitByMaterial.
+// ============================================================================
+
+// HasFaceMaterials reports whether the mesh has a material for every triangle
+func (m *Mesh) HasFaceMaterials() bool {
+	return len(m.Indices) > 0 && len(m.FaceMaterials) == len(m.Indices)/3
+}
+
+// MaterialForFace returns the material of a triangle
+func (m *Mesh) MaterialForFace(face int) IMaterial {
+	if m.HasFaceMaterials() && face >= 0 && face < len(m.FaceMaterials) {
+		return m.FaceMaterials[face]
+	}
+	return m.Material
+}
+
+// SplitByMaterial returns one mesh per distinct material, in order of first
+// use. Each part keeps only the vertices its triangles use, with their UVs,
+// normals, tangents and colors. A mesh without face materials is returned as
+// is.
+func (m *Mesh) SplitByMaterial() []*Mesh {
+	if !m.HasFaceMaterials() {
+		return []*Mesh{m}
+	}
+
+	hasUVs := len(m.UVs) == len(m.Vertices)
+	hasNormals := m.HasNormals()
+	hasTangents := m.HasTangents()
+	hasColors := m.HasColors()
+
+	var parts []*Mesh
+	partIndex := make(map[IMaterial]int)
+	remaps := make([]map[int]int, 0)
+
+	for face := 0; face < len(m.FaceMaterials); face++ {
+		material := m.FaceMaterials[face]
+		p, ok := partIndex[material]
+		if !ok {
+			part := NewMesh()
+			part.Position = m.Position
+			part.Material = material
+			p = len(parts)
+			parts = append(parts, part)
+			partIndex[material] = p
+			remaps = append(remaps, make(map[int]int))
+		}
+		part, remap := parts[p], remaps[p]
+
+		for _, idx := range m.Indices[face*3 : face*3+3] {
+			mapped, ok := remap[idx]
+			if !ok {
+				mapped = len(part.Vertices)
+				remap[idx] = mapped
+				part.Vertices = append(part.Vertices, m.Vertices[idx])
+				if hasUVs {
+					part.UVs = append(part.UVs, m.UVs[idx])
+				}
+				if hasNormals {
+					part.Normals = append(part.Normals, m.Normals[idx])
+				}
+				if hasTangents {
+					part.Tangents = append(part.Tangents, m.Tangents[idx])
+				}
+				if hasColors {
+					part.Colors = append(part.Colors, m.Colors[idx])
+				}
+			}
+			part.Indices = append(part.Indices, mapped)
+		}
+	}
+
+	return parts
+}
@@ -0,0 +1,178 @@
+package main
+
+import "math"
+
+// ============================================================================
+// VERTEX NORMALS
+// ============================================================================
+// Smooth shading interpolates per-vertex normals across each face. Normals are
+// generated by averaging the faces around a vertex, weighted by the angle each
+// face makes at that corner, so the result does not depend on how the
+// surface is triangulated. Faces meeting at more than the crease angle are
+// kept apart: their shared vertices are split so hard edges stay sharp.
+// ============================================================================
+
+// DefaultCreaseAngle is the largest angle between faces that is smoothed over
+const DefaultCreaseAngle = math.Pi / 3
+
+// HasNormals reports whether the mesh has a normal for every vertex
+func (m *Mesh) HasNormals() bool {
+	return len(m.Vertices) > 0 && len(m.Normals) == len(m.Vertices)
+}
+
+// GenerateNormals computes angle-weighted vertex normals. Corners at the same
+// position are smoothed together (seams and duplicated vertices included)
+// unless their faces differ by more than creaseAngle radians; vertices whose
+// corners end up with different normals are duplicated.
+func (m *Mesh) GenerateNormals(creaseAngle float64) {
+	triCount := len(m.Indices) / 3
+	if triCount == 0 {
+		m.Normals = nil
+		return
+	}
+
+	faceNormals := make([]Point, triCount)
+	cornerAngles := make([]float64, triCount*3)
+	for t := 0; t < triCount; t++ {
+		p := [3]Point{
+			m.Vertices[m.Indices[t*3]],
+			m.Vertices[m.Indices[t*3+1]],
+			m.Vertices[m.Indices[t*3+2]],
+		}
+
+		nx, ny, nz := crossProduct(p[1].X-p[0].X, p[1].Y-p[0].Y, p[1].Z-p[0].Z,
+			p[2].X-p[0].X, p[2].Y-p[0].Y, p[2].Z-p[0].Z)
+		if length := math.Sqrt(nx*nx + ny*ny + nz*nz); length > 1e-12 {
+			faceNormals[t] = Point{X: nx / length, Y: ny / length, Z: nz / length}
+		}
+
+		for k := 0; k < 3; k++ {
+			cornerAngles[t*3+k] = cornerAngle(p[k], p[(k+1)%3], p[(k+2)%3])
+		}
+	}
+
+	// Corners sharing a position
+	groups := make(map[Point][]int)
+	for c, idx := range m.Indices[:triCount*3] {
+		key := weldKey(m.Vertices[idx])
+		groups[key] = append(groups[key], c)
+	}
+
+	cosCrease := math.Cos(creaseAngle)
+	cornerNormals := make([]Point, triCount*3)
+	for c := range cornerNormals {
+		fn := faceNormals[c/3]
+		degenerate := fn == (Point{})
+
+		var sum Point
+		for _, d := range groups[weldKey(m.Vertices[m.Indices[c]])] {
+			fd := faceNormals[d/3]
+			if !degenerate && dotProduct(fn.X, fn.Y, fn.Z, fd.X, fd.Y, fd.Z) < cosCrease-1e-9 {
+				continue
+			}
+			w := cornerAngles[d]
+			sum.X += fd.X * w
+			sum.Y += fd.Y * w
+			sum.Z += fd.Z * w
+		}
+		if sum.X*sum.X+sum.Y*sum.Y+sum.Z*sum.Z < 1e-24 {
+			sum = fn
+		}
+		x, y, z := normalizeVector(sum.X, sum.Y, sum.Z)
+		cornerNormals[c] = Point{X: x, Y: y, Z: z}
+	}
+
+	// Assign corner normals to vertices, splitting vertices used with
+	// different normals
+	vertexCount := len(m.Vertices)
+	m.Normals = make([]Point, vertexCount)
+	assigned := make([]bool, vertexCount)
+	splits := make(map[int][]int) // Original vertex -> duplicates
+	hasUVs := len(m.UVs) == vertexCount
+	hasColors := len(m.Colors) == vertexCount
+
+	sameNormal := func(a, b Point) bool {
+		return dotProduct(a.X, a.Y, a.Z, b.X, b.Y, b.Z) > 1-1e-9
+	}
+
+	for c, n := range cornerNormals {
+		v := m.Indices[c]
+		if !assigned[v] {
+			m.Normals[v] = n
+			assigned[v] = true
+			continue
+		}
+		if sameNormal(m.Normals[v], n) {
+			continue
+		}
+
+		target := -1
+		for _, dup := range splits[v] {
+			if sameNormal(m.Normals[dup], n) {
+				target = dup
+				break
+			}
+		}
+		if target < 0 {
+			target = len(m.Vertices)
+			m.Vertices = append(m.Vertices, m.Vertices[v])
+			m.Normals = append(m.Normals, n)
+			if hasUVs {
+				m.UVs = append(m.UVs, m.UVs[v])
+			}
+			if hasColors {
+				m.Colors = append(m.Colors, m.Colors[v])
+			}
+			splits[v] = append(splits[v], target)
+		}
+		m.Indices[c] = target
+	}
+
+	if len(m.Vertices) != vertexCount {
+		m.Meshlets = nil
+	}
+
+	// Unreferenced vertices keep a valid normal
+	for v := 0; v < vertexCount; v++ {
+		if !assigned[v] {
+			m.Normals[v] = Point{X: 0, Y: 1, Z: 0}
+		}
+	}
+}
+
+// InterpolateNormal returns the normal at a point on a triangle with vertex
+// normals, or the face normal when it has none
+func InterpolateNormal(t *Triangle, p Point, faceNormal Point) Point {
+	if !t.HasVertexNormals {
+		return faceNormal
+	}
+
+	w0, w1, w2 := barycentric3D(p, t.P0, t.P1, t.P2)
+	x, y, z := normalizeVector(
+		w0*t.N0.X+w1*t.N1.X+w2*t.N2.X,
+		w0*t.N0.Y+w1*t.N1.Y+w2*t.N2.Y,
+		w0*t.N0.Z+w1*t.N1.Z+w2*t.N2.Z,
+	)
+	return Point{X: x, Y: y, Z: z}
+}
+
+// cornerAngle returns the angle at corner a of triangle abc
+func cornerAngle(a, b, c Point) float64 {
+	ux, uy, uz := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+	vx, vy, vz := c.X-a.X, c.Y-a.Y, c.Z-a.Z
+	lu := math.Sqrt(ux*ux + uy*uy + uz*uz)
+	lv := math.Sqrt(vx*vx + vy*vy + vz*vz)
+	if lu < 1e-12 || lv < 1e-12 {
+		return 0
+	}
+	return math.Acos(clampFloat(dotProduct(ux, uy, uz, vx, vy, vz)/(lu*lv), -1, 1))
+}
+
+// weldKey rounds a position so nearly coincident vertices compare equal
+func weldKey(p Point) Point {
+	return Point{
+		X: math.Round(p.X*1e5) / 1e5,
+		Y: math.Round(p.Y*1e5) / 1e5,
+		Z: math.Round(p.Z*1e5) / 1e5,
+	}
+}
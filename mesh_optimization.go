@@ -0,0 +1,564 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// INDEX AND VERTEX ORDER OPTIMIZATION
+// ============================================================================
+// GPUs keep the last few transformed vertices in a small cache, so a vertex
+// shared by neighbouring triangles is only shaded once if those triangles
+// are drawn close together. Loaders emit triangles in file order, which for
+// exported models is often close to random. Three passes fix that:
+//
+//   - OptimizeVertexCache orders triangles for cache hits (Forsyth's
+//     scoring or Sander et al.'s Tipsify)
+//   - OptimizeOverdraw then moves whole patches of that order so outward
+//     facing parts draw first and hide what is behind them, without giving
+//     up more than a set fraction of the cache hits
+//   - OptimizeVertexFetch renumbers vertices in the order the indices use
+//     them, so vertex reads walk memory forwards
+//
+// Cache efficiency is reported as ACMR (vertex shader runs per triangle,
+// 3 at worst and around 0.5 for large grids) and ATVR (runs per distinct
+// vertex, 1 at best) from a FIFO cache simulation.
+// ============================================================================
+
+// VertexCacheAlgorithm selects how OptimizeVertexCache orders triangles
+type VertexCacheAlgorithm int
+
+const (
+	VertexCacheForsyth VertexCacheAlgorithm = iota // Best hit rate on any cache size
+	VertexCacheTipsify                             // Faster, tuned to one cache size
+)
+
+// VertexCacheStats are the results of a vertex cache simulation
+type VertexCacheStats struct {
+	CacheSize int
+	Triangles int
+	Vertices  int     // Distinct vertices referenced
+	Misses    int     // Vertex shader runs
+	ACMR      float64 // Average cache miss ratio: misses per triangle
+	ATVR      float64 // Average transformed vertex ratio: misses per vertex
+}
+
+func (s VertexCacheStats) String() string {
+	return fmt.Sprintf("ACMR %.3f, ATVR %.3f (%d misses, %d triangles, %d vertices, cache %d)",
+		s.ACMR, s.ATVR, s.Misses, s.Triangles, s.Vertices, s.CacheSize)
+}
+
+// AnalyzeVertexCache simulates a FIFO vertex cache over an index list
+func AnalyzeVertexCache(indices []int, vertexCount, cacheSize int) VertexCacheStats {
+	stats := VertexCacheStats{CacheSize: cacheSize, Triangles: len(indices) / 3}
+	cache := newVertexCacheSim(vertexCount, cacheSize)
+	seen := make([]bool, vertexCount)
+	for _, v := range indices[:stats.Triangles*3] {
+		if !seen[v] {
+			seen[v] = true
+			stats.Vertices++
+		}
+		if cache.access(v) {
+			stats.Misses++
+		}
+	}
+	if stats.Triangles > 0 {
+		stats.ACMR = float64(stats.Misses) / float64(stats.Triangles)
+	}
+	if stats.Vertices > 0 {
+		stats.ATVR = float64(stats.Misses) / float64(stats.Vertices)
+	}
+	return stats
+}
+
+// VertexCacheStats simulates a FIFO vertex cache over the mesh's indices
+func (m *Mesh) VertexCacheStats(cacheSize int) VertexCacheStats {
+	return AnalyzeVertexCache(m.Indices, len(m.Vertices), cacheSize)
+}
+
+// vertexCacheSim is a FIFO cache: a vertex is cached while fewer than size
+// misses have happened since it was loaded
+type vertexCacheSim struct {
+	stamp []int
+	time  int
+	size  int
+}
+
+func newVertexCacheSim(vertexCount, size int) *vertexCacheSim {
+	return &vertexCacheSim{stamp: make([]int, vertexCount), time: size + 1, size: size}
+}
+
+// access looks a vertex up, loading it on a miss. Returns true on a miss.
+func (c *vertexCacheSim) access(v int) bool {
+	if c.time-c.stamp[v] <= c.size {
+		return false
+	}
+	c.stamp[v] = c.time
+	c.time++
+	return true
+}
+
+// flush empties the cache
+func (c *vertexCacheSim) flush() {
+	c.time += c.size + 1
+}
+
+// vertexTriangles lists the triangles using each vertex, once per corner
+func vertexTriangles(indices []int, vertexCount int) [][]int {
+	counts := make([]int, vertexCount)
+	for _, v := range indices {
+		counts[v]++
+	}
+	adjacency := make([][]int, vertexCount)
+	for v, n := range counts {
+		adjacency[v] = make([]int, 0, n)
+	}
+	for i, v := range indices {
+		adjacency[v] = append(adjacency[v], i/3)
+	}
+	return adjacency
+}
+
+// ============================================================================
+// VERTEX CACHE ORDER
+// ============================================================================
+
+// Forsyth's scoring constants, from "Linear-Speed Vertex Cache Optimisation"
+const (
+	forsythCacheSize         = 32
+	forsythCacheDecayPower   = 1.5
+	forsythLastTriScore      = 0.75
+	forsythValenceBoostScale = 2.0
+	forsythValenceBoostPower = 0.5
+)
+
+// forsythScore rates a vertex by its cache position (-1: not cached) and
+// the triangles still waiting for it
+func forsythScore(cachePos, remaining int) float64 {
+	if remaining == 0 {
+		return -1
+	}
+	score := 0.0
+	if cachePos >= 0 {
+		if cachePos < 3 {
+			// Just used: discourage going straight back to it
+			score = forsythLastTriScore
+		} else {
+			score = math.Pow(1-float64(cachePos-3)/float64(forsythCacheSize-3), forsythCacheDecayPower)
+		}
+	}
+	// Vertices with few triangles left are worth finishing off
+	return score + forsythValenceBoostScale*math.Pow(float64(remaining), -forsythValenceBoostPower)
+}
+
+// forsythOrder returns triangle indices in Forsyth's order: always draw the
+// triangle whose vertices score highest in a modelled LRU cache
+func forsythOrder(indices []int, vertexCount int) []int {
+	triangles := len(indices) / 3
+	adjacency := vertexTriangles(indices, vertexCount)
+
+	cachePos := make([]int, vertexCount)
+	score := make([]float64, vertexCount)
+	for v := range adjacency {
+		cachePos[v] = -1
+		score[v] = forsythScore(-1, len(adjacency[v]))
+	}
+	triScore := make([]float64, triangles)
+	for i, v := range indices[:triangles*3] {
+		triScore[i/3] += score[v]
+	}
+
+	emitted := make([]bool, triangles)
+	order := make([]int, 0, triangles)
+	cache := make([]int, 0, forsythCacheSize+3)
+	next := make([]int, 0, forsythCacheSize+3)
+	best, cursor := -1, 0
+
+	for len(order) < triangles {
+		if best < 0 {
+			// Nothing cached has triangles left: start from the next unused one
+			for emitted[cursor] {
+				cursor++
+			}
+			best = cursor
+		}
+
+		t := best
+		emitted[t] = true
+		order = append(order, t)
+		tri := indices[t*3 : t*3+3]
+
+		// The triangle's vertices move to the front of the cache
+		next = next[:0]
+		for _, v := range tri {
+			if cachePos[v] != -2 {
+				next = append(next, v)
+				cachePos[v] = -2 // Marks it placed
+			}
+			list := adjacency[v]
+			for k, other := range list {
+				if other == t {
+					list[k] = list[len(list)-1]
+					adjacency[v] = list[:len(list)-1]
+					break
+				}
+			}
+		}
+		for _, v := range cache {
+			if cachePos[v] != -2 {
+				next = append(next, v)
+			}
+		}
+
+		// Rescore everything that moved, including what fell out the back
+		for i, v := range next {
+			if i < forsythCacheSize {
+				cachePos[v] = i
+			} else {
+				cachePos[v] = -1
+			}
+			s := forsythScore(cachePos[v], len(adjacency[v]))
+			delta := s - score[v]
+			score[v] = s
+			for _, other := range adjacency[v] {
+				triScore[other] += delta
+			}
+		}
+		if len(next) > forsythCacheSize {
+			next = next[:forsythCacheSize]
+		}
+		cache, next = next, cache
+
+		best = -1
+		bestScore := math.Inf(-1)
+		for _, v := range cache {
+			for _, other := range adjacency[v] {
+				if triScore[other] > bestScore {
+					best, bestScore = other, triScore[other]
+				}
+			}
+		}
+	}
+	return order
+}
+
+// tipsifyOrder returns triangle indices in Tipsify order (Sander, Nehab and
+// Barczak, "Fast Triangle Reordering for Vertex Locality and Reduced
+// Overdraw"): draw every remaining triangle around one vertex, then move to
+// the neighbour that will still be cached once its own fan is drawn
+func tipsifyOrder(indices []int, vertexCount, cacheSize int) []int {
+	triangles := len(indices) / 3
+	adjacency := vertexTriangles(indices, vertexCount)
+	live := make([]int, vertexCount)
+	for v := range adjacency {
+		live[v] = len(adjacency[v])
+	}
+
+	cache := newVertexCacheSim(vertexCount, cacheSize)
+	emitted := make([]bool, triangles)
+	order := make([]int, 0, triangles)
+	var deadEnd, candidates []int
+	cursor := 0
+
+	fan := nextLiveVertex(live, &cursor)
+	for fan >= 0 {
+		candidates = candidates[:0]
+		for _, t := range adjacency[fan] {
+			if emitted[t] {
+				continue
+			}
+			emitted[t] = true
+			order = append(order, t)
+			for _, v := range indices[t*3 : t*3+3] {
+				deadEnd = append(deadEnd, v)
+				candidates = append(candidates, v)
+				live[v]--
+				cache.access(v)
+			}
+		}
+
+		// Prefer the oldest neighbour whose fan still fits in the cache
+		fan = -1
+		bestPriority := -1
+		for _, v := range candidates {
+			if live[v] == 0 {
+				continue
+			}
+			priority := 0
+			if age := cache.time - cache.stamp[v]; age+2*live[v] <= cacheSize {
+				priority = age
+			}
+			if priority > bestPriority {
+				fan, bestPriority = v, priority
+			}
+		}
+		if fan >= 0 {
+			continue
+		}
+
+		// Dead end: back up to a recently used vertex, then to any
+		for len(deadEnd) > 0 && fan < 0 {
+			v := deadEnd[len(deadEnd)-1]
+			deadEnd = deadEnd[:len(deadEnd)-1]
+			if live[v] > 0 {
+				fan = v
+			}
+		}
+		if fan < 0 {
+			fan = nextLiveVertex(live, &cursor)
+		}
+	}
+	return order
+}
+
+// nextLiveVertex advances cursor to the next vertex with triangles left
+func nextLiveVertex(live []int, cursor *int) int {
+	for *cursor < len(live) && live[*cursor] == 0 {
+		*cursor++
+	}
+	if *cursor == len(live) {
+		return -1
+	}
+	return *cursor
+}
+
+// ============================================================================
+// OVERDRAW ORDER
+// ============================================================================
+
+// overdrawOrder groups triangles, in their current order, into patches and
+// sorts the patches so those facing away from the mesh's centre draw first.
+// Patches break wherever the cache starts cold, and also wherever the cache
+// hits so far are within threshold of the whole run's, so each patch keeps
+// its share of the cache hits.
+func overdrawOrder(indices []int, vertices []Point, cacheSize int, threshold float64) []int {
+	triangles := len(indices) / 3
+	cache := newVertexCacheSim(len(vertices), cacheSize)
+	misses := func(t int) int {
+		n := 0
+		for _, v := range indices[t*3 : t*3+3] {
+			if cache.access(v) {
+				n++
+			}
+		}
+		return n
+	}
+
+	// Hard boundaries: triangles missing on every corner
+	var hard []int
+	for t := 0; t < triangles; t++ {
+		if misses(t) == 3 {
+			hard = append(hard, t)
+		}
+	}
+	hard = append(hard, triangles)
+
+	// Soft boundaries within each run
+	var starts []int
+	for h := 0; h+1 < len(hard); h++ {
+		from, to := hard[h], hard[h+1]
+		cache.flush()
+		total := 0
+		for t := from; t < to; t++ {
+			total += misses(t)
+		}
+		limit := float64(total) / float64(to-from) * threshold
+
+		cache.flush()
+		start, count := from, 0
+		starts = append(starts, from)
+		for t := from; t < to-1; t++ {
+			count += misses(t)
+			if float64(count)/float64(t+1-start) <= limit {
+				start, count = t+1, 0
+				starts = append(starts, start)
+				cache.flush()
+			}
+		}
+	}
+	starts = append(starts, triangles)
+
+	// Area weighted centre and normal of each patch, and of the mesh
+	type patch struct {
+		from, to int
+		center   Point
+		normal   Point
+		area     float64
+		key      float64
+	}
+	patches := make([]patch, len(starts)-1)
+	meshCenter, meshArea := Point{}, 0.0
+	for i := range patches {
+		p := patch{from: starts[i], to: starts[i+1]}
+		for t := p.from; t < p.to; t++ {
+			a, b, c := vertices[indices[t*3]], vertices[indices[t*3+1]], vertices[indices[t*3+2]]
+			n := crossPoints(subPoints(b, a), subPoints(c, a))
+			area := pointLength(n) / 2
+			centroid := scalePoint(addPoints(addPoints(a, b), c), 1.0/3)
+			p.center = addPoints(p.center, scalePoint(centroid, area))
+			p.normal = addPoints(p.normal, n)
+			p.area += area
+		}
+		meshCenter = addPoints(meshCenter, p.center)
+		meshArea += p.area
+		if p.area > 0 {
+			p.center = scalePoint(p.center, 1/p.area)
+		}
+		patches[i] = p
+	}
+	if meshArea > 0 {
+		meshCenter = scalePoint(meshCenter, 1/meshArea)
+	}
+	for i := range patches {
+		if l := pointLength(patches[i].normal); l > 0 {
+			patches[i].key = dotPoints(subPoints(patches[i].center, meshCenter), patches[i].normal) / l
+		}
+	}
+	sort.SliceStable(patches, func(i, j int) bool {
+		return patches[i].key > patches[j].key
+	})
+
+	order := make([]int, 0, triangles)
+	for _, p := range patches {
+		for t := p.from; t < p.to; t++ {
+			order = append(order, t)
+		}
+	}
+	return order
+}
+
+// ============================================================================
+// MESH PASSES
+// ============================================================================
+
+// reorderTriangles moves triangle order[k] into slot k, carrying its
+// lightmap UVs and face material along
+func (m *Mesh) reorderTriangles(order []int) {
+	hasFaceMaterials := m.HasFaceMaterials()
+	hasLightmapUVs := len(m.LightmapUVs) == len(m.Indices)
+
+	indices := make([]int, 0, len(m.Indices))
+	var lightmapUVs []TextureCoord
+	var faceMaterials []IMaterial
+	if hasLightmapUVs {
+		lightmapUVs = make([]TextureCoord, 0, len(m.LightmapUVs))
+	}
+	if hasFaceMaterials {
+		faceMaterials = make([]IMaterial, 0, len(m.FaceMaterials))
+	}
+
+	moved := false
+	for k, t := range order {
+		moved = moved || k != t
+		indices = append(indices, m.Indices[t*3:t*3+3]...)
+		if hasLightmapUVs {
+			lightmapUVs = append(lightmapUVs, m.LightmapUVs[t*3:t*3+3]...)
+		}
+		if hasFaceMaterials {
+			faceMaterials = append(faceMaterials, m.FaceMaterials[t])
+		}
+	}
+
+	m.Indices = indices
+	if hasLightmapUVs {
+		m.LightmapUVs = lightmapUVs
+	}
+	if hasFaceMaterials {
+		m.FaceMaterials = faceMaterials
+	}
+	if moved {
+		// Triangles of one polygon or meshlet are no longer consecutive
+		m.FaceSizes = nil
+		m.Meshlets = nil
+	}
+}
+
+// OptimizeVertexCache reorders triangles for vertex cache hits. cacheSize is
+// the cache Tipsify targets; Forsyth's order suits any size.
+func (m *Mesh) OptimizeVertexCache(algorithm VertexCacheAlgorithm, cacheSize int) {
+	if len(m.Indices) < 6 {
+		return
+	}
+	switch algorithm {
+	case VertexCacheTipsify:
+		m.reorderTriangles(tipsifyOrder(m.Indices, len(m.Vertices), cacheSize))
+	default:
+		m.reorderTriangles(forsythOrder(m.Indices, len(m.Vertices)))
+	}
+}
+
+// OptimizeOverdraw reorders patches of triangles so outward facing ones draw
+// first. Run it after OptimizeVertexCache: threshold is how much worse than
+// the current order the ACMR may get, e.g. 1.05 for 5%.
+func (m *Mesh) OptimizeOverdraw(cacheSize int, threshold float64) {
+	if len(m.Indices) < 6 {
+		return
+	}
+	m.reorderTriangles(overdrawOrder(m.Indices, m.Vertices, cacheSize, threshold))
+}
+
+// OptimizeVertexFetch renumbers vertices in the order the indices first use
+// them, dropping any that are never used. Returns the number dropped.
+func (m *Mesh) OptimizeVertexFetch() int {
+	remap := make([]int, len(m.Vertices))
+	for i := range remap {
+		remap[i] = -1
+	}
+	count := 0
+	for _, v := range m.Indices {
+		if remap[v] < 0 {
+			remap[v] = count
+			count++
+		}
+	}
+	dropped := len(m.Vertices) - count
+	m.remapVertices(remap, count)
+	return dropped
+}
+
+// MeshOptimizationSettings selects the optimization passes
+type MeshOptimizationSettings struct {
+	Algorithm         VertexCacheAlgorithm // Triangle order for the vertex cache
+	CacheSize         int                  // Cache entries to optimize and report for
+	OverdrawThreshold float64              // ACMR allowed relative to the cache order, e.g. 1.05 (0: skip overdraw)
+	OptimizeFetch     bool                 // Renumber vertices in order of use
+}
+
+// DefaultMeshOptimizationSettings returns settings for a 16 entry cache,
+// trading up to 5% of the cache hits for less overdraw
+func DefaultMeshOptimizationSettings() MeshOptimizationSettings {
+	return MeshOptimizationSettings{
+		Algorithm:         VertexCacheForsyth,
+		CacheSize:         16,
+		OverdrawThreshold: 1.05,
+		OptimizeFetch:     true,
+	}
+}
+
+// MeshOptimizationReport compares the cache behaviour before and after
+// OptimizeMesh
+type MeshOptimizationReport struct {
+	Before          VertexCacheStats
+	After           VertexCacheStats
+	DroppedVertices int // Unused vertices removed by the fetch pass
+}
+
+// OptimizeMesh returns a copy of a mesh with its triangles and vertices
+// reordered. The geometry drawn is unchanged.
+func OptimizeMesh(mesh *Mesh, settings MeshOptimizationSettings) (*Mesh, MeshOptimizationReport) {
+	optimized := mesh.Clone()
+	report := MeshOptimizationReport{Before: optimized.VertexCacheStats(settings.CacheSize)}
+
+	optimized.OptimizeVertexCache(settings.Algorithm, settings.CacheSize)
+	if settings.OverdrawThreshold > 0 {
+		optimized.OptimizeOverdraw(settings.CacheSize, settings.OverdrawThreshold)
+	}
+	if settings.OptimizeFetch {
+		report.DroppedVertices = optimized.OptimizeVertexFetch()
+	}
+
+	report.After = optimized.VertexCacheStats(settings.CacheSize)
+	return optimized, report
+}
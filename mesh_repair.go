@@ -0,0 +1,835 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// ============================================================================
+// MESH VALIDATION AND REPAIR
+// ============================================================================
+// Imported meshes are often not clean: duplicated corners, zero-area
+// slivers, triangles listed twice, neighbours wound in opposite directions
+// and small holes. Inconsistent winding is the visible one, since
+// IsBackfacing culls every flipped triangle and leaves a hole.
+//
+// Topology is always taken from positions welded within a tolerance, so
+// UV seams and per-corner OBJ vertices do not count as boundaries. The
+// repair steps work in place and can be used on their own; RepairMesh runs
+// them in order on a copy.
+// ============================================================================
+
+// MeshReport summarises a mesh's topology
+type MeshReport struct {
+	Positions           int // Distinct positions after welding
+	Triangles           int
+	DegenerateTriangles int // Repeated corners or thinner than the tolerance
+	DuplicateTriangles  int // Same three positions as an earlier triangle
+	BoundaryEdges       int // Edges used by one triangle
+	NonManifoldEdges    int // Edges used by more than two triangles
+	InconsistentEdges   int // Edges both triangles traverse in the same direction
+	BoundaryLoops       int // Holes and open borders
+	Components          int // Edge-connected pieces
+}
+
+// IsManifold reports whether every edge has at most two triangles
+func (r MeshReport) IsManifold() bool {
+	return r.NonManifoldEdges == 0
+}
+
+// IsClosed reports whether the mesh is watertight
+func (r MeshReport) IsClosed() bool {
+	return r.BoundaryEdges == 0 && r.NonManifoldEdges == 0
+}
+
+// IsConsistent reports whether neighbouring triangles agree on winding
+func (r MeshReport) IsConsistent() bool {
+	return r.InconsistentEdges == 0
+}
+
+func (r MeshReport) String() string {
+	return fmt.Sprintf("%d positions, %d triangles (%d degenerate, %d duplicate), %d boundary / %d non-manifold / %d inconsistent edges, %d holes, %d components",
+		r.Positions, r.Triangles, r.DegenerateTriangles, r.DuplicateTriangles,
+		r.BoundaryEdges, r.NonManifoldEdges, r.InconsistentEdges, r.BoundaryLoops, r.Components)
+}
+
+// RepairSettings selects the repair steps
+type RepairSettings struct {
+	WeldTolerance     float64 // Positions closer than this are the same point (0: exact)
+	RemoveDegenerates bool    // Drop zero-area triangles
+	RemoveDuplicates  bool    // Drop triangles repeating an earlier one
+	FixWinding        bool    // Make winding consistent, closed parts facing outward
+	FixNormals        bool    // Flip vertex normals that point into their faces
+	MaxHoleEdges      int     // Fill holes with up to this many edges (0: leave holes)
+}
+
+// DefaultRepairSettings returns settings that run every step
+func DefaultRepairSettings() RepairSettings {
+	return RepairSettings{
+		WeldTolerance:     1e-5,
+		RemoveDegenerates: true,
+		RemoveDuplicates:  true,
+		FixWinding:        true,
+		FixNormals:        true,
+		MaxHoleEdges:      8,
+	}
+}
+
+// RepairReport counts what RepairMesh changed
+type RepairReport struct {
+	WeldedVertices      int
+	DegenerateTriangles int
+	DuplicateTriangles  int
+	FlippedTriangles    int
+	FlippedNormals      int
+	FilledHoles         int
+}
+
+// RepairMesh returns a repaired copy of a mesh
+func RepairMesh(mesh *Mesh, settings RepairSettings) (*Mesh, RepairReport) {
+	repaired := mesh.Clone()
+	report := RepairReport{}
+
+	report.WeldedVertices = repaired.WeldVertices(settings.WeldTolerance)
+	if settings.RemoveDegenerates {
+		report.DegenerateTriangles = repaired.RemoveDegenerateTriangles(settings.WeldTolerance)
+	}
+	if settings.RemoveDuplicates {
+		report.DuplicateTriangles = repaired.RemoveDuplicateTriangles(settings.WeldTolerance)
+	}
+	if settings.FixWinding {
+		report.FlippedTriangles = repaired.FixWinding(settings.WeldTolerance)
+	}
+	if settings.MaxHoleEdges >= 3 {
+		report.FilledHoles = repaired.FillHoles(settings.MaxHoleEdges, settings.WeldTolerance)
+	}
+	if settings.FixNormals {
+		report.FlippedNormals = repaired.FixNormals()
+	}
+
+	return repaired, report
+}
+
+// ============================================================================
+// TOPOLOGY
+// ============================================================================
+
+// weldPositions gives every vertex the id of the first earlier vertex within
+// tolerance of it. Returns the ids and the number of distinct positions.
+func weldPositions(vertices []Point, tolerance float64) ([]int, int) {
+	ids := make([]int, len(vertices))
+	if tolerance <= 0 {
+		exact := make(map[Point]int)
+		for i, v := range vertices {
+			id, ok := exact[v]
+			if !ok {
+				id = len(exact)
+				exact[v] = id
+			}
+			ids[i] = id
+		}
+		return ids, len(exact)
+	}
+
+	// Hash into cells one tolerance wide; a match can only be in a neighbour
+	type cell struct{ X, Y, Z int64 }
+	cellOf := func(p Point) cell {
+		return cell{
+			int64(math.Floor(p.X / tolerance)),
+			int64(math.Floor(p.Y / tolerance)),
+			int64(math.Floor(p.Z / tolerance)),
+		}
+	}
+	grid := make(map[cell][]int)
+	var reps []Point
+	tolSq := tolerance * tolerance
+
+	for i, v := range vertices {
+		c := cellOf(v)
+		id := -1
+	search:
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dz := int64(-1); dz <= 1; dz++ {
+					for _, candidate := range grid[cell{c.X + dx, c.Y + dy, c.Z + dz}] {
+						d := subPoints(v, reps[candidate])
+						if d.X*d.X+d.Y*d.Y+d.Z*d.Z <= tolSq {
+							id = candidate
+							break search
+						}
+					}
+				}
+			}
+		}
+		if id < 0 {
+			id = len(reps)
+			reps = append(reps, v)
+			grid[c] = append(grid[c], id)
+		}
+		ids[i] = id
+	}
+	return ids, len(reps)
+}
+
+// meshHalfEdge is one triangle's use of an edge
+type meshHalfEdge struct {
+	Face    int
+	Forward bool // The triangle runs from the lower position id to the higher
+}
+
+// meshTopology connects triangles through welded positions
+type meshTopology struct {
+	ids       []int // Position id per vertex
+	positions int
+	edges     map[[2]int][]meshHalfEdge
+	skipped   []bool // Triangles left out (repeated corners)
+}
+
+func buildMeshTopology(mesh *Mesh, tolerance float64) *meshTopology {
+	ids, count := weldPositions(mesh.Vertices, tolerance)
+	topo := &meshTopology{
+		ids:       ids,
+		positions: count,
+		edges:     make(map[[2]int][]meshHalfEdge),
+		skipped:   make([]bool, len(mesh.Indices)/3),
+	}
+
+	for t := range topo.skipped {
+		c := topo.corners(mesh, t)
+		if c[0] == c[1] || c[1] == c[2] || c[2] == c[0] {
+			topo.skipped[t] = true
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			a, b := c[k], c[(k+1)%3]
+			key := [2]int{a, b}
+			if a > b {
+				key = [2]int{b, a}
+			}
+			topo.edges[key] = append(topo.edges[key], meshHalfEdge{Face: t, Forward: a < b})
+		}
+	}
+	return topo
+}
+
+// corners returns the position ids of a triangle
+func (topo *meshTopology) corners(mesh *Mesh, t int) [3]int {
+	return [3]int{
+		topo.ids[mesh.Indices[t*3]],
+		topo.ids[mesh.Indices[t*3+1]],
+		topo.ids[mesh.Indices[t*3+2]],
+	}
+}
+
+// components labels triangles by edge-connected piece; skipped triangles get
+// -1. Returns the labels and the number of pieces.
+func (topo *meshTopology) components() ([]int, int) {
+	labels := make([]int, len(topo.skipped))
+	for i := range labels {
+		labels[i] = -1
+	}
+	adjacent := make([][]int, len(topo.skipped))
+	for _, uses := range topo.edges {
+		for _, a := range uses {
+			for _, b := range uses {
+				if a.Face != b.Face {
+					adjacent[a.Face] = append(adjacent[a.Face], b.Face)
+				}
+			}
+		}
+	}
+
+	count := 0
+	for start := range labels {
+		if topo.skipped[start] || labels[start] >= 0 {
+			continue
+		}
+		labels[start] = count
+		stack := []int{start}
+		for len(stack) > 0 {
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, g := range adjacent[f] {
+				if labels[g] < 0 {
+					labels[g] = count
+					stack = append(stack, g)
+				}
+			}
+		}
+		count++
+	}
+	return labels, count
+}
+
+// boundaryLoops follows boundary edges into closed loops, in the direction
+// a patch filling the hole would be wound. Each loop entry is a vertex index
+// from the triangle along that edge. Loops through a vertex where several
+// borders meet are not followed.
+func (topo *meshTopology) boundaryLoops(mesh *Mesh) (loops [][]int, faces []int) {
+	next := make(map[int]int)     // Position id -> next position id
+	vertexOf := make(map[int]int) // Position id -> vertex index on the border
+	faceOf := make(map[int]int)   // Position id -> triangle along the outgoing edge
+	ambiguous := make(map[int]bool)
+
+	for t, skipped := range topo.skipped {
+		if skipped {
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			va, vb := mesh.Indices[t*3+k], mesh.Indices[t*3+(k+1)%3]
+			a, b := topo.ids[va], topo.ids[vb]
+			key := [2]int{a, b}
+			if a > b {
+				key = [2]int{b, a}
+			}
+			if len(topo.edges[key]) != 1 || topo.edges[key][0].Face != t {
+				continue
+			}
+			// The triangle runs a -> b, so the patch runs b -> a
+			if _, taken := next[b]; taken {
+				ambiguous[b] = true
+			}
+			next[b] = a
+			vertexOf[a], vertexOf[b] = va, vb
+			faceOf[b] = t
+		}
+	}
+
+	visited := make(map[int]bool)
+	for start := 0; start < topo.positions; start++ {
+		if _, ok := next[start]; !ok || visited[start] {
+			continue
+		}
+		var loop []int
+		closed := false
+		for id := start; ; {
+			if ambiguous[id] || visited[id] {
+				closed = id == start && !ambiguous[id]
+				break
+			}
+			visited[id] = true
+			loop = append(loop, id)
+			n, ok := next[id]
+			if !ok {
+				break
+			}
+			id = n
+		}
+		if !closed || len(loop) < 3 {
+			continue
+		}
+		vertices := make([]int, len(loop))
+		for i, id := range loop {
+			vertices[i] = vertexOf[id]
+		}
+		loops = append(loops, vertices)
+		faces = append(faces, faceOf[loop[0]])
+	}
+	return loops, faces
+}
+
+// triangleIsDegenerate reports whether a triangle is thinner than tolerance
+func triangleIsDegenerate(p0, p1, p2 Point, tolerance float64) bool {
+	e0, e1, e2 := subPoints(p1, p0), subPoints(p2, p1), subPoints(p0, p2)
+	longest := math.Max(pointLength(e0), math.Max(pointLength(e1), pointLength(e2)))
+	if longest < 1e-12 {
+		return true
+	}
+	height := pointLength(crossPoints(e0, subPoints(p2, p0))) / longest
+	return height <= math.Max(tolerance, 1e-12)
+}
+
+// sortedTriangle returns a triangle's position ids in ascending order
+func sortedTriangle(c [3]int) [3]int {
+	if c[0] > c[1] {
+		c[0], c[1] = c[1], c[0]
+	}
+	if c[1] > c[2] {
+		c[1], c[2] = c[2], c[1]
+	}
+	if c[0] > c[1] {
+		c[0], c[1] = c[1], c[0]
+	}
+	return c
+}
+
+// AnalyzeMesh reports a mesh's defects without changing it
+func AnalyzeMesh(mesh *Mesh, tolerance float64) MeshReport {
+	topo := buildMeshTopology(mesh, tolerance)
+	report := MeshReport{
+		Positions: topo.positions,
+		Triangles: len(mesh.Indices) / 3,
+	}
+
+	seen := make(map[[3]int]bool)
+	for t := 0; t < report.Triangles; t++ {
+		p0, p1, p2 := mesh.Vertices[mesh.Indices[t*3]], mesh.Vertices[mesh.Indices[t*3+1]], mesh.Vertices[mesh.Indices[t*3+2]]
+		if topo.skipped[t] || triangleIsDegenerate(p0, p1, p2, tolerance) {
+			report.DegenerateTriangles++
+			continue
+		}
+		key := sortedTriangle(topo.corners(mesh, t))
+		if seen[key] {
+			report.DuplicateTriangles++
+		}
+		seen[key] = true
+	}
+
+	for _, uses := range topo.edges {
+		switch {
+		case len(uses) == 1:
+			report.BoundaryEdges++
+		case len(uses) > 2:
+			report.NonManifoldEdges++
+		case uses[0].Forward == uses[1].Forward:
+			report.InconsistentEdges++
+		}
+	}
+
+	loops, _ := topo.boundaryLoops(mesh)
+	report.BoundaryLoops = len(loops)
+	_, report.Components = topo.components()
+	return report
+}
+
+// ============================================================================
+// REPAIR STEPS
+// ============================================================================
+
+// WeldVertices merges vertices within tolerance of each other whose UVs,
+// normals, tangents and colors also match, snapping them onto one position,
+// and drops vertices no triangle uses. Vertices on a UV or normal seam are
+// snapped together but kept apart. Returns the number of vertices removed.
+func (m *Mesh) WeldVertices(tolerance float64) int {
+	hasUVs := len(m.UVs) == len(m.Vertices)
+	hasNormals := m.HasNormals()
+	hasTangents := m.HasTangents()
+	hasColors := m.HasColors()
+	round := func(x float64) float64 { return math.Round(x*1e6) / 1e6 }
+
+	type vertexKey struct {
+		Position int
+		UV       TextureCoord
+		Normal   Point
+		Tangent  Tangent
+		Color    Color
+	}
+
+	ids, _ := weldPositions(m.Vertices, tolerance)
+	used := make([]bool, len(m.Vertices))
+	for _, idx := range m.Indices {
+		used[idx] = true
+	}
+
+	firstOf := make(map[int]int) // Position id -> first vertex at it
+	merged := make(map[vertexKey]int)
+	remap := make([]int, len(m.Vertices))
+	count := 0
+	for i := range m.Vertices {
+		remap[i] = -1
+		if !used[i] {
+			continue
+		}
+		if _, ok := firstOf[ids[i]]; !ok {
+			firstOf[ids[i]] = i
+		}
+		key := vertexKey{Position: ids[i]}
+		if hasUVs {
+			key.UV = TextureCoord{U: round(m.UVs[i].U), V: round(m.UVs[i].V)}
+		}
+		if hasNormals {
+			n := m.Normals[i]
+			key.Normal = Point{X: round(n.X), Y: round(n.Y), Z: round(n.Z)}
+		}
+		if hasTangents {
+			tg := m.Tangents[i]
+			key.Tangent = Tangent{X: round(tg.X), Y: round(tg.Y), Z: round(tg.Z), W: tg.W}
+		}
+		if hasColors {
+			key.Color = m.Colors[i]
+		}
+		idx, ok := merged[key]
+		if !ok {
+			idx = count
+			merged[key] = idx
+			count++
+		}
+		remap[i] = idx
+	}
+
+	removed := len(m.Vertices) - count
+	snapped := make([]Point, len(m.Vertices))
+	for i := range m.Vertices {
+		if used[i] {
+			snapped[i] = m.Vertices[firstOf[ids[i]]]
+		}
+	}
+	m.Vertices = snapped
+	m.remapVertices(remap, count)
+	return removed
+}
+
+// remapVertices moves vertex i to remap[i] (dropped when negative), keeping
+// the first vertex's attributes where several land on one index
+func (m *Mesh) remapVertices(remap []int, count int) {
+	hasUVs := len(m.UVs) == len(m.Vertices)
+	hasNormals := m.HasNormals()
+	hasTangents := m.HasTangents()
+	hasColors := m.HasColors()
+
+	vertices := make([]Point, count)
+	var uvs []TextureCoord
+	var normals []Point
+	var tangents []Tangent
+	var colors []Color
+	if hasUVs {
+		uvs = make([]TextureCoord, count)
+	}
+	if hasNormals {
+		normals = make([]Point, count)
+	}
+	if hasTangents {
+		tangents = make([]Tangent, count)
+	}
+	if hasColors {
+		colors = make([]Color, count)
+	}
+
+	set := make([]bool, count)
+	for i, j := range remap {
+		if j < 0 || set[j] {
+			continue
+		}
+		set[j] = true
+		vertices[j] = m.Vertices[i]
+		if hasUVs {
+			uvs[j] = m.UVs[i]
+		}
+		if hasNormals {
+			normals[j] = m.Normals[i]
+		}
+		if hasTangents {
+			tangents[j] = m.Tangents[i]
+		}
+		if hasColors {
+			colors[j] = m.Colors[i]
+		}
+	}
+
+	m.Vertices, m.UVs, m.Normals, m.Tangents, m.Colors = vertices, uvs, normals, tangents, colors
+	for i, idx := range m.Indices {
+		m.Indices[i] = remap[idx]
+	}
+	m.Meshlets = nil // Their vertex lists use the old numbering
+}
+
+// keepTriangles drops the triangles not marked in keep, with their face
+// materials and lightmap UVs. Polygon grouping is dropped once a polygon
+// may have lost a triangle. Returns the number removed.
+func (m *Mesh) keepTriangles(keep []bool) int {
+	hasFaceMaterials := m.HasFaceMaterials()
+	hasLightmapUVs := len(m.LightmapUVs) == len(m.Indices)
+
+	removed := 0
+	out := 0
+	for t, k := range keep {
+		if !k {
+			removed++
+			continue
+		}
+		copy(m.Indices[out*3:out*3+3], m.Indices[t*3:t*3+3])
+		if hasLightmapUVs {
+			copy(m.LightmapUVs[out*3:out*3+3], m.LightmapUVs[t*3:t*3+3])
+		}
+		if hasFaceMaterials {
+			m.FaceMaterials[out] = m.FaceMaterials[t]
+		}
+		out++
+	}
+	if removed == 0 {
+		return 0
+	}
+
+	m.Indices = m.Indices[:out*3]
+	if hasLightmapUVs {
+		m.LightmapUVs = m.LightmapUVs[:out*3]
+	}
+	if hasFaceMaterials {
+		m.FaceMaterials = m.FaceMaterials[:out]
+	}
+	m.FaceSizes = nil
+	m.Meshlets = nil
+	return removed
+}
+
+// RemoveDegenerateTriangles drops triangles with repeated corners or thinner
+// than tolerance. Returns the number removed.
+func (m *Mesh) RemoveDegenerateTriangles(tolerance float64) int {
+	ids, _ := weldPositions(m.Vertices, tolerance)
+	keep := make([]bool, len(m.Indices)/3)
+	for t := range keep {
+		i0, i1, i2 := m.Indices[t*3], m.Indices[t*3+1], m.Indices[t*3+2]
+		repeated := ids[i0] == ids[i1] || ids[i1] == ids[i2] || ids[i2] == ids[i0]
+		keep[t] = !repeated && !triangleIsDegenerate(m.Vertices[i0], m.Vertices[i1], m.Vertices[i2], tolerance)
+	}
+	return m.keepTriangles(keep)
+}
+
+// RemoveDuplicateTriangles drops triangles over the same three positions as
+// an earlier triangle, whichever way they are wound. Returns the number
+// removed.
+func (m *Mesh) RemoveDuplicateTriangles(tolerance float64) int {
+	ids, _ := weldPositions(m.Vertices, tolerance)
+	seen := make(map[[3]int]bool)
+	keep := make([]bool, len(m.Indices)/3)
+	for t := range keep {
+		key := sortedTriangle([3]int{ids[m.Indices[t*3]], ids[m.Indices[t*3+1]], ids[m.Indices[t*3+2]]})
+		keep[t] = !seen[key]
+		seen[key] = true
+	}
+	return m.keepTriangles(keep)
+}
+
+// FixWinding makes neighbouring triangles agree on winding, spreading out
+// across manifold edges from the first triangle of each piece. Closed pieces
+// are then turned to face outward (positive volume). Open pieces follow
+// their vertex normals when the mesh has them and otherwise whichever
+// orientation most of their area already had. Returns the number of
+// triangles flipped.
+func (m *Mesh) FixWinding(tolerance float64) int {
+	topo := buildMeshTopology(m, tolerance)
+	triCount := len(m.Indices) / 3
+	flip := make([]bool, triCount)
+	component := make([]int, triCount)
+	for i := range component {
+		component[i] = -1
+	}
+
+	// neighbours across manifold edges, with whether the pair already agrees
+	type link struct {
+		Face  int
+		Agree bool
+	}
+	links := make([][]link, triCount)
+	for _, uses := range topo.edges {
+		if len(uses) == 2 {
+			a, b := uses[0], uses[1]
+			agree := a.Forward != b.Forward
+			links[a.Face] = append(links[a.Face], link{b.Face, agree})
+			links[b.Face] = append(links[b.Face], link{a.Face, agree})
+		}
+	}
+
+	var pieces [][]int
+	for start := 0; start < triCount; start++ {
+		if topo.skipped[start] || component[start] >= 0 {
+			continue
+		}
+		id := len(pieces)
+		piece := []int{start}
+		component[start] = id
+		for i := 0; i < len(piece); i++ {
+			f := piece[i]
+			for _, l := range links[f] {
+				if component[l.Face] >= 0 {
+					continue
+				}
+				component[l.Face] = id
+				flip[l.Face] = flip[f] != !l.Agree
+				piece = append(piece, l.Face)
+			}
+		}
+		pieces = append(pieces, piece)
+	}
+
+	// Per-piece orientation
+	hasNormals := m.HasNormals()
+	for _, piece := range pieces {
+		closed := true
+		for _, f := range piece {
+			c := topo.corners(m, f)
+			for k := 0; k < 3; k++ {
+				a, b := c[k], c[(k+1)%3]
+				if a > b {
+					a, b = b, a
+				}
+				if len(topo.edges[[2]int{a, b}]) != 2 {
+					closed = false
+				}
+			}
+		}
+
+		vote := 0.0
+		for _, f := range piece {
+			i0, i1, i2 := m.Indices[f*3], m.Indices[f*3+1], m.Indices[f*3+2]
+			p0, p1, p2 := m.Vertices[i0], m.Vertices[i1], m.Vertices[i2]
+			n := crossPoints(subPoints(p1, p0), subPoints(p2, p0))
+			if flip[f] {
+				n = scalePoint(n, -1)
+			}
+			switch {
+			case closed:
+				vote += p0.X*n.X + p0.Y*n.Y + p0.Z*n.Z
+			case hasNormals:
+				avg := addPoints(addPoints(m.Normals[i0], m.Normals[i1]), m.Normals[i2])
+				vote += avg.X*n.X + avg.Y*n.Y + avg.Z*n.Z
+			case flip[f]:
+				vote -= pointLength(n)
+			default:
+				vote += pointLength(n)
+			}
+		}
+		if vote < 0 {
+			for _, f := range piece {
+				flip[f] = !flip[f]
+			}
+		}
+	}
+
+	flipped := 0
+	hasLightmapUVs := len(m.LightmapUVs) == len(m.Indices)
+	for t, f := range flip {
+		if !f {
+			continue
+		}
+		m.Indices[t*3+1], m.Indices[t*3+2] = m.Indices[t*3+2], m.Indices[t*3+1]
+		if hasLightmapUVs {
+			m.LightmapUVs[t*3+1], m.LightmapUVs[t*3+2] = m.LightmapUVs[t*3+2], m.LightmapUVs[t*3+1]
+		}
+		flipped++
+	}
+
+	if flipped > 0 {
+		m.FaceSizes = nil
+		m.Meshlets = nil // Their normal cones face the old way
+		if m.HasTangents() {
+			m.GenerateTangents()
+		}
+	}
+	return flipped
+}
+
+// FixNormals flips vertex normals pointing against the faces around them
+// (and tangent handedness with them, so the bitangent is unchanged).
+// Returns the number of normals flipped.
+func (m *Mesh) FixNormals() int {
+	if !m.HasNormals() {
+		return 0
+	}
+
+	faceSum := make([]Point, len(m.Vertices))
+	for t := 0; t < len(m.Indices)/3; t++ {
+		i0, i1, i2 := m.Indices[t*3], m.Indices[t*3+1], m.Indices[t*3+2]
+		p0 := m.Vertices[i0]
+		n := crossPoints(subPoints(m.Vertices[i1], p0), subPoints(m.Vertices[i2], p0))
+		for _, idx := range []int{i0, i1, i2} {
+			faceSum[idx] = addPoints(faceSum[idx], n)
+		}
+	}
+
+	hasTangents := m.HasTangents()
+	flipped := 0
+	for i, n := range m.Normals {
+		s := faceSum[i]
+		if n.X*s.X+n.Y*s.Y+n.Z*s.Z < 0 {
+			m.Normals[i] = scalePoint(n, -1)
+			if hasTangents {
+				m.Tangents[i].W = -m.Tangents[i].W
+			}
+			flipped++
+		}
+	}
+	return flipped
+}
+
+// FillHoles closes boundary loops of up to maxEdges edges. Triangular holes
+// get one triangle; larger ones a fan around a new vertex at their centre,
+// with averaged UVs, normals and colors. Patches take the material of a
+// triangle on their border and get blank lightmap UVs, so a baked lightmap
+// needs rebaking. Returns the number of holes filled.
+func (m *Mesh) FillHoles(maxEdges int, tolerance float64) int {
+	topo := buildMeshTopology(m, tolerance)
+	loops, faces := topo.boundaryLoops(m)
+
+	hasUVs := len(m.UVs) == len(m.Vertices)
+	hasNormals := m.HasNormals()
+	hasTangents := m.HasTangents()
+	hasColors := m.HasColors()
+	hasFaceMaterials := m.HasFaceMaterials()
+	hasLightmapUVs := len(m.LightmapUVs) == len(m.Indices)
+	hasFaceSizes := len(m.FaceSizes) > 0
+
+	addTriangle := func(a, b, c int, material IMaterial) {
+		m.AddTriangleIndices(a, b, c)
+		if hasFaceMaterials {
+			m.FaceMaterials = append(m.FaceMaterials, material)
+		}
+		if hasLightmapUVs {
+			m.LightmapUVs = append(m.LightmapUVs, TextureCoord{}, TextureCoord{}, TextureCoord{})
+		}
+		if hasFaceSizes {
+			m.FaceSizes = append(m.FaceSizes, 3)
+		}
+	}
+
+	filled := 0
+	for l, loop := range loops {
+		if len(loop) > maxEdges {
+			continue
+		}
+		material := m.MaterialForFace(faces[l])
+		if len(loop) == 3 {
+			addTriangle(loop[0], loop[1], loop[2], material)
+			filled++
+			continue
+		}
+
+		n := float64(len(loop))
+		var center, normal Point
+		var uv TextureCoord
+		var r, g, b float64
+		for _, idx := range loop {
+			center = addPoints(center, m.Vertices[idx])
+			if hasUVs {
+				uv.U += m.UVs[idx].U
+				uv.V += m.UVs[idx].V
+			}
+			if hasNormals {
+				normal = addPoints(normal, m.Normals[idx])
+			}
+			if hasColors {
+				r += float64(m.Colors[idx].R)
+				g += float64(m.Colors[idx].G)
+				b += float64(m.Colors[idx].B)
+			}
+		}
+
+		c := len(m.Vertices)
+		m.Vertices = append(m.Vertices, scalePoint(center, 1/n))
+		if hasUVs {
+			m.UVs = append(m.UVs, TextureCoord{U: uv.U / n, V: uv.V / n})
+		}
+		if hasNormals {
+			if pointLength(normal) > 1e-12 {
+				normal = normalizePoint(normal)
+			}
+			m.Normals = append(m.Normals, normal)
+		}
+		if hasTangents {
+			m.Tangents = append(m.Tangents, m.Tangents[loop[0]])
+		}
+		if hasColors {
+			m.Colors = append(m.Colors, Color{
+				R: uint8(math.Round(r / n)),
+				G: uint8(math.Round(g / n)),
+				B: uint8(math.Round(b / n)),
+			})
+		}
+
+		for k := range loop {
+			addTriangle(loop[k], loop[(k+1)%len(loop)], c, material)
+		}
+		filled++
+	}
+	return filled
+}
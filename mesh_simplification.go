@@ -44,341 +44,623 @@ func (q *Quadric) Error(x, y, z float64) float64 {
 		q.A[9]
 }
 
-// SimplificationVertex represents a vertex in the simplification mesh
-type SimplificationVertex struct {
-	Position Point
-	Quadric  *Quadric
-	ID       int
-	Edges    []*SimplificationEdge
+// addScaled accumulates another quadric multiplied by w
+func (q *Quadric) addScaled(other *Quadric, w float64) {
+	for i := range q.A {
+		q.A[i] += other.A[i] * w
+	}
 }
 
-// SimplificationEdge represents an edge that can be collapsed
-type SimplificationEdge struct {
-	V0        *SimplificationVertex
-	V1        *SimplificationVertex
-	Cost      float64
-	TargetPos Point
-	Index     int // Index in heap
-	Collapsed bool
+// ============================================================================
+// ATTRIBUTE-AWARE SIMPLIFICATION
+// ============================================================================
+// Simplification works on positions welded across seams, so UV and normal
+// seams cannot tear: each collapse moves one position onto a neighbour
+// (a half-edge collapse) and remaps every attribute wedge (a distinct
+// UV/normal set at a position) on to the matching wedge on the other side.
+// Collapses that would need a wedge that isn't there are rejected, which
+// keeps seam vertices sliding only along their seam.
+//
+// Besides the face-plane quadric, every position accumulates
+// - constraint planes through open border and seam edges, perpendicular to
+//   the surface, so borders don't shrink and seams stay straight
+// - per wedge attribute quadrics measuring how far each UV and normal
+//   channel drifts from its linear extension over the original faces
+//
+// Face quadrics are weighted by area, so the geometric error of a collapse,
+// reported in world units, is the RMS distance to the original planes
+// around it.
+// ============================================================================
+
+// QEMSettings controls quadric error metric simplification
+type QEMSettings struct {
+	TargetTriangles int     // Stop at this many triangles (0: no target)
+	MaxError        float64 // Skip collapses whose geometric error exceeds this, in world units (0: no limit)
+	UVWeight        float64 // Cost of UV distortion; 1 weighs a full UV tile like the mesh's size
+	NormalWeight    float64 // Cost of vertex normal distortion, on the same scale
+	BoundaryWeight  float64 // Weight of the planes holding borders and seams in place
 }
 
-// EdgeHeap implements heap.Interface for edge priority queue
-type EdgeHeap []*SimplificationEdge
+// DefaultQEMSettings returns settings that preserve UVs, normals and borders
+func DefaultQEMSettings() QEMSettings {
+	return QEMSettings{
+		UVWeight:       1.0,
+		NormalWeight:   0.25,
+		BoundaryWeight: 10.0,
+	}
+}
 
-func (h EdgeHeap) Len() int           { return len(h) }
-func (h EdgeHeap) Less(i, j int) bool { return h[i].Cost < h[j].Cost }
-func (h EdgeHeap) Swap(i, j int) {
-	h[i], h[j] = h[j], h[i]
-	h[i].Index = i
-	h[j].Index = j
+// qemChannels are the attribute channels: U, V and the normal's X, Y, Z
+const qemChannels = 5
+
+// attributeQuadric holds, per channel, the sum of w * (g·p + d - s)^2 over
+// faces, where g·p + d extends the channel linearly across a face and s is
+// the value kept at the vertex. Each is a symmetric 5x5 matrix over
+// (x, y, z, s, 1), stored as its upper triangle.
+type attributeQuadric [qemChannels][15]float64
+
+// add adds one face's term for a channel
+func (aq *attributeQuadric) add(channel int, g Point, d, w float64) {
+	a := [5]float64{g.X, g.Y, g.Z, -1, d}
+	k := 0
+	for i := 0; i < 5; i++ {
+		for j := i; j < 5; j++ {
+			aq[channel][k] += w * a[i] * a[j]
+			k++
+		}
+	}
 }
 
-func (h *EdgeHeap) Push(x interface{}) {
-	edge := x.(*SimplificationEdge)
-	edge.Index = len(*h)
-	*h = append(*h, edge)
+// accumulate adds another attribute quadric
+func (aq *attributeQuadric) accumulate(other *attributeQuadric) {
+	for c := range aq {
+		for k := range aq[c] {
+			aq[c][k] += other[c][k]
+		}
+	}
 }
 
-func (h *EdgeHeap) Pop() interface{} {
+// error evaluates every channel at a position with the given values
+func (aq *attributeQuadric) error(p Point, values [qemChannels]float64) float64 {
+	total := 0.0
+	for c := range aq {
+		v := [5]float64{p.X, p.Y, p.Z, values[c], 1}
+		k := 0
+		for i := 0; i < 5; i++ {
+			for j := i; j < 5; j++ {
+				if i == j {
+					total += aq[c][k] * v[i] * v[j]
+				} else {
+					total += 2 * aq[c][k] * v[i] * v[j]
+				}
+				k++
+			}
+		}
+	}
+	return total
+}
+
+// qemCollapse is a candidate move of one position onto a neighbour
+type qemCollapse struct {
+	From, To       int
+	Cost           float64
+	FromVer, ToVer int
+}
+
+// collapseHeap orders candidate collapses by cost
+type collapseHeap []qemCollapse
+
+func (h collapseHeap) Len() int            { return len(h) }
+func (h collapseHeap) Less(i, j int) bool  { return h[i].Cost < h[j].Cost }
+func (h collapseHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *collapseHeap) Push(x interface{}) { *h = append(*h, x.(qemCollapse)) }
+func (h *collapseHeap) Pop() interface{} {
 	old := *h
-	n := len(old)
-	edge := old[n-1]
-	old[n-1] = nil
-	edge.Index = -1
-	*h = old[0 : n-1]
-	return edge
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
 }
 
-// SimplificationMesh represents a mesh being simplified
-type SimplificationMesh struct {
-	Vertices  []*SimplificationVertex
-	Triangles [][3]int // Indices into Vertices
-	Edges     EdgeHeap
+// qemSimplifier is a mesh being simplified
+type qemSimplifier struct {
+	settings QEMSettings
+	source   *Mesh
+
+	// Per welded position
+	positions []Point
+	alive     []bool
+	version   []int
+	quadric   []Quadric // Area-weighted face planes
+	boundary  []Quadric // Border and seam constraint planes
+	weight    []float64 // Area the face planes were weighted with
+	posTris   [][]int
+
+	// Per wedge (source vertex and face material)
+	wedgePos     []int
+	wedgeVertex  []int
+	wedgeValues  [][qemChannels]float64
+	wedgeQuadric []attributeQuadric
+
+	// Per triangle, as wedges
+	tris      [][3]int
+	triAlive  []bool
+	triSource []int // Source triangle, for its face material
+	triCount  int
+
+	maxError float64
 }
 
-// SimplifyMesh simplifies a mesh using quadric error metrics
+// SimplifyMeshQEM simplifies a mesh to a triangle count using quadric error
+// metrics with the default attribute and border weights
 func SimplifyMeshQEM(mesh *Mesh, targetTriangleCount int) *Mesh {
-	// Build simplification mesh
-	simpMesh := buildSimplificationMesh(mesh)
-
-	if len(simpMesh.Triangles) <= targetTriangleCount {
+	if len(mesh.Indices)/3 <= targetTriangleCount {
 		return mesh // Already simple enough
 	}
 
-	// Compute initial quadrics for all vertices
-	simpMesh.computeQuadrics()
-
-	// Compute costs for all edges
-	simpMesh.computeEdgeCosts()
-
-	// Build heap
-	heap.Init(&simpMesh.Edges)
+	settings := DefaultQEMSettings()
+	settings.TargetTriangles = targetTriangleCount
+	simplified, _ := SimplifyMeshQEMWithSettings(mesh, settings)
+	return simplified
+}
 
-	// Collapse edges until we reach target
-	targetEdgeCollapses := len(simpMesh.Triangles) - targetTriangleCount
-	collapseCount := 0
+// SimplifyMeshQEMWithSettings simplifies a mesh until it reaches the target
+// triangle count or no collapse stays within MaxError. It returns the result
+// and the largest geometric error of the collapses made, in world units.
+func SimplifyMeshQEMWithSettings(mesh *Mesh, settings QEMSettings) (*Mesh, float64) {
+	s := newQEMSimplifier(mesh, settings)
+	s.run()
+	return s.toMesh(), s.maxError
+}
 
-	for collapseCount < targetEdgeCollapses && len(simpMesh.Edges) > 0 {
-		// Get cheapest edge
-		edge := heap.Pop(&simpMesh.Edges).(*SimplificationEdge)
+// newQEMSimplifier welds the mesh and builds the initial quadrics
+func newQEMSimplifier(mesh *Mesh, settings QEMSettings) *qemSimplifier {
+	s := &qemSimplifier{settings: settings, source: mesh}
 
-		if edge.Collapsed {
+	ids, count := weldPositions(mesh.Vertices, 1e-6)
+	s.positions = make([]Point, count)
+	for i, v := range mesh.Vertices {
+		s.positions[ids[i]] = v
+	}
+	s.alive = make([]bool, count)
+	s.version = make([]int, count)
+	s.quadric = make([]Quadric, count)
+	s.boundary = make([]Quadric, count)
+	s.weight = make([]float64, count)
+	s.posTris = make([][]int, count)
+
+	// Wedges: vertices are split by face material so material borders act
+	// as seams
+	hasFaceMaterials := mesh.HasFaceMaterials()
+	type wedgeKey struct {
+		Vertex   int
+		Material IMaterial
+	}
+	wedges := make(map[wedgeKey]int)
+	for t := 0; t+2 < len(mesh.Indices); t += 3 {
+		corners := [3]int{mesh.Indices[t], mesh.Indices[t+1], mesh.Indices[t+2]}
+		if ids[corners[0]] == ids[corners[1]] || ids[corners[1]] == ids[corners[2]] || ids[corners[2]] == ids[corners[0]] {
 			continue
 		}
-
-		// Collapse edge
-		if simpMesh.collapseEdge(edge) {
-			collapseCount++
+		var tri [3]int
+		for k, idx := range corners {
+			key := wedgeKey{Vertex: idx}
+			if hasFaceMaterials {
+				key.Material = mesh.FaceMaterials[t/3]
+			}
+			w, ok := wedges[key]
+			if !ok {
+				w = len(s.wedgePos)
+				wedges[key] = w
+				s.wedgePos = append(s.wedgePos, ids[idx])
+				s.wedgeVertex = append(s.wedgeVertex, idx)
+				s.wedgeValues = append(s.wedgeValues, s.attributeValues(idx))
+			}
+			tri[k] = w
+		}
+		s.posTris[ids[corners[0]]] = append(s.posTris[ids[corners[0]]], len(s.tris))
+		s.posTris[ids[corners[1]]] = append(s.posTris[ids[corners[1]]], len(s.tris))
+		s.posTris[ids[corners[2]]] = append(s.posTris[ids[corners[2]]], len(s.tris))
+		s.tris = append(s.tris, tri)
+		s.triAlive = append(s.triAlive, true)
+		s.triSource = append(s.triSource, t/3)
+		for _, idx := range corners {
+			s.alive[ids[idx]] = true
 		}
 	}
+	s.triCount = len(s.tris)
+	s.wedgeQuadric = make([]attributeQuadric, len(s.wedgePos))
 
-	// Convert back to regular mesh
-	return simpMesh.toMesh(mesh.Material)
+	s.computeQuadrics()
+	return s
 }
 
-// buildSimplificationMesh creates a simplification mesh from a regular mesh
-func buildSimplificationMesh(mesh *Mesh) *SimplificationMesh {
-	simpMesh := &SimplificationMesh{
-		Vertices:  make([]*SimplificationVertex, 0),
-		Triangles: make([][3]int, 0),
-		Edges:     make(EdgeHeap, 0),
+// attributeValues returns a source vertex's channel values
+func (s *qemSimplifier) attributeValues(idx int) [qemChannels]float64 {
+	var values [qemChannels]float64
+	if len(s.source.UVs) == len(s.source.Vertices) {
+		values[0], values[1] = s.source.UVs[idx].U, s.source.UVs[idx].V
 	}
+	if s.source.HasNormals() {
+		n := s.source.Normals[idx]
+		values[2], values[3], values[4] = n.X, n.Y, n.Z
+	}
+	return values
+}
 
-	// Build vertex map
-	vertexMap := make(map[Point]int)
-	vertexID := 0
-
-	// Helper to get or create vertex
-	getVertex := func(p Point) int {
-		// Round to reduce duplicates
-		rounded := Point{
-			X: math.Round(p.X*1000) / 1000,
-			Y: math.Round(p.Y*1000) / 1000,
-			Z: math.Round(p.Z*1000) / 1000,
-		}
-
-		if id, exists := vertexMap[rounded]; exists {
-			return id
+// computeQuadrics accumulates face planes, attribute gradients and border
+// and seam constraint planes
+func (s *qemSimplifier) computeQuadrics() {
+	// Attribute error is scaled by the mesh size so the weights are unitless
+	extent := 0.0
+	if aabb, ok := ComputeMeshBounds(s.source).(*AABB); ok {
+		size := aabb.GetSize()
+		extent = math.Max(size.X, math.Max(size.Y, size.Z))
+	}
+	channelWeight := [qemChannels]float64{}
+	if len(s.source.UVs) == len(s.source.Vertices) {
+		channelWeight[0] = s.settings.UVWeight * extent * extent
+		channelWeight[1] = channelWeight[0]
+	}
+	if s.source.HasNormals() {
+		for c := 2; c < 5; c++ {
+			channelWeight[c] = s.settings.NormalWeight * extent * extent
 		}
+	}
 
-		simpMesh.Vertices = append(simpMesh.Vertices, &SimplificationVertex{
-			Position: p,
-			Quadric:  &Quadric{},
-			ID:       vertexID,
-			Edges:    make([]*SimplificationEdge, 0),
-		})
-		vertexMap[rounded] = vertexID
-		vertexID++
-		return vertexID - 1
+	type edgeUse struct {
+		Tri    int
+		A, B   int // Wedges, in the triangle's order
+		Normal Point
 	}
+	edges := make(map[[2]int][]edgeUse)
+
+	for t, tri := range s.tris {
+		p0, p1, p2 := s.positions[s.wedgePos[tri[0]]], s.positions[s.wedgePos[tri[1]]], s.positions[s.wedgePos[tri[2]]]
+		e1, e2 := subPoints(p1, p0), subPoints(p2, p0)
+		n := crossPoints(e1, e2)
+		length := pointLength(n)
+		if length < 1e-12 {
+			continue // Degenerate triangle
+		}
+		area := length / 2
+		n = scalePoint(n, 1/length)
+
+		plane := NewQuadric(n.X, n.Y, n.Z, -(n.X*p0.X + n.Y*p0.Y + n.Z*p0.Z))
+		for _, w := range tri {
+			pos := s.wedgePos[w]
+			s.quadric[pos].addScaled(plane, area)
+			s.weight[pos] += area
+		}
 
-	// Add all triangles
-	for i := 0; i < len(mesh.Indices); i += 3 {
-		if i+2 < len(mesh.Indices) {
-			idx0, idx1, idx2 := mesh.Indices[i], mesh.Indices[i+1], mesh.Indices[i+2]
-			if idx0 < len(mesh.Vertices) && idx1 < len(mesh.Vertices) && idx2 < len(mesh.Vertices) {
-				v0 := getVertex(mesh.Vertices[idx0])
-				v1 := getVertex(mesh.Vertices[idx1])
-				v2 := getVertex(mesh.Vertices[idx2])
-				simpMesh.Triangles = append(simpMesh.Triangles, [3]int{v0, v1, v2})
+		// Linear extension of each channel over the face
+		a, b, c := dotPoints(e1, e1), dotPoints(e1, e2), dotPoints(e2, e2)
+		det := a*c - b*b
+		if det > 1e-18 {
+			for ch := 0; ch < qemChannels; ch++ {
+				if channelWeight[ch] == 0 {
+					continue
+				}
+				s0 := s.wedgeValues[tri[0]][ch]
+				d1 := s.wedgeValues[tri[1]][ch] - s0
+				d2 := s.wedgeValues[tri[2]][ch] - s0
+				alpha, beta := (c*d1-b*d2)/det, (a*d2-b*d1)/det
+				g := addPoints(scalePoint(e1, alpha), scalePoint(e2, beta))
+				d := s0 - dotPoints(g, p0)
+				for _, w := range tri {
+					s.wedgeQuadric[w].add(ch, g, d, area*channelWeight[ch])
+				}
 			}
 		}
-	}
-
-	// Build edge list
-	edgeMap := make(map[[2]int]*SimplificationEdge)
 
-	for _, tri := range simpMesh.Triangles {
-		// Add three edges
-		for i := 0; i < 3; i++ {
-			v0 := tri[i]
-			v1 := tri[(i+1)%3]
-
-			// Order vertices
-			if v0 > v1 {
-				v0, v1 = v1, v0
+		for k := 0; k < 3; k++ {
+			wa, wb := tri[k], tri[(k+1)%3]
+			pa, pb := s.wedgePos[wa], s.wedgePos[wb]
+			key := [2]int{pa, pb}
+			if pa > pb {
+				key = [2]int{pb, pa}
 			}
+			edges[key] = append(edges[key], edgeUse{Tri: t, A: wa, B: wb, Normal: n})
+		}
+	}
 
-			key := [2]int{v0, v1}
-			if _, exists := edgeMap[key]; !exists {
-				edge := &SimplificationEdge{
-					V0: simpMesh.Vertices[v0],
-					V1: simpMesh.Vertices[v1],
-				}
-				edgeMap[key] = edge
-				simpMesh.Vertices[v0].Edges = append(simpMesh.Vertices[v0].Edges, edge)
-				simpMesh.Vertices[v1].Edges = append(simpMesh.Vertices[v1].Edges, edge)
+	// Constraint planes along borders (one face) and seams (faces whose
+	// wedges differ)
+	for _, uses := range edges {
+		constrained := len(uses) == 1
+		if len(uses) == 2 {
+			u0, u1 := uses[0], uses[1]
+			constrained = !(u0.A == u1.B && u0.B == u1.A)
+		}
+		if !constrained {
+			continue
+		}
+		for _, use := range uses {
+			pa, pb := s.wedgePos[use.A], s.wedgePos[use.B]
+			edge := subPoints(s.positions[pb], s.positions[pa])
+			m := crossPoints(edge, use.Normal)
+			if pointLength(m) < 1e-12 {
+				continue
 			}
+			m = normalizePoint(m)
+			a := s.positions[pa]
+			plane := NewQuadric(m.X, m.Y, m.Z, -(m.X*a.X + m.Y*a.Y + m.Z*a.Z))
+			w := dotPoints(edge, edge)
+			s.boundary[pa].addScaled(plane, w)
+			s.boundary[pb].addScaled(plane, w)
 		}
 	}
+}
 
-	// Convert edges to slice
-	for _, edge := range edgeMap {
-		simpMesh.Edges = append(simpMesh.Edges, edge)
+// trianglesAt returns the live triangles around a position, dropping dead
+// ones from its list
+func (s *qemSimplifier) trianglesAt(pos int) []int {
+	live := s.posTris[pos][:0]
+	for _, t := range s.posTris[pos] {
+		if s.triAlive[t] {
+			live = append(live, t)
+		}
 	}
-
-	return simpMesh
+	s.posTris[pos] = live
+	return live
 }
 
-// computeQuadrics computes initial quadrics for all vertices
-func (sm *SimplificationMesh) computeQuadrics() {
-	// For each triangle, create a quadric and add to vertices
-	for _, tri := range sm.Triangles {
-		v0 := sm.Vertices[tri[0]].Position
-		v1 := sm.Vertices[tri[1]].Position
-		v2 := sm.Vertices[tri[2]].Position
-
-		// Compute plane equation: ax + by + cz + d = 0
-		// Normal = (v1-v0) × (v2-v0)
-		e1x, e1y, e1z := v1.X-v0.X, v1.Y-v0.Y, v1.Z-v0.Z
-		e2x, e2y, e2z := v2.X-v0.X, v2.Y-v0.Y, v2.Z-v0.Z
-
-		nx, ny, nz := crossProduct(e1x, e1y, e1z, e2x, e2y, e2z)
-		length := math.Sqrt(nx*nx + ny*ny + nz*nz)
-
-		if length < 1e-10 {
-			continue // Degenerate triangle
+// cornerAt returns which corner of a triangle is at a position (-1: none)
+func (s *qemSimplifier) cornerAt(t, pos int) int {
+	for k, w := range s.tris[t] {
+		if s.wedgePos[w] == pos {
+			return k
 		}
-
-		// Normalize
-		a := nx / length
-		b := ny / length
-		c := nz / length
-		d := -(a*v0.X + b*v0.Y + c*v0.Z)
-
-		// Create quadric
-		q := NewQuadric(a, b, c, d)
-
-		// Add to all three vertices
-		sm.Vertices[tri[0]].Quadric = sm.Vertices[tri[0]].Quadric.Add(q)
-		sm.Vertices[tri[1]].Quadric = sm.Vertices[tri[1]].Quadric.Add(q)
-		sm.Vertices[tri[2]].Quadric = sm.Vertices[tri[2]].Quadric.Add(q)
 	}
+	return -1
 }
 
-// computeEdgeCosts computes collapse cost for all edges
-func (sm *SimplificationMesh) computeEdgeCosts() {
-	for _, edge := range sm.Edges {
-		sm.computeEdgeCost(edge)
+// neighbours returns the positions sharing a triangle with pos, with the
+// number of triangles on each edge
+func (s *qemSimplifier) neighbours(pos int) map[int]int {
+	result := make(map[int]int)
+	for _, t := range s.trianglesAt(pos) {
+		for _, w := range s.tris[t] {
+			if p := s.wedgePos[w]; p != pos {
+				result[p]++
+			}
+		}
 	}
+	return result
 }
 
-// computeEdgeCost computes cost for a single edge
-func (sm *SimplificationMesh) computeEdgeCost(edge *SimplificationEdge) {
-	// Combined quadric
-	q := edge.V0.Quadric.Add(edge.V1.Quadric)
-
-	// Optimal position is midpoint (simplified)
-	// For full QEM, solve for optimal position using matrix inversion
-	edge.TargetPos = Point{
-		X: (edge.V0.Position.X + edge.V1.Position.X) / 2,
-		Y: (edge.V0.Position.Y + edge.V1.Position.Y) / 2,
-		Z: (edge.V0.Position.Z + edge.V1.Position.Z) / 2,
+// evaluate checks moving position u onto v and returns its cost, its
+// geometric error and the wedge each of u's wedges becomes
+func (s *qemSimplifier) evaluate(u, v int) (float64, float64, map[int]int, bool) {
+	uEdges := s.neighbours(u)
+	shared, ok := uEdges[v]
+	if !ok || shared > 2 {
+		return 0, 0, nil, false
 	}
 
-	// Compute error at target position
-	edge.Cost = q.Error(edge.TargetPos.X, edge.TargetPos.Y, edge.TargetPos.Z)
-
-	// Penalize boundary edges (simplified)
-	if len(edge.V0.Edges) < 4 || len(edge.V1.Edges) < 4 {
-		edge.Cost *= 1000.0
+	// Borders only collapse along themselves; non-manifold vertices stay
+	border := false
+	for _, count := range uEdges {
+		if count > 2 {
+			return 0, 0, nil, false
+		}
+		if count == 1 {
+			border = true
+		}
+	}
+	if border && shared != 1 {
+		return 0, 0, nil, false
 	}
-}
 
-// collapseEdge collapses an edge
-func (sm *SimplificationMesh) collapseEdge(edge *SimplificationEdge) bool {
-	if edge.Collapsed {
-		return false
+	// Link condition: the only common neighbours are across the shared faces
+	common := 0
+	for p := range s.neighbours(v) {
+		if _, ok := uEdges[p]; ok {
+			common++
+		}
+	}
+	if common != shared {
+		return 0, 0, nil, false
 	}
 
-	v0 := edge.V0
-	v1 := edge.V1
+	// Every wedge at u must continue into exactly one wedge at v
+	mapping := make(map[int]int)
+	tris := s.trianglesAt(u)
+	for _, t := range tris {
+		kv := s.cornerAt(t, v)
+		if kv < 0 {
+			continue
+		}
+		wu, wv := s.tris[t][s.cornerAt(t, u)], s.tris[t][kv]
+		if prev, ok := mapping[wu]; ok && prev != wv {
+			return 0, 0, nil, false
+		}
+		mapping[wu] = wv
+	}
 
-	// Move v0 to target position
-	v0.Position = edge.TargetPos
-	v0.Quadric = v0.Quadric.Add(v1.Quadric)
+	target := s.positions[v]
+	for _, t := range tris {
+		ku := s.cornerAt(t, u)
+		if _, ok := mapping[s.tris[t][ku]]; !ok {
+			return 0, 0, nil, false
+		}
+		if s.cornerAt(t, v) >= 0 {
+			continue
+		}
 
-	// Track which triangles to remove
-	toRemove := make(map[int]bool)
+		// Reject collapses that flip or flatten a remaining face
+		var before, after [3]Point
+		for k, w := range s.tris[t] {
+			before[k] = s.positions[s.wedgePos[w]]
+			after[k] = before[k]
+		}
+		after[ku] = target
+		n0 := crossPoints(subPoints(before[1], before[0]), subPoints(before[2], before[0]))
+		n1 := crossPoints(subPoints(after[1], after[0]), subPoints(after[2], after[0]))
+		if dotPoints(n0, n1) <= 1e-12*pointLength(n0)*pointLength(n0) {
+			return 0, 0, nil, false
+		}
+	}
 
-	// Remove triangles using both v0 and v1 (degenerate after collapse)
-	for i, tri := range sm.Triangles {
-		hasV0 := tri[0] == v0.ID || tri[1] == v0.ID || tri[2] == v0.ID
-		hasV1 := tri[0] == v1.ID || tri[1] == v1.ID || tri[2] == v1.ID
+	q := s.quadric[u]
+	q.addScaled(&s.quadric[v], 1)
+	b := s.boundary[u]
+	b.addScaled(&s.boundary[v], 1)
+	geometric := math.Max(q.Error(target.X, target.Y, target.Z), 0)
+	bound := math.Max(b.Error(target.X, target.Y, target.Z), 0)
 
-		if hasV0 && hasV1 {
-			toRemove[i] = true
+	attributes := 0.0
+	for wu, wv := range mapping {
+		attributes += s.wedgeQuadric[wu].error(target, s.wedgeValues[wv])
+	}
+	counted := make(map[int]bool)
+	for _, t := range s.trianglesAt(v) {
+		wv := s.tris[t][s.cornerAt(t, v)]
+		if !counted[wv] {
+			counted[wv] = true
+			attributes += s.wedgeQuadric[wv].error(target, s.wedgeValues[wv])
 		}
 	}
 
-	// Create new triangle list without removed ones
-	newTriangles := make([][3]int, 0, len(sm.Triangles)-len(toRemove))
-	for i, tri := range sm.Triangles {
-		if !toRemove[i] {
-			// Replace v1 with v0
-			for j := 0; j < 3; j++ {
-				if tri[j] == v1.ID {
-					tri[j] = v0.ID
-				}
-			}
+	errorDistance := 0.0
+	if w := s.weight[u] + s.weight[v]; w > 0 {
+		errorDistance = math.Sqrt((geometric + bound) / w)
+	}
+	cost := geometric + s.settings.BoundaryWeight*bound + math.Max(attributes, 0)
+	return cost, errorDistance, mapping, true
+}
+
+// pushEdge queues the cheaper direction of collapsing an edge
+func (s *qemSimplifier) pushEdge(h *collapseHeap, a, b int) {
+	costAB, _, _, okAB := s.evaluate(a, b)
+	costBA, _, _, okBA := s.evaluate(b, a)
+	switch {
+	case okAB && (!okBA || costAB <= costBA):
+		heap.Push(h, qemCollapse{From: a, To: b, Cost: costAB, FromVer: s.version[a], ToVer: s.version[b]})
+	case okBA:
+		heap.Push(h, qemCollapse{From: b, To: a, Cost: costBA, FromVer: s.version[b], ToVer: s.version[a]})
+	}
+}
 
-			// Check for degenerate triangle (all same vertex)
-			if tri[0] != tri[1] && tri[1] != tri[2] && tri[0] != tri[2] {
-				newTriangles = append(newTriangles, tri)
+// run collapses edges, cheapest first, until a stop condition is met
+func (s *qemSimplifier) run() {
+	h := &collapseHeap{}
+	for a := range s.positions {
+		if !s.alive[a] {
+			continue
+		}
+		for b := range s.neighbours(a) {
+			if a < b {
+				s.pushEdge(h, a, b)
 			}
 		}
 	}
-	sm.Triangles = newTriangles
 
-	// Update edge list
-	newEdges := EdgeHeap{}
-	for _, e := range sm.Edges {
-		if e == edge || e.Collapsed {
+	target := s.settings.TargetTriangles
+	for h.Len() > 0 && (target <= 0 || s.triCount > target) {
+		c := heap.Pop(h).(qemCollapse)
+		if !s.alive[c.From] || !s.alive[c.To] || c.FromVer != s.version[c.From] || c.ToVer != s.version[c.To] {
+			continue // Superseded
+		}
+		_, errorDistance, mapping, ok := s.evaluate(c.From, c.To)
+		if !ok {
 			continue
 		}
-
-		// Update edges connected to v1 to point to v0
-		if e.V0 == v1 {
-			e.V0 = v0
+		if s.settings.MaxError > 0 && errorDistance > s.settings.MaxError {
+			continue
 		}
-		if e.V1 == v1 {
-			e.V1 = v0
+		s.collapse(c.From, c.To, mapping)
+		s.maxError = math.Max(s.maxError, errorDistance)
+
+		// Costs around the merged vertex changed; requeue them
+		ring := s.neighbours(c.To)
+		s.version[c.To]++
+		for p := range ring {
+			s.version[p]++
 		}
-
-		// Skip edges that became degenerate (both vertices same)
-		if e.V0 != e.V1 {
-			sm.computeEdgeCost(e)
-			newEdges = append(newEdges, e)
+		ring[c.To] = 0
+		queued := make(map[[2]int]bool)
+		for a := range ring {
+			for b := range s.neighbours(a) {
+				key := [2]int{a, b}
+				if a > b {
+					key = [2]int{b, a}
+				}
+				if !queued[key] {
+					queued[key] = true
+					s.pushEdge(h, key[0], key[1])
+				}
+			}
 		}
 	}
+}
 
-	// Rebuild heap
-	sm.Edges = newEdges
-	heap.Init(&sm.Edges)
+// collapse moves position u onto v
+func (s *qemSimplifier) collapse(u, v int, mapping map[int]int) {
+	for _, t := range s.trianglesAt(u) {
+		if s.cornerAt(t, v) >= 0 {
+			s.triAlive[t] = false
+			s.triCount--
+			continue
+		}
+		k := s.cornerAt(t, u)
+		s.tris[t][k] = mapping[s.tris[t][k]]
+		s.posTris[v] = append(s.posTris[v], t)
+	}
+	s.posTris[u] = nil
 
-	edge.Collapsed = true
-	return true
+	s.quadric[v].addScaled(&s.quadric[u], 1)
+	s.boundary[v].addScaled(&s.boundary[u], 1)
+	s.weight[v] += s.weight[u]
+	for wu, wv := range mapping {
+		s.wedgeQuadric[wv].accumulate(&s.wedgeQuadric[wu])
+	}
+	s.alive[u] = false
 }
 
-// toMesh converts back to regular mesh
-func (sm *SimplificationMesh) toMesh(material IMaterial) *Mesh {
+// toMesh builds the simplified mesh. Wedges keep their source vertex's
+// attributes; triangles keep their face material.
+func (s *qemSimplifier) toMesh() *Mesh {
+	source := s.source
 	mesh := NewMesh()
-	mesh.Material = material
+	mesh.Material = source.Material
+	mesh.Position = source.Position
+
+	hasUVs := len(source.UVs) == len(source.Vertices)
+	hasNormals := source.HasNormals()
+	hasTangents := source.HasTangents()
+	hasColors := source.HasColors()
+	hasFaceMaterials := source.HasFaceMaterials()
 
-	// Build vertex map
 	vertexMap := make(map[int]int)
-	for _, tri := range sm.Triangles {
-		for _, vIdx := range tri {
-			if _, exists := vertexMap[vIdx]; !exists {
-				vertexMap[vIdx] = len(mesh.Vertices)
-				mesh.Vertices = append(mesh.Vertices, sm.Vertices[vIdx].Position)
+	for t, tri := range s.tris {
+		if !s.triAlive[t] {
+			continue
+		}
+		for _, w := range tri {
+			idx, ok := vertexMap[w]
+			if !ok {
+				idx = len(mesh.Vertices)
+				vertexMap[w] = idx
+				src := s.wedgeVertex[w]
+				mesh.Vertices = append(mesh.Vertices, s.positions[s.wedgePos[w]])
+				if hasUVs {
+					mesh.UVs = append(mesh.UVs, source.UVs[src])
+				}
+				if hasNormals {
+					mesh.Normals = append(mesh.Normals, source.Normals[src])
+				}
+				if hasTangents {
+					mesh.Tangents = append(mesh.Tangents, source.Tangents[src])
+				}
+				if hasColors {
+					mesh.Colors = append(mesh.Colors, source.Colors[src])
+				}
 			}
+			mesh.Indices = append(mesh.Indices, idx)
+		}
+		if hasFaceMaterials {
+			mesh.FaceMaterials = append(mesh.FaceMaterials, source.FaceMaterials[s.triSource[t]])
 		}
-	}
-
-	// Add indices
-	for _, tri := range sm.Triangles {
-		mesh.Indices = append(mesh.Indices, vertexMap[tri[0]], vertexMap[tri[1]], vertexMap[tri[2]])
 	}
 
 	return mesh
@@ -484,7 +766,9 @@ func pointsEqual(a, b Point) bool {
 		math.Abs(a.Z-b.Z) < epsilon
 }
 
-// GenerateAdvancedLODChain generates LOD chain with proper simplification
+// GenerateAdvancedLODChain generates LOD chain with proper simplification.
+// Each level records its geometric error, so distances can be replaced with
+// SetDistancesFromError.
 func GenerateAdvancedLODChain(baseMesh *Mesh, numLevels int, useQEM bool) *LODGroup {
 	lodGroup := NewLODGroup()
 
@@ -496,13 +780,16 @@ func GenerateAdvancedLODChain(baseMesh *Mesh, numLevels int, useQEM bool) *LODGr
 		targetRatio := 1.0 - (float64(i) / float64(numLevels))
 
 		var simplifiedMesh *Mesh
+		var geometricError float64
 		if useQEM {
 			// Use quadric error metrics (slower but better quality)
 			targetTris := int(float64(len(baseMesh.Indices)/3) * targetRatio)
 			if targetTris < 4 {
 				targetTris = 4
 			}
-			simplifiedMesh = SimplifyMeshQEM(baseMesh, targetTris)
+			settings := DefaultQEMSettings()
+			settings.TargetTriangles = targetTris
+			simplifiedMesh, geometricError = SimplifyMeshQEMWithSettings(baseMesh, settings)
 		} else {
 			// Use vertex clustering (faster but lower quality)
 			boundsVol := ComputeMeshBounds(baseMesh)
@@ -511,13 +798,60 @@ func GenerateAdvancedLODChain(baseMesh *Mesh, numLevels int, useQEM bool) *LODGr
 				avgSize := (size.X + size.Y + size.Z) / 3.0
 				gridSize := avgSize * (1.0 - targetRatio) * 0.5
 				simplifiedMesh = SimplifyMeshClustering(baseMesh, gridSize)
+				geometricError = gridSize * math.Sqrt(3) // Farthest a vertex can move in its cell
 			} else {
 				simplifiedMesh = SimplifyMesh(baseMesh, targetRatio)
+				// Sampling gives no tighter bound than the mesh's extent
+				geometricError = 2 * boundsVol.GetRadius()
 			}
 		}
 
 		distance := 50.0 * float64(i+1)
-		lodGroup.AddLOD(simplifiedMesh, distance)
+		lodGroup.AddLODWithError(simplifiedMesh, distance, geometricError)
+	}
+
+	return lodGroup
+}
+
+// GenerateSubdividedLODChain extends GenerateAdvancedLODChain in both
+// directions: subdivisionLevels smoothed levels (see Subdivide) are placed
+// in front of the base mesh for close-up viewing, followed by numLevels
+// levels from the base mesh down. Errors are measured against the most
+// subdivided level.
+func GenerateSubdividedLODChain(baseMesh *Mesh, subdivisionLevels, numLevels int, useQEM bool) *LODGroup {
+	lodGroup := GenerateAdvancedLODChain(baseMesh, numLevels, useQEM)
+	if subdivisionLevels <= 0 {
+		return lodGroup
+	}
+
+	// Each level refines the previous one; moved[i] is how far level i is
+	// from level i+1
+	settings := DefaultSubdivisionSettings()
+	refined := []*Mesh{baseMesh}
+	moved := make([]float64, subdivisionLevels)
+	for level := 0; level < subdivisionLevels; level++ {
+		var mesh *Mesh
+		mesh, moved[level] = subdivide(refined[level], settings, hasPolygonFaces(refined[level]))
+		refined = append(refined, mesh)
+	}
+
+	// Push the base and simplified levels out to make room, measuring their
+	// error from the finest level
+	shift := 50.0 * float64(subdivisionLevels)
+	baseError := 0.0
+	for _, m := range moved {
+		baseError += m
+	}
+	for i := range lodGroup.Levels {
+		lodGroup.Levels[i].MaxDistance += shift
+		lodGroup.Levels[i].Error += baseError
+	}
+
+	// Finest closest to the camera
+	levelError := baseError
+	for level := 1; level <= subdivisionLevels; level++ {
+		levelError = math.Max(levelError-moved[level-1], 0)
+		lodGroup.AddLODWithError(refined[level], 50.0*float64(subdivisionLevels-level+1), levelError)
 	}
 
 	return lodGroup
@@ -0,0 +1,544 @@
+package main
+
+import "math"
+
+// ============================================================================
+// SUBDIVISION SURFACES
+// ============================================================================
+// Subdivision refines a coarse mesh towards a smooth limit surface, the
+// opposite direction to SimplifyMeshQEM. Triangle meshes use Loop
+// subdivision (every triangle becomes four); meshes that keep their source
+// polygons in Mesh.FaceSizes (OBJ and PLY quads) use Catmull-Clark (every
+// n-gon becomes n quads). Topology is taken from vertex positions, so
+// duplicated OBJ corners and UV seams are smoothed across, while UVs and
+// colors are interpolated per face and keep their seams. Crease edges and
+// open boundaries follow the B-spline curve along the edge instead of the
+// surface rules, so they stay sharp.
+// ============================================================================
+
+// SubdivisionSettings controls subdivision
+type SubdivisionSettings struct {
+	Levels      int      // Number of subdivision steps
+	CreaseAngle float64  // Edges whose faces meet at more than this angle (radians) stay sharp; 0 = none
+	Creases     [][2]int // Extra sharp edges, as pairs of vertex indices in the input mesh
+}
+
+// DefaultSubdivisionSettings returns one fully smooth subdivision step
+func DefaultSubdivisionSettings() SubdivisionSettings {
+	return SubdivisionSettings{Levels: 1}
+}
+
+// subdivCorner holds the attributes interpolated per face corner
+type subdivCorner struct {
+	UV    TextureCoord
+	Color Color
+}
+
+// lerp interpolates corner attributes
+func (c subdivCorner) lerp(other subdivCorner, t float64) subdivCorner {
+	return subdivCorner{
+		UV: TextureCoord{
+			U: c.UV.U + (other.UV.U-c.UV.U)*t,
+			V: c.UV.V + (other.UV.V-c.UV.V)*t,
+		},
+		Color: c.Color.Lerp(other.Color, t),
+	}
+}
+
+// subdivEdge is an edge between two positions
+type subdivEdge struct {
+	Index  int
+	A, B   int
+	Faces  []int
+	Crease bool
+}
+
+// subdivSurface is a polygon mesh over welded positions
+type subdivSurface struct {
+	Positions []Point
+	Faces     [][]int          // Position indices per face
+	Corners   [][]subdivCorner // Attributes per face corner
+	Materials []IMaterial      // Material per face (nil: the mesh material)
+	Creases   map[[2]int]bool  // Sharp edges, keyed by sorted position pair
+
+	edges    map[[2]int]*subdivEdge
+	edgeList []*subdivEdge
+}
+
+func subdivEdgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// newSubdivSurface welds a mesh into a surface. With polygons, the faces
+// come from Mesh.Polygons; otherwise every triangle is a face.
+func newSubdivSurface(mesh *Mesh, polygons bool, settings SubdivisionSettings) *subdivSurface {
+	s := &subdivSurface{Creases: make(map[[2]int]bool)}
+
+	positionIndex := make(map[Point]int)
+	welded := make([]int, len(mesh.Vertices))
+	for i, v := range mesh.Vertices {
+		key := weldKey(v)
+		idx, ok := positionIndex[key]
+		if !ok {
+			idx = len(s.Positions)
+			positionIndex[key] = idx
+			s.Positions = append(s.Positions, v)
+		}
+		welded[i] = idx
+	}
+
+	loops := mesh.trianglePolygons()
+	if polygons {
+		loops = mesh.Polygons()
+	}
+
+	hasUVs := len(mesh.UVs) == len(mesh.Vertices)
+	hasColors := mesh.HasColors()
+	hasFaceMaterials := mesh.HasFaceMaterials()
+
+	tri := 0
+	for _, loop := range loops {
+		firstTri := tri
+		tri += len(loop) - 2
+
+		face := make([]int, len(loop))
+		corners := make([]subdivCorner, len(loop))
+		degenerate := false
+		for k, idx := range loop {
+			face[k] = welded[idx]
+			corners[k].Color = ColorWhite
+			if hasUVs {
+				corners[k].UV = mesh.UVs[idx]
+			}
+			if hasColors {
+				corners[k].Color = mesh.Colors[idx]
+			}
+		}
+		for k := range face {
+			if face[k] == face[(k+1)%len(face)] {
+				degenerate = true
+			}
+		}
+		if degenerate {
+			continue
+		}
+
+		s.Faces = append(s.Faces, face)
+		s.Corners = append(s.Corners, corners)
+		if hasFaceMaterials {
+			s.Materials = append(s.Materials, mesh.FaceMaterials[firstTri])
+		}
+	}
+
+	for _, pair := range settings.Creases {
+		if pair[0] >= 0 && pair[0] < len(welded) && pair[1] >= 0 && pair[1] < len(welded) {
+			s.Creases[subdivEdgeKey(welded[pair[0]], welded[pair[1]])] = true
+		}
+	}
+
+	s.buildEdges()
+
+	// Sharp feature edges are found once, on the coarse surface
+	if settings.CreaseAngle > 0 {
+		normals := make([]Point, len(s.Faces))
+		for f := range s.Faces {
+			normals[f] = s.faceNormal(f)
+		}
+		cosCrease := math.Cos(settings.CreaseAngle)
+		for _, e := range s.edgeList {
+			if len(e.Faces) == 2 {
+				n0, n1 := normals[e.Faces[0]], normals[e.Faces[1]]
+				if dotProduct(n0.X, n0.Y, n0.Z, n1.X, n1.Y, n1.Z) < cosCrease-1e-9 {
+					s.Creases[subdivEdgeKey(e.A, e.B)] = true
+					e.Crease = true
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+// faceNormal returns a face's unit normal (Newell's method)
+func (s *subdivSurface) faceNormal(f int) Point {
+	var n Point
+	face := s.Faces[f]
+	for k := range face {
+		a, b := s.Positions[face[k]], s.Positions[face[(k+1)%len(face)]]
+		n.X += (a.Y - b.Y) * (a.Z + b.Z)
+		n.Y += (a.Z - b.Z) * (a.X + b.X)
+		n.Z += (a.X - b.X) * (a.Y + b.Y)
+	}
+	if pointLength(n) < 1e-12 {
+		return n
+	}
+	return normalizePoint(n)
+}
+
+// buildEdges collects the edges of every face. Boundary and non-manifold
+// edges are treated as creases.
+func (s *subdivSurface) buildEdges() {
+	s.edges = make(map[[2]int]*subdivEdge)
+	s.edgeList = s.edgeList[:0]
+	for f, face := range s.Faces {
+		for k := range face {
+			a, b := face[k], face[(k+1)%len(face)]
+			key := subdivEdgeKey(a, b)
+			e, ok := s.edges[key]
+			if !ok {
+				e = &subdivEdge{Index: len(s.edgeList), A: key[0], B: key[1]}
+				s.edges[key] = e
+				s.edgeList = append(s.edgeList, e)
+			}
+			e.Faces = append(e.Faces, f)
+		}
+	}
+	for key, e := range s.edges {
+		e.Crease = len(e.Faces) != 2 || s.Creases[key]
+	}
+}
+
+// edge returns the edge between two positions
+func (s *subdivSurface) edge(a, b int) *subdivEdge {
+	return s.edges[subdivEdgeKey(a, b)]
+}
+
+// vertexNeighbours returns, per position, its neighbours and the neighbours
+// across crease edges
+func (s *subdivSurface) vertexNeighbours() (all, creased [][]int) {
+	all = make([][]int, len(s.Positions))
+	creased = make([][]int, len(s.Positions))
+	for _, e := range s.edgeList {
+		all[e.A] = append(all[e.A], e.B)
+		all[e.B] = append(all[e.B], e.A)
+		if e.Crease {
+			creased[e.A] = append(creased[e.A], e.B)
+			creased[e.B] = append(creased[e.B], e.A)
+		}
+	}
+	return all, creased
+}
+
+// creaseVertex applies the crease rules: a vertex on one crease curve moves
+// along it, a corner where more creases meet stays put. ok is false for
+// smooth vertices.
+func (s *subdivSurface) creaseVertex(v int, creased []int) (Point, bool) {
+	p := s.Positions[v]
+	switch {
+	case len(creased) == 2:
+		a, b := s.Positions[creased[0]], s.Positions[creased[1]]
+		return addPoints(scalePoint(p, 0.75), scalePoint(addPoints(a, b), 0.125)), true
+	case len(creased) > 2:
+		return p, true
+	}
+	return p, false
+}
+
+// childCreases builds the child surface's crease set: each crease edge is
+// split at its new midpoint
+func (s *subdivSurface) childCreases(edgePoint func(e *subdivEdge) int) map[[2]int]bool {
+	creases := make(map[[2]int]bool)
+	for _, e := range s.edgeList {
+		if e.Crease {
+			m := edgePoint(e)
+			creases[subdivEdgeKey(e.A, m)] = true
+			creases[subdivEdgeKey(m, e.B)] = true
+		}
+	}
+	return creases
+}
+
+// loopStep performs one Loop subdivision step on a triangle surface
+func (s *subdivSurface) loopStep() *subdivSurface {
+	vertexCount := len(s.Positions)
+	child := &subdivSurface{Positions: make([]Point, vertexCount+len(s.edgeList))}
+	edgePoint := func(e *subdivEdge) int { return vertexCount + e.Index }
+
+	// Edge points
+	for _, e := range s.edgeList {
+		a, b := s.Positions[e.A], s.Positions[e.B]
+		if e.Crease {
+			child.Positions[edgePoint(e)] = scalePoint(addPoints(a, b), 0.5)
+			continue
+		}
+		opposite := Point{}
+		for _, f := range e.Faces {
+			for _, v := range s.Faces[f] {
+				if v != e.A && v != e.B {
+					opposite = addPoints(opposite, s.Positions[v])
+				}
+			}
+		}
+		child.Positions[edgePoint(e)] = addPoints(scalePoint(addPoints(a, b), 0.375), scalePoint(opposite, 0.125))
+	}
+
+	// Vertex points
+	all, creased := s.vertexNeighbours()
+	for v := 0; v < vertexCount; v++ {
+		if p, ok := s.creaseVertex(v, creased[v]); ok {
+			child.Positions[v] = p
+			continue
+		}
+		n := len(all[v])
+		if n == 0 {
+			child.Positions[v] = s.Positions[v]
+			continue
+		}
+		beta := 3.0 / (8.0 * float64(n))
+		if n == 3 {
+			beta = 3.0 / 16.0
+		}
+		sum := Point{}
+		for _, u := range all[v] {
+			sum = addPoints(sum, s.Positions[u])
+		}
+		child.Positions[v] = addPoints(scalePoint(s.Positions[v], 1-float64(n)*beta), scalePoint(sum, beta))
+	}
+
+	// Every triangle becomes three corner triangles and a middle one
+	for f, face := range s.Faces {
+		c := s.Corners[f]
+		m := [3]int{
+			edgePoint(s.edge(face[0], face[1])),
+			edgePoint(s.edge(face[1], face[2])),
+			edgePoint(s.edge(face[2], face[0])),
+		}
+		mc := [3]subdivCorner{c[0].lerp(c[1], 0.5), c[1].lerp(c[2], 0.5), c[2].lerp(c[0], 0.5)}
+
+		child.addFace([]int{face[0], m[0], m[2]}, []subdivCorner{c[0], mc[0], mc[2]}, s.faceMaterial(f))
+		child.addFace([]int{face[1], m[1], m[0]}, []subdivCorner{c[1], mc[1], mc[0]}, s.faceMaterial(f))
+		child.addFace([]int{face[2], m[2], m[1]}, []subdivCorner{c[2], mc[2], mc[1]}, s.faceMaterial(f))
+		child.addFace([]int{m[0], m[1], m[2]}, []subdivCorner{mc[0], mc[1], mc[2]}, s.faceMaterial(f))
+	}
+
+	child.Creases = s.childCreases(edgePoint)
+	child.buildEdges()
+	return child
+}
+
+// catmullClarkStep performs one Catmull-Clark step on a polygon surface
+func (s *subdivSurface) catmullClarkStep() *subdivSurface {
+	vertexCount, edgeCount := len(s.Positions), len(s.edgeList)
+	child := &subdivSurface{Positions: make([]Point, vertexCount+edgeCount+len(s.Faces))}
+	edgePoint := func(e *subdivEdge) int { return vertexCount + e.Index }
+	facePoint := func(f int) int { return vertexCount + edgeCount + f }
+
+	// Face points
+	for f, face := range s.Faces {
+		sum := Point{}
+		for _, v := range face {
+			sum = addPoints(sum, s.Positions[v])
+		}
+		child.Positions[facePoint(f)] = scalePoint(sum, 1/float64(len(face)))
+	}
+
+	// Edge points
+	for _, e := range s.edgeList {
+		mid := scalePoint(addPoints(s.Positions[e.A], s.Positions[e.B]), 0.5)
+		if e.Crease {
+			child.Positions[edgePoint(e)] = mid
+			continue
+		}
+		faces := addPoints(child.Positions[facePoint(e.Faces[0])], child.Positions[facePoint(e.Faces[1])])
+		child.Positions[edgePoint(e)] = addPoints(scalePoint(mid, 0.5), scalePoint(faces, 0.25))
+	}
+
+	// Vertex points: (F + 2R + (n-3)P) / n
+	all, creased := s.vertexNeighbours()
+	vertexFaces := make([][]int, vertexCount)
+	for f, face := range s.Faces {
+		for _, v := range face {
+			vertexFaces[v] = append(vertexFaces[v], f)
+		}
+	}
+	for v := 0; v < vertexCount; v++ {
+		if p, ok := s.creaseVertex(v, creased[v]); ok {
+			child.Positions[v] = p
+			continue
+		}
+		n := float64(len(all[v]))
+		if n == 0 || len(vertexFaces[v]) == 0 {
+			child.Positions[v] = s.Positions[v]
+			continue
+		}
+		var F, R Point
+		for _, f := range vertexFaces[v] {
+			F = addPoints(F, child.Positions[facePoint(f)])
+		}
+		F = scalePoint(F, 1/float64(len(vertexFaces[v])))
+		for _, u := range all[v] {
+			R = addPoints(R, scalePoint(addPoints(s.Positions[v], s.Positions[u]), 0.5))
+		}
+		R = scalePoint(R, 1/n)
+		p := addPoints(addPoints(F, scalePoint(R, 2)), scalePoint(s.Positions[v], n-3))
+		child.Positions[v] = scalePoint(p, 1/n)
+	}
+
+	// Every n-gon becomes n quads around its face point
+	for f, face := range s.Faces {
+		c := s.Corners[f]
+		n := len(face)
+		center := subdivCorner{}
+		var r, g, b, u, uv float64
+		for _, corner := range c {
+			u += corner.UV.U
+			uv += corner.UV.V
+			r += float64(corner.Color.R)
+			g += float64(corner.Color.G)
+			b += float64(corner.Color.B)
+		}
+		center.UV = TextureCoord{U: u / float64(n), V: uv / float64(n)}
+		center.Color = Color{
+			R: uint8(math.Round(r / float64(n))),
+			G: uint8(math.Round(g / float64(n))),
+			B: uint8(math.Round(b / float64(n))),
+		}
+
+		for k := 0; k < n; k++ {
+			prev, next := (k+n-1)%n, (k+1)%n
+			child.addFace(
+				[]int{face[k], edgePoint(s.edge(face[k], face[next])), facePoint(f), edgePoint(s.edge(face[prev], face[k]))},
+				[]subdivCorner{c[k], c[k].lerp(c[next], 0.5), center, c[prev].lerp(c[k], 0.5)},
+				s.faceMaterial(f))
+		}
+	}
+
+	child.Creases = s.childCreases(edgePoint)
+	child.buildEdges()
+	return child
+}
+
+func (s *subdivSurface) addFace(face []int, corners []subdivCorner, material IMaterial) {
+	s.Faces = append(s.Faces, face)
+	s.Corners = append(s.Corners, corners)
+	if material != nil {
+		s.Materials = append(s.Materials, material)
+	}
+}
+
+func (s *subdivSurface) faceMaterial(f int) IMaterial {
+	if len(s.Materials) == len(s.Faces) {
+		return s.Materials[f]
+	}
+	return nil
+}
+
+// toMesh triangulates the surface, sharing vertices whose position and
+// corner attributes match
+func (s *subdivSurface) toMesh(source *Mesh, settings SubdivisionSettings, polygons bool) *Mesh {
+	mesh := NewMesh()
+	mesh.Position = source.Position
+	mesh.Material = source.Material
+	hasUVs := len(source.UVs) > 0 && len(source.UVs) == len(source.Vertices)
+	hasColors := source.HasColors()
+
+	type cornerKey struct {
+		Position int
+		Corner   subdivCorner
+	}
+	shared := make(map[cornerKey]int)
+	vertex := func(position int, corner subdivCorner) int {
+		key := cornerKey{Position: position, Corner: corner}
+		if idx, ok := shared[key]; ok {
+			return idx
+		}
+		idx := len(mesh.Vertices)
+		shared[key] = idx
+		mesh.Vertices = append(mesh.Vertices, s.Positions[position])
+		if hasUVs {
+			mesh.UVs = append(mesh.UVs, corner.UV)
+		}
+		if hasColors {
+			mesh.Colors = append(mesh.Colors, corner.Color)
+		}
+		return idx
+	}
+
+	hasMaterials := len(s.Materials) == len(s.Faces)
+	for f, face := range s.Faces {
+		loop := make([]int, len(face))
+		for k := range face {
+			loop[k] = vertex(face[k], s.Corners[f][k])
+		}
+		for k := 1; k+1 < len(loop); k++ {
+			mesh.AddTriangleIndices(loop[0], loop[k], loop[k+1])
+			if hasMaterials {
+				mesh.FaceMaterials = append(mesh.FaceMaterials, s.Materials[f])
+			}
+		}
+		if polygons {
+			mesh.FaceSizes = append(mesh.FaceSizes, len(loop))
+		}
+	}
+
+	creaseAngle := DefaultCreaseAngle
+	if settings.CreaseAngle > 0 {
+		creaseAngle = settings.CreaseAngle
+	}
+	mesh.GenerateNormals(creaseAngle)
+	if source.HasTangents() && hasUVs {
+		mesh.GenerateTangents()
+	}
+	return mesh
+}
+
+// subdivide runs the subdivision steps. Also returns how far the input's
+// vertices moved in total, a bound on how far the input is from the result
+// that LOD chains use as the input's error.
+func subdivide(mesh *Mesh, settings SubdivisionSettings, polygons bool) (*Mesh, float64) {
+	surface := newSubdivSurface(mesh, polygons, settings)
+	moved := 0.0
+	for level := 0; level < settings.Levels; level++ {
+		var child *subdivSurface
+		if polygons {
+			child = surface.catmullClarkStep()
+		} else {
+			child = surface.loopStep()
+		}
+
+		// Child positions start with the parent's vertices, in order
+		step := 0.0
+		for v, p := range surface.Positions {
+			step = math.Max(step, pointLength(subPoints(child.Positions[v], p)))
+		}
+		moved += step
+		surface = child
+	}
+	return surface.toMesh(mesh, settings, polygons), moved
+}
+
+// hasPolygonFaces reports whether a mesh has faces with more than three
+// corners
+func hasPolygonFaces(mesh *Mesh) bool {
+	for _, polygon := range mesh.Polygons() {
+		if len(polygon) > 3 {
+			return true
+		}
+	}
+	return false
+}
+
+// SubdivideLoop refines a mesh with Loop subdivision, treating it as
+// triangles
+func SubdivideLoop(mesh *Mesh, settings SubdivisionSettings) *Mesh {
+	result, _ := subdivide(mesh, settings, false)
+	return result
+}
+
+// SubdivideCatmullClark refines a mesh with Catmull-Clark subdivision over
+// its source polygons (see Mesh.FaceSizes). The result is all quads and
+// records them in FaceSizes, so it can be subdivided again.
+func SubdivideCatmullClark(mesh *Mesh, settings SubdivisionSettings) *Mesh {
+	result, _ := subdivide(mesh, settings, true)
+	return result
+}
+
+// Subdivide uses Catmull-Clark when the mesh has polygon faces with more than
+// three corners and Loop otherwise
+func Subdivide(mesh *Mesh, settings SubdivisionSettings) *Mesh {
+	result, _ := subdivide(mesh, settings, hasPolygonFaces(mesh))
+	return result
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// ============================================================================
+// TANGENT SPACE
+// ============================================================================
+// Tangent-space normal maps store normals relative to a per-vertex basis: the
+// tangent follows +U, the bitangent +V and the normal points out of the
+// surface. Tangents are generated the way MikkTSpace does it, so maps baked
+// by standard tools line up:
+//   - each face's UV-derived tangent is projected into the plane of the vertex
+//     normal and normalized before averaging, weighted by the corner angle
+//   - faces with mirrored UVs are never averaged with unmirrored ones; the
+//     vertex is split instead and the handedness stored in W
+//   - the bitangent is not stored but rebuilt per pixel as W * cross(N, T)
+//     from the interpolated, unnormalized normal and tangent
+// ============================================================================
+
+// Tangent is a unit tangent vector with the bitangent's handedness in W
+// (+1 or -1), as in MikkTSpace
+type Tangent struct {
+	X, Y, Z float64
+	W       float64
+}
+
+// RotateFast rotates the tangent direction; handedness is unchanged
+func (t *Tangent) RotateFast(axis byte, c, s float64) {
+	p := Point{X: t.X, Y: t.Y, Z: t.Z}
+	p.RotateFast(axis, c, s)
+	t.X, t.Y, t.Z = p.X, p.Y, p.Z
+}
+
+// HasTangents reports whether the mesh has a tangent for every vertex
+func (m *Mesh) HasTangents() bool {
+	return len(m.Vertices) > 0 && len(m.Tangents) == len(m.Vertices)
+}
+
+// GenerateTangents computes per-vertex tangents from the mesh's UVs and
+// normals (generating normals first if there are none). Vertices shared by
+// faces with opposite UV orientation are split.
+func (m *Mesh) GenerateTangents() error {
+	if len(m.UVs) != len(m.Vertices) {
+		return fmt.Errorf("mesh needs one UV per vertex for tangents, has %d for %d vertices", len(m.UVs), len(m.Vertices))
+	}
+	if !m.HasNormals() {
+		m.GenerateNormals(DefaultCreaseAngle)
+	}
+
+	triCount := len(m.Indices) / 3
+	type cornerBasis struct {
+		tangent   Point
+		bitangent Point
+		weight    float64
+		sign      float64
+	}
+	corners := make([]cornerBasis, triCount*3)
+
+	for t := 0; t < triCount; t++ {
+		idx := [3]int{m.Indices[t*3], m.Indices[t*3+1], m.Indices[t*3+2]}
+		p := [3]Point{m.Vertices[idx[0]], m.Vertices[idx[1]], m.Vertices[idx[2]]}
+		uv := [3]TextureCoord{m.UVs[idx[0]], m.UVs[idx[1]], m.UVs[idx[2]]}
+
+		// Solve e1 = du1*T + dv1*B, e2 = du2*T + dv2*B
+		e1x, e1y, e1z := p[1].X-p[0].X, p[1].Y-p[0].Y, p[1].Z-p[0].Z
+		e2x, e2y, e2z := p[2].X-p[0].X, p[2].Y-p[0].Y, p[2].Z-p[0].Z
+		du1, dv1 := uv[1].U-uv[0].U, uv[1].V-uv[0].V
+		du2, dv2 := uv[2].U-uv[0].U, uv[2].V-uv[0].V
+
+		det := du1*dv2 - du2*dv1
+		sign := 1.0
+		if det < 0 {
+			sign = -1.0
+		}
+		var faceT, faceB Point
+		if math.Abs(det) > 1e-20 {
+			faceT = Point{X: dv2*e1x - dv1*e2x, Y: dv2*e1y - dv1*e2y, Z: dv2*e1z - dv1*e2z}
+			faceB = Point{X: du1*e2x - du2*e1x, Y: du1*e2y - du2*e1y, Z: du1*e2z - du2*e1z}
+			// det only flips the directions; its magnitude is normalized away
+			faceT = Point{X: faceT.X * sign, Y: faceT.Y * sign, Z: faceT.Z * sign}
+			faceB = Point{X: faceB.X * sign, Y: faceB.Y * sign, Z: faceB.Z * sign}
+		}
+
+		for k := 0; k < 3; k++ {
+			n := m.Normals[idx[k]]
+			corners[t*3+k] = cornerBasis{
+				tangent:   projectOntoPlane(faceT, n),
+				bitangent: projectOntoPlane(faceB, n),
+				weight:    cornerAngle(p[k], p[(k+1)%3], p[(k+2)%3]),
+				sign:      sign,
+			}
+		}
+	}
+
+	// Accumulate per vertex and handedness; a vertex used with both
+	// handednesses is split
+	type accumulator struct {
+		vertex    int
+		sign      float64
+		tangent   Point
+		bitangent Point
+	}
+	accums := make([]*accumulator, 0, len(m.Vertices))
+	byVertex := make(map[[2]int]int) // (vertex, handedness) -> accumulator
+	cornerAccum := make([]int, len(corners))
+
+	for c, basis := range corners {
+		v := m.Indices[c]
+		key := [2]int{v, int(basis.sign)}
+		a, ok := byVertex[key]
+		if !ok {
+			a = len(accums)
+			accums = append(accums, &accumulator{vertex: v, sign: basis.sign})
+			byVertex[key] = a
+		}
+		acc := accums[a]
+		acc.tangent = addScaled(acc.tangent, basis.tangent, basis.weight)
+		acc.bitangent = addScaled(acc.bitangent, basis.bitangent, basis.weight)
+		cornerAccum[c] = a
+	}
+
+	vertexCount := len(m.Vertices)
+	m.Tangents = make([]Tangent, vertexCount)
+	owner := make([]int, vertexCount) // Accumulator stored at each vertex slot
+	for i := range owner {
+		owner[i] = -1
+	}
+	splits := make(map[int]int) // Accumulator -> duplicated vertex
+	hasColors := len(m.Colors) == vertexCount
+
+	for c, a := range cornerAccum {
+		acc := accums[a]
+		v := acc.vertex
+		if owner[v] == a {
+			continue
+		}
+		if owner[v] < 0 {
+			owner[v] = a
+			m.Tangents[v] = finalizeTangent(acc.tangent, acc.bitangent, m.Normals[v], acc.sign)
+			continue
+		}
+
+		// Second handedness at this vertex: duplicate it (once)
+		dup, ok := splits[a]
+		if !ok {
+			dup = len(m.Vertices)
+			m.Vertices = append(m.Vertices, m.Vertices[v])
+			m.UVs = append(m.UVs, m.UVs[v])
+			m.Normals = append(m.Normals, m.Normals[v])
+			if hasColors {
+				m.Colors = append(m.Colors, m.Colors[v])
+			}
+			m.Tangents = append(m.Tangents, finalizeTangent(acc.tangent, acc.bitangent, m.Normals[v], acc.sign))
+			splits[a] = dup
+		}
+		m.Indices[c] = dup
+	}
+
+	for v := 0; v < vertexCount; v++ {
+		if owner[v] < 0 {
+			m.Tangents[v] = finalizeTangent(Point{}, Point{}, m.Normals[v], 1)
+		}
+	}
+	if len(m.Vertices) != vertexCount {
+		m.Meshlets = nil
+	}
+
+	return nil
+}
+
+// finalizeTangent orthonormalizes an accumulated tangent against the normal.
+// Faces without usable UVs fall back to any vector perpendicular to the normal.
+func finalizeTangent(tangent, bitangent, normal Point, sign float64) Tangent {
+	t := projectOntoPlane(tangent, normal)
+	if t.X*t.X+t.Y*t.Y+t.Z*t.Z < 1e-20 {
+		// Derive the tangent from the bitangent, or pick an arbitrary one
+		if b := projectOntoPlane(bitangent, normal); b.X*b.X+b.Y*b.Y+b.Z*b.Z > 1e-20 {
+			x, y, z := crossProduct(b.X, b.Y, b.Z, normal.X, normal.Y, normal.Z)
+			t = Point{X: x * sign, Y: y * sign, Z: z * sign}
+		} else {
+			axis := Point{X: 1}
+			if math.Abs(normal.X) > 0.9 {
+				axis = Point{Y: 1}
+			}
+			t = projectOntoPlane(axis, normal)
+		}
+	}
+	x, y, z := normalizeVector(t.X, t.Y, t.Z)
+	return Tangent{X: x, Y: y, Z: z, W: sign}
+}
+
+// projectOntoPlane removes the component of v along a unit normal
+func projectOntoPlane(v, normal Point) Point {
+	d := dotProduct(v.X, v.Y, v.Z, normal.X, normal.Y, normal.Z)
+	p := Point{X: v.X - normal.X*d, Y: v.Y - normal.Y*d, Z: v.Z - normal.Z*d}
+	if l := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z); l > 1e-20 {
+		return Point{X: p.X / l, Y: p.Y / l, Z: p.Z / l}
+	}
+	return Point{}
+}
+
+// addScaled returns a + b*s
+func addScaled(a, b Point, s float64) Point {
+	return Point{X: a.X + b.X*s, Y: a.Y + b.Y*s, Z: a.Z + b.Z*s}
+}
+
+// TransformTangent transforms a tangent by a world matrix. Mirroring
+// transforms flip the handedness.
+func (m *Matrix4x4) TransformTangent(t Tangent) Tangent {
+	d := m.TransformDirection(Point{X: t.X, Y: t.Y, Z: t.Z})
+	x, y, z := normalizeVector(d.X, d.Y, d.Z)
+
+	w := t.W
+	if m.Determinant3() < 0 {
+		w = -w
+	}
+	return Tangent{X: x, Y: y, Z: z, W: w}
+}
+
+// ============================================================================
+// NORMAL MAPPING
+// ============================================================================
+
+// InterpolateTangent returns the unnormalized tangent at a point on a
+// triangle with tangents (MikkTSpace normalizes only after the per-pixel
+// bitangent is built)
+func InterpolateTangent(t *Triangle, p Point) Tangent {
+	w0, w1, w2 := barycentric3D(p, t.P0, t.P1, t.P2)
+	return Tangent{
+		X: w0*t.T0.X + w1*t.T1.X + w2*t.T2.X,
+		Y: w0*t.T0.Y + w1*t.T1.Y + w2*t.T2.Y,
+		Z: w0*t.T0.Z + w1*t.T1.Z + w2*t.T2.Z,
+		W: t.T0.W,
+	}
+}
+
+// PerturbNormal applies a tangent-space normal (from IMaterial.SampleNormal)
+// to a surface normal using the tangent basis
+func PerturbNormal(normal Point, tangent Tangent, mapped Point) Point {
+	// Bitangent from the unnormalized interpolants, as MikkTSpace expects
+	bx, by, bz := crossProduct(normal.X, normal.Y, normal.Z, tangent.X, tangent.Y, tangent.Z)
+	sign := tangent.W
+	if sign == 0 {
+		sign = 1
+	}
+
+	x := mapped.X*tangent.X + mapped.Y*bx*sign + mapped.Z*normal.X
+	y := mapped.X*tangent.Y + mapped.Y*by*sign + mapped.Z*normal.Y
+	z := mapped.X*tangent.Z + mapped.Y*bz*sign + mapped.Z*normal.Z
+	if x*x+y*y+z*z < 1e-20 {
+		return normal
+	}
+	x, y, z = normalizeVector(x, y, z)
+	return Point{X: x, Y: y, Z: z}
+}
@@ -0,0 +1,226 @@
+package main
+
+import "math"
+
+// ============================================================================
+// MESHLETS
+// ============================================================================
+// A meshlet is a small cluster of neighbouring triangles with its own
+// bounding sphere and normal cone, so a large mesh can be culled piece by
+// piece: a meshlet outside the frustum, or one whose triangles all face
+// away from the camera, is skipped before any of its vertices are
+// transformed. Without them a huge mesh is either culled whole by
+// FrustumCullNode or every triangle is transformed and backface tested.
+//
+// BuildMeshlets reorders the mesh's triangles so each meshlet is one run of
+// Indices. Clusters grow greedily from a seed triangle, preferring
+// neighbours that add the fewest new vertices and that face the same way,
+// which keeps the normal cones narrow enough to be useful.
+// ============================================================================
+
+// Meshlet is a run of triangles in a mesh's Indices
+type Meshlet struct {
+	FirstTriangle int   // Triangle k uses Indices[3k:3k+3]
+	TriangleCount int   // Triangles in the run
+	Vertices      []int // Distinct vertices used
+
+	// Bounding sphere in mesh space
+	Center Point
+	Radius float64
+
+	// Normal cone: every triangle faces within the cone around ConeAxis.
+	// ConeCutoff is the sine of its half angle, 1 if the triangles spread
+	// too far for the cone to ever cull.
+	ConeAxis   Point
+	ConeCutoff float64
+}
+
+// MeshletSettings controls meshlet building
+type MeshletSettings struct {
+	MaxVertices  int     // Vertices per meshlet
+	MaxTriangles int     // Triangles per meshlet
+	ConeWeight   float64 // Preference for triangles facing like the rest of the meshlet (0: vertex reuse only)
+}
+
+// DefaultMeshletSettings returns the usual mesh shader limits
+func DefaultMeshletSettings() MeshletSettings {
+	return MeshletSettings{
+		MaxVertices:  64,
+		MaxTriangles: 124,
+		ConeWeight:   0.5,
+	}
+}
+
+// HasMeshlets reports whether the meshlets cover the current Indices and
+// were built for the current vertices
+func (m *Mesh) HasMeshlets() bool {
+	if len(m.Meshlets) == 0 || m.meshletVertices != len(m.Vertices) {
+		return false
+	}
+	last := m.Meshlets[len(m.Meshlets)-1]
+	return (last.FirstTriangle+last.TriangleCount)*3 == len(m.Indices)
+}
+
+// BuildMeshlets splits the mesh into meshlets, reordering its triangles so
+// each meshlet is contiguous. Rebuild after editing the mesh.
+func (m *Mesh) BuildMeshlets(settings MeshletSettings) {
+	triangles := len(m.Indices) / 3
+	maxVertices := maxInt(settings.MaxVertices, 3)
+	maxTriangles := maxInt(settings.MaxTriangles, 1)
+	adjacency := vertexTriangles(m.Indices[:triangles*3], len(m.Vertices))
+
+	normals := make([]Point, triangles)
+	for t := range normals {
+		a, b, c := m.Vertices[m.Indices[t*3]], m.Vertices[m.Indices[t*3+1]], m.Vertices[m.Indices[t*3+2]]
+		if n := crossPoints(subPoints(b, a), subPoints(c, a)); pointLength(n) > 0 {
+			normals[t] = normalizePoint(n)
+		}
+	}
+
+	assigned := make([]bool, triangles)
+	owner := make([]int, len(m.Vertices)) // Meshlet number + 1 of the meshlet using each vertex
+	order := make([]int, 0, triangles)
+	var meshlets []Meshlet
+	cursor := 0
+
+	for len(order) < triangles {
+		for assigned[cursor] {
+			cursor++
+		}
+		meshlet := Meshlet{FirstTriangle: len(order)}
+		id := len(meshlets) + 1
+		facing := Point{}
+
+		for t := cursor; t >= 0; {
+			assigned[t] = true
+			order = append(order, t)
+			meshlet.TriangleCount++
+			facing = addPoints(facing, normals[t])
+			for _, v := range m.Indices[t*3 : t*3+3] {
+				if owner[v] != id {
+					owner[v] = id
+					meshlet.Vertices = append(meshlet.Vertices, v)
+				}
+			}
+			if meshlet.TriangleCount == maxTriangles {
+				break
+			}
+
+			// Next: the neighbour adding the fewest vertices, then the one
+			// facing most like the meshlet so far
+			axis := Point{}
+			if pointLength(facing) > 0 {
+				axis = normalizePoint(facing)
+			}
+			t = -1
+			bestScore := math.Inf(1)
+			for _, v := range meshlet.Vertices {
+				for _, other := range adjacency[v] {
+					if assigned[other] {
+						continue
+					}
+					added := 0
+					for _, w := range m.Indices[other*3 : other*3+3] {
+						if owner[w] != id {
+							added++
+						}
+					}
+					if len(meshlet.Vertices)+added > maxVertices {
+						continue
+					}
+					score := float64(added) + settings.ConeWeight*(1-dotPoints(normals[other], axis))
+					if score < bestScore {
+						t, bestScore = other, score
+					}
+				}
+			}
+		}
+
+		meshlets = append(meshlets, meshlet)
+	}
+
+	m.reorderTriangles(order)
+	for i := range meshlets {
+		m.computeMeshletBounds(&meshlets[i])
+	}
+	m.Meshlets = meshlets
+	m.meshletVertices = len(m.Vertices)
+}
+
+// computeMeshletBounds fills in a meshlet's bounding sphere and normal cone
+func (m *Mesh) computeMeshletBounds(meshlet *Meshlet) {
+	points := make([]Point, len(meshlet.Vertices))
+	for i, v := range meshlet.Vertices {
+		points[i] = m.Vertices[v]
+	}
+	meshlet.Center = NewAABBFromPoints(points).GetCenter()
+	for _, p := range points {
+		meshlet.Radius = math.Max(meshlet.Radius, pointLength(subPoints(p, meshlet.Center)))
+	}
+
+	var normals []Point
+	sum := Point{}
+	for t := meshlet.FirstTriangle; t < meshlet.FirstTriangle+meshlet.TriangleCount; t++ {
+		a, b, c := m.Vertices[m.Indices[t*3]], m.Vertices[m.Indices[t*3+1]], m.Vertices[m.Indices[t*3+2]]
+		if n := crossPoints(subPoints(b, a), subPoints(c, a)); pointLength(n) > 0 {
+			n = normalizePoint(n)
+			normals = append(normals, n)
+			sum = addPoints(sum, n)
+		}
+	}
+	meshlet.ConeCutoff = 1
+	if pointLength(sum) < 1e-9 {
+		return
+	}
+	meshlet.ConeAxis = normalizePoint(sum)
+	spread := 1.0 // Cosine of the widest angle from the axis
+	for _, n := range normals {
+		spread = math.Min(spread, dotPoints(n, meshlet.ConeAxis))
+	}
+	if spread > 0 {
+		meshlet.ConeCutoff = math.Sqrt(1 - spread*spread)
+	}
+}
+
+// VisibleMeshlets returns the meshlets that are inside the frustum and have
+// at least one triangle that may face cameraPos. Vertices are placed in the
+// world as worldMatrix·v + Position, as the software renderers do.
+func (m *Mesh) VisibleMeshlets(worldMatrix Matrix4x4, frustum *ViewFrustum, cameraPos Point) []*Meshlet {
+	// Spheres grow with the largest scale; cones only survive uniform scale
+	var axes [3]Point
+	minScale, maxScale := math.Inf(1), 0.0
+	for i, axis := range []Point{{X: 1}, {Y: 1}, {Z: 1}} {
+		axes[i] = worldMatrix.TransformDirection(axis)
+		s := pointLength(axes[i])
+		minScale, maxScale = math.Min(minScale, s), math.Max(maxScale, s)
+	}
+	useCones := maxScale-minScale <= 1e-6*maxScale
+
+	// A mirroring matrix reverses the winding, so triangles face the other way
+	coneSign := 1.0
+	if dotPoints(crossPoints(axes[0], axes[1]), axes[2]) < 0 {
+		coneSign = -1
+	}
+
+	visible := make([]*Meshlet, 0, len(m.Meshlets))
+	for i := range m.Meshlets {
+		meshlet := &m.Meshlets[i]
+		center := addPoints(worldMatrix.TransformPoint(meshlet.Center), m.Position)
+		radius := meshlet.Radius * maxScale
+		if frustum != nil && !frustum.TestSphere(center, radius) {
+			continue
+		}
+
+		// Every triangle faces away if the whole sphere is seen from
+		// behind the cone
+		if useCones && meshlet.ConeCutoff < 1 {
+			axis := scalePoint(normalizePoint(worldMatrix.TransformDirection(meshlet.ConeAxis)), coneSign)
+			view := subPoints(center, cameraPos)
+			if dotPoints(view, axis) >= meshlet.ConeCutoff*pointLength(view)+radius {
+				continue
+			}
+		}
+		visible = append(visible, meshlet)
+	}
+	return visible
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"image"
 	_ "image/jpeg"
-	_ "image/png"
+	"image/png"
 	"os"
 	"strconv"
 	"strings"
@@ -23,10 +23,14 @@ func LoadOBJ(filepath string) (*Mesh, error) {
 	scanner := bufio.NewScanner(file)
 
 	var vertices []Point
+	var vertexColors []Color // Parallel to vertices (white when a vertex has none)
+	hasColors := false
 	var normals []Point
 	var uvs []TextureCoord
 	var materialLib *MaterialLibrary
 	var currentMaterial *Material
+	missingNormals := false
+	missingUVs := false
 
 	lineNum := 0
 	for scanner.Scan() {
@@ -56,6 +60,18 @@ func LoadOBJ(filepath string) (*Mesh, error) {
 			}
 			vertices = append(vertices, Point{X: x, Y: y, Z: z})
 
+			// Vertex color extension: v x y z r g b
+			color := ColorWhite
+			if len(parts) >= 7 {
+				c, err := parseOBJColor(parts[4:7])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+				color = c
+				hasColors = true
+			}
+			vertexColors = append(vertexColors, color)
+
 		case "vn": // Vertex normal
 			if len(parts) < 4 {
 				return nil, fmt.Errorf("line %d: invalid normal definition", lineNum)
@@ -103,12 +119,37 @@ func LoadOBJ(filepath string) (*Mesh, error) {
 				// For simplicity, we duplicate vertices (could optimize with index mapping)
 				meshVertexIdx := mesh.AddVertex(vertices[vertexIdx].X, vertices[vertexIdx].Y, vertices[vertexIdx].Z)
 				faceVertices = append(faceVertices, meshVertexIdx)
+				mesh.Colors = append(mesh.Colors, vertexColors[vertexIdx])
+
+				// Keep the file's normal for this corner
+				normalIdx := indices[2] - 1
+				if indices[2] != 0 && (normalIdx < 0 || normalIdx >= len(normals)) {
+					return nil, fmt.Errorf("line %d: normal index out of range", lineNum)
+				}
+				if indices[2] != 0 {
+					mesh.Normals = append(mesh.Normals, normals[normalIdx])
+				} else {
+					missingNormals = true
+				}
+
+				// And its texture coordinate
+				uvIdx := indices[1] - 1
+				if indices[1] != 0 && (uvIdx < 0 || uvIdx >= len(uvs)) {
+					return nil, fmt.Errorf("line %d: texture coordinate index out of range", lineNum)
+				}
+				if indices[1] != 0 {
+					mesh.UVs = append(mesh.UVs, uvs[uvIdx])
+				} else {
+					missingUVs = true
+				}
 			}
 
-			// Triangulate face (fan triangulation for n-gons)
+			// Triangulate face (fan triangulation for n-gons), remembering
+			// the polygon for quad-based subdivision
 			for i := 1; i < len(faceVertices)-1; i++ {
 				mesh.AddTriangleIndices(faceVertices[0], faceVertices[i], faceVertices[i+1])
 			}
+			mesh.FaceSizes = append(mesh.FaceSizes, len(faceVertices))
 
 		case "mtllib": // Material library
 			if len(parts) >= 2 {
@@ -158,9 +199,44 @@ func LoadOBJ(filepath string) (*Mesh, error) {
 		return nil, fmt.Errorf("no vertices found in OBJ file")
 	}
 
+	if !hasColors {
+		mesh.Colors = nil
+	}
+	if missingUVs {
+		mesh.UVs = mesh.UVs[:0]
+	}
+
+	// Files without (complete) normals get generated ones
+	if missingNormals {
+		mesh.Normals = nil
+		mesh.GenerateNormals(DefaultCreaseAngle)
+	}
+
 	return mesh, nil
 }
 
+// parseOBJColor parses the r g b of a vertex color extension. Colors are
+// normally in [0, 1]; files that write 0-255 are detected and scaled.
+func parseOBJColor(fields []string) (Color, error) {
+	var rgb [3]float64
+	scale := 255.0
+	for i, f := range fields {
+		value, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return Color{}, fmt.Errorf("invalid vertex color")
+		}
+		rgb[i] = value
+		if value > 1 {
+			scale = 1
+		}
+	}
+	return Color{
+		R: uint8(clampFloat(rgb[0]*scale+0.5, 0, 255)),
+		G: uint8(clampFloat(rgb[1]*scale+0.5, 0, 255)),
+		B: uint8(clampFloat(rgb[2]*scale+0.5, 0, 255)),
+	}, nil
+}
+
 // parseFaceVertex parses a face vertex string (v, v/vt, v/vt/vn, v//vn)
 // Returns [vertexIdx, texCoordIdx, normalIdx] (0 means not present)
 func parseFaceVertex(s string) [3]int {
@@ -211,20 +287,52 @@ func SaveOBJ(mesh *Mesh, filepath string) error {
 	writer.WriteString(fmt.Sprintf("# Vertices: %d\n", len(mesh.Vertices)))
 	writer.WriteString(fmt.Sprintf("# Triangles: %d\n\n", len(mesh.Indices)/3))
 
-	// Write vertices
-	for _, v := range mesh.Vertices {
+	// Write vertices, with the vertex color extension when colored
+	hasColors := mesh.HasColors()
+	for i, v := range mesh.Vertices {
+		if hasColors {
+			c := mesh.Colors[i]
+			writer.WriteString(fmt.Sprintf("v %.6f %.6f %.6f %.4f %.4f %.4f\n", v.X, v.Y, v.Z,
+				float64(c.R)/255.0, float64(c.G)/255.0, float64(c.B)/255.0))
+			continue
+		}
 		writer.WriteString(fmt.Sprintf("v %.6f %.6f %.6f\n", v.X, v.Y, v.Z))
 	}
 
+	// Write normals and UVs (one per vertex, so they share the vertex indices)
+	hasNormals := mesh.HasNormals()
+	if hasNormals {
+		for _, n := range mesh.Normals {
+			writer.WriteString(fmt.Sprintf("vn %.6f %.6f %.6f\n", n.X, n.Y, n.Z))
+		}
+	}
+	hasUVs := len(mesh.UVs) > 0 && len(mesh.UVs) == len(mesh.Vertices)
+	if hasUVs {
+		for _, uv := range mesh.UVs {
+			writer.WriteString(fmt.Sprintf("vt %.6f %.6f\n", uv.U, uv.V))
+		}
+	}
+
 	writer.WriteString("\n")
 
-	// Write faces (triangles)
-	for i := 0; i < len(mesh.Indices); i += 3 {
-		// OBJ uses 1-based indexing
-		writer.WriteString(fmt.Sprintf("f %d %d %d\n",
-			mesh.Indices[i]+1,
-			mesh.Indices[i+1]+1,
-			mesh.Indices[i+2]+1))
+	// Write faces, keeping the source polygons when known
+	for _, polygon := range mesh.Polygons() {
+		writer.WriteString("f")
+		for _, idx := range polygon {
+			// OBJ uses 1-based indexing
+			i := idx + 1
+			switch {
+			case hasUVs && hasNormals:
+				writer.WriteString(fmt.Sprintf(" %d/%d/%d", i, i, i))
+			case hasUVs:
+				writer.WriteString(fmt.Sprintf(" %d/%d", i, i))
+			case hasNormals:
+				writer.WriteString(fmt.Sprintf(" %d//%d", i, i))
+			default:
+				writer.WriteString(fmt.Sprintf(" %d", i))
+			}
+		}
+		writer.WriteString("\n")
 	}
 
 	return nil
@@ -246,6 +354,20 @@ func LoadTextureFromFile(filepath string) (*Texture, error) {
 	return NewTextureFromImage(img), nil
 }
 
+// SaveTextureToFile writes a texture to a PNG file
+func SaveTextureToFile(tex *Texture, filepath string) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, tex.ToImage()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
 // OBJStats holds statistics about a loaded OBJ file
 type OBJStats struct {
 	Vertices  int
@@ -38,6 +38,10 @@ func AcquireTriangle() *Triangle {
 func ReleaseTriangle(t *Triangle) {
 	// Reset to avoid keeping references
 	t.Normal = nil
+	t.HasVertexNormals = false
+	t.HasTangents = false
+	t.HasVertexColors = false
+	t.Lightmap = nil
 	trianglePoolGlobal.Put(t)
 }
 
@@ -54,7 +54,9 @@ func SetupThreePointLighting(camera *Camera) *LightingSystem {
 	ls.AddLight(keyLight)
 
 	// Fill Light (softer, opposite side, fills shadows)
+	// It does not cast shadows of its own, so it brightens the key light's shadows
 	fillLight := NewLight(-30, 20, -20, Color{200, 210, 220}, 0.4)
+	fillLight.CastShadows = false
 	ls.AddLight(fillLight)
 
 	// Rim Light (back light, creates edge highlight)
@@ -112,9 +114,23 @@ func SetupDynamicLighting(camera *Camera) *LightingSystem {
 	light3 := NewLight(0, 30, -20, ColorMagenta, 0.6)
 	ls.AddLight(light3)
 
+	// Ring of short-range accent lights; each one only reaches a few
+	// screen tiles, so tiled light culling skips it everywhere else
+	accentColors := []Color{ColorRed, ColorYellow, ColorGreen, ColorCyan, ColorBlue, ColorMagenta}
+	for i := 0; i < dynamicAccentLights; i++ {
+		angle := float64(i) / dynamicAccentLights * 2 * math.Pi
+		accent := NewLight(30*math.Cos(angle), 8, 30*math.Sin(angle), accentColors[i%len(accentColors)], 0.6)
+		accent.Range = 18
+		accent.CastShadows = false
+		ls.AddLight(accent)
+	}
+
 	return ls
 }
 
+// dynamicAccentLights is the number of ranged lights in SetupDynamicLighting
+const dynamicAccentLights = 24
+
 // ============================================================================
 // SCENARIO 6: NIGHT SCENE - Dark, focused lighting
 // ============================================================================
@@ -222,6 +238,23 @@ func SetupSunsetLighting(camera *Camera) *LightingSystem {
 	return ls
 }
 
+// ============================================================================
+// SCENARIO 11: LAMP - Point light with omnidirectional shadows
+// ============================================================================
+func SetupLampLighting(camera *Camera) *LightingSystem {
+	ls := NewLightingSystem(camera)
+
+	// Dim ambient so the cube shadows read clearly
+	ls.AmbientLight = Color{25, 25, 35}
+	ls.AmbientIntensity = 0.12
+
+	// Hanging lamp in the middle of the shadow casters
+	lamp := NewPointLight(0, 14, 0, Color{255, 225, 180}, 1.6)
+	ls.AddLight(lamp)
+
+	return ls
+}
+
 // ============================================================================
 // LIGHTING SCENARIO SELECTOR
 // ============================================================================
@@ -230,26 +263,28 @@ func SetupSunsetLighting(camera *Camera) *LightingSystem {
 func GetLightingScenario(demoIndex int, camera *Camera) *LightingSystem {
 	// Map demo indices to lighting scenarios
 	switch demoIndex {
-	case 0: // Basic Geometry
+	case DemoBasicGeometry:
 		return SetupSoftLighting(camera)
-	case 1: // Mesh Generators
+	case DemoMeshGenerators:
 		return SetupThreePointLighting(camera)
-	case 2: // Lighting Showcase - Special handling
+	case DemoLightingShowcase: // Special handling
 		return SetupLightingShowcase(camera)
-	case 3: // Material Showcase
+	case DemoMaterialShowcase:
 		return SetupThreePointLighting(camera)
-	case 4: // Transform Hierarchy
+	case DemoTransformHierarchy, DemoTerrain:
 		return SetupOutdoorLighting(camera)
-	case 5: // LOD System
+	case DemoLODSystem:
 		return SetupDirectionalLighting(camera)
-	case 6: // Spatial Partitioning
+	case DemoSpatialPartitioning:
 		return SetupColoredLighting(camera)
-	case 7: // Collision Physics
+	case DemoCollisionPhysics:
 		return SetupDynamicLighting(camera)
-	case 8: // Advanced Rendering
+	case DemoAdvancedRendering:
 		return SetupDramaticLighting(camera)
-	case 9: // Performance Test
+	case DemoPerformanceTest:
 		return SetupDirectionalLighting(camera)
+	case DemoShadowMapping:
+		return SetupLampLighting(camera)
 	default:
 		return SetupThreePointLighting(camera)
 	}
@@ -305,25 +340,36 @@ func AnimateDynamicLights(lightingSystem *LightingSystem, time float64) {
 	// Pulse light 3 up and down
 	lightingSystem.Lights[2].Position.Y = 30 + math.Sin(time*1.5)*15
 	lightingSystem.Lights[2].Intensity = 0.6 + math.Sin(time*2.0)*0.2
-}
 
-// GetLightingScenarioName returns a descriptive name for logging
-func GetLightingScenarioName(demoIndex int) string {
-	names := []string{
-		"Soft Lighting (Product)",
-		"Three-Point Lighting (Studio)",
-		"Mixed Scenarios (Showcase)",
-		"Three-Point Lighting (Studio)",
-		"Outdoor Lighting (Day)",
-		"Directional Lighting (Sun)",
-		"Colored Lighting (RGB Mix)",
-		"Dynamic Lighting (Animated)",
-		"Dramatic Lighting (High Contrast)",
-		"Directional Lighting (Sun)",
+	// Bob the accent ring
+	for i, light := range lightingSystem.Lights[3:] {
+		light.Position.Y = 8 + math.Sin(time*2.0+float64(i)*0.5)*4
 	}
+}
 
-	if demoIndex >= 0 && demoIndex < len(names) {
-		return names[demoIndex]
+// GetLightingScenarioName returns a descriptive name for logging; it must
+// match the scenario GetLightingScenario picks
+func GetLightingScenarioName(demoIndex int) string {
+	switch demoIndex {
+	case DemoBasicGeometry:
+		return "Soft Lighting (Product)"
+	case DemoMeshGenerators, DemoMaterialShowcase:
+		return "Three-Point Lighting (Studio)"
+	case DemoLightingShowcase:
+		return "Mixed Scenarios (Showcase)"
+	case DemoTransformHierarchy, DemoTerrain:
+		return "Outdoor Lighting (Day)"
+	case DemoLODSystem, DemoPerformanceTest:
+		return "Directional Lighting (Sun)"
+	case DemoSpatialPartitioning:
+		return "Colored Lighting (RGB Mix)"
+	case DemoCollisionPhysics:
+		return "Dynamic Lighting (Animated)"
+	case DemoAdvancedRendering:
+		return "Dramatic Lighting (High Contrast)"
+	case DemoShadowMapping:
+		return "Lamp Lighting (Point Shadows)"
+	default:
+		return "Three-Point Lighting (Default)"
 	}
-	return "Three-Point Lighting (Default)"
 }
@@ -0,0 +1,898 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// ============================================================================
+// PARAMETRIC CURVES AND SURFACES
+// ============================================================================
+// Bezier patch surfaces, NURBS surfaces and NURBS curves (which include
+// Bezier and B-spline curves) are scene objects kept in their exact form and
+// tessellated for the camera. Update picks how finely to tessellate from the
+// screen-space error: splitting a span into n equal segments strays from the
+// exact shape by at most |P''|/(8n²), and an error e at distance d covers
+// e*projectionScale/d screen cells (the camera's FOV, as in ProjectPoint).
+// Spans close to the camera get more segments, distant ones fewer. Both
+// sides scale alike, so a uniformly scaled node needs no correction.
+//
+// The patches of a Bezier surface each pick power of two segment counts.
+// Where two patches share an edge at different counts, the finer patch moves
+// its extra edge vertices onto the coarser patch's edge so no cracks open.
+// ============================================================================
+
+// TessellationSettings controls adaptive tessellation
+type TessellationSettings struct {
+	MaxScreenError float64 // Allowed distance from the exact shape, in screen cells
+	MinSegments    int     // Segments per patch side or curve, however far away
+	MaxSegments    int     // Segments per patch side or curve, however close
+}
+
+// DefaultTessellationSettings returns settings for half a screen cell of error
+func DefaultTessellationSettings() TessellationSettings {
+	return TessellationSettings{
+		MaxScreenError: 0.5,
+		MinSegments:    2,
+		MaxSegments:    32,
+	}
+}
+
+// segmentsFor returns the segments that keep a span whose second derivative
+// is at most curvature within the screen error, seen from distance
+func (ts TessellationSettings) segmentsFor(curvature, distance, projectionScale float64) int {
+	lo, hi := maxInt(ts.MinSegments, 1), maxInt(ts.MaxSegments, 1)
+	if ts.MaxScreenError <= 0 || distance <= 0 {
+		return hi
+	}
+	if projectionScale <= 0 {
+		return lo
+	}
+	worldError := ts.MaxScreenError * distance / projectionScale
+	n := math.Ceil(math.Sqrt(curvature / (8 * worldError)))
+	if n > float64(hi) {
+		return hi
+	}
+	return clampInt(int(n), lo, hi)
+}
+
+// ============================================================================
+// NURBS BASIS
+// ============================================================================
+
+// validateNURBS checks the control net and knot vector along one direction
+func validateNURBS(degree, count int, knots []float64) error {
+	if degree < 1 {
+		return fmt.Errorf("NURBS degree must be at least 1, got %d", degree)
+	}
+	if count < degree+1 {
+		return fmt.Errorf("degree %d NURBS needs at least %d control points, got %d", degree, degree+1, count)
+	}
+	if len(knots) != count+degree+1 {
+		return fmt.Errorf("NURBS needs %d knots, got %d", count+degree+1, len(knots))
+	}
+	for i := 1; i < len(knots); i++ {
+		if knots[i] < knots[i-1] {
+			return fmt.Errorf("NURBS knots must not decrease, knot %d is %g after %g", i, knots[i], knots[i-1])
+		}
+	}
+	if knots[count] <= knots[degree] {
+		return fmt.Errorf("NURBS knots leave an empty domain")
+	}
+	return nil
+}
+
+// validateWeights checks that weights are absent or positive, one per point
+func validateWeights(weights []float64, count int) error {
+	if weights == nil {
+		return nil
+	}
+	if len(weights) != count {
+		return fmt.Errorf("NURBS needs %d weights, got %d", count, len(weights))
+	}
+	for i, w := range weights {
+		if w <= 0 {
+			return fmt.Errorf("NURBS weight %d must be positive, got %g", i, w)
+		}
+	}
+	return nil
+}
+
+// ClampedKnots returns a uniform knot vector whose ends are repeated
+// degree+1 times, so the curve starts and ends on its end control points
+func ClampedKnots(count, degree int) []float64 {
+	knots := make([]float64, count+degree+1)
+	spans := count - degree
+	for i := range knots {
+		switch {
+		case i <= degree:
+			knots[i] = 0
+		case i >= count:
+			knots[i] = 1
+		default:
+			knots[i] = float64(i-degree) / float64(spans)
+		}
+	}
+	return knots
+}
+
+// nurbsSpan returns the knot span holding u, for count control points
+func nurbsSpan(count, degree int, u float64, knots []float64) int {
+	if u >= knots[count] {
+		// The end of the domain belongs to the last non-empty span
+		span := count - 1
+		for span > degree && knots[span] == knots[span+1] {
+			span--
+		}
+		return span
+	}
+	if u <= knots[degree] {
+		return degree
+	}
+	lo, hi := degree, count
+	mid := (lo + hi) / 2
+	for u < knots[mid] || u >= knots[mid+1] {
+		if u < knots[mid] {
+			hi = mid
+		} else {
+			lo = mid
+		}
+		mid = (lo + hi) / 2
+	}
+	return mid
+}
+
+// nurbsBasis returns the degree+1 basis functions that are non-zero in span
+// at u
+func nurbsBasis(span int, u float64, degree int, knots []float64) []float64 {
+	basis := make([]float64, degree+1)
+	left := make([]float64, degree+1)
+	right := make([]float64, degree+1)
+	basis[0] = 1
+	for j := 1; j <= degree; j++ {
+		left[j] = u - knots[span+1-j]
+		right[j] = knots[span+j] - u
+		saved := 0.0
+		for r := 0; r < j; r++ {
+			temp := basis[r] / (right[r+1] + left[j-r])
+			basis[r] = saved + right[r+1]*temp
+			saved = left[j-r] * temp
+		}
+		basis[j] = saved
+	}
+	return basis
+}
+
+// domainParameter maps t in [0, 1] onto a knot vector's domain
+func domainParameter(t float64, count, degree int, knots []float64) float64 {
+	lo, hi := knots[degree], knots[count]
+	return lo + clampFloat(t, 0, 1)*(hi-lo)
+}
+
+// ============================================================================
+// NURBS CURVES
+// ============================================================================
+
+// NURBSCurve is a non-uniform rational B-spline curve, drawn as a polyline
+type NURBSCurve struct {
+	Degree        int
+	ControlPoints []Point
+	Weights       []float64 // One per control point; nil for a non-rational curve
+	Knots         []float64 // len(ControlPoints)+Degree+1 values, never decreasing
+	Settings      TessellationSettings
+
+	segments int
+	polyline []Point
+}
+
+// NewNURBSCurve creates a NURBS curve, checking its knots and weights
+func NewNURBSCurve(degree int, points []Point, weights, knots []float64) (*NURBSCurve, error) {
+	if err := validateNURBS(degree, len(points), knots); err != nil {
+		return nil, err
+	}
+	if err := validateWeights(weights, len(points)); err != nil {
+		return nil, err
+	}
+	return &NURBSCurve{
+		Degree:        degree,
+		ControlPoints: points,
+		Weights:       weights,
+		Knots:         knots,
+		Settings:      DefaultTessellationSettings(),
+	}, nil
+}
+
+// NewBezierCurve creates a Bezier curve through its first and last control
+// points, of degree one less than the number of points (at least 2)
+func NewBezierCurve(points []Point) (*NURBSCurve, error) {
+	return NewBSplineCurve(points, len(points)-1)
+}
+
+// NewBSplineCurve creates a clamped uniform B-spline curve from at least 2
+// points. The degree is lowered if there are too few points for it. The
+// curve keeps its own copy of the points.
+func NewBSplineCurve(points []Point, degree int) (*NURBSCurve, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("B-spline curve needs at least 2 control points, got %d", len(points))
+	}
+	degree = clampInt(degree, 1, len(points)-1)
+	return &NURBSCurve{
+		Degree:        degree,
+		ControlPoints: append([]Point(nil), points...),
+		Knots:         ClampedKnots(len(points), degree),
+		Settings:      DefaultTessellationSettings(),
+	}, nil
+}
+
+// circleControlNet returns the nine control points, weights and knots of a
+// rational quadratic circle around the Y axis in the XZ plane, starting on
+// +X and turning towards -Z
+func circleControlNet(radius float64) ([]Point, []float64, []float64) {
+	points := make([]Point, 9)
+	weights := make([]float64, 9)
+	for k := range points {
+		angle := float64(k) * math.Pi / 4
+		r := radius
+		weights[k] = 1
+		if k%2 == 1 {
+			// Corners of the square the arcs are inscribed in
+			r *= math.Sqrt2
+			weights[k] = math.Sqrt2 / 2
+		}
+		points[k] = Point{X: r * math.Cos(angle), Z: -r * math.Sin(angle)}
+	}
+	knots := []float64{0, 0, 0, 0.25, 0.25, 0.5, 0.5, 0.75, 0.75, 1, 1, 1}
+	return points, weights, knots
+}
+
+// NewNURBSCircle creates an exact circle around the Y axis in the XZ plane
+func NewNURBSCircle(radius float64) *NURBSCurve {
+	points, weights, knots := circleControlNet(radius)
+	curve, _ := NewNURBSCurve(2, points, weights, knots)
+	return curve
+}
+
+// Evaluate returns the point at t, which runs from 0 to 1 along the curve
+func (c *NURBSCurve) Evaluate(t float64) Point {
+	count := len(c.ControlPoints)
+	u := domainParameter(t, count, c.Degree, c.Knots)
+	span := nurbsSpan(count, c.Degree, u, c.Knots)
+	basis := nurbsBasis(span, u, c.Degree, c.Knots)
+
+	var sum Point
+	total := 0.0
+	for i, b := range basis {
+		k := span - c.Degree + i
+		w := b
+		if c.Weights != nil {
+			w *= c.Weights[k]
+		}
+		sum = addPoints(sum, scalePoint(c.ControlPoints[k], w))
+		total += w
+	}
+	return scalePoint(sum, 1/total)
+}
+
+// Bounds returns the box around the control points, which holds the curve
+func (c *NURBSCurve) Bounds() *AABB {
+	return NewAABBFromPoints(c.ControlPoints)
+}
+
+// curvature estimates the largest second derivative along t
+func (c *NURBSCurve) curvature() float64 {
+	samples := 4*len(c.ControlPoints) + 1
+	h := 1 / float64(samples-1)
+	points := make([]Point, samples)
+	for i := range points {
+		points[i] = c.Evaluate(float64(i) * h)
+	}
+	return secondDifference(points, h)
+}
+
+// secondDifference returns the largest second difference along a row of
+// evenly spaced samples, divided by the spacing squared
+func secondDifference(points []Point, h float64) float64 {
+	largest := 0.0
+	for i := 1; i+1 < len(points); i++ {
+		d := addPoints(subPoints(points[i+1], points[i]), subPoints(points[i-1], points[i]))
+		largest = math.Max(largest, pointLength(d))
+	}
+	return largest / (h * h)
+}
+
+// Update picks the number of segments for a camera position in the curve's
+// local space
+func (c *NURBSCurve) Update(cameraPos Point, projectionScale float64) {
+	distance := distanceToAABB(cameraPos, c.Bounds())
+	c.SetSegments(c.Settings.segmentsFor(c.curvature(), distance, projectionScale))
+}
+
+// SetSegments tessellates the curve into a fixed number of segments
+func (c *NURBSCurve) SetSegments(segments int) {
+	segments = maxInt(segments, 1)
+	if segments == c.segments && c.polyline != nil {
+		return
+	}
+	c.segments = segments
+	c.polyline = make([]Point, segments+1)
+	for i := range c.polyline {
+		c.polyline[i] = c.Evaluate(float64(i) / float64(segments))
+	}
+}
+
+// Segments returns the number of segments the curve is drawn with
+func (c *NURBSCurve) Segments() int {
+	return c.segments
+}
+
+// Polyline returns the tessellated curve, tessellating it at the minimum
+// segment count if Update has not been called
+func (c *NURBSCurve) Polyline() []Point {
+	if c.polyline == nil {
+		c.SetSegments(c.Settings.MinSegments)
+	}
+	return c.polyline
+}
+
+// Lines returns the tessellated curve as line segments for rendering
+func (c *NURBSCurve) Lines() []*Line {
+	polyline := c.Polyline()
+	lines := make([]*Line, len(polyline)-1)
+	for i := range lines {
+		lines[i] = NewLine(polyline[i], polyline[i+1])
+	}
+	return lines
+}
+
+// Draw renders the tessellated curve as lines
+func (c *NURBSCurve) Draw(r Renderer, worldMatrix Matrix4x4, camera *Camera) {
+	for _, line := range c.Lines() {
+		r.RenderLine(line, worldMatrix, camera)
+	}
+}
+
+// ============================================================================
+// NURBS SURFACES
+// ============================================================================
+
+// NURBSSurface is a tensor product NURBS surface, drawn as a grid mesh
+type NURBSSurface struct {
+	DegreeU, DegreeV int
+	CountU, CountV   int       // Control points along u and v
+	ControlPoints    []Point   // CountU*CountV points; point (i, j) is at j*CountU+i
+	Weights          []float64 // One per control point; nil for a non-rational surface
+	KnotsU, KnotsV   []float64
+	Material         IMaterial
+	Settings         TessellationSettings
+
+	segmentsU, segmentsV int
+	mesh                 *Mesh
+}
+
+// NewNURBSSurface creates a NURBS surface, checking its knots and weights
+func NewNURBSSurface(degreeU, degreeV, countU, countV int, points []Point, weights, knotsU, knotsV []float64) (*NURBSSurface, error) {
+	if len(points) != countU*countV {
+		return nil, fmt.Errorf("NURBS surface needs %dx%d control points, got %d", countU, countV, len(points))
+	}
+	if err := validateNURBS(degreeU, countU, knotsU); err != nil {
+		return nil, fmt.Errorf("along u: %w", err)
+	}
+	if err := validateNURBS(degreeV, countV, knotsV); err != nil {
+		return nil, fmt.Errorf("along v: %w", err)
+	}
+	if err := validateWeights(weights, len(points)); err != nil {
+		return nil, err
+	}
+	return &NURBSSurface{
+		DegreeU:       degreeU,
+		DegreeV:       degreeV,
+		CountU:        countU,
+		CountV:        countV,
+		ControlPoints: points,
+		Weights:       weights,
+		KnotsU:        knotsU,
+		KnotsV:        knotsV,
+		Settings:      DefaultTessellationSettings(),
+	}, nil
+}
+
+// NewSurfaceOfRevolution sweeps a profile curve in the XY plane (X being the
+// distance from the axis) around the Y axis. The profile runs along v and
+// the exact circle along u; a profile rising along +Y faces outward.
+func NewSurfaceOfRevolution(profile *NURBSCurve) *NURBSSurface {
+	circle, circleWeights, circleKnots := circleControlNet(1)
+	countU, countV := len(circle), len(profile.ControlPoints)
+	points := make([]Point, 0, countU*countV)
+	weights := make([]float64, 0, countU*countV)
+
+	for j, p := range profile.ControlPoints {
+		w := 1.0
+		if profile.Weights != nil {
+			w = profile.Weights[j]
+		}
+		for i, c := range circle {
+			points = append(points, Point{X: c.X * p.X, Y: p.Y, Z: c.Z * p.X})
+			weights = append(weights, circleWeights[i]*w)
+		}
+	}
+
+	surface, _ := NewNURBSSurface(2, profile.Degree, countU, countV, points, weights, circleKnots, profile.Knots)
+	return surface
+}
+
+// Evaluate returns the point at (u, v), each running from 0 to 1 across the
+// surface
+func (s *NURBSSurface) Evaluate(u, v float64) Point {
+	pu := domainParameter(u, s.CountU, s.DegreeU, s.KnotsU)
+	pv := domainParameter(v, s.CountV, s.DegreeV, s.KnotsV)
+	spanU := nurbsSpan(s.CountU, s.DegreeU, pu, s.KnotsU)
+	spanV := nurbsSpan(s.CountV, s.DegreeV, pv, s.KnotsV)
+	basisU := nurbsBasis(spanU, pu, s.DegreeU, s.KnotsU)
+	basisV := nurbsBasis(spanV, pv, s.DegreeV, s.KnotsV)
+
+	var sum Point
+	total := 0.0
+	for j, bv := range basisV {
+		row := (spanV - s.DegreeV + j) * s.CountU
+		for i, bu := range basisU {
+			k := row + spanU - s.DegreeU + i
+			w := bu * bv
+			if s.Weights != nil {
+				w *= s.Weights[k]
+			}
+			sum = addPoints(sum, scalePoint(s.ControlPoints[k], w))
+			total += w
+		}
+	}
+	return scalePoint(sum, 1/total)
+}
+
+// Normal returns the unit normal at (u, v), the direction of dS/du × dS/dv
+func (s *NURBSSurface) Normal(u, v float64) Point {
+	return parametricNormal(func(u, v float64) Point { return s.Evaluate(u, v) }, u, v)
+}
+
+// parametricNormal returns the normal of a surface over the unit square from
+// central differences. Where the surface pinches to a point the derivatives
+// vanish, so the normal is taken slightly towards the middle instead.
+func parametricNormal(evaluate func(u, v float64) Point, u, v float64) Point {
+	const h = 1e-5
+	for nudge := 0; nudge < 4; nudge++ {
+		u0, u1 := math.Max(u-h, 0), math.Min(u+h, 1)
+		v0, v1 := math.Max(v-h, 0), math.Min(v+h, 1)
+		du := scalePoint(subPoints(evaluate(u1, v), evaluate(u0, v)), 1/(u1-u0))
+		dv := scalePoint(subPoints(evaluate(u, v1), evaluate(u, v0)), 1/(v1-v0))
+		if n := crossPoints(du, dv); pointLength(n) > 1e-9*(pointLength(du)*pointLength(dv)+1e-300) {
+			return normalizePoint(n)
+		}
+		u += (0.5 - u) * 1e-3
+		v += (0.5 - v) * 1e-3
+	}
+	return Point{}
+}
+
+// Bounds returns the box around the control points, which holds the surface
+func (s *NURBSSurface) Bounds() *AABB {
+	return NewAABBFromPoints(s.ControlPoints)
+}
+
+// Update picks the segments along u and v for a camera position in the
+// surface's local space
+func (s *NURBSSurface) Update(cameraPos Point, projectionScale float64) {
+	samplesU, samplesV := 4*s.CountU+1, 4*s.CountV+1
+	hu, hv := 1/float64(samplesU-1), 1/float64(samplesV-1)
+	grid := make([]Point, samplesU*samplesV)
+	for j := 0; j < samplesV; j++ {
+		for i := 0; i < samplesU; i++ {
+			grid[j*samplesU+i] = s.Evaluate(float64(i)*hu, float64(j)*hv)
+		}
+	}
+
+	curvatureU, curvatureV := 0.0, 0.0
+	column := make([]Point, samplesV)
+	for j := 0; j < samplesV; j++ {
+		curvatureU = math.Max(curvatureU, secondDifference(grid[j*samplesU:(j+1)*samplesU], hu))
+	}
+	for i := 0; i < samplesU; i++ {
+		for j := range column {
+			column[j] = grid[j*samplesU+i]
+		}
+		curvatureV = math.Max(curvatureV, secondDifference(column, hv))
+	}
+
+	distance := distanceToAABB(cameraPos, s.Bounds())
+	s.SetSegments(
+		s.Settings.segmentsFor(curvatureU, distance, projectionScale),
+		s.Settings.segmentsFor(curvatureV, distance, projectionScale),
+	)
+}
+
+// SetSegments tessellates the surface into a fixed grid
+func (s *NURBSSurface) SetSegments(segmentsU, segmentsV int) {
+	segmentsU, segmentsV = maxInt(segmentsU, 1), maxInt(segmentsV, 1)
+	if segmentsU == s.segmentsU && segmentsV == s.segmentsV && s.mesh != nil {
+		return
+	}
+	s.segmentsU, s.segmentsV = segmentsU, segmentsV
+
+	mesh := NewMesh()
+	mesh.Material = s.Material
+	evaluate := func(u, v float64) Point { return s.Evaluate(u, v) }
+	for j := 0; j <= segmentsV; j++ {
+		for i := 0; i <= segmentsU; i++ {
+			u, v := float64(i)/float64(segmentsU), float64(j)/float64(segmentsV)
+			p := s.Evaluate(u, v)
+			mesh.AddVertexWithUV(p.X, p.Y, p.Z, u, v)
+			mesh.Normals = append(mesh.Normals, parametricNormal(evaluate, u, v))
+		}
+	}
+	addGridTriangles(mesh, 0, segmentsU, segmentsV)
+	s.mesh = mesh
+}
+
+// Segments returns the grid the surface is drawn with
+func (s *NURBSSurface) Segments() (int, int) {
+	return s.segmentsU, s.segmentsV
+}
+
+// VisibleMeshes returns the tessellated surface, tessellating it at the
+// minimum segment count if Update has not been called
+func (s *NURBSSurface) VisibleMeshes() []*Mesh {
+	if s.mesh == nil {
+		s.SetSegments(s.Settings.MinSegments, s.Settings.MinSegments)
+	}
+	s.mesh.Material = s.Material
+	return []*Mesh{s.mesh}
+}
+
+// addGridTriangles adds two triangles per cell of a (nu+1)x(nv+1) vertex grid
+// starting at first, wound to face along du × dv. Cells pinched to a line or
+// point are left out.
+func addGridTriangles(mesh *Mesh, first, nu, nv int) {
+	add := func(a, b, c int) {
+		p0, p1, p2 := mesh.Vertices[a], mesh.Vertices[b], mesh.Vertices[c]
+		if pointLength(crossPoints(subPoints(p1, p0), subPoints(p2, p0))) > 1e-12 {
+			mesh.AddTriangleIndices(a, b, c)
+		}
+	}
+	for j := 0; j < nv; j++ {
+		for i := 0; i < nu; i++ {
+			a := first + j*(nu+1) + i
+			b, c, d := a+1, a+nu+2, a+nu+1
+			add(a, b, c)
+			add(a, c, d)
+		}
+	}
+}
+
+// ============================================================================
+// BICUBIC BEZIER PATCHES
+// ============================================================================
+
+// BezierPatch is a bicubic Bezier patch; point (i, j) is at j*4+i, with i
+// running along u and j along v
+type BezierPatch [16]Point
+
+// bernstein3 returns the cubic Bernstein polynomials at t and their
+// derivatives
+func bernstein3(t float64) (b, d [4]float64) {
+	s := 1 - t
+	b = [4]float64{s * s * s, 3 * t * s * s, 3 * t * t * s, t * t * t}
+	d = [4]float64{-3 * s * s, 3*s*s - 6*t*s, 6*t*s - 3*t*t, 3 * t * t}
+	return b, d
+}
+
+// Evaluate returns the point at (u, v)
+func (p *BezierPatch) Evaluate(u, v float64) Point {
+	bu, _ := bernstein3(u)
+	bv, _ := bernstein3(v)
+	var sum Point
+	for j := 0; j < 4; j++ {
+		for i := 0; i < 4; i++ {
+			sum = addPoints(sum, scalePoint(p[j*4+i], bu[i]*bv[j]))
+		}
+	}
+	return sum
+}
+
+// Normal returns the unit normal at (u, v), the direction of dP/du × dP/dv.
+// Where the patch pinches to a point it is taken slightly towards the middle.
+func (p *BezierPatch) Normal(u, v float64) Point {
+	for nudge := 0; nudge < 4; nudge++ {
+		bu, du := bernstein3(u)
+		bv, dv := bernstein3(v)
+		var tu, tv Point
+		for j := 0; j < 4; j++ {
+			for i := 0; i < 4; i++ {
+				tu = addPoints(tu, scalePoint(p[j*4+i], du[i]*bv[j]))
+				tv = addPoints(tv, scalePoint(p[j*4+i], bu[i]*dv[j]))
+			}
+		}
+		if n := crossPoints(tu, tv); pointLength(n) > 1e-9*(pointLength(tu)*pointLength(tv)+1e-300) {
+			return normalizePoint(n)
+		}
+		u += (0.5 - u) * 1e-3
+		v += (0.5 - v) * 1e-3
+	}
+	return Point{}
+}
+
+// curvature bounds the second derivatives along u and v by the second
+// differences of the control net
+func (p *BezierPatch) curvature() (float64, float64) {
+	cu, cv := 0.0, 0.0
+	for a := 0; a < 4; a++ {
+		for b := 1; b < 3; b++ {
+			du := addPoints(subPoints(p[a*4+b+1], p[a*4+b]), subPoints(p[a*4+b-1], p[a*4+b]))
+			dv := addPoints(subPoints(p[(b+1)*4+a], p[b*4+a]), subPoints(p[(b-1)*4+a], p[b*4+a]))
+			cu = math.Max(cu, pointLength(du))
+			cv = math.Max(cv, pointLength(dv))
+		}
+	}
+	// A cubic's second derivative is 6 times its control points' second
+	// differences
+	return 6 * cu, 6 * cv
+}
+
+// edge returns the control points along one side: 0 is v = 0, 1 is u = 1,
+// 2 is v = 1 and 3 is u = 0
+func (p *BezierPatch) edge(side int) [4]Point {
+	var points [4]Point
+	for k := 0; k < 4; k++ {
+		switch side {
+		case 0:
+			points[k] = p[k]
+		case 1:
+			points[k] = p[k*4+3]
+		case 2:
+			points[k] = p[12+k]
+		case 3:
+			points[k] = p[k*4]
+		}
+	}
+	return points
+}
+
+// patchSide identifies one side of a patch in a BezierSurface
+type patchSide struct {
+	Patch, Side int
+}
+
+// BezierSurface is a set of bicubic Bezier patches drawn as one mesh
+type BezierSurface struct {
+	Patches  []BezierPatch
+	Material IMaterial
+	Settings TessellationSettings
+
+	neighbours [][4]patchSide // Patch sharing each side, Patch -1 if none
+	segments   [][2]int       // Segments along u and v per patch
+	mesh       *Mesh
+}
+
+// NewBezierSurface creates a surface from patches, finding the sides they
+// share
+func NewBezierSurface(patches []BezierPatch) *BezierSurface {
+	s := &BezierSurface{
+		Patches:  patches,
+		Settings: DefaultTessellationSettings(),
+	}
+	s.findNeighbours()
+	return s
+}
+
+// findNeighbours matches patch sides with the same control points, in either
+// direction
+func (s *BezierSurface) findNeighbours() {
+	quantize := func(p Point) [3]int64 {
+		return [3]int64{int64(math.Round(p.X * 1e6)), int64(math.Round(p.Y * 1e6)), int64(math.Round(p.Z * 1e6))}
+	}
+	less := func(a, b [4][3]int64) bool {
+		for k := 0; k < 4; k++ {
+			for c := 0; c < 3; c++ {
+				if a[k][c] != b[k][c] {
+					return a[k][c] < b[k][c]
+				}
+			}
+		}
+		return false
+	}
+
+	s.neighbours = make([][4]patchSide, len(s.Patches))
+	sides := make(map[[4][3]int64][]patchSide)
+	for i := range s.Patches {
+		for side := 0; side < 4; side++ {
+			s.neighbours[i][side] = patchSide{Patch: -1}
+			points := s.Patches[i].edge(side)
+			var forward, backward [4][3]int64
+			for k, p := range points {
+				forward[k] = quantize(p)
+				backward[3-k] = forward[k]
+			}
+			if forward[0] == forward[1] && forward[1] == forward[2] && forward[2] == forward[3] {
+				continue // Pinched to a point
+			}
+			key := forward
+			if less(backward, forward) {
+				key = backward
+			}
+			for _, other := range sides[key] {
+				if s.neighbours[other.Patch][other.Side].Patch < 0 {
+					s.neighbours[other.Patch][other.Side] = patchSide{Patch: i, Side: side}
+				}
+				if s.neighbours[i][side].Patch < 0 {
+					s.neighbours[i][side] = other
+				}
+			}
+			sides[key] = append(sides[key], patchSide{Patch: i, Side: side})
+		}
+	}
+}
+
+// powerOfTwoSegments rounds a segment count up to a power of two no larger
+// than the largest allowed
+func (ts TessellationSettings) powerOfTwoSegments(n int) int {
+	hi := 1
+	for hi*2 <= maxInt(ts.MaxSegments, 1) {
+		hi *= 2
+	}
+	level := 1
+	for level < n && level < hi {
+		level *= 2
+	}
+	return level
+}
+
+// Bounds returns the box around the control points, which holds the surface
+func (s *BezierSurface) Bounds() *AABB {
+	points := make([]Point, 0, len(s.Patches)*16)
+	for i := range s.Patches {
+		points = append(points, s.Patches[i][:]...)
+	}
+	return NewAABBFromPoints(points)
+}
+
+// Update picks the segments of every patch for a camera position in the
+// surface's local space
+func (s *BezierSurface) Update(cameraPos Point, projectionScale float64) {
+	segments := make([][2]int, len(s.Patches))
+	for i := range s.Patches {
+		patch := &s.Patches[i]
+		cu, cv := patch.curvature()
+		distance := distanceToAABB(cameraPos, NewAABBFromPoints(patch[:]))
+		segments[i] = [2]int{
+			s.Settings.powerOfTwoSegments(s.Settings.segmentsFor(cu, distance, projectionScale)),
+			s.Settings.powerOfTwoSegments(s.Settings.segmentsFor(cv, distance, projectionScale)),
+		}
+	}
+	s.setSegments(segments)
+}
+
+// SetSegments tessellates every patch into the same grid
+func (s *BezierSurface) SetSegments(segmentsU, segmentsV int) {
+	segments := make([][2]int, len(s.Patches))
+	for i := range segments {
+		segments[i] = [2]int{s.Settings.powerOfTwoSegments(segmentsU), s.Settings.powerOfTwoSegments(segmentsV)}
+	}
+	s.setSegments(segments)
+}
+
+// setSegments rebuilds the mesh if any patch's segments changed
+func (s *BezierSurface) setSegments(segments [][2]int) {
+	if s.mesh != nil && len(segments) == len(s.segments) {
+		same := true
+		for i := range segments {
+			if segments[i] != s.segments[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return
+		}
+	}
+	s.segments = segments
+	s.mesh = s.tessellate()
+}
+
+// PatchSegments returns the segments along u and v of a patch
+func (s *BezierSurface) PatchSegments(patch int) (int, int) {
+	if patch < 0 || patch >= len(s.segments) {
+		return 0, 0
+	}
+	return s.segments[patch][0], s.segments[patch][1]
+}
+
+// sideSegments returns the segments along one side of a patch
+func (s *BezierSurface) sideSegments(side patchSide) int {
+	return s.segments[side.Patch][side.Side%2]
+}
+
+// tessellate builds the mesh, snapping the vertices of sides finer than
+// their neighbour onto the neighbour's coarser edge
+func (s *BezierSurface) tessellate() *Mesh {
+	mesh := NewMesh()
+	mesh.Material = s.Material
+
+	for i := range s.Patches {
+		patch := &s.Patches[i]
+		nu, nv := s.segments[i][0], s.segments[i][1]
+		first := len(mesh.Vertices)
+
+		for j := 0; j <= nv; j++ {
+			for k := 0; k <= nu; k++ {
+				u, v := float64(k)/float64(nu), float64(j)/float64(nv)
+				p := patch.Evaluate(u, v)
+				mesh.AddVertexWithUV(p.X, p.Y, p.Z, u, v)
+				mesh.Normals = append(mesh.Normals, patch.Normal(u, v))
+			}
+		}
+
+		for side := 0; side < 4; side++ {
+			neighbour := s.neighbours[i][side]
+			if neighbour.Patch < 0 {
+				continue
+			}
+			own := s.sideSegments(patchSide{Patch: i, Side: side})
+			coarse := s.sideSegments(neighbour)
+			if coarse >= own {
+				continue
+			}
+			step := own / coarse
+
+			// Grid index of the k-th vertex along the side
+			at := func(k int) int {
+				switch side {
+				case 0:
+					return first + k
+				case 1:
+					return first + k*(nu+1) + nu
+				case 2:
+					return first + nv*(nu+1) + k
+				}
+				return first + k*(nu+1)
+			}
+			for k := 0; k <= own; k++ {
+				if k%step == 0 {
+					continue
+				}
+				a, b := at(k-k%step), at(k-k%step+step)
+				t := float64(k%step) / float64(step)
+				mesh.Vertices[at(k)] = lerpPoint(mesh.Vertices[a], mesh.Vertices[b], t)
+				mesh.Normals[at(k)] = normalizePoint(lerpPoint(mesh.Normals[a], mesh.Normals[b], t))
+			}
+		}
+
+		addGridTriangles(mesh, first, nu, nv)
+	}
+	return mesh
+}
+
+// VisibleMeshes returns the tessellated surface, tessellating it at the
+// minimum segment count if Update has not been called
+func (s *BezierSurface) VisibleMeshes() []*Mesh {
+	if s.mesh == nil {
+		s.SetSegments(s.Settings.MinSegments, s.Settings.MinSegments)
+	}
+	s.mesh.Material = s.Material
+	return []*Mesh{s.mesh}
+}
+
+// UpdateTessellation re-tessellates every curve and surface in the scene for
+// the camera
+func (s *Scene) UpdateTessellation() {
+	if s.Camera == nil {
+		return
+	}
+	cameraPos := s.Camera.GetPosition()
+	projectionScale := s.Camera.FOV.Y
+	for _, node := range s.GetEnabledNodes() {
+		switch obj := node.Object.(type) {
+		case *BezierSurface:
+			obj.Update(node.Transform.InverseTransformPoint(cameraPos), projectionScale)
+		case *NURBSSurface:
+			obj.Update(node.Transform.InverseTransformPoint(cameraPos), projectionScale)
+		case *NURBSCurve:
+			obj.Update(node.Transform.InverseTransformPoint(cameraPos), projectionScale)
+		}
+	}
+}
@@ -8,6 +8,7 @@ type PBRMaterial struct {
 	Metallic  float64 // 0 = dielectric, 1 = metal
 	Roughness float64 // 0 = smooth, 1 = rough
 	AO        float64 // Ambient occlusion (0-1)
+	Emissive  Color   // Light the surface gives off, unaffected by lighting (black = none)
 
 	// Optional textures
 	AlbedoMap    *Texture
@@ -15,6 +16,7 @@ type PBRMaterial struct {
 	RoughnessMap *Texture
 	NormalMap    *Texture
 	AOMap        *Texture
+	EmissiveMap  *Texture // Multiplied by Emissive
 
 	// Texture settings
 	UseTextures   bool
@@ -33,6 +35,7 @@ func NewPBRMaterial() *PBRMaterial {
 		Metallic:       0.0,
 		Roughness:      0.5,
 		AO:             1.0,
+		Emissive:       ColorBlack,
 		UseTextures:    false,
 		TextureFilter:  FilterLinear,
 		TextureWrap:    WrapRepeat,
@@ -138,6 +141,13 @@ func (pbr *PBRMaterial) SampleAO(u, v float64) float64 {
 	return pbr.AO
 }
 
+func (pbr *PBRMaterial) SampleEmissive(u, v float64) Color {
+	if pbr.UseTextures && pbr.EmissiveMap != nil {
+		return pbr.EmissiveMap.Sample(u, v, pbr.TextureFilter, pbr.TextureWrap).Multiply(pbr.Emissive)
+	}
+	return pbr.Emissive
+}
+
 // PBR Lighting Calculations
 
 // DistributionGGX calculates the normal distribution function (NDF)
@@ -209,9 +219,26 @@ func CalculatePBRLightingWithUV(
 	ambientIntensity float64,
 	u, v float64,
 	shadowCallback func(*Light, Point) float64,
+) Color {
+	return CalculatePBRLightingWithTint(surfacePoint, normal, viewDir, material, lights, ambientLight, ambientIntensity, u, v, ColorWhite, shadowCallback)
+}
+
+// CalculatePBRLightingWithTint computes lighting with the albedo multiplied by
+// a tint (the interpolated vertex color)
+func CalculatePBRLightingWithTint(
+	surfacePoint Point,
+	normal Point,
+	viewDir Point,
+	material *PBRMaterial,
+	lights []*Light,
+	ambientLight Color,
+	ambientIntensity float64,
+	u, v float64,
+	tint Color,
+	shadowCallback func(*Light, Point) float64,
 ) Color {
 	// Sample material properties
-	albedo := material.GetDiffuseColor(u, v)
+	albedo := material.GetDiffuseColor(u, v).Multiply(tint)
 	metallic := material.SampleMetallic(u, v)
 	roughness := material.SampleRoughness(u, v)
 	ao := material.SampleAO(u, v)
@@ -243,6 +270,9 @@ func CalculatePBRLightingWithUV(
 			Z: light.Position.Z - surfacePoint.Z,
 		}
 		distance := math.Sqrt(lightDir.X*lightDir.X + lightDir.Y*lightDir.Y + lightDir.Z*lightDir.Z)
+		if light.Range > 0 && distance >= light.Range {
+			continue
+		}
 		lightDir.X, lightDir.Y, lightDir.Z = normalizeVector(lightDir.X, lightDir.Y, lightDir.Z)
 
 		// Half vector
@@ -254,7 +284,7 @@ func CalculatePBRLightingWithUV(
 		H.X, H.Y, H.Z = normalizeVector(H.X, H.Y, H.Z)
 
 		// Attenuation
-		attenuation := 1.0 / (distance * distance)
+		attenuation := rangeWindow(distance, light.Range) / (distance * distance)
 
 		// Shadow factor
 		shadow := 1.0
@@ -315,10 +345,12 @@ func CalculatePBRLightingWithUV(
 		Z: float64(albedo.B) / 255.0,
 	}
 
+	emissive := material.SampleEmissive(u, v)
+
 	finalColor := Point{
-		X: (ambient.X*albedoNorm.X*ao + Lo.X),
-		Y: (ambient.Y*albedoNorm.Y*ao + Lo.Y),
-		Z: (ambient.Z*albedoNorm.Z*ao + Lo.Z),
+		X: (ambient.X*albedoNorm.X*ao + Lo.X + float64(emissive.R)/255.0),
+		Y: (ambient.Y*albedoNorm.Y*ao + Lo.Y + float64(emissive.G)/255.0),
+		Z: (ambient.Z*albedoNorm.Z*ao + Lo.Z + float64(emissive.B)/255.0),
 	}
 
 	// Tone mapping (simple Reinhard)
@@ -0,0 +1,773 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// PLY LOADER
+// ============================================================================
+// PLY (Stanford polygon format) stores a header describing elements and their
+// properties, followed by the element data as text or as packed binary in
+// either byte order. Meshes are read from the "vertex" element (x, y, z plus
+// optional normals, colors and UVs) and the "face" element (a list of vertex
+// indices, fan-triangulated). Point clouds are read from the vertex element
+// alone and keep every other scalar property (confidence, intensity ...) by
+// name, so scanned data survives a load and save. Other elements are skipped.
+// ============================================================================
+
+// PLYFormat selects how SavePLY and SavePointCloud encode a file
+type PLYFormat int
+
+const (
+	PLYBinary PLYFormat = iota // Little endian binary, compact and fast to load
+	PLYASCII                   // Readable text
+)
+
+// plyTypeSizes gives the size in bytes of each PLY scalar type, under both
+// its original and its sized name
+var plyTypeSizes = map[string]int{
+	"char": 1, "int8": 1, "uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2, "ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4, "uint": 4, "uint32": 4,
+	"float": 4, "float32": 4, "double": 8, "float64": 8,
+}
+
+// plyProperty describes one property of a PLY element
+type plyProperty struct {
+	Name      string
+	Type      string // Scalar type, or the item type of a list
+	CountType string // Type of a list's length; empty for scalars
+}
+
+// IsList reports whether the property is a list
+func (p plyProperty) IsList() bool {
+	return p.CountType != ""
+}
+
+// plyElement describes a block of rows in a PLY file
+type plyElement struct {
+	Name       string
+	Count      int
+	Properties []plyProperty
+}
+
+// plyHeader is the parsed header of a PLY file
+type plyHeader struct {
+	Format   string // ascii, binary_little_endian or binary_big_endian
+	Elements []plyElement
+}
+
+// property returns the index of a named property, or -1
+func (e *plyElement) property(names ...string) int {
+	for _, name := range names {
+		for i, p := range e.Properties {
+			if p.Name == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// LoadPLY loads a PLY file and returns a Mesh. Vertex colors, normals and
+// UVs are kept when present; meshes without normals get generated ones.
+func LoadPLY(filepath string) (*Mesh, error) {
+	mesh := NewMesh()
+	err := readPLYFile(filepath, func(element *plyElement, rows plyRows) error {
+		switch element.Name {
+		case "vertex":
+			return readPLYVertices(rows, element, mesh)
+		case "face":
+			return readPLYFaces(rows, element, mesh)
+		}
+		return skipPLYElement(rows, element)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mesh.Vertices) == 0 {
+		return nil, fmt.Errorf("no vertices found in PLY file")
+	}
+	for _, idx := range mesh.Indices {
+		if idx < 0 || idx >= len(mesh.Vertices) {
+			return nil, fmt.Errorf("face index %d out of range", idx)
+		}
+	}
+
+	if !mesh.HasNormals() {
+		mesh.Normals = nil
+		mesh.GenerateNormals(DefaultCreaseAngle)
+	}
+
+	return mesh, nil
+}
+
+// LoadPointCloud loads the vertices of a PLY file as a PointCloud. Normals
+// and colors are recognized by name; every other scalar vertex property is
+// kept in Properties. Faces and other elements are skipped.
+func LoadPointCloud(filepath string) (*PointCloud, error) {
+	cloud := NewPointCloud()
+	err := readPLYFile(filepath, func(element *plyElement, rows plyRows) error {
+		if element.Name == "vertex" {
+			return readPLYPoints(rows, element, cloud)
+		}
+		return skipPLYElement(rows, element)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cloud.Points) == 0 {
+		return nil, fmt.Errorf("no vertices found in PLY file")
+	}
+	return cloud, nil
+}
+
+// readPLYFile opens a PLY file, reads its header and calls readElement for
+// each element in order, which must consume all of the element's rows
+func readPLYFile(filepath string, readElement func(element *plyElement, rows plyRows) error) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return fmt.Errorf("cannot open file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header, err := readPLYHeader(reader)
+	if err != nil {
+		return err
+	}
+
+	var rows plyRows
+	switch header.Format {
+	case "ascii":
+		rows = newPLYASCIIReader(reader)
+	case "binary_little_endian":
+		rows = &plyBinaryReader{reader: reader, order: binary.LittleEndian}
+	case "binary_big_endian":
+		rows = &plyBinaryReader{reader: reader, order: binary.BigEndian}
+	default:
+		return fmt.Errorf("unsupported PLY format %q", header.Format)
+	}
+
+	for i := range header.Elements {
+		if err := readElement(&header.Elements[i], rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipPLYElement reads past the rows of an element that isn't needed
+func skipPLYElement(rows plyRows, element *plyElement) error {
+	for i := 0; i < element.Count; i++ {
+		if _, err := rows.row(element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPLYHeader parses the header up to and including end_header
+func readPLYHeader(reader *bufio.Reader) (*plyHeader, error) {
+	header := &plyHeader{}
+	lineNum := 0
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("unexpected end of PLY header: %w", err)
+		}
+		lineNum++
+		parts := strings.Fields(line)
+
+		if lineNum == 1 {
+			if len(parts) != 1 || parts[0] != "ply" {
+				return nil, fmt.Errorf("not a PLY file")
+			}
+			continue
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case "format":
+			if len(parts) < 2 {
+				return nil, fmt.Errorf("header line %d: invalid format", lineNum)
+			}
+			header.Format = parts[1]
+
+		case "element":
+			if len(parts) < 3 {
+				return nil, fmt.Errorf("header line %d: invalid element", lineNum)
+			}
+			count, err := strconv.Atoi(parts[2])
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("header line %d: invalid element count", lineNum)
+			}
+			header.Elements = append(header.Elements, plyElement{Name: parts[1], Count: count})
+
+		case "property":
+			if len(header.Elements) == 0 {
+				return nil, fmt.Errorf("header line %d: property outside an element", lineNum)
+			}
+			element := &header.Elements[len(header.Elements)-1]
+			var prop plyProperty
+			switch {
+			case len(parts) == 5 && parts[1] == "list":
+				prop = plyProperty{Name: parts[4], Type: parts[3], CountType: parts[2]}
+			case len(parts) == 3:
+				prop = plyProperty{Name: parts[2], Type: parts[1]}
+			default:
+				return nil, fmt.Errorf("header line %d: invalid property", lineNum)
+			}
+			for _, typ := range []string{prop.Type, prop.CountType} {
+				if _, ok := plyTypeSizes[typ]; typ != "" && !ok {
+					return nil, fmt.Errorf("header line %d: unknown property type %q", lineNum, typ)
+				}
+			}
+			element.Properties = append(element.Properties, prop)
+
+		case "end_header":
+			if header.Format == "" {
+				return nil, fmt.Errorf("PLY header has no format")
+			}
+			return header, nil
+
+		case "comment", "obj_info":
+			continue
+
+		default:
+			return nil, fmt.Errorf("header line %d: unknown keyword %q", lineNum, parts[0])
+		}
+	}
+}
+
+// plyRows reads element rows from the body of a PLY file. Each row has one
+// entry per property; list properties become a slice of values.
+type plyRows interface {
+	row(element *plyElement) ([][]float64, error)
+}
+
+// plyASCIIReader reads element rows from the body of an ASCII PLY file
+type plyASCIIReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newPLYASCIIReader(reader *bufio.Reader) *plyASCIIReader {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &plyASCIIReader{scanner: scanner}
+}
+
+// row reads one element row from a line of text
+func (r *plyASCIIReader) row(element *plyElement) ([][]float64, error) {
+	var fields []string
+	for len(fields) == 0 {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return nil, fmt.Errorf("error reading file: %w", err)
+			}
+			return nil, fmt.Errorf("unexpected end of file in %s element", element.Name)
+		}
+		r.line++
+		fields = strings.Fields(r.scanner.Text())
+	}
+
+	values := make([][]float64, len(element.Properties))
+	next := 0
+	read := func(typ string) (float64, error) {
+		if next >= len(fields) {
+			return 0, fmt.Errorf("data line %d: too few values for %s", r.line, element.Name)
+		}
+		// Float values are rounded as a binary file would store them
+		bitSize := 64
+		if typ == "float" || typ == "float32" {
+			bitSize = 32
+		}
+		v, err := strconv.ParseFloat(fields[next], bitSize)
+		if err != nil {
+			return 0, fmt.Errorf("data line %d: invalid value %q", r.line, fields[next])
+		}
+		next++
+		return v, nil
+	}
+
+	for i, prop := range element.Properties {
+		if !prop.IsList() {
+			v, err := read(prop.Type)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = []float64{v}
+			continue
+		}
+
+		count, err := read(prop.CountType)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("data line %d: negative list length", r.line)
+		}
+		list := make([]float64, int(count))
+		for j := range list {
+			if list[j], err = read(prop.Type); err != nil {
+				return nil, err
+			}
+		}
+		values[i] = list
+	}
+
+	return values, nil
+}
+
+// plyBinaryReader reads element rows from the body of a binary PLY file
+type plyBinaryReader struct {
+	reader *bufio.Reader
+	order  binary.ByteOrder
+	buf    [8]byte
+}
+
+// row reads one element row of packed values
+func (r *plyBinaryReader) row(element *plyElement) ([][]float64, error) {
+	values := make([][]float64, len(element.Properties))
+	for i, prop := range element.Properties {
+		if !prop.IsList() {
+			v, err := r.value(prop.Type, element)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = []float64{v}
+			continue
+		}
+
+		count, err := r.value(prop.CountType, element)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("negative list length in %s element", element.Name)
+		}
+		list := make([]float64, int(count))
+		for j := range list {
+			if list[j], err = r.value(prop.Type, element); err != nil {
+				return nil, err
+			}
+		}
+		values[i] = list
+	}
+	return values, nil
+}
+
+// value reads one scalar of the given type
+func (r *plyBinaryReader) value(typ string, element *plyElement) (float64, error) {
+	b := r.buf[:plyTypeSizes[typ]]
+	if _, err := io.ReadFull(r.reader, b); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, fmt.Errorf("unexpected end of file in %s element", element.Name)
+		}
+		return 0, fmt.Errorf("error reading file: %w", err)
+	}
+
+	switch typ {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(r.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(r.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(r.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(r.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(r.order.Uint32(b))), nil
+	default:
+		return math.Float64frombits(r.order.Uint64(b)), nil
+	}
+}
+
+// readPLYVertices reads the vertex element into the mesh
+func readPLYVertices(rows plyRows, element *plyElement, mesh *Mesh) error {
+	x, y, z := element.property("x"), element.property("y"), element.property("z")
+	if x < 0 || y < 0 || z < 0 {
+		return fmt.Errorf("PLY vertices need x, y and z properties")
+	}
+	nx, ny, nz := element.property("nx"), element.property("ny"), element.property("nz")
+	red := element.property("red", "r", "diffuse_red")
+	green := element.property("green", "g", "diffuse_green")
+	blue := element.property("blue", "b", "diffuse_blue")
+	u := element.property("u", "s", "texture_u")
+	v := element.property("v", "t", "texture_v")
+
+	hasNormals := nx >= 0 && ny >= 0 && nz >= 0
+	hasColors := red >= 0 && green >= 0 && blue >= 0
+	hasUVs := u >= 0 && v >= 0
+
+	for i := 0; i < element.Count; i++ {
+		values, err := rows.row(element)
+		if err != nil {
+			return err
+		}
+		scalar := func(idx int) float64 {
+			if len(values[idx]) == 0 {
+				return 0
+			}
+			return values[idx][0]
+		}
+
+		mesh.AddVertex(scalar(x), scalar(y), scalar(z))
+		if hasNormals {
+			mesh.Normals = append(mesh.Normals, Point{X: scalar(nx), Y: scalar(ny), Z: scalar(nz)})
+		}
+		if hasColors {
+			mesh.Colors = append(mesh.Colors, Color{
+				R: plyColorChannel(scalar(red), element.Properties[red].Type),
+				G: plyColorChannel(scalar(green), element.Properties[green].Type),
+				B: plyColorChannel(scalar(blue), element.Properties[blue].Type),
+			})
+		}
+		if hasUVs {
+			mesh.AddUV(scalar(u), scalar(v))
+		}
+	}
+
+	return nil
+}
+
+// readPLYFaces reads the face element into the mesh, fan-triangulating polygons
+func readPLYFaces(rows plyRows, element *plyElement, mesh *Mesh) error {
+	indices := element.property("vertex_indices", "vertex_index")
+	if indices < 0 || !element.Properties[indices].IsList() {
+		return fmt.Errorf("PLY faces need a vertex_indices list")
+	}
+
+	for i := 0; i < element.Count; i++ {
+		values, err := rows.row(element)
+		if err != nil {
+			return err
+		}
+		face := values[indices]
+		if len(face) < 3 {
+			return fmt.Errorf("face %d must have at least 3 vertices", i)
+		}
+		for k := 1; k < len(face)-1; k++ {
+			mesh.AddTriangleIndices(int(face[0]), int(face[k]), int(face[k+1]))
+		}
+		mesh.FaceSizes = append(mesh.FaceSizes, len(face))
+	}
+
+	return nil
+}
+
+// readPLYPoints reads the vertex element into the point cloud
+func readPLYPoints(rows plyRows, element *plyElement, cloud *PointCloud) error {
+	x, y, z := element.property("x"), element.property("y"), element.property("z")
+	if x < 0 || y < 0 || z < 0 {
+		return fmt.Errorf("PLY vertices need x, y and z properties")
+	}
+	nx, ny, nz := element.property("nx"), element.property("ny"), element.property("nz")
+	red := element.property("red", "r", "diffuse_red")
+	green := element.property("green", "g", "diffuse_green")
+	blue := element.property("blue", "b", "diffuse_blue")
+
+	hasNormals := nx >= 0 && ny >= 0 && nz >= 0
+	hasColors := red >= 0 && green >= 0 && blue >= 0
+
+	// Whatever isn't a position, normal or color is kept as it is
+	used := map[int]bool{x: true, y: true, z: true}
+	if hasNormals {
+		used[nx], used[ny], used[nz] = true, true, true
+	}
+	if hasColors {
+		used[red], used[green], used[blue] = true, true, true
+	}
+	var extra []int
+	for i, prop := range element.Properties {
+		if !used[i] && !prop.IsList() {
+			extra = append(extra, i)
+			cloud.Properties = append(cloud.Properties, PointProperty{Name: prop.Name, Type: prop.Type})
+		}
+	}
+	first := len(cloud.Properties) - len(extra)
+
+	for i := 0; i < element.Count; i++ {
+		values, err := rows.row(element)
+		if err != nil {
+			return err
+		}
+
+		scalar := func(idx int) float64 {
+			if len(values[idx]) == 0 {
+				return 0
+			}
+			return values[idx][0]
+		}
+
+		cloud.Points = append(cloud.Points, Point{X: scalar(x), Y: scalar(y), Z: scalar(z)})
+		if hasNormals {
+			cloud.Normals = append(cloud.Normals, Point{X: scalar(nx), Y: scalar(ny), Z: scalar(nz)})
+		}
+		if hasColors {
+			cloud.Colors = append(cloud.Colors, Color{
+				R: plyColorChannel(scalar(red), element.Properties[red].Type),
+				G: plyColorChannel(scalar(green), element.Properties[green].Type),
+				B: plyColorChannel(scalar(blue), element.Properties[blue].Type),
+			})
+		}
+		for k, idx := range extra {
+			prop := &cloud.Properties[first+k]
+			prop.Values = append(prop.Values, scalar(idx))
+		}
+	}
+
+	cloud.MarkDirty()
+	return nil
+}
+
+// plyColorChannel converts a color value to 8 bits: integer types are
+// already 0-255, float types are 0-1
+func plyColorChannel(value float64, typ string) uint8 {
+	if isPLYFloatType(typ) {
+		value *= 255
+	}
+	return uint8(clampFloat(value+0.5, 0, 255))
+}
+
+// isPLYFloatType reports whether a PLY scalar type is floating point
+func isPLYFloatType(typ string) bool {
+	switch typ {
+	case "float", "float32", "double", "float64":
+		return true
+	}
+	return false
+}
+
+// ============================================================================
+// PLY WRITER
+// ============================================================================
+
+// SavePLY saves a mesh to a PLY file: positions, normals, colors and UVs per
+// vertex when the mesh has them, and its polygons as faces
+func SavePLY(mesh *Mesh, filepath string, format PLYFormat) error {
+	vertex := plyElement{Name: "vertex", Count: len(mesh.Vertices)}
+	vertex.Properties = append(vertex.Properties, plyFloatProperties("x", "y", "z")...)
+	hasNormals := mesh.HasNormals()
+	if hasNormals {
+		vertex.Properties = append(vertex.Properties, plyFloatProperties("nx", "ny", "nz")...)
+	}
+	hasColors := mesh.HasColors()
+	if hasColors {
+		vertex.Properties = append(vertex.Properties, plyColorProperties()...)
+	}
+	hasUVs := mesh.HasUVs()
+	if hasUVs {
+		vertex.Properties = append(vertex.Properties, plyFloatProperties("u", "v")...)
+	}
+
+	polygons := mesh.Polygons()
+	countType := "uchar"
+	for _, loop := range polygons {
+		if len(loop) > math.MaxUint8 {
+			countType = "int"
+		}
+	}
+	face := plyElement{Name: "face", Count: len(polygons), Properties: []plyProperty{
+		{Name: "vertex_indices", Type: "int", CountType: countType},
+	}}
+
+	return writePLYFile(filepath, format, []plyElement{vertex, face}, func(w *plyWriter) {
+		for i, v := range mesh.Vertices {
+			w.point(v)
+			if hasNormals {
+				w.point(mesh.Normals[i])
+			}
+			if hasColors {
+				w.color(mesh.Colors[i])
+			}
+			if hasUVs {
+				w.value(mesh.UVs[i].U, "float")
+				w.value(mesh.UVs[i].V, "float")
+			}
+			w.endRow()
+		}
+		for _, loop := range polygons {
+			w.value(float64(len(loop)), countType)
+			for _, idx := range loop {
+				w.value(float64(idx), "int")
+			}
+			w.endRow()
+		}
+	})
+}
+
+// SavePointCloud saves a point cloud to a PLY file: positions, normals and
+// colors when the cloud has them, then its other properties in order
+func SavePointCloud(cloud *PointCloud, filepath string, format PLYFormat) error {
+	for _, prop := range cloud.Properties {
+		if len(prop.Values) != len(cloud.Points) {
+			return fmt.Errorf("point property %s has %d values for %d points", prop.Name, len(prop.Values), len(cloud.Points))
+		}
+		if _, ok := plyTypeSizes[prop.Type]; !ok {
+			return fmt.Errorf("point property %s has unknown type %q", prop.Name, prop.Type)
+		}
+	}
+
+	vertex := plyElement{Name: "vertex", Count: len(cloud.Points)}
+	vertex.Properties = append(vertex.Properties, plyFloatProperties("x", "y", "z")...)
+	hasNormals := cloud.HasNormals()
+	if hasNormals {
+		vertex.Properties = append(vertex.Properties, plyFloatProperties("nx", "ny", "nz")...)
+	}
+	hasColors := cloud.HasColors()
+	if hasColors {
+		vertex.Properties = append(vertex.Properties, plyColorProperties()...)
+	}
+	for _, prop := range cloud.Properties {
+		vertex.Properties = append(vertex.Properties, plyProperty{Name: prop.Name, Type: prop.Type})
+	}
+
+	return writePLYFile(filepath, format, []plyElement{vertex}, func(w *plyWriter) {
+		for i, p := range cloud.Points {
+			w.point(p)
+			if hasNormals {
+				w.point(cloud.Normals[i])
+			}
+			if hasColors {
+				w.color(cloud.Colors[i])
+			}
+			for _, prop := range cloud.Properties {
+				w.value(prop.Values[i], prop.Type)
+			}
+			w.endRow()
+		}
+	})
+}
+
+// plyFloatProperties returns float properties with the given names
+func plyFloatProperties(names ...string) []plyProperty {
+	props := make([]plyProperty, len(names))
+	for i, name := range names {
+		props[i] = plyProperty{Name: name, Type: "float"}
+	}
+	return props
+}
+
+// plyColorProperties returns the red, green and blue uchar properties
+func plyColorProperties() []plyProperty {
+	return []plyProperty{{Name: "red", Type: "uchar"}, {Name: "green", Type: "uchar"}, {Name: "blue", Type: "uchar"}}
+}
+
+// writePLYFile writes the header for elements, then calls writeRows to
+// write their rows in order
+func writePLYFile(filepath string, format PLYFormat, elements []plyElement, writeRows func(w *plyWriter)) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	writer.WriteString("ply\n")
+	if format == PLYASCII {
+		writer.WriteString("format ascii 1.0\n")
+	} else {
+		writer.WriteString("format binary_little_endian 1.0\n")
+	}
+	writer.WriteString("comment Generated by Go 3D Graphics Engine\n")
+	for _, element := range elements {
+		writer.WriteString(fmt.Sprintf("element %s %d\n", element.Name, element.Count))
+		for _, prop := range element.Properties {
+			if prop.IsList() {
+				writer.WriteString(fmt.Sprintf("property list %s %s %s\n", prop.CountType, prop.Type, prop.Name))
+			} else {
+				writer.WriteString(fmt.Sprintf("property %s %s\n", prop.Type, prop.Name))
+			}
+		}
+	}
+	writer.WriteString("end_header\n")
+
+	writeRows(&plyWriter{writer: writer, ascii: format == PLYASCII})
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// plyWriter writes element rows as text lines or as packed little endian
+// values
+type plyWriter struct {
+	writer *bufio.Writer
+	ascii  bool
+	fields []string // Values of the current text row
+	buf    [8]byte
+}
+
+// value writes one scalar of the given type
+func (w *plyWriter) value(v float64, typ string) {
+	if w.ascii {
+		switch {
+		case typ == "float" || typ == "float32":
+			w.fields = append(w.fields, strconv.FormatFloat(v, 'g', -1, 32))
+		case isPLYFloatType(typ):
+			w.fields = append(w.fields, strconv.FormatFloat(v, 'g', -1, 64))
+		default:
+			w.fields = append(w.fields, strconv.FormatInt(int64(math.Round(v)), 10))
+		}
+		return
+	}
+
+	b := w.buf[:plyTypeSizes[typ]]
+	switch typ {
+	case "char", "int8", "uchar", "uint8":
+		b[0] = byte(int64(math.Round(v)))
+	case "short", "int16", "ushort", "uint16":
+		binary.LittleEndian.PutUint16(b, uint16(int64(math.Round(v))))
+	case "int", "int32", "uint", "uint32":
+		binary.LittleEndian.PutUint32(b, uint32(int64(math.Round(v))))
+	case "float", "float32":
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+	default:
+		binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+	}
+	w.writer.Write(b)
+}
+
+// point writes a point or normal as three floats
+func (w *plyWriter) point(p Point) {
+	w.value(p.X, "float")
+	w.value(p.Y, "float")
+	w.value(p.Z, "float")
+}
+
+// color writes a color as three uchars
+func (w *plyWriter) color(c Color) {
+	w.value(float64(c.R), "uchar")
+	w.value(float64(c.G), "uchar")
+	w.value(float64(c.B), "uchar")
+}
+
+// endRow finishes a row; text rows are written as one line
+func (w *plyWriter) endRow() {
+	if w.ascii {
+		w.writer.WriteString(strings.Join(w.fields, " "))
+		w.writer.WriteString("\n")
+		w.fields = w.fields[:0]
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import "math"
+
+// ============================================================================
+// POINT CLOUDS
+// ============================================================================
+// A PointCloud is one scene object holding many points, such as a scan
+// loaded with LoadPointCloud. Renderers draw the whole cloud in one call
+// instead of one *Point node per point, which can't scale past a few
+// thousand points. Points are drawn either as dots of a fixed size on
+// screen, or as round splats of a fixed size in the world, which shrink
+// with distance and close the gaps between neighbouring scan samples.
+// Points are unlit: scanned colors already include the lighting they were
+// captured under.
+// ============================================================================
+
+// PointCloudMode selects how a PointCloud's points are drawn
+type PointCloudMode int
+
+const (
+	PointCloudPoints PointCloudMode = iota // PointSize pixels (terminal cells) across at any distance
+	PointCloudSplats                       // Discs PointSize local units across, facing the camera
+)
+
+// PointProperty is a named value per point, kept from or written to a PLY
+// file (confidence, intensity, u, v ...)
+type PointProperty struct {
+	Name   string
+	Type   string // PLY scalar type used when saving ("float", "uchar" ...)
+	Values []float64
+}
+
+// PointCloud is a set of points drawn as one object
+type PointCloud struct {
+	Points  []Point
+	Normals []Point // Normals per point (empty: none)
+	Colors  []Color // Colors per point (empty: Color everywhere)
+	Color   Color
+
+	// Other values per point, in file order
+	Properties []PointProperty
+
+	Mode      PointCloudMode
+	PointSize float64 // Pixels for PointCloudPoints, local units for PointCloudSplats
+
+	version uint64 // Bumped by MarkDirty so GPU copies are refreshed
+	bounds  *AABB  // Cached by Bounds
+}
+
+// NewPointCloud creates an empty white point cloud drawn as single pixels
+func NewPointCloud() *PointCloud {
+	return &PointCloud{
+		Color:     ColorWhite,
+		Mode:      PointCloudPoints,
+		PointSize: 1,
+	}
+}
+
+// HasNormals reports whether every point has a normal
+func (pc *PointCloud) HasNormals() bool {
+	return len(pc.Points) > 0 && len(pc.Normals) == len(pc.Points)
+}
+
+// HasColors reports whether every point has a color
+func (pc *PointCloud) HasColors() bool {
+	return len(pc.Points) > 0 && len(pc.Colors) == len(pc.Points)
+}
+
+// ColorAt returns the color of point i
+func (pc *PointCloud) ColorAt(i int) Color {
+	if pc.HasColors() {
+		return pc.Colors[i]
+	}
+	return pc.Color
+}
+
+// Property returns the named property, or nil
+func (pc *PointCloud) Property(name string) *PointProperty {
+	for i := range pc.Properties {
+		if pc.Properties[i].Name == name {
+			return &pc.Properties[i]
+		}
+	}
+	return nil
+}
+
+// Bounds returns the box around all points, and around their discs when
+// drawn as splats
+func (pc *PointCloud) Bounds() *AABB {
+	if pc.bounds == nil {
+		pc.bounds = NewAABBFromPoints(pc.Points)
+	}
+	if pc.Mode == PointCloudSplats && pc.PointSize > 0 {
+		return pc.bounds.Expand(pc.PointSize / 2)
+	}
+	return pc.bounds
+}
+
+// Draw hands the cloud to the renderer's point cloud path
+func (pc *PointCloud) Draw(r Renderer, worldMatrix Matrix4x4, camera *Camera) {
+	r.RenderPointCloud(pc, worldMatrix, camera)
+}
+
+// MarkDirty must be called after changing points, normals or colors in
+// place, so cached bounds and GPU buffers are rebuilt
+func (pc *PointCloud) MarkDirty() {
+	pc.bounds = nil
+	pc.version++
+}
+
+// Version changes every time the cloud is marked dirty
+func (pc *PointCloud) Version() uint64 {
+	return pc.version
+}
+
+// splatScale returns how much a world matrix enlarges splats: the longest
+// of its axes, so non-uniformly scaled clouds don't open gaps
+func splatScale(worldMatrix Matrix4x4) float64 {
+	scale := 0.0
+	for col := 0; col < 3; col++ {
+		axis := Point{X: worldMatrix.M[col], Y: worldMatrix.M[4+col], Z: worldMatrix.M[8+col]}
+		scale = math.Max(scale, pointLength(axis))
+	}
+	return scale
+}
@@ -149,6 +149,21 @@ func (s *Scene) Raycast(ray Ray, maxDistance float64) RayHit {
 	return closestHit
 }
 
+// RaycastBVH is Raycast accelerated by a BVH built from the scene
+// (Scene.BuildBVH): only nodes whose bounds the ray crosses are tested
+func (s *Scene) RaycastBVH(bvh *BVH, ray Ray, maxDistance float64) RayHit {
+	if bvh == nil {
+		return s.Raycast(ray, maxDistance)
+	}
+
+	closestHit := RayHit{Hit: false, Distance: maxDistance}
+	for _, node := range bvh.RayQuery(ray, maxDistance) {
+		s.raycastObject(node, ray, node.Transform.GetWorldMatrix(), &closestHit)
+	}
+
+	return closestHit
+}
+
 // raycastNode recursively tests a node and its children
 func (s *Scene) raycastNode(node *SceneNode, ray Ray, closestHit *RayHit) {
 	if !node.IsEnabled() {
@@ -299,6 +314,65 @@ func (s *Scene) raycastObject(node *SceneNode, ray Ray, worldMatrix Matrix4x4, c
 				}
 			}
 		}
+
+	case *Terrain:
+		// Trace in local space; an unnormalized direction keeps distances
+		// in world units
+		inverse := worldMatrix.Invert()
+		localRay := Ray{
+			Origin:    inverse.TransformPoint(ray.Origin),
+			Direction: inverse.TransformDirection(ray.Direction),
+		}
+		if hit, distance, normal := obj.Raycast(localRay, closestHit.Distance); hit {
+			normalMatrix := worldMatrix.NormalMatrix()
+			closestHit.Hit = true
+			closestHit.Distance = distance
+			closestHit.Point = ray.GetPoint(distance)
+			closestHit.Normal = normalMatrix.TransformNormal(normal)
+			closestHit.Node = node
+			closestHit.Triangle = nil
+		}
+
+	case *VoxelWorld:
+		inverse := worldMatrix.Invert()
+		localRay := Ray{
+			Origin:    inverse.TransformPoint(ray.Origin),
+			Direction: inverse.TransformDirection(ray.Direction),
+		}
+		if hit := obj.Raycast(localRay, closestHit.Distance); hit.Hit {
+			normalMatrix := worldMatrix.NormalMatrix()
+			closestHit.Hit = true
+			closestHit.Distance = hit.Distance
+			closestHit.Point = ray.GetPoint(hit.Distance)
+			closestHit.Normal = normalMatrix.TransformNormal(hit.Normal)
+			closestHit.Node = node
+			closestHit.Triangle = nil
+		}
+
+	case MeshProvider:
+		for _, mesh := range obj.VisibleMeshes() {
+			s.testMeshTriangles(mesh, worldMatrix, ray, node, closestHit)
+		}
+	}
+}
+
+// testMeshTriangles tests a ray against a mesh drawn by a MeshProvider
+func (s *Scene) testMeshTriangles(mesh *Mesh, worldMatrix Matrix4x4, ray Ray, node *SceneNode, closestHit *RayHit) {
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		p0 := worldMatrix.TransformPoint(addPoints(mesh.Vertices[mesh.Indices[i]], mesh.Position))
+		p1 := worldMatrix.TransformPoint(addPoints(mesh.Vertices[mesh.Indices[i+1]], mesh.Position))
+		p2 := worldMatrix.TransformPoint(addPoints(mesh.Vertices[mesh.Indices[i+2]], mesh.Position))
+
+		tri := &Triangle{P0: p0, P1: p1, P2: p2}
+		hit, distance, _, _ := ray.IntersectsTriangle(tri)
+		if hit && distance > 0 && distance < closestHit.Distance {
+			closestHit.Hit = true
+			closestHit.Distance = distance
+			closestHit.Point = ray.GetPoint(distance)
+			closestHit.Normal = CalculateSurfaceNormal(&p0, &p1, &p2, nil, false)
+			closestHit.Node = node
+			closestHit.Triangle = nil
+		}
 	}
 }
 
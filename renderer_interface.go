@@ -16,6 +16,7 @@ type Renderer interface {
 	RenderLine(line *Line, worldMatrix Matrix4x4, camera *Camera)
 	RenderPoint(point *Point, worldMatrix Matrix4x4, camera *Camera)
 	RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera *Camera)
+	RenderPointCloud(cloud *PointCloud, worldMatrix Matrix4x4, camera *Camera)
 
 	// Scene rendering
 	RenderScene(scene *Scene)
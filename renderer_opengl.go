@@ -49,6 +49,22 @@ type OpenGLRenderer struct {
 	pbrUniformLightSpaceMatrix int32
 	pbrUniformShadowMap   int32
 	pbrUniformUseShadows  int32
+	pbrUniformPointShadowMap    int32
+	pbrUniformUsePointShadow    int32
+	pbrUniformPointShadowFar    int32
+	pbrUniformPointShadowRes    int32
+
+	// Per-light shadow settings
+	pbrUniformShadowFilter       int32
+	pbrUniformShadowBias         int32
+	pbrUniformShadowSlopeBias    int32
+	pbrUniformShadowNormalOffset int32
+	pbrUniformShadowPCFRadius    int32
+	pbrUniformShadowLightSize    int32
+	pbrUniformShadowBlockerSearch int32
+	pbrUniformShadowMaxPenumbra  int32
+	pbrUniformShadowTexelWorld   int32
+	pbrUniformShadowDepthRange   int32
 
 	pbrUniformAlbedoMap       int32
 	pbrUniformUseAlbedoMap    int32
@@ -60,6 +76,9 @@ type OpenGLRenderer struct {
 	pbrUniformUseRoughnessMap int32
 	pbrUniformAOMap           int32
 	pbrUniformUseAOMap        int32
+	pbrUniformEmissive        int32
+	pbrUniformEmissiveMap     int32
+	pbrUniformUseEmissiveMap  int32
 
 	// Texture support
 	textureProgram        uint32
@@ -83,8 +102,31 @@ type OpenGLRenderer struct {
 	shadowUniformModel    int32
 	shadowUniformLightSpaceMatrix int32
 	enableShadows         bool
+	shadowPassActive      bool      // The shaded light cast shadows this frame
 	shadowLightMatrix     Matrix4x4 // Light space transformation matrix
 
+	// Point light (cube map) shadows
+	pointShadowProgram            uint32 // Writes linear light distance into each cube face
+	pointShadowFBO                uint32
+	pointShadowCubeTexture        uint32
+	pointShadowResolution         int
+	pointShadowUniformFaceMatrix  int32
+	pointShadowUniformLightPos    int32
+	pointShadowUniformFar         int32
+	pointShadowMap                *CubeShadowMap // Face views shared with the CPU path
+	pointShadowActive             bool           // True when the shadowing light is a point light
+
+	// Point clouds, drawn as point sprites in Present from buffers kept per cloud
+	pointCloudProgram       uint32
+	pointCloudUniformModel  int32
+	pointCloudUniformView   int32
+	pointCloudUniformProj   int32
+	pointCloudUniformSize   int32
+	pointCloudUniformSplats int32
+	pointCloudUniformHeight int32
+	pointCloudBuffers       map[*PointCloud]*pointCloudBuffer
+	pointCloudDraws         []pointCloudDraw
+
 	// Vertex data
 	maxVertices     int
 	currentVertices []VulkanVertex // Interleaved: pos(3) + color(3)
@@ -109,10 +151,11 @@ type OpenGLRenderer struct {
 
 // PBRVertex represents a vertex with position, normal, UV, and color for PBR rendering
 type PBRVertex struct {
-	Pos    [3]float32
-	Normal [3]float32
-	UV     [2]float32
-	Color  [3]float32
+	Pos     [3]float32
+	Normal  [3]float32
+	UV      [2]float32
+	Color   [3]float32
+	Tangent [4]float32 // xyz tangent, w handedness; zero when the mesh has none
 }
 
 // TexturedVertex represents a vertex with position, UV, and color
@@ -175,6 +218,49 @@ out vec4 color;
 void main() {
     color = vec4(FragColor, 1.0);
 }
+` + "\x00"
+
+	pointCloudVertexShaderSource = `
+#version 410 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aColor;
+
+out vec3 PointColor;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 proj;
+uniform float pointSize; // Pixels, or world units for splats
+uniform bool splats;
+uniform float viewportHeight;
+
+void main() {
+    vec4 viewPos = view * model * vec4(aPos, 1.0);
+    gl_Position = proj * viewPos;
+    if (splats) {
+        // Pixels covered by a disc pointSize across at this depth
+        gl_PointSize = max(pointSize * proj[1][1] * viewportHeight * 0.5 / max(abs(viewPos.z), 1e-4), 1.0);
+    } else {
+        gl_PointSize = pointSize;
+    }
+    PointColor = aColor;
+}
+` + "\x00"
+
+	pointCloudFragmentShaderSource = `
+#version 410 core
+in vec3 PointColor;
+out vec4 color;
+
+uniform bool splats;
+
+void main() {
+    // Splats are round; dots stay square
+    if (splats && length(gl_PointCoord - vec2(0.5)) > 0.5) {
+        discard;
+    }
+    color = vec4(PointColor, 1.0);
+}
 ` + "\x00"
 
 	pbrVertexShaderSource = `
@@ -183,17 +269,17 @@ layout (location = 0) in vec3 aPos;
 layout (location = 1) in vec3 aNormal;
 layout (location = 2) in vec3 aColor;
 layout (location = 3) in vec2 aUV;
+layout (location = 4) in vec4 aTangent;
 
 out vec3 FragPos;
 out vec3 Normal;
 out vec3 BaseColor;
 out vec2 TexCoord;
-out vec4 FragPosLightSpace;
+out vec4 Tangent;
 
 uniform mat4 model;
 uniform mat4 view;
 uniform mat4 proj;
-uniform mat4 lightSpaceMatrix;
 
 void main() {
     vec4 worldPos = model * vec4(aPos, 1.0);
@@ -201,7 +287,7 @@ void main() {
     Normal = mat3(transpose(inverse(model))) * aNormal;
     BaseColor = aColor;
     TexCoord = aUV;
-    FragPosLightSpace = lightSpaceMatrix * worldPos;
+    Tangent = vec4(mat3(model) * aTangent.xyz, aTangent.w);
     gl_Position = proj * view * worldPos;
 }
 ` + "\x00"
@@ -212,7 +298,7 @@ in vec3 FragPos;
 in vec3 Normal;
 in vec3 BaseColor;
 in vec2 TexCoord;
-in vec4 FragPosLightSpace;
+in vec4 Tangent;
 
 out vec4 FragColor;
 
@@ -224,15 +310,37 @@ uniform float roughness;
 uniform vec3 albedo;
 uniform sampler2D shadowMap;
 uniform bool useShadows;
+uniform samplerCube pointShadowMap;
+uniform bool usePointShadow;
+uniform float pointShadowFar;
+uniform float pointShadowResolution;
+
+// Per-light shadow settings (see ShadowSettings)
+uniform mat4 lightSpaceMatrix;
+uniform int shadowFilter;
+uniform float shadowBias;
+uniform float shadowSlopeBias;
+uniform float shadowNormalOffset;
+uniform int shadowPCFRadius;
+uniform float shadowLightSize;
+uniform int shadowBlockerSearch;
+uniform int shadowMaxPenumbra;
+uniform float shadowTexelWorld;
+uniform float shadowDepthRange;
 
 uniform sampler2D albedoMap;
 uniform bool useAlbedoMap;
+uniform sampler2D normalMap;
+uniform bool useNormalMap;
 uniform sampler2D metallicMap;
 uniform bool useMetallicMap;
 uniform sampler2D roughnessMap;
 uniform bool useRoughnessMap;
 uniform sampler2D aoMap;
 uniform bool useAOMap;
+uniform vec3 emissive;
+uniform sampler2D emissiveMap;
+uniform bool useEmissiveMap;
 
 const float PI = 3.14159265359;
 
@@ -273,11 +381,43 @@ float geometrySmith(vec3 N, vec3 V, vec3 L, float roughness) {
     return ggx1 * ggx2;
 }
 
-float shadowCalculation(vec4 fragPosLightSpace) {
+// Filter values mirror ShadowFilter in shadow_filtering.go
+const int FILTER_PCF = 0;
+const int FILTER_HARD = 1;
+const int FILTER_PCSS = 2;
+
+// receiverBias returns the slope-scaled depth bias (world units) and writes the
+// normal-offset receiver position, both growing at grazing angles
+float receiverBias(vec3 fragPos, vec3 N, vec3 L, float texelWorld, out vec3 offsetPos) {
+    float cosTheta = clamp(dot(N, L), 0.0, 1.0);
+    float sinTheta = sqrt(1.0 - cosTheta * cosTheta);
+    float tanTheta = min(sinTheta / max(cosTheta, 0.001), 10.0);
+    offsetPos = fragPos + N * (shadowNormalOffset * texelWorld * sinTheta);
+    return shadowBias + shadowSlopeBias * texelWorld * tanTheta;
+}
+
+float shadowPCF(vec2 uv, float currentDepth, float bias, int radius) {
+    float shadow = 0.0;
+    vec2 texelSize = 1.0 / textureSize(shadowMap, 0);
+    for (int x = -radius; x <= radius; ++x) {
+        for (int y = -radius; y <= radius; ++y) {
+            float pcfDepth = texture(shadowMap, uv + vec2(x, y) * texelSize).r * shadowDepthRange;
+            shadow += currentDepth - bias > pcfDepth ? 0.0 : 1.0;
+        }
+    }
+    float taps = float(2 * radius + 1);
+    return shadow / (taps * taps);
+}
+
+float shadowCalculation(vec3 fragPos, vec3 N, vec3 L) {
     if (!useShadows) {
         return 1.0;
     }
-    
+
+    vec3 receiver;
+    float bias = receiverBias(fragPos, N, L, shadowTexelWorld, receiver);
+    vec4 fragPosLightSpace = lightSpaceMatrix * vec4(receiver, 1.0);
+
     // Perspective divide
     vec3 projCoords = fragPosLightSpace.xyz / fragPosLightSpace.w;
     
@@ -290,36 +430,115 @@ float shadowCalculation(vec4 fragPosLightSpace) {
         return 1.0;
     }
     
-    // Get closest depth from shadow map
-    float closestDepth = texture(shadowMap, projCoords.xy).r;
-    float currentDepth = projCoords.z;
-    
-    // Shadow bias to prevent shadow acne
-    float bias = 0.005;
-    
-    // PCF (Percentage Closer Filtering)
+    // Compare in world units so the bias matches the CPU path
+    float currentDepth = projCoords.z * shadowDepthRange;
+
+    if (shadowFilter == FILTER_HARD) {
+        return shadowPCF(projCoords.xy, currentDepth, bias, 0);
+    }
+
+    if (shadowFilter == FILTER_PCSS) {
+        // Blocker search: average depth of texels in front of the receiver
+        vec2 texelSize = 1.0 / textureSize(shadowMap, 0);
+        float blockerSum = 0.0;
+        int blockers = 0;
+        for (int x = -shadowBlockerSearch; x <= shadowBlockerSearch; ++x) {
+            for (int y = -shadowBlockerSearch; y <= shadowBlockerSearch; ++y) {
+                float d = texture(shadowMap, projCoords.xy + vec2(x, y) * texelSize).r * shadowDepthRange;
+                if (d + bias < currentDepth) {
+                    blockerSum += d;
+                    blockers++;
+                }
+            }
+        }
+        if (blockers == 0) {
+            return 1.0;
+        }
+        float blockerDepth = max(blockerSum / float(blockers), 0.1);
+        float penumbra = shadowLightSize * (currentDepth - blockerDepth) / blockerDepth;
+        int radius = clamp(int(ceil(penumbra / shadowTexelWorld)), 1, max(shadowMaxPenumbra, 1));
+        return shadowPCF(projCoords.xy, currentDepth, bias, radius);
+    }
+
+    // PCF; VSM/ESM moments are only built by the CPU shadow path, so they use PCF here
+    return shadowPCF(projCoords.xy, currentDepth, bias, shadowPCFRadius);
+}
+
+const vec3 pointShadowOffsets[20] = vec3[](
+    vec3(1, 1, 1), vec3(1, -1, 1), vec3(-1, -1, 1), vec3(-1, 1, 1),
+    vec3(1, 1, -1), vec3(1, -1, -1), vec3(-1, -1, -1), vec3(-1, 1, -1),
+    vec3(1, 1, 0), vec3(1, -1, 0), vec3(-1, -1, 0), vec3(-1, 1, 0),
+    vec3(1, 0, 1), vec3(-1, 0, 1), vec3(1, 0, -1), vec3(-1, 0, -1),
+    vec3(0, 1, 1), vec3(0, -1, 1), vec3(0, -1, -1), vec3(0, 1, -1)
+);
+
+float pointShadowDisk(vec3 fragToLight, float currentDepth, float bias, float diskRadius) {
     float shadow = 0.0;
-    vec2 texelSize = 1.0 / textureSize(shadowMap, 0);
-    for(int x = -1; x <= 1; ++x) {
-        for(int y = -1; y <= 1; ++y) {
-            float pcfDepth = texture(shadowMap, projCoords.xy + vec2(x, y) * texelSize).r;
-            shadow += currentDepth - bias > pcfDepth ? 0.0 : 1.0;
+    for (int i = 0; i < 20; ++i) {
+        float closestDepth = texture(pointShadowMap, fragToLight + pointShadowOffsets[i] * diskRadius).r * pointShadowFar;
+        shadow += currentDepth - bias > closestDepth ? 0.0 : 1.0;
+    }
+    return shadow / 20.0;
+}
+
+float pointShadowCalculation(vec3 fragPos, vec3 N, vec3 L) {
+    float dist = length(fragPos - lightPos);
+    if (dist > pointShadowFar) {
+        return 1.0;
+    }
+
+    // A 90 degree face spans 2*dist world units across its resolution
+    float texelWorld = 2.0 * dist / pointShadowResolution;
+    vec3 receiver;
+    float bias = receiverBias(fragPos, N, L, texelWorld, receiver);
+
+    vec3 fragToLight = receiver - lightPos;
+    float currentDepth = length(fragToLight);
+
+    if (shadowFilter == FILTER_HARD) {
+        return pointShadowDisk(fragToLight, currentDepth, bias, 0.0);
+    }
+
+    if (shadowFilter == FILTER_PCSS) {
+        float searchRadius = float(shadowBlockerSearch) * texelWorld;
+        float blockerSum = 0.0;
+        int blockers = 0;
+        for (int i = 0; i < 20; ++i) {
+            float d = texture(pointShadowMap, fragToLight + pointShadowOffsets[i] * searchRadius).r * pointShadowFar;
+            if (d + bias < currentDepth) {
+                blockerSum += d;
+                blockers++;
+            }
+        }
+        if (blockers == 0) {
+            return 1.0;
         }
+        float blockerDepth = max(blockerSum / float(blockers), 0.1);
+        float penumbra = shadowLightSize * (currentDepth - blockerDepth) / blockerDepth;
+        float radius = clamp(penumbra, texelWorld, float(max(shadowMaxPenumbra, 1)) * texelWorld);
+        return pointShadowDisk(fragToLight, currentDepth, bias, radius);
     }
-    shadow /= 9.0;
-    
-    return shadow;
+
+    return pointShadowDisk(fragToLight, currentDepth, bias, float(shadowPCFRadius) * texelWorld);
 }
 
 void main() {
     vec3 N = normalize(Normal);
+    if (useNormalMap && dot(Tangent.xyz, Tangent.xyz) > 0.0) {
+        // MikkTSpace: bitangent from the unnormalized interpolants
+        vec3 B = Tangent.w * cross(Normal, Tangent.xyz);
+        vec3 mapped = texture(normalMap, TexCoord).rgb * 2.0 - 1.0;
+        N = normalize(mapped.x * Tangent.xyz + mapped.y * B + mapped.z * Normal);
+    }
     vec3 V = normalize(cameraPos - FragPos);
     
     // Sample maps
-    vec3 materialAlbedo = useAlbedoMap ? texture(albedoMap, TexCoord).rgb : ((length(albedo) > 0.01) ? albedo : BaseColor);
+    // BaseColor carries the vertex color (white when the mesh has none)
+    vec3 materialAlbedo = (useAlbedoMap ? texture(albedoMap, TexCoord).rgb : albedo) * BaseColor;
     float materialMetallic = useMetallicMap ? texture(metallicMap, TexCoord).r : metallic;
     float materialRoughness = useRoughnessMap ? texture(roughnessMap, TexCoord).r : roughness;
     float materialAO = useAOMap ? texture(aoMap, TexCoord).r : 1.0;
+    vec3 materialEmissive = useEmissiveMap ? texture(emissiveMap, TexCoord).rgb * emissive : emissive;
     
     // Calculate reflectance at normal incidence
     vec3 F0 = vec3(0.04); 
@@ -348,13 +567,13 @@ void main() {
     float NdotL = max(dot(N, L), 0.0);
     
     // Calculate shadow
-    float shadow = shadowCalculation(FragPosLightSpace);
+    float shadow = usePointShadow ? pointShadowCalculation(FragPos, N, L) : shadowCalculation(FragPos, N, L);
     
     vec3 Lo = (kD * materialAlbedo / PI + specular) * radiance * NdotL * shadow;
     
     // Ambient
     vec3 ambient = vec3(0.03) * materialAlbedo * materialAO;
-    vec3 color = ambient + Lo;
+    vec3 color = ambient + Lo + materialEmissive;
     
     // Tone mapping
     color = color / (color + vec3(1.0));
@@ -423,9 +642,50 @@ void main() {
 void main() {
     // Depth is written automatically
 }
+` + "\x00"
+
+	pointShadowVertexShaderSource = `
+#version 410 core
+layout (location = 0) in vec3 aPos;
+
+out vec3 WorldPos;
+
+uniform mat4 faceMatrix;
+
+void main() {
+    WorldPos = aPos;
+    gl_Position = faceMatrix * vec4(aPos, 1.0);
+}
+` + "\x00"
+
+	pointShadowFragmentShaderSource = `
+#version 410 core
+in vec3 WorldPos;
+
+uniform vec3 lightPos;
+uniform float farPlane;
+
+void main() {
+    // Store normalized linear distance so the cube map can be sampled by direction
+    gl_FragDepth = length(WorldPos - lightPos) / farPlane;
+}
 ` + "\x00"
 )
 
+// pointCloudBuffer is a point cloud's copy on the GPU
+type pointCloudBuffer struct {
+	VAO     uint32
+	VBO     uint32
+	Count   int32
+	Version uint64 // PointCloud version the buffer was filled from
+}
+
+// pointCloudDraw is a point cloud queued for Present
+type pointCloudDraw struct {
+	Cloud       *PointCloud
+	WorldMatrix Matrix4x4
+}
+
 func NewOpenGLRenderer(width, height int) *OpenGLRenderer {
 	return &OpenGLRenderer{
 		width:  width,
@@ -442,8 +702,10 @@ func NewOpenGLRenderer(width, height int) *OpenGLRenderer {
 		lineVertices:     make([]float32, 0, 60000),
 		vboCache:         NewMeshBufferCache(),
 		textureCache:     make(map[*Texture]uint32),
+		pointCloudBuffers: make(map[*PointCloud]*pointCloudBuffer),
 		shadowResolution: 2048,
 		enableShadows:    true,
+		pointShadowResolution: 1024,
 		usePBRPath:       false,
 	}
 }
@@ -498,6 +760,7 @@ func (r *OpenGLRenderer) Initialize() error {
 	gl.ClearColor(0.0, 0.0, 0.0, 1.0)
 	gl.Enable(gl.BLEND)
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.Enable(gl.PROGRAM_POINT_SIZE) // Point clouds size their sprites in the shader
 
 	// Create shader programs
 	if err := r.createShaderProgram(); err != nil {
@@ -508,6 +771,10 @@ func (r *OpenGLRenderer) Initialize() error {
 		return err
 	}
 
+	if err := r.createPointCloudShaderProgram(); err != nil {
+		return err
+	}
+
 	if err := r.createPBRShaderProgram(); err != nil {
 		return err
 	}
@@ -525,6 +792,14 @@ func (r *OpenGLRenderer) Initialize() error {
 		return err
 	}
 
+	if err := r.createPointShadowShaderProgram(); err != nil {
+		return err
+	}
+
+	if err := r.createPointShadowCubeMap(); err != nil {
+		return err
+	}
+
 	// Create vertex buffers
 	if err := r.createBuffers(); err != nil {
 		return err
@@ -622,6 +897,46 @@ func (r *OpenGLRenderer) createLineShaderProgram() error {
 	return nil
 }
 
+func (r *OpenGLRenderer) createPointCloudShaderProgram() error {
+	vertexShader, err := r.compileShader(pointCloudVertexShaderSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return fmt.Errorf("point cloud vertex shader: %v", err)
+	}
+	defer gl.DeleteShader(vertexShader)
+
+	fragmentShader, err := r.compileShader(pointCloudFragmentShaderSource, gl.FRAGMENT_SHADER)
+	if err != nil {
+		return fmt.Errorf("point cloud fragment shader: %v", err)
+	}
+	defer gl.DeleteShader(fragmentShader)
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragmentShader)
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		return fmt.Errorf("failed to link point cloud program: %v", log)
+	}
+
+	r.pointCloudProgram = program
+
+	r.pointCloudUniformModel = gl.GetUniformLocation(program, gl.Str("model\x00"))
+	r.pointCloudUniformView = gl.GetUniformLocation(program, gl.Str("view\x00"))
+	r.pointCloudUniformProj = gl.GetUniformLocation(program, gl.Str("proj\x00"))
+	r.pointCloudUniformSize = gl.GetUniformLocation(program, gl.Str("pointSize\x00"))
+	r.pointCloudUniformSplats = gl.GetUniformLocation(program, gl.Str("splats\x00"))
+	r.pointCloudUniformHeight = gl.GetUniformLocation(program, gl.Str("viewportHeight\x00"))
+
+	return nil
+}
+
 func (r *OpenGLRenderer) createPBRShaderProgram() error {
 	// Compile vertex shader
 	vertexShader, err := r.compileShader(pbrVertexShaderSource, gl.VERTEX_SHADER)
@@ -669,6 +984,20 @@ func (r *OpenGLRenderer) createPBRShaderProgram() error {
 	r.pbrUniformLightSpaceMatrix = gl.GetUniformLocation(program, gl.Str("lightSpaceMatrix\x00"))
 	r.pbrUniformShadowMap = gl.GetUniformLocation(program, gl.Str("shadowMap\x00"))
 	r.pbrUniformUseShadows = gl.GetUniformLocation(program, gl.Str("useShadows\x00"))
+	r.pbrUniformPointShadowMap = gl.GetUniformLocation(program, gl.Str("pointShadowMap\x00"))
+	r.pbrUniformUsePointShadow = gl.GetUniformLocation(program, gl.Str("usePointShadow\x00"))
+	r.pbrUniformPointShadowFar = gl.GetUniformLocation(program, gl.Str("pointShadowFar\x00"))
+	r.pbrUniformPointShadowRes = gl.GetUniformLocation(program, gl.Str("pointShadowResolution\x00"))
+	r.pbrUniformShadowFilter = gl.GetUniformLocation(program, gl.Str("shadowFilter\x00"))
+	r.pbrUniformShadowBias = gl.GetUniformLocation(program, gl.Str("shadowBias\x00"))
+	r.pbrUniformShadowSlopeBias = gl.GetUniformLocation(program, gl.Str("shadowSlopeBias\x00"))
+	r.pbrUniformShadowNormalOffset = gl.GetUniformLocation(program, gl.Str("shadowNormalOffset\x00"))
+	r.pbrUniformShadowPCFRadius = gl.GetUniformLocation(program, gl.Str("shadowPCFRadius\x00"))
+	r.pbrUniformShadowLightSize = gl.GetUniformLocation(program, gl.Str("shadowLightSize\x00"))
+	r.pbrUniformShadowBlockerSearch = gl.GetUniformLocation(program, gl.Str("shadowBlockerSearch\x00"))
+	r.pbrUniformShadowMaxPenumbra = gl.GetUniformLocation(program, gl.Str("shadowMaxPenumbra\x00"))
+	r.pbrUniformShadowTexelWorld = gl.GetUniformLocation(program, gl.Str("shadowTexelWorld\x00"))
+	r.pbrUniformShadowDepthRange = gl.GetUniformLocation(program, gl.Str("shadowDepthRange\x00"))
 
 	r.pbrUniformAlbedoMap = gl.GetUniformLocation(program, gl.Str("albedoMap\x00"))
 	r.pbrUniformUseAlbedoMap = gl.GetUniformLocation(program, gl.Str("useAlbedoMap\x00"))
@@ -680,6 +1009,9 @@ func (r *OpenGLRenderer) createPBRShaderProgram() error {
 	r.pbrUniformUseRoughnessMap = gl.GetUniformLocation(program, gl.Str("useRoughnessMap\x00"))
 	r.pbrUniformAOMap = gl.GetUniformLocation(program, gl.Str("aoMap\x00"))
 	r.pbrUniformUseAOMap = gl.GetUniformLocation(program, gl.Str("useAOMap\x00"))
+	r.pbrUniformEmissive = gl.GetUniformLocation(program, gl.Str("emissive\x00"))
+	r.pbrUniformEmissiveMap = gl.GetUniformLocation(program, gl.Str("emissiveMap\x00"))
+	r.pbrUniformUseEmissiveMap = gl.GetUniformLocation(program, gl.Str("useEmissiveMap\x00"))
 
 	fmt.Println("[OpenGL] PBR shader program created successfully")
 	return nil
@@ -811,6 +1143,78 @@ func (r *OpenGLRenderer) createShadowMapFBO() error {
 	return nil
 }
 
+func (r *OpenGLRenderer) createPointShadowShaderProgram() error {
+	vertexShader, err := r.compileShader(pointShadowVertexShaderSource, gl.VERTEX_SHADER)
+	if err != nil {
+		return fmt.Errorf("point shadow vertex shader: %v", err)
+	}
+	defer gl.DeleteShader(vertexShader)
+
+	fragmentShader, err := r.compileShader(pointShadowFragmentShaderSource, gl.FRAGMENT_SHADER)
+	if err != nil {
+		return fmt.Errorf("point shadow fragment shader: %v", err)
+	}
+	defer gl.DeleteShader(fragmentShader)
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragmentShader)
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		return fmt.Errorf("failed to link point shadow program: %v", log)
+	}
+
+	r.pointShadowProgram = program
+	r.pointShadowUniformFaceMatrix = gl.GetUniformLocation(program, gl.Str("faceMatrix\x00"))
+	r.pointShadowUniformLightPos = gl.GetUniformLocation(program, gl.Str("lightPos\x00"))
+	r.pointShadowUniformFar = gl.GetUniformLocation(program, gl.Str("farPlane\x00"))
+
+	fmt.Println("[OpenGL] Point shadow shader program created successfully")
+	return nil
+}
+
+func (r *OpenGLRenderer) createPointShadowCubeMap() error {
+	gl.GenTextures(1, &r.pointShadowCubeTexture)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, r.pointShadowCubeTexture)
+	for face := uint32(0); face < 6; face++ {
+		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+face, 0, gl.DEPTH_COMPONENT,
+			int32(r.pointShadowResolution), int32(r.pointShadowResolution), 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	}
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+
+	gl.GenFramebuffers(1, &r.pointShadowFBO)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, r.pointShadowFBO)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_CUBE_MAP_POSITIVE_X, r.pointShadowCubeTexture, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+
+	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("point shadow framebuffer is not complete")
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	// Face matrices are built by the CPU cube map so both paths agree on orientation
+	r.pointShadowMap = &CubeShadowMap{Resolution: r.pointShadowResolution}
+	for i := range r.pointShadowMap.Faces {
+		r.pointShadowMap.Faces[i] = &ShadowMap{Width: r.pointShadowResolution, Height: r.pointShadowResolution}
+	}
+
+	fmt.Printf("[OpenGL] Point shadow cube map created (6x%dx%d)\n", r.pointShadowResolution, r.pointShadowResolution)
+	return nil
+}
+
 func (r *OpenGLRenderer) compileShader(source string, shaderType uint32) (uint32, error) {
 	shader := gl.CreateShader(shaderType)
 
@@ -886,11 +1290,11 @@ func (r *OpenGLRenderer) createBuffers() error {
 	gl.GenBuffers(1, &pbrVBO)
 	gl.BindBuffer(gl.ARRAY_BUFFER, pbrVBO)
 
-	// Allocate buffer for PBR vertices (pos(3) + normal(3) + uv(2) + color(3) = 11 floats)
-	pbrBufferSize := r.maxVertices * 11 * 4
+	// Allocate buffer for PBR vertices (pos(3) + normal(3) + uv(2) + color(3) + tangent(4) = 15 floats)
+	pbrBufferSize := r.maxVertices * 15 * 4
 	gl.BufferData(gl.ARRAY_BUFFER, pbrBufferSize, nil, gl.DYNAMIC_DRAW)
 
-	stride := int32(11 * 4)
+	stride := int32(15 * 4)
 
 	// Position attribute (location 0)
 	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, stride, gl.PtrOffset(0))
@@ -908,6 +1312,10 @@ func (r *OpenGLRenderer) createBuffers() error {
 	gl.VertexAttribPointer(3, 2, gl.FLOAT, false, stride, gl.PtrOffset(6*4))
 	gl.EnableVertexAttribArray(3)
 
+	// Tangent attribute (location 4) - Offset 44 (3+3+2+3 floats)
+	gl.VertexAttribPointer(4, 4, gl.FLOAT, false, stride, gl.PtrOffset(11*4))
+	gl.EnableVertexAttribArray(4)
+
 	gl.BindVertexArray(0)
 
 	// Store PBR VAO/VBO
@@ -968,6 +1376,15 @@ func (r *OpenGLRenderer) Shutdown() {
 	gl.DeleteProgram(r.pbrProgram)
 	gl.DeleteProgram(r.textureProgram)
 	gl.DeleteProgram(r.shadowProgram)
+	gl.DeleteProgram(r.pointShadowProgram)
+	gl.DeleteProgram(r.pointCloudProgram)
+
+	// Delete point cloud buffers
+	for _, buffer := range r.pointCloudBuffers {
+		gl.DeleteBuffers(1, &buffer.VBO)
+		gl.DeleteVertexArrays(1, &buffer.VAO)
+	}
+	r.pointCloudBuffers = make(map[*PointCloud]*pointCloudBuffer)
 	
 	// Delete cached textures
 	for _, texID := range r.textureCache {
@@ -982,6 +1399,12 @@ func (r *OpenGLRenderer) Shutdown() {
 	if r.shadowFBO != 0 {
 		gl.DeleteFramebuffers(1, &r.shadowFBO)
 	}
+	if r.pointShadowCubeTexture != 0 {
+		gl.DeleteTextures(1, &r.pointShadowCubeTexture)
+	}
+	if r.pointShadowFBO != 0 {
+		gl.DeleteFramebuffers(1, &r.pointShadowFBO)
+	}
 
 	r.window.Destroy()
 	glfw.Terminate()
@@ -1011,6 +1434,7 @@ func (r *OpenGLRenderer) Present() {
 	r.FlushStandard()
 	r.FlushPBR()
 	r.FlushTextured()
+	r.FlushPointClouds()
 	r.FlushLines()
 
 	r.window.SwapBuffers()
@@ -1130,6 +1554,12 @@ func (r *OpenGLRenderer) renderNode(node *SceneNode, worldMatrix Matrix4x4, came
 		if currentMesh != nil && len(currentMesh.Vertices) > 0 && len(currentMesh.Indices) > 0 {
 			r.RenderMesh(currentMesh, worldMatrix, camera)
 		}
+	case MeshProvider:
+		for _, chunk := range obj.VisibleMeshes() {
+			r.RenderMesh(chunk, worldMatrix, camera)
+		}
+	case Drawable:
+		obj.Draw(r, worldMatrix, camera)
 	}
 }
 
@@ -1211,6 +1641,14 @@ func (r *OpenGLRenderer) RenderTriangle(tri *Triangle, worldMatrix Matrix4x4, ca
 }
 
 func (r *OpenGLRenderer) RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera *Camera) {
+	// Material uniforms are per draw, so mixed-material meshes draw per material
+	if mesh.HasFaceMaterials() {
+		for _, part := range mesh.SplitByMaterial() {
+			r.RenderMesh(part, worldMatrix, camera)
+		}
+		return
+	}
+
 	meshPos := worldMatrix.TransformPoint(mesh.Position)
 
 	// Check if this is a PBR material
@@ -1230,6 +1668,11 @@ func (r *OpenGLRenderer) RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera *C
 		}
 	}
 
+	hasNormals := mesh.HasNormals()
+	hasTangents := mesh.HasTangents()
+	hasColors := mesh.HasColors()
+	normalMatrix := worldMatrix.NormalMatrix()
+
 	for i := 0; i < len(mesh.Indices); i += 3 {
 		if i+2 < len(mesh.Indices) {
 			idx0, idx1, idx2 := mesh.Indices[i], mesh.Indices[i+1], mesh.Indices[i+2]
@@ -1260,11 +1703,22 @@ func (r *OpenGLRenderer) RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera *C
 					continue
 				}
 
-				// Calculate normal for all cases
+				// Calculate normal for all cases; smooth meshes use one per vertex
 				normal := CalculateSurfaceNormal(&p0, &p1, &p2, nil, false)
 				worldNormal := worldMatrix.TransformDirection(normal)
+				n0, n1, n2 := worldNormal, worldNormal, worldNormal
+				if hasNormals {
+					n0 = normalMatrix.TransformNormal(mesh.Normals[idx0])
+					n1 = normalMatrix.TransformNormal(mesh.Normals[idx1])
+					n2 = normalMatrix.TransformNormal(mesh.Normals[idx2])
+				}
 
-				rf, gf, bf := float32(color.R)/255.0, float32(color.G)/255.0, float32(color.B)/255.0
+				// Vertex colors multiply the material color
+				tint0, tint1, tint2 := ColorWhite, ColorWhite, ColorWhite
+				if hasColors {
+					tint0, tint1, tint2 = mesh.Colors[idx0], mesh.Colors[idx1], mesh.Colors[idx2]
+				}
+				c0, c1, c2 := color.Multiply(tint0), color.Multiply(tint1), color.Multiply(tint2)
 
 				if isPBR {
 					// Use PBR rendering path with normals
@@ -1281,55 +1735,70 @@ func (r *OpenGLRenderer) RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera *C
 						}
 					}
 
-					r.addPBRVertex(finalP0, worldNormal, u0, v0, rf, gf, bf)
-					r.addPBRVertex(finalP1, worldNormal, u1, v1, rf, gf, bf)
-					r.addPBRVertex(finalP2, worldNormal, u2, v2, rf, gf, bf)
+					// Without tangents the shader skips the normal map
+					var t0, t1, t2 Tangent
+					if hasTangents {
+						t0 = worldMatrix.TransformTangent(mesh.Tangents[idx0])
+						t1 = worldMatrix.TransformTangent(mesh.Tangents[idx1])
+						t2 = worldMatrix.TransformTangent(mesh.Tangents[idx2])
+					}
+
+					// The shader multiplies the albedo by the vertex color
+					r.addPBRVertex(finalP0, n0, t0, u0, v0, float32(tint0.R)/255.0, float32(tint0.G)/255.0, float32(tint0.B)/255.0)
+					r.addPBRVertex(finalP1, n1, t1, u1, v1, float32(tint1.R)/255.0, float32(tint1.G)/255.0, float32(tint1.B)/255.0)
+					r.addPBRVertex(finalP2, n2, t2, u2, v2, float32(tint2.R)/255.0, float32(tint2.G)/255.0, float32(tint2.B)/255.0)
 				} else {
-					// Apply simple lighting (FIXED)
+					// Apply simple lighting per vertex (Gouraud), so smooth
+					// meshes blend across faces
 					if r.LightingSystem != nil {
-						intensity := 0.2 // Ambient base
-						for _, light := range r.LightingSystem.Lights {
-							if !light.IsEnabled {
-								continue
-							}
-
-							centerX := (finalP0.X + finalP1.X + finalP2.X) / 3.0
-							centerY := (finalP0.Y + finalP1.Y + finalP2.Y) / 3.0
-							centerZ := (finalP0.Z + finalP1.Z + finalP2.Z) / 3.0
-
-							lx := light.Position.X - centerX
-							ly := light.Position.Y - centerY
-							lz := light.Position.Z - centerZ
-							lx, ly, lz = normalizeVector(lx, ly, lz)
-
-							diff := dotProduct(worldNormal.X, worldNormal.Y, worldNormal.Z, lx, ly, lz)
-							if diff > 0 {
-								intensity += diff * light.Intensity * 0.8
-							}
-						}
-
-						if intensity > 1.0 {
-							intensity = 1.0
-						}
-
-						color = Color{
-							R: uint8(float64(color.R) * intensity),
-							G: uint8(float64(color.G) * intensity),
-							B: uint8(float64(color.B) * intensity),
-						}
-						rf, gf, bf = float32(color.R)/255.0, float32(color.G)/255.0, float32(color.B)/255.0
+						r0, g0, b0 := r.simpleVertexLighting(finalP0, n0, c0)
+						r1, g1, b1 := r.simpleVertexLighting(finalP1, n1, c1)
+						r2, g2, b2 := r.simpleVertexLighting(finalP2, n2, c2)
+						r.addVertex(finalP0, r0, g0, b0)
+						r.addVertex(finalP1, r1, g1, b1)
+						r.addVertex(finalP2, r2, g2, b2)
+						continue
 					}
 
 					// Use basic rendering path
-					r.addVertex(finalP0, rf, gf, bf)
-					r.addVertex(finalP1, rf, gf, bf)
-					r.addVertex(finalP2, rf, gf, bf)
+					r.addVertex(finalP0, float32(c0.R)/255.0, float32(c0.G)/255.0, float32(c0.B)/255.0)
+					r.addVertex(finalP1, float32(c1.R)/255.0, float32(c1.G)/255.0, float32(c1.B)/255.0)
+					r.addVertex(finalP2, float32(c2.R)/255.0, float32(c2.G)/255.0, float32(c2.B)/255.0)
 				}
 			}
 		}
 	}
 }
 
+// simpleVertexLighting applies ambient plus diffuse lighting at a vertex and
+// returns the color as GL floats
+func (r *OpenGLRenderer) simpleVertexLighting(p, normal Point, color Color) (float32, float32, float32) {
+	intensity := 0.2 // Ambient base
+	for _, light := range r.LightingSystem.Lights {
+		if !light.IsEnabled {
+			continue
+		}
+
+		lx := light.Position.X - p.X
+		ly := light.Position.Y - p.Y
+		lz := light.Position.Z - p.Z
+		lx, ly, lz = normalizeVector(lx, ly, lz)
+
+		diff := dotProduct(normal.X, normal.Y, normal.Z, lx, ly, lz)
+		if diff > 0 {
+			intensity += diff * light.Intensity * 0.8
+		}
+	}
+
+	if intensity > 1.0 {
+		intensity = 1.0
+	}
+
+	return float32(float64(color.R)*intensity) / 255.0,
+		float32(float64(color.G)*intensity) / 255.0,
+		float32(float64(color.B)*intensity) / 255.0
+}
+
 // RenderInstancedMesh renders multiple instances of the same mesh efficiently
 func (r *OpenGLRenderer) RenderInstancedMesh(instMesh *InstancedMesh, worldMatrix Matrix4x4, camera *Camera) {
 	if !instMesh.Enabled || instMesh.BaseMesh == nil || len(instMesh.Instances) == 0 {
@@ -1441,6 +1910,15 @@ func (r *OpenGLRenderer) RenderPoint(point *Point, worldMatrix Matrix4x4, camera
 	r.addVertex(left, rf, gf, bf)
 }
 
+// RenderPointCloud queues a point cloud; its points stay on the GPU between
+// frames and are drawn in Present
+func (r *OpenGLRenderer) RenderPointCloud(cloud *PointCloud, worldMatrix Matrix4x4, camera *Camera) {
+	if len(cloud.Points) == 0 {
+		return
+	}
+	r.pointCloudDraws = append(r.pointCloudDraws, pointCloudDraw{Cloud: cloud, WorldMatrix: worldMatrix})
+}
+
 func (r *OpenGLRenderer) addVertex(p Point, red, green, blue float32) {
 	if len(r.currentVertices) >= r.maxVertices {
 		r.FlushStandard()
@@ -1453,16 +1931,17 @@ func (r *OpenGLRenderer) addVertex(p Point, red, green, blue float32) {
 	)
 }
 
-func (r *OpenGLRenderer) addPBRVertex(p Point, normal Point, u, v float32, red, green, blue float32) {
+func (r *OpenGLRenderer) addPBRVertex(p Point, normal Point, tangent Tangent, u, v float32, red, green, blue float32) {
 	if len(r.pbrVertices) >= r.maxVertices {
 		r.FlushPBR()
 	}
 	r.pbrVertices = append(r.pbrVertices,
 		PBRVertex{
-			Pos:    [3]float32{float32(p.X), float32(p.Y), float32(p.Z)},
-			Normal: [3]float32{float32(normal.X), float32(normal.Y), float32(normal.Z)},
-			UV:     [2]float32{u, v},
-			Color:  [3]float32{red, green, blue},
+			Pos:     [3]float32{float32(p.X), float32(p.Y), float32(p.Z)},
+			Normal:  [3]float32{float32(normal.X), float32(normal.Y), float32(normal.Z)},
+			UV:      [2]float32{u, v},
+			Color:   [3]float32{red, green, blue},
+			Tangent: [4]float32{float32(tangent.X), float32(tangent.Y), float32(tangent.Z), float32(tangent.W)},
 		},
 	)
 }
@@ -1638,6 +2117,13 @@ func (r *OpenGLRenderer) RenderTexturedMesh(mesh *Mesh, worldMatrix Matrix4x4, c
 	}
 }
 
+// Orthographic shadow frustum used for non-point lights
+const (
+	glShadowOrthoSize = 50.0
+	glShadowNear      = 0.1
+	glShadowFar       = 200.0
+)
+
 // calculateLightSpaceMatrix calculates the light view-projection matrix for shadow mapping
 func (r *OpenGLRenderer) calculateLightSpaceMatrix(lightPos Point, sceneCenter Point) Matrix4x4 {
 	// Create light view matrix (look at scene center from light position)
@@ -1645,10 +2131,8 @@ func (r *OpenGLRenderer) calculateLightSpaceMatrix(lightPos Point, sceneCenter P
 	
 	// Create orthographic projection for shadow map
 	// Adjust size based on scene bounds
-	size := 50.0
-	near := 0.1
-	far := 200.0
-	projMatrix := CreateOrthographicMatrix(-size, size, -size, size, near, far)
+	size := glShadowOrthoSize
+	projMatrix := CreateOrthographicMatrix(-size, size, -size, size, glShadowNear, glShadowFar)
 	
 	// Combine matrices
 	return projMatrix.Multiply(viewMatrix)
@@ -1656,12 +2140,23 @@ func (r *OpenGLRenderer) calculateLightSpaceMatrix(lightPos Point, sceneCenter P
 
 // renderShadowPass renders the scene from light's perspective to generate shadow map
 func (r *OpenGLRenderer) renderShadowPass(scene *Scene) {
+	r.shadowPassActive = false
 	if !r.enableShadows || r.LightingSystem == nil || len(r.LightingSystem.Lights) == 0 {
 		return
 	}
 
 	// Use first light for shadows
 	light := r.LightingSystem.Lights[0]
+	r.shadowPassActive = light.IsEnabled && light.CastShadows
+	if !r.shadowPassActive {
+		r.pointShadowActive = false
+		return
+	}
+	r.pointShadowActive = light.Type == LightTypePoint
+	if r.pointShadowActive {
+		r.renderPointShadowPass(light, scene)
+		return
+	}
 	sceneCenter := Point{X: 0, Y: 0, Z: 0} // Could calculate from scene bounds
 
 	// Calculate light space matrix
@@ -1676,11 +2171,7 @@ func (r *OpenGLRenderer) renderShadowPass(scene *Scene) {
 	gl.UseProgram(r.shadowProgram)
 
 	// Upload light space matrix
-	var m [16]float32
-	for i := 0; i < 16; i++ {
-		m[i] = float32(r.shadowLightMatrix.M[i])
-	}
-	gl.UniformMatrix4fv(r.shadowUniformLightSpaceMatrix, 1, false, &m[0])
+	r.uploadMatrix(r.shadowUniformLightSpaceMatrix, r.shadowLightMatrix)
 
 	// Render all scene nodes (depth only)
 	nodes := scene.GetRenderableNodes()
@@ -1694,6 +2185,61 @@ func (r *OpenGLRenderer) renderShadowPass(scene *Scene) {
 	gl.Viewport(0, 0, int32(r.width), int32(r.height))
 }
 
+// renderPointShadowPass renders the six cube faces around a point light
+func (r *OpenGLRenderer) renderPointShadowPass(light *Light, scene *Scene) {
+	r.pointShadowMap.SetupLightViews(light, 0.1, pointShadowFar(light, scene))
+
+	// Gather world-space positions once and draw them into every face
+	positions := make([]float32, 0, 4096)
+	for _, node := range scene.GetRenderableNodes() {
+		forEachShadowTriangle(node, func(v0, v1, v2 Point) {
+			positions = append(positions,
+				float32(v0.X), float32(v0.Y), float32(v0.Z),
+				float32(v1.X), float32(v1.Y), float32(v1.Z),
+				float32(v2.X), float32(v2.Y), float32(v2.Z))
+		})
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, r.pointShadowFBO)
+	gl.Viewport(0, 0, int32(r.pointShadowResolution), int32(r.pointShadowResolution))
+
+	gl.UseProgram(r.pointShadowProgram)
+	gl.Uniform3f(r.pointShadowUniformLightPos, float32(light.Position.X), float32(light.Position.Y), float32(light.Position.Z))
+	gl.Uniform1f(r.pointShadowUniformFar, float32(r.pointShadowMap.Far))
+
+	var shadowVAO, shadowVBO uint32
+	if len(positions) > 0 {
+		gl.GenVertexArrays(1, &shadowVAO)
+		gl.BindVertexArray(shadowVAO)
+		gl.GenBuffers(1, &shadowVBO)
+		gl.BindBuffer(gl.ARRAY_BUFFER, shadowVBO)
+		gl.BufferData(gl.ARRAY_BUFFER, len(positions)*4, gl.Ptr(positions), gl.STREAM_DRAW)
+		gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 3*4, gl.PtrOffset(0))
+		gl.EnableVertexAttribArray(0)
+	}
+
+	for face := 0; face < 6; face++ {
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT,
+			gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), r.pointShadowCubeTexture, 0)
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+
+		if len(positions) == 0 {
+			continue
+		}
+		r.uploadMatrix(r.pointShadowUniformFaceMatrix, r.pointShadowMap.FaceMatrix(face))
+		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(positions)/3))
+	}
+
+	if len(positions) > 0 {
+		gl.BindVertexArray(0)
+		gl.DeleteVertexArrays(1, &shadowVAO)
+		gl.DeleteBuffers(1, &shadowVBO)
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(r.width), int32(r.height))
+}
+
 // renderNodeShadow renders a node for shadow pass (depth only)
 func (r *OpenGLRenderer) renderNodeShadow(node *SceneNode, worldMatrix Matrix4x4) {
 	switch obj := node.Object.(type) {
@@ -1709,6 +2255,10 @@ func (r *OpenGLRenderer) renderNodeShadow(node *SceneNode, worldMatrix Matrix4x4
 		if currentMesh != nil && len(currentMesh.Vertices) > 0 && len(currentMesh.Indices) > 0 {
 			r.renderMeshShadow(currentMesh, worldMatrix)
 		}
+	case MeshProvider:
+		for _, chunk := range obj.VisibleMeshes() {
+			r.renderMeshShadow(chunk, worldMatrix)
+		}
 	// Skip lines, points, etc. for shadow pass
 	}
 }
@@ -1719,12 +2269,8 @@ func (r *OpenGLRenderer) renderMeshShadow(mesh *Mesh, worldMatrix Matrix4x4) {
 		return
 	}
 
-	// Upload model matrix
-	var m [16]float32
-	for i := 0; i < 16; i++ {
-		m[i] = float32(worldMatrix.M[i])
-	}
-	gl.UniformMatrix4fv(r.shadowUniformModel, 1, false, &m[0])
+	// Positions below are already in world space, so the model matrix is identity
+	r.uploadMatrix(r.shadowUniformModel, IdentityMatrix())
 
 	// Render mesh using existing VAO (reuse regular mesh VAO for shadow pass)
 	// We'll create a simple vertex buffer with just positions
@@ -1786,7 +2332,7 @@ func (r *OpenGLRenderer) FlushPBR() {
 	}
 
 	gl.BindBuffer(gl.ARRAY_BUFFER, r.pbrVBO)
-	dataSize := len(r.pbrVertices) * 44 // 11 floats * 4 bytes
+	dataSize := len(r.pbrVertices) * 60 // 15 floats * 4 bytes
 	gl.BufferSubData(gl.ARRAY_BUFFER, 0, dataSize, gl.Ptr(r.pbrVertices))
 
 	gl.UseProgram(r.pbrProgram)
@@ -1812,21 +2358,20 @@ func (r *OpenGLRenderer) FlushPBR() {
 		gl.Uniform1f(r.pbrUniformRoughness, float32(r.activePBRMaterial.Roughness))
 		col := r.activePBRMaterial.Albedo
 		gl.Uniform3f(r.pbrUniformAlbedo, float32(col.R)/255.0, float32(col.G)/255.0, float32(col.B)/255.0)
+		emissive := r.activePBRMaterial.Emissive
+		gl.Uniform3f(r.pbrUniformEmissive, float32(emissive.R)/255.0, float32(emissive.G)/255.0, float32(emissive.B)/255.0)
 
 		r.bindPBRTextures()
 	} else {
 		gl.Uniform1f(r.pbrUniformMetallic, 0.5)
 		gl.Uniform1f(r.pbrUniformRoughness, 0.5)
 		gl.Uniform3f(r.pbrUniformAlbedo, 0, 0, 0)
+		gl.Uniform3f(r.pbrUniformEmissive, 0, 0, 0)
 		r.disablePBRTextures()
 	}
 
-	if r.enableShadows {
-		var m [16]float32
-		for i := 0; i < 16; i++ {
-			m[i] = float32(r.shadowLightMatrix.M[i])
-		}
-		gl.UniformMatrix4fv(r.pbrUniformLightSpaceMatrix, 1, false, &m[0])
+	if r.enableShadows && r.shadowPassActive {
+		r.uploadMatrix(r.pbrUniformLightSpaceMatrix, r.shadowLightMatrix)
 
 		gl.ActiveTexture(gl.TEXTURE5) // Slot 5 for shadow map
 		gl.BindTexture(gl.TEXTURE_2D, r.shadowDepthTexture)
@@ -1836,6 +2381,20 @@ func (r *OpenGLRenderer) FlushPBR() {
 		gl.Uniform1i(r.pbrUniformUseShadows, 0)
 	}
 
+	// Slot 6 for the point light cube map (kept bound so the samplers never share a unit)
+	gl.ActiveTexture(gl.TEXTURE6)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, r.pointShadowCubeTexture)
+	gl.Uniform1i(r.pbrUniformPointShadowMap, 6)
+	if r.enableShadows && r.shadowPassActive && r.pointShadowActive {
+		gl.Uniform1i(r.pbrUniformUsePointShadow, 1)
+		gl.Uniform1f(r.pbrUniformPointShadowFar, float32(r.pointShadowMap.Far))
+		gl.Uniform1f(r.pbrUniformPointShadowRes, float32(r.pointShadowResolution))
+	} else {
+		gl.Uniform1i(r.pbrUniformUsePointShadow, 0)
+	}
+
+	r.uploadShadowSettings()
+
 	gl.BindVertexArray(r.pbrVAO)
 	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(r.pbrVertices)))
 	gl.BindVertexArray(0)
@@ -1843,6 +2402,25 @@ func (r *OpenGLRenderer) FlushPBR() {
 	r.pbrVertices = r.pbrVertices[:0]
 }
 
+// uploadShadowSettings sends the shadowing light's bias and filter settings
+func (r *OpenGLRenderer) uploadShadowSettings() {
+	settings := DefaultShadowSettings()
+	if r.LightingSystem != nil && len(r.LightingSystem.Lights) > 0 {
+		settings = r.LightingSystem.Lights[0].Shadow
+	}
+
+	gl.Uniform1i(r.pbrUniformShadowFilter, int32(settings.Filter))
+	gl.Uniform1f(r.pbrUniformShadowBias, float32(settings.Bias))
+	gl.Uniform1f(r.pbrUniformShadowSlopeBias, float32(settings.SlopeBias))
+	gl.Uniform1f(r.pbrUniformShadowNormalOffset, float32(settings.NormalOffset))
+	gl.Uniform1i(r.pbrUniformShadowPCFRadius, int32(settings.PCFRadius))
+	gl.Uniform1f(r.pbrUniformShadowLightSize, float32(settings.LightSize))
+	gl.Uniform1i(r.pbrUniformShadowBlockerSearch, int32(settings.BlockerSearchRadius))
+	gl.Uniform1i(r.pbrUniformShadowMaxPenumbra, int32(settings.MaxPenumbra))
+	gl.Uniform1f(r.pbrUniformShadowTexelWorld, float32(2.0*glShadowOrthoSize/float64(r.shadowResolution)))
+	gl.Uniform1f(r.pbrUniformShadowDepthRange, float32(glShadowFar-glShadowNear))
+}
+
 func (r *OpenGLRenderer) FlushTextured() {
 	if len(r.texturedVertices) == 0 {
 		return
@@ -1891,6 +2469,80 @@ func (r *OpenGLRenderer) FlushLines() {
 	r.lineVertices = r.lineVertices[:0]
 }
 
+func (r *OpenGLRenderer) FlushPointClouds() {
+	if len(r.pointCloudDraws) == 0 {
+		return
+	}
+
+	gl.UseProgram(r.pointCloudProgram)
+	r.updateMatrices(r.pointCloudUniformModel, r.pointCloudUniformView, r.pointCloudUniformProj)
+	gl.Uniform1f(r.pointCloudUniformHeight, float32(r.height))
+
+	for _, draw := range r.pointCloudDraws {
+		buffer := r.uploadPointCloud(draw.Cloud)
+
+		// Clouds keep their points in local space, so the model matrix is used
+		r.uploadMatrix(r.pointCloudUniformModel, draw.WorldMatrix)
+		size := draw.Cloud.PointSize
+		splats := int32(0)
+		if draw.Cloud.Mode == PointCloudSplats {
+			size *= splatScale(draw.WorldMatrix)
+			splats = 1
+		}
+		gl.Uniform1f(r.pointCloudUniformSize, float32(size))
+		gl.Uniform1i(r.pointCloudUniformSplats, splats)
+
+		gl.BindVertexArray(buffer.VAO)
+		gl.DrawArrays(gl.POINTS, 0, buffer.Count)
+	}
+	gl.BindVertexArray(0)
+
+	r.pointCloudDraws = r.pointCloudDraws[:0]
+}
+
+// uploadPointCloud returns a cloud's GPU buffer, filling it the first time
+// and again whenever the cloud has been marked dirty
+func (r *OpenGLRenderer) uploadPointCloud(cloud *PointCloud) *pointCloudBuffer {
+	buffer, ok := r.pointCloudBuffers[cloud]
+	if ok && buffer.Version == cloud.Version() && buffer.Count == int32(len(cloud.Points)) {
+		return buffer
+	}
+	if !ok {
+		buffer = &pointCloudBuffer{}
+		gl.GenVertexArrays(1, &buffer.VAO)
+		gl.GenBuffers(1, &buffer.VBO)
+		r.pointCloudBuffers[cloud] = buffer
+	}
+
+	// Interleaved: pos(3) + color(3)
+	vertices := make([]float32, 0, len(cloud.Points)*6)
+	for i, p := range cloud.Points {
+		c := cloud.ColorAt(i)
+		vertices = append(vertices,
+			float32(p.X), float32(p.Y), float32(p.Z),
+			float32(c.R)/255.0, float32(c.G)/255.0, float32(c.B)/255.0,
+		)
+	}
+
+	gl.BindVertexArray(buffer.VAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, buffer.VBO)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	// Position attribute (location 0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 6*4, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(0)
+
+	// Color attribute (location 1)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 6*4, gl.PtrOffset(3*4))
+	gl.EnableVertexAttribArray(1)
+
+	gl.BindVertexArray(0)
+
+	buffer.Count = int32(len(cloud.Points))
+	buffer.Version = cloud.Version()
+	return buffer
+}
+
 func (r *OpenGLRenderer) bindPBRTextures() {
 	mat := r.activePBRMaterial
 
@@ -1910,11 +2562,10 @@ func (r *OpenGLRenderer) bindPBRTextures() {
 		texID := r.uploadTexture(mat.NormalMap)
 		gl.ActiveTexture(gl.TEXTURE1)
 		gl.BindTexture(gl.TEXTURE_2D, texID)
-		gl.Uniform1i(r.pbrUniformNormalMap, 1) // Note: I need to add pbrUniformNormalMap to struct!
-		gl.Uniform1i(r.pbrUniformUseNormalMap, 1) // And this!
+		gl.Uniform1i(r.pbrUniformNormalMap, 1)
+		gl.Uniform1i(r.pbrUniformUseNormalMap, 1)
 	} else {
-		// gl.Uniform1i(r.pbrUniformUseNormalMap, 0)
-		// Skip normal map uniforms for now as I didn't add them to struct yet
+		gl.Uniform1i(r.pbrUniformUseNormalMap, 0)
 	}
 
 	// Metallic (Slot 2)
@@ -1949,6 +2600,17 @@ func (r *OpenGLRenderer) bindPBRTextures() {
 	} else {
 		gl.Uniform1i(r.pbrUniformUseAOMap, 0)
 	}
+
+	// Emissive (Slot 7; 5 and 6 hold the shadow maps)
+	if mat.UseTextures && mat.EmissiveMap != nil {
+		texID := r.uploadTexture(mat.EmissiveMap)
+		gl.ActiveTexture(gl.TEXTURE7)
+		gl.BindTexture(gl.TEXTURE_2D, texID)
+		gl.Uniform1i(r.pbrUniformEmissiveMap, 7)
+		gl.Uniform1i(r.pbrUniformUseEmissiveMap, 1)
+	} else {
+		gl.Uniform1i(r.pbrUniformUseEmissiveMap, 0)
+	}
 }
 
 func (r *OpenGLRenderer) disablePBRTextures() {
@@ -1956,4 +2618,5 @@ func (r *OpenGLRenderer) disablePBRTextures() {
 	gl.Uniform1i(r.pbrUniformUseMetallicMap, 0)
 	gl.Uniform1i(r.pbrUniformUseRoughnessMap, 0)
 	gl.Uniform1i(r.pbrUniformUseAOMap, 0)
+	gl.Uniform1i(r.pbrUniformUseEmissiveMap, 0)
 }
@@ -86,6 +86,9 @@ func (pr *ParallelRenderer) RenderPoint(pt *Point, wm Matrix4x4, cam *Camera) {
 func (pr *ParallelRenderer) RenderMesh(mesh *Mesh, wm Matrix4x4, cam *Camera) {
 	pr.Renderer.RenderMesh(mesh, wm, cam)
 }
+func (pr *ParallelRenderer) RenderPointCloud(cloud *PointCloud, wm Matrix4x4, cam *Camera) {
+	pr.Renderer.RenderPointCloud(cloud, wm, cam)
+}
 func (pr *ParallelRenderer) SetLightingSystem(ls *LightingSystem) { pr.Renderer.SetLightingSystem(ls) }
 func (pr *ParallelRenderer) SetCamera(camera *Camera)             { pr.Renderer.SetCamera(camera) }
 func (pr *ParallelRenderer) SetUseColor(use bool)                 { pr.Renderer.SetUseColor(use) }
@@ -130,6 +133,8 @@ func (pr *ParallelRenderer) generateTiles(nodes []*SceneNode, camera *Camera) {
 			}
 		case *Triangle:
 			aabb = ComputeTriangleBounds(obj)
+		case Bounded:
+			aabb = obj.Bounds()
 		default:
 			// Fallback: use point bounds at node position
 			pos := node.Transform.GetWorldPosition()
@@ -223,6 +228,12 @@ func (pr *ParallelRenderer) renderNodeWithRenderer(node *SceneNode, worldMatrix
 		if tr, ok := r.(*TerminalRenderer); ok {
 			tr.renderQuad(obj, worldMatrix, camera)
 		}
+	case MeshProvider:
+		for _, chunk := range obj.VisibleMeshes() {
+			r.RenderMesh(chunk, worldMatrix, camera)
+		}
+	case Drawable:
+		obj.Draw(r, worldMatrix, camera)
 	}
 }
 
@@ -271,6 +282,8 @@ func (pr *ParallelRenderer) RenderBatched(scene *Scene) {
 			}
 		case *Triangle:
 			aabb = ComputeTriangleBounds(obj)
+		case Bounded:
+			aabb = obj.Bounds()
 		default:
 			// Fallback: don't bin, put in all tiles or skip?
 			// For simplicity, we add to all tiles if we can't bound it (expensive)
@@ -473,6 +486,12 @@ func (jr *JobBasedRenderer) renderNodeWithMatrix(node *SceneNode, worldMatrix Ma
 		jr.Renderer.RenderLine(obj, worldMatrix, camera)
 	case *Point:
 		jr.Renderer.RenderPoint(obj, worldMatrix, camera)
+	case MeshProvider:
+		for _, chunk := range obj.VisibleMeshes() {
+			jr.Renderer.RenderMesh(chunk, worldMatrix, camera)
+		}
+	case Drawable:
+		obj.Draw(jr.Renderer, worldMatrix, camera)
 	}
 }
 
@@ -21,6 +21,12 @@ type TerminalRenderer struct {
 	ShadowRenderer *SimpleShadowRenderer
 	Camera         *Camera
 	ShowDebugInfo  bool
+
+	// Tiled light culling (rebuilt by RenderScene; other paths shade with every light)
+	LightGrid        *LightGrid
+	UseLightCulling  bool
+	ShowLightHeatmap bool // Overlay lights-per-tile on the frame
+	lightGridValid   bool
 	debugBuffer    strings.Builder
 	lastDebugLine  string
 
@@ -46,6 +52,10 @@ func NewTerminalRenderer(writer *bufio.Writer, height, width int) *TerminalRende
 		}
 	}
 
+	// Moderate resolution for CPU rendering; all shadow-casting lights share one atlas
+	shadowRenderer := NewSimpleShadowRenderer(512)
+	shadowRenderer.Atlas = NewShadowAtlas(DefaultShadowAtlasBudget)
+
 	return &TerminalRenderer{
 		Writer:        writer,
 		Height:        height,
@@ -56,7 +66,9 @@ func NewTerminalRenderer(writer *bufio.Writer, height, width int) *TerminalRende
 		Charset:       DefaultCharset,
 		UseColor:      true,
 		ShowDebugInfo:  true,
-		ShadowRenderer: NewSimpleShadowRenderer(512), // Moderate resolution for CPU rendering
+		ShadowRenderer: shadowRenderer,
+		LightGrid:       NewLightGrid(width, height, DefaultLightTileSize),
+		UseLightCulling: true,
 		ClipMinX:       0,
 		ClipMinY:       0,
 		ClipMaxX:       width,
@@ -103,6 +115,7 @@ func (r *TerminalRenderer) BeginFrame() {
 	r.ClipMinY = 0
 	r.ClipMaxX = r.Width
 	r.ClipMaxY = r.Height
+	r.lightGridValid = false
 
 	for y := 0; y < r.Height; y++ {
 		for x := 0; x < r.Width; x++ {
@@ -183,11 +196,13 @@ func (r *TerminalRenderer) RenderScene(scene *Scene) {
 
 		// Generate shadow maps
 		if r.ShadowRenderer != nil {
-			for _, light := range r.LightingSystem.Lights {
-				if light.IsEnabled {
-					r.ShadowRenderer.RenderShadowMap(light, scene)
-				}
-			}
+			r.ShadowRenderer.RenderShadows(r.LightingSystem.Lights, scene, scene.Camera)
+		}
+
+		// Assign lights to screen tiles
+		if r.UseLightCulling && r.LightGrid != nil {
+			r.LightGrid.Build(r.LightingSystem.Lights, scene.Camera)
+			r.lightGridValid = true
 		}
 	}
 
@@ -197,6 +212,10 @@ func (r *TerminalRenderer) RenderScene(scene *Scene) {
 		r.renderNode(node, worldMatrix, scene.Camera)
 	}
 
+	if r.ShowLightHeatmap && r.lightGridValid {
+		r.drawLightHeatmap()
+	}
+
 	r.EndFrame()
 }
 
@@ -222,6 +241,12 @@ func (r *TerminalRenderer) renderNode(node *SceneNode, worldMatrix Matrix4x4, ca
 		r.renderCircle(obj, worldMatrix, camera)
 	case *Point:
 		r.RenderPoint(obj, worldMatrix, camera)
+	case MeshProvider:
+		for _, chunk := range obj.VisibleMeshes() {
+			r.RenderMesh(chunk, worldMatrix, camera)
+		}
+	case Drawable:
+		obj.Draw(r, worldMatrix, camera)
 	}
 }
 
@@ -238,6 +263,27 @@ func (r *TerminalRenderer) RenderTriangle(tri *Triangle, worldMatrix Matrix4x4,
 		transformedNormal = &tn
 	}
 
+	// Vertex normals are carried on the triangle, so shade a world-space copy
+	if tri.HasVertexNormals {
+		normalMatrix := worldMatrix.NormalMatrix()
+		worldTri := *tri
+		worldTri.SetVertexNormals(
+			normalMatrix.TransformNormal(tri.N0),
+			normalMatrix.TransformNormal(tri.N1),
+			normalMatrix.TransformNormal(tri.N2),
+		)
+		tri = &worldTri
+	}
+	if tri.HasTangents {
+		worldTri := *tri
+		worldTri.SetTangents(
+			worldMatrix.TransformTangent(tri.T0),
+			worldMatrix.TransformTangent(tri.T1),
+			worldMatrix.TransformTangent(tri.T2),
+		)
+		tri = &worldTri
+	}
+
 	r.renderTriangleInternal(p0, p1, p2, tri, transformedNormal, camera)
 }
 
@@ -264,6 +310,22 @@ func (r *TerminalRenderer) renderTriangleInternal(p0, p1, p2 Point, originalTri
 	if originalTri.HasUVs {
 		transformed.SetUVs(originalTri.UV0, originalTri.UV1, originalTri.UV2)
 	}
+	transformed.N0 = originalTri.N0
+	transformed.N1 = originalTri.N1
+	transformed.N2 = originalTri.N2
+	transformed.HasVertexNormals = originalTri.HasVertexNormals
+	transformed.T0 = originalTri.T0
+	transformed.T1 = originalTri.T1
+	transformed.T2 = originalTri.T2
+	transformed.HasTangents = originalTri.HasTangents
+	transformed.C0 = originalTri.C0
+	transformed.C1 = originalTri.C1
+	transformed.C2 = originalTri.C2
+	transformed.HasVertexColors = originalTri.HasVertexColors
+	transformed.Lightmap = originalTri.Lightmap
+	transformed.LightmapUV0 = originalTri.LightmapUV0
+	transformed.LightmapUV1 = originalTri.LightmapUV1
+	transformed.LightmapUV2 = originalTri.LightmapUV2
 
 	if originalTri.UseSetNormal && transformedNormal != nil {
 		transformed.Normal = transformedNormal
@@ -308,8 +370,77 @@ func (r *TerminalRenderer) RenderPoint(point *Point, worldMatrix Matrix4x4, came
 	}
 }
 
+// RenderPointCloud renders every point of a cloud as a dot or a splat
+func (r *TerminalRenderer) RenderPointCloud(cloud *PointCloud, worldMatrix Matrix4x4, camera *Camera) {
+	// One matrix takes points straight to view space
+	viewMatrix := camera.Transform.GetInverseMatrix()
+	toView := viewMatrix.Multiply(worldMatrix)
+	radius := cloud.PointSize / 2 * splatScale(worldMatrix)
+
+	for i, p := range cloud.Points {
+		v := toView.TransformPoint(p)
+		if v.Z <= camera.Near {
+			continue
+		}
+		x, y := normalize(r.Height, r.Width, int(v.X*camera.FOV.X/v.Z), int(v.Y*camera.FOV.Y/v.Z))
+
+		// Splats shrink with distance like everything else; dots don't
+		rx, ry := (cloud.PointSize-1)/2, (cloud.PointSize-1)/2
+		if cloud.Mode == PointCloudSplats {
+			rx = radius * camera.FOV.X / v.Z * ASPECT_RATIO
+			ry = radius * camera.FOV.Y / v.Z
+		}
+		r.drawSplat(x, y, rx, ry, v.Z, cloud.ColorAt(i))
+	}
+}
+
+// drawSplat fills the ellipse of radii rx, ry cells around a point with
+// z-buffering and clipping; radii under a cell cover the point's own cell
+func (r *TerminalRenderer) drawSplat(cx, cy int, rx, ry, z float64, color Color) {
+	ix := int(math.Min(math.Max(rx, 0), float64(r.Width)))
+	iy := int(math.Min(math.Max(ry, 0), float64(r.Height)))
+	rx, ry = math.Max(rx, 0.5), math.Max(ry, 0.5)
+
+	for dy := -iy; dy <= iy; dy++ {
+		y := cy + dy
+		if y < r.ClipMinY || y >= r.ClipMaxY {
+			continue
+		}
+		for dx := -ix; dx <= ix; dx++ {
+			x := cx + dx
+			if x < r.ClipMinX || x >= r.ClipMaxX {
+				continue
+			}
+			ex, ey := float64(dx)/rx, float64(dy)/ry
+			if ex*ex+ey*ey > 1 || z >= r.ZBuffer[y][x] {
+				continue
+			}
+
+			if r.UseColor {
+				r.Surface[y][x] = FILLED_CHAR
+				r.ColorBuffer[y][x] = color
+			} else {
+				brightness := (float64(color.R) + float64(color.G) + float64(color.B)) / (3.0 * 255.0)
+				r.Surface[y][x] = rune(SHADING_RAMP[clampInt(int(brightness*float64(len(SHADING_RAMP)-1)), 0, len(SHADING_RAMP)-1)])
+			}
+			r.ZBuffer[y][x] = z
+		}
+	}
+}
+
 // RenderMesh renders a complete mesh
 func (r *TerminalRenderer) RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera *Camera) {
+	// Meshlets outside the frustum or facing away are dropped before any of
+	// their vertices are transformed
+	var meshlets []*Meshlet
+	if mesh.HasMeshlets() && camera != nil {
+		frustum := BuildFrustumSimple(camera)
+		meshlets = mesh.VisibleMeshlets(worldMatrix, &frustum, camera.GetPosition())
+		if len(meshlets) == 0 {
+			return
+		}
+	}
+
 	// Optimization: Pre-transform vertices once per mesh instead of per triangle
 	// This reduces matrix multiplications by a factor of ~6 (depending on mesh topology)
 	transformedVertices := make([]Point, len(mesh.Vertices))
@@ -318,8 +449,8 @@ func (r *TerminalRenderer) RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera
 	offsetX, offsetY, offsetZ := mesh.Position.X, mesh.Position.Y, mesh.Position.Z
 	hasOffset := offsetX != 0 || offsetY != 0 || offsetZ != 0
 
-	for i, v := range mesh.Vertices {
-		transformed := worldMatrix.TransformPoint(v)
+	transformVertex := func(i int) {
+		transformed := worldMatrix.TransformPoint(mesh.Vertices[i])
 		if hasOffset {
 			transformed.X += offsetX
 			transformed.Y += offsetY
@@ -327,6 +458,17 @@ func (r *TerminalRenderer) RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera
 		}
 		transformedVertices[i] = transformed
 	}
+	if meshlets != nil {
+		for _, meshlet := range meshlets {
+			for _, v := range meshlet.Vertices {
+				transformVertex(v)
+			}
+		}
+	} else {
+		for i := range mesh.Vertices {
+			transformVertex(i)
+		}
+	}
 
 	// Reusable triangle struct for metadata passing to internal renderer
 	// We only set metadata (Material, UVs) on this, not vertices
@@ -339,29 +481,75 @@ func (r *TerminalRenderer) RenderMesh(mesh *Mesh, worldMatrix Matrix4x4, camera
 	// RenderMesh original code didn't set Normal/UseSetNormal (it was nil/false by default).
 
 	hasUVs := len(mesh.UVs) > 0
+	hasLightmap := mesh.Lightmap != nil && len(mesh.LightmapUVs) == len(mesh.Indices)
+	hasNormals := mesh.HasNormals()
+	tempTri.HasVertexNormals = hasNormals
+	var normalMatrix Matrix4x4
+	if hasNormals {
+		normalMatrix = worldMatrix.NormalMatrix()
+	}
+	hasTangents := mesh.HasTangents() && hasUVs
+	tempTri.HasTangents = hasTangents
+	hasColors := mesh.HasColors()
+	tempTri.HasVertexColors = hasColors
+	hasFaceMaterials := mesh.HasFaceMaterials()
+	if hasLightmap {
+		tempTri.Lightmap = mesh.Lightmap
+	}
 
 	// Render triangles from indexed geometry
-	for i := 0; i < len(mesh.Indices); i += 3 {
-		if i+2 < len(mesh.Indices) {
-			idx0, idx1, idx2 := mesh.Indices[i], mesh.Indices[i+1], mesh.Indices[i+2]
-			if idx0 < len(mesh.Vertices) && idx1 < len(mesh.Vertices) && idx2 < len(mesh.Vertices) {
-				// Use pre-transformed vertices
-				p0 := transformedVertices[idx0]
-				p1 := transformedVertices[idx1]
-				p2 := transformedVertices[idx2]
-
-				// Update UVs on the reuseable triangle if needed
-				if hasUVs {
-					if idx0 < len(mesh.UVs) && idx1 < len(mesh.UVs) && idx2 < len(mesh.UVs) {
-						tempTri.SetUVs(mesh.UVs[idx0], mesh.UVs[idx1], mesh.UVs[idx2])
+	renderRange := func(from, to int) {
+		for i := from; i < to; i += 3 {
+			if i+2 < len(mesh.Indices) {
+				idx0, idx1, idx2 := mesh.Indices[i], mesh.Indices[i+1], mesh.Indices[i+2]
+				if idx0 < len(mesh.Vertices) && idx1 < len(mesh.Vertices) && idx2 < len(mesh.Vertices) {
+					// Use pre-transformed vertices
+					p0 := transformedVertices[idx0]
+					p1 := transformedVertices[idx1]
+					p2 := transformedVertices[idx2]
+
+					// Update UVs on the reuseable triangle if needed
+					if hasUVs {
+						if idx0 < len(mesh.UVs) && idx1 < len(mesh.UVs) && idx2 < len(mesh.UVs) {
+							tempTri.SetUVs(mesh.UVs[idx0], mesh.UVs[idx1], mesh.UVs[idx2])
+						}
+					}
+					if hasNormals {
+						tempTri.N0 = normalMatrix.TransformNormal(mesh.Normals[idx0])
+						tempTri.N1 = normalMatrix.TransformNormal(mesh.Normals[idx1])
+						tempTri.N2 = normalMatrix.TransformNormal(mesh.Normals[idx2])
+					}
+					if hasTangents {
+						tempTri.T0 = worldMatrix.TransformTangent(mesh.Tangents[idx0])
+						tempTri.T1 = worldMatrix.TransformTangent(mesh.Tangents[idx1])
+						tempTri.T2 = worldMatrix.TransformTangent(mesh.Tangents[idx2])
+					}
+					if hasColors {
+						tempTri.SetVertexColors(mesh.Colors[idx0], mesh.Colors[idx1], mesh.Colors[idx2])
+					}
+					if hasFaceMaterials {
+						tempTri.Material = mesh.FaceMaterials[i/3]
+					}
+					if hasLightmap {
+						tempTri.LightmapUV0 = mesh.LightmapUVs[i]
+						tempTri.LightmapUV1 = mesh.LightmapUVs[i+1]
+						tempTri.LightmapUV2 = mesh.LightmapUVs[i+2]
 					}
-				}
 				
-				// Call internal renderer directly, skipping redundant transforms and allocations
-				r.renderTriangleInternal(p0, p1, p2, tempTri, nil, camera)
+					// Call internal renderer directly, skipping redundant transforms and allocations
+					r.renderTriangleInternal(p0, p1, p2, tempTri, nil, camera)
+				}
 			}
 		}
 	}
+
+	if meshlets == nil {
+		renderRange(0, len(mesh.Indices))
+		return
+	}
+	for _, meshlet := range meshlets {
+		renderRange(meshlet.FirstTriangle*3, (meshlet.FirstTriangle+meshlet.TriangleCount)*3)
+	}
 }
 
 // RenderInstancedMesh renders multiple instances of the same mesh
@@ -442,17 +630,21 @@ func (r *TerminalRenderer) rasterizeTriangleWithLighting(t *Triangle, camera *Ca
 		return
 	}
 
+	// Clipping drops per-vertex attributes (vertex normals, lightmap UVs), so
+	// pixels look them up on the original triangle
 	for _, tri := range clipped {
-		r.fillTriangleWithPerPixelLighting(tri, camera, normal, t.Material)
+		r.fillTriangleWithPerPixelLighting(tri, camera, normal, t.Material, t)
 	}
 }
 
-// fillTriangleWithPerPixelLighting fills a triangle using perspective-correct interpolation
+// fillTriangleWithPerPixelLighting fills a triangle using perspective-correct interpolation.
+// original is the unclipped triangle carrying vertex normals and lightmap UVs (may be nil).
 func (r *TerminalRenderer) fillTriangleWithPerPixelLighting(
 	t *Triangle,
 	camera *Camera,
-	normal Point,
+	faceNormal Point,
 	material IMaterial,
+	original *Triangle,
 ) {
 	// 1. Project vertices to screen space
 	x0, y0, zDepth0 := camera.ProjectPoint(t.P0, r.Height, r.Width)
@@ -493,6 +685,15 @@ func (r *TerminalRenderer) fillTriangleWithPerPixelLighting(
 		uv2OverZ = TextureCoord{U: t.UV2.U * invZ2, V: t.UV2.V * invZ2}
 	}
 
+	pixelHasUVs := hasUVs || (original != nil && original.HasUVs)
+	normalMapped := pixelHasUVs && original != nil && original.HasTangents && material.HasNormalMap()
+
+	// Vertex colors tint the diffuse through a wrapper reused for every pixel
+	var tinted *tintedMaterial
+	if original != nil && original.HasVertexColors {
+		tinted = &tintedMaterial{IMaterial: material}
+	}
+
 	// 3. Sort vertices by Y (Standard Scanline approach)
 	if y1 < y0 {
 		x0, y0, invZ0, p0OverZ, x1, y1, invZ1, p1OverZ = x1, y1, invZ1, p1OverZ, x0, y0, invZ0, p0OverZ
@@ -628,42 +829,58 @@ func (r *TerminalRenderer) fillTriangleWithPerPixelLighting(
 					currentUVOverZ := lerpTextureCoord(uvOverZA, uvOverZB, t)
 					u = currentUVOverZ.U / currentInvZ
 					v = currentUVOverZ.V / currentInvZ
+				} else if pixelHasUVs {
+					// Near-plane clipping drops UVs; take them from the unclipped triangle
+					w0, w1, w2 := barycentric3D(pixelWorldPos, original.P0, original.P1, original.P2)
+					u = w0*original.UV0.U + w1*original.UV1.U + w2*original.UV2.U
+					v = w0*original.UV0.V + w1*original.UV1.V + w2*original.UV2.V
+				}
+
+				// Smooth shading interpolates the vertex normals
+				normal := faceNormal
+				if original != nil {
+					normal = InterpolateNormal(original, pixelWorldPos, faceNormal)
+				}
+				if normalMapped {
+					normal = PerturbNormal(normal, InterpolateTangent(original, pixelWorldPos), material.SampleNormal(u, v))
+				}
+
+				shadingMaterial, tint := material, ColorWhite
+				if tinted != nil {
+					tint = InterpolateColor(original, pixelWorldPos)
+					tinted.tint = tint
+					shadingMaterial = tinted
 				}
 
 				var pixelColor Color
-				if r.LightingSystem != nil {
-					if pbrMat, ok := material.(*PBRMaterial); ok {
-						shadowCb := func(l *Light, p Point) float64 {
-							if r.ShadowRenderer != nil {
-								if sm := r.ShadowRenderer.ShadowMaps[l]; sm != nil {
-									return sm.CalculateShadow(p)
-								}
-							}
-							return 1.0
+				if original != nil && original.Lightmap != nil {
+					// Baked lighting replaces the dynamic lights
+					pixelColor = ApplyLightmap(shadingMaterial.GetDiffuseColor(u, v), SampleLightmap(original, pixelWorldPos))
+				} else if r.LightingSystem != nil {
+					lights := r.LightingSystem.Lights
+					if r.lightGridValid {
+						lights = r.LightGrid.LightsAt(x, y)
+					}
+
+					// Each light is attenuated by its own shadow map
+					shadowCb := func(l *Light, p Point) float64 {
+						if r.ShadowRenderer != nil {
+							return r.ShadowRenderer.ShadowFactor(l, p, normal)
 						}
+						return 1.0
+					}
 
+					if pbrMat, ok := material.(*PBRMaterial); ok {
 						viewDirX, viewDirY, viewDirZ := camera.GetViewDirection(pixelWorldPos)
 						viewDir := Point{X: viewDirX, Y: viewDirY, Z: viewDirZ}
 
-						pixelColor = CalculatePBRLightingWithUV(pixelWorldPos, normal, viewDir, pbrMat, r.LightingSystem.Lights, r.LightingSystem.AmbientLight, r.LightingSystem.AmbientIntensity, u, v, shadowCb)
+						pixelColor = CalculatePBRLightingWithTint(pixelWorldPos, normal, viewDir, pbrMat, lights, r.LightingSystem.AmbientLight, r.LightingSystem.AmbientIntensity, u, v, tint, shadowCb)
 					} else {
 						// Standard Lighting with Shadows & Textures
-						shadowFactor := 1.0
-						if r.ShadowRenderer != nil && len(r.LightingSystem.Lights) > 0 {
-							for _, l := range r.LightingSystem.Lights {
-								if l.IsEnabled {
-									if sm := r.ShadowRenderer.ShadowMaps[l]; sm != nil {
-										shadowFactor = sm.CalculateShadow(pixelWorldPos)
-										break
-									}
-								}
-							}
-						}
-
 						ao := CalculateSimpleAO(normal)
 
-						if texMat, ok := material.(*TexturedMaterial); ok && hasUVs && texMat.UseTextures {
-							litColor := r.LightingSystem.CalculateLighting(pixelWorldPos, normal, material, ao)
+						if texMat, ok := material.(*TexturedMaterial); ok && pixelHasUVs && texMat.UseTextures {
+							litColor := r.LightingSystem.CalculateLightingForLights(lights, pixelWorldPos, normal, shadingMaterial, ao, shadowCb)
 							texColor := texMat.SampleDiffuse(u, v)
 							pixelColor = Color{
 								R: uint8(float64(litColor.R) * float64(texColor.R) / 255.0),
@@ -671,19 +888,11 @@ func (r *TerminalRenderer) fillTriangleWithPerPixelLighting(
 								B: uint8(float64(litColor.B) * float64(texColor.B) / 255.0),
 							}
 						} else {
-							pixelColor = r.LightingSystem.CalculateLighting(pixelWorldPos, normal, material, ao)
-						}
-
-						if shadowFactor < 1.0 {
-							pixelColor = Color{
-								R: uint8(float64(pixelColor.R) * (0.2 + 0.8*shadowFactor)),
-								G: uint8(float64(pixelColor.G) * (0.2 + 0.8*shadowFactor)),
-								B: uint8(float64(pixelColor.B) * (0.2 + 0.8*shadowFactor)),
-							}
+							pixelColor = r.LightingSystem.CalculateLightingForLights(lights, pixelWorldPos, normal, shadingMaterial, ao, shadowCb)
 						}
 					}
 				} else {
-					pixelColor = r.simpleLighting(normal, material)
+					pixelColor = r.simpleLighting(normal, shadingMaterial)
 				}
 
 				if r.UseColor {
@@ -712,7 +921,7 @@ func (r *TerminalRenderer) fillTriangle(t *Triangle, camera *Camera, color Color
 	// Redirect to the robust lighting function to ensure clipping/perspective consistency
 	// This ensures we don't duplicate the complex clipping logic.
 	// If specific "solid color" behavior is needed, the material can be adjusted.
-	r.fillTriangleWithPerPixelLighting(t, camera, Point{0, 1, 0}, t.Material)
+	r.fillTriangleWithPerPixelLighting(t, camera, Point{0, 1, 0}, t.Material, nil)
 }
 
 // renderTriangleWireframe renders triangle edges
@@ -872,6 +1081,41 @@ func (r *TerminalRenderer) simpleLighting(normal Point, material IMaterial) Colo
 	}
 }
 
+// drawLightHeatmap tints every cell by the number of lights in its tile and
+// prints the count at each tile's top-left corner
+func (r *TerminalRenderer) drawLightHeatmap() {
+	maxLights, _ := r.LightGrid.Stats()
+
+	for y := 0; y < r.Height; y++ {
+		for x := 0; x < r.Width; x++ {
+			count := len(r.LightGrid.LightsAt(x, y))
+			heat := HeatmapColor(count, maxLights)
+
+			if r.Surface[y][x] == ' ' {
+				r.Surface[y][x] = '.'
+				r.ColorBuffer[y][x] = heat
+			} else {
+				r.ColorBuffer[y][x] = r.ColorBuffer[y][x].Lerp(heat, 0.6)
+			}
+
+			if x%r.LightGrid.TileSize == 0 && y%r.LightGrid.TileSize == 0 {
+				label := '+'
+				if count < 10 {
+					label = rune('0' + count)
+				}
+				r.Surface[y][x] = label
+				r.ColorBuffer[y][x] = ColorWhite
+			}
+		}
+	}
+}
+
+// SetShowLightHeatmap toggles the lights-per-tile overlay
+func (r *TerminalRenderer) SetShowLightHeatmap(show bool) {
+	r.ShowLightHeatmap = show
+	r.lastDebugLine = ""
+}
+
 func (r *TerminalRenderer) showDebugLine() {
 	if r.Camera == nil {
 		return
@@ -880,6 +1124,10 @@ func (r *TerminalRenderer) showDebugLine() {
 	pitch, yaw, roll := r.Camera.GetRotation()
 	r.debugBuffer.Reset()
 	r.debugBuffer.WriteString(fmt.Sprintf("FPS: %.1f", 60.0))
+	if r.ShowLightHeatmap && r.lightGridValid {
+		maxLights, avgLights := r.LightGrid.Stats()
+		r.debugBuffer.WriteString(fmt.Sprintf("  Lights/tile: max %d avg %.1f", maxLights, avgLights))
+	}
 	camInfo := fmt.Sprintf("Pos:(%.1f,%.1f,%.1f) Rot:(P:%.2f Y:%.2f R:%.2f)", pos.X, pos.Y, pos.Z, pitch*180/3.14159, yaw*180/3.14159, roll*180/3.14159)
 	totalLen := r.debugBuffer.Len() + len(camInfo)
 	padding := r.Width - totalLen
@@ -895,6 +895,12 @@ func (r *VulkanRenderer) addNodeGeometry(node *SceneNode, worldMatrix Matrix4x4,
 		r.addMeshVertices(obj, worldMatrix, camera)
 	case *Quad:
 		r.addQuadVertices(obj, worldMatrix, camera)
+	case MeshProvider:
+		for _, chunk := range obj.VisibleMeshes() {
+			r.addMeshVertices(chunk, worldMatrix, camera)
+		}
+	case Drawable:
+		obj.Draw(r, worldMatrix, camera)
 	}
 }
 
@@ -926,6 +932,47 @@ func (r *VulkanRenderer) addMeshVertices(mesh *Mesh, worldMatrix Matrix4x4, came
 	}
 }
 
+// RenderPointCloud adds a cloud to the frame being collected by RenderScene
+func (r *VulkanRenderer) RenderPointCloud(cloud *PointCloud, wm Matrix4x4, cam *Camera) {
+	r.addPointCloudVertices(cloud, wm, cam)
+}
+
+// addPointCloudVertices adds a camera-facing square per point, as the
+// pipeline only draws triangle lists
+func (r *VulkanRenderer) addPointCloudVertices(cloud *PointCloud, worldMatrix Matrix4x4, camera *Camera) {
+	camPos := camera.GetPosition()
+	forward := camera.GetForwardVectorPoint()
+	right := camera.GetRightVectorPoint()
+	up := camera.GetUpVectorPoint()
+
+	// World size of one pixel at unit depth, for dots of a fixed pixel size
+	pixel := 2 * math.Tan(camera.FOV.Y*math.Pi/360) / float64(r.height)
+	radius := cloud.PointSize / 2 * splatScale(worldMatrix)
+
+	for i, local := range cloud.Points {
+		p := worldMatrix.TransformPoint(local)
+		depth := dotPoints(subPoints(p, camPos), forward)
+		if depth <= camera.Near {
+			continue
+		}
+		half := radius
+		if cloud.Mode == PointCloudPoints {
+			half = cloud.PointSize / 2 * pixel * depth
+		}
+
+		color := cloud.ColorAt(i)
+		c := [3]float32{float32(color.R) / 255.0, float32(color.G) / 255.0, float32(color.B) / 255.0}
+		corner := func(sx, sy float64) VulkanVertex {
+			q := addPoints(p, addPoints(scalePoint(right, sx*half), scalePoint(up, sy*half)))
+			return VulkanVertex{Pos: [3]float32{float32(q.X), float32(q.Y), float32(q.Z)}, Color: c}
+		}
+		r.currentVertices = append(r.currentVertices,
+			corner(-1, -1), corner(1, -1), corner(1, 1),
+			corner(-1, -1), corner(1, 1), corner(-1, 1),
+		)
+	}
+}
+
 func (r *VulkanRenderer) addQuadVertices(quad *Quad, worldMatrix Matrix4x4, camera *Camera) {
 	triangles := ConvertQuadToTriangles(quad)
 	for _, tri := range triangles {
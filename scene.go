@@ -13,6 +13,30 @@ type SceneNode struct {
 	OnUpdate  func(*SceneNode, float64)
 }
 
+// MeshProvider is a scene object that builds the meshes it is drawn with,
+// such as a terrain picking LOD chunks for the camera. Renderers, shadows and
+// exporters draw VisibleMeshes with the node's transform; culling uses
+// Bounds, in the object's local space.
+type MeshProvider interface {
+	VisibleMeshes() []*Mesh
+	Bounds() *AABB
+}
+
+// Drawable is a scene object drawn with a renderer's primitives instead of
+// meshes, such as a curve's line segments or a point cloud. Renderers call
+// Draw with the node's transform; culling uses Bounds, in local space.
+type Drawable interface {
+	Draw(r Renderer, worldMatrix Matrix4x4, camera *Camera)
+	Bounds() *AABB
+}
+
+// Bounded is any scene object that knows its own local-space bounds, which
+// is all culling and spatial partitioning need of MeshProviders and
+// Drawables
+type Bounded interface {
+	Bounds() *AABB
+}
+
 // Scene manages the scene graph
 type Scene struct {
 	Root     *SceneNode
@@ -180,6 +204,9 @@ func (s *Scene) computeNodeBounds(node *SceneNode) *AABB {
 			}
 			return NewAABBFromPoints(points)
 		}
+
+	case Bounded:
+		return TransformAABBByMatrix(obj.Bounds(), worldMatrix)
 	}
 
 	return nil
@@ -355,6 +382,31 @@ func (sn *SceneNode) TransformSceneObject() any {
 			transformed.Normal = &transformedNormal
 		}
 
+		if obj.HasVertexNormals {
+			worldMatrix := worldTransform.GetWorldMatrix()
+			normalMatrix := worldMatrix.NormalMatrix()
+			transformed.SetVertexNormals(
+				normalMatrix.TransformNormal(obj.N0),
+				normalMatrix.TransformNormal(obj.N1),
+				normalMatrix.TransformNormal(obj.N2),
+			)
+		}
+
+		if obj.HasUVs {
+			transformed.SetUVs(obj.UV0, obj.UV1, obj.UV2)
+		}
+		if obj.HasVertexColors {
+			transformed.SetVertexColors(obj.C0, obj.C1, obj.C2)
+		}
+		if obj.HasTangents {
+			worldMatrix := worldTransform.GetWorldMatrix()
+			transformed.SetTangents(
+				worldMatrix.TransformTangent(obj.T0),
+				worldMatrix.TransformTangent(obj.T1),
+				worldMatrix.TransformTangent(obj.T2),
+			)
+		}
+
 		return transformed
 
 	case *Quad:
@@ -408,6 +460,37 @@ func (sn *SceneNode) TransformSceneObject() any {
 			copy(transformedMesh.Indices, obj.Indices)
 		}
 
+		// Normals need the inverse transpose to survive non-uniform scale
+		if obj.HasNormals() {
+			worldMatrix := worldTransform.GetWorldMatrix()
+			normalMatrix := worldMatrix.NormalMatrix()
+			transformedMesh.Normals = make([]Point, len(obj.Normals))
+			for i, n := range obj.Normals {
+				transformedMesh.Normals[i] = normalMatrix.TransformNormal(n)
+			}
+		}
+
+		// UVs and colors are unaffected by the transform; tangents follow the surface
+		if len(obj.UVs) > 0 {
+			transformedMesh.UVs = make([]TextureCoord, len(obj.UVs))
+			copy(transformedMesh.UVs, obj.UVs)
+		}
+		if len(obj.Colors) > 0 {
+			transformedMesh.Colors = make([]Color, len(obj.Colors))
+			copy(transformedMesh.Colors, obj.Colors)
+		}
+		if obj.HasFaceMaterials() {
+			transformedMesh.FaceMaterials = make([]IMaterial, len(obj.FaceMaterials))
+			copy(transformedMesh.FaceMaterials, obj.FaceMaterials)
+		}
+		if obj.HasTangents() {
+			worldMatrix := worldTransform.GetWorldMatrix()
+			transformedMesh.Tangents = make([]Tangent, len(obj.Tangents))
+			for i, t := range obj.Tangents {
+				transformedMesh.Tangents[i] = worldMatrix.TransformTangent(t)
+			}
+		}
+
 		return transformedMesh
 	}
 
@@ -474,6 +557,70 @@ func (s *Scene) CreateSphere(name string, radius float64, rings, sectors int, ma
 	return node
 }
 
+// CreateTorus creates a torus scene node (see GenerateTorus)
+func (s *Scene) CreateTorus(name string, majorRadius, minorRadius float64, majorSegments, minorSegments int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateTorus(majorRadius, minorRadius, majorSegments, minorSegments), material)
+}
+
+// CreatePlane creates a grid plane scene node facing +Y (see GeneratePlane)
+func (s *Scene) CreatePlane(name string, width, depth float64, segmentsX, segmentsZ int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GeneratePlane(width, depth, segmentsX, segmentsZ), material)
+}
+
+// CreateBox creates a segmented box scene node (see GenerateBox)
+func (s *Scene) CreateBox(name string, width, height, depth float64, segmentsX, segmentsY, segmentsZ int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateBox(width, height, depth, segmentsX, segmentsY, segmentsZ), material)
+}
+
+// CreateRoundedBox creates a box scene node with rounded edges (see GenerateRoundedBox)
+func (s *Scene) CreateRoundedBox(name string, width, height, depth, radius float64, segments int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateRoundedBox(width, height, depth, radius, segments), material)
+}
+
+// CreateCylinder creates a capped cylinder scene node (see GenerateCylinder)
+func (s *Scene) CreateCylinder(name string, radius, height float64, radialSegments, heightSegments int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateCylinder(radius, height, radialSegments, heightSegments), material)
+}
+
+// CreateCone creates a cone scene node (see GenerateCone)
+func (s *Scene) CreateCone(name string, radius, height float64, radialSegments, heightSegments int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateCone(radius, height, radialSegments, heightSegments), material)
+}
+
+// CreateCapsule creates a capsule scene node (see GenerateCapsule)
+func (s *Scene) CreateCapsule(name string, radius, height float64, radialSegments, capRings int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateCapsule(radius, height, radialSegments, capRings), material)
+}
+
+// CreateIcosphere creates a subdivided icosahedron scene node (see GenerateIcosphere)
+func (s *Scene) CreateIcosphere(name string, radius float64, subdivisions int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateIcosphere(radius, subdivisions), material)
+}
+
+// CreateLathe creates a surface of revolution scene node (see GenerateLathe)
+func (s *Scene) CreateLathe(name string, profile []Point, segments int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateLathe(profile, segments), material)
+}
+
+// CreateExtrusion creates a scene node sweeping a shape along a path (see GenerateExtrusion)
+func (s *Scene) CreateExtrusion(name string, shape, path []Point, capped bool, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateExtrusion(shape, path, capped), material)
+}
+
+// CreateTube creates a tube scene node following a spline (see GenerateTube)
+func (s *Scene) CreateTube(name string, spline *CatmullRomSpline, radius float64, tubularSegments, radialSegments int, material IMaterial) *SceneNode {
+	return s.createMeshNode(name, GenerateTube(spline, radius, tubularSegments, radialSegments), material)
+}
+
+// createMeshNode adds a node holding a generated mesh
+func (s *Scene) createMeshNode(name string, mesh *Mesh, material IMaterial) *SceneNode {
+	node := NewSceneNode(name)
+	mesh.Material = material
+	node.Object = mesh
+	s.AddNode(node)
+	return node
+}
+
 // CreateEmpty creates an empty scene node
 func (s *Scene) CreateEmpty(name string) *SceneNode {
 	node := NewSceneNode(name)
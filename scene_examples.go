@@ -902,7 +902,7 @@ func TextureShowcaseDemo(scene *Scene) {
 
 func ShadowMappingDemo(scene *Scene) {
 	fmt.Println("=== Shadow Mapping Demo ===")
-	fmt.Println("Showcasing: Real-time shadows with shadow mapping")
+	fmt.Println("Showcasing: Real-time shadows from a point lamp (cube shadow maps)")
 
 	// Create ground plane (to receive shadows)
 	// Simple plane made from two triangles
@@ -929,48 +929,320 @@ func ShadowMappingDemo(scene *Scene) {
 	groundNode := NewSceneNodeWithObject("Ground", groundMesh)
 	scene.AddNode(groundNode)
 
-	// Create floating objects that cast shadows
-	// Sphere 1 - Red metallic
-	sphere1PBR := NewPBRMaterial()
-	sphere1PBR.Albedo = Color{R: 200, G: 50, B: 50}
-	sphere1PBR.Metallic = 0.8
-	sphere1PBR.Roughness = 0.2
-	sphere1 := GenerateSphere(4, 32, 32)
-	sphere1.Material = sphere1PBR
-	sphere1Node := NewSceneNodeWithObject("Sphere1", sphere1)
-	sphere1Node.Transform.SetPosition(-12, 5, 0)
-	sphere1Node.Tags = append(sphere1Node.Tags, "shadow_caster")
-	scene.AddNode(sphere1Node)
-
-	// Sphere 2 - Green
-	sphere2PBR := NewPBRMaterial()
-	sphere2PBR.Albedo = Color{R: 50, G: 200, B: 50}
-	sphere2PBR.Metallic = 0.3
-	sphere2PBR.Roughness = 0.5
-	sphere2 := GenerateSphere(4, 32, 32)
-	sphere2.Material = sphere2PBR
-	sphere2Node := NewSceneNodeWithObject("Sphere2", sphere2)
-	sphere2Node.Transform.SetPosition(0, 5, 0)
-	sphere2Node.Tags = append(sphere2Node.Tags, "shadow_caster")
-	scene.AddNode(sphere2Node)
+	// Pedestal directly below the lamp (shadow falls straight down)
+	pedestalPBR := NewPBRMaterial()
+	pedestalPBR.Albedo = Color{R: 180, G: 180, B: 60}
+	pedestalPBR.Metallic = 0.2
+	pedestalPBR.Roughness = 0.6
+	pedestal := GenerateSphere(3, 24, 24)
+	pedestal.Material = pedestalPBR
+	pedestalNode := NewSceneNodeWithObject("Pedestal", pedestal)
+	pedestalNode.Transform.SetPosition(0, 2, 0)
+	scene.AddNode(pedestalNode)
+
+	// Casters surround the lamp on every side so each cube face is exercised
+	casterColors := []Color{
+		{R: 200, G: 50, B: 50},
+		{R: 50, G: 200, B: 50},
+		{R: 50, G: 50, B: 200},
+		{R: 200, G: 120, B: 40},
+	}
+	for i, col := range casterColors {
+		casterPBR := NewPBRMaterial()
+		casterPBR.Albedo = col
+		casterPBR.Metallic = 0.1 + 0.2*float64(i)
+		casterPBR.Roughness = 0.7 - 0.15*float64(i)
+
+		var caster *Mesh
+		if i%2 == 0 {
+			caster = GenerateSphere(3, 24, 24)
+		} else {
+			caster = GenerateTorus(3, 1, 24, 12)
+		}
+		caster.Material = casterPBR
 
-	// Sphere 3 - Blue
-	sphere3PBR := NewPBRMaterial()
-	sphere3PBR.Albedo = Color{R: 50, G: 50, B: 200}
-	sphere3PBR.Metallic = 0.1
-	sphere3PBR.Roughness = 0.7
-	sphere3 := GenerateSphere(4, 32, 32)
-	sphere3.Material = sphere3PBR
-	sphere3Node := NewSceneNodeWithObject("Sphere3", sphere3)
-	sphere3Node.Transform.SetPosition(12, 5, 0)
-	sphere3Node.Tags = append(sphere3Node.Tags, "shadow_caster")
-	scene.AddNode(sphere3Node)
+		angle := float64(i) * math.Pi / 2.0
+		casterNode := NewSceneNodeWithObject(fmt.Sprintf("Caster%d", i+1), caster)
+		casterNode.Transform.SetPosition(math.Cos(angle)*12.0, 6, math.Sin(angle)*12.0)
+		casterNode.Tags = append(casterNode.Tags, "shadow_caster")
+		scene.AddNode(casterNode)
+	}
 
 	// Setup camera
 	scene.Camera.SetPosition(0, 15, 40)
 
+	fmt.Println("  - Point lamp hanging above the scene center")
 	fmt.Println("  - Ground plane to receive shadows")
-	fmt.Println("  - 3 Floating spheres casting shadows")
-	fmt.Println("  - Real-time shadow map rendering")
-	fmt.Println("  - PCF (Percentage Closer Filtering) for soft shadows")
+	fmt.Println("  - Pedestal below and 4 casters around the lamp")
+	fmt.Println("  - Omnidirectional cube shadow maps with PCF")
+}
+
+// AnimateShadowMapping orbits the shadow casters around the lamp
+func AnimateShadowMapping(scene *Scene, time float64) {
+	casters := scene.FindNodesByTag("shadow_caster")
+	for i, obj := range casters {
+		angle := float64(i)*2.0*math.Pi/float64(len(casters)) + time*0.3
+		obj.Transform.SetPosition(
+			math.Cos(angle)*12.0,
+			6.0+math.Sin(time*1.5+float64(i))*2.0,
+			math.Sin(angle)*12.0,
+		)
+		obj.RotateLocal(0.02, 0.015, 0)
+	}
+}
+
+// ============================================================================
+// DEMO 14: SDF SHAPES AND METABALLS
+// ============================================================================
+
+// metaballCount is the number of balls in the metaball demo
+const metaballCount = 5
+
+// metaballsAt places the demo's metaballs on looping paths
+func metaballsAt(time float64) []Metaball {
+	balls := make([]Metaball, metaballCount)
+	for i := range balls {
+		phase := float64(i) * 2 * math.Pi / metaballCount
+		balls[i] = Metaball{
+			Center: Point{
+				X: math.Sin(time*0.7+phase) * 7,
+				Y: math.Sin(time*1.1+phase*2) * 4,
+				Z: math.Cos(time*0.5+phase) * 7,
+			},
+			Radius: 6 + math.Sin(time+phase)*1.5,
+		}
+	}
+	return balls
+}
+
+// remeshMetaballs rebuilds a metaball mesh in place, so renderers holding
+// the mesh see the new surface
+func remeshMetaballs(mesh *Mesh, time float64) {
+	balls := metaballsAt(time)
+	remeshed := PolygonizeSDF(MetaballSDF(balls, 0.5), MetaballBounds(balls), 28)
+	remeshed.Material = mesh.Material
+	*mesh = *remeshed
+}
+
+func MetaballsDemo(scene *Scene) {
+	fmt.Println("=== SDF & Metaballs Demo ===")
+	fmt.Println("Showcasing: Signed distance shapes and metaballs meshed by dual contouring")
+
+	// Metaballs, remeshed every frame
+	blobMat := NewMaterial()
+	blobMat.DiffuseColor = Color{R: 80, G: 200, B: 160}
+	blob := NewMesh()
+	blob.Material = &blobMat
+	remeshMetaballs(blob, 0)
+	blobNode := NewSceneNodeWithObject("Metaballs", blob)
+	blobNode.AddTag("metaballs")
+	scene.AddNode(blobNode)
+
+	// A sculpted SDF: a ring blended into a ball, with a box cut out
+	shape := SDFSmoothUnion(SDFSphere(Point{}, 5), SDFTorus(Point{}, 7, 1.5), 2)
+	shape = SDFSmoothSubtract(shape, SDFBox(Point{Y: 5}, Point{X: 3, Y: 3, Z: 3}), 0.5)
+	sculpture := PolygonizeSDF(shape, NewAABB(Point{X: -9, Y: -6, Z: -9}, Point{X: 9, Y: 6, Z: 9}), 40)
+	sculptureMat := NewMaterial()
+	sculptureMat.DiffuseColor = Color{R: 210, G: 160, B: 90}
+	sculpture.Material = &sculptureMat
+	sculptureNode := NewSceneNodeWithObject("Sculpture", sculpture)
+	sculptureNode.Transform.SetPosition(28, 0, 0)
+	sculptureNode.AddTag("rotating")
+	scene.AddNode(sculptureNode)
+
+	fmt.Printf("Metaballs: %d balls, %d triangles per frame\n", metaballCount, len(blob.Indices)/3)
+	fmt.Printf("Sculpture: %d vertices, %d triangles\n", len(sculpture.Vertices), len(sculpture.Indices)/3)
+}
+
+// AnimateMetaballs moves the metaballs and remeshes them
+func AnimateMetaballs(scene *Scene, time float64) {
+	for _, node := range scene.FindNodesByTag("metaballs") {
+		if mesh, ok := node.Object.(*Mesh); ok {
+			remeshMetaballs(mesh, time)
+		}
+	}
+	for _, node := range scene.FindNodesByTag("rotating") {
+		node.RotateLocal(0, 0.01, 0)
+	}
+}
+
+// ============================================================================
+// DEMO 15: VOXEL SANDBOX
+// ============================================================================
+
+// plantVoxelTree grows a trunk with a cube of leaves on the highest block of
+// a column
+func plantVoxelTree(world *VoxelWorld, x, z int) {
+	top := world.Settings.ChunkSize*world.Settings.HeightChunks - 1
+	y := top
+	for y > 0 && world.GetBlock(x, y, z) == BlockAir {
+		y--
+	}
+	for dy := 1; dy <= 4; dy++ {
+		world.SetBlock(x, y+dy, z, BlockWood)
+	}
+	for dy := 4; dy <= 6; dy++ {
+		for dz := -1; dz <= 1; dz++ {
+			for dx := -1; dx <= 1; dx++ {
+				if world.GetBlock(x+dx, y+dy, z+dz) == BlockAir {
+					world.SetBlock(x+dx, y+dy, z+dz, BlockLeaves)
+				}
+			}
+		}
+	}
+}
+
+func VoxelWorldDemo(scene *Scene) {
+	fmt.Println("=== Voxel Sandbox Demo ===")
+	fmt.Println("Showcasing: Chunked voxel world with greedy meshing, block picking and chunk streaming")
+
+	settings := DefaultVoxelWorldSettings()
+	settings.HeightChunks = 2
+	settings.LoadRadius = 3
+	settings.UnloadRadius = 4
+	world := NewVoxelWorld(settings, VoxelTerrainGenerator(7, 8, 16))
+	node := scene.CreateVoxelWorld("Voxels", world)
+	for world.Update(Point{}) {
+	}
+
+	for _, tree := range [][2]int{{-12, 6}, {10, -9}, {4, 14}, {-6, -15}} {
+		plantVoxelTree(world, tree[0], tree[1])
+	}
+
+	// Every half second, dig out the block the camera looks at; after a
+	// while, build the hole back up
+	elapsed := 0.0
+	edits := 0
+	node.OnUpdate = func(node *SceneNode, dt float64) {
+		elapsed += dt
+		if elapsed < 0.5 || scene.Camera == nil {
+			return
+		}
+		elapsed = 0
+
+		worldMatrix := node.Transform.GetWorldMatrix()
+		inverse := worldMatrix.Invert()
+		ray := Ray{
+			Origin:    inverse.TransformPoint(scene.Camera.GetPosition()),
+			Direction: inverse.TransformDirection(scene.Camera.GetForwardVectorPoint()),
+		}
+		hit := world.Raycast(ray, 500)
+		if !hit.Hit {
+			return
+		}
+		if (edits/12)%2 == 0 {
+			world.SetBlock(hit.X, hit.Y, hit.Z, BlockAir)
+		} else {
+			x, y, z := hit.Adjacent()
+			world.SetBlock(x, y, z, BlockStone)
+		}
+		edits++
+	}
+
+	triangles := 0
+	for _, mesh := range world.VisibleMeshes() {
+		triangles += len(mesh.Indices) / 3
+	}
+	fmt.Printf("Voxel world: %d chunks loaded, %d triangles after greedy meshing\n", world.LoadedChunks(), triangles)
+}
+
+// AnimateVoxelWorld streams chunks around the orbiting camera
+func AnimateVoxelWorld(scene *Scene) {
+	scene.UpdateVoxelWorlds()
+}
+
+// ============================================================================
+// DEMO 16: CURVED SURFACES
+// ============================================================================
+
+func CurvedSurfacesDemo(scene *Scene) {
+	fmt.Println("=== Curved Surfaces Demo ===")
+	fmt.Println("Showcasing: Bezier patches and NURBS tessellated by screen-space error")
+
+	// The Utah teapot from its Bezier patches
+	teapotMat := NewMaterial()
+	teapotMat.DiffuseColor = Color{R: 220, G: 220, B: 235}
+	teapotMat.Shininess = 64
+	teapot := NewTeapot()
+	teapot.Material = &teapotMat
+	teapotNode := NewSceneNodeWithObject("Teapot", teapot)
+	teapotNode.Transform.SetScale(5, 5, 5)
+	teapotNode.AddTag("rotating")
+	scene.AddNode(teapotNode)
+
+	// A vase turned from a B-spline profile. Cannot fail: curves only need
+	// 2 points.
+	profile, _ := NewBSplineCurve([]Point{
+		{X: 3, Y: 0}, {X: 5, Y: 2}, {X: 5.5, Y: 6}, {X: 2, Y: 10}, {X: 2.5, Y: 14}, {X: 4, Y: 16},
+	}, 3)
+	vaseMat := NewMaterial()
+	vaseMat.DiffuseColor = Color{R: 70, G: 130, B: 200}
+	vase := NewSurfaceOfRevolution(profile)
+	vase.Material = &vaseMat
+	vaseNode := NewSceneNodeWithObject("Vase", vase)
+	vaseNode.Transform.SetPosition(-30, 0, 0)
+	vaseNode.AddTag("rotating")
+	scene.AddNode(vaseNode)
+
+	// An exact circle and a wavy B-spline around the scene
+	scene.AddNode(NewSceneNodeWithObject("Ring", NewNURBSCircle(45)))
+	var wave []Point
+	for i := 0; i <= 12; i++ {
+		wave = append(wave, Point{X: -36 + float64(i)*6, Y: 24 + 4*math.Sin(float64(i)), Z: 12})
+	}
+	waveCurve, _ := NewBSplineCurve(wave, 3)
+	scene.AddNode(NewSceneNodeWithObject("Wave", waveCurve))
+
+	scene.UpdateTessellation()
+	fmt.Printf("Teapot: %d patches, %d triangles at the current distance\n", len(teapot.Patches), len(teapot.VisibleMeshes()[0].Indices)/3)
+	segmentsU, segmentsV := vase.Segments()
+	fmt.Printf("Vase: %dx%d control net tessellated %dx%d\n", vase.CountU, vase.CountV, segmentsU, segmentsV)
+}
+
+// AnimateCurvedSurfaces turns the surfaces and re-tessellates for the camera
+func AnimateCurvedSurfaces(scene *Scene) {
+	for _, node := range scene.FindNodesByTag("rotating") {
+		node.RotateLocal(0, 0.01, 0)
+	}
+	scene.UpdateTessellation()
+}
+
+// ============================================================================
+// DEMO 17: TERRAIN
+// ============================================================================
+
+func TerrainDemo(scene *Scene) {
+	fmt.Println("=== Terrain Demo ===")
+	fmt.Println("Showcasing: Heightmap terrain with quadtree chunk LOD and skirts")
+
+	settings := DefaultTerrainSettings()
+	settings.LODLevels = 5
+	terrain := NewTerrainFromNoise(settings, 11, 5)
+
+	terrainMat := NewMaterial()
+	terrainMat.DiffuseColor = Color{R: 95, G: 140, B: 70}
+	terrainMat.Shininess = 4
+	terrainMat.SpecularStrength = 0.05
+	terrain.SetMaterial(&terrainMat)
+	scene.CreateTerrain("Terrain", terrain)
+
+	// Boulders resting on the ground, to show the height lookups
+	rockMat := NewMaterial()
+	rockMat.DiffuseColor = Color{R: 130, G: 125, B: 120}
+	for i, spot := range [][2]float64{{-60, 40}, {30, -50}, {70, 65}, {-20, -80}} {
+		rock := GenerateSphere(4, 8, 12)
+		rock.Material = &rockMat
+		node := NewSceneNodeWithObject(fmt.Sprintf("Boulder%d", i), rock)
+		node.Transform.SetPosition(spot[0], terrain.HeightAt(spot[0], spot[1])+2, spot[1])
+		scene.AddNode(node)
+	}
+
+	scene.UpdateTerrains()
+	finest := settings.ChunkSize << (settings.LODLevels - 1)
+	fmt.Printf("Terrain: %dx%d cells, %d chunks drawn for the camera\n", finest, finest, len(terrain.VisibleMeshes()))
+}
+
+// AnimateTerrain re-selects terrain chunks as the camera orbits
+func AnimateTerrain(scene *Scene) {
+	scene.UpdateTerrains()
 }
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// SHADOW ATLAS
+// ============================================================================
+// Shadow-casting lights share one depth atlas sized from a memory budget.
+// Lights are ranked by their influence on what is visible: the most important
+// ones get the largest tiles, the others are shrunk, and lights that still do
+// not fit go without shadows for the frame.
+// ============================================================================
+
+const (
+	DefaultShadowAtlasBudget = 8 << 20 // Bytes: a 1024x1024 atlas of float64 depths
+	DefaultMinShadowTileSize = 32      // Smallest tile a light is shrunk to before it is dropped
+)
+
+// AtlasTile is a square region of the shadow atlas
+type AtlasTile struct {
+	X, Y int
+	Size int
+}
+
+// ShadowRequest asks the atlas for room for one light's shadow map
+type ShadowRequest struct {
+	Light    *Light
+	Priority float64 // On-screen influence, higher keeps more resolution
+	Size     int     // Preferred tile size in texels
+	Faces    int     // 1 for a 2D map, 6 for a cube map
+}
+
+// ShadowAtlas packs the shadow maps of several lights into one depth buffer
+type ShadowAtlas struct {
+	Size        int       // Texels per side (power of two)
+	BudgetBytes int       // Memory budget the atlas was sized from
+	MinTileSize int       // Tiles are never shrunk below this size
+	Data        []float64 // Row-major depth texels shared by all tiles
+
+	Tiles    map[*Light][]AtlasTile // Result of the last allocation (one tile per face)
+	Priority map[*Light]float64     // Influence used for the last allocation
+}
+
+// NewShadowAtlas creates the largest power-of-two atlas that fits the budget
+func NewShadowAtlas(budgetBytes int) *ShadowAtlas {
+	texels := budgetBytes / 8
+	size := 0
+	for s := 1; s*s <= texels; s *= 2 {
+		size = s
+	}
+
+	return &ShadowAtlas{
+		Size:        size,
+		BudgetBytes: budgetBytes,
+		MinTileSize: minInt(DefaultMinShadowTileSize, size),
+		Data:        make([]float64, size*size),
+		Tiles:       make(map[*Light][]AtlasTile),
+		Priority:    make(map[*Light]float64),
+	}
+}
+
+// MemoryBytes returns the size of the depth storage
+func (a *ShadowAtlas) MemoryBytes() int {
+	return len(a.Data) * 8
+}
+
+// View returns the rows of a tile as slices into the atlas storage
+func (a *ShadowAtlas) View(tile AtlasTile) [][]float64 {
+	rows := make([][]float64, tile.Size)
+	for y := range rows {
+		start := (tile.Y+y)*a.Size + tile.X
+		rows[y] = a.Data[start : start+tile.Size : start+tile.Size]
+	}
+	return rows
+}
+
+// Allocate assigns tiles to the requests. Tile sizes are powers of two scaled by
+// each light's priority relative to the most important one. While the atlas is
+// over budget, the tile using the most memory per unit of priority is halved;
+// once every tile is at the minimum size the lowest-priority lights are dropped.
+func (a *ShadowAtlas) Allocate(requests []ShadowRequest) map[*Light][]AtlasTile {
+	a.Tiles = make(map[*Light][]AtlasTile)
+	a.Priority = make(map[*Light]float64)
+	if a.Size == 0 || len(requests) == 0 {
+		return a.Tiles
+	}
+
+	sorted := append([]ShadowRequest(nil), requests...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	top := sorted[0].Priority
+
+	// Tile area proportional to priority
+	sizes := make([]int, len(sorted))
+	for i, req := range sorted {
+		size := floorPowerOfTwo(minInt(maxInt(req.Size, 1), a.Size))
+		if top > 0 {
+			target := float64(size) * math.Sqrt(math.Max(req.Priority, 0)/top)
+			for size > a.MinTileSize && float64(size/2) >= target {
+				size /= 2
+			}
+		}
+		sizes[i] = size
+	}
+
+	// Shrink, then drop, until the tiles fit
+	count := len(sorted)
+	for {
+		used := 0
+		for i := 0; i < count; i++ {
+			used += sizes[i] * sizes[i] * maxInt(sorted[i].Faces, 1)
+		}
+		if used <= a.Size*a.Size {
+			break
+		}
+
+		costliest := -1
+		maxCost := 0.0
+		for i := 0; i < count; i++ {
+			if sizes[i] <= a.MinTileSize {
+				continue
+			}
+			cost := float64(sizes[i]*sizes[i]*maxInt(sorted[i].Faces, 1)) / math.Max(sorted[i].Priority, 1e-9)
+			if costliest < 0 || cost >= maxCost {
+				costliest, maxCost = i, cost
+			}
+		}
+		if costliest >= 0 {
+			sizes[costliest] /= 2
+		} else {
+			count--
+		}
+	}
+
+	// Pack largest tiles first along a Z-order curve. Every tile is a power of
+	// two no larger than the ones before it, so each one starts aligned to its
+	// own size and the packing leaves no gaps.
+	type placement struct {
+		index int
+		size  int
+	}
+	placements := make([]placement, 0)
+	for i := 0; i < count; i++ {
+		for f := 0; f < maxInt(sorted[i].Faces, 1); f++ {
+			placements = append(placements, placement{index: i, size: sizes[i]})
+		}
+	}
+	sort.SliceStable(placements, func(i, j int) bool {
+		return placements[i].size > placements[j].size
+	})
+
+	offset := 0
+	for _, p := range placements {
+		x, y := mortonDecode(offset)
+		light := sorted[p.index].Light
+		a.Tiles[light] = append(a.Tiles[light], AtlasTile{X: x, Y: y, Size: p.size})
+		offset += p.size * p.size
+	}
+	for i := 0; i < count; i++ {
+		a.Priority[sorted[i].Light] = sorted[i].Priority
+	}
+
+	return a.Tiles
+}
+
+// floorPowerOfTwo returns the largest power of two not above n (n >= 1)
+func floorPowerOfTwo(n int) int {
+	p := 1
+	for p*2 <= n {
+		p *= 2
+	}
+	return p
+}
+
+// mortonDecode splits a Z-order index into its x (even bits) and y (odd bits)
+func mortonDecode(index int) (int, int) {
+	x, y := 0, 0
+	for bit := 0; index>>(2*bit) != 0; bit++ {
+		x |= (index >> (2 * bit) & 1) << bit
+		y |= (index >> (2*bit + 1) & 1) << bit
+	}
+	return x, y
+}
+
+// LightScreenInfluence estimates how much a light contributes to the visible
+// image: its attenuated strength at each visible object, weighted by the
+// object's projected size. Without a camera every object counts fully.
+func LightScreenInfluence(light *Light, scene *Scene, camera *Camera) float64 {
+	strength := light.Intensity * luminance(light.Color) / 255.0
+	influence := 0.0
+
+	for _, node := range scene.GetRenderableNodes() {
+		bounds := ComputeNodeBounds(node)
+		if bounds == nil {
+			continue
+		}
+		center := bounds.GetCenter()
+		radius := math.Max(bounds.GetRadius(), 1e-3)
+
+		coverage := 1.0
+		if camera != nil {
+			if !camera.IsSphereVisible(center, radius) {
+				continue
+			}
+			depth := camera.TransformToViewSpace(center).Z + camera.DZ
+			coverage = math.Min(radius*radius/math.Max(depth*depth, 1e-6), 1.0)
+		}
+
+		dx := light.Position.X - center.X
+		dy := light.Position.Y - center.Y
+		dz := light.Position.Z - center.Z
+		influence += strength * light.Attenuation(math.Sqrt(dx*dx+dy*dy+dz*dz)) * coverage
+	}
+
+	return influence
+}
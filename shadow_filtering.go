@@ -0,0 +1,289 @@
+package main
+
+import "math"
+
+// ============================================================================
+// SHADOW FILTERING
+// ============================================================================
+// Per-light shadow settings and the filters shared by 2D and cube shadow
+// maps: hard, PCF, PCSS (blocker search) and prefiltered VSM/ESM.
+// ============================================================================
+
+// ShadowFilter selects how shadow map samples are filtered
+type ShadowFilter int
+
+const (
+	ShadowFilterPCF  ShadowFilter = iota // Fixed-radius percentage closer filtering
+	ShadowFilterHard                     // Single sample
+	ShadowFilterPCSS                     // Percentage closer soft shadows (penumbra from blocker distance)
+	ShadowFilterVSM                      // Variance shadow map (blurred moments)
+	ShadowFilterESM                      // Exponential shadow map (blurred exp(c*d))
+)
+
+// ShadowSettings configures how a light's shadow map is biased and filtered
+type ShadowSettings struct {
+	Filter ShadowFilter
+
+	// Acne control
+	Bias         float64 // Constant depth bias in world units
+	SlopeBias    float64 // Extra bias in texels, scaled by tan(angle to light)
+	NormalOffset float64 // Receiver offset along the normal in texels, grows at grazing angles
+
+	// PCF
+	PCFRadius int // Kernel radius in texels
+
+	// PCSS
+	LightSize           float64 // Light source size in world units
+	BlockerSearchRadius int     // Texels searched for occluders
+	MaxPenumbra         int     // Upper bound on the filter radius in texels
+
+	// VSM / ESM
+	BlurRadius          int     // Box blur radius applied to the moments
+	ESMExponent         float64 // Sharpness of the exponential falloff
+	LightBleedReduction float64 // VSM: cuts the tail of the Chebyshev bound (0-1)
+	MinVariance         float64 // VSM: clamps variance to avoid acne on flat receivers
+}
+
+// DefaultShadowSettings returns PCF shadows with slope-scaled bias and normal offset
+func DefaultShadowSettings() ShadowSettings {
+	return ShadowSettings{
+		Filter:              ShadowFilterPCF,
+		Bias:                0.05,
+		SlopeBias:           1.5,
+		NormalOffset:        1.0,
+		PCFRadius:           2,
+		LightSize:           1.0,
+		BlockerSearchRadius: 4,
+		MaxPenumbra:         6,
+		BlurRadius:          2,
+		ESMExponent:         80.0,
+		LightBleedReduction: 0.2,
+		MinVariance:         1e-5,
+	}
+}
+
+// offsetReceiver pushes the receiver along its normal and returns the depth bias.
+// Both terms grow with the angle between the normal and the light so grazing
+// surfaces do not self-shadow, while surfaces facing the light keep tight contact.
+func (s *ShadowSettings) offsetReceiver(worldPos, normal, toLight Point, texelWorld float64) (Point, float64) {
+	bias := s.Bias
+
+	nx, ny, nz := normal.X, normal.Y, normal.Z
+	if nx*nx+ny*ny+nz*nz < 1e-12 {
+		return worldPos, bias
+	}
+	nx, ny, nz = normalizeVector(nx, ny, nz)
+
+	cosTheta := clampFloat(dotProduct(nx, ny, nz, toLight.X, toLight.Y, toLight.Z), 0, 1)
+	sinTheta := math.Sqrt(1.0 - cosTheta*cosTheta)
+	tanTheta := math.Min(sinTheta/math.Max(cosTheta, 1e-3), 10.0)
+
+	bias += s.SlopeBias * texelWorld * tanTheta
+
+	offset := s.NormalOffset * texelWorld * sinTheta
+	return Point{
+		X: worldPos.X + nx*offset,
+		Y: worldPos.Y + ny*offset,
+		Z: worldPos.Z + nz*offset,
+	}, bias
+}
+
+// filterShadow applies the map's filter at texel (x, y) for a receiver depth
+func (sm *ShadowMap) filterShadow(x, y int, depth, bias, texelWorld float64) float64 {
+	switch sm.Settings.Filter {
+	case ShadowFilterHard:
+		return sm.pcf(x, y, depth, bias, 0)
+	case ShadowFilterPCSS:
+		return sm.pcss(x, y, depth, bias, texelWorld)
+	case ShadowFilterVSM:
+		return sm.vsm(x, y, depth, bias)
+	case ShadowFilterESM:
+		return sm.esm(x, y, depth, bias)
+	default:
+		return sm.pcf(x, y, depth, bias, sm.Settings.PCFRadius)
+	}
+}
+
+// pcf returns the fraction of texels in the kernel that do not occlude the receiver
+func (sm *ShadowMap) pcf(x, y int, depth, bias float64, radius int) float64 {
+	lit := 0.0
+	count := 0
+
+	for dy := -radius; dy <= radius; dy++ {
+		sy := y + dy
+		if sy < 0 || sy >= sm.Height {
+			continue
+		}
+		for dx := -radius; dx <= radius; dx++ {
+			sx := x + dx
+			if sx < 0 || sx >= sm.Width {
+				continue
+			}
+			if depth <= sm.DepthBuffer[sy][sx]+bias {
+				lit += 1.0
+			}
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 1.0
+	}
+	return lit / float64(count)
+}
+
+// pcss estimates the penumbra from the average blocker depth, then runs PCF
+// with a kernel sized to match it
+func (sm *ShadowMap) pcss(x, y int, depth, bias, texelWorld float64) float64 {
+	r := sm.Settings.BlockerSearchRadius
+	blockerSum := 0.0
+	blockers := 0
+
+	for dy := -r; dy <= r; dy++ {
+		sy := y + dy
+		if sy < 0 || sy >= sm.Height {
+			continue
+		}
+		for dx := -r; dx <= r; dx++ {
+			sx := x + dx
+			if sx < 0 || sx >= sm.Width {
+				continue
+			}
+			if d := sm.DepthBuffer[sy][sx]; d+bias < depth {
+				blockerSum += d
+				blockers++
+			}
+		}
+	}
+
+	if blockers == 0 {
+		return 1.0
+	}
+
+	blockerDepth := math.Max(blockerSum/float64(blockers), sm.Near)
+	penumbra := sm.Settings.LightSize * (depth - blockerDepth) / blockerDepth
+
+	radius := 1
+	if texelWorld > 0 {
+		radius = int(math.Ceil(penumbra / texelWorld))
+	}
+	radius = clampInt(radius, 1, maxInt(sm.Settings.MaxPenumbra, 1))
+
+	return sm.pcf(x, y, depth, bias, radius)
+}
+
+// normalizedDepth maps a linear depth into [0, 1] over the light range
+func (sm *ShadowMap) normalizedDepth(depth float64) float64 {
+	if math.IsInf(depth, 1) || sm.Far <= 0 {
+		return 1.0
+	}
+	return clampFloat(depth/sm.Far, 0, 1)
+}
+
+// vsm bounds the lit fraction with Chebyshev's inequality
+func (sm *ShadowMap) vsm(x, y int, depth, bias float64) float64 {
+	if sm.Moments1 == nil {
+		return sm.pcf(x, y, depth, bias, 0)
+	}
+
+	d := sm.normalizedDepth(depth - bias)
+	mean := sm.Moments1[y][x]
+	if d <= mean {
+		return 1.0
+	}
+
+	variance := math.Max(sm.Moments2[y][x]-mean*mean, sm.Settings.MinVariance)
+	diff := d - mean
+	pMax := variance / (variance + diff*diff)
+
+	// Light bleeding reduction: remap [amount, 1] to [0, 1]
+	amount := clampFloat(sm.Settings.LightBleedReduction, 0, 0.99)
+	return clampFloat((pMax-amount)/(1.0-amount), 0, 1)
+}
+
+// esm evaluates exp(-c*d) against the blurred exp(c*occluder)
+func (sm *ShadowMap) esm(x, y int, depth, bias float64) float64 {
+	if sm.Moments1 == nil {
+		return sm.pcf(x, y, depth, bias, 0)
+	}
+
+	d := sm.normalizedDepth(depth - bias)
+	return clampFloat(sm.Moments1[y][x]*math.Exp(-sm.Settings.ESMExponent*d), 0, 1)
+}
+
+// PrepareFilter builds and blurs the moment maps when the filter needs them
+func (sm *ShadowMap) PrepareFilter() {
+	filter := sm.Settings.Filter
+	if filter != ShadowFilterVSM && filter != ShadowFilterESM {
+		sm.Moments1 = nil
+		sm.Moments2 = nil
+		return
+	}
+
+	if len(sm.Moments1) != sm.Height {
+		sm.Moments1 = make([][]float64, sm.Height)
+		sm.Moments2 = make([][]float64, sm.Height)
+		for i := range sm.Moments1 {
+			sm.Moments1[i] = make([]float64, sm.Width)
+			sm.Moments2[i] = make([]float64, sm.Width)
+		}
+	}
+
+	for y := 0; y < sm.Height; y++ {
+		for x := 0; x < sm.Width; x++ {
+			d := sm.normalizedDepth(sm.DepthBuffer[y][x])
+			if filter == ShadowFilterESM {
+				sm.Moments1[y][x] = math.Exp(sm.Settings.ESMExponent * d)
+			} else {
+				sm.Moments1[y][x] = d
+				sm.Moments2[y][x] = d * d
+			}
+		}
+	}
+
+	boxBlur(sm.Moments1, sm.Settings.BlurRadius)
+	if filter == ShadowFilterVSM {
+		boxBlur(sm.Moments2, sm.Settings.BlurRadius)
+	}
+}
+
+// boxBlur applies a separable box blur in place (edges are clamped).
+// Each tap is summed directly: ESM values span many orders of magnitude and
+// a running sum would lose the small ones to cancellation.
+func boxBlur(data [][]float64, radius int) {
+	if radius <= 0 || len(data) == 0 {
+		return
+	}
+
+	height := len(data)
+	width := len(data[0])
+	window := float64(2*radius + 1)
+	line := make([]float64, maxInt(width, height))
+
+	// Horizontal pass
+	for y := 0; y < height; y++ {
+		row := data[y]
+		for x := 0; x < width; x++ {
+			sum := 0.0
+			for k := -radius; k <= radius; k++ {
+				sum += row[clampInt(x+k, 0, width-1)]
+			}
+			line[x] = sum / window
+		}
+		copy(row, line[:width])
+	}
+
+	// Vertical pass
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			sum := 0.0
+			for k := -radius; k <= radius; k++ {
+				sum += data[clampInt(y+k, 0, height-1)][x]
+			}
+			line[y] = sum / window
+		}
+		for y := 0; y < height; y++ {
+			data[y][x] = line[y]
+		}
+	}
+}
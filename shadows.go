@@ -4,14 +4,21 @@ import "math"
 
 // ShadowMap represents a depth buffer for shadow mapping
 type ShadowMap struct {
-	Width       int
-	Height      int
-	DepthBuffer [][]float64
-	LightMatrix Matrix4x4
-	LightPos    Point
-	Resolution  int
-	Bias        float64
-	PCFSamples  int
+	Width          int
+	Height         int
+	DepthBuffer    [][]float64 // Linear depth along the light direction (world units)
+	LightMatrix    Matrix4x4
+	LightPos       Point
+	LightDir       Point // Normalized direction the map looks along
+	Resolution     int
+	Near           float64
+	Far            float64
+	TexelWorldSize float64        // World-space footprint of one texel (orthographic maps)
+	Settings       ShadowSettings // Copied from the light when the view is set up
+
+	// Prefiltered moments for VSM/ESM (built by PrepareFilter)
+	Moments1 [][]float64
+	Moments2 [][]float64
 }
 
 // NewShadowMap creates a new shadow map
@@ -19,19 +26,25 @@ func NewShadowMap(resolution int) *ShadowMap {
 	depthBuffer := make([][]float64, resolution)
 	for i := range depthBuffer {
 		depthBuffer[i] = make([]float64, resolution)
-		for j := range depthBuffer[i] {
-			depthBuffer[i][j] = math.Inf(1)
-		}
 	}
+	return newShadowMapWithBuffer(depthBuffer)
+}
 
-	return &ShadowMap{
+// newShadowMapWithBuffer creates a square shadow map over existing depth rows
+// (used to place maps inside a ShadowAtlas)
+func newShadowMapWithBuffer(depthBuffer [][]float64) *ShadowMap {
+	resolution := len(depthBuffer)
+	sm := &ShadowMap{
 		Width:       resolution,
 		Height:      resolution,
 		DepthBuffer: depthBuffer,
 		Resolution:  resolution,
-		Bias:        0.005,
-		PCFSamples:  2,
+		Near:        0.1,
+		Far:         100.0,
+		Settings:    DefaultShadowSettings(),
 	}
+	sm.Clear()
+	return sm
 }
 
 // Clear clears the shadow map
@@ -60,18 +73,24 @@ func (sm *ShadowMap) SetupLightView(light *Light, target Point, near, far float6
 		up = Point{X: 0, Y: 0, Z: 1}
 	}
 	viewMatrix := CreateLookAtMatrix(light.Position, target, up)
-	
+
 	// Create orthographic projection for directional lights
 	// Size of the shadow map frustum (adjust based on scene size)
 	size := 40.0 // Increased to cover more area
 	projMatrix := CreateOrthographicMatrix(-size, size, -size, size, near, far)
-	
+
 	// Combine view and projection matrices
 	sm.LightMatrix = projMatrix.Multiply(viewMatrix)
 	sm.LightPos = light.Position
+	sm.LightDir = lightDir
+	sm.Near = near
+	sm.Far = far
+	sm.TexelWorldSize = 2.0 * size / float64(sm.Width)
+	sm.Settings = light.Shadow
 }
 
-// ProjectToShadowMap projects a world point to shadow map coordinates
+// ProjectToShadowMap projects a world point to shadow map coordinates.
+// The returned depth is the linear distance along the light direction.
 func (sm *ShadowMap) ProjectToShadowMap(worldPos Point) (x, y int, depth float64, valid bool) {
 	// Transform to light space
 	transformed := sm.LightMatrix.MultiplyPoint(worldPos)
@@ -79,7 +98,8 @@ func (sm *ShadowMap) ProjectToShadowMap(worldPos Point) (x, y int, depth float64
 	// Convert to shadow map coordinates
 	x = int((transformed.X + 1.0) * float64(sm.Width) * 0.5)
 	y = int((transformed.Y + 1.0) * float64(sm.Height) * 0.5)
-	depth = transformed.Z
+	depth = dotProduct(worldPos.X-sm.LightPos.X, worldPos.Y-sm.LightPos.Y, worldPos.Z-sm.LightPos.Z,
+		sm.LightDir.X, sm.LightDir.Y, sm.LightDir.Z)
 
 	// Check bounds
 	if x < 0 || x >= sm.Width || y < 0 || y >= sm.Height {
@@ -114,49 +134,257 @@ func (sm *ShadowMap) IsInShadow(worldPos Point) bool {
 	}
 
 	shadowDepth := sm.SampleDepth(x, y)
-	return depth > shadowDepth+sm.Bias
+	return depth > shadowDepth+sm.Settings.Bias
 }
 
 // CalculateShadow calculates shadow factor (0 = full shadow, 1 = no shadow)
 func (sm *ShadowMap) CalculateShadow(worldPos Point) float64 {
-	x, y, depth, valid := sm.ProjectToShadowMap(worldPos)
+	return sm.CalculateShadowWithNormal(worldPos, Point{})
+}
+
+// CalculateShadowWithNormal calculates the shadow factor using the receiver
+// normal for slope-scaled bias and normal offset (zero normal disables both)
+func (sm *ShadowMap) CalculateShadowWithNormal(worldPos, normal Point) float64 {
+	toLight := Point{X: -sm.LightDir.X, Y: -sm.LightDir.Y, Z: -sm.LightDir.Z}
+	pos, bias := sm.Settings.offsetReceiver(worldPos, normal, toLight, sm.TexelWorldSize)
+
+	x, y, depth, valid := sm.ProjectToShadowMap(pos)
 	if !valid {
 		return 1.0 // Outside shadow map = no shadow
 	}
 
-	// PCF (Percentage Closer Filtering)
-	if sm.PCFSamples <= 1 {
-		// Simple shadow test
-		shadowDepth := sm.SampleDepth(x, y)
-		if depth > shadowDepth+sm.Bias {
-			return 0.0 // In shadow
+	return sm.filterShadow(x, y, depth, bias, sm.TexelWorldSize)
+}
+
+// ============================================================================
+// CUBE SHADOW MAPS (POINT LIGHTS)
+// ============================================================================
+
+// Cube face order follows the OpenGL convention: +X, -X, +Y, -Y, +Z, -Z
+var cubeFaceDirections = [6]Point{
+	{X: 1, Y: 0, Z: 0},
+	{X: -1, Y: 0, Z: 0},
+	{X: 0, Y: 1, Z: 0},
+	{X: 0, Y: -1, Z: 0},
+	{X: 0, Y: 0, Z: 1},
+	{X: 0, Y: 0, Z: -1},
+}
+
+var cubeFaceUps = [6]Point{
+	{X: 0, Y: -1, Z: 0},
+	{X: 0, Y: -1, Z: 0},
+	{X: 0, Y: 0, Z: 1},
+	{X: 0, Y: 0, Z: -1},
+	{X: 0, Y: -1, Z: 0},
+	{X: 0, Y: -1, Z: 0},
+}
+
+// CubeShadowMap stores six 90 degree depth maps around a point light.
+// Each face stores linear view depth along its axis.
+type CubeShadowMap struct {
+	Faces      [6]*ShadowMap
+	FaceViews  [6]Matrix4x4 // World to face view space
+	LightPos   Point
+	Near       float64
+	Far        float64
+	Resolution int
+	Settings   ShadowSettings // Copied from the light when the views are set up
+}
+
+// NewCubeShadowMap creates a cube shadow map with square faces
+func NewCubeShadowMap(resolution int) *CubeShadowMap {
+	var faces [6]*ShadowMap
+	for i := range faces {
+		faces[i] = NewShadowMap(resolution)
+	}
+	return newCubeShadowMapWithFaces(faces)
+}
+
+// newCubeShadowMapWithFaces creates a cube shadow map from six existing faces
+func newCubeShadowMapWithFaces(faces [6]*ShadowMap) *CubeShadowMap {
+	return &CubeShadowMap{
+		Faces:      faces,
+		Near:       0.1,
+		Far:        100.0,
+		Resolution: faces[0].Width,
+		Settings:   DefaultShadowSettings(),
+	}
+}
+
+// Clear clears all six faces
+func (cm *CubeShadowMap) Clear() {
+	for _, face := range cm.Faces {
+		face.Clear()
+	}
+}
+
+// SetupLightViews builds the six face views around the light position
+func (cm *CubeShadowMap) SetupLightViews(light *Light, near, far float64) {
+	cm.LightPos = light.Position
+	cm.Near = near
+	cm.Far = far
+	cm.Settings = light.Shadow
+
+	proj := CreatePerspectiveMatrix(math.Pi/2, 1.0, near, far)
+	for i := range cm.Faces {
+		target := Point{
+			X: light.Position.X + cubeFaceDirections[i].X,
+			Y: light.Position.Y + cubeFaceDirections[i].Y,
+			Z: light.Position.Z + cubeFaceDirections[i].Z,
 		}
-		return 1.0 // Not in shadow
+		cm.FaceViews[i] = CreateLookAtMatrix(light.Position, target, cubeFaceUps[i])
+		cm.Faces[i].LightMatrix = proj.Multiply(cm.FaceViews[i])
+		cm.Faces[i].LightPos = light.Position
+		cm.Faces[i].LightDir = cubeFaceDirections[i]
+		cm.Faces[i].Near = near
+		cm.Faces[i].Far = far
+		cm.Faces[i].Settings = light.Shadow
 	}
+}
 
-	// PCF sampling
-	shadowFactor := 0.0
-	sampleCount := 0
+// FaceMatrix returns the view-projection matrix for a face (used by GPU backends)
+func (cm *CubeShadowMap) FaceMatrix(face int) Matrix4x4 {
+	return cm.Faces[face].LightMatrix
+}
 
-	for dx := -sm.PCFSamples; dx <= sm.PCFSamples; dx++ {
-		for dy := -sm.PCFSamples; dy <= sm.PCFSamples; dy++ {
-			sx := x + dx
-			sy := y + dy
-			if sx >= 0 && sx < sm.Width && sy >= 0 && sy < sm.Height {
-				shadowDepth := sm.SampleDepth(sx, sy)
-				if depth <= shadowDepth+sm.Bias {
-					shadowFactor += 1.0
-				}
-				sampleCount++
-			}
+// selectCubeFace picks the face whose axis dominates the direction
+func selectCubeFace(dx, dy, dz float64) int {
+	ax, ay, az := math.Abs(dx), math.Abs(dy), math.Abs(dz)
+	if ax >= ay && ax >= az {
+		if dx >= 0 {
+			return 0
+		}
+		return 1
+	}
+	if ay >= az {
+		if dy >= 0 {
+			return 2
 		}
+		return 3
+	}
+	if dz >= 0 {
+		return 4
+	}
+	return 5
+}
+
+// ProjectToCubeMap finds the face, texel and linear depth of a world point
+func (cm *CubeShadowMap) ProjectToCubeMap(worldPos Point) (face, x, y int, depth float64, valid bool) {
+	face = selectCubeFace(worldPos.X-cm.LightPos.X, worldPos.Y-cm.LightPos.Y, worldPos.Z-cm.LightPos.Z)
+	view := cm.FaceViews[face].TransformPoint(worldPos)
+	depth = -view.Z
+	if depth < cm.Near || depth > cm.Far {
+		return face, 0, 0, depth, false
+	}
+
+	fm := cm.Faces[face]
+	x = clampInt(int((view.X/depth+1.0)*0.5*float64(fm.Width)), 0, fm.Width-1)
+	y = clampInt(int((view.Y/depth+1.0)*0.5*float64(fm.Height)), 0, fm.Height-1)
+	return face, x, y, depth, true
+}
+
+// IsInShadow checks if a point is in shadow (simple test)
+func (cm *CubeShadowMap) IsInShadow(worldPos Point) bool {
+	face, x, y, depth, valid := cm.ProjectToCubeMap(worldPos)
+	if !valid {
+		return false
 	}
+	return depth > cm.Faces[face].SampleDepth(x, y)+cm.Settings.Bias
+}
+
+// CalculateShadow calculates shadow factor (0 = full shadow, 1 = no shadow)
+func (cm *CubeShadowMap) CalculateShadow(worldPos Point) float64 {
+	return cm.CalculateShadowWithNormal(worldPos, Point{})
+}
 
-	if sampleCount == 0 {
+// CalculateShadowWithNormal calculates the shadow factor using the receiver
+// normal for slope-scaled bias and normal offset (zero normal disables both)
+func (cm *CubeShadowMap) CalculateShadowWithNormal(worldPos, normal Point) float64 {
+	dx := cm.LightPos.X - worldPos.X
+	dy := cm.LightPos.Y - worldPos.Y
+	dz := cm.LightPos.Z - worldPos.Z
+	dist := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if dist < 1e-9 {
+		return 1.0
+	}
+
+	// A 90 degree face spans 2*depth world units across its resolution
+	texelWorld := 2.0 * dist / float64(cm.Resolution)
+	toLight := Point{X: dx / dist, Y: dy / dist, Z: dz / dist}
+	pos, bias := cm.Settings.offsetReceiver(worldPos, normal, toLight, texelWorld)
+
+	face, x, y, depth, valid := cm.ProjectToCubeMap(pos)
+	if !valid {
 		return 1.0
 	}
 
-	return shadowFactor / float64(sampleCount)
+	return cm.Faces[face].filterShadow(x, y, depth, bias, 2.0*depth/float64(cm.Resolution))
+}
+
+// PrepareFilter builds the prefiltered maps each face needs for its filter
+func (cm *CubeShadowMap) PrepareFilter() {
+	for _, face := range cm.Faces {
+		face.PrepareFilter()
+	}
+}
+
+// RasterizeTriangle writes a world-space triangle into every face it touches
+func (cm *CubeShadowMap) RasterizeTriangle(v0, v1, v2 Point) {
+	for i := range cm.Faces {
+		a := cm.FaceViews[i].TransformPoint(v0)
+		b := cm.FaceViews[i].TransformPoint(v1)
+		c := cm.FaceViews[i].TransformPoint(v2)
+
+		// View space looks down -Z; convert to positive depth
+		a.Z, b.Z, c.Z = -a.Z, -b.Z, -c.Z
+
+		// Reject triangles fully outside one side of the 90 degree frustum
+		if (a.X > a.Z && b.X > b.Z && c.X > c.Z) || (a.X < -a.Z && b.X < -b.Z && c.X < -c.Z) ||
+			(a.Y > a.Z && b.Y > b.Z && c.Y > c.Z) || (a.Y < -a.Z && b.Y < -b.Z && c.Y < -c.Z) {
+			continue
+		}
+
+		poly := clipPolygonNear([]Point{a, b, c}, cm.Near)
+		for j := 1; j+1 < len(poly); j++ {
+			rasterizeCubeFaceTriangle(cm.Faces[i], poly[0], poly[j], poly[j+1])
+		}
+	}
+}
+
+// clipPolygonNear clips a view-space polygon (positive Z depth) against the near plane
+func clipPolygonNear(poly []Point, near float64) []Point {
+	out := make([]Point, 0, len(poly)+1)
+	for i := range poly {
+		cur := poly[i]
+		next := poly[(i+1)%len(poly)]
+		curIn := cur.Z >= near
+		nextIn := next.Z >= near
+
+		if curIn {
+			out = append(out, cur)
+		}
+		if curIn != nextIn {
+			t := (near - cur.Z) / (next.Z - cur.Z)
+			out = append(out, Point{
+				X: cur.X + (next.X-cur.X)*t,
+				Y: cur.Y + (next.Y-cur.Y)*t,
+				Z: near,
+			})
+		}
+	}
+	return out
+}
+
+// rasterizeCubeFaceTriangle rasterizes a near-clipped view-space triangle,
+// interpolating 1/depth so the stored depth is perspective correct
+func rasterizeCubeFaceTriangle(face *ShadowMap, a, b, c Point) {
+	w := float64(face.Width)
+	h := float64(face.Height)
+
+	toTexels := func(p Point) Point {
+		return Point{X: (p.X/p.Z + 1.0) * 0.5 * w, Y: (p.Y/p.Z + 1.0) * 0.5 * h, Z: 1.0 / p.Z}
+	}
+	fillDepthTriangle(face, toTexels(a), toTexels(b), toTexels(c), true)
 }
 
 // ShadowRenderer interface for rendering shadows
@@ -166,15 +394,211 @@ type ShadowRenderer interface {
 
 // SimpleShadowRenderer implements basic shadow mapping
 type SimpleShadowRenderer struct {
-	ShadowMaps map[*Light]*ShadowMap
-	Resolution int
+	ShadowMaps     map[*Light]*ShadowMap
+	CubeShadowMaps map[*Light]*CubeShadowMap
+	Resolution     int
+	CubeResolution int          // Per-face resolution for point lights
+	Atlas          *ShadowAtlas // Shared depth storage for RenderShadows (nil = one buffer per map)
 }
 
 // NewSimpleShadowRenderer creates a shadow renderer
 func NewSimpleShadowRenderer(resolution int) *SimpleShadowRenderer {
 	return &SimpleShadowRenderer{
-		ShadowMaps: make(map[*Light]*ShadowMap),
-		Resolution: resolution,
+		ShadowMaps:     make(map[*Light]*ShadowMap),
+		CubeShadowMaps: make(map[*Light]*CubeShadowMap),
+		Resolution:     resolution,
+		CubeResolution: maxInt(resolution/2, 1),
+	}
+}
+
+// RenderLightShadows renders the shadow map matching the light type
+func (sr *SimpleShadowRenderer) RenderLightShadows(light *Light, scene *Scene) {
+	if light.Type == LightTypePoint {
+		sr.RenderCubeShadowMap(light, scene)
+	} else {
+		sr.RenderShadowMap(light, scene)
+	}
+}
+
+// RenderShadows renders the shadow maps of every enabled light that casts
+// shadows. With an atlas the lights share its memory budget: tiles go to the
+// lights with the most influence on the camera's view, and lights that get no
+// tile (or light nothing visible) are left unshadowed for the frame.
+func (sr *SimpleShadowRenderer) RenderShadows(lights []*Light, scene *Scene, camera *Camera) {
+	casters := make(map[*Light]bool)
+	for _, light := range lights {
+		if light.IsEnabled && light.CastShadows {
+			casters[light] = true
+		}
+	}
+	sr.releaseShadowMaps(casters)
+
+	if sr.Atlas == nil {
+		for _, light := range lights {
+			if casters[light] {
+				sr.RenderLightShadows(light, scene)
+			}
+		}
+		return
+	}
+
+	requests := make([]ShadowRequest, 0, len(casters))
+	for _, light := range lights {
+		if !casters[light] {
+			continue
+		}
+		priority := LightScreenInfluence(light, scene, camera)
+		if priority <= 0 {
+			continue
+		}
+		request := ShadowRequest{Light: light, Priority: priority, Size: sr.Resolution, Faces: 1}
+		if light.Type == LightTypePoint {
+			request.Size = sr.CubeResolution
+			request.Faces = 6
+		}
+		requests = append(requests, request)
+	}
+
+	tiles := sr.Atlas.Allocate(requests)
+	for _, light := range lights {
+		if !casters[light] {
+			continue
+		}
+		lightTiles, ok := tiles[light]
+		if !ok {
+			delete(sr.ShadowMaps, light)
+			delete(sr.CubeShadowMaps, light)
+			continue
+		}
+		sr.bindAtlasTiles(light, lightTiles)
+		sr.RenderLightShadows(light, scene)
+	}
+}
+
+// releaseShadowMaps drops the maps of lights that no longer cast shadows
+func (sr *SimpleShadowRenderer) releaseShadowMaps(casters map[*Light]bool) {
+	for light := range sr.ShadowMaps {
+		if !casters[light] {
+			delete(sr.ShadowMaps, light)
+		}
+	}
+	for light := range sr.CubeShadowMaps {
+		if !casters[light] {
+			delete(sr.CubeShadowMaps, light)
+		}
+	}
+}
+
+// bindAtlasTiles points a light's shadow map at its atlas tiles, keeping the
+// existing map (and its filter buffers) when the tile size is unchanged
+func (sr *SimpleShadowRenderer) bindAtlasTiles(light *Light, tiles []AtlasTile) {
+	if light.Type == LightTypePoint {
+		cubeMap := sr.CubeShadowMaps[light]
+		if cubeMap == nil || cubeMap.Resolution != tiles[0].Size {
+			var faces [6]*ShadowMap
+			for i := range faces {
+				faces[i] = newShadowMapWithBuffer(sr.Atlas.View(tiles[i]))
+			}
+			sr.CubeShadowMaps[light] = newCubeShadowMapWithFaces(faces)
+			return
+		}
+		for i, face := range cubeMap.Faces {
+			face.DepthBuffer = sr.Atlas.View(tiles[i])
+		}
+		return
+	}
+
+	shadowMap := sr.ShadowMaps[light]
+	if shadowMap == nil || shadowMap.Width != tiles[0].Size {
+		sr.ShadowMaps[light] = newShadowMapWithBuffer(sr.Atlas.View(tiles[0]))
+		return
+	}
+	shadowMap.DepthBuffer = sr.Atlas.View(tiles[0])
+}
+
+// ShadowFactor returns the shadow factor of a world point for a light
+// (0 = full shadow, 1 = no shadow). The surface normal drives the slope bias
+// and normal offset; pass a zero normal when it is unknown.
+func (sr *SimpleShadowRenderer) ShadowFactor(light *Light, worldPos, normal Point) float64 {
+	if !light.CastShadows {
+		return 1.0
+	}
+	if light.Type == LightTypePoint {
+		if cm := sr.CubeShadowMaps[light]; cm != nil {
+			return cm.CalculateShadowWithNormal(worldPos, normal)
+		}
+		return 1.0
+	}
+	if sm := sr.ShadowMaps[light]; sm != nil {
+		return sm.CalculateShadowWithNormal(worldPos, normal)
+	}
+	return 1.0
+}
+
+// RenderCubeShadowMap renders all six faces of a point light's shadow map
+func (sr *SimpleShadowRenderer) RenderCubeShadowMap(light *Light, scene *Scene) *CubeShadowMap {
+	cubeMap, exists := sr.CubeShadowMaps[light]
+	if !exists {
+		cubeMap = NewCubeShadowMap(sr.CubeResolution)
+		sr.CubeShadowMaps[light] = cubeMap
+	}
+
+	cubeMap.Clear()
+	cubeMap.SetupLightViews(light, 0.1, pointShadowFar(light, scene))
+
+	for _, node := range scene.GetRenderableNodes() {
+		forEachShadowTriangle(node, cubeMap.RasterizeTriangle)
+	}
+	cubeMap.PrepareFilter()
+
+	return cubeMap
+}
+
+// pointShadowFar returns the far plane of a point light's cube shadow map:
+// the light's Range when set, otherwise the distance to the farthest corner
+// of any renderable node's bounds, so no receiver falls past it. Small
+// scenes keep a far plane of 100, for receivers outside the scene.
+func pointShadowFar(light *Light, scene *Scene) float64 {
+	if light.Range > 0 {
+		return light.Range
+	}
+	far := 100.0
+	for _, node := range scene.GetRenderableNodes() {
+		bounds := scene.computeNodeBounds(node)
+		if bounds == nil {
+			continue
+		}
+		corner := Point{
+			X: math.Max(math.Abs(bounds.Min.X-light.Position.X), math.Abs(bounds.Max.X-light.Position.X)),
+			Y: math.Max(math.Abs(bounds.Min.Y-light.Position.Y), math.Abs(bounds.Max.Y-light.Position.Y)),
+			Z: math.Max(math.Abs(bounds.Min.Z-light.Position.Z), math.Abs(bounds.Max.Z-light.Position.Z)),
+		}
+		far = math.Max(far, pointLength(corner))
+	}
+	return far
+}
+
+// forEachShadowTriangle visits the world-space triangles of a shadow caster
+func forEachShadowTriangle(node *SceneNode, fn func(v0, v1, v2 Point)) {
+	var chunks []*Mesh
+	switch obj := node.TransformSceneObject().(type) {
+	case *Mesh:
+		for i := 0; i+2 < len(obj.Indices); i += 3 {
+			fn(obj.Vertices[obj.Indices[i]], obj.Vertices[obj.Indices[i+1]], obj.Vertices[obj.Indices[i+2]])
+		}
+	case *Triangle:
+		fn(obj.P0, obj.P1, obj.P2)
+	case MeshProvider:
+		chunks = obj.VisibleMeshes()
+	}
+
+	worldMatrix := node.Transform.GetWorldMatrix()
+	for _, chunk := range chunks {
+		for i := 0; i+2 < len(chunk.Indices); i += 3 {
+			fn(worldMatrix.TransformPoint(chunk.Vertices[chunk.Indices[i]]),
+				worldMatrix.TransformPoint(chunk.Vertices[chunk.Indices[i+1]]),
+				worldMatrix.TransformPoint(chunk.Vertices[chunk.Indices[i+2]]))
+		}
 	}
 }
 
@@ -199,115 +623,90 @@ func (sr *SimpleShadowRenderer) RenderShadowMap(light *Light, scene *Scene) *Sha
 		// Transform object and rasterize to shadow map
 		sr.renderNodeToShadowMap(node, shadowMap)
 	}
+	shadowMap.PrepareFilter()
 
 	return shadowMap
 }
 
 // renderNodeToShadowMap renders a node to the shadow map
 func (sr *SimpleShadowRenderer) renderNodeToShadowMap(node *SceneNode, shadowMap *ShadowMap) {
-	transformed := node.TransformSceneObject()
-
-	switch obj := transformed.(type) {
-	case *Mesh:
-		// Rasterize mesh triangles to shadow map
-		for i := 0; i < len(obj.Indices); i += 3 {
-			if i+2 >= len(obj.Indices) {
-				break
-			}
-
-			v0 := obj.Vertices[obj.Indices[i]]
-			v1 := obj.Vertices[obj.Indices[i+1]]
-			v2 := obj.Vertices[obj.Indices[i+2]]
-
-			// Project triangle vertices
-			x0, y0, z0, valid0 := shadowMap.ProjectToShadowMap(v0)
-			x1, y1, z1, valid1 := shadowMap.ProjectToShadowMap(v1)
-			x2, y2, z2, valid2 := shadowMap.ProjectToShadowMap(v2)
-
-			if !valid0 || !valid1 || !valid2 {
-				continue
-			}
-
-			// Rasterize triangle to depth buffer
-			sr.rasterizeDepthTriangle(shadowMap, x0, y0, z0, x1, y1, z1, x2, y2, z2)
+	w := float64(shadowMap.Width)
+	h := float64(shadowMap.Height)
+
+	forEachShadowTriangle(node, func(v0, v1, v2 Point) {
+		// Project triangle vertices (kept sub-texel to avoid snapping acne)
+		p0 := shadowMap.projectToTexels(v0)
+		p1 := shadowMap.projectToTexels(v1)
+		p2 := shadowMap.projectToTexels(v2)
+
+		// Skip triangles entirely outside the map
+		if (p0.X < 0 && p1.X < 0 && p2.X < 0) || (p0.X > w && p1.X > w && p2.X > w) ||
+			(p0.Y < 0 && p1.Y < 0 && p2.Y < 0) || (p0.Y > h && p1.Y > h && p2.Y > h) {
+			return
 		}
 
-	case *Triangle:
-		// Rasterize single triangle
-		x0, y0, z0, valid0 := shadowMap.ProjectToShadowMap(obj.P0)
-		x1, y1, z1, valid1 := shadowMap.ProjectToShadowMap(obj.P1)
-		x2, y2, z2, valid2 := shadowMap.ProjectToShadowMap(obj.P2)
+		// Rasterize triangle to depth buffer
+		sr.rasterizeDepthTriangle(shadowMap, p0, p1, p2)
+	})
+}
 
-		if valid0 && valid1 && valid2 {
-			sr.rasterizeDepthTriangle(shadowMap, x0, y0, z0, x1, y1, z1, x2, y2, z2)
-		}
+// projectToTexels returns continuous texel coordinates in X/Y and linear depth in Z
+func (sm *ShadowMap) projectToTexels(worldPos Point) Point {
+	transformed := sm.LightMatrix.MultiplyPoint(worldPos)
+	return Point{
+		X: (transformed.X + 1.0) * float64(sm.Width) * 0.5,
+		Y: (transformed.Y + 1.0) * float64(sm.Height) * 0.5,
+		Z: dotProduct(worldPos.X-sm.LightPos.X, worldPos.Y-sm.LightPos.Y, worldPos.Z-sm.LightPos.Z,
+			sm.LightDir.X, sm.LightDir.Y, sm.LightDir.Z),
 	}
 }
 
-// rasterizeDepthTriangle rasterizes a triangle to the depth buffer
-func (sr *SimpleShadowRenderer) rasterizeDepthTriangle(
-	shadowMap *ShadowMap,
-	x0, y0 int, z0 float64,
-	x1, y1 int, z1 float64,
-	x2, y2 int, z2 float64,
-) {
-	// Find bounding box
-	minX := minInt(minInt(x0, x1), x2)
-	maxX := maxInt(maxInt(x0, x1), x2)
-	minY := minInt(minInt(y0, y1), y2)
-	maxY := maxInt(maxInt(y0, y1), y2)
+// rasterizeDepthTriangle rasterizes a triangle to the depth buffer.
+// Vertices are in texel space with linear depth in Z (orthographic, so depth
+// interpolates linearly across the screen).
+func (sr *SimpleShadowRenderer) rasterizeDepthTriangle(shadowMap *ShadowMap, a, b, c Point) {
+	fillDepthTriangle(shadowMap, a, b, c, false)
+}
+
+// fillDepthTriangle writes the minimum depth of a texel-space triangle into the map.
+// With perspective set, Z holds 1/depth and is inverted after interpolation.
+func fillDepthTriangle(sm *ShadowMap, a, b, c Point, perspective bool) {
+	area := (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+	if math.Abs(area) < 1e-12 {
+		return
+	}
 
-	// Clamp to shadow map bounds
-	minX = maxInt(minX, 0)
-	maxX = minInt(maxX, shadowMap.Width-1)
-	minY = maxInt(minY, 0)
-	maxY = minInt(maxY, shadowMap.Height-1)
+	// Find bounding box, clamped to shadow map bounds
+	minX := maxInt(int(math.Floor(math.Min(a.X, math.Min(b.X, c.X)))), 0)
+	maxX := minInt(int(math.Ceil(math.Max(a.X, math.Max(b.X, c.X)))), sm.Width-1)
+	minY := maxInt(int(math.Floor(math.Min(a.Y, math.Min(b.Y, c.Y)))), 0)
+	maxY := minInt(int(math.Ceil(math.Max(a.Y, math.Max(b.Y, c.Y)))), sm.Height-1)
 
-	// Rasterize
 	for y := minY; y <= maxY; y++ {
+		py := float64(y) + 0.5
 		for x := minX; x <= maxX; x++ {
-			// Calculate barycentric coordinates
-			w0, w1, w2 := calculateBarycentricInt(x, y, x0, y0, x1, y1, x2, y2)
+			px := float64(x) + 0.5
 
-			// Check if point is inside triangle
-			if w0 >= 0 && w1 >= 0 && w2 >= 0 {
-				// Interpolate depth
-				depth := w0*z0 + w1*z1 + w2*z2
+			// Barycentric coordinates at the texel center
+			w0 := ((b.X-px)*(c.Y-py) - (b.Y-py)*(c.X-px)) / area
+			w1 := ((c.X-px)*(a.Y-py) - (c.Y-py)*(a.X-px)) / area
+			w2 := 1.0 - w0 - w1
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
 
-				// Write to depth buffer
-				shadowMap.WriteDepth(x, y, depth)
+			depth := w0*a.Z + w1*b.Z + w2*c.Z
+			if perspective {
+				if depth <= 0 {
+					continue
+				}
+				depth = 1.0 / depth
 			}
+			sm.WriteDepth(x, y, depth)
 		}
 	}
 }
 
-// calculateBarycentricInt calculates barycentric coordinates for integer points
-func calculateBarycentricInt(px, py, x0, y0, x1, y1, x2, y2 int) (float64, float64, float64) {
-	v0x := float64(x1 - x0)
-	v0y := float64(y1 - y0)
-	v1x := float64(x2 - x0)
-	v1y := float64(y2 - y0)
-	v2x := float64(px - x0)
-	v2y := float64(py - y0)
-
-	d00 := v0x*v0x + v0y*v0y
-	d01 := v0x*v1x + v0y*v1y
-	d11 := v1x*v1x + v1y*v1y
-	d20 := v2x*v0x + v2y*v0y
-	d21 := v2x*v1x + v2y*v1y
-
-	denom := d00*d11 - d01*d01
-	if math.Abs(denom) < 1e-10 {
-		return -1, -1, -1
-	}
-
-	v := (d11*d20 - d01*d21) / denom
-	w := (d00*d21 - d01*d20) / denom
-	u := 1.0 - v - w
-
-	return u, v, w
-}
-
 // CreateLookAtMatrix creates a view matrix looking at a target
 func CreateLookAtMatrix(eye, target, up Point) Matrix4x4 {
 	// Calculate forward vector (from eye to target)
@@ -327,9 +726,10 @@ func CreateLookAtMatrix(eye, target, up Point) Matrix4x4 {
 
 	// Create view matrix
 	mat := Matrix4x4{}
-	mat.M[0], mat.M[1], mat.M[2] = rightX, upX, -forward.X
-	mat.M[4], mat.M[5], mat.M[6] = rightY, upY, -forward.Y
-	mat.M[8], mat.M[9], mat.M[10] = rightZ, upZ, -forward.Z
+	// Rows are the camera basis so TransformPoint yields view space
+	mat.M[0], mat.M[1], mat.M[2] = rightX, rightY, rightZ
+	mat.M[4], mat.M[5], mat.M[6] = upX, upY, upZ
+	mat.M[8], mat.M[9], mat.M[10] = -forward.X, -forward.Y, -forward.Z
 	mat.M[3] = -dotProduct(rightX, rightY, rightZ, eye.X, eye.Y, eye.Z)
 	mat.M[7] = -dotProduct(upX, upY, upZ, eye.X, eye.Y, eye.Z)
 	mat.M[11] = dotProduct(forward.X, forward.Y, forward.Z, eye.X, eye.Y, eye.Z)
@@ -247,6 +247,9 @@ func (ot *Octree) getObjectBounds(node *SceneNode) *AABB {
 		p2 := worldMatrix.TransformPoint(obj.P2)
 		p3 := worldMatrix.TransformPoint(obj.P3)
 		return NewAABBFromPoints([]Point{p0, p1, p2, p3})
+
+	case Bounded:
+		return TransformAABBByMatrix(obj.Bounds(), worldMatrix)
 	}
 	return nil
 }
@@ -513,6 +516,9 @@ func (bvh *BVH) computeObjectBounds(node *SceneNode) *AABB {
 		p2 := worldMatrix.TransformPoint(obj.P2)
 		p3 := worldMatrix.TransformPoint(obj.P3)
 		return NewAABBFromPoints([]Point{p0, p1, p2, p3})
+
+	case Bounded:
+		return TransformAABBByMatrix(obj.Bounds(), worldMatrix)
 	}
 
 	pos := node.Transform.GetWorldPosition()
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// STL LOADER
+// ============================================================================
+// STL (stereolithography) files are a list of triangles, each with a facet
+// normal and three corner positions, as text ("solid" ... "endsolid") or as
+// binary (an 80 byte header, a triangle count and 50 bytes per triangle).
+// There is no sharing between triangles, so corners at the same position
+// are welded on import to give an indexed mesh, and smooth normals are
+// generated with DefaultCreaseAngle so flat faces keep their hard edges.
+// Corners are counterclockwise seen from outside; facet normals are ignored
+// on import, as many writers leave them zero.
+// ============================================================================
+
+// STLFormat selects how SaveSTL encodes a file
+type STLFormat int
+
+const (
+	STLBinary STLFormat = iota // Compact, the usual format for printing
+	STLASCII                   // Readable text
+)
+
+// stlWeldTolerance is how close corners must be to be welded together
+const stlWeldTolerance = 1e-5
+
+const (
+	stlHeaderSize   = 80
+	stlTriangleSize = 50
+)
+
+// LoadSTL loads an ASCII or binary STL file and returns an indexed Mesh
+func LoadSTL(filepath string) (*Mesh, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file: %w", err)
+	}
+
+	var corners []Point
+	if isBinarySTL(data) {
+		corners = readBinarySTL(data)
+	} else if bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		corners, err = readASCIISTL(data)
+	} else if len(data) >= stlHeaderSize+4 {
+		count := binary.LittleEndian.Uint32(data[stlHeaderSize:])
+		return nil, fmt.Errorf("binary STL declares %d triangles but has %d bytes", count, len(data))
+	} else {
+		return nil, fmt.Errorf("not an STL file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(corners) == 0 {
+		return nil, fmt.Errorf("no triangles found in STL file")
+	}
+
+	mesh := NewMesh()
+	mesh.Vertices = corners
+	mesh.Indices = make([]int, len(corners))
+	for i := range mesh.Indices {
+		mesh.Indices[i] = i
+	}
+	mesh.WeldVertices(stlWeldTolerance)
+	mesh.GenerateNormals(DefaultCreaseAngle)
+	return mesh, nil
+}
+
+// isBinarySTL reports whether data is a binary STL file. Some binary files
+// start their header with "solid" too, so the size is what decides.
+func isBinarySTL(data []byte) bool {
+	if len(data) < stlHeaderSize+4 {
+		return false
+	}
+	count := uint64(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+	return uint64(len(data)) == stlHeaderSize+4+count*stlTriangleSize
+}
+
+// readBinarySTL returns the corners of every triangle in a binary file
+func readBinarySTL(data []byte) []Point {
+	count := int(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+	corners := make([]Point, 0, count*3)
+	for t := 0; t < count; t++ {
+		// The facet normal (12 bytes) is skipped; the attribute count
+		// (2 bytes) after the corners is unused
+		record := data[stlHeaderSize+4+t*stlTriangleSize:]
+		for k := 0; k < 3; k++ {
+			at := 12 + k*12
+			corners = append(corners, Point{
+				X: float64(math.Float32frombits(binary.LittleEndian.Uint32(record[at:]))),
+				Y: float64(math.Float32frombits(binary.LittleEndian.Uint32(record[at+4:]))),
+				Z: float64(math.Float32frombits(binary.LittleEndian.Uint32(record[at+8:]))),
+			})
+		}
+	}
+	return corners
+}
+
+// readASCIISTL returns the corners of every triangle in a text file. Facets
+// with more than three vertices are fan-triangulated; several solids in one
+// file are read into one list.
+func readASCIISTL(data []byte) ([]Point, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var corners, loop []Point
+	inLoop := false
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case "solid", "endsolid", "facet", "endfacet":
+			// Names and facet normals are not needed
+		case "outer":
+			if inLoop {
+				return nil, fmt.Errorf("line %d: loop inside a loop", lineNum)
+			}
+			inLoop, loop = true, loop[:0]
+		case "vertex":
+			if !inLoop {
+				return nil, fmt.Errorf("line %d: vertex outside a loop", lineNum)
+			}
+			if len(parts) < 4 {
+				return nil, fmt.Errorf("line %d: invalid vertex definition", lineNum)
+			}
+			x, err1 := strconv.ParseFloat(parts[1], 64)
+			y, err2 := strconv.ParseFloat(parts[2], 64)
+			z, err3 := strconv.ParseFloat(parts[3], 64)
+			if err1 != nil || err2 != nil || err3 != nil {
+				return nil, fmt.Errorf("line %d: invalid vertex coordinates", lineNum)
+			}
+			loop = append(loop, Point{X: x, Y: y, Z: z})
+		case "endloop":
+			if !inLoop || len(loop) < 3 {
+				return nil, fmt.Errorf("line %d: facet needs at least 3 vertices", lineNum)
+			}
+			for i := 1; i+1 < len(loop); i++ {
+				corners = append(corners, loop[0], loop[i], loop[i+1])
+			}
+			inLoop = false
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q", lineNum, parts[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inLoop {
+		return nil, fmt.Errorf("unexpected end of STL file inside a facet")
+	}
+	return corners, nil
+}
+
+// SaveSTL saves a mesh's triangles to an STL file, with facet normals from
+// their winding. Only positions are stored: STL has no normals, UVs or
+// colors per vertex.
+func SaveSTL(mesh *Mesh, filepath string, format STLFormat) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if format == STLASCII {
+		writeASCIISTL(writer, mesh, stlSolidName(filepath))
+	} else {
+		writeBinarySTL(writer, mesh)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// stlSolidName names the solid after the file ("parts/gear.stl" -> "gear")
+func stlSolidName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// writeBinarySTL writes the header, the triangle count and each triangle
+func writeBinarySTL(writer *bufio.Writer, mesh *Mesh) {
+	// The header must not start with "solid", or readers take it for text
+	header := make([]byte, stlHeaderSize)
+	copy(header, "Binary STL generated by Go 3D Graphics Engine")
+	writer.Write(header)
+
+	triCount := len(mesh.Indices) / 3
+	record := make([]byte, stlTriangleSize)
+	binary.LittleEndian.PutUint32(record, uint32(triCount))
+	writer.Write(record[:4])
+
+	put := func(at int, p Point) {
+		binary.LittleEndian.PutUint32(record[at:], math.Float32bits(float32(p.X)))
+		binary.LittleEndian.PutUint32(record[at+4:], math.Float32bits(float32(p.Y)))
+		binary.LittleEndian.PutUint32(record[at+8:], math.Float32bits(float32(p.Z)))
+	}
+	for t := 0; t < triCount; t++ {
+		p0, p1, p2 := mesh.triangleCorners(t)
+		put(0, mesh.faceNormal(t))
+		put(12, p0)
+		put(24, p1)
+		put(36, p2)
+		record[48], record[49] = 0, 0
+		writer.Write(record)
+	}
+}
+
+// writeASCIISTL writes one solid with a facet per triangle
+func writeASCIISTL(writer *bufio.Writer, mesh *Mesh, name string) {
+	writer.WriteString(fmt.Sprintf("solid %s\n", name))
+	for t := 0; t < len(mesh.Indices)/3; t++ {
+		p0, p1, p2 := mesh.triangleCorners(t)
+		n := mesh.faceNormal(t)
+		writer.WriteString(fmt.Sprintf("  facet normal %e %e %e\n", n.X, n.Y, n.Z))
+		writer.WriteString("    outer loop\n")
+		for _, v := range []Point{p0, p1, p2} {
+			writer.WriteString(fmt.Sprintf("      vertex %.9e %.9e %.9e\n", v.X, v.Y, v.Z))
+		}
+		writer.WriteString("    endloop\n")
+		writer.WriteString("  endfacet\n")
+	}
+	writer.WriteString(fmt.Sprintf("endsolid %s\n", name))
+}
@@ -1,10 +1,35 @@
 package main
 
 import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
 	"math"
+	"math/rand"
+	"os"
 	"testing"
 )
 
+// ============================================================================
+// TEST HELPERS
+// ============================================================================
+
+// pointsNear reports whether two points are within eps of each other
+func pointsNear(a, b Point, eps float64) bool {
+	return pointLength(subPoints(a, b)) < eps
+}
+
+// meshVolume returns the signed volume enclosed by a closed mesh
+func meshVolume(mesh *Mesh) float64 {
+	v := 0.0
+	for i := 0; i+2 < len(mesh.Indices); i += 3 {
+		v += dotPoints(mesh.Vertices[mesh.Indices[i]], crossPoints(mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]])) / 6
+	}
+	return v
+}
+
 // ============================================================================
 // BOUNDING VOLUME TESTS
 // ============================================================================
@@ -561,6 +586,37 @@ func TestTransforms(t *testing.T) {
 		}
 		t.Logf("Child world position: (%.1f, %.1f, %.1f)", worldPos.X, worldPos.Y, worldPos.Z)
 	})
+
+	t.Run("ComposeDecompose", func(t *testing.T) {
+		// Scale stretches the object's own axes before they are rotated
+		transform := NewTransform()
+		transform.SetPosition(1, -2, 3)
+		transform.SetRotation(0.4, 1.1, -0.7)
+		transform.SetScale(2, 3, 0.5)
+		m := transform.GetLocalMatrix()
+
+		rotation := transform.Rotation.ToMatrix()
+		for _, axis := range []Point{{X: 1}, {Y: 1}, {Z: 1}} {
+			stretched := Point{X: axis.X * 2, Y: axis.Y * 3, Z: axis.Z * 0.5}
+			want := addPoints(transform.Position, rotation.TransformDirection(stretched))
+			if got := m.TransformPoint(axis); pointLength(subPoints(got, want)) > 1e-9 {
+				t.Errorf("Axis %v should map to %v, got %v", axis, want, got)
+			}
+		}
+
+		pos, rot, scale := DecomposeMatrix(m)
+		if pointLength(subPoints(pos, transform.Position)) > 1e-9 {
+			t.Errorf("Expected position %v, got %v", transform.Position, pos)
+		}
+		if pointLength(subPoints(scale, transform.Scale)) > 1e-9 {
+			t.Errorf("Expected scale %v, got %v", transform.Scale, scale)
+		}
+		// q and -q are the same rotation
+		q := transform.Rotation
+		if dot := rot.W*q.W + rot.X*q.X + rot.Y*q.Y + rot.Z*q.Z; math.Abs(dot) < 1-1e-9 {
+			t.Errorf("Expected rotation %v, got %v", q, rot)
+		}
+	})
 }
 
 // ============================================================================
@@ -741,3 +797,4560 @@ func TestMathUtils(t *testing.T) {
 		}
 	})
 }
+
+// ============================================================================
+// SHADOW TESTS
+// ============================================================================
+
+func TestShadows(t *testing.T) {
+	// Square blocker facing +X at the given distance from the origin
+	newBlocker := func(x float64) *SceneNode {
+		mesh := NewMesh()
+		mesh.AddVertex(x, -2, -2)
+		mesh.AddVertex(x, 2, -2)
+		mesh.AddVertex(x, 2, 2)
+		mesh.AddVertex(x, -2, 2)
+		mesh.AddQuadIndices(0, 1, 2, 3)
+		return NewSceneNodeWithObject("Blocker", mesh)
+	}
+
+	t.Run("CubeShadowAllDirections", func(t *testing.T) {
+		scene := NewScene()
+		scene.AddNode(newBlocker(5))
+		blockerBehind := newBlocker(-5)
+		scene.AddNode(blockerBehind)
+
+		light := NewPointLight(0, 0, 0, ColorWhite, 1.0)
+		sr := NewSimpleShadowRenderer(128)
+		sr.RenderLightShadows(light, scene)
+
+		if sr.ShadowFactor(light, Point{X: 10, Y: 0, Z: 0}, Point{}) > 0.01 {
+			t.Error("Point behind +X blocker should be in shadow")
+		}
+		if sr.ShadowFactor(light, Point{X: -10, Y: 0.5, Z: 0.5}, Point{}) > 0.01 {
+			t.Error("Point behind -X blocker should be in shadow")
+		}
+		if sr.ShadowFactor(light, Point{X: 0, Y: 10, Z: 0}, Point{}) < 0.99 {
+			t.Error("Point above the light should be lit")
+		}
+		if sr.ShadowFactor(light, Point{X: 0, Y: 0, Z: -10}, Point{}) < 0.99 {
+			t.Error("Point beside the light should be lit")
+		}
+		if sr.ShadowFactor(light, Point{X: 4, Y: 0, Z: 0}, Point{}) < 0.99 {
+			t.Error("Point in front of the blocker should be lit")
+		}
+	})
+
+	t.Run("CubeShadowBeyond100", func(t *testing.T) {
+		// The far plane follows the scene bounds, or the light's range
+		for _, lightRange := range []float64{0, 250} {
+			scene := NewScene()
+			caster, receiver := newBlocker(150), newBlocker(200)
+			caster.Transform.SetScale(1, 10, 10)
+			receiver.Transform.SetScale(1, 10, 10)
+			scene.AddNode(caster)
+			scene.AddNode(receiver)
+
+			light := NewPointLight(0, 0, 0, ColorWhite, 1.0)
+			light.Range = lightRange
+			sr := NewSimpleShadowRenderer(128)
+			sr.RenderLightShadows(light, scene)
+
+			if sr.ShadowFactor(light, Point{X: 200}, Point{X: -1}) > 0.01 {
+				t.Errorf("Range %.0f: receiver 200 units away should be shadowed by the caster at 150", lightRange)
+			}
+			if sr.ShadowFactor(light, Point{X: 150}, Point{X: -1}) < 0.99 {
+				t.Errorf("Range %.0f: caster facing the light should be lit", lightRange)
+			}
+		}
+	})
+
+	t.Run("CubeFaceSelection", func(t *testing.T) {
+		cases := []struct {
+			dir  Point
+			face int
+		}{
+			{Point{X: 3, Y: 1, Z: 1}, 0},
+			{Point{X: -3, Y: 1, Z: 1}, 1},
+			{Point{X: 1, Y: 3, Z: 1}, 2},
+			{Point{X: 1, Y: -3, Z: 1}, 3},
+			{Point{X: 1, Y: 1, Z: 3}, 4},
+			{Point{X: 1, Y: 1, Z: -3}, 5},
+		}
+		for _, c := range cases {
+			if face := selectCubeFace(c.dir.X, c.dir.Y, c.dir.Z); face != c.face {
+				t.Errorf("Direction %v should map to face %d, got %d", c.dir, c.face, face)
+			}
+		}
+	})
+
+	t.Run("LookAtMatrix", func(t *testing.T) {
+		eye := Point{X: 0, Y: 10, Z: 0}
+		view := CreateLookAtMatrix(eye, Point{X: 0, Y: 0, Z: 0}, Point{X: 0, Y: 0, Z: 1})
+
+		// Target straight below must land on the -Z axis of view space
+		p := view.TransformPoint(Point{X: 0, Y: 0, Z: 0})
+		if math.Abs(p.X) > 1e-9 || math.Abs(p.Y) > 1e-9 || math.Abs(p.Z+10) > 1e-9 {
+			t.Errorf("Target should map to (0, 0, -10), got (%.2f, %.2f, %.2f)", p.X, p.Y, p.Z)
+		}
+	})
+
+	t.Run("DirectionalLightUsesShadowMap", func(t *testing.T) {
+		scene := NewScene()
+		scene.AddNode(newBlocker(5))
+
+		light := NewLight(20, 0, 0, ColorWhite, 1.0)
+		sr := NewSimpleShadowRenderer(64)
+		sr.RenderLightShadows(light, scene)
+
+		if sr.ShadowMaps[light] == nil {
+			t.Error("Directional light should render a 2D shadow map")
+		}
+		if sr.CubeShadowMaps[light] != nil {
+			t.Error("Directional light should not render a cube shadow map")
+		}
+	})
+
+	// Half-plane blocker facing +X covering y < 0
+	newEdge := func(x float64) *SceneNode {
+		mesh := NewMesh()
+		mesh.AddVertex(x, -20, -20)
+		mesh.AddVertex(x, 0, -20)
+		mesh.AddVertex(x, 0, 20)
+		mesh.AddVertex(x, -20, 20)
+		mesh.AddQuadIndices(0, 1, 2, 3)
+		return NewSceneNodeWithObject("Edge", mesh)
+	}
+
+	t.Run("GrazingReceiverNoAcne", func(t *testing.T) {
+		ground := NewMesh()
+		ground.AddVertex(-30, 0, -30)
+		ground.AddVertex(30, 0, -30)
+		ground.AddVertex(30, 0, 30)
+		ground.AddVertex(-30, 0, 30)
+		ground.AddQuadIndices(0, 1, 2, 3)
+		scene := NewScene()
+		scene.AddNode(NewSceneNodeWithObject("Ground", ground))
+
+		filters := []ShadowFilter{ShadowFilterHard, ShadowFilterPCF, ShadowFilterPCSS, ShadowFilterVSM, ShadowFilterESM}
+		for _, filter := range filters {
+			// Light 10 degrees above the horizon
+			light := NewLight(40, 7, 0, ColorWhite, 1.0)
+			light.Shadow.Filter = filter
+			sr := NewSimpleShadowRenderer(128)
+			sr.RenderLightShadows(light, scene)
+
+			for x := -20.0; x <= 20; x += 1.7 {
+				for z := -20.0; z <= 20; z += 1.7 {
+					if f := sr.ShadowFactor(light, Point{X: x, Y: 0, Z: z}, Point{Y: 1}); f < 0.9 {
+						t.Fatalf("Filter %d: ground self-shadows at (%.1f, %.1f): %.2f", filter, x, z, f)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("PCSSPenumbraWidens", func(t *testing.T) {
+		scene := NewScene()
+		scene.AddNode(newEdge(5))
+
+		light := NewLight(20, 0, 0, ColorWhite, 1.0)
+		light.Shadow.Filter = ShadowFilterPCSS
+		sr := NewSimpleShadowRenderer(256)
+		sr.RenderLightShadows(light, scene)
+
+		penumbra := func(x float64) int {
+			partial := 0
+			for y := -5.0; y <= 5; y += 0.05 {
+				f := sr.ShadowFactor(light, Point{X: x, Y: y, Z: 0}, Point{X: 1})
+				if f > 0.05 && f < 0.95 {
+					partial++
+				}
+			}
+			return partial
+		}
+
+		near, far := penumbra(0), penumbra(-30)
+		if near == 0 {
+			t.Error("Receiver near the blocker should have a penumbra")
+		}
+		if far <= near {
+			t.Errorf("Penumbra should widen with receiver distance: near %d, far %d", near, far)
+		}
+	})
+
+	t.Run("PrefilteredFilters", func(t *testing.T) {
+		scene := NewScene()
+		scene.AddNode(newEdge(5))
+
+		for _, filter := range []ShadowFilter{ShadowFilterVSM, ShadowFilterESM} {
+			light := NewLight(20, 0, 0, ColorWhite, 1.0)
+			light.Shadow.Filter = filter
+			sr := NewSimpleShadowRenderer(128)
+			sr.RenderLightShadows(light, scene)
+
+			if f := sr.ShadowFactor(light, Point{X: 0, Y: -8, Z: 0}, Point{X: 1}); f > 0.1 {
+				t.Errorf("Filter %d: point behind blocker should be shadowed, got %.2f", filter, f)
+			}
+			if f := sr.ShadowFactor(light, Point{X: 0, Y: 8, Z: 0}, Point{X: 1}); f < 0.9 {
+				t.Errorf("Filter %d: unoccluded point should be lit, got %.2f", filter, f)
+			}
+		}
+	})
+
+	t.Run("MultipleLights", func(t *testing.T) {
+		scene := NewScene()
+		scene.AddNode(newBlocker(5))
+
+		right := NewLight(20, 0, 0, ColorWhite, 1.0)
+		left := NewLight(-20, 0, 0, ColorWhite, 1.0)
+		lamp := NewPointLight(0, 0, 10, ColorWhite, 1.0)
+		lamp.CastShadows = false
+
+		sr := NewSimpleShadowRenderer(128)
+		sr.Atlas = NewShadowAtlas(DefaultShadowAtlasBudget)
+		sr.RenderShadows([]*Light{right, left, lamp}, scene, nil)
+
+		if sr.ShadowMaps[right] == nil || sr.ShadowMaps[left] == nil {
+			t.Fatal("Every shadow-casting light should get a shadow map")
+		}
+		if sr.CubeShadowMaps[lamp] != nil {
+			t.Error("Light with CastShadows disabled should not get a shadow map")
+		}
+
+		origin := Point{X: 0, Y: 0, Z: 0}
+		if sr.ShadowFactor(right, origin, Point{}) > 0.01 {
+			t.Error("Origin should be shadowed from the right light")
+		}
+		if sr.ShadowFactor(left, origin, Point{}) < 0.99 {
+			t.Error("Origin should be lit by the left light")
+		}
+
+		// Only the occluded light loses its contribution
+		ls := NewLightingSystem(nil)
+		ls.AddLight(right)
+		ls.AddLight(left)
+		mat := NewMaterial()
+		shadow := func(l *Light, p Point) float64 { return sr.ShadowFactor(l, p, Point{}) }
+		shadowed := ls.CalculateLightingWithShadows(origin, Point{X: 1}, &mat, 1.0, shadow)
+		unshadowed := ls.CalculateLighting(origin, Point{X: 1}, &mat, 1.0)
+		if shadowed.R >= unshadowed.R {
+			t.Error("Shadow from the right light should darken the surface facing it")
+		}
+
+		rightOnly := NewLightingSystem(nil)
+		rightOnly.AddLight(left)
+		back := rightOnly.CalculateLighting(origin, Point{X: -1}, &mat, 1.0)
+		if got := ls.CalculateLightingWithShadows(origin, Point{X: -1}, &mat, 1.0, shadow); got != back {
+			t.Errorf("Surface facing the unoccluded light should be unaffected: got %v, want %v", got, back)
+		}
+	})
+
+	t.Run("AtlasBudget", func(t *testing.T) {
+		a, b, c := NewLight(0, 0, 0, ColorWhite, 1), NewLight(0, 0, 0, ColorWhite, 1), NewPointLight(0, 0, 0, ColorWhite, 1)
+		requests := []ShadowRequest{
+			{Light: c, Priority: 0.1, Size: 128, Faces: 6},
+			{Light: a, Priority: 1.0, Size: 256, Faces: 1},
+			{Light: b, Priority: 0.2, Size: 256, Faces: 1},
+		}
+
+		atlas := NewShadowAtlas(512 * 512 * 8)
+		if atlas.Size != 512 || atlas.MemoryBytes() > 512*512*8 {
+			t.Fatalf("Atlas should be 512x512 within budget, got %d", atlas.Size)
+		}
+
+		tiles := atlas.Allocate(requests)
+		if len(tiles[a]) != 1 || len(tiles[b]) != 1 || len(tiles[c]) != 6 {
+			t.Fatalf("Every light should fit: %v", tiles)
+		}
+		if tiles[a][0].Size <= tiles[b][0].Size {
+			t.Errorf("Higher priority light should get the larger tile: %d vs %d", tiles[a][0].Size, tiles[b][0].Size)
+		}
+
+		// Tiles stay inside the atlas and never overlap
+		used := make([]bool, atlas.Size*atlas.Size)
+		for _, lightTiles := range tiles {
+			for _, tile := range lightTiles {
+				if tile.X < 0 || tile.Y < 0 || tile.X+tile.Size > atlas.Size || tile.Y+tile.Size > atlas.Size {
+					t.Fatalf("Tile %v outside atlas", tile)
+				}
+				for y := tile.Y; y < tile.Y+tile.Size; y++ {
+					for x := tile.X; x < tile.X+tile.Size; x++ {
+						if used[y*atlas.Size+x] {
+							t.Fatalf("Tile %v overlaps another tile", tile)
+						}
+						used[y*atlas.Size+x] = true
+					}
+				}
+			}
+		}
+
+		// A tiny budget keeps the most important light and drops the rest
+		small := NewShadowAtlas(64 * 64 * 8)
+		tiles = small.Allocate(requests)
+		if len(tiles[a]) != 1 {
+			t.Error("Highest priority light should keep its shadow")
+		}
+		if len(tiles[c]) != 0 {
+			t.Error("Lowest priority light should be dropped when over budget")
+		}
+	})
+}
+
+// ============================================================================
+// LIGHT CULLING TESTS
+// ============================================================================
+
+func TestLightCulling(t *testing.T) {
+	t.Run("RangeFalloff", func(t *testing.T) {
+		light := NewLight(0, 0, 0, ColorWhite, 1.0)
+		if light.Attenuation(50) != lightAttenuation(50) {
+			t.Error("Unbounded light should use the plain falloff")
+		}
+
+		light.Range = 20
+		if light.Attenuation(20) != 0 || light.Attenuation(25) != 0 {
+			t.Error("Light should have no effect at or beyond its range")
+		}
+		if a := light.Attenuation(19.9); a <= 0 || a > 0.01 {
+			t.Errorf("Falloff should fade smoothly to zero near the range, got %.4f", a)
+		}
+	})
+
+	t.Run("TileAssignment", func(t *testing.T) {
+		camera := NewCamera()
+		grid := NewLightGrid(200, 50, 8)
+
+		sun := NewLight(0, 100, 0, ColorWhite, 1.0)
+		local := NewLight(0, 0, 0, ColorWhite, 1.0)
+		local.Range = 5
+		behind := NewLight(0, 0, DEFAULT_CAMERA_Z-50, ColorWhite, 1.0)
+		behind.Range = 10
+		off := NewLight(0, 0, 0, ColorWhite, 1.0)
+		off.IsEnabled = false
+
+		grid.Build([]*Light{sun, local, behind, off}, camera)
+
+		contains := func(lights []*Light, l *Light) bool {
+			for _, candidate := range lights {
+				if candidate == l {
+					return true
+				}
+			}
+			return false
+		}
+
+		for i, tile := range grid.Tiles {
+			if !contains(tile, sun) {
+				t.Fatalf("Unbounded light missing from tile %d", i)
+			}
+			if contains(tile, behind) || contains(tile, off) {
+				t.Fatalf("Tile %d holds a light that cannot affect the screen", i)
+			}
+		}
+		if !contains(grid.LightsAt(100, 25), local) {
+			t.Error("Ranged light should reach the tile it projects to")
+		}
+		if contains(grid.LightsAt(0, 0), local) {
+			t.Error("Ranged light should be culled from distant tiles")
+		}
+
+		maxLights, avgLights := grid.Stats()
+		if maxLights != 2 || avgLights >= 2 {
+			t.Errorf("Unexpected lights per tile: max %d avg %.2f", maxLights, avgLights)
+		}
+	})
+
+	t.Run("ConservativeBounds", func(t *testing.T) {
+		camera := NewCamera()
+		camera.SetPosition(0, 10, -60)
+		grid := NewLightGrid(223, 51, 8)
+
+		lights := make([]*Light, 0)
+		for i := 0; i < 12; i++ {
+			angle := float64(i) / 12 * 2 * math.Pi
+			light := NewLight(30*math.Cos(angle), 8, 30*math.Sin(angle), ColorWhite, 1.0)
+			light.Range = 12
+			lights = append(lights, light)
+		}
+		grid.Build(lights, camera)
+
+		// Any visible point a light reaches must land in a tile that lists it
+		for _, light := range lights {
+			for x := -1.0; x <= 1; x += 0.25 {
+				for y := -1.0; y <= 1; y += 0.25 {
+					for z := -1.0; z <= 1; z += 0.25 {
+						p := Point{
+							X: light.Position.X + x*light.Range*0.99,
+							Y: light.Position.Y + y*light.Range*0.99,
+							Z: light.Position.Z + z*light.Range*0.99,
+						}
+						if x*x+y*y+z*z > 1 {
+							continue
+						}
+						sx, sy, _ := camera.ProjectPoint(p, grid.Height, grid.Width)
+						if sx < 0 || sy < 0 || sx >= grid.Width || sy >= grid.Height {
+							continue
+						}
+						found := false
+						for _, l := range grid.LightsAt(sx, sy) {
+							found = found || l == light
+						}
+						if !found {
+							t.Fatalf("Light at %v missing from tile at (%d, %d)", light.Position, sx, sy)
+						}
+					}
+				}
+			}
+		}
+	})
+}
+
+// ============================================================================
+// LIGHTMAP TESTS
+// ============================================================================
+
+func TestLightmaps(t *testing.T) {
+	// Ground quad facing +Y with a cube hovering over its centre
+	newLightmapScene := func() (*Scene, *SceneNode, *LightingSystem) {
+		scene := NewScene()
+		ground := NewMesh()
+		ground.AddVertex(-10, 0, -10)
+		ground.AddVertex(-10, 0, 10)
+		ground.AddVertex(10, 0, 10)
+		ground.AddVertex(10, 0, -10)
+		ground.AddTriangleIndices(0, 1, 2)
+		ground.AddTriangleIndices(0, 2, 3)
+		node := NewSceneNodeWithObject("Ground", ground)
+		scene.AddNode(node)
+
+		mat := NewMaterial()
+		blocker := scene.CreateCube("Blocker", 2, &mat)
+		blocker.Transform.SetPosition(0, 6, 0)
+
+		lighting := NewLightingSystem(nil)
+		light := NewLight(0, 20, 0, ColorWhite, 1.0)
+		lighting.AddLight(light)
+		return scene, node, lighting
+	}
+
+	// lightAt samples a mesh's lightmap at a world point inside triangle tri
+	lightAt := func(mesh *Mesh, tri int, p Point) Color {
+		lookup := &Triangle{
+			P0:          mesh.Vertices[mesh.Indices[tri*3]],
+			P1:          mesh.Vertices[mesh.Indices[tri*3+1]],
+			P2:          mesh.Vertices[mesh.Indices[tri*3+2]],
+			LightmapUV0: mesh.LightmapUVs[tri*3],
+			LightmapUV1: mesh.LightmapUVs[tri*3+1],
+			LightmapUV2: mesh.LightmapUVs[tri*3+2],
+			Lightmap:    mesh.Lightmap,
+		}
+		return SampleLightmap(lookup, p)
+	}
+
+	t.Run("UVCharts", func(t *testing.T) {
+		scene := NewScene()
+		mat := NewMaterial()
+		mesh := scene.CreateSphere("Sphere", 5, 8, 12, &mat).Object.(*Mesh)
+
+		const res = 128
+		if err := GenerateLightmapUVs(mesh, res); err != nil {
+			t.Fatalf("Unwrap failed: %v", err)
+		}
+		if len(mesh.LightmapUVs) != len(mesh.Indices) {
+			t.Fatalf("Expected %d lightmap UVs, got %d", len(mesh.Indices), len(mesh.LightmapUVs))
+		}
+
+		// Rasterize every chart's texel bounds: none may overlap or leave the map
+		owner := make([]int, res*res)
+		for tri := 0; tri < len(mesh.Indices)/3; tri++ {
+			minU, minV, maxU, maxV := 1.0, 1.0, 0.0, 0.0
+			for _, uv := range mesh.LightmapUVs[tri*3 : tri*3+3] {
+				if uv.U < 0 || uv.U > 1 || uv.V < 0 || uv.V > 1 {
+					t.Fatalf("UV %v outside the lightmap", uv)
+				}
+				minU, maxU = math.Min(minU, uv.U), math.Max(maxU, uv.U)
+				minV, maxV = math.Min(minV, uv.V), math.Max(maxV, uv.V)
+			}
+			for y := int(minV * res); y < int(math.Ceil(maxV*res)); y++ {
+				for x := int(minU * res); x < int(math.Ceil(maxU*res)); x++ {
+					if owner[y*res+x] != 0 {
+						t.Fatalf("Charts %d and %d overlap at texel (%d, %d)", owner[y*res+x]-1, tri, x, y)
+					}
+					owner[y*res+x] = tri + 1
+				}
+			}
+		}
+	})
+
+	t.Run("BakeShadowsAndBounce", func(t *testing.T) {
+		scene, node, lighting := newLightmapScene()
+		settings := DefaultLightmapSettings()
+		settings.Resolution = 32
+		settings.IndirectSamples = 16
+
+		tex, err := NewLightmapBaker(scene, lighting, settings).BakeNode(node)
+		if err != nil {
+			t.Fatalf("Bake failed: %v", err)
+		}
+		mesh := node.Object.(*Mesh)
+		if mesh.Lightmap != tex {
+			t.Fatal("Baked lightmap should be attached to the mesh")
+		}
+
+		shadowed := luminance(lightAt(mesh, 0, Point{X: -0.5, Y: 0, Z: 0.5}))
+		lit := luminance(lightAt(mesh, 0, Point{X: -8, Y: 0, Z: 7}))
+		if shadowed >= lit*0.5 {
+			t.Errorf("Ground under the blocker should be in shadow: %.1f vs %.1f lit", shadowed, lit)
+		}
+		if shadowed == 0 {
+			t.Error("Shadowed ground should still receive sky and bounced light")
+		}
+	})
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		scene, node, lighting := newLightmapScene()
+		settings := DefaultLightmapSettings()
+		settings.Resolution = 32
+		settings.DirectSamples = 1
+		settings.IndirectSamples = 0
+
+		baked, err := NewLightmapBaker(scene, lighting, settings).BakeScene()
+		if err != nil {
+			t.Fatalf("Bake failed: %v", err)
+		}
+		if len(baked) != 2 {
+			t.Fatalf("Expected ground and blocker lightmaps, got %d", len(baked))
+		}
+
+		mesh := node.Object.(*Mesh)
+		path := t.TempDir() + "/ground.obj"
+		if err := SaveOBJ(mesh, path); err != nil {
+			t.Fatalf("Saving mesh failed: %v", err)
+		}
+		if err := SaveLightmap(mesh, path); err != nil {
+			t.Fatalf("Saving lightmap failed: %v", err)
+		}
+
+		loaded, err := NewAssetManager().LoadMesh(path)
+		if err != nil {
+			t.Fatalf("Loading failed: %v", err)
+		}
+		if loaded.Lightmap == nil || len(loaded.LightmapUVs) != len(mesh.LightmapUVs) {
+			t.Fatal("Asset manager should attach the lightmap saved next to the mesh")
+		}
+		for i, uv := range mesh.LightmapUVs {
+			if loaded.LightmapUVs[i] != uv {
+				t.Fatalf("Lightmap UV %d differs after reload: %v vs %v", i, loaded.LightmapUVs[i], uv)
+			}
+		}
+		for i, c := range mesh.Lightmap.Data {
+			if loaded.Lightmap.Data[i] != c {
+				t.Fatalf("Lightmap texel %d differs after reload", i)
+			}
+		}
+
+		// A lightmap baked for other triangles must not be attached
+		moved := mesh.Clone()
+		moved.Vertices[0].Y += 1
+		dropped := mesh.Clone()
+		dropped.Indices = dropped.Indices[:3]
+		for name, changed := range map[string]*Mesh{"moved": moved, "dropped": dropped} {
+			if err := SaveOBJ(changed, path); err != nil {
+				t.Fatalf("Saving mesh failed: %v", err)
+			}
+			if _, err := NewAssetManager().LoadMesh(path); err == nil {
+				t.Errorf("Expected an error attaching the lightmap to a %s mesh", name)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// MESH NORMAL TESTS
+// ============================================================================
+
+func TestMeshNormals(t *testing.T) {
+
+	t.Run("CreaseKeepsHardEdges", func(t *testing.T) {
+		scene := NewScene()
+		mat := NewMaterial()
+		mesh := scene.CreateCube("Cube", 1, &mat).Object.(*Mesh)
+
+		mesh.GenerateNormals(DefaultCreaseAngle)
+		if len(mesh.Vertices) != 24 || !mesh.HasNormals() {
+			t.Fatalf("Each cube corner should split into 3 vertices, got %d", len(mesh.Vertices))
+		}
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			a, b, c := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+			face := CalculateSurfaceNormal(&a, &b, &c, nil, false)
+			for k := 0; k < 3; k++ {
+				if n := mesh.Normals[mesh.Indices[i+k]]; !pointsNear(n, face, 1e-6) {
+					t.Fatalf("Cube vertex normal %v should match its face %v", n, face)
+				}
+			}
+		}
+	})
+
+	t.Run("SmoothBeyondCrease", func(t *testing.T) {
+		scene := NewScene()
+		mat := NewMaterial()
+		mesh := scene.CreateCube("Cube", 1, &mat).Object.(*Mesh)
+
+		mesh.GenerateNormals(math.Pi)
+		if len(mesh.Vertices) != 8 {
+			t.Fatalf("Smoothing everything should not split vertices, got %d", len(mesh.Vertices))
+		}
+		for i, v := range mesh.Vertices {
+			x, y, z := normalizeVector(v.X, v.Y, v.Z)
+			if !pointsNear(mesh.Normals[i], Point{X: x, Y: y, Z: z}, 1e-6) {
+				t.Errorf("Corner %v should point along the diagonal, got %v", v, mesh.Normals[i])
+			}
+		}
+	})
+
+	t.Run("AngleWeighted", func(t *testing.T) {
+		// A corner shared by a +Y face (one triangle) and a +X face split into
+		// two triangles: the split must not pull the normal towards +X
+		mesh := NewMesh()
+		o := mesh.AddVertex(0, 0, 0)
+		top1 := mesh.AddVertex(0, 0, 1)
+		top2 := mesh.AddVertex(-1, 0, 0)
+		side1 := mesh.AddVertex(0, -1, 0)
+		side2 := mesh.AddVertex(0, -1, 1)
+		side3 := mesh.AddVertex(0, 0, 1)
+		mesh.AddTriangleIndices(o, top2, top1)
+		mesh.AddTriangleIndices(o, side2, side1)
+		mesh.AddTriangleIndices(o, side3, side2)
+
+		mesh.GenerateNormals(math.Pi)
+		want := Point{X: math.Sqrt(0.5), Y: math.Sqrt(0.5)}
+		if n := mesh.Normals[o]; !pointsNear(n, want, 1e-6) {
+			t.Errorf("Expected angle-weighted normal %v, got %v", want, n)
+		}
+	})
+
+	t.Run("GeneratedSphereIsSmooth", func(t *testing.T) {
+		mesh := GenerateSphere(5, 8, 12)
+		if !mesh.HasNormals() {
+			t.Fatal("Generated sphere should carry vertex normals")
+		}
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			a, b, c := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+			face := CalculateSurfaceNormal(&a, &b, &c, nil, false)
+			n := mesh.Normals[mesh.Indices[i+2]]
+			if cornerAngle(a, b, c) > 0 && dotProduct(face.X, face.Y, face.Z, n.X, n.Y, n.Z) <= 0 {
+				t.Fatalf("Triangle %d is wound against its vertex normals", i/3)
+			}
+		}
+	})
+
+	t.Run("InterpolatedShading", func(t *testing.T) {
+		tri := NewTriangle(Point{X: 0}, Point{X: 1}, Point{Y: 1}, 'o')
+		tri.SetVertexNormals(Point{X: 1}, Point{Y: 1}, Point{Y: 1})
+
+		n := InterpolateNormal(tri, Point{X: 0.5}, Point{Z: 1})
+		if !pointsNear(n, Point{X: math.Sqrt(0.5), Y: math.Sqrt(0.5)}, 1e-6) {
+			t.Errorf("Normal halfway along an edge should blend its ends, got %v", n)
+		}
+
+		tri.HasVertexNormals = false
+		if n := InterpolateNormal(tri, Point{X: 0.5}, Point{Z: 1}); n != (Point{Z: 1}) {
+			t.Errorf("Flat triangles should keep the face normal, got %v", n)
+		}
+	})
+
+	t.Run("NonUniformScale", func(t *testing.T) {
+		// A 45 degree slope stays perpendicular to its surface when stretched
+		mesh := NewMesh()
+		mesh.AddVertex(0, 0, 0)
+		mesh.AddVertex(0, 0, 1)
+		mesh.AddVertex(1, 1, 0)
+		mesh.AddTriangleIndices(0, 1, 2)
+		mesh.GenerateNormals(DefaultCreaseAngle)
+
+		node := NewSceneNodeWithObject("Slope", mesh)
+		node.Transform.SetScale(3, 1, 1)
+		world := node.TransformSceneObject().(*Mesh)
+
+		edge := Point{
+			X: world.Vertices[2].X - world.Vertices[0].X,
+			Y: world.Vertices[2].Y - world.Vertices[0].Y,
+			Z: world.Vertices[2].Z - world.Vertices[0].Z,
+		}
+		n := world.Normals[0]
+		if d := dotProduct(n.X, n.Y, n.Z, edge.X, edge.Y, edge.Z); math.Abs(d) > 1e-6 {
+			t.Errorf("Scaled normal %v is not perpendicular to the surface (dot %.4f)", n, d)
+		}
+	})
+
+	t.Run("OBJRoundTrip", func(t *testing.T) {
+		mesh := GenerateSphere(3, 6, 8)
+		path := t.TempDir() + "/sphere.obj"
+		if err := SaveOBJ(mesh, path); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		loaded, err := LoadOBJ(path)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if !loaded.HasNormals() {
+			t.Fatal("Loaded mesh should keep the file's normals")
+		}
+		for i, idx := range loaded.Indices {
+			if !pointsNear(loaded.Normals[idx], mesh.Normals[mesh.Indices[i]], 1e-6) {
+				t.Fatalf("Normal of corner %d changed on reload", i)
+			}
+		}
+	})
+
+	t.Run("SimplificationKeepsNormals", func(t *testing.T) {
+		mesh := GenerateSphere(5, 16, 16)
+		simplified := SimplifyMeshQEM(mesh, 400)
+		if len(simplified.Indices) == 0 || !simplified.HasNormals() {
+			t.Fatal("Simplified mesh should keep vertex normals")
+		}
+		for i, v := range simplified.Vertices {
+			x, y, z := normalizeVector(v.X, v.Y, v.Z)
+			n := simplified.Normals[i]
+			if dotProduct(x, y, z, n.X, n.Y, n.Z) < 0.9 {
+				t.Errorf("Simplified normal %v should still point outward at %v", n, v)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// TANGENT TESTS
+// ============================================================================
+
+func TestTangents(t *testing.T) {
+	dir := func(tg Tangent) Point { return Point{X: tg.X, Y: tg.Y, Z: tg.Z} }
+
+	// Unit quad facing +Z with U along +X and V along +Y
+	quad := func() *Mesh {
+		mesh := NewMesh()
+		mesh.AddVertexWithUV(0, 0, 0, 0, 0)
+		mesh.AddVertexWithUV(1, 0, 0, 1, 0)
+		mesh.AddVertexWithUV(1, 1, 0, 1, 1)
+		mesh.AddVertexWithUV(0, 1, 0, 0, 1)
+		mesh.AddTriangleIndices(0, 1, 2)
+		mesh.AddTriangleIndices(0, 2, 3)
+		return mesh
+	}
+
+	t.Run("FollowsUVs", func(t *testing.T) {
+		mesh := quad()
+		if err := mesh.GenerateTangents(); err != nil {
+			t.Fatalf("GenerateTangents failed: %v", err)
+		}
+		if !mesh.HasTangents() || !mesh.HasNormals() {
+			t.Fatal("Mesh should have tangents and generated normals")
+		}
+		for i, tg := range mesh.Tangents {
+			if !pointsNear(dir(tg), Point{X: 1}, 1e-6) || tg.W != 1 {
+				t.Errorf("Vertex %d tangent should be +X with W=+1, got %+v", i, tg)
+			}
+		}
+	})
+
+	t.Run("RequiresUVs", func(t *testing.T) {
+		mesh := NewMesh()
+		mesh.AddVertex(0, 0, 0)
+		mesh.AddVertex(1, 0, 0)
+		mesh.AddVertex(0, 1, 0)
+		mesh.AddTriangleIndices(0, 1, 2)
+		if err := mesh.GenerateTangents(); err == nil {
+			t.Error("Expected an error for a mesh without UVs")
+		}
+	})
+
+	t.Run("MirroredUVsSplit", func(t *testing.T) {
+		// Two quads sharing the x=1 edge; the right one mirrors U
+		mesh := NewMesh()
+		for _, y := range []float64{0, 1} {
+			mesh.AddVertexWithUV(0, y, 0, 0, y)
+			mesh.AddVertexWithUV(1, y, 0, 1, y)
+			mesh.AddVertexWithUV(2, y, 0, 0, y)
+		}
+		mesh.AddTriangleIndices(0, 1, 4)
+		mesh.AddTriangleIndices(0, 4, 3)
+		mesh.AddTriangleIndices(1, 2, 5)
+		mesh.AddTriangleIndices(1, 5, 4)
+
+		if err := mesh.GenerateTangents(); err != nil {
+			t.Fatalf("GenerateTangents failed: %v", err)
+		}
+		if len(mesh.Vertices) != 8 {
+			t.Fatalf("The two seam vertices should be split, got %d vertices", len(mesh.Vertices))
+		}
+		for i, idx := range mesh.Indices {
+			tg := mesh.Tangents[idx]
+			want, wantW := Point{X: 1}, 1.0
+			if i >= 6 {
+				want, wantW = Point{X: -1}, -1.0
+			}
+			if !pointsNear(dir(tg), want, 1e-6) || tg.W != wantW {
+				t.Errorf("Corner %d: expected tangent %v with W=%v, got %+v", i, want, wantW, tg)
+			}
+		}
+	})
+
+	t.Run("MirroredTransformFlipsHandedness", func(t *testing.T) {
+		mesh := quad()
+		if err := mesh.GenerateTangents(); err != nil {
+			t.Fatalf("GenerateTangents failed: %v", err)
+		}
+		node := NewSceneNodeWithObject("Quad", mesh)
+		node.Transform.SetScale(-1, 1, 1)
+		world := node.TransformSceneObject().(*Mesh)
+
+		if !world.HasTangents() {
+			t.Fatal("Transformed mesh should keep its tangents")
+		}
+		if tg := world.Tangents[0]; !pointsNear(dir(tg), Point{X: -1}, 1e-6) || tg.W != -1 {
+			t.Errorf("Mirroring should flip the tangent and its handedness, got %+v", tg)
+		}
+	})
+
+	t.Run("UnpackNormalMapIsUnit", func(t *testing.T) {
+		for _, c := range []Color{{128, 128, 255}, {255, 128, 128}, {200, 60, 180}} {
+			n := UnpackNormalMap(c)
+			if l := math.Sqrt(n.X*n.X + n.Y*n.Y + n.Z*n.Z); math.Abs(l-1) > 1e-9 {
+				t.Errorf("Unpacked %v has length %.4f", c, l)
+			}
+		}
+	})
+
+	t.Run("FlatNormalMap", func(t *testing.T) {
+		tex := NewTexture(2, 2)
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 2; x++ {
+				tex.SetPixel(x, y, Color{128, 128, 255})
+			}
+		}
+		mat := NewTexturedMaterial()
+		mat.NormalMap = tex
+		mat.UseTextures = true
+		if !mat.HasNormalMap() {
+			t.Fatal("Textured material should report its normal map")
+		}
+
+		normal := Point{Z: 1}
+		n := PerturbNormal(normal, Tangent{X: 1, W: 1}, mat.SampleNormal(0.5, 0.5))
+		if dotProduct(n.X, n.Y, n.Z, normal.X, normal.Y, normal.Z) < 0.999 {
+			t.Errorf("A flat normal map should leave the normal alone, got %v", n)
+		}
+	})
+
+	t.Run("TiltedNormalMap", func(t *testing.T) {
+		tri := NewTriangle(Point{X: 0}, Point{X: 1}, Point{Y: 1}, 'o')
+		tri.SetVertexNormals(Point{Z: 1}, Point{Z: 1}, Point{Z: 1})
+		tri.SetTangents(Tangent{X: 1, W: 1}, Tangent{X: 1, W: 1}, Tangent{X: 1, W: 1})
+
+		p := Point{X: 0.25, Y: 0.25}
+		normal := InterpolateNormal(tri, p, Point{Z: 1})
+		tangent := InterpolateTangent(tri, p)
+
+		s := math.Sqrt(0.5)
+		if n := PerturbNormal(normal, tangent, Point{X: s, Z: s}); !pointsNear(n, Point{X: s, Z: s}, 1e-6) {
+			t.Errorf("+X in tangent space should tilt towards the tangent, got %v", n)
+		}
+		if n := PerturbNormal(normal, tangent, Point{Y: s, Z: s}); !pointsNear(n, Point{Y: s, Z: s}, 1e-6) {
+			t.Errorf("+Y in tangent space should tilt towards the bitangent, got %v", n)
+		}
+		tangent.W = -1
+		if n := PerturbNormal(normal, tangent, Point{Y: s, Z: s}); !pointsNear(n, Point{Y: -s, Z: s}, 1e-6) {
+			t.Errorf("Mirrored handedness should flip the bitangent, got %v", n)
+		}
+	})
+}
+
+// ============================================================================
+// VERTEX COLOR TESTS
+// ============================================================================
+
+func TestVertexColors(t *testing.T) {
+	writeFile := func(t *testing.T, name, content string) string {
+		path := t.TempDir() + "/" + name
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		return path
+	}
+
+	t.Run("Multiply", func(t *testing.T) {
+		c := Color{200, 100, 50}
+		if got := c.Multiply(ColorWhite); got != c {
+			t.Errorf("White should leave the color unchanged, got %v", got)
+		}
+		if got := c.Multiply(ColorBlack); got != ColorBlack {
+			t.Errorf("Black should give black, got %v", got)
+		}
+		if got := ColorWhite.Multiply(Color{255, 128, 0}); got != (Color{255, 128, 0}) {
+			t.Errorf("Tinting white should give the tint, got %v", got)
+		}
+	})
+
+	t.Run("Interpolate", func(t *testing.T) {
+		tri := NewTriangle(Point{X: 0}, Point{X: 2}, Point{Y: 2}, 'o')
+		if got := InterpolateColor(tri, Point{X: 1}); got != ColorWhite {
+			t.Errorf("Uncolored triangles should interpolate to white, got %v", got)
+		}
+
+		tri.SetVertexColors(ColorRed, ColorBlue, ColorBlue)
+		if got := InterpolateColor(tri, Point{X: 1}); got != (Color{128, 0, 128}) {
+			t.Errorf("Midpoint of a red-blue edge should be purple, got %v", got)
+		}
+	})
+
+	t.Run("TintMultipliesDiffuse", func(t *testing.T) {
+		mat := NewMaterial()
+		mat.DiffuseColor = Color{255, 255, 0}
+		tinted := &tintedMaterial{IMaterial: &mat, tint: Color{255, 0, 255}}
+		if got := tinted.GetDiffuseColor(0, 0); got != ColorRed {
+			t.Errorf("Yellow tinted magenta should be red, got %v", got)
+		}
+
+		pbr := NewPBRMaterial()
+		pbr.Albedo = ColorWhite
+		plain := CalculatePBRLightingWithTint(Point{}, Point{Y: 1}, Point{Y: 1}, pbr, nil, ColorWhite, 1, 0, 0, ColorWhite, nil)
+		red := CalculatePBRLightingWithTint(Point{}, Point{Y: 1}, Point{Y: 1}, pbr, nil, ColorWhite, 1, 0, 0, ColorRed, nil)
+		if red.R != plain.R || red.G != 0 || red.B != 0 {
+			t.Errorf("Red tint should keep only the red channel: plain %v, tinted %v", plain, red)
+		}
+	})
+
+	t.Run("ColorByHeight", func(t *testing.T) {
+		mesh := GenerateSphere(2, 8, 8)
+		mesh.ColorByHeight(ColorBlue, ColorRed)
+		if !mesh.HasColors() {
+			t.Fatal("Every vertex should be colored")
+		}
+		for i, v := range mesh.Vertices {
+			c := mesh.Colors[i]
+			if v.Y > 1.9 && c.R < 240 || v.Y < -1.9 && c.B < 240 {
+				t.Errorf("Vertex at height %.2f has color %v", v.Y, c)
+			}
+		}
+	})
+
+	t.Run("NormalSplitsKeepColors", func(t *testing.T) {
+		scene := NewScene()
+		mat := NewMaterial()
+		mesh := scene.CreateCube("Cube", 1, &mat).Object.(*Mesh)
+		mesh.SetColors(func(p Point) Color {
+			return Color{uint8(127 + 128*p.X), uint8(127 + 128*p.Y), uint8(127 + 128*p.Z)}
+		})
+
+		mesh.GenerateNormals(DefaultCreaseAngle)
+		if !mesh.HasColors() {
+			t.Fatalf("Split vertices should keep their colors (%d colors, %d vertices)", len(mesh.Colors), len(mesh.Vertices))
+		}
+		for i, v := range mesh.Vertices {
+			if want := (Color{uint8(127 + 128*v.X), uint8(127 + 128*v.Y), uint8(127 + 128*v.Z)}); mesh.Colors[i] != want {
+				t.Errorf("Vertex %v has color %v, want %v", v, mesh.Colors[i], want)
+			}
+		}
+	})
+
+	t.Run("OBJ", func(t *testing.T) {
+		path := writeFile(t, "tri.obj", "v 0 0 0 1 0 0\nv 1 0 0 0 1 0\nv 0 1 0 0 0 1\nf 1 2 3\n")
+		mesh, err := LoadOBJ(path)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if !mesh.HasColors() {
+			t.Fatal("Loaded mesh should have vertex colors")
+		}
+		for i, idx := range mesh.Indices {
+			if want := []Color{ColorRed, ColorGreen, ColorBlue}[i]; mesh.Colors[idx] != want {
+				t.Errorf("Corner %d: expected %v, got %v", i, want, mesh.Colors[idx])
+			}
+		}
+
+		// 0-255 values are accepted too
+		path = writeFile(t, "bytes.obj", "v 0 0 0 255 128 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n")
+		if mesh, err = LoadOBJ(path); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if mesh.Colors[mesh.Indices[0]] != (Color{255, 128, 0}) || mesh.Colors[mesh.Indices[1]] != ColorWhite {
+			t.Errorf("Expected orange then white, got %v and %v", mesh.Colors[mesh.Indices[0]], mesh.Colors[mesh.Indices[1]])
+		}
+
+		// Round trip
+		out := t.TempDir() + "/out.obj"
+		if err := SaveOBJ(mesh, out); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		reloaded, err := LoadOBJ(out)
+		if err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+		for i, idx := range reloaded.Indices {
+			if reloaded.Colors[idx] != mesh.Colors[mesh.Indices[i]] {
+				t.Errorf("Corner %d color changed on reload", i)
+			}
+		}
+	})
+
+	t.Run("PLY", func(t *testing.T) {
+		path := writeFile(t, "quad.ply", `ply
+format ascii 1.0
+comment colored quad
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0 255 0 0
+1 0 0 0 255 0
+1 1 0 0 0 255
+0 1 0 255 255 255
+4 0 1 2 3
+`)
+		mesh, err := LoadPLY(path)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if len(mesh.Indices) != 6 {
+			t.Fatalf("Quad should be split into 2 triangles, got %d indices", len(mesh.Indices))
+		}
+		if !mesh.HasColors() || !mesh.HasNormals() {
+			t.Fatal("Loaded mesh should have colors and generated normals")
+		}
+		want := map[Point]Color{{}: ColorRed, {X: 1}: ColorGreen, {X: 1, Y: 1}: ColorBlue, {Y: 1}: ColorWhite}
+		for i, v := range mesh.Vertices {
+			if mesh.Colors[i] != want[v] {
+				t.Errorf("Vertex %v: expected %v, got %v", v, want[v], mesh.Colors[i])
+			}
+		}
+
+		// Float colors are in [0, 1]
+		path = writeFile(t, "float.ply", "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\n"+
+			"property float red\nproperty float green\nproperty float blue\nelement face 1\nproperty list uchar int vertex_indices\nend_header\n"+
+			"0 0 0 1 0.5 0\n1 0 0 1 0.5 0\n0 1 0 1 0.5 0\n3 0 1 2\n")
+		if mesh, err = LoadPLY(path); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if mesh.Colors[0] != (Color{255, 128, 0}) {
+			t.Errorf("Expected orange, got %v", mesh.Colors[0])
+		}
+
+		// Out-of-range faces are rejected
+		path = writeFile(t, "bad.ply", "ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nproperty float z\n"+
+			"element face 1\nproperty list uchar int vertex_indices\nend_header\n0 0 0\n3 0 1 2\n")
+		if _, err := LoadPLY(path); err == nil {
+			t.Error("Expected an error for out-of-range face indices")
+		}
+	})
+}
+
+// ============================================================================
+// MESH GENERATOR TESTS
+// ============================================================================
+
+func TestMeshGenerators(t *testing.T) {
+	// checkMesh verifies indices, per-vertex normals and UVs, and that each
+	// triangle's winding agrees with its vertex normals
+	checkMesh := func(t *testing.T, mesh *Mesh) {
+		t.Helper()
+		if len(mesh.Indices) == 0 || len(mesh.Indices)%3 != 0 {
+			t.Fatalf("Expected whole triangles, got %d indices", len(mesh.Indices))
+		}
+		if !mesh.HasNormals() || len(mesh.UVs) != len(mesh.Vertices) {
+			t.Fatalf("Expected a normal and UV per vertex: %d vertices, %d normals, %d UVs",
+				len(mesh.Vertices), len(mesh.Normals), len(mesh.UVs))
+		}
+		for _, idx := range mesh.Indices {
+			if idx < 0 || idx >= len(mesh.Vertices) {
+				t.Fatalf("Index %d out of range", idx)
+			}
+		}
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			a, b, c := mesh.Indices[i], mesh.Indices[i+1], mesh.Indices[i+2]
+			face := crossPoints(subPoints(mesh.Vertices[b], mesh.Vertices[a]), subPoints(mesh.Vertices[c], mesh.Vertices[a]))
+			if pointLength(face) < 1e-12 {
+				t.Fatalf("Triangle %d is degenerate", i/3)
+			}
+			n := addPoints(addPoints(mesh.Normals[a], mesh.Normals[b]), mesh.Normals[c])
+			if face.X*n.X+face.Y*n.Y+face.Z*n.Z <= 0 {
+				t.Fatalf("Triangle %d is wound against its normals", i/3)
+			}
+		}
+	}
+
+	// checkOutward verifies every face of a convex mesh points away from center
+	checkOutward := func(t *testing.T, mesh *Mesh, center Point) {
+		t.Helper()
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			p0, p1, p2 := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+			face := crossPoints(subPoints(p1, p0), subPoints(p2, p0))
+			out := subPoints(scalePoint(addPoints(addPoints(p0, p1), p2), 1.0/3), center)
+			if face.X*out.X+face.Y*out.Y+face.Z*out.Z <= 0 {
+				t.Fatalf("Triangle %d faces inward", i/3)
+			}
+		}
+	}
+
+	t.Run("Plane", func(t *testing.T) {
+		mesh := GeneratePlane(4, 2, 4, 2)
+		checkMesh(t, mesh)
+		if len(mesh.Vertices) != 5*3 || len(mesh.Indices) != 4*2*6 {
+			t.Errorf("Expected a 4x2 grid, got %d vertices, %d indices", len(mesh.Vertices), len(mesh.Indices))
+		}
+		for i, n := range mesh.Normals {
+			if n != (Point{Y: 1}) || mesh.Vertices[i].Y != 0 {
+				t.Fatalf("Vertex %d should lie on the plane facing +Y", i)
+			}
+		}
+	})
+
+	t.Run("Box", func(t *testing.T) {
+		mesh := GenerateBox(2, 4, 6, 2, 3, 4)
+		checkMesh(t, mesh)
+		checkOutward(t, mesh, Point{})
+		if v := meshVolume(mesh); math.Abs(v-48) > 1e-9 {
+			t.Errorf("Expected volume 48, got %.6f", v)
+		}
+	})
+
+	t.Run("RoundedBox", func(t *testing.T) {
+		mesh := GenerateRoundedBox(2, 2, 2, 0.5, 4)
+		checkMesh(t, mesh)
+		checkOutward(t, mesh, Point{})
+		for i, p := range mesh.Vertices {
+			if math.Abs(p.X) > 1+1e-9 || math.Abs(p.Y) > 1+1e-9 || math.Abs(p.Z) > 1+1e-9 {
+				t.Fatalf("Vertex %d %v lies outside the box", i, p)
+			}
+		}
+		// The corner is cut off: nothing reaches (1, 1, 1)
+		cornerDist := math.Sqrt(3)*0.5 + 0.5
+		for i, p := range mesh.Vertices {
+			if d := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z); d > cornerDist+1e-9 {
+				t.Fatalf("Vertex %d is %.4f from the center, past the rounded corner", i, d)
+			}
+		}
+	})
+
+	t.Run("CylinderAndCone", func(t *testing.T) {
+		cylinder := GenerateCylinder(1, 2, 32, 2)
+		checkMesh(t, cylinder)
+		checkOutward(t, cylinder, Point{})
+		if v, want := meshVolume(cylinder), 2*32*math.Sin(2*math.Pi/32)/2; math.Abs(v-want) > 1e-9 {
+			t.Errorf("Expected volume %.6f, got %.6f", want, v)
+		}
+
+		cone := GenerateCone(1, 2, 32, 3)
+		checkMesh(t, cone)
+		checkOutward(t, cone, Point{})
+		if v, want := meshVolume(cone), 2*32*math.Sin(2*math.Pi/32)/2/3; math.Abs(v-want) > 1e-9 {
+			t.Errorf("Expected volume %.6f, got %.6f", want, v)
+		}
+	})
+
+	t.Run("Capsule", func(t *testing.T) {
+		mesh := GenerateCapsule(0.5, 2, 16, 6)
+		checkMesh(t, mesh)
+		checkOutward(t, mesh, Point{})
+		minY, maxY := math.Inf(1), math.Inf(-1)
+		for _, p := range mesh.Vertices {
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+		if math.Abs(minY+1.5) > 1e-9 || math.Abs(maxY-1.5) > 1e-9 {
+			t.Errorf("Expected Y in [-1.5, 1.5], got [%.4f, %.4f]", minY, maxY)
+		}
+	})
+
+	t.Run("Icosphere", func(t *testing.T) {
+		prev := 0
+		for sub := 0; sub <= 3; sub++ {
+			mesh := GenerateIcosphere(2, sub)
+			checkMesh(t, mesh)
+			checkOutward(t, mesh, Point{})
+			if tris := len(mesh.Indices) / 3; tris != 20<<(2*sub) {
+				t.Errorf("Subdivision %d: expected %d triangles, got %d", sub, 20<<(2*sub), tris)
+			}
+			for i, p := range mesh.Vertices {
+				if d := pointLength(p); math.Abs(d-2) > 1e-9 {
+					t.Fatalf("Vertex %d is %.6f from the center, expected 2", i, d)
+				}
+			}
+			if len(mesh.Vertices) <= prev {
+				t.Errorf("Subdivision %d should add vertices", sub)
+			}
+			prev = len(mesh.Vertices)
+		}
+	})
+
+	t.Run("Lathe", func(t *testing.T) {
+		// A closed vase: the profile starts and ends on the axis
+		profile := []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1.2, Y: 1}, {X: 0.6, Y: 2}, {X: 0, Y: 2}}
+		mesh := GenerateLathe(profile, 24)
+		checkMesh(t, mesh)
+		if v := meshVolume(mesh); v <= 0 {
+			t.Errorf("A closed lathe should enclose a positive volume, got %.6f", v)
+		}
+	})
+
+	t.Run("ExtrusionCapsClose", func(t *testing.T) {
+		square := []Point{{X: -0.5, Y: -0.5}, {X: 0.5, Y: -0.5}, {X: 0.5, Y: 0.5}, {X: -0.5, Y: 0.5}}
+		path := []Point{{Z: 0}, {Z: 1}, {Z: 3}}
+		mesh := GenerateExtrusion(square, path, true)
+		checkMesh(t, mesh)
+		if v := meshVolume(mesh); math.Abs(v-3) > 1e-9 {
+			t.Errorf("Expected volume 3, got %.6f", v)
+		}
+
+		// Clockwise input is accepted and gives the same solid
+		reversed := []Point{square[3], square[2], square[1], square[0]}
+		if v := meshVolume(GenerateExtrusion(reversed, path, true)); math.Abs(v-3) > 1e-9 {
+			t.Errorf("Clockwise shape: expected volume 3, got %.6f", v)
+		}
+
+		// A concave L shape still gets closed caps
+		ell := []Point{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 0, Y: 2}}
+		if v := meshVolume(GenerateExtrusion(ell, path, true)); math.Abs(v-9) > 1e-9 {
+			t.Errorf("L shape: expected volume 9, got %.6f", v)
+		}
+	})
+
+	t.Run("TubeFollowsSpline", func(t *testing.T) {
+		spline := NewCatmullRomSpline([]Point{{X: 2}, {Z: 2}, {X: -2}, {Z: -2}}, true)
+		if p := spline.Evaluate(0); pointLength(subPoints(p, Point{X: 2})) > 1e-9 {
+			t.Errorf("Spline should pass through its first point, got %v", p)
+		}
+		if p := spline.Evaluate(1); pointLength(subPoints(p, Point{X: 2})) > 1e-9 {
+			t.Errorf("Closed spline should return to its first point, got %v", p)
+		}
+
+		mesh := GenerateTube(spline, 0.25, 64, 12)
+		checkMesh(t, mesh)
+		samples := spline.Sample(2048)
+		for i, p := range mesh.Vertices {
+			best := math.Inf(1)
+			for _, s := range samples {
+				best = math.Min(best, pointLength(subPoints(p, s)))
+			}
+			if math.Abs(best-0.25) > 0.01 {
+				t.Fatalf("Vertex %d is %.4f from the spline, expected 0.25", i, best)
+			}
+		}
+		// A closed tube around a loop encloses a torus-like positive volume
+		if v := meshVolume(mesh); v <= 0 {
+			t.Errorf("Closed tube should enclose a positive volume, got %.6f", v)
+		}
+	})
+
+	t.Run("SceneHelpers", func(t *testing.T) {
+		scene := NewScene()
+		mat := NewMaterial()
+		nodes := []*SceneNode{
+			scene.CreatePlane("plane", 1, 1, 1, 1, &mat),
+			scene.CreateBox("box", 1, 1, 1, 1, 1, 1, &mat),
+			scene.CreateRoundedBox("rounded", 1, 1, 1, 0.2, 2, &mat),
+			scene.CreateCylinder("cylinder", 1, 1, 8, 1, &mat),
+			scene.CreateCone("cone", 1, 1, 8, 1, &mat),
+			scene.CreateCapsule("capsule", 1, 1, 8, 2, &mat),
+			scene.CreateIcosphere("ico", 1, 1, &mat),
+			scene.CreateLathe("lathe", []Point{{X: 1}, {X: 1, Y: 1}}, 8, &mat),
+			scene.CreateExtrusion("extrusion", []Point{{}, {X: 1}, {Y: 1}}, []Point{{}, {Z: 1}}, true, &mat),
+			scene.CreateTube("tube", NewCatmullRomSpline([]Point{{}, {X: 1}, {X: 2, Y: 1}}, false), 0.1, 8, 6, &mat),
+		}
+		for _, node := range nodes {
+			mesh, ok := node.Object.(*Mesh)
+			if !ok || mesh.Material != IMaterial(&mat) {
+				t.Errorf("Node %s should hold a mesh with the material", node.Name)
+			}
+			if scene.FindNode(node.Name) != node {
+				t.Errorf("Node %s should be added to the scene", node.Name)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// TERRAIN TESTS
+// ============================================================================
+
+func TestTerrain(t *testing.T) {
+	settings := TerrainSettings{Size: 64, HeightScale: 10, ChunkSize: 4, LODLevels: 3, LODDistance: 1.5, SkirtDepth: 1}
+
+	t.Run("PlaneHeightAndNormal", func(t *testing.T) {
+		// h = 10u + 5v rises 10 over 64 units along X and 5 along Z
+		terrain := NewTerrain(settings, func(u, v float64) float64 { return u + 0.5*v })
+		if terrain.Resolution != 16 {
+			t.Fatalf("Expected a 16 cell grid, got %d", terrain.Resolution)
+		}
+
+		want := normalizePoint(Point{X: -10.0 / 64, Y: 1, Z: -5.0 / 64})
+		for _, p := range [][2]float64{{0, 0}, {-31, 7.3}, {12.5, -20.25}, {32, 32}} {
+			expected := 10*(p[0]+32)/64 + 5*(p[1]+32)/64
+			if h := terrain.HeightAt(p[0], p[1]); math.Abs(h-expected) > 1e-9 {
+				t.Errorf("HeightAt(%v, %v): expected %.6f, got %.6f", p[0], p[1], expected, h)
+			}
+			if n := terrain.NormalAt(p[0], p[1]); pointLength(subPoints(n, want)) > 1e-9 {
+				t.Errorf("NormalAt(%v, %v): expected %v, got %v", p[0], p[1], want, n)
+			}
+		}
+
+		// Queries off the terrain clamp to its edge
+		if h := terrain.HeightAt(100, -100); math.Abs(h-10) > 1e-9 {
+			t.Errorf("Expected the clamped corner height 10, got %.6f", h)
+		}
+	})
+
+	t.Run("HeightMatchesTriangles", func(t *testing.T) {
+		terrain := NewTerrainFromNoise(settings, 7, 4)
+		for k := 0; k < 50; k++ {
+			x := -31.9 + 63.8*math.Mod(float64(k)*0.6180339887, 1)
+			z := -31.9 + 63.8*math.Mod(float64(k)*0.4142135623, 1)
+			ray := Ray{Origin: Point{X: x, Y: 100, Z: z}, Direction: Point{Y: -1}}
+			hit, distance, normal := terrain.Raycast(ray, 1000)
+			if !hit {
+				t.Fatalf("Vertical ray at (%.3f, %.3f) missed the terrain", x, z)
+			}
+			if h := terrain.HeightAt(x, z); math.Abs(100-distance-h) > 1e-9 {
+				t.Fatalf("At (%.3f, %.3f): HeightAt %.6f, ray hit %.6f", x, z, h, 100-distance)
+			}
+			if normal.Y <= 0 {
+				t.Fatalf("Hit normal should face up, got %v", normal)
+			}
+		}
+	})
+
+	t.Run("Heightmap", func(t *testing.T) {
+		heightmap := GenerateGradient(32, 32, ColorBlack, ColorWhite, true)
+		terrain := NewTerrainFromHeightmap(heightmap, settings)
+		if h := terrain.HeightAt(-32, 0); math.Abs(h) > 1e-9 {
+			t.Errorf("Black edge should be at height 0, got %.4f", h)
+		}
+		if h := terrain.HeightAt(32, 0); math.Abs(h-10) > 1e-9 {
+			t.Errorf("White edge should be at HeightScale, got %.4f", h)
+		}
+		if terrain.HeightAt(-10, 0) >= terrain.HeightAt(10, 0) {
+			t.Error("Height should rise with brightness")
+		}
+	})
+
+	t.Run("QuadtreeSelection", func(t *testing.T) {
+		nearSettings := settings
+		nearSettings.LODDistance = 1
+		terrain := NewTerrainFromNoise(nearSettings, 3, 3)
+
+		// Chunks must tile the terrain exactly once
+		checkCoverage := func(t *testing.T) {
+			t.Helper()
+			area := 0
+			for _, chunk := range terrain.visible {
+				area += chunk.Size * chunk.Size
+			}
+			if area != terrain.Resolution*terrain.Resolution {
+				t.Fatalf("Selected chunks cover %d cells, expected %d", area, terrain.Resolution*terrain.Resolution)
+			}
+		}
+
+		terrain.Update(Point{Y: 10000})
+		checkCoverage(t)
+		if len(terrain.visible) != 1 || terrain.visible[0].Level != 0 {
+			t.Errorf("A distant camera should select only the root, got %d chunks", len(terrain.visible))
+		}
+
+		terrain.Update(Point{X: -30, Y: 12, Z: -30})
+		checkCoverage(t)
+		near, far := -1, -1
+		for _, chunk := range terrain.visible {
+			if chunk.X == 0 && chunk.Z == 0 {
+				near = chunk.Level
+			}
+			if chunk.X+chunk.Size == terrain.Resolution && chunk.Z+chunk.Size == terrain.Resolution {
+				far = chunk.Level
+			}
+		}
+		if near != settings.LODLevels-1 {
+			t.Errorf("Chunk under the camera should be at the finest level, got %d", near)
+		}
+		if far >= near {
+			t.Errorf("Far corner should be coarser than the near one: near %d, far %d", near, far)
+		}
+	})
+
+	t.Run("ChunkMeshesHaveSkirts", func(t *testing.T) {
+		terrain := NewTerrainFromNoise(settings, 5, 3)
+		terrain.Update(Point{X: -30, Y: 12, Z: -30})
+		n := settings.ChunkSize
+		for _, mesh := range terrain.VisibleMeshes() {
+			grid := (n + 1) * (n + 1)
+			if len(mesh.Vertices) != grid+4*n {
+				t.Fatalf("Expected %d grid and %d skirt vertices, got %d", grid, 4*n, len(mesh.Vertices))
+			}
+			if !mesh.HasNormals() || len(mesh.UVs) != len(mesh.Vertices) {
+				t.Fatal("Chunk meshes need normals and UVs")
+			}
+			for i := 0; i < 6*n*n; i += 3 {
+				p0, p1, p2 := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+				if crossPoints(subPoints(p1, p0), subPoints(p2, p0)).Y <= 0 {
+					t.Fatalf("Triangle %d faces down", i/3)
+				}
+			}
+			for i := grid; i < len(mesh.Vertices); i++ {
+				v := mesh.Vertices[i]
+				if h := terrain.HeightAt(v.X, v.Z); math.Abs(h-settings.SkirtDepth-v.Y) > 1e-9 {
+					t.Fatalf("Skirt vertex %d should hang %.1f below the surface", i, settings.SkirtDepth)
+				}
+			}
+		}
+	})
+
+	t.Run("SceneIntegration", func(t *testing.T) {
+		scene := NewScene()
+		scene.Camera = NewCameraAt(0, 50, 0)
+		terrain := NewTerrain(settings, func(u, v float64) float64 { return 0.5 })
+		node := scene.CreateTerrain("ground", terrain)
+		node.Transform.SetPosition(300, 0, 0)
+
+		scene.UpdateTerrains()
+		if len(terrain.visible) != 1 {
+			t.Errorf("A camera 268 units away should see one chunk, got %d", len(terrain.visible))
+		}
+
+		hit := scene.Raycast(NewRay(Point{X: 310, Y: 50, Z: 5}, Point{Y: -1}), 1000)
+		if !hit.Hit || hit.Node != node || math.Abs(hit.Point.Y-5) > 1e-9 {
+			t.Fatalf("Expected to hit the terrain at height 5, got %+v", hit)
+		}
+		if math.Abs(hit.Normal.Y-1) > 1e-9 {
+			t.Errorf("Expected an up normal, got %v", hit.Normal)
+		}
+
+		bounds := scene.computeNodeBounds(node)
+		if bounds == nil || math.Abs(bounds.Min.X-268) > 1e-9 || math.Abs(bounds.Max.X-332) > 1e-9 {
+			t.Errorf("Unexpected terrain bounds %+v", bounds)
+		}
+	})
+}
+
+// ============================================================================
+// CSG TESTS
+// ============================================================================
+
+func TestCSG(t *testing.T) {
+	box := func(w, h, d float64, offset Point, color Color) *Mesh {
+		mesh := GenerateBox(w, h, d, 1, 1, 1)
+		for i := range mesh.Vertices {
+			mesh.Vertices[i] = addPoints(mesh.Vertices[i], offset)
+		}
+		mat := NewMaterial()
+		mat.DiffuseColor = color
+		mesh.Material = &mat
+		return mesh
+	}
+	closed := func(t *testing.T, name string, mesh *Mesh) {
+		t.Helper()
+		if report := AnalyzeMesh(mesh, 1e-9); !report.IsClosed() || !report.IsConsistent() {
+			t.Errorf("%s: result should be closed and consistently wound: %s", name, report)
+		}
+	}
+
+	t.Run("Volumes", func(t *testing.T) {
+		a := box(2, 2, 2, Point{}, ColorRed)
+		b := box(2, 2, 2, Point{X: 1}, ColorBlue)
+		tests := []struct {
+			op   CSGOperation
+			want float64
+		}{
+			{CSGUnion, 12},
+			{CSGDifference, 4},
+			{CSGIntersection, 4},
+		}
+		for _, tt := range tests {
+			result := CSG(tt.op, a, b)
+			if v := meshVolume(result); math.Abs(v-tt.want) > 1e-9 {
+				t.Errorf("%v: expected volume %.1f, got %.6f", tt.op, tt.want, v)
+			}
+			if !result.HasNormals() || len(result.UVs) != len(result.Vertices) {
+				t.Errorf("%v: result should keep normals and UVs", tt.op)
+			}
+			closed(t, tt.op.String(), result)
+		}
+
+		// Inputs are left untouched
+		if v := meshVolume(a); math.Abs(v-8) > 1e-9 {
+			t.Errorf("Input mesh changed, volume %.6f", v)
+		}
+	})
+
+	t.Run("CutThroughHole", func(t *testing.T) {
+		wall := box(4, 3, 0.5, Point{}, ColorRed)
+		door := box(1, 2, 2, Point{Y: -0.6}, ColorBlue)
+		result := MeshDifference(wall, door)
+		closed(t, "Doorway", result)
+
+		if v, want := meshVolume(result), 4*3*0.5-1*1.9*0.5; math.Abs(v-want) > 1e-9 {
+			t.Errorf("Expected volume %.2f, got %.6f", want, v)
+		}
+
+		// Hole walls take the door's material, everything else the wall's
+		if !result.HasFaceMaterials() {
+			t.Fatal("Result mixing two materials should have face materials")
+		}
+		for face := 0; face < len(result.Indices)/3; face++ {
+			inside := true
+			for k := 0; k < 3; k++ {
+				p := result.Vertices[result.Indices[face*3+k]]
+				if math.Abs(p.X) > 0.5+1e-9 || p.Y > 0.4+1e-9 {
+					inside = false
+				}
+			}
+			want := wall.Material
+			if inside {
+				want = door.Material
+			}
+			if result.MaterialForFace(face) != want {
+				t.Fatalf("Face %d has the wrong material", face)
+			}
+		}
+
+		parts := result.SplitByMaterial()
+		if len(parts) != 2 || len(parts[0].Indices)+len(parts[1].Indices) != len(result.Indices) {
+			t.Errorf("Expected two parts covering every triangle, got %d", len(parts))
+		}
+
+		// A ray through the doorway passes; one beside it hits the wall
+		scene := NewScene()
+		node := NewSceneNode("wall")
+		node.Object = result
+		scene.AddNode(node)
+		if hit := scene.Raycast(NewRay(Point{Y: -0.5, Z: -5}, Point{Z: 1}), 100); hit.Hit {
+			t.Errorf("Ray through the doorway should miss, hit at %v", hit.Point)
+		}
+		if hit := scene.Raycast(NewRay(Point{X: 1.5, Y: -0.5, Z: -5}, Point{Z: 1}), 100); !hit.Hit || math.Abs(hit.Point.Z+0.25) > 1e-9 {
+			t.Errorf("Ray beside the doorway should hit the wall face, got %+v", hit)
+		}
+	})
+
+	t.Run("UVsInterpolated", func(t *testing.T) {
+		a := box(2, 2, 2, Point{}, ColorRed)
+		b := box(1, 4, 1, Point{X: 0.5, Z: 0.5}, ColorBlue)
+		result := MeshDifference(a, b)
+		closed(t, "Notch", result)
+
+		// UVs are linear across a's top face; split vertices must stay on it
+		var corner [3]TextureCoord
+		for i, v := range a.Vertices {
+			if a.Normals[i] != (Point{Y: 1}) {
+				continue
+			}
+			switch {
+			case v.X < 0 && v.Z < 0:
+				corner[0] = a.UVs[i]
+			case v.X > 0 && v.Z < 0:
+				corner[1] = a.UVs[i]
+			case v.X < 0 && v.Z > 0:
+				corner[2] = a.UVs[i]
+			}
+		}
+
+		checked := 0
+		for i, v := range result.Vertices {
+			if math.Abs(v.Y-1) > 1e-9 || result.Normals[i].Y < 0.999 {
+				continue
+			}
+			fx, fz := (v.X+1)/2, (v.Z+1)/2
+			wantU := corner[0].U + (corner[1].U-corner[0].U)*fx + (corner[2].U-corner[0].U)*fz
+			wantV := corner[0].V + (corner[1].V-corner[0].V)*fx + (corner[2].V-corner[0].V)*fz
+			if math.Abs(result.UVs[i].U-wantU) > 1e-9 || math.Abs(result.UVs[i].V-wantV) > 1e-9 {
+				t.Fatalf("Top vertex %v: expected UV (%.3f, %.3f), got %v", v, wantU, wantV, result.UVs[i])
+			}
+			checked++
+		}
+		if checked <= 4 {
+			t.Errorf("Expected split vertices on the top face, checked %d", checked)
+		}
+	})
+
+	t.Run("SceneNodes", func(t *testing.T) {
+		scene := NewScene()
+		mat := NewMaterial()
+		a := scene.CreateBox("a", 2, 2, 2, 1, 1, 1, &mat)
+		b := scene.CreateIcosphere("b", 1, 2, &mat)
+		b.Transform.SetPosition(1, 0, 0)
+		b.Transform.SetScale(0.5, 0.5, 0.5)
+
+		result, err := CSGNodes(CSGDifference, a, b)
+		if err != nil {
+			t.Fatalf("CSGNodes failed: %v", err)
+		}
+		if result.HasFaceMaterials() {
+			t.Error("Operands sharing a material shouldn't need face materials")
+		}
+		closed(t, "Sphere", result)
+
+		// Half of the scaled sphere is cut out of the box
+		sphere := GenerateIcosphere(0.5, 2)
+		if v, want := meshVolume(result), 8-meshVolume(sphere)/2; math.Abs(v-want) > 1e-6 {
+			t.Errorf("Expected volume %.6f, got %.6f", want, v)
+		}
+
+		if _, err := CSGNodes(CSGUnion, a, scene.CreateEmpty("empty")); err == nil {
+			t.Error("Expected an error for a node without a mesh")
+		}
+	})
+}
+
+// ============================================================================
+// SUBDIVISION TESTS
+// ============================================================================
+
+func TestSubdivision(t *testing.T) {
+	cubeOBJ := "v -1 -1 -1\nv 1 -1 -1\nv 1 1 -1\nv -1 1 -1\n" +
+		"v -1 -1 1\nv 1 -1 1\nv 1 1 1\nv -1 1 1\n" +
+		"vt 0 0\nvt 1 0\nvt 1 1\nvt 0 1\n" +
+		"f 1/1 4/4 3/3 2/2\nf 5/1 6/2 7/3 8/4\nf 1/1 2/2 6/3 5/4\n" +
+		"f 4/1 8/4 7/3 3/2\nf 1/1 5/2 8/3 4/4\nf 2/1 3/2 7/3 6/4\n"
+	loadCube := func(t *testing.T) *Mesh {
+		path := t.TempDir() + "/cube.obj"
+		if err := os.WriteFile(path, []byte(cubeOBJ), 0644); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		mesh, err := LoadOBJ(path)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		return mesh
+	}
+	radiusSpread := func(mesh *Mesh) float64 {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, v := range mesh.Vertices {
+			r := pointLength(v)
+			lo, hi = math.Min(lo, r), math.Max(hi, r)
+		}
+		return hi - lo
+	}
+
+	t.Run("LoopSmoothsTowardsSphere", func(t *testing.T) {
+		base := GenerateIcosphere(1, 0)
+		result := SubdivideLoop(base, DefaultSubdivisionSettings())
+		if len(result.Indices) != 4*len(base.Indices) {
+			t.Errorf("Expected %d triangles, got %d", len(base.Indices)/3*4, len(result.Indices)/3)
+		}
+		if !result.HasNormals() {
+			t.Error("Result should have normals")
+		}
+
+		// Splitting edges at midpoints leaves a 0.15 spread; Loop smooths it
+		if spread := radiusSpread(result); spread > 0.05 {
+			t.Errorf("Expected vertices close to a sphere, radius spread %.3f", spread)
+		}
+		for i := 0; i < len(result.Indices); i += 3 {
+			p0, p1, p2 := result.Vertices[result.Indices[i]], result.Vertices[result.Indices[i+1]], result.Vertices[result.Indices[i+2]]
+			n := crossPoints(subPoints(p1, p0), subPoints(p2, p0))
+			if n.X*p0.X+n.Y*p0.Y+n.Z*p0.Z <= 0 {
+				t.Fatalf("Triangle %d faces inward", i/3)
+			}
+		}
+	})
+
+	t.Run("CatmullClarkQuads", func(t *testing.T) {
+		cube := loadCube(t)
+		if polygons := cube.Polygons(); len(polygons) != 6 || len(polygons[0]) != 4 {
+			t.Fatalf("OBJ cube should keep 6 quads, got %d polygons", len(polygons))
+		}
+
+		result := Subdivide(cube, SubdivisionSettings{Levels: 2})
+		polygons := result.Polygons()
+		if len(polygons) != 6*16 {
+			t.Fatalf("Expected %d quads, got %d", 6*16, len(polygons))
+		}
+		for _, polygon := range polygons {
+			if len(polygon) != 4 {
+				t.Fatalf("Expected only quads, got a %d-gon", len(polygon))
+			}
+		}
+
+		// The cube rounds off: corners pull in, but not past the inscribed sphere
+		for _, v := range result.Vertices {
+			m := math.Max(math.Abs(v.X), math.Max(math.Abs(v.Y), math.Abs(v.Z)))
+			if m > 1-1e-3 || m < 0.5 {
+				t.Fatalf("Vertex %v should be inside the cube and rounded", v)
+			}
+		}
+	})
+
+	t.Run("Creases", func(t *testing.T) {
+		cube := loadCube(t)
+
+		// Every cube edge exceeds the crease angle, so the shape is kept
+		result := SubdivideCatmullClark(cube, SubdivisionSettings{Levels: 2, CreaseAngle: math.Pi / 4})
+		for _, v := range result.Vertices {
+			m := math.Max(math.Abs(v.X), math.Max(math.Abs(v.Y), math.Abs(v.Z)))
+			if math.Abs(m-1) > 1e-9 {
+				t.Fatalf("Vertex %v left the cube surface", v)
+			}
+		}
+
+		// A single explicit crease keeps that edge straight
+		var a, b int
+		for i, v := range cube.Vertices {
+			switch v {
+			case Point{X: -1, Y: -1, Z: -1}:
+				a = i
+			case Point{X: 1, Y: -1, Z: -1}:
+				b = i
+			}
+		}
+		result = SubdivideCatmullClark(cube, SubdivisionSettings{Levels: 1, Creases: [][2]int{{a, b}}})
+		found := false
+		for _, v := range result.Vertices {
+			if v == (Point{Y: -1, Z: -1}) {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("Creased edge midpoint should stay on the edge")
+		}
+	})
+
+	t.Run("UVsInterpolated", func(t *testing.T) {
+		result := SubdivideLoop(GeneratePlane(2, 2, 1, 1), DefaultSubdivisionSettings())
+		if len(result.Vertices) != 9 || len(result.UVs) != 9 {
+			t.Fatalf("Expected a 3x3 grid of vertices, got %d (%d UVs)", len(result.Vertices), len(result.UVs))
+		}
+		seen := make(map[TextureCoord]bool)
+		for _, uv := range result.UVs {
+			for _, c := range []float64{uv.U, uv.V} {
+				if c != 0 && c != 0.5 && c != 1 {
+					t.Fatalf("UV %v isn't on the half grid", uv)
+				}
+			}
+			seen[uv] = true
+		}
+		if len(seen) != 9 {
+			t.Errorf("Expected 9 distinct UVs, got %d", len(seen))
+		}
+	})
+
+	t.Run("OBJRoundTrip", func(t *testing.T) {
+		result := SubdivideCatmullClark(loadCube(t), DefaultSubdivisionSettings())
+		out := t.TempDir() + "/smooth.obj"
+		if err := SaveOBJ(result, out); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		reloaded, err := LoadOBJ(out)
+		if err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+		if len(reloaded.Polygons()) != 24 || len(reloaded.Indices) != len(result.Indices) {
+			t.Errorf("Expected 24 quads back, got %d polygons", len(reloaded.Polygons()))
+		}
+		if len(reloaded.UVs) != len(reloaded.Vertices) {
+			t.Error("Reloaded mesh should keep its UVs")
+		}
+	})
+
+	t.Run("LODChain", func(t *testing.T) {
+		base := GenerateIcosphere(1, 1)
+		lod := GenerateSubdividedLODChain(base, 2, 3, true)
+		if len(lod.Levels) != 5 {
+			t.Fatalf("Expected 5 levels, got %d", len(lod.Levels))
+		}
+		if lod.Levels[2].Mesh != base {
+			t.Error("Base mesh should sit between the subdivided and simplified levels")
+		}
+		for i := 1; i < len(lod.Levels); i++ {
+			if lod.Levels[i].MaxDistance <= lod.Levels[i-1].MaxDistance {
+				t.Errorf("Level %d distance %.0f isn't past level %d", i, lod.Levels[i].MaxDistance, i-1)
+			}
+			if len(lod.Levels[i].Mesh.Indices) >= len(lod.Levels[i-1].Mesh.Indices) {
+				t.Errorf("Level %d should have fewer triangles than level %d", i, i-1)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// MESH REPAIR TESTS
+// ============================================================================
+
+func TestMeshRepair(t *testing.T) {
+	// Triangle soup: every corner its own vertex, as OBJ files load
+	soup := func(mesh *Mesh) *Mesh {
+		out := NewMesh()
+		for _, idx := range mesh.Indices {
+			out.Indices = append(out.Indices, len(out.Vertices))
+			out.Vertices = append(out.Vertices, mesh.Vertices[idx])
+		}
+		return out
+	}
+	flipTriangle := func(mesh *Mesh, tri int) {
+		mesh.Indices[tri*3+1], mesh.Indices[tri*3+2] = mesh.Indices[tri*3+2], mesh.Indices[tri*3+1]
+	}
+
+	t.Run("AnalyzeClosedMesh", func(t *testing.T) {
+		sphere := soup(GenerateIcosphere(1, 1))
+		report := AnalyzeMesh(sphere, 1e-5)
+		if report.Positions != 42 || report.Triangles != 80 {
+			t.Errorf("Expected 42 positions and 80 triangles, got %v", report)
+		}
+		if !report.IsClosed() || !report.IsConsistent() || report.Components != 1 || report.BoundaryLoops != 0 {
+			t.Errorf("Icosphere should be one closed consistent piece, got %v", report)
+		}
+
+		// Exact welding sees the jittered copy as a separate, open piece
+		sphere.Vertices[0].X += 1e-7
+		if report := AnalyzeMesh(sphere, 0); report.IsClosed() {
+			t.Error("Jittered vertex should open the mesh without a tolerance")
+		}
+		if report := AnalyzeMesh(sphere, 1e-5); !report.IsClosed() {
+			t.Error("Jittered vertex should weld within the tolerance")
+		}
+	})
+
+	t.Run("WeldVertices", func(t *testing.T) {
+		sphere := soup(GenerateIcosphere(1, 1))
+		for i := range sphere.Vertices {
+			sphere.Vertices[i].Y += float64(i%3) * 1e-7
+		}
+		if removed := sphere.WeldVertices(1e-5); removed != 240-42 {
+			t.Errorf("Expected %d vertices removed, got %d", 240-42, removed)
+		}
+		if len(sphere.Vertices) != 42 {
+			t.Errorf("Expected 42 vertices, got %d", len(sphere.Vertices))
+		}
+
+		// Seams survive: a box keeps its per-face normals
+		box := GenerateBox(2, 2, 2, 1, 1, 1)
+		before := len(box.Vertices)
+		if removed := box.WeldVertices(1e-5); removed != 0 || len(box.Vertices) != before {
+			t.Errorf("Box vertices differ in normal and shouldn't weld, removed %d", removed)
+		}
+	})
+
+	t.Run("DegenerateAndDuplicate", func(t *testing.T) {
+		mesh := soup(GenerateIcosphere(1, 1))
+		mesh.Vertices = append(mesh.Vertices, Point{X: 5}, Point{X: 6}, Point{X: 7, Y: 1e-9})
+		n := len(mesh.Vertices)
+		mesh.Indices = append(mesh.Indices,
+			0, 0, 1, // Repeated corner
+			n-3, n-2, n-1, // Collinear sliver
+			0, 2, 1, // Duplicate of the first triangle, wound the other way
+		)
+
+		if got := AnalyzeMesh(mesh, 1e-5); got.DegenerateTriangles != 2 || got.DuplicateTriangles != 1 {
+			t.Errorf("Expected 2 degenerate and 1 duplicate triangles, got %v", got)
+		}
+		if removed := mesh.RemoveDegenerateTriangles(1e-5); removed != 2 {
+			t.Errorf("Expected 2 degenerate triangles removed, got %d", removed)
+		}
+		if removed := mesh.RemoveDuplicateTriangles(1e-5); removed != 1 {
+			t.Errorf("Expected 1 duplicate removed, got %d", removed)
+		}
+		if len(mesh.Indices) != 240 || !AnalyzeMesh(mesh, 1e-5).IsClosed() {
+			t.Error("Cleaned mesh should be the closed icosphere again")
+		}
+	})
+
+	t.Run("FixWinding", func(t *testing.T) {
+		mesh := soup(GenerateIcosphere(1, 1))
+		want := meshVolume(mesh)
+		for tri := 0; tri < 80; tri += 7 {
+			flipTriangle(mesh, tri)
+		}
+		if report := AnalyzeMesh(mesh, 1e-5); report.IsConsistent() {
+			t.Fatal("Flipped triangles should be reported")
+		}
+		if flipped := mesh.FixWinding(1e-5); flipped != 12 {
+			t.Errorf("Expected 12 triangles flipped back, got %d", flipped)
+		}
+		if v := meshVolume(mesh); math.Abs(v-want) > 1e-9 {
+			t.Errorf("Expected volume %.6f, got %.6f", want, v)
+		}
+
+		// An inside-out mesh is turned outward
+		for tri := 0; tri < 80; tri++ {
+			flipTriangle(mesh, tri)
+		}
+		if flipped := mesh.FixWinding(1e-5); flipped != 80 || meshVolume(mesh) <= 0 {
+			t.Errorf("Inside-out mesh should be turned outward, flipped %d", flipped)
+		}
+
+		// An open patch keeps the orientation most of it has
+		plane := GeneratePlane(2, 2, 4, 4)
+		plane.Normals = nil
+		flipTriangle(plane, 3)
+		if flipped := plane.FixWinding(1e-5); flipped != 1 {
+			t.Errorf("Expected the single flipped plane triangle fixed, got %d", flipped)
+		}
+	})
+
+	t.Run("FixNormals", func(t *testing.T) {
+		sphere := GenerateIcosphere(1, 1)
+		if !sphere.HasNormals() {
+			sphere.GenerateNormals(DefaultCreaseAngle)
+		}
+		sphere.Normals[3] = scalePoint(sphere.Normals[3], -1)
+		sphere.Normals[7] = scalePoint(sphere.Normals[7], -1)
+		if flipped := sphere.FixNormals(); flipped != 2 {
+			t.Errorf("Expected 2 normals flipped, got %d", flipped)
+		}
+		for i, n := range sphere.Normals {
+			v := sphere.Vertices[i]
+			if n.X*v.X+n.Y*v.Y+n.Z*v.Z <= 0 {
+				t.Fatalf("Normal %d still points inward", i)
+			}
+		}
+	})
+
+	t.Run("FillHoles", func(t *testing.T) {
+		sphere := GenerateIcosphere(1, 1)
+		mesh := soup(sphere)
+		mat := NewMaterial()
+		for range mesh.Indices[:len(mesh.Indices)/3] {
+			mesh.FaceMaterials = append(mesh.FaceMaterials, &mat)
+		}
+
+		// Remove one triangle, and every triangle around a far vertex
+		keep := make([]bool, 80)
+		far := sphere.Vertices[sphere.Indices[3*40]]
+		for tri := range keep {
+			keep[tri] = tri != 0
+			for k := 0; k < 3; k++ {
+				if mesh.Vertices[mesh.Indices[tri*3+k]] == far {
+					keep[tri] = false
+				}
+			}
+		}
+		mesh.keepTriangles(keep)
+		if report := AnalyzeMesh(mesh, 1e-5); report.BoundaryLoops != 2 {
+			t.Fatalf("Expected 2 holes, got %v", report)
+		}
+
+		if filled := mesh.FillHoles(3, 1e-5); filled != 1 {
+			t.Errorf("Only the triangular hole is small enough, filled %d", filled)
+		}
+		if filled := mesh.FillHoles(8, 1e-5); filled != 1 {
+			t.Errorf("Expected the fan hole filled, filled %d", filled)
+		}
+		report := AnalyzeMesh(mesh, 1e-5)
+		if !report.IsClosed() || !report.IsConsistent() {
+			t.Errorf("Filled mesh should be closed and consistent, got %v", report)
+		}
+		// The fan over the hole has as many triangles as were cut out
+		if len(mesh.Indices)/3 != 80 || !mesh.HasFaceMaterials() {
+			t.Errorf("Unexpected triangle count %d or lost face materials", len(mesh.Indices)/3)
+		}
+		if v := meshVolume(mesh); v <= 0 || v > meshVolume(sphere) {
+			t.Errorf("Patched volume %.4f should be positive and below the sphere's %.4f", v, meshVolume(sphere))
+		}
+	})
+
+	t.Run("RepairOBJ", func(t *testing.T) {
+		// A cube with one face wound backwards and one face missing
+		obj := "v -1 -1 -1\nv 1 -1 -1\nv 1 1 -1\nv -1 1 -1\n" +
+			"v -1 -1 1\nv 1 -1 1\nv 1 1 1\nv -1 1 1\n" +
+			"f 1 4 3 2\nf 5 8 7 6\nf 1 2 6 5\nf 4 8 7 3\nf 1 5 8 4\n"
+		path := t.TempDir() + "/broken.obj"
+		if err := os.WriteFile(path, []byte(obj), 0644); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+
+		am := NewAssetManager()
+		settings := DefaultRepairSettings()
+		am.Repair = &settings
+		mesh, err := am.LoadMesh(path)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		report := AnalyzeMesh(mesh, 1e-5)
+		if !report.IsClosed() || !report.IsConsistent() {
+			t.Errorf("Repaired cube should be closed and consistent, got %v", report)
+		}
+		if v := meshVolume(mesh); math.Abs(v-8) > 1e-9 {
+			t.Errorf("Expected outward volume 8, got %.6f", v)
+		}
+
+		original, err := LoadOBJ(path)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if _, r := RepairMesh(original, settings); r.FlippedTriangles == 0 || r.FilledHoles != 1 {
+			t.Errorf("Expected flipped triangles and one filled hole, got %+v", r)
+		}
+	})
+}
+
+// ============================================================================
+// QEM SIMPLIFICATION TESTS
+// ============================================================================
+
+func TestQEMSimplification(t *testing.T) {
+	t.Run("TargetWithoutTearingSeams", func(t *testing.T) {
+		sphere := GenerateSphere(5, 32, 32)
+		settings := DefaultQEMSettings()
+		settings.TargetTriangles = 400
+		result, geometricError := SimplifyMeshQEMWithSettings(sphere, settings)
+
+		if got := len(result.Indices) / 3; got != 400 {
+			t.Errorf("Expected exactly 400 triangles, got %d", got)
+		}
+		if geometricError <= 0 || geometricError > 0.5 {
+			t.Errorf("Expected a small positive error, got %.4f", geometricError)
+		}
+		if report := AnalyzeMesh(result, 1e-5); !report.IsClosed() || !report.IsConsistent() {
+			t.Errorf("Simplified sphere should stay closed across its UV seam, got %v", report)
+		}
+
+		// Every vertex keeps a UV the source had at that position
+		sourceUVs := make(map[Point][]TextureCoord)
+		for i, v := range sphere.Vertices {
+			sourceUVs[weldKey(v)] = append(sourceUVs[weldKey(v)], sphere.UVs[i])
+		}
+		for i, v := range result.Vertices {
+			found := false
+			for _, uv := range sourceUVs[weldKey(v)] {
+				if uv == result.UVs[i] {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("Vertex %v has UV %v, not one of the source's", v, result.UVs[i])
+			}
+		}
+	})
+
+	t.Run("BordersKept", func(t *testing.T) {
+		plane := GeneratePlane(4, 4, 16, 16)
+		result, geometricError := SimplifyMeshQEMWithSettings(plane, QEMSettings{TargetTriangles: 2, BoundaryWeight: 10})
+		if len(result.Indices) != 6 || geometricError > 1e-9 {
+			t.Errorf("Flat plane should collapse to 2 triangles without error, got %d (error %.4f)", len(result.Indices)/3, geometricError)
+		}
+		bounds := ComputeMeshBounds(result).(*AABB)
+		if bounds.Min != (Point{X: -2, Z: -2}) || bounds.Max != (Point{X: 2, Z: 2}) {
+			t.Errorf("Border shrank to %v - %v", bounds.Min, bounds.Max)
+		}
+	})
+
+	t.Run("ErrorThreshold", func(t *testing.T) {
+		sphere := GenerateSphere(5, 32, 32)
+		settings := DefaultQEMSettings()
+		settings.MaxError = 0.05
+		result, geometricError := SimplifyMeshQEMWithSettings(sphere, settings)
+		if geometricError > 0.05 {
+			t.Errorf("Error %.4f exceeds the threshold", geometricError)
+		}
+		if n := len(result.Indices) / 3; n >= len(sphere.Indices)/3 || n < 100 {
+			t.Errorf("Expected some but not all triangles removed, got %d", n)
+		}
+	})
+
+	t.Run("FaceMaterialsKept", func(t *testing.T) {
+		sphere := GenerateSphere(5, 16, 16)
+		top, bottom := NewMaterial(), NewMaterial()
+		for tri := 0; tri < len(sphere.Indices)/3; tri++ {
+			if sphere.Vertices[sphere.Indices[tri*3]].Y+sphere.Vertices[sphere.Indices[tri*3+1]].Y+sphere.Vertices[sphere.Indices[tri*3+2]].Y > 0 {
+				sphere.FaceMaterials = append(sphere.FaceMaterials, &top)
+			} else {
+				sphere.FaceMaterials = append(sphere.FaceMaterials, &bottom)
+			}
+		}
+		result := SimplifyMeshQEM(sphere, 100)
+		if !result.HasFaceMaterials() {
+			t.Fatal("Face materials should survive simplification")
+		}
+		for tri, material := range result.FaceMaterials {
+			y := 0.0
+			for k := 0; k < 3; k++ {
+				y += result.Vertices[result.Indices[tri*3+k]].Y
+			}
+			if (material == &top) != (y > 0) {
+				t.Fatalf("Triangle %d crossed the material border", tri)
+			}
+		}
+	})
+
+	t.Run("DistancesFromError", func(t *testing.T) {
+		lod := GenerateAdvancedLODChain(GenerateSphere(5, 32, 32), 4, true)
+		for i := 1; i < len(lod.Levels); i++ {
+			if lod.Levels[i].Error < lod.Levels[i-1].Error {
+				t.Errorf("Level %d error %.4f is below level %d's", i, lod.Levels[i].Error, i-1)
+			}
+		}
+
+		lod.SetDistancesFromError(FOV_Y, 0.5)
+		for i := 0; i < len(lod.Levels)-1; i++ {
+			want := lod.Levels[i+1].Error * FOV_Y / 0.5
+			if got := lod.Levels[i].MaxDistance; math.Abs(got-want) > 1e-9 {
+				t.Errorf("Level %d: expected switch at %.3f, got %.3f", i, want, got)
+			}
+		}
+		if !math.IsInf(lod.Levels[len(lod.Levels)-1].MaxDistance, 1) {
+			t.Error("Coarsest level should be used at any distance")
+		}
+
+		camera := NewCamera()
+		camera.SetPosition(0, 0, -1000)
+		if got := lod.SelectLOD(Point{}, camera); got != len(lod.Levels)-1 {
+			t.Errorf("Expected the coarsest level far away, got %d", got)
+		}
+	})
+
+	t.Run("ErrorOnSharedMesh", func(t *testing.T) {
+		mesh := GenerateSphere(5, 8, 8)
+		lod := NewLODGroup()
+		lod.AddLODWithError(mesh, 50, 0)
+		lod.AddLODWithError(mesh, 100, 2)
+		if lod.Levels[0].Error != 0 || lod.Levels[1].Error != 2 {
+			t.Errorf("Expected errors 0 and 2, got %.1f and %.1f", lod.Levels[0].Error, lod.Levels[1].Error)
+		}
+	})
+}
+
+// ============================================================================
+// CONVEX HULL TESTS
+// ============================================================================
+
+func TestConvexHull(t *testing.T) {
+	cube := func() []Point {
+		var points []Point
+		for _, x := range []float64{-1, 1} {
+			for _, y := range []float64{-1, 1} {
+				for _, z := range []float64{-1, 1} {
+					points = append(points, Point{X: x, Y: y, Z: z})
+				}
+			}
+		}
+		// Interior and face points must not end up on the hull
+		for i := 0; i < 50; i++ {
+			f := float64(i) / 50
+			points = append(points, Point{X: f - 0.5, Y: 0.3 - f*0.5, Z: f * 0.9}, Point{X: 1, Y: f - 0.5, Z: 0.2})
+		}
+		return points
+	}
+
+	t.Run("Cube", func(t *testing.T) {
+		hull, err := ComputeConvexHull(cube())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hull.Vertices) != 8 || len(hull.Faces) != 12 {
+			t.Errorf("Expected 8 vertices and 12 faces, got %d and %d", len(hull.Vertices), len(hull.Faces))
+		}
+		if v := hull.Volume(); math.Abs(v-8) > 1e-9 {
+			t.Errorf("Expected volume 8, got %.6f", v)
+		}
+		if report := AnalyzeMesh(hull.ToMesh(), 1e-9); !report.IsClosed() || !report.IsConsistent() {
+			t.Errorf("Hull mesh should be closed and consistently wound: %s", report)
+		}
+	})
+
+	t.Run("Degenerate", func(t *testing.T) {
+		flat := []Point{{X: 0}, {X: 1}, {Y: 1}, {X: 1, Y: 1}, {X: 0.5, Y: 0.5}}
+		if _, err := ComputeConvexHull(flat); err != ErrDegenerateHull {
+			t.Errorf("Expected ErrDegenerateHull for coplanar points, got %v", err)
+		}
+	})
+
+	t.Run("SphereContainsPoints", func(t *testing.T) {
+		sphere := GenerateSphere(5, 12, 16)
+		sphere.Position = Point{X: 3, Y: -2, Z: 10}
+		hull, err := ComputeMeshConvexHull(sphere)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range sphere.Vertices {
+			if !hull.Contains(addPoints(v, sphere.Position)) {
+				t.Fatalf("Hull should contain vertex %v", v)
+			}
+		}
+		if hull.Contains(Point{X: 3, Y: -2, Z: 16}) {
+			t.Error("Point outside the sphere should be outside the hull")
+		}
+		if v, want := hull.Volume(), 4.0/3*math.Pi*125; v > want || v < want*0.8 {
+			t.Errorf("Volume %.2f is not close to the sphere's %.2f", v, want)
+		}
+	})
+
+	t.Run("DenseSphere", func(t *testing.T) {
+		sphere := GenerateIcosphere(1, 5)
+		hull, err := ComputeMeshConvexHull(sphere)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unique := make(map[Point]bool)
+		for _, v := range sphere.Vertices {
+			unique[v] = true
+		}
+		if len(hull.Vertices) != len(unique) {
+			t.Errorf("Every vertex of a sphere is on its hull: expected %d, got %d", len(unique), len(hull.Vertices))
+		}
+		if report := AnalyzeMesh(hull.ToMesh(), 1e-12); !report.IsClosed() || !report.IsConsistent() {
+			t.Errorf("Hull mesh should be closed and consistently wound: %s", report)
+		}
+	})
+
+	t.Run("Intersects", func(t *testing.T) {
+		hull, _ := ComputeConvexHull(cube())
+		tests := []struct {
+			name   string
+			volume BoundingVolume
+			want   bool
+		}{
+			{"OverlappingAABB", NewAABB(Point{X: 0.5, Y: 0.5, Z: 0.5}, Point{X: 3, Y: 3, Z: 3}), true},
+			{"SeparateAABB", NewAABB(Point{X: 1.5}, Point{X: 3, Y: 1, Z: 1}), false},
+			{"TouchingSphere", NewBoundingSphere(Point{X: 1.9, Y: 1.9}, 1.3), true},
+			{"CornerSphere", NewBoundingSphere(Point{X: 1.9, Y: 1.9, Z: 1.9}, 1.3), false},
+		}
+		for _, tt := range tests {
+			if got := hull.Intersects(tt.volume); got != tt.want {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+			}
+		}
+
+		transform := NewTransform()
+		transform.SetPosition(1.5, 0, 0)
+		if !hull.IntersectsHull(hull.Transformed(transform)) {
+			t.Error("Hull moved by half its width should still overlap")
+		}
+		transform.SetPosition(0, 2.5, 0)
+		if hull.IntersectsHull(hull.Transformed(transform)) {
+			t.Error("Hull moved past its height should not overlap")
+		}
+	})
+
+	t.Run("Ray", func(t *testing.T) {
+		hull, _ := ComputeConvexHull(cube())
+		hit, dist := hull.IntersectsRay(NewRay(Point{Z: -5}, Point{Z: 1}))
+		if !hit || math.Abs(dist-4) > 1e-9 {
+			t.Errorf("Expected a hit at 4, got %v at %.4f", hit, dist)
+		}
+		if hit, _ := hull.IntersectsRay(NewRay(Point{X: 2, Z: -5}, Point{Z: 1})); hit {
+			t.Error("Ray beside the hull should miss")
+		}
+	})
+
+	t.Run("Frustum", func(t *testing.T) {
+		// A box from -10 to 10 on every axis, planes facing in
+		frustum := ViewFrustum{}
+		for i, n := range []Point{{X: 1}, {X: -1}, {Y: -1}, {Y: 1}, {Z: 1}, {Z: -1}} {
+			frustum.Planes[i] = FrustumPlane{Normal: n, Distance: 10}
+		}
+		hull, _ := ComputeConvexHull(cube())
+		transform := NewTransform()
+		transform.SetPosition(10.5, 0, 0)
+		if !frustum.TestConvexHull(hull.Transformed(transform)) {
+			t.Error("Hull straddling a plane should be visible")
+		}
+		transform.SetPosition(0, 0, -11.5)
+		if frustum.TestConvexHull(hull.Transformed(transform)) {
+			t.Error("Hull past the near plane should be culled")
+		}
+	})
+
+	t.Run("Silhouette", func(t *testing.T) {
+		hull, _ := ComputeConvexHull(cube())
+		edges := hull.SilhouetteEdges(Point{Y: 1})
+		if len(edges) != 4 {
+			t.Fatalf("Expected 4 silhouette edges around the top face, got %d", len(edges))
+		}
+		for _, e := range edges {
+			if e[0].Y != 1 || e[1].Y != 1 {
+				t.Errorf("Edge %v should lie on the top face", e)
+			}
+		}
+	})
+
+	t.Run("Decomposition", func(t *testing.T) {
+		// An L made of two boxes
+		a := GenerateBox(4, 1, 1, 1, 1, 1)
+		b := GenerateBox(1, 3, 1, 1, 1, 1)
+		for i := range a.Vertices {
+			a.Vertices[i].X += 1.5
+		}
+		for i := range b.Vertices {
+			b.Vertices[i].Y += 1
+		}
+		shape := MeshUnion(a, b)
+
+		hulls, err := ConvexDecomposition(shape, DefaultConvexDecompositionSettings())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hulls) < 2 {
+			t.Fatalf("Expected the L to be split, got %d hull(s)", len(hulls))
+		}
+		total := 0.0
+		for _, h := range hulls {
+			total += h.Volume()
+		}
+		if total < 6-1e-6 || total > 6*1.05 {
+			t.Errorf("Hull volumes should add up to about 6, got %.4f", total)
+		}
+
+		// A ring only gets less concave after several cuts
+		ring, _ := ConvexDecomposition(GenerateTorus(5, 1.5, 16, 12), DefaultConvexDecompositionSettings())
+		if len(ring) < 4 {
+			t.Errorf("Expected a torus to need several hulls, got %d", len(ring))
+		}
+
+		box, _ := ConvexDecomposition(GenerateBox(2, 2, 2, 1, 1, 1), DefaultConvexDecompositionSettings())
+		if len(box) != 1 {
+			t.Errorf("A box is already convex, got %d hulls", len(box))
+		}
+	})
+}
+
+// ============================================================================
+// MESH OPTIMIZATION TESTS
+// ============================================================================
+
+func TestMeshOptimization(t *testing.T) {
+	// A grid with its triangles shuffled, as an exporter might leave it
+	shuffled := func() *Mesh {
+		mesh := GeneratePlane(10, 10, 32, 32)
+		rng := rand.New(rand.NewSource(7))
+		mesh.reorderTriangles(rng.Perm(len(mesh.Indices) / 3))
+		return mesh
+	}
+	less := func(a, b Point) bool {
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	}
+	// Triangles rotated to start at their least corner, keeping the winding
+	triangleSet := func(mesh *Mesh) map[[3]Point]int {
+		set := make(map[[3]Point]int)
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			a, b, c := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+			for !(less(a, b) && less(a, c)) {
+				a, b, c = b, c, a
+			}
+			set[[3]Point{a, b, c}]++
+		}
+		return set
+	}
+	sameTriangles := func(t *testing.T, a, b *Mesh) {
+		t.Helper()
+		setA, setB := triangleSet(a), triangleSet(b)
+		if len(setA) != len(setB) {
+			t.Fatalf("Expected %d distinct triangles, got %d", len(setA), len(setB))
+		}
+		for k, n := range setA {
+			if setB[k] != n {
+				t.Fatalf("Triangle %v missing or rewound", k)
+			}
+		}
+	}
+
+	t.Run("CacheStats", func(t *testing.T) {
+		stats := AnalyzeVertexCache([]int{0, 1, 2, 2, 1, 3}, 4, 16)
+		if stats.Misses != 4 || stats.ACMR != 2 || stats.ATVR != 1 {
+			t.Errorf("Expected 4 misses, ACMR 2 and ATVR 1, got %s", stats)
+		}
+		stats = AnalyzeVertexCache([]int{0, 1, 2, 3, 4, 5, 0, 1, 2}, 6, 3)
+		if stats.Misses != 9 {
+			t.Errorf("Evicted vertices should miss again, got %d misses", stats.Misses)
+		}
+	})
+
+	t.Run("VertexCache", func(t *testing.T) {
+		for _, algorithm := range []VertexCacheAlgorithm{VertexCacheForsyth, VertexCacheTipsify} {
+			mesh := shuffled()
+			before := mesh.VertexCacheStats(16)
+			optimized := mesh.Clone()
+			optimized.OptimizeVertexCache(algorithm, 16)
+			after := optimized.VertexCacheStats(16)
+			if after.ACMR > 0.8 || after.ACMR >= before.ACMR {
+				t.Errorf("Algorithm %d: ACMR %.3f -> %.3f, expected below 0.8", algorithm, before.ACMR, after.ACMR)
+			}
+			sameTriangles(t, mesh, optimized)
+		}
+	})
+
+	t.Run("Overdraw", func(t *testing.T) {
+		mesh := GenerateSphere(5, 32, 32)
+		mesh.OptimizeVertexCache(VertexCacheTipsify, 16)
+		cacheOnly := mesh.VertexCacheStats(16)
+
+		optimized := mesh.Clone()
+		optimized.OptimizeOverdraw(16, 1.05)
+		if acmr := optimized.VertexCacheStats(16).ACMR; acmr > cacheOnly.ACMR*1.1 {
+			t.Errorf("ACMR went from %.3f to %.3f, more than the threshold allows", cacheOnly.ACMR, acmr)
+		}
+		sameTriangles(t, mesh, optimized)
+	})
+
+	t.Run("VertexFetch", func(t *testing.T) {
+		mesh := shuffled()
+		mesh.GenerateNormals(DefaultCreaseAngle)
+		mesh.Vertices = append(mesh.Vertices, Point{X: 100})
+		mesh.Normals = append(mesh.Normals, Point{Y: 1})
+		if len(mesh.UVs) > 0 {
+			mesh.UVs = append(mesh.UVs, TextureCoord{})
+		}
+		original := mesh.Clone()
+
+		if dropped := mesh.OptimizeVertexFetch(); dropped != 1 {
+			t.Errorf("Expected the unused vertex dropped, got %d", dropped)
+		}
+		next := 0
+		for _, v := range mesh.Indices {
+			if v > next {
+				t.Fatalf("Vertex %d used before vertex %d", v, next)
+			}
+			if v == next {
+				next++
+			}
+		}
+		for i, v := range mesh.Indices {
+			if mesh.Vertices[v] != original.Vertices[original.Indices[i]] || mesh.Normals[v] != original.Normals[original.Indices[i]] {
+				t.Fatalf("Corner %d lost its vertex data", i)
+			}
+		}
+	})
+
+	t.Run("PerTriangleData", func(t *testing.T) {
+		mesh := shuffled()
+		left, right := NewMaterial(), NewMaterial()
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			if mesh.Vertices[mesh.Indices[i]].X+mesh.Vertices[mesh.Indices[i+1]].X+mesh.Vertices[mesh.Indices[i+2]].X < 0 {
+				mesh.FaceMaterials = append(mesh.FaceMaterials, &left)
+			} else {
+				mesh.FaceMaterials = append(mesh.FaceMaterials, &right)
+			}
+		}
+
+		optimized, report := OptimizeMesh(mesh, DefaultMeshOptimizationSettings())
+		if report.After.ACMR >= report.Before.ACMR {
+			t.Errorf("Expected a better ACMR: %s -> %s", report.Before, report.After)
+		}
+		for tri, material := range optimized.FaceMaterials {
+			x := 0.0
+			for k := 0; k < 3; k++ {
+				x += optimized.Vertices[optimized.Indices[tri*3+k]].X
+			}
+			if (material == &left) != (x < 0) {
+				t.Fatalf("Triangle %d lost its material", tri)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// MESHLET TESTS
+// ============================================================================
+
+func TestMeshlets(t *testing.T) {
+	build := func() *Mesh {
+		mesh := GenerateSphere(10, 48, 48)
+		mesh.BuildMeshlets(DefaultMeshletSettings())
+		return mesh
+	}
+	triangleNormal := func(mesh *Mesh, tri int) Point {
+		a, b, c := mesh.Vertices[mesh.Indices[tri*3]], mesh.Vertices[mesh.Indices[tri*3+1]], mesh.Vertices[mesh.Indices[tri*3+2]]
+		return crossPoints(subPoints(b, a), subPoints(c, a))
+	}
+
+	t.Run("Limits", func(t *testing.T) {
+		mesh := build()
+		if !mesh.HasMeshlets() || len(mesh.Meshlets) < 2 {
+			t.Fatalf("Expected the sphere split into meshlets, got %d", len(mesh.Meshlets))
+		}
+		next := 0
+		for i, meshlet := range mesh.Meshlets {
+			if meshlet.FirstTriangle != next {
+				t.Fatalf("Meshlet %d starts at %d, expected %d", i, meshlet.FirstTriangle, next)
+			}
+			next += meshlet.TriangleCount
+			if len(meshlet.Vertices) > 64 || meshlet.TriangleCount > 124 {
+				t.Errorf("Meshlet %d has %d vertices and %d triangles", i, len(meshlet.Vertices), meshlet.TriangleCount)
+			}
+
+			used := make(map[int]bool)
+			for _, v := range meshlet.Vertices {
+				used[v] = true
+				if d := pointLength(subPoints(mesh.Vertices[v], meshlet.Center)); d > meshlet.Radius+1e-9 {
+					t.Errorf("Meshlet %d: vertex %d outside the bounding sphere", i, v)
+				}
+			}
+			for k := meshlet.FirstTriangle * 3; k < (meshlet.FirstTriangle+meshlet.TriangleCount)*3; k++ {
+				if !used[mesh.Indices[k]] {
+					t.Fatalf("Meshlet %d uses vertex %d without listing it", i, mesh.Indices[k])
+				}
+			}
+		}
+		if next*3 != len(mesh.Indices) {
+			t.Errorf("Meshlets cover %d triangles of %d", next, len(mesh.Indices)/3)
+		}
+	})
+
+	t.Run("NormalCones", func(t *testing.T) {
+		mesh := build()
+		narrow := 0
+		for i, meshlet := range mesh.Meshlets {
+			if meshlet.ConeCutoff >= 1 {
+				continue
+			}
+			narrow++
+			minDot := math.Sqrt(1 - meshlet.ConeCutoff*meshlet.ConeCutoff)
+			for tri := meshlet.FirstTriangle; tri < meshlet.FirstTriangle+meshlet.TriangleCount; tri++ {
+				n := triangleNormal(mesh, tri)
+				if pointLength(n) == 0 {
+					continue
+				}
+				if d := dotPoints(normalizePoint(n), meshlet.ConeAxis); d < minDot-1e-9 {
+					t.Fatalf("Meshlet %d: triangle %d faces outside the cone", i, tri)
+				}
+			}
+		}
+		if narrow < len(mesh.Meshlets)/2 {
+			t.Errorf("Only %d of %d meshlets have a usable normal cone", narrow, len(mesh.Meshlets))
+		}
+	})
+
+	t.Run("Culling", func(t *testing.T) {
+		mesh := build()
+		camera := NewCamera()
+		frustum := BuildFrustumSimple(camera)
+		visible := mesh.VisibleMeshlets(IdentityMatrix(), &frustum, camera.GetPosition())
+		if len(visible) == 0 || len(visible) > len(mesh.Meshlets)*3/4 {
+			t.Errorf("Expected the far side culled, %d of %d meshlets visible", len(visible), len(mesh.Meshlets))
+		}
+
+		// Culled meshlets must not hold any triangle facing the camera
+		kept := make(map[*Meshlet]bool)
+		for _, meshlet := range visible {
+			kept[meshlet] = true
+		}
+		for i := range mesh.Meshlets {
+			meshlet := &mesh.Meshlets[i]
+			if kept[meshlet] {
+				continue
+			}
+			for tri := meshlet.FirstTriangle; tri < meshlet.FirstTriangle+meshlet.TriangleCount; tri++ {
+				p := mesh.Vertices[mesh.Indices[tri*3]]
+				if dotPoints(triangleNormal(mesh, tri), subPoints(camera.GetPosition(), p)) > 1e-9 {
+					t.Fatalf("Meshlet %d was culled with triangle %d facing the camera", i, tri)
+				}
+			}
+		}
+
+		mesh.Position = Point{X: 5000}
+		if n := len(mesh.VisibleMeshlets(IdentityMatrix(), &frustum, camera.GetPosition())); n != 0 {
+			t.Errorf("Expected every meshlet outside the frustum, %d visible", n)
+		}
+	})
+
+	t.Run("VertexEditsInvalidate", func(t *testing.T) {
+		passes := []struct {
+			name string
+			run  func(mesh *Mesh)
+		}{
+			{"OptimizeVertexFetch", func(mesh *Mesh) { mesh.OptimizeVertexFetch() }},
+			{"WeldVertices", func(mesh *Mesh) { mesh.WeldVertices(1e-6) }},
+			{"GenerateNormals", func(mesh *Mesh) { mesh.GenerateNormals(0.05) }},
+			{"GenerateTangents", func(mesh *Mesh) {
+				if err := mesh.GenerateTangents(); err != nil {
+					t.Fatalf("GenerateTangents: %v", err)
+				}
+			}},
+		}
+		for _, pass := range passes {
+			// Smooth normals, and UVs mirrored at x=0 so tangents split there
+			mesh := GenerateIcosphere(1, 2)
+			mesh.GenerateNormals(math.Pi)
+			mesh.UVs = make([]TextureCoord, len(mesh.Vertices))
+			for i, v := range mesh.Vertices {
+				mesh.UVs[i] = TextureCoord{U: math.Abs(v.X), V: v.Y}
+			}
+			mesh.BuildMeshlets(DefaultMeshletSettings())
+			if !mesh.HasMeshlets() {
+				t.Fatal("Expected meshlets to be built")
+			}
+			vertices := len(mesh.Vertices)
+			pass.run(mesh)
+			if mesh.HasMeshlets() {
+				t.Errorf("%s: meshlets should be dropped after renumbering vertices (%d -> %d)", pass.name, vertices, len(mesh.Vertices))
+			}
+		}
+
+		// Flipped triangles turn the normal cones around
+		inverted := GenerateIcosphere(1, 2)
+		for i := 0; i < len(inverted.Indices); i += 3 {
+			inverted.Indices[i+1], inverted.Indices[i+2] = inverted.Indices[i+2], inverted.Indices[i+1]
+		}
+		inverted.BuildMeshlets(DefaultMeshletSettings())
+		if inverted.FixWinding(1e-9) == 0 {
+			t.Fatal("Expected FixWinding to turn the inverted sphere outward")
+		}
+		if inverted.HasMeshlets() {
+			t.Error("FixWinding: meshlets should be dropped after flipping triangles")
+		}
+
+		// Vertices added behind the mesh's back are caught too
+		mesh := build()
+		mesh.Vertices = append(mesh.Vertices, Point{})
+		if mesh.HasMeshlets() {
+			t.Error("Meshlets built for fewer vertices should not be used")
+		}
+	})
+
+	t.Run("RenderMatches", func(t *testing.T) {
+		plain := GenerateSphere(10, 24, 24)
+		clustered := plain.Clone()
+		clustered.BuildMeshlets(DefaultMeshletSettings())
+
+		camera := NewCamera()
+		camera.SetPosition(0, 0, -40)
+		render := func(mesh *Mesh) [][]rune {
+			r := NewTerminalRenderer(bufio.NewWriter(io.Discard), 40, 80)
+			r.RenderMesh(mesh, IdentityMatrix(), camera)
+			return r.Surface
+		}
+		want, got := render(plain), render(clustered)
+		drawn := 0
+		for y := range want {
+			for x := range want[y] {
+				if want[y][x] != got[y][x] {
+					t.Fatalf("Pixel (%d, %d) differs with meshlet culling", x, y)
+				}
+				if want[y][x] != DefaultCharset[0] {
+					drawn++
+				}
+			}
+		}
+		if drawn == 0 {
+			t.Error("Expected the sphere on screen")
+		}
+	})
+}
+
+// ============================================================================
+// ISOSURFACE TESTS
+// ============================================================================
+
+func TestIsosurface(t *testing.T) {
+	closed := func(t *testing.T, mesh *Mesh) {
+		t.Helper()
+		if len(mesh.Indices) == 0 {
+			t.Fatal("Expected a surface")
+		}
+		if report := AnalyzeMesh(mesh, 1e-9); !report.IsClosed() || !report.IsConsistent() || report.DegenerateTriangles > 0 {
+			t.Errorf("Surface should be closed, consistently wound and free of degenerate triangles: %s", report)
+		}
+		if !mesh.HasNormals() {
+			t.Error("Surface should have normals")
+		}
+	}
+
+	t.Run("SDFPrimitives", func(t *testing.T) {
+		box := SDFBox(Point{}, Point{X: 1, Y: 2, Z: 3})
+		tests := []struct {
+			name string
+			sdf  SDF
+			p    Point
+			want float64
+		}{
+			{"SphereOutside", SDFSphere(Point{X: 1}, 2), Point{X: 5}, 2},
+			{"SphereCenter", SDFSphere(Point{X: 1}, 2), Point{X: 1}, -2},
+			{"BoxFace", box, Point{X: 3}, 2},
+			{"BoxCorner", box, Point{X: 2, Y: 3, Z: 3}, math.Sqrt2},
+			{"BoxInside", box, Point{}, -1},
+			{"TorusTube", SDFTorus(Point{}, 5, 1), Point{X: 5}, -1},
+			{"TorusHole", SDFTorus(Point{}, 5, 1), Point{}, 4},
+			{"Capsule", SDFCapsule(Point{}, Point{Y: 4}, 1), Point{X: 3, Y: 2}, 2},
+			{"Subtract", SDFSubtract(SDFSphere(Point{}, 3), SDFSphere(Point{}, 1)), Point{}, 1},
+			{"Union", SDFUnion(SDFSphere(Point{X: -2}, 1), SDFSphere(Point{X: 2}, 1)), Point{X: 2}, -1},
+			{"Translate", SDFTranslate(SDFSphere(Point{}, 1), Point{Y: 3}), Point{Y: 3}, -1},
+		}
+		for _, tt := range tests {
+			if got := tt.sdf(tt.p); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("%s: expected %.4f, got %.4f", tt.name, tt.want, got)
+			}
+		}
+
+		// Smooth union bulges between close shapes but matches far away
+		a, b := SDFSphere(Point{X: -1.5}, 1), SDFSphere(Point{X: 1.5}, 1)
+		smooth := SDFSmoothUnion(a, b, 1)
+		if smooth(Point{}) >= SDFUnion(a, b)(Point{}) {
+			t.Error("Smooth union should fill in between the spheres")
+		}
+		if p := (Point{X: 10}); math.Abs(smooth(p)-SDFUnion(a, b)(p)) > 1e-9 {
+			t.Error("Smooth union should match the union away from the seam")
+		}
+	})
+
+	t.Run("Sphere", func(t *testing.T) {
+		mesh := PolygonizeSDF(SDFSphere(Point{}, 5), NewAABB(Point{X: -5, Y: -5, Z: -5}, Point{X: 5, Y: 5, Z: 5}), 24)
+		closed(t, mesh)
+		if v, want := meshVolume(mesh), 4.0/3*math.Pi*125; math.Abs(v-want) > want*0.02 {
+			t.Errorf("Expected volume near %.2f, got %.2f", want, v)
+		}
+		for i, v := range mesh.Vertices {
+			if d := math.Abs(pointLength(v) - 5); d > 0.05 {
+				t.Fatalf("Vertex %d is %.3f off the surface", i, d)
+			}
+			if dotPoints(mesh.Normals[i], normalizePoint(v)) < 0.95 {
+				t.Fatalf("Vertex %d normal should point outward", i)
+			}
+		}
+	})
+
+	t.Run("SharpEdges", func(t *testing.T) {
+		box := SDFBox(Point{X: 0.1, Y: 0.2, Z: 0.05}, Point{X: 3, Y: 2, Z: 1.5})
+		mesh := PolygonizeSDF(box, NewAABB(Point{X: -4, Y: -4, Z: -4}, Point{X: 4, Y: 4, Z: 4}), 20)
+		closed(t, mesh)
+		for i, v := range mesh.Vertices {
+			if d := math.Abs(box(v)); d > 0.05 {
+				t.Fatalf("Vertex %d is %.3f off the box", i, d)
+			}
+		}
+		if v := meshVolume(mesh); math.Abs(v-72) > 1.5 {
+			t.Errorf("Expected volume near 72, got %.2f", v)
+		}
+	})
+
+	t.Run("ThinSubtraction", func(t *testing.T) {
+		// Thin tubes and the rims the sphere leaves behind put several
+		// sheets of surface in one cell
+		tests := []struct {
+			name string
+			sdf  SDF
+			size float64
+		}{
+			{"ThinTube", SDFSubtract(SDFTorus(Point{}, 1, 0.3), SDFSphere(Point{}, 1)), 2.3},
+			{"Bite", SDFSubtract(SDFTorus(Point{}, 3, 1.5), SDFSphere(Point{X: 2}, 3)), 6},
+		}
+		for _, tt := range tests {
+			bounds := NewAABB(Point{X: -tt.size, Y: -tt.size, Z: -tt.size}, Point{X: tt.size, Y: tt.size, Z: tt.size})
+			t.Run(tt.name, func(t *testing.T) {
+				closed(t, PolygonizeSDF(tt.sdf, bounds, 32))
+			})
+		}
+	})
+
+	t.Run("DensityGrid", func(t *testing.T) {
+		grid := NewDensityGrid(NewAABB(Point{X: -6, Y: -6, Z: -6}, Point{X: 6, Y: 6, Z: 6}), 0.5)
+		if grid.NX != 25 || len(grid.Values) != 25*25*25 {
+			t.Fatalf("Expected 25 samples per axis, got %d", grid.NX)
+		}
+		grid.FillSDF(SDFTorus(Point{}, 4, 1.5))
+		if got := grid.Sample(Point{X: 4.25}); math.Abs(got-1.25) > 0.05 {
+			t.Errorf("Expected density 1.25 inside the tube, got %.3f", got)
+		}
+
+		mesh := grid.Polygonize(0)
+		closed(t, mesh)
+		if v, want := meshVolume(mesh), 2*math.Pi*math.Pi*4*2.25; math.Abs(v-want) > want*0.03 {
+			t.Errorf("Expected volume near %.2f, got %.2f", want, v)
+		}
+	})
+
+	t.Run("Metaballs", func(t *testing.T) {
+		apart := []Metaball{{Center: Point{X: -10}, Radius: 4}, {Center: Point{X: 10}, Radius: 4}}
+		together := []Metaball{{Center: Point{X: -2}, Radius: 4}, {Center: Point{X: 2}, Radius: 4}}
+
+		components := func(balls []Metaball) int {
+			mesh := PolygonizeSDF(MetaballSDF(balls, 0.5), MetaballBounds(balls), 32)
+			closed(t, mesh)
+			return AnalyzeMesh(mesh, 1e-9).Components
+		}
+		if n := components(apart); n != 2 {
+			t.Errorf("Distant metaballs should stay apart, got %d components", n)
+		}
+		if n := components(together); n != 1 {
+			t.Errorf("Close metaballs should merge, got %d components", n)
+		}
+	})
+}
+
+// ============================================================================
+// VOXEL WORLD TESTS
+// ============================================================================
+
+func TestVoxelWorld(t *testing.T) {
+	settings := VoxelWorldSettings{ChunkSize: 8, HeightChunks: 2, BlockSize: 1, LoadRadius: 1, UnloadRadius: 2}
+	floor := func(x, y, z int) BlockType {
+		if y < 4 {
+			return BlockStone
+		}
+		return BlockAir
+	}
+	triangles := func(meshes []*Mesh) int {
+		n := 0
+		for _, mesh := range meshes {
+			n += len(mesh.Indices) / 3
+		}
+		return n
+	}
+
+	t.Run("GreedyMeshing", func(t *testing.T) {
+		world := NewVoxelWorld(VoxelWorldSettings{ChunkSize: 8, HeightChunks: 1, BlockSize: 2}, floor)
+		world.Update(Point{X: 8, Y: 20, Z: 8})
+		meshes := world.VisibleMeshes()
+		if len(meshes) != 1 {
+			t.Fatalf("Expected one chunk mesh, got %d", len(meshes))
+		}
+
+		// The sides are hidden by the unloaded neighbours, leaving one quad
+		// on top and one underneath
+		mesh := meshes[0]
+		if len(mesh.Indices) != 12 || len(mesh.Vertices) != 8 {
+			t.Fatalf("Expected 2 quads, got %d vertices and %d triangles", len(mesh.Vertices), len(mesh.Indices)/3)
+		}
+		if !mesh.HasNormals() || !mesh.HasFaceMaterials() || mesh.FaceMaterials[0] != world.Materials[BlockStone] {
+			t.Fatal("Chunk meshes need normals and block materials")
+		}
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			p0, p1, p2 := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+			n := normalizePoint(crossPoints(subPoints(p1, p0), subPoints(p2, p0)))
+			if want := mesh.Normals[mesh.Indices[i]]; dotPoints(n, want) < 1-1e-9 {
+				t.Fatalf("Triangle %d winds against its normal %v", i/3, want)
+			}
+			if math.Abs(n.Y) != 1 {
+				t.Fatalf("Triangle %d should be horizontal", i/3)
+			}
+		}
+		if box := NewAABBFromPoints(mesh.Vertices); box.Max.X != 16 || box.Max.Y != 8 || box.Min.Y != 0 {
+			t.Errorf("Expected the quads to cover 16x16 units up to height 8, got %+v", box)
+		}
+
+		// A lone block of another type shows all six faces
+		world.SetBlock(3, 4, 3, BlockWood)
+		mesh = world.VisibleMeshes()[0]
+		wood := 0
+		for _, m := range mesh.FaceMaterials {
+			if m == world.Materials[BlockWood] {
+				wood++
+			}
+		}
+		if wood != 10 {
+			t.Errorf("Expected 5 exposed wood faces, got %d triangles", wood)
+		}
+	})
+
+	t.Run("EditRemeshesNeighbours", func(t *testing.T) {
+		world := NewVoxelWorld(settings, floor)
+		world.Update(Point{X: 4, Z: 4})
+		before := triangles(world.VisibleMeshes())
+		right := world.chunks[ChunkCoord{X: 1}]
+		rightBefore := len(right.mesh.Indices)
+
+		// Digging the last column of chunk 0 exposes a wall in chunk 1
+		if !world.SetBlock(7, 3, 4, BlockAir) {
+			t.Fatal("Edits inside the world should succeed")
+		}
+		if !right.dirty {
+			t.Fatal("Editing a border block should mark the neighbour for remeshing")
+		}
+		after := triangles(world.VisibleMeshes())
+		if len(right.mesh.Indices) <= rightBefore {
+			t.Error("The neighbour should gain the newly exposed face")
+		}
+		if after <= before {
+			t.Errorf("Digging a hole should add faces: %d -> %d", before, after)
+		}
+		if world.GetBlock(7, 3, 4) != BlockAir || world.GetBlock(7, 2, 4) != BlockStone {
+			t.Error("GetBlock should see the edit and the untouched block below")
+		}
+
+		if world.SetBlock(0, -1, 0, BlockStone) || world.SetBlock(0, 16, 0, BlockStone) {
+			t.Error("Edits outside the world's height should fail")
+		}
+	})
+
+	t.Run("RaycastPicking", func(t *testing.T) {
+		world := NewVoxelWorld(settings, floor)
+		world.Update(Point{X: 4, Z: 4})
+
+		hit := world.Raycast(NewRay(Point{X: 3.5, Y: 20, Z: 2.5}, Point{Y: -1}), 100)
+		if !hit.Hit || hit.Block != BlockStone || hit.X != 3 || hit.Y != 3 || hit.Z != 2 {
+			t.Fatalf("Expected to pick block (3, 3, 2), got %+v", hit)
+		}
+		if math.Abs(hit.Distance-16) > 1e-9 || hit.Normal != (Point{Y: 1}) {
+			t.Errorf("Expected the top face at distance 16, got %+v", hit)
+		}
+		if x, y, z := hit.Adjacent(); x != 3 || y != 4 || z != 2 {
+			t.Errorf("Expected to place at (3, 4, 2), got (%d, %d, %d)", x, y, z)
+		}
+
+		if hit := world.Raycast(NewRay(Point{X: 3.5, Y: 20, Z: 2.5}, Point{Y: 1}), 100); hit.Hit {
+			t.Error("A ray into the sky should miss")
+		}
+		if hit := world.Raycast(NewRay(Point{X: 3.5, Y: 20, Z: 2.5}, Point{Y: -1}), 10); hit.Hit {
+			t.Error("A ray should not reach past its max distance")
+		}
+		if hit := world.Raycast(NewRay(Point{X: 3.5, Y: 1.5, Z: 2.5}, Point{X: 1}), 100); !hit.Hit || hit.Distance != 0 {
+			t.Errorf("A ray starting inside a block should hit it at once, got %+v", hit)
+		}
+	})
+
+	t.Run("RaycastMatchesMeshes", func(t *testing.T) {
+		// Random blocks confined to the loaded chunks
+		world := NewVoxelWorld(settings, func(x, y, z int) BlockType {
+			if x < -8 || x >= 16 || z < -8 || z >= 16 || y >= 12 {
+				return BlockAir
+			}
+			h := uint32(x*73856093) ^ uint32(y*19349663) ^ uint32(z*83492791)
+			h = (h ^ (h >> 13)) * 0x5bd1e995
+			if h%10 < 3 {
+				return BlockType(1 + h%3)
+			}
+			return BlockAir
+		})
+		world.Update(Point{X: 4, Z: 4})
+		meshes := world.VisibleMeshes()
+
+		rng := rand.New(rand.NewSource(11))
+		for i := 0; i < 200; i++ {
+			origin := Point{X: rng.Float64()*24 - 8, Y: 20, Z: rng.Float64()*24 - 8}
+			ray := NewRay(origin, Point{X: rng.Float64()*2 - 1, Y: -1 - rng.Float64(), Z: rng.Float64()*2 - 1})
+
+			want := math.Inf(1)
+			for _, mesh := range meshes {
+				for k := 0; k < len(mesh.Indices); k += 3 {
+					tri := NewTriangle(mesh.Vertices[mesh.Indices[k]], mesh.Vertices[mesh.Indices[k+1]], mesh.Vertices[mesh.Indices[k+2]], 'o')
+					if ok, d, _, _ := ray.IntersectsTriangle(tri); ok && d > 0 && d < want {
+						want = d
+					}
+				}
+			}
+
+			hit := world.Raycast(ray, 1000)
+			if hit.Hit != !math.IsInf(want, 1) || (hit.Hit && math.Abs(hit.Distance-want) > 1e-6) {
+				t.Fatalf("Ray %d: DDA hit %v at %.4f, meshes at %.4f", i, hit.Hit, hit.Distance, want)
+			}
+		}
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		world := NewVoxelWorld(settings, floor)
+		if !world.Update(Point{X: 4, Z: 4}) {
+			t.Fatal("The first update should load chunks")
+		}
+		if n := world.LoadedChunks(); n != 9*settings.HeightChunks {
+			t.Fatalf("Expected 3x3 columns of %d chunks, got %d", settings.HeightChunks, n)
+		}
+		if world.Update(Point{X: 4, Z: 4}) {
+			t.Error("An update without moving should change nothing")
+		}
+
+		world.SetBlock(2, 3, 2, BlockAir)
+		world.Update(Point{X: 100, Z: 4})
+		if world.IsChunkLoaded(ChunkCoord{}) || world.LoadedChunks() != 9*settings.HeightChunks {
+			t.Fatalf("Moving away should swap the loaded chunks, got %d", world.LoadedChunks())
+		}
+		if world.GetBlock(2, 3, 2) != BlockAir {
+			t.Error("Edits should survive their chunk being unloaded")
+		}
+		world.Update(Point{X: 4, Z: 4})
+		if hit := world.Raycast(NewRay(Point{X: 2.5, Y: 10, Z: 2.5}, Point{Y: -1}), 100); hit.Y != 2 {
+			t.Errorf("Reloaded chunks should keep their edits, picked y=%d", hit.Y)
+		}
+
+		limited := settings
+		limited.MaxLoadsPerUpdate = 3
+		world = NewVoxelWorld(limited, floor)
+		world.Update(Point{X: 4, Z: 4})
+		if world.LoadedChunks() != 3 || !world.IsChunkLoaded(ChunkCoord{}) {
+			t.Errorf("Expected the 3 chunks nearest the camera first, got %d", world.LoadedChunks())
+		}
+	})
+
+	t.Run("SceneIntegration", func(t *testing.T) {
+		scene := NewScene()
+		scene.Camera = NewCameraAt(104, 30, 4)
+		world := NewVoxelWorld(settings, floor)
+		node := scene.CreateVoxelWorld("world", world)
+		node.Transform.SetPosition(100, 0, 0)
+
+		if !scene.UpdateVoxelWorlds() || scene.UpdateVoxelWorlds() {
+			t.Fatal("Only the first update should load chunks")
+		}
+
+		hit := scene.Raycast(NewRay(Point{X: 103.5, Y: 30, Z: 2.5}, Point{Y: -1}), 1000)
+		if !hit.Hit || hit.Node != node || math.Abs(hit.Point.Y-4) > 1e-9 || math.Abs(hit.Normal.Y-1) > 1e-9 {
+			t.Fatalf("Expected to hit the floor at height 4, got %+v", hit)
+		}
+
+		bvh := scene.BuildBVH()
+		bounds := bvh.Root.Bounds
+		if math.Abs(bounds.Min.X-92) > 1e-9 || math.Abs(bounds.Max.X-116) > 1e-9 || math.Abs(bounds.Max.Y-8) > 1e-9 {
+			t.Errorf("Unexpected world bounds %+v", bounds)
+		}
+
+		// Moving the camera streams new chunks; the BVH follows on rebuild
+		scene.Camera.Transform.SetPosition(140, 30, 4)
+		if !scene.UpdateVoxelWorlds() {
+			t.Fatal("Moving the camera should stream chunks")
+		}
+		bvh.Rebuild()
+		if math.Abs(bvh.Root.Bounds.Min.X-132) > 1e-9 || math.Abs(bvh.Root.Bounds.Max.X-156) > 1e-9 {
+			t.Errorf("Rebuilt BVH should cover only the new chunks, got %+v", bvh.Root.Bounds)
+		}
+	})
+}
+
+// ============================================================================
+// PARAMETRIC SURFACE TESTS
+// ============================================================================
+
+func TestParametricSurfaces(t *testing.T) {
+
+	t.Run("Curves", func(t *testing.T) {
+		quadratic, err := NewBezierCurve([]Point{{X: 0}, {X: 1, Y: 2}, {X: 2}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pointsNear(quadratic.Evaluate(0), Point{}, 1e-9) || !pointsNear(quadratic.Evaluate(1), Point{X: 2}, 1e-9) {
+			t.Error("A Bezier curve should start and end on its end points")
+		}
+		if got := quadratic.Evaluate(0.5); !pointsNear(got, Point{X: 1, Y: 1}, 1e-9) {
+			t.Errorf("Expected the quadratic's midpoint at (1, 1), got %v", got)
+		}
+
+		// A degree 1 B-spline is the control polygon
+		points := []Point{{X: 0}, {X: 1, Y: 1}, {X: 2}, {X: 3, Y: 1}}
+		linear, err := NewBSplineCurve(points, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range points {
+			if got := linear.Evaluate(float64(i) / 3); !pointsNear(got, p, 1e-9) {
+				t.Errorf("Linear B-spline should pass through point %d, got %v", i, got)
+			}
+		}
+		cubic, err := NewBSplineCurve(points, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pointsNear(cubic.Evaluate(0), points[0], 1e-9) || !pointsNear(cubic.Evaluate(1), points[3], 1e-9) {
+			t.Error("A clamped B-spline should start and end on its end points")
+		}
+		cubic.ControlPoints[0] = Point{X: 9}
+		if points[0] != (Point{}) {
+			t.Error("Curves should copy their control points")
+		}
+
+		circle := NewNURBSCircle(3)
+		for i := 0; i <= 40; i++ {
+			p := circle.Evaluate(float64(i) / 40)
+			if r := math.Hypot(p.X, p.Z); math.Abs(r-3) > 1e-9 || p.Y != 0 {
+				t.Fatalf("NURBS circle point %v is off the circle (radius %.12f)", p, r)
+			}
+		}
+		if got := circle.Evaluate(0.25); !pointsNear(got, Point{Z: -3}, 1e-9) {
+			t.Errorf("A quarter of the way round should be at -Z, got %v", got)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		points := []Point{{X: 0}, {X: 1}, {X: 2}}
+		if _, err := NewNURBSCurve(2, points, nil, []float64{0, 0, 1, 1}); err == nil {
+			t.Error("Expected an error for too few knots")
+		}
+		if _, err := NewNURBSCurve(2, points, nil, []float64{0, 0, 1, 0, 1, 1}); err == nil {
+			t.Error("Expected an error for decreasing knots")
+		}
+		if _, err := NewNURBSCurve(2, points, []float64{1, -1, 1}, ClampedKnots(3, 2)); err == nil {
+			t.Error("Expected an error for a negative weight")
+		}
+		if _, err := NewNURBSSurface(1, 1, 2, 2, points, nil, ClampedKnots(2, 1), ClampedKnots(2, 1)); err == nil {
+			t.Error("Expected an error for a control net of the wrong size")
+		}
+		if _, err := NewBezierCurve(nil); err == nil {
+			t.Error("Expected an error for a curve without points")
+		}
+		if _, err := NewBSplineCurve(points[:1], 3); err == nil {
+			t.Error("Expected an error for a curve with one point")
+		}
+	})
+
+	t.Run("SurfaceOfRevolution", func(t *testing.T) {
+		profile, err := NewBSplineCurve([]Point{{X: 2}, {X: 2, Y: 5}}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cylinder := NewSurfaceOfRevolution(profile)
+		cylinder.SetSegments(12, 3)
+		mesh := cylinder.VisibleMeshes()[0]
+		if len(mesh.Vertices) != 13*4 || len(mesh.Indices) != 12*3*6 {
+			t.Fatalf("Expected a 12x3 grid, got %d vertices and %d triangles", len(mesh.Vertices), len(mesh.Indices)/3)
+		}
+		for i, p := range mesh.Vertices {
+			if r := math.Hypot(p.X, p.Z); math.Abs(r-2) > 1e-9 {
+				t.Fatalf("Vertex %v is off the cylinder (radius %.12f)", p, r)
+			}
+			if n := mesh.Normals[i]; n.X*p.X+n.Z*p.Z < 1.99 || math.Abs(n.Y) > 1e-6 {
+				t.Fatalf("Normal %v at %v should point straight out", n, p)
+			}
+		}
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			p0, p1, p2 := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+			if dotPoints(crossPoints(subPoints(p1, p0), subPoints(p2, p0)), mesh.Normals[mesh.Indices[i]]) <= 0 {
+				t.Fatalf("Triangle %d winds against its normals", i/3)
+			}
+		}
+	})
+
+	t.Run("ScreenSpaceError", func(t *testing.T) {
+		settings := TessellationSettings{MaxScreenError: 0.5, MinSegments: 2, MaxSegments: 64}
+		circle := NewNURBSCircle(5)
+		circle.Settings = settings
+
+		segments := func(distance float64) int {
+			circle.Update(Point{Y: distance}, FOV_Y)
+			return circle.Segments()
+		}
+		close, mid, far := segments(10), segments(100), segments(1e6)
+		if !(close > mid && mid > far) || far != settings.MinSegments {
+			t.Errorf("Segments should fall with distance to the minimum: %d, %d, %d", close, mid, far)
+		}
+		if segments(0) != settings.MaxSegments {
+			t.Error("A camera on the curve should get the most segments")
+		}
+
+		// The chosen tessellation keeps within the error on screen
+		circle.Update(Point{Y: 100}, FOV_Y)
+		polyline := circle.Polyline()
+		worst := 0.0
+		for i := 0; i+1 < len(polyline); i++ {
+			mid := scalePoint(addPoints(polyline[i], polyline[i+1]), 0.5)
+			worst = math.Max(worst, 5-math.Hypot(mid.X, mid.Z))
+		}
+		if screen := worst * FOV_Y / distanceToAABB(Point{Y: 100}, circle.Bounds()); screen > settings.MaxScreenError {
+			t.Errorf("Tessellation strays %.3f cells from the circle", screen)
+		}
+	})
+
+	t.Run("Teapot", func(t *testing.T) {
+		teapot := NewTeapot()
+		if len(teapot.Patches) != 32 {
+			t.Fatalf("Expected 32 patches, got %d", len(teapot.Patches))
+		}
+		bounds := teapot.Bounds()
+		if !pointsNear(bounds.Min, Point{X: -3, Z: -2}, 1e-9) || !pointsNear(bounds.Max, Point{X: 3.525, Y: 3.15, Z: 2}, 1e-9) {
+			t.Errorf("Unexpected teapot bounds %+v", bounds)
+		}
+
+		teapot.SetSegments(8, 8)
+		mesh := teapot.VisibleMeshes()[0]
+		volume := 0.0
+		for i := 0; i < len(mesh.Indices); i += 3 {
+			p0, p1, p2 := mesh.Vertices[mesh.Indices[i]], mesh.Vertices[mesh.Indices[i+1]], mesh.Vertices[mesh.Indices[i+2]]
+			if dotPoints(crossPoints(subPoints(p1, p0), subPoints(p2, p0)), mesh.Normals[mesh.Indices[i]]) <= 0 {
+				t.Fatalf("Triangle %d winds against its normals", i/3)
+			}
+			volume += dotPoints(p0, crossPoints(p1, p2)) / 6
+		}
+		if volume <= 0 {
+			t.Errorf("Patches should face outward, got volume %.3f", volume)
+		}
+	})
+
+	t.Run("NoCracksBetweenLevels", func(t *testing.T) {
+		teapot := NewTeapot()
+		teapot.Settings = TessellationSettings{MaxScreenError: 0.5, MinSegments: 1, MaxSegments: 32}
+		teapot.Update(Point{X: 6, Y: 2.4}, FOV_Y)
+		mesh := teapot.VisibleMeshes()[0]
+
+		// Vertices of a patch side, found from each patch's grid
+		first := make([]int, len(teapot.Patches))
+		levels := map[int]bool{}
+		for i, offset := 0, 0; i < len(teapot.Patches); i++ {
+			nu, nv := teapot.PatchSegments(i)
+			first[i] = offset
+			offset += (nu + 1) * (nv + 1)
+			levels[nu], levels[nv] = true, true
+		}
+		if len(levels) < 2 {
+			t.Fatal("Patches near the camera should be finer than the rest")
+		}
+		side := func(ps patchSide) []Point {
+			nu, nv := teapot.PatchSegments(ps.Patch)
+			var points []Point
+			for k := 0; k <= []int{nu, nv}[ps.Side%2]; k++ {
+				at := []int{k, k*(nu+1) + nu, nv*(nu+1) + k, k * (nu + 1)}[ps.Side]
+				points = append(points, mesh.Vertices[first[ps.Patch]+at])
+			}
+			return points
+		}
+		onPolyline := func(p Point, polyline []Point) bool {
+			for i := 0; i+1 < len(polyline); i++ {
+				a, b := polyline[i], polyline[i+1]
+				ab := subPoints(b, a)
+				t := clampFloat(dotPoints(subPoints(p, a), ab)/math.Max(dotPoints(ab, ab), 1e-300), 0, 1)
+				if pointLength(subPoints(p, addPoints(a, scalePoint(ab, t)))) < 1e-6 {
+					return true
+				}
+			}
+			return false
+		}
+
+		checked := 0
+		for i, sides := range teapot.neighbours {
+			for s, other := range sides {
+				if other.Patch < 0 {
+					continue
+				}
+				for _, p := range side(patchSide{Patch: i, Side: s}) {
+					if !onPolyline(p, side(other)) {
+						t.Fatalf("Patch %d side %d leaves a crack at %v", i, s, p)
+					}
+				}
+				checked++
+			}
+		}
+		if checked == 0 {
+			t.Fatal("No shared sides were checked")
+		}
+	})
+
+	t.Run("SceneIntegration", func(t *testing.T) {
+		scene := NewScene()
+		scene.Camera = NewCameraAt(0, 2, -20)
+		teapot := NewTeapot()
+		node := NewSceneNodeWithObject("teapot", teapot)
+		node.Transform.SetPosition(10, 0, 0)
+		scene.AddNode(node)
+		curve := NewNURBSCircle(4)
+		ring := NewSceneNodeWithObject("ring", curve)
+		scene.AddNode(ring)
+
+		scene.UpdateTessellation()
+		if nu, _ := teapot.PatchSegments(0); nu == 0 || curve.Segments() == 0 {
+			t.Fatal("UpdateTessellation should tessellate every curve and surface")
+		}
+
+		hit := scene.Raycast(NewRay(Point{X: 10, Y: 10, Z: 0}, Point{Y: -1}), 100)
+		if !hit.Hit || hit.Node != node || math.Abs(hit.Point.Y-3.15) > 0.01 {
+			t.Fatalf("Expected to hit the lid's knob near y = 3.15, got %+v", hit)
+		}
+
+		bounds := scene.computeNodeBounds(node)
+		if bounds == nil || math.Abs(bounds.Min.X-7) > 1e-9 || math.Abs(bounds.Max.X-13.525) > 1e-9 {
+			t.Errorf("Unexpected teapot bounds %+v", bounds)
+		}
+
+		// The ring is bounded and drawn through the shared interfaces
+		bounds = scene.computeNodeBounds(ring)
+		if bounds == nil || math.Abs(bounds.Min.Z+4) > 1e-9 || math.Abs(bounds.Max.X-4) > 1e-9 {
+			t.Errorf("Unexpected ring bounds %+v", bounds)
+		}
+		r := NewTerminalRenderer(bufio.NewWriter(io.Discard), 40, 80)
+		r.renderNode(ring, ring.Transform.GetWorldMatrix(), scene.Camera)
+		drawn := 0
+		for y := range r.Surface {
+			for x := range r.Surface[y] {
+				if r.Surface[y][x] != DefaultCharset[0] {
+					drawn++
+				}
+			}
+		}
+		if drawn < 20 {
+			t.Errorf("Expected the ring to be drawn as lines, got %d cells", drawn)
+		}
+	})
+}
+
+// ============================================================================
+// UV MAPPING TESTS
+// ============================================================================
+
+func TestUVMapping(t *testing.T) {
+	stripped := func(mesh *Mesh) *Mesh {
+		mesh.UVs = nil
+		mesh.Tangents = nil
+		return mesh
+	}
+	signedArea := func(a, b, c TextureCoord) float64 {
+		return ((b.U-a.U)*(c.V-a.V) - (b.V-a.V)*(c.U-a.U)) / 2
+	}
+	// checkLayout reports corners outside [0, 1] and overlapping triangles
+	checkLayout := func(t *testing.T, uvs []TextureCoord) {
+		t.Helper()
+		tris := make([][3][2]float64, len(uvs)/3)
+		for c, uv := range uvs {
+			if uv.U < 0 || uv.U > 1 || uv.V < 0 || uv.V > 1 {
+				t.Fatalf("Corner %d UV %v is outside [0, 1]", c, uv)
+			}
+			tris[c/3][c%3] = [2]float64{uv.U, uv.V}
+		}
+		for i := range tris {
+			for j := i + 1; j < len(tris); j++ {
+				if trianglesOverlap2D(tris[i], tris[j], 1e-9) {
+					t.Fatalf("Triangles %d and %d overlap in UV space", i, j)
+				}
+			}
+		}
+	}
+
+	t.Run("PlanarProjection", func(t *testing.T) {
+		mesh := stripped(GeneratePlane(2, 4, 2, 2))
+		if mesh.HasUVs() {
+			t.Fatal("Stripped plane should have no UVs")
+		}
+		vertices := len(mesh.Vertices)
+		mesh.ProjectPlanarUVs(Point{Y: 1})
+		if !mesh.HasUVs() || len(mesh.Vertices) != vertices {
+			t.Fatalf("Planar projection should not split vertices: %d -> %d", vertices, len(mesh.Vertices))
+		}
+
+		// Looking down, U follows +X and V follows +Z (up is -Z)
+		for i, p := range mesh.Vertices {
+			uv := mesh.UVs[i]
+			if math.Abs(uv.U-(p.X+1)/2) > 1e-9 || math.Abs(uv.V-(p.Z+2)/4) > 1e-9 {
+				t.Errorf("Vertex %v: expected UV (%g, %g), got %v", p, (p.X+1)/2, (p.Z+2)/4, uv)
+			}
+		}
+	})
+
+	t.Run("BoxProjection", func(t *testing.T) {
+		mesh := stripped(GenerateBox(1, 2, 3, 1, 1, 1))
+		mesh.Normals = nil
+		mesh.WeldVertices(1e-6)
+		if len(mesh.Vertices) != 8 {
+			t.Fatalf("Expected 8 welded box vertices, got %d", len(mesh.Vertices))
+		}
+
+		mesh.ProjectBoxUVs()
+		if len(mesh.Vertices) <= 8 {
+			t.Errorf("Corners on different faces should be split, got %d vertices", len(mesh.Vertices))
+		}
+		for i, uv := range mesh.UVs {
+			for _, x := range []float64{uv.U, uv.V} {
+				if math.Abs(x) > 1e-9 && math.Abs(x-1) > 1e-9 {
+					t.Fatalf("Every face should cover the whole texture, vertex %d has UV %v", i, uv)
+				}
+			}
+		}
+
+		// Seen from outside no face is mirrored: V runs down, so the
+		// counter-clockwise faces all turn clockwise in UV space
+		for tri := 0; tri < len(mesh.Indices)/3; tri++ {
+			a, b, c := mesh.UVs[mesh.Indices[tri*3]], mesh.UVs[mesh.Indices[tri*3+1]], mesh.UVs[mesh.Indices[tri*3+2]]
+			if area := signedArea(a, b, c); area > -1e-9 {
+				t.Errorf("Triangle %d is mirrored or flat in UV space (signed area %g)", tri, area)
+			}
+		}
+	})
+
+	t.Run("SphericalProjection", func(t *testing.T) {
+		mesh := GenerateIcosphere(1.5, 2)
+		want := make([]TextureCoord, len(mesh.Indices))
+		for c, idx := range mesh.Indices {
+			want[c] = mesh.UVs[idx]
+		}
+
+		stripped(mesh).ProjectSphericalUVs(Point{Y: 1})
+		for c, idx := range mesh.Indices {
+			if got := mesh.UVs[idx]; math.Abs(got.U-want[c].U) > 1e-9 || math.Abs(got.V-want[c].V) > 1e-9 {
+				t.Fatalf("Corner %d: expected the icosphere's UV %v, got %v", c, want[c], got)
+			}
+		}
+	})
+
+	t.Run("CylindricalProjection", func(t *testing.T) {
+		mesh := GenerateCylinder(1, 3, 16, 2)
+		mesh.UVs = nil
+		mesh.WeldVertices(1e-6)
+		mesh.ProjectCylindricalUVs(Point{Y: 1})
+
+		for tri := 0; tri < len(mesh.Indices)/3; tri++ {
+			minU, maxU := math.Inf(1), math.Inf(-1)
+			for k := 0; k < 3; k++ {
+				uv := mesh.UVs[mesh.Indices[tri*3+k]]
+				minU, maxU = math.Min(minU, uv.U), math.Max(maxU, uv.U)
+				if uv.V < -1e-9 || uv.V > 1+1e-9 {
+					t.Fatalf("V should stay in [0, 1], got %v", uv)
+				}
+			}
+			if maxU-minU > 0.5 {
+				t.Fatalf("Triangle %d smears across the seam (U %g to %g)", tri, minU, maxU)
+			}
+		}
+
+		// The top rim is at V = 0, the bottom rim at V = 1
+		for i, p := range mesh.Vertices {
+			if math.Abs(p.Y-1.5) < 1e-9 && math.Abs(mesh.UVs[i].V) > 1e-9 {
+				t.Errorf("Top vertex %v should have V = 0, got %g", p, mesh.UVs[i].V)
+			}
+		}
+	})
+
+	t.Run("UnwrapBox", func(t *testing.T) {
+		mesh := stripped(GenerateBox(1, 2, 3, 1, 1, 1))
+		mesh.WeldVertices(1e-6)
+		if err := mesh.AutoUnwrap(DefaultUnwrapSettings()); err != nil {
+			t.Fatalf("AutoUnwrap failed: %v", err)
+		}
+		if !mesh.HasUVs() || len(mesh.Vertices) != 24 {
+			t.Fatalf("Expected one chart per face (24 vertices), got %d vertices", len(mesh.Vertices))
+		}
+
+		corners := make([]TextureCoord, len(mesh.Indices))
+		for c, idx := range mesh.Indices {
+			corners[c] = mesh.UVs[idx]
+		}
+		checkLayout(t, corners)
+
+		// Flat charts are not distorted and share one texel density
+		ratio := 0.0
+		for tri := 0; tri < len(mesh.Indices)/3; tri++ {
+			a, b, c := mesh.triangleCorners(tri)
+			area := pointLength(crossPoints(subPoints(b, a), subPoints(c, a))) / 2
+			r := math.Abs(signedArea(corners[tri*3], corners[tri*3+1], corners[tri*3+2])) / area
+			if ratio == 0 {
+				ratio = r
+			} else if math.Abs(r-ratio) > 1e-9*ratio {
+				t.Errorf("Triangle %d has UV/surface area %g, expected %g", tri, r, ratio)
+			}
+		}
+	})
+
+	t.Run("UnwrapCurvedSurfaces", func(t *testing.T) {
+		sphere := GenerateSphere(1, 12, 16)
+		uvs, err := UnwrapUVs(sphere, DefaultUnwrapSettings())
+		if err != nil {
+			t.Fatalf("UnwrapUVs failed: %v", err)
+		}
+		if len(uvs) != len(sphere.Indices) {
+			t.Fatalf("Expected one UV per index, got %d for %d", len(uvs), len(sphere.Indices))
+		}
+		checkLayout(t, uvs)
+
+		// A spiral ramp faces up everywhere but covers itself when projected
+		// straight down; its turns must end up in different charts
+		ramp := NewMesh()
+		const steps = 64
+		for i := 0; i <= steps; i++ {
+			angle := 4 * math.Pi * float64(i) / steps
+			cos, sin := math.Cos(angle), math.Sin(angle)
+			ramp.AddVertex(cos, 0.05*angle, -sin)
+			ramp.AddVertex(2*cos, 0.05*angle, -2*sin)
+		}
+		for i := 0; i < steps; i++ {
+			a := i * 2
+			ramp.AddTriangleIndices(a, a+1, a+3)
+			ramp.AddTriangleIndices(a, a+3, a+2)
+		}
+		if n := ramp.faceNormal(0); n.Y < 0.9 {
+			t.Fatalf("Ramp should face up, got normal %v", n)
+		}
+		if err := ramp.AutoUnwrap(DefaultUnwrapSettings()); err != nil {
+			t.Fatalf("AutoUnwrap failed: %v", err)
+		}
+		corners := make([]TextureCoord, len(ramp.Indices))
+		for c, idx := range ramp.Indices {
+			corners[c] = ramp.UVs[idx]
+		}
+		checkLayout(t, corners)
+	})
+
+	t.Run("UnwrapValidation", func(t *testing.T) {
+		box := GenerateBox(1, 1, 1, 1, 1, 1)
+		settings := DefaultUnwrapSettings()
+		settings.MaxChartAngle = math.Pi / 2
+		if _, err := UnwrapUVs(box, settings); err == nil {
+			t.Error("A chart angle of 90 degrees should be rejected")
+		}
+		if err := NewMesh().AutoUnwrap(DefaultUnwrapSettings()); err == nil {
+			t.Error("Unwrapping an empty mesh should fail")
+		}
+
+		// Tangents follow the new UVs
+		box.ProjectBoxUVs()
+		if err := box.GenerateTangents(); err != nil {
+			t.Fatalf("GenerateTangents failed: %v", err)
+		}
+		if err := box.AutoUnwrap(DefaultUnwrapSettings()); err != nil {
+			t.Fatalf("AutoUnwrap failed: %v", err)
+		}
+		if !box.HasTangents() {
+			t.Error("Unwrapping should regenerate the mesh's tangents")
+		}
+	})
+}
+
+// ============================================================================
+// GLTF TESTS
+// ============================================================================
+
+// gltfTestFile builds glTF documents with one binary buffer
+type gltfTestFile struct {
+	bin       []byte
+	views     []map[string]any
+	accessors []map[string]any
+}
+
+// view adds a buffer view, 4-byte aligned
+func (f *gltfTestFile) view(data []byte, stride int) int {
+	for len(f.bin)%4 != 0 {
+		f.bin = append(f.bin, 0)
+	}
+	view := map[string]any{"buffer": 0, "byteOffset": len(f.bin), "byteLength": len(data)}
+	if stride > 0 {
+		view["byteStride"] = stride
+	}
+	f.bin = append(f.bin, data...)
+	f.views = append(f.views, view)
+	return len(f.views) - 1
+}
+
+// accessor adds an accessor over a new buffer view
+func (f *gltfTestFile) accessor(data []byte, componentType, count int, kind string) int {
+	f.accessors = append(f.accessors, map[string]any{
+		"bufferView": f.view(data, 0), "componentType": componentType, "count": count, "type": kind,
+	})
+	return len(f.accessors) - 1
+}
+
+// document returns the glTF JSON with the buffer as a data URI, or without
+// a URI for a GLB
+func (f *gltfTestFile) document(doc map[string]any, embed bool) map[string]any {
+	buffer := map[string]any{"byteLength": len(f.bin)}
+	if embed {
+		buffer["uri"] = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(f.bin)
+	}
+	doc["asset"] = map[string]any{"version": "2.0"}
+	doc["buffers"] = []any{buffer}
+	doc["bufferViews"] = f.views
+	doc["accessors"] = f.accessors
+	return doc
+}
+
+// glb packs a document and the buffer into a .glb
+func (f *gltfTestFile) glb(doc map[string]any) []byte {
+	text, _ := json.Marshal(f.document(doc, false))
+	for len(text)%4 != 0 {
+		text = append(text, ' ')
+	}
+	bin := append([]byte(nil), f.bin...)
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	out := binary.LittleEndian.AppendUint32(nil, glbMagic)
+	out = binary.LittleEndian.AppendUint32(out, 2)
+	out = binary.LittleEndian.AppendUint32(out, uint32(12+8+len(text)+8+len(bin)))
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(text)))
+	out = binary.LittleEndian.AppendUint32(out, glbChunkJSON)
+	out = append(out, text...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(bin)))
+	out = binary.LittleEndian.AppendUint32(out, glbChunkBIN)
+	return append(out, bin...)
+}
+
+func gltfFloats(values ...float64) []byte {
+	var out []byte
+	for _, v := range values {
+		out = binary.LittleEndian.AppendUint32(out, math.Float32bits(float32(v)))
+	}
+	return out
+}
+
+func gltfShorts(values ...int) []byte {
+	var out []byte
+	for _, v := range values {
+		out = binary.LittleEndian.AppendUint16(out, uint16(v))
+	}
+	return out
+}
+
+func TestGLTF(t *testing.T) {
+	write := func(t *testing.T, path string, doc map[string]any) {
+		t.Helper()
+		text, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, text, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A unit quad in the XY plane facing +Z, as two primitives: the lower
+	// right triangle with material 0, the upper left with material 1
+	quad := func() (*gltfTestFile, map[string]any) {
+		f := &gltfTestFile{}
+		positions := f.accessor(gltfFloats(0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0), gltfFloat, 4, "VEC3")
+		normals := f.accessor(gltfFloats(0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1), gltfFloat, 4, "VEC3")
+		uvs := f.accessor(gltfFloats(0, 1, 1, 1, 1, 0, 0, 0), gltfFloat, 4, "VEC2")
+		lower := f.accessor(gltfShorts(0, 1, 2), gltfUnsignedShort, 3, "SCALAR")
+		upper := f.accessor(gltfShorts(0, 2, 3), gltfUnsignedShort, 3, "SCALAR")
+		attributes := map[string]int{"POSITION": positions, "NORMAL": normals, "TEXCOORD_0": uvs}
+
+		doc := map[string]any{
+			"scene":  0,
+			"scenes": []any{map[string]any{"nodes": []int{0}}},
+			"nodes": []any{
+				map[string]any{
+					"name": "Body", "children": []int{1},
+					"translation": []float64{1, 2, 3},
+					"rotation":    []float64{0, math.Sin(math.Pi / 4), 0, math.Cos(math.Pi / 4)},
+					"scale":       []float64{2, 2, 2},
+				},
+				map[string]any{
+					"name": "Panel", "mesh": 0,
+					"matrix": []float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 1, 1},
+				},
+			},
+			"meshes": []any{map[string]any{"primitives": []any{
+				map[string]any{"attributes": attributes, "indices": lower, "material": 0},
+				map[string]any{"attributes": attributes, "indices": upper, "material": 1},
+			}}},
+			"materials": []any{
+				map[string]any{"pbrMetallicRoughness": map[string]any{
+					"baseColorFactor": []float64{1, 0, 0, 1}, "metallicFactor": 0.25, "roughnessFactor": 0.75,
+				}},
+				map[string]any{"emissiveFactor": []float64{0, 1, 0}},
+			},
+		}
+		return f, doc
+	}
+
+	t.Run("HierarchyAndMeshes", func(t *testing.T) {
+		f, doc := quad()
+		path := t.TempDir() + "/quad.gltf"
+		write(t, path, f.document(doc, true))
+
+		gltf, err := LoadGLTF(path)
+		if err != nil {
+			t.Fatalf("LoadGLTF failed: %v", err)
+		}
+		if gltf.Root.Name != "quad" || len(gltf.Root.Children) != 1 {
+			t.Fatalf("Expected root \"quad\" with one child, got %q with %d", gltf.Root.Name, len(gltf.Root.Children))
+		}
+		body, panel := gltf.Nodes[0], gltf.Nodes[1]
+		if body.Name != "Body" || panel.Name != "Panel" || panel.Parent != body {
+			t.Fatalf("Unexpected hierarchy: %q > %q", body.Name, panel.Name)
+		}
+
+		// Panel sits 1 along Body's Z, which is turned to +X and scaled by 2
+		if got := panel.Transform.GetWorldPosition(); !pointsNear(got, Point{X: 3, Y: 2, Z: 3}, 1e-6) {
+			t.Errorf("Expected Panel at (3, 2, 3), got %v", got)
+		}
+		world := panel.Transform.GetWorldMatrix()
+		if got := world.TransformPoint(Point{X: 1}); !pointsNear(got, Point{X: 3, Y: 2, Z: 1}, 1e-6) {
+			t.Errorf("Expected the quad's +X corner at (3, 2, 1), got %v", got)
+		}
+
+		mesh, ok := panel.Object.(*Mesh)
+		if !ok || gltf.Meshes[0] != mesh {
+			t.Fatal("Panel should hold the imported mesh")
+		}
+		if len(mesh.Vertices) != 8 || len(mesh.Indices) != 6 || !mesh.HasNormals() || !mesh.HasUVs() {
+			t.Fatalf("Expected 8 vertices, 2 triangles, normals and UVs, got %d, %d", len(mesh.Vertices), len(mesh.Indices)/3)
+		}
+		if mesh.UVs[2] != (TextureCoord{U: 1, V: 0}) || !pointsNear(mesh.Normals[0], Point{Z: 1}, 1e-6) {
+			t.Errorf("Unexpected attributes: UV %v, normal %v", mesh.UVs[2], mesh.Normals[0])
+		}
+		if n := mesh.faceNormal(1); !pointsNear(n, Point{Z: 1}, 1e-6) {
+			t.Errorf("Triangles should keep their winding, got face normal %v", n)
+		}
+
+		red, green := gltf.Materials[0], gltf.Materials[1]
+		if mesh.MaterialForFace(0) != red || mesh.MaterialForFace(1) != green {
+			t.Error("Each primitive's triangles should use its material")
+		}
+		if red.Albedo != (Color{R: 255}) || red.Metallic != 0.25 || red.Roughness != 0.75 {
+			t.Errorf("Unexpected red material %+v", red)
+		}
+		if green.Emissive != (Color{G: 255}) || green.Metallic != 1 || green.Roughness != 1 {
+			t.Errorf("Missing factors should take glTF defaults, got %+v", green)
+		}
+	})
+
+	t.Run("TexturesAndGLB", func(t *testing.T) {
+		dir := t.TempDir()
+		base := NewTexture(2, 1)
+		base.Data = []Color{{R: 255, G: 255, B: 255}, {R: 100, G: 200, B: 50}}
+		packed := NewTexture(1, 1)
+		packed.Data = []Color{{R: 0, G: 128, B: 255}}
+		for name, tex := range map[string]*Texture{"base.png": base, "packed.png": packed} {
+			if err := SaveTextureToFile(tex, dir+"/"+name); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		f, doc := quad()
+		doc["materials"] = []any{
+			map[string]any{
+				"pbrMetallicRoughness": map[string]any{
+					"baseColorFactor":          []float64{1, 0.5, 1, 1},
+					"baseColorTexture":         map[string]any{"index": 0},
+					"metallicFactor":           0.5,
+					"metallicRoughnessTexture": map[string]any{"index": 1},
+				},
+				"normalTexture":    map[string]any{"index": 1},
+				"occlusionTexture": map[string]any{"index": 1, "strength": 0.5},
+				"emissiveTexture":  map[string]any{"index": 0},
+				"emissiveFactor":   []float64{1, 1, 1},
+			},
+			map[string]any{},
+		}
+		doc["samplers"] = []any{map[string]any{"magFilter": gltfNearest, "wrapS": gltfClampToEdge, "wrapT": gltfClampToEdge}}
+
+		check := func(t *testing.T, gltf *GLTFScene) {
+			t.Helper()
+			m := gltf.Materials[0]
+			if !m.UseTextures || m.TextureFilter != FilterNearest || m.TextureWrap != WrapClamp {
+				t.Fatalf("Textures and sampler not applied: %+v", m)
+			}
+			// The factor is baked into the base color map
+			if got := m.AlbedoMap.Data[1]; got != (Color{R: 100, G: 100, B: 50}) || m.Albedo != ColorWhite {
+				t.Errorf("Expected the base color texel scaled to (100, 100, 50), got %v", got)
+			}
+			if got := m.SampleMetallic(0.5, 0.5); math.Abs(got-0.5) > 0.01 {
+				t.Errorf("Metallic comes from blue times the factor, expected 0.5, got %g", got)
+			}
+			if got := m.SampleRoughness(0.5, 0.5); math.Abs(got-128.0/255) > 0.01 {
+				t.Errorf("Roughness comes from green, expected 0.5, got %g", got)
+			}
+			if got := m.SampleAO(0.5, 0.5); math.Abs(got-0.5) > 0.01 {
+				t.Errorf("Occlusion at half strength of a black texel should be 0.5, got %g", got)
+			}
+			if m.EmissiveMap == nil || m.NormalMap == nil {
+				t.Error("Emissive and normal maps should be imported")
+			}
+			if mesh := gltf.Meshes[0]; !mesh.HasTangents() {
+				t.Error("A normal-mapped mesh without tangents should get generated ones")
+			}
+		}
+
+		// External images next to the file
+		doc["images"] = []any{map[string]any{"uri": "base.png"}, map[string]any{"uri": "packed.png"}}
+		doc["textures"] = []any{map[string]any{"source": 0, "sampler": 0}, map[string]any{"source": 1, "sampler": 0}}
+		write(t, dir+"/textured.gltf", f.document(doc, true))
+		gltf, err := LoadGLTF(dir + "/textured.gltf")
+		if err != nil {
+			t.Fatalf("LoadGLTF failed: %v", err)
+		}
+		check(t, gltf)
+
+		// The same images embedded in a GLB's binary chunk
+		var images []any
+		for _, name := range []string{"base.png", "packed.png"} {
+			data, err := os.ReadFile(dir + "/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			images = append(images, map[string]any{"bufferView": f.view(data, 0), "mimeType": "image/png"})
+		}
+		doc["images"] = images
+		if err := os.WriteFile(dir+"/textured.glb", f.glb(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gltf, err = LoadGLTF(dir + "/textured.glb")
+		if err != nil {
+			t.Fatalf("LoadGLTF failed on GLB: %v", err)
+		}
+		check(t, gltf)
+	})
+
+	t.Run("AccessorsAndModes", func(t *testing.T) {
+		f := &gltfTestFile{}
+
+		// Interleaved positions and normalized byte colors
+		var interleaved []byte
+		for i := 0; i < 4; i++ {
+			interleaved = append(interleaved, gltfFloats(float64(i%2), float64(i/2), 0)...)
+			interleaved = append(interleaved, 255, byte(i*85), 0, 255)
+		}
+		view := f.view(interleaved, 16)
+		f.accessors = append(f.accessors,
+			map[string]any{"bufferView": view, "componentType": gltfFloat, "count": 4, "type": "VEC3"},
+			map[string]any{"bufferView": view, "byteOffset": 12, "componentType": gltfUnsignedByte, "normalized": true, "count": 4, "type": "VEC4"},
+		)
+
+		// A sparse accessor lifting vertex 3 off the plane
+		f.accessors[0]["sparse"] = map[string]any{
+			"count":   1,
+			"indices": map[string]any{"bufferView": f.view(gltfShorts(3), 0), "componentType": gltfUnsignedShort},
+			"values":  map[string]any{"bufferView": f.view(gltfFloats(1, 1, 5), 0)},
+		}
+		strip := f.accessor([]byte{0, 1, 2, 3}, gltfUnsignedByte, 4, "SCALAR")
+
+		path := t.TempDir() + "/strip.gltf"
+		write(t, path, f.document(map[string]any{
+			"nodes": []any{map[string]any{"mesh": 0}},
+			"meshes": []any{map[string]any{"primitives": []any{map[string]any{
+				"attributes": map[string]int{"POSITION": 0, "COLOR_0": 1}, "indices": strip, "mode": gltfTriangleStrip,
+			}}}},
+		}, true))
+
+		gltf, err := LoadGLTF(path)
+		if err != nil {
+			t.Fatalf("LoadGLTF failed: %v", err)
+		}
+		mesh := gltf.Meshes[0]
+		if len(mesh.Indices) != 6 || mesh.HasNormals() {
+			t.Fatalf("Expected 2 flat-shaded strip triangles, got %d indices", len(mesh.Indices))
+		}
+		if !pointsNear(mesh.Vertices[3], Point{X: 1, Y: 1, Z: 5}, 1e-6) || !pointsNear(mesh.Vertices[2], Point{Y: 1}, 1e-6) {
+			t.Errorf("Sparse values should replace vertex 3 only, got %v", mesh.Vertices)
+		}
+		if mesh.Colors[2] != (Color{R: 255, G: 170}) {
+			t.Errorf("Expected normalized color (255, 170, 0), got %v", mesh.Colors[2])
+		}
+		// The strip's second triangle is flipped back to the first's winding
+		if got := mesh.Indices[3:6]; got[0] != 2 || got[1] != 1 || got[2] != 3 {
+			t.Errorf("Expected the second strip triangle as 2, 1, 3, got %v", got)
+		}
+		if gltf.Nodes[0].Name != "node0" {
+			t.Errorf("Unnamed nodes should be named by index, got %q", gltf.Nodes[0].Name)
+		}
+	})
+
+	t.Run("CamerasAndLights", func(t *testing.T) {
+		down := []float64{-math.Sin(math.Pi / 4), 0, 0, math.Cos(math.Pi / 4)} // Turns -Z to -Y
+		path := t.TempDir() + "/lit.gltf"
+		write(t, path, map[string]any{
+			"asset":              map[string]any{"version": "2.0"},
+			"extensionsUsed":     []string{"KHR_lights_punctual"},
+			"extensionsRequired": []string{"KHR_lights_punctual"},
+			"extensions": map[string]any{"KHR_lights_punctual": map[string]any{"lights": []any{
+				map[string]any{"type": "point", "color": []float64{1, 0, 0}, "intensity": 20, "range": 15},
+				map[string]any{"type": "directional"},
+			}}},
+			"cameras": []any{map[string]any{"type": "perspective", "perspective": map[string]any{
+				"yfov": math.Pi / 3, "aspectRatio": 2, "znear": 0.5, "zfar": 500,
+			}}},
+			"nodes": []any{
+				map[string]any{"name": "Eye", "camera": 0, "translation": []float64{0, 1, 10}},
+				map[string]any{"name": "Lamp", "translation": []float64{2, 3, 4},
+					"extensions": map[string]any{"KHR_lights_punctual": map[string]any{"light": 0}}},
+				map[string]any{"name": "Sun", "rotation": down, "translation": []float64{0, 5, 0},
+					"extensions": map[string]any{"KHR_lights_punctual": map[string]any{"light": 1}}},
+			},
+		})
+
+		gltf, err := LoadGLTF(path)
+		if err != nil {
+			t.Fatalf("LoadGLTF failed: %v", err)
+		}
+		if len(gltf.Cameras) != 1 || len(gltf.Lights) != 2 {
+			t.Fatalf("Expected 1 camera and 2 lights, got %d and %d", len(gltf.Cameras), len(gltf.Lights))
+		}
+
+		camera := gltf.Cameras[0]
+		if !pointsNear(camera.GetPosition(), Point{Y: 1, Z: 10}, 1e-6) || !pointsNear(camera.GetForwardVectorPoint(), Point{Z: -1}, 1e-6) {
+			t.Errorf("Camera should be at (0, 1, 10) looking down -Z, got %v looking %v", camera.GetPosition(), camera.GetForwardVectorPoint())
+		}
+		wantX := 2 * math.Atan(math.Tan(math.Pi/6)*2) / DegToRad
+		if math.Abs(camera.FOV.Y-60) > 1e-9 || math.Abs(camera.FOV.X-wantX) > 1e-9 || camera.Near != 0.5 || camera.Far != 500 {
+			t.Errorf("Unexpected camera projection: FOV %v, near %g, far %g", camera.FOV, camera.Near, camera.Far)
+		}
+
+		lamp, sun := gltf.Lights[0], gltf.Lights[1]
+		if lamp.Type != LightTypePoint || !pointsNear(lamp.Position, Point{X: 2, Y: 3, Z: 4}, 1e-6) ||
+			lamp.Color != (Color{R: 255}) || lamp.Intensity != 20 || lamp.Range != 15 {
+			t.Errorf("Unexpected point light %+v", lamp)
+		}
+		if sun.Type != LightTypeDirectional || !pointsNear(sun.Position, Point{Y: 5 + gltfDirectionalDistance}, 1e-6) || sun.Intensity != 1 {
+			t.Errorf("Directional light should sit above its node shining down, got %+v", sun)
+		}
+	})
+
+	t.Run("SceneAndAssetManager", func(t *testing.T) {
+		f, doc := quad()
+		path := t.TempDir() + "/panel.gltf"
+		write(t, path, f.document(doc, true))
+
+		scene := NewScene()
+		gltf, err := scene.ImportGLTF(path)
+		if err != nil {
+			t.Fatalf("ImportGLTF failed: %v", err)
+		}
+		if scene.FindNode("panel") != gltf.Root || scene.FindNode("Panel") != gltf.Nodes[1] {
+			t.Error("Imported nodes should be registered with the scene")
+		}
+
+		// The asset manager gets one mesh in the file's space
+		mesh, err := NewAssetManager().LoadMesh(path)
+		if err != nil {
+			t.Fatalf("LoadMesh failed: %v", err)
+		}
+		if len(mesh.Indices) != 6 || !pointsNear(mesh.Vertices[1], Point{X: 3, Y: 2, Z: 1}, 1e-6) {
+			t.Errorf("Expected the quad moved by its nodes, got %v", mesh.Vertices)
+		}
+		if !pointsNear(mesh.Normals[0], Point{X: 1}, 1e-6) || len(mesh.FaceMaterials) != 2 {
+			t.Errorf("Expected normals turned to +X and per-face materials, got %v, %d", mesh.Normals[0], len(mesh.FaceMaterials))
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		dir := t.TempDir()
+		f, doc := quad()
+		valid := f.document(doc, true)
+
+		cases := map[string]func(doc map[string]any){
+			"version":   func(doc map[string]any) { doc["asset"] = map[string]any{"version": "1.0"} },
+			"extension": func(doc map[string]any) { doc["extensionsRequired"] = []string{"KHR_draco_mesh_compression"} },
+			"buffer":    func(doc map[string]any) { doc["buffers"] = []any{map[string]any{"uri": "missing.bin", "byteLength": 4}} },
+			"node":      func(doc map[string]any) { doc["scenes"] = []any{map[string]any{"nodes": []int{7}}} },
+		}
+		for name, breakDoc := range cases {
+			broken := map[string]any{}
+			for k, v := range valid {
+				broken[k] = v
+			}
+			breakDoc(broken)
+			path := dir + "/" + name + ".gltf"
+			write(t, path, broken)
+			if _, err := LoadGLTF(path); err == nil {
+				t.Errorf("Expected an error for a bad %s", name)
+			}
+		}
+
+		if err := os.WriteFile(dir+"/short.glb", []byte("glTF\x02\x00"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadGLTF(dir + "/short.glb"); err == nil {
+			t.Error("Expected an error for a truncated GLB")
+		}
+	})
+}
+
+func TestGLTFExport(t *testing.T) {
+
+	// A scene using each kind of content the exporter handles
+	buildScene := func(t *testing.T) (*Scene, []*Light) {
+		scene := NewScene()
+
+		albedo := GenerateCheckerboard(4, 2, 1, Color{R: 200, G: 40, B: 40}, Color{R: 20, G: 20, B: 220})
+		metallic := NewTexture(2, 2)
+		roughness := NewTexture(4, 2)
+		for i := range metallic.Data {
+			metallic.Data[i] = grayColor(float64(i) / 3)
+		}
+		for i := range roughness.Data {
+			roughness.Data[i] = grayColor(float64(i) / 7)
+		}
+		metal := NewPBRMaterial()
+		metal.AlbedoMap, metal.MetallicMap, metal.RoughnessMap = albedo, metallic, roughness
+		metal.NormalMap = GenerateCheckerboard(2, 2, 1, Color{R: 128, G: 128, B: 255}, Color{R: 160, G: 128, B: 240})
+		metal.AO = 0.5
+		metal.Emissive = Color{R: 10, G: 20, B: 30}
+		metal.UseTextures = true
+		metal.TextureFilter, metal.TextureWrap = FilterNearest, WrapClamp
+
+		box := GenerateBox(2, 1, 1, 1, 1, 1)
+		box.Material = metal
+		if err := box.GenerateTangents(); err != nil {
+			t.Fatalf("GenerateTangents: %v", err)
+		}
+
+		body := NewSceneNode("Body")
+		body.Transform.SetPosition(1, 2, 3)
+		body.Transform.SetRotationQuaternion(QuaternionFromAxisAngle(Point{Y: 1}, 0.5))
+		body.Transform.SetScale(1, 2, 1)
+		scene.AddNode(body)
+		scene.AddNodeTo(NewSceneNodeWithObject("Box", box), body)
+
+		// Two Phong materials on one mesh
+		floor := GeneratePlane(4, 4, 2, 2)
+		red, blue := NewMaterial(), NewMaterial()
+		red.DiffuseColor, blue.DiffuseColor = Color{R: 255}, Color{B: 255}
+		floor.Material = &red
+		floor.FaceMaterials = make([]IMaterial, len(floor.Indices)/3)
+		for i := range floor.FaceMaterials {
+			floor.FaceMaterials[i] = &red
+			if i%2 == 1 {
+				floor.FaceMaterials[i] = &blue
+			}
+		}
+		scene.AddNode(NewSceneNodeWithObject("Floor", floor))
+
+		rocks := NewInstancedMesh(GenerateIcosphere(0.5, 1))
+		rocks.AddInstanceAt(3, 0, 0, ColorBlack)
+		rocks.AddInstanceAt(-3, 0, 1, ColorBlack)
+		scene.AddNode(NewSceneNodeWithObject("Rocks", rocks))
+
+		hidden := NewSceneNodeWithObject("Hidden", GenerateSphere(1, 4, 4))
+		hidden.Enabled = false
+		scene.AddNode(hidden)
+
+		scene.Camera = NewCameraAt(0, 3, -10)
+		scene.Camera.Transform.LookAt(Point{})
+		scene.Camera.SetFOV(70, 50)
+		scene.Camera.Near, scene.Camera.Far = 0.5, 200
+
+		lamp := NewPointLight(2, 4, 1, Color{R: 255, G: 200, B: 100}, 3)
+		lamp.Range = 20
+		sun := NewLight(10, 20, 0, ColorWhite, 0.8)
+		off := NewPointLight(0, 1, 0, ColorWhite, 1)
+		off.IsEnabled = false
+		return scene, []*Light{lamp, sun, off}
+	}
+
+	for _, ext := range []string{".gltf", ".glb"} {
+		t.Run("RoundTrip"+ext, func(t *testing.T) {
+			scene, lights := buildScene(t)
+			path := t.TempDir() + "/scene" + ext
+			if err := SaveGLTF(scene, path, lights...); err != nil {
+				t.Fatalf("SaveGLTF failed: %v", err)
+			}
+			gltf, err := LoadGLTF(path)
+			if err != nil {
+				t.Fatalf("LoadGLTF failed on the exported file: %v", err)
+			}
+
+			byName := make(map[string]*SceneNode)
+			for _, node := range gltf.Nodes {
+				if node != nil {
+					byName[node.Name] = node
+				}
+			}
+			if byName["Hidden"] != nil || len(gltf.Lights) != 2 || len(gltf.Cameras) != 1 {
+				t.Fatalf("Disabled nodes and lights should be left out, got %d lights", len(gltf.Lights))
+			}
+
+			// Transforms: the same world matrices
+			for _, name := range []string{"Body", "Box", "Floor"} {
+				want, got := scene.FindNode(name), byName[name]
+				if got == nil {
+					t.Fatalf("Node %s missing", name)
+				}
+				probe := Point{X: 0.3, Y: -0.7, Z: 1.1}
+				wantWorld, gotWorld := want.Transform.GetWorldMatrix(), got.Transform.GetWorldMatrix()
+				a, b := wantWorld.TransformPoint(probe), gotWorld.TransformPoint(probe)
+				if !pointsNear(a, b, 1e-5) {
+					t.Errorf("Node %s moved: %v became %v", name, a, b)
+				}
+			}
+			if byName["Box"].Parent != byName["Body"] {
+				t.Error("Hierarchy should be kept")
+			}
+
+			// Geometry and tangents, to float32 precision
+			src := scene.FindNode("Box").Object.(*Mesh)
+			mesh := byName["Box"].Object.(*Mesh)
+			if len(mesh.Vertices) != len(src.Vertices) || len(mesh.Indices) != len(src.Indices) {
+				t.Fatalf("Box changed size: %d vertices, %d indices", len(mesh.Vertices), len(mesh.Indices))
+			}
+			for i := range src.Indices {
+				if mesh.Indices[i] != src.Indices[i] {
+					t.Fatalf("Index %d changed", i)
+				}
+			}
+			for i, v := range src.Vertices {
+				tan, srcTan := mesh.Tangents[i], src.Tangents[i]
+				if !pointsNear(mesh.Vertices[i], v, 1e-5) || !pointsNear(mesh.Normals[i], src.Normals[i], 1e-5) ||
+					math.Abs(mesh.UVs[i].U-src.UVs[i].U) > 1e-6 || math.Abs(mesh.UVs[i].V-src.UVs[i].V) > 1e-6 ||
+					!pointsNear(Point{X: tan.X, Y: tan.Y, Z: tan.Z}, Point{X: srcTan.X, Y: srcTan.Y, Z: srcTan.Z}, 1e-5) || tan.W != srcTan.W {
+					t.Fatalf("Vertex %d attributes changed", i)
+				}
+			}
+
+			// Materials sample the same
+			metal := src.Material.(*PBRMaterial)
+			got := mesh.Material.(*PBRMaterial)
+			if got.TextureFilter != FilterNearest || got.TextureWrap != WrapClamp || got.Emissive != metal.Emissive {
+				t.Errorf("Material settings changed: %+v", got)
+			}
+			for _, uv := range [][2]float64{{0.1, 0.2}, {0.6, 0.3}, {0.9, 0.8}, {0.4, 0.7}} {
+				u, v := uv[0], uv[1]
+				if got.SampleDiffuse(u, v) != metal.SampleDiffuse(u, v) ||
+					got.SampleNormal(u, v) != metal.SampleNormal(u, v) ||
+					math.Abs(got.SampleMetallic(u, v)-metal.SampleMetallic(u, v)) > 1.0/255 ||
+					math.Abs(got.SampleRoughness(u, v)-metal.SampleRoughness(u, v)) > 1.0/255 ||
+					math.Abs(got.SampleAO(u, v)-metal.SampleAO(u, v)) > 1.0/255 {
+					t.Errorf("Material samples differently at (%g, %g)", u, v)
+				}
+			}
+
+			floor := byName["Floor"].Object.(*Mesh)
+			if len(floor.Indices) != len(scene.FindNode("Floor").Object.(*Mesh).Indices) || len(floor.FaceMaterials) == 0 {
+				t.Fatal("Floor should keep its triangles and per-face materials")
+			}
+			reds := 0
+			for f := 0; f < len(floor.Indices)/3; f++ {
+				if c := floor.MaterialForFace(f).GetDiffuseColor(0, 0); c == (Color{R: 255}) {
+					reds++
+				} else if c != (Color{B: 255}) {
+					t.Fatalf("Unexpected face color %v", c)
+				}
+			}
+			if reds != len(floor.Indices)/6 {
+				t.Errorf("Expected half the floor red, got %d of %d", reds, len(floor.Indices)/3)
+			}
+			if r := floor.Material.GetRoughness(); math.Abs(r-shininessRoughness(32)) > 1e-9 {
+				t.Errorf("Phong materials should get a matching roughness, got %g", r)
+			}
+
+			// Instances share one mesh
+			rocks := byName["Rocks"]
+			if len(rocks.Children) != 2 || rocks.Children[0].Object != rocks.Children[1].Object {
+				t.Fatal("Instances should be child nodes sharing a mesh")
+			}
+			if p := rocks.Children[1].Transform.GetWorldPosition(); !pointsNear(p, Point{X: -3, Z: 1}, 1e-9) {
+				t.Errorf("Expected the second instance at (-3, 0, 1), got %v", p)
+			}
+
+			camera, want := gltf.Cameras[0], scene.Camera
+			if !pointsNear(camera.GetPosition(), want.GetPosition(), 1e-9) ||
+				!pointsNear(camera.GetForwardVectorPoint(), want.GetForwardVectorPoint(), 1e-9) {
+				t.Errorf("Camera moved: %v looking %v", camera.GetPosition(), camera.GetForwardVectorPoint())
+			}
+			if math.Abs(camera.FOV.X-70) > 1e-6 || math.Abs(camera.FOV.Y-50) > 1e-6 || camera.Near != 0.5 || camera.Far != 200 {
+				t.Errorf("Camera projection changed: %v, %g, %g", camera.FOV, camera.Near, camera.Far)
+			}
+
+			for i, light := range gltf.Lights {
+				want := lights[i]
+				if light.Type != want.Type || !pointsNear(light.Position, want.Position, 1e-9) ||
+					light.Color != want.Color || light.Intensity != want.Intensity || light.Range != want.Range {
+					t.Errorf("Light %d changed: %+v", i, light)
+				}
+			}
+		})
+	}
+
+	t.Run("ProceduralContent", func(t *testing.T) {
+		scene := NewScene()
+		lod := NewLODGroup()
+		lod.AddLOD(GenerateSphere(1, 16, 16), 10)
+		lod.AddLOD(GenerateSphere(1, 4, 4), 100)
+		lod.CurrentLOD = 1
+		scene.AddNode(NewSceneNodeWithObject("LOD", lod))
+		scene.AddNode(NewSceneNodeWithObject("Line", NewLine(Point{}, Point{X: 1})))
+
+		path := t.TempDir() + "/procedural.glb"
+		if err := SaveGLTF(scene, path); err != nil {
+			t.Fatalf("SaveGLTF failed: %v", err)
+		}
+		mesh, err := LoadGLTFMesh(path)
+		if err != nil {
+			t.Fatalf("LoadGLTFMesh failed: %v", err)
+		}
+		if len(mesh.Indices) != len(lod.Levels[1].Mesh.Indices) {
+			t.Errorf("Expected the current LOD's %d indices, got %d", len(lod.Levels[1].Mesh.Indices), len(mesh.Indices))
+		}
+
+		if err := SaveGLTF(scene, t.TempDir()+"/scene.obj"); err == nil {
+			t.Error("Expected an error for a non-glTF extension")
+		}
+	})
+}
+
+// ============================================================================
+// STL TESTS
+// ============================================================================
+
+func TestSTL(t *testing.T) {
+	// Unique positions of a mesh, which is what an STL round trip keeps (to
+	// float32 precision)
+	key := func(p Point) Point {
+		return Point{X: math.Round(p.X * 1e4), Y: math.Round(p.Y * 1e4), Z: math.Round(p.Z * 1e4)}
+	}
+	positions := func(mesh *Mesh) map[Point]bool {
+		unique := make(map[Point]bool)
+		for _, v := range mesh.Vertices {
+			unique[key(v)] = true
+		}
+		return unique
+	}
+
+	for name, format := range map[string]STLFormat{"Binary": STLBinary, "ASCII": STLASCII} {
+		t.Run("RoundTrip"+name, func(t *testing.T) {
+			src := GenerateIcosphere(1.5, 2)
+			path := t.TempDir() + "/sphere.stl"
+			if err := SaveSTL(src, path, format); err != nil {
+				t.Fatalf("SaveSTL failed: %v", err)
+			}
+			if info, err := os.Stat(path); err != nil {
+				t.Fatal(err)
+			} else if format == STLBinary && info.Size() != int64(84+50*len(src.Indices)/3) {
+				t.Errorf("Expected %d bytes, got %d", 84+50*len(src.Indices)/3, info.Size())
+			}
+
+			mesh, err := LoadSTL(path)
+			if err != nil {
+				t.Fatalf("LoadSTL failed: %v", err)
+			}
+			if len(mesh.Indices) != len(src.Indices) {
+				t.Fatalf("Expected %d triangles, got %d", len(src.Indices)/3, len(mesh.Indices)/3)
+			}
+
+			// Welded back to one vertex per position, smooth and outward
+			want := positions(src)
+			if len(mesh.Vertices) != len(want) || !mesh.HasNormals() {
+				t.Fatalf("Expected %d welded vertices with normals, got %d", len(want), len(mesh.Vertices))
+			}
+			for i, v := range mesh.Vertices {
+				if !want[key(v)] {
+					t.Fatalf("Vertex %v is not in the source mesh", v)
+				}
+				if dotPoints(mesh.Normals[i], normalizePoint(v)) < 0.9 {
+					t.Fatalf("Normal %v at %v should point outward", mesh.Normals[i], v)
+				}
+			}
+			for f := 0; f < len(mesh.Indices)/3; f++ {
+				a, _, _ := mesh.triangleCorners(f)
+				if dotPoints(mesh.faceNormal(f), a) <= 0 {
+					t.Fatalf("Triangle %d was turned inside out", f)
+				}
+			}
+		})
+	}
+
+	t.Run("HardEdges", func(t *testing.T) {
+		path := t.TempDir() + "/box.stl"
+		if err := SaveSTL(GenerateBox(2, 2, 2, 1, 1, 1), path, STLBinary); err != nil {
+			t.Fatal(err)
+		}
+		mesh, err := LoadSTL(path)
+		if err != nil {
+			t.Fatalf("LoadSTL failed: %v", err)
+		}
+		// 8 welded corners, split again by the crease angle into 3 normals each
+		if len(mesh.Vertices) != 24 || len(mesh.Indices) != 36 {
+			t.Errorf("Expected 24 vertices and 12 triangles, got %d and %d", len(mesh.Vertices), len(mesh.Indices)/3)
+		}
+	})
+
+	t.Run("ASCIIVariants", func(t *testing.T) {
+		// A quad facet, odd spacing, zero normals and two solids
+		text := "solid first part\n" +
+			"facet normal 0 0 0\n outer loop\n" +
+			"  vertex 0 0 0\n  vertex 1 0 0\n\tvertex 1 1 0\n  vertex 0 1 0\n" +
+			" endloop\nendfacet\nendsolid first part\n" +
+			"solid second\n  facet normal 0 0 1\n    outer loop\n" +
+			"      vertex 1.0e0 0 0\n      vertex 2 0 0\n      vertex 1 1 0\n" +
+			"    endloop\n  endfacet\nendsolid\n"
+		path := t.TempDir() + "/parts.stl"
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mesh, err := LoadSTL(path)
+		if err != nil {
+			t.Fatalf("LoadSTL failed: %v", err)
+		}
+		if len(mesh.Indices) != 9 || len(mesh.Vertices) != 5 {
+			t.Errorf("Expected 3 triangles sharing 5 vertices, got %d and %d", len(mesh.Indices)/3, len(mesh.Vertices))
+		}
+		if n := mesh.faceNormal(0); n != (Point{Z: 1}) {
+			t.Errorf("Winding should give +Z, got %v", n)
+		}
+	})
+
+	t.Run("BinaryHeaderStartingWithSolid", func(t *testing.T) {
+		path := t.TempDir() + "/solid.stl"
+		if err := SaveSTL(GenerateBox(1, 1, 1, 1, 1, 1), path, STLBinary); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		copy(data, "solid exported by some CAD package")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if mesh, err := LoadSTL(path); err != nil || len(mesh.Indices) != 36 {
+			t.Errorf("Binary file with a \"solid\" header should load by its size, got %v", err)
+		}
+
+		// The asset manager loads STL files too
+		if mesh, err := NewAssetManager().LoadMesh(path); err != nil || len(mesh.Indices) != 36 {
+			t.Errorf("AssetManager should load .stl files, got %v", err)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		dir := t.TempDir()
+		box := dir + "/box.stl"
+		if err := SaveSTL(GenerateBox(1, 1, 1, 1, 1, 1), box, STLBinary); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(box)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cases := map[string][]byte{
+			"truncated": data[:len(data)-10],
+			"vertex":    []byte("solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0\nendloop\nendfacet\nendsolid x\n"),
+			"facet":     []byte("solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\nendfacet\nendsolid x\n"),
+			"empty":     []byte("solid x\nendsolid x\n"),
+			"unknown":   []byte("not a model"),
+		}
+		for name, content := range cases {
+			path := dir + "/" + name + ".stl"
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadSTL(path); err == nil {
+				t.Errorf("Expected an error for the %s file", name)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// PLY / POINT CLOUD TESTS
+// ============================================================================
+
+func TestPLYPointCloud(t *testing.T) {
+	type plyVertex struct {
+		X, Y, Z    float32
+		R, G, B    uint8
+		Confidence float64
+		Label      int16
+	}
+	vertices := []plyVertex{
+		{0, 0, 0, 255, 0, 0, 0.25, -3},
+		{1, 0, 0, 0, 255, 0, 0.5, 7},
+		{0, 1, 0, 0, 0, 255, 1, 300},
+	}
+	header := "element vertex 3\nproperty float x\nproperty float y\nproperty float z\n" +
+		"property uchar red\nproperty uchar green\nproperty uchar blue\n" +
+		"property double confidence\nproperty short label\n" +
+		"element face 1\nproperty list uchar int vertex_indices\n" +
+		"element edge 1\nproperty int vertex1\nproperty int vertex2\nend_header\n"
+	binaryPLY := func(t *testing.T, order binary.ByteOrder, format string) string {
+		data := []byte("ply\nformat " + format + " 1.0\n" + header)
+		var err error
+		for _, v := range vertices {
+			if data, err = binary.Append(data, order, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if data, err = binary.Append(data, order, struct {
+			Count   uint8
+			Indices [3]int32
+			Edge    [2]int32
+		}{3, [3]int32{0, 1, 2}, [2]int32{0, 1}}); err != nil {
+			t.Fatal(err)
+		}
+		path := t.TempDir() + "/tri.ply"
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	for format, order := range map[string]binary.ByteOrder{
+		"binary_little_endian": binary.LittleEndian,
+		"binary_big_endian":    binary.BigEndian,
+	} {
+		t.Run(format, func(t *testing.T) {
+			path := binaryPLY(t, order, format)
+			mesh, err := LoadPLY(path)
+			if err != nil {
+				t.Fatalf("LoadPLY failed: %v", err)
+			}
+			if len(mesh.Vertices) != 3 || len(mesh.Indices) != 3 || mesh.Indices[2] != 2 {
+				t.Fatalf("Expected one triangle, got %d vertices and indices %v", len(mesh.Vertices), mesh.Indices)
+			}
+			if mesh.Vertices[1] != (Point{X: 1}) || mesh.Colors[2] != ColorBlue {
+				t.Errorf("Unexpected vertex %v or color %v", mesh.Vertices[1], mesh.Colors[2])
+			}
+
+			// The same file as a point cloud keeps the other properties
+			cloud, err := LoadPointCloud(path)
+			if err != nil {
+				t.Fatalf("LoadPointCloud failed: %v", err)
+			}
+			if len(cloud.Points) != 3 || !cloud.HasColors() || cloud.HasNormals() {
+				t.Fatalf("Expected 3 colored points without normals, got %d", len(cloud.Points))
+			}
+			confidence, label := cloud.Property("confidence"), cloud.Property("label")
+			if confidence == nil || label == nil || len(cloud.Properties) != 2 {
+				t.Fatalf("Expected confidence and label properties, got %v", cloud.Properties)
+			}
+			for i, v := range vertices {
+				if confidence.Values[i] != v.Confidence || label.Values[i] != float64(v.Label) {
+					t.Errorf("Point %d: expected %v and %v, got %v and %v", i, v.Confidence, v.Label, confidence.Values[i], label.Values[i])
+				}
+			}
+			if confidence.Type != "double" || label.Type != "short" {
+				t.Errorf("Property types should be kept, got %s and %s", confidence.Type, label.Type)
+			}
+		})
+	}
+
+	for name, format := range map[string]PLYFormat{"Binary": PLYBinary, "ASCII": PLYASCII} {
+		t.Run("MeshRoundTrip"+name, func(t *testing.T) {
+			// A quad and a triangle with every vertex attribute
+			src := NewMesh()
+			for i, p := range []Point{{}, {X: 1}, {X: 1, Y: 1}, {Y: 1}, {X: 2, Y: 0.5}} {
+				src.AddVertex(p.X, p.Y, p.Z)
+				src.AddUV(p.X/2, p.Y)
+				src.Normals = append(src.Normals, Point{Z: 1})
+				src.Colors = append(src.Colors, Color{R: uint8(i * 50), G: 10, B: 255})
+			}
+			src.Indices = []int{0, 1, 2, 0, 2, 3, 1, 4, 2}
+			src.FaceSizes = []int{4, 3}
+
+			path := t.TempDir() + "/mesh.ply"
+			if err := SavePLY(src, path, format); err != nil {
+				t.Fatalf("SavePLY failed: %v", err)
+			}
+			mesh, err := LoadPLY(path)
+			if err != nil {
+				t.Fatalf("LoadPLY failed: %v", err)
+			}
+
+			if len(mesh.FaceSizes) != 2 || mesh.FaceSizes[0] != 4 || len(mesh.Indices) != len(src.Indices) {
+				t.Fatalf("Expected the quad and triangle back, got faces %v", mesh.FaceSizes)
+			}
+			for i := range src.Indices {
+				if mesh.Indices[i] != src.Indices[i] {
+					t.Fatalf("Indices changed: %v", mesh.Indices)
+				}
+			}
+			for i := range src.Vertices {
+				if mesh.Vertices[i] != src.Vertices[i] || mesh.Normals[i] != src.Normals[i] ||
+					mesh.Colors[i] != src.Colors[i] || mesh.UVs[i] != src.UVs[i] {
+					t.Errorf("Vertex %d changed: %v %v %v %v", i, mesh.Vertices[i], mesh.Normals[i], mesh.Colors[i], mesh.UVs[i])
+				}
+			}
+		})
+
+		t.Run("PointCloudRoundTrip"+name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(5))
+			src := NewPointCloud()
+			confidence := PointProperty{Name: "confidence", Type: "float"}
+			intensity := PointProperty{Name: "intensity", Type: "uchar"}
+			for i := 0; i < 100; i++ {
+				p := Point{X: rng.Float64()*10 - 5, Y: rng.Float64() * 3, Z: rng.Float64()*10 - 5}
+				src.Points = append(src.Points, p)
+				src.Normals = append(src.Normals, normalizePoint(p))
+				src.Colors = append(src.Colors, Color{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256))})
+				confidence.Values = append(confidence.Values, float64(float32(rng.Float64())))
+				intensity.Values = append(intensity.Values, float64(rng.Intn(256)))
+			}
+			src.Properties = []PointProperty{confidence, intensity}
+
+			path := t.TempDir() + "/scan.ply"
+			if err := SavePointCloud(src, path, format); err != nil {
+				t.Fatalf("SavePointCloud failed: %v", err)
+			}
+			if info, err := os.Stat(path); err != nil {
+				t.Fatal(err)
+			} else if format == PLYBinary {
+				// Header plus 12 + 12 + 3 + 4 + 1 bytes per point
+				data, _ := os.ReadFile(path)
+				headerSize := len(data) - 100*32
+				if headerSize <= 0 || string(data[headerSize-len("end_header\n"):headerSize]) != "end_header\n" {
+					t.Errorf("Expected 32 bytes per point after the header, file has %d bytes", info.Size())
+				}
+			}
+
+			cloud, err := LoadPointCloud(path)
+			if err != nil {
+				t.Fatalf("LoadPointCloud failed: %v", err)
+			}
+			if len(cloud.Points) != 100 || !cloud.HasNormals() || !cloud.HasColors() || len(cloud.Properties) != 2 {
+				t.Fatalf("Expected 100 points with normals, colors and 2 properties, got %d", len(cloud.Points))
+			}
+			for i := range src.Points {
+				if !pointsNear(cloud.Points[i], src.Points[i], 1e-5) || !pointsNear(cloud.Normals[i], src.Normals[i], 1e-5) || cloud.Colors[i] != src.Colors[i] {
+					t.Fatalf("Point %d changed", i)
+				}
+				for k, prop := range src.Properties {
+					if cloud.Properties[k].Name != prop.Name || cloud.Properties[k].Values[i] != prop.Values[i] {
+						t.Fatalf("Property %s of point %d changed: %v", prop.Name, i, cloud.Properties[k].Values[i])
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("TerminalPointsAndSplats", func(t *testing.T) {
+		camera := NewCamera()
+		camera.SetPosition(0, 0, -40)
+		cloud := NewPointCloud()
+		cloud.Points = []Point{{}}
+		cloud.Colors = []Color{ColorRed}
+
+		render := func(cloud *PointCloud) (*TerminalRenderer, int) {
+			r := NewTerminalRenderer(bufio.NewWriter(io.Discard), 40, 80)
+			r.renderNode(NewSceneNodeWithObject("Scan", cloud), IdentityMatrix(), camera)
+			drawn := 0
+			for y := range r.Surface {
+				for x := range r.Surface[y] {
+					if r.Surface[y][x] != DefaultCharset[0] {
+						drawn++
+						if r.ColorBuffer[y][x] != ColorRed {
+							t.Errorf("Cell (%d, %d) should be red, got %v", x, y, r.ColorBuffer[y][x])
+						}
+					}
+				}
+			}
+			return r, drawn
+		}
+
+		if _, drawn := render(cloud); drawn != 1 {
+			t.Errorf("A one pixel point should cover 1 cell, got %d", drawn)
+		}
+
+		// A splat 8 units across at depth 40 is an ellipse 6 by 3 cells in radius
+		cloud.Mode, cloud.PointSize = PointCloudSplats, 8
+		_, near := render(cloud)
+		if near < 40 || near > 13*7 {
+			t.Errorf("Expected the splat to fill its ellipse, got %d cells", near)
+		}
+		camera.SetPosition(0, 0, -80)
+		if _, far := render(cloud); far >= near || far < 4 {
+			t.Errorf("Splats should shrink with distance: %d cells near, %d far", near, far)
+		}
+
+		// Bounds grow by the splat radius
+		if b := cloud.Bounds(); b.Min.X != -4 || b.Max.Y != 4 {
+			t.Errorf("Expected splat bounds of radius 4, got %v", b)
+		}
+
+		// Nearer points win the depth test
+		cloud.Points = append(cloud.Points, Point{Z: -10})
+		cloud.Colors = append(cloud.Colors, ColorGreen)
+		cloud.MarkDirty()
+		r := NewTerminalRenderer(bufio.NewWriter(io.Discard), 40, 80)
+		r.RenderPointCloud(cloud, IdentityMatrix(), camera)
+		if c := r.ColorBuffer[20][40]; c != ColorGreen {
+			t.Errorf("The nearer green splat should be on top, got %v", c)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		dir := t.TempDir()
+		data, err := os.ReadFile(binaryPLY(t, binary.LittleEndian, "binary_little_endian"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cases := map[string][]byte{
+			"truncated": data[:len(data)-5],
+			"type":      []byte("ply\nformat ascii 1.0\nelement vertex 1\nproperty half x\nend_header\n0\n"),
+			"format":    []byte("ply\nformat binary_middle_endian 1.0\nelement vertex 0\nend_header\n"),
+		}
+		for name, content := range cases {
+			path := dir + "/" + name + ".ply"
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPLY(path); err == nil {
+				t.Errorf("Expected an error loading the %s file as a mesh", name)
+			}
+			if _, err := LoadPointCloud(path); err == nil {
+				t.Errorf("Expected an error loading the %s file as a point cloud", name)
+			}
+		}
+
+		cloud := NewPointCloud()
+		cloud.Points = []Point{{}, {X: 1}}
+		cloud.Properties = []PointProperty{{Name: "confidence", Type: "float", Values: []float64{1}}}
+		if err := SavePointCloud(cloud, dir+"/short.ply", PLYBinary); err == nil {
+			t.Error("Expected an error saving a property with too few values")
+		}
+	})
+}
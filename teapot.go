@@ -0,0 +1,103 @@
+package main
+
+// ============================================================================
+// UTAH TEAPOT
+// ============================================================================
+// Martin Newell's teapot as bicubic Bezier patches. The data is the usual
+// compact form of the original: ten patches for one quarter (rim, body, lid
+// and bottom) or one half (handle and spout), mirrored into the full 32, in
+// Newell's Z-up coordinates. NewTeapot turns it Y-up, sitting on y = 0.
+// ============================================================================
+
+// teapotPatches index teapotControlPoints; the first six are mirrored four
+// ways, the last four (handle and spout) two ways
+var teapotPatches = [10][16]int{
+	// Rim
+	{102, 103, 104, 105, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	// Body
+	{12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27},
+	{24, 25, 26, 27, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40},
+	// Lid
+	{96, 96, 96, 96, 97, 98, 99, 100, 101, 101, 101, 101, 0, 1, 2, 3},
+	{0, 1, 2, 3, 106, 107, 108, 109, 110, 111, 112, 113, 114, 115, 116, 117},
+	// Bottom
+	{118, 118, 118, 118, 124, 122, 119, 121, 123, 126, 125, 120, 40, 39, 38, 37},
+	// Handle
+	{41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56},
+	{53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63, 64, 28, 65, 66, 67},
+	// Spout
+	{68, 69, 70, 71, 72, 73, 74, 75, 76, 77, 78, 79, 80, 81, 82, 83},
+	{80, 81, 82, 83, 84, 85, 86, 87, 88, 89, 90, 91, 92, 93, 94, 95},
+}
+
+// teapotControlPoints are in Newell's Z-up coordinates
+var teapotControlPoints = [127][3]float64{
+	{0.2, 0, 2.7}, {0.2, -0.112, 2.7}, {0.112, -0.2, 2.7}, {0, -0.2, 2.7},
+	{1.3375, 0, 2.53125}, {1.3375, -0.749, 2.53125}, {0.749, -1.3375, 2.53125}, {0, -1.3375, 2.53125},
+	{1.4375, 0, 2.53125}, {1.4375, -0.805, 2.53125}, {0.805, -1.4375, 2.53125}, {0, -1.4375, 2.53125},
+	{1.5, 0, 2.4}, {1.5, -0.84, 2.4}, {0.84, -1.5, 2.4}, {0, -1.5, 2.4},
+	{1.75, 0, 1.875}, {1.75, -0.98, 1.875}, {0.98, -1.75, 1.875}, {0, -1.75, 1.875},
+	{2, 0, 1.35}, {2, -1.12, 1.35}, {1.12, -2, 1.35}, {0, -2, 1.35},
+	{2, 0, 0.9}, {2, -1.12, 0.9}, {1.12, -2, 0.9}, {0, -2, 0.9},
+	{-2, 0, 0.9}, {2, 0, 0.45}, {2, -1.12, 0.45}, {1.12, -2, 0.45},
+	{0, -2, 0.45}, {1.5, 0, 0.225}, {1.5, -0.84, 0.225}, {0.84, -1.5, 0.225},
+	{0, -1.5, 0.225}, {1.5, 0, 0.15}, {1.5, -0.84, 0.15}, {0.84, -1.5, 0.15},
+	{0, -1.5, 0.15}, {-1.6, 0, 2.025}, {-1.6, -0.3, 2.025}, {-1.5, -0.3, 2.25},
+	{-1.5, 0, 2.25}, {-2.3, 0, 2.025}, {-2.3, -0.3, 2.025}, {-2.5, -0.3, 2.25},
+	{-2.5, 0, 2.25}, {-2.7, 0, 2.025}, {-2.7, -0.3, 2.025}, {-3, -0.3, 2.25},
+	{-3, 0, 2.25}, {-2.7, 0, 1.8}, {-2.7, -0.3, 1.8}, {-3, -0.3, 1.8},
+	{-3, 0, 1.8}, {-2.7, 0, 1.575}, {-2.7, -0.3, 1.575}, {-3, -0.3, 1.35},
+	{-3, 0, 1.35}, {-2.5, 0, 1.125}, {-2.5, -0.3, 1.125}, {-2.65, -0.3, 0.9375},
+	{-2.65, 0, 0.9375}, {-2, -0.3, 0.9}, {-1.9, -0.3, 0.6}, {-1.9, 0, 0.6},
+	{1.7, 0, 1.425}, {1.7, -0.66, 1.425}, {1.7, -0.66, 0.6}, {1.7, 0, 0.6},
+	{2.6, 0, 1.425}, {2.6, -0.66, 1.425}, {3.1, -0.66, 0.825}, {3.1, 0, 0.825},
+	{2.3, 0, 2.1}, {2.3, -0.25, 2.1}, {2.4, -0.25, 2.025}, {2.4, 0, 2.025},
+	{2.7, 0, 2.4}, {2.7, -0.25, 2.4}, {3.3, -0.25, 2.4}, {3.3, 0, 2.4},
+	{2.8, 0, 2.475}, {2.8, -0.25, 2.475}, {3.525, -0.25, 2.49375}, {3.525, 0, 2.49375},
+	{2.9, 0, 2.475}, {2.9, -0.15, 2.475}, {3.45, -0.15, 2.5125}, {3.45, 0, 2.5125},
+	{2.8, 0, 2.4}, {2.8, -0.15, 2.4}, {3.2, -0.15, 2.4}, {3.2, 0, 2.4},
+	{0, 0, 3.15}, {0.8, 0, 3.15}, {0.8, -0.45, 3.15}, {0.45, -0.8, 3.15},
+	{0, -0.8, 3.15}, {0, 0, 2.85}, {1.4, 0, 2.4}, {1.4, -0.784, 2.4},
+	{0.784, -1.4, 2.4}, {0, -1.4, 2.4}, {0.4, 0, 2.55}, {0.4, -0.224, 2.55},
+	{0.224, -0.4, 2.55}, {0, -0.4, 2.55}, {1.3, 0, 2.55}, {1.3, -0.728, 2.55},
+	{0.728, -1.3, 2.55}, {0, -1.3, 2.55}, {1.3, 0, 2.4}, {1.3, -0.728, 2.4},
+	{0.728, -1.3, 2.4}, {0, -1.3, 2.4}, {0, 0, 0}, {1.425, -0.798, 0},
+	{1.5, 0, 0.075}, {1.425, 0, 0}, {0.798, -1.425, 0}, {0, -1.5, 0.075},
+	{0, -1.425, 0}, {1.5, -0.84, 0.075}, {0.84, -1.5, 0.075},
+}
+
+// TeapotPatches returns the 32 patches of the Utah teapot, Y-up, standing on
+// y = 0 with the spout along +X. It is 3.15 units tall.
+func TeapotPatches() []BezierPatch {
+	var patches []BezierPatch
+	for p, indices := range teapotPatches {
+		mirrors := [][2]float64{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+		if p >= 6 {
+			mirrors = mirrors[:2]
+		}
+		for _, m := range mirrors {
+			var patch BezierPatch
+			for k, index := range indices {
+				c := teapotControlPoints[index]
+				x, y, z := c[0]*m[0], c[1]*m[1], c[2]
+				// Z-up to Y-up, keeping handedness
+				patch[k] = Point{X: x, Y: z, Z: -y}
+			}
+
+			// A single mirror turns the patch inside out; reverse its rows
+			if m[0]*m[1] < 0 {
+				for row := 0; row < 4; row++ {
+					patch[row*4], patch[row*4+3] = patch[row*4+3], patch[row*4]
+					patch[row*4+1], patch[row*4+2] = patch[row*4+2], patch[row*4+1]
+				}
+			}
+			patches = append(patches, patch)
+		}
+	}
+	return patches
+}
+
+// NewTeapot returns the Utah teapot as a Bezier surface
+func NewTeapot() *BezierSurface {
+	return NewBezierSurface(TeapotPatches())
+}
@@ -0,0 +1,422 @@
+package main
+
+import "math"
+
+// ============================================================================
+// HEIGHTMAP TERRAIN
+// ============================================================================
+// A terrain is a square grid of height samples centered on the origin. It is
+// drawn as chunks taken from a quadtree: every chunk has the same number of
+// cells, so a chunk one level up covers four times the area at half the
+// resolution. Update picks the chunks for a camera position, splitting those
+// the camera is close to. Neighbouring chunks at different levels don't share
+// all their edge vertices, so every chunk hangs a skirt below its border to
+// hide the cracks.
+// ============================================================================
+
+// TerrainSettings controls terrain size and LOD
+type TerrainSettings struct {
+	Size        float64 // World extent along X and Z
+	HeightScale float64 // World height of a height value of 1
+	ChunkSize   int     // Cells along a chunk edge, at every LOD
+	LODLevels   int     // Quadtree depth; the finest grid has ChunkSize << (LODLevels-1) cells per side
+	LODDistance float64 // A chunk splits while the camera is closer than LODDistance times its width
+	SkirtDepth  float64 // How far skirts hang below chunk edges (0 = no skirts)
+}
+
+// DefaultTerrainSettings returns settings for a 256 unit terrain
+func DefaultTerrainSettings() TerrainSettings {
+	return TerrainSettings{
+		Size:        256.0,
+		HeightScale: 32.0,
+		ChunkSize:   16,
+		LODLevels:   4,
+		LODDistance: 1.5,
+		SkirtDepth:  2.0,
+	}
+}
+
+// Terrain is a chunked heightfield with quadtree LOD
+type Terrain struct {
+	Settings   TerrainSettings
+	Material   IMaterial
+	Resolution int       // Cells along each side of the finest grid
+	Heights    []float64 // (Resolution+1)^2 world heights, row by row along +Z
+
+	normals []Point
+	root    *terrainChunk
+	visible []*terrainChunk
+}
+
+// terrainChunk is a quadtree node covering a square of finest-grid cells
+type terrainChunk struct {
+	X, Z     int // First cell on the finest grid
+	Size     int // Cells covered on the finest grid
+	Level    int // 0 is the root
+	Bounds   *AABB
+	Children []*terrainChunk
+
+	mesh *Mesh // Built on first use
+}
+
+// NewTerrain builds a terrain from a height function. u and v run from 0 to 1
+// across the terrain along X and Z; heights are scaled by HeightScale.
+func NewTerrain(settings TerrainSettings, height func(u, v float64) float64) *Terrain {
+	settings.ChunkSize = maxInt(settings.ChunkSize, 1)
+	settings.LODLevels = maxInt(settings.LODLevels, 1)
+
+	t := &Terrain{
+		Settings:   settings,
+		Resolution: settings.ChunkSize << (settings.LODLevels - 1),
+	}
+
+	samples := t.Resolution + 1
+	t.Heights = make([]float64, samples*samples)
+	for j := 0; j < samples; j++ {
+		for i := 0; i < samples; i++ {
+			u := float64(i) / float64(t.Resolution)
+			v := float64(j) / float64(t.Resolution)
+			t.Heights[j*samples+i] = height(u, v) * settings.HeightScale
+		}
+	}
+
+	t.computeNormals()
+	t.root = t.buildChunk(0, 0, t.Resolution, 0)
+	t.visible = []*terrainChunk{t.root}
+	return t
+}
+
+// NewTerrainFromHeightmap builds a terrain from the brightness of a texture.
+// The top row of the image lies along -Z.
+func NewTerrainFromHeightmap(heightmap *Texture, settings TerrainSettings) *Terrain {
+	return NewTerrain(settings, func(u, v float64) float64 {
+		c := heightmap.Sample(u, v, FilterLinear, WrapClamp)
+		return (float64(c.R) + float64(c.G) + float64(c.B)) / (3 * 255.0)
+	})
+}
+
+// NewTerrainFromNoise builds a terrain from fractal value noise with heights
+// between 0 and HeightScale
+func NewTerrainFromNoise(settings TerrainSettings, seed int64, octaves int) *Terrain {
+	return NewTerrain(settings, terrainNoise(seed, octaves))
+}
+
+// terrainNoise returns fractal value noise in [0, 1], starting from a lattice
+// of 4x4 cells across the terrain
+func terrainNoise(seed int64, octaves int) func(u, v float64) float64 {
+	octaves = maxInt(octaves, 1)
+
+	lattice := func(x, z int, octave int) float64 {
+		h := uint64(seed) ^ uint64(octave)*0x9E3779B97F4A7C15
+		h ^= uint64(int64(x)) * 0xBF58476D1CE4E5B9
+		h ^= uint64(int64(z)) * 0x94D049BB133111EB
+		h = (h ^ (h >> 30)) * 0xBF58476D1CE4E5B9
+		h = (h ^ (h >> 27)) * 0x94D049BB133111EB
+		h ^= h >> 31
+		return float64(h>>11) / float64(1<<53)
+	}
+
+	return func(u, v float64) float64 {
+		sum, amplitude, total := 0.0, 1.0, 0.0
+		frequency := 4.0
+		for o := 0; o < octaves; o++ {
+			x, z := u*frequency, v*frequency
+			ix, iz := int(math.Floor(x)), int(math.Floor(z))
+			tx, tz := SmoothStep(0, 1, x-float64(ix)), SmoothStep(0, 1, z-float64(iz))
+
+			a := lattice(ix, iz, o) + (lattice(ix+1, iz, o)-lattice(ix, iz, o))*tx
+			b := lattice(ix, iz+1, o) + (lattice(ix+1, iz+1, o)-lattice(ix, iz+1, o))*tx
+			sum += (a + (b-a)*tz) * amplitude
+
+			total += amplitude
+			amplitude *= 0.5
+			frequency *= 2
+		}
+		return sum / total
+	}
+}
+
+// cellSize returns the world width of a finest-grid cell
+func (t *Terrain) cellSize() float64 {
+	return t.Settings.Size / float64(t.Resolution)
+}
+
+// samplePosition returns the world position of a grid sample
+func (t *Terrain) samplePosition(i, j int) Point {
+	cell := t.cellSize()
+	return Point{
+		X: -t.Settings.Size/2 + float64(i)*cell,
+		Y: t.Heights[j*(t.Resolution+1)+i],
+		Z: -t.Settings.Size/2 + float64(j)*cell,
+	}
+}
+
+// computeNormals computes a normal per sample from central differences
+func (t *Terrain) computeNormals() {
+	samples := t.Resolution + 1
+	cell := t.cellSize()
+	t.normals = make([]Point, samples*samples)
+
+	for j := 0; j < samples; j++ {
+		for i := 0; i < samples; i++ {
+			i0, i1 := maxInt(i-1, 0), minInt(i+1, t.Resolution)
+			j0, j1 := maxInt(j-1, 0), minInt(j+1, t.Resolution)
+			dx := (t.Heights[j*samples+i1] - t.Heights[j*samples+i0]) / (float64(i1-i0) * cell)
+			dz := (t.Heights[j1*samples+i] - t.Heights[j0*samples+i]) / (float64(j1-j0) * cell)
+			t.normals[j*samples+i] = normalizePoint(Point{X: -dx, Y: 1, Z: -dz})
+		}
+	}
+}
+
+// buildChunk builds the quadtree below a chunk and computes its bounds
+func (t *Terrain) buildChunk(x, z, size, level int) *terrainChunk {
+	chunk := &terrainChunk{X: x, Z: z, Size: size, Level: level}
+
+	if level == t.Settings.LODLevels-1 {
+		points := make([]Point, 0, (size+1)*(size+1))
+		for j := z; j <= z+size; j++ {
+			for i := x; i <= x+size; i++ {
+				points = append(points, t.samplePosition(i, j))
+			}
+		}
+		chunk.Bounds = NewAABBFromPoints(points)
+		return chunk
+	}
+
+	half := size / 2
+	for _, offset := range [4][2]int{{0, 0}, {half, 0}, {0, half}, {half, half}} {
+		child := t.buildChunk(x+offset[0], z+offset[1], half, level+1)
+		chunk.Children = append(chunk.Children, child)
+		if chunk.Bounds == nil {
+			chunk.Bounds = NewAABB(child.Bounds.Min, child.Bounds.Max)
+		} else {
+			chunk.Bounds = chunk.Bounds.Merge(child.Bounds)
+		}
+	}
+	return chunk
+}
+
+// Update selects the chunks to draw for a camera position in the terrain's
+// local space
+func (t *Terrain) Update(cameraPos Point) {
+	t.visible = t.visible[:0]
+	t.selectChunks(t.root, cameraPos)
+}
+
+// selectChunks splits chunks near the camera down to the finest level
+func (t *Terrain) selectChunks(chunk *terrainChunk, cameraPos Point) {
+	width := float64(chunk.Size) * t.cellSize()
+	if len(chunk.Children) > 0 && distanceToAABB(cameraPos, chunk.Bounds) < t.Settings.LODDistance*width {
+		for _, child := range chunk.Children {
+			t.selectChunks(child, cameraPos)
+		}
+		return
+	}
+	t.visible = append(t.visible, chunk)
+}
+
+// distanceToAABB returns the distance from a point to a box (0 inside it)
+func distanceToAABB(p Point, box *AABB) float64 {
+	dx := math.Max(math.Max(box.Min.X-p.X, 0), p.X-box.Max.X)
+	dy := math.Max(math.Max(box.Min.Y-p.Y, 0), p.Y-box.Max.Y)
+	dz := math.Max(math.Max(box.Min.Z-p.Z, 0), p.Z-box.Max.Z)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// VisibleMeshes returns the meshes of the chunks chosen by the last Update
+func (t *Terrain) VisibleMeshes() []*Mesh {
+	meshes := make([]*Mesh, len(t.visible))
+	for i, chunk := range t.visible {
+		if chunk.mesh == nil {
+			chunk.mesh = t.buildChunkMesh(chunk)
+		}
+		meshes[i] = chunk.mesh
+	}
+	return meshes
+}
+
+// SetMaterial sets the material of the terrain and of every built chunk
+func (t *Terrain) SetMaterial(material IMaterial) {
+	t.Material = material
+	var visit func(chunk *terrainChunk)
+	visit = func(chunk *terrainChunk) {
+		if chunk.mesh != nil {
+			chunk.mesh.Material = material
+		}
+		for _, child := range chunk.Children {
+			visit(child)
+		}
+	}
+	visit(t.root)
+}
+
+// Bounds returns the terrain's bounding box in local space
+func (t *Terrain) Bounds() *AABB {
+	return NewAABB(t.root.Bounds.Min, t.root.Bounds.Max)
+}
+
+// buildChunkMesh builds a chunk's grid, sampling every step-th finest sample,
+// plus its skirt
+func (t *Terrain) buildChunkMesh(chunk *terrainChunk) *Mesh {
+	n := t.Settings.ChunkSize
+	step := chunk.Size / n
+	mesh := NewMesh()
+	mesh.Material = t.Material
+
+	for j := 0; j <= n; j++ {
+		for i := 0; i <= n; i++ {
+			t.addSampleVertex(mesh, chunk.X+i*step, chunk.Z+j*step, 0)
+		}
+	}
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			a := j*(n+1) + i
+			b, c := a+1, a+n+1
+			mesh.AddTriangleIndices(a, c, b)
+			mesh.AddTriangleIndices(b, c, c+1)
+		}
+	}
+
+	if t.Settings.SkirtDepth <= 0 {
+		return mesh
+	}
+
+	// Walk the border so each skirt quad faces away from the chunk
+	border := make([][2]int, 0, 4*n)
+	for i := 0; i < n; i++ {
+		border = append(border, [2]int{i, 0})
+	}
+	for j := 0; j < n; j++ {
+		border = append(border, [2]int{n, j})
+	}
+	for i := n; i > 0; i-- {
+		border = append(border, [2]int{i, n})
+	}
+	for j := n; j > 0; j-- {
+		border = append(border, [2]int{0, j})
+	}
+
+	first := len(mesh.Vertices)
+	for _, b := range border {
+		t.addSampleVertex(mesh, chunk.X+b[0]*step, chunk.Z+b[1]*step, t.Settings.SkirtDepth)
+	}
+	for k := range border {
+		next := (k + 1) % len(border)
+		p := border[k][1]*(n+1) + border[k][0]
+		q := border[next][1]*(n+1) + border[next][0]
+		mesh.AddTriangleIndices(p, q, first+k)
+		mesh.AddTriangleIndices(q, first+next, first+k)
+	}
+
+	return mesh
+}
+
+// addSampleVertex adds a grid sample lowered by drop, with its normal and a
+// UV spanning the whole terrain
+func (t *Terrain) addSampleVertex(mesh *Mesh, i, j int, drop float64) {
+	p := t.samplePosition(i, j)
+	mesh.AddVertexWithUV(p.X, p.Y-drop, p.Z, float64(i)/float64(t.Resolution), float64(j)/float64(t.Resolution))
+	mesh.Normals = append(mesh.Normals, t.normals[j*(t.Resolution+1)+i])
+}
+
+// gridCoords converts a local position to a finest-grid cell and the offset
+// within it; positions off the terrain are clamped to its edge
+func (t *Terrain) gridCoords(x, z float64) (i, j int, fx, fz float64) {
+	cell := t.cellSize()
+	gx := clampFloat((x+t.Settings.Size/2)/cell, 0, float64(t.Resolution))
+	gz := clampFloat((z+t.Settings.Size/2)/cell, 0, float64(t.Resolution))
+	i = minInt(int(gx), t.Resolution-1)
+	j = minInt(int(gz), t.Resolution-1)
+	return i, j, gx - float64(i), gz - float64(j)
+}
+
+// HeightAt returns the height of the finest grid at a local (x, z), matching
+// its triangles exactly
+func (t *Terrain) HeightAt(x, z float64) float64 {
+	i, j, fx, fz := t.gridCoords(x, z)
+	samples := t.Resolution + 1
+	h00 := t.Heights[j*samples+i]
+	h10 := t.Heights[j*samples+i+1]
+	h01 := t.Heights[(j+1)*samples+i]
+	h11 := t.Heights[(j+1)*samples+i+1]
+
+	// Cells are split along the diagonal from (1, 0) to (0, 1)
+	if fx+fz <= 1 {
+		return h00 + (h10-h00)*fx + (h01-h00)*fz
+	}
+	return h11 + (h01-h11)*(1-fx) + (h10-h11)*(1-fz)
+}
+
+// NormalAt returns the smooth surface normal at a local (x, z)
+func (t *Terrain) NormalAt(x, z float64) Point {
+	i, j, fx, fz := t.gridCoords(x, z)
+	samples := t.Resolution + 1
+	n00 := t.normals[j*samples+i]
+	n10 := t.normals[j*samples+i+1]
+	n01 := t.normals[(j+1)*samples+i]
+	n11 := t.normals[(j+1)*samples+i+1]
+
+	a := lerpPoint(n00, n10, fx)
+	b := lerpPoint(n01, n11, fx)
+	return normalizePoint(lerpPoint(a, b, fz))
+}
+
+// Raycast intersects a local-space ray with the finest grid, walking only the
+// quadtree chunks whose bounds the ray crosses. The direction need not be
+// normalized; distances are in units of its length.
+func (t *Terrain) Raycast(ray Ray, maxDistance float64) (bool, float64, Point) {
+	best := maxDistance
+	var normal Point
+	hit := false
+
+	var visit func(chunk *terrainChunk)
+	visit = func(chunk *terrainChunk) {
+		if ok, d := chunk.Bounds.IntersectsRay(ray); !ok || d > best {
+			return
+		}
+		if len(chunk.Children) > 0 {
+			for _, child := range chunk.Children {
+				visit(child)
+			}
+			return
+		}
+
+		for j := chunk.Z; j < chunk.Z+chunk.Size; j++ {
+			for i := chunk.X; i < chunk.X+chunk.Size; i++ {
+				a, b := t.samplePosition(i, j), t.samplePosition(i+1, j)
+				c, d := t.samplePosition(i, j+1), t.samplePosition(i+1, j+1)
+				for _, tri := range [2]Triangle{{P0: a, P1: c, P2: b}, {P0: b, P1: c, P2: d}} {
+					if ok, dist, _, _ := ray.IntersectsTriangle(&tri); ok && dist > 0 && dist < best {
+						best = dist
+						normal = normalizePoint(crossPoints(subPoints(tri.P1, tri.P0), subPoints(tri.P2, tri.P0)))
+						hit = true
+					}
+				}
+			}
+		}
+	}
+	visit(t.root)
+
+	return hit, best, normal
+}
+
+// CreateTerrain adds a terrain to the scene; Scene.UpdateTerrains picks its LOD
+func (s *Scene) CreateTerrain(name string, terrain *Terrain) *SceneNode {
+	node := NewSceneNode(name)
+	node.AddTag("terrain")
+	node.Object = terrain
+	s.AddNode(node)
+	return node
+}
+
+// UpdateTerrains selects the LOD of every terrain in the scene for the camera
+func (s *Scene) UpdateTerrains() {
+	if s.Camera == nil {
+		return
+	}
+	for _, node := range s.FindNodesByTag("terrain") {
+		if terrain, ok := node.Object.(*Terrain); ok {
+			terrain.Update(node.Transform.InverseTransformPoint(s.Camera.GetPosition()))
+		}
+	}
+}
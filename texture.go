@@ -284,6 +284,19 @@ func NewTexturedMaterial() TexturedMaterial {
 	}
 }
 
+// HasNormalMap reports whether a tangent-space normal map is in use
+func (tm *TexturedMaterial) HasNormalMap() bool {
+	return tm.UseTextures && tm.NormalMap != nil
+}
+
+// SampleNormal returns the tangent-space normal at a UV coordinate
+func (tm *TexturedMaterial) SampleNormal(u, v float64) Point {
+	if tm.HasNormalMap() {
+		return UnpackNormalMap(tm.NormalMap.Sample(u, v, tm.TextureFilter, tm.TextureWrap))
+	}
+	return Point{X: 0, Y: 0, Z: 1}
+}
+
 // TexturedTriangle extends Triangle with UV coordinates
 type TexturedTriangle struct {
 	Triangle
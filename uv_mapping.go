@@ -0,0 +1,611 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// UV MAPPING
+// ============================================================================
+// Meshes without texture coordinates get them by projection or by unwrapping:
+//   - planar, box (triplanar), cylindrical and spherical projections cover
+//     the usual primitive-shaped objects; V runs down the texture as it does
+//     for the generated primitives
+//   - the automatic unwrapper cuts the surface into nearly flat charts,
+//     flattens each one without overlaps, turns it to its smallest bounding
+//     rectangle and shelf-packs the charts like lightmap charts, so the
+//     result suits lightmaps and texture painting
+//
+// Corners of a vertex that end up with different UVs (box faces, wrap seams,
+// chart borders) split the vertex.
+// ============================================================================
+
+// HasUVs reports whether the mesh has a UV for every vertex
+func (m *Mesh) HasUVs() bool {
+	return len(m.Vertices) > 0 && len(m.UVs) == len(m.Vertices)
+}
+
+// ProjectPlanarUVs projects the mesh along normal, fitting its extent to
+// [0, 1] in both directions. Looking along -normal, U runs right and V down.
+func (m *Mesh) ProjectPlanarUVs(normal Point) {
+	if len(m.Indices) < 3 {
+		return
+	}
+
+	right, up := viewBasis(normal)
+	uvs := make([]TextureCoord, len(m.Indices))
+	for c, idx := range m.Indices {
+		p := m.Vertices[idx]
+		uvs[c] = TextureCoord{U: dotPoints(p, right), V: -dotPoints(p, up)}
+	}
+	fitUVs(uvs)
+	m.setCornerUVs(uvs)
+}
+
+// ProjectBoxUVs projects every face along the axis its normal is closest to,
+// as seen from outside the mesh. Each of the six directions is fitted to
+// [0, 1] on its own, so every side of a box gets the whole texture.
+func (m *Mesh) ProjectBoxUVs() {
+	triCount := len(m.Indices) / 3
+	if triCount == 0 {
+		return
+	}
+
+	axes := [6]Point{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1}}
+	var groups [6][]int // Corners projected along each axis
+	for t := 0; t < triCount; t++ {
+		n := m.faceNormal(t)
+		best, bestDot := 0, math.Inf(-1)
+		for a, axis := range axes {
+			if d := dotPoints(n, axis); d > bestDot+1e-9 {
+				best, bestDot = a, d
+			}
+		}
+		groups[best] = append(groups[best], t*3, t*3+1, t*3+2)
+	}
+
+	uvs := make([]TextureCoord, len(m.Indices))
+	for a, corners := range groups {
+		if len(corners) == 0 {
+			continue
+		}
+		right, up := viewBasis(axes[a])
+		projected := make([]TextureCoord, len(corners))
+		for i, c := range corners {
+			p := m.Vertices[m.Indices[c]]
+			projected[i] = TextureCoord{U: dotPoints(p, right), V: -dotPoints(p, up)}
+		}
+		fitUVs(projected)
+		for i, c := range corners {
+			uvs[c] = projected[i]
+		}
+	}
+	m.setCornerUVs(uvs)
+}
+
+// ProjectCylindricalUVs wraps U around axis (through the centre of the
+// mesh's bounds) and runs V from the top to the bottom of the mesh. Around Y
+// the sides get the mapping GenerateCylinder gives them.
+func (m *Mesh) ProjectCylindricalUVs(axis Point) {
+	m.projectAroundAxis(axis, false)
+}
+
+// ProjectSphericalUVs maps longitude around axis to U and latitude to V,
+// from the pole axis points to (V = 0) to the opposite one, about the centre
+// of the mesh's bounds. Around Y this is the mapping GenerateIcosphere uses.
+func (m *Mesh) ProjectSphericalUVs(axis Point) {
+	m.projectAroundAxis(axis, true)
+}
+
+// projectAroundAxis is the cylindrical or spherical projection. Triangles
+// crossing the seam wrap their small U values past 1 and corners on the
+// axis take the U of the rest of their triangle.
+func (m *Mesh) projectAroundAxis(axis Point, spherical bool) {
+	triCount := len(m.Indices) / 3
+	if triCount == 0 {
+		return
+	}
+
+	axis = normalizePoint(axis)
+	if pointLength(axis) < 0.5 {
+		axis = Point{Y: 1}
+	}
+	e1, e2 := axisBasis(axis)
+	bounds := NewAABBFromPoints(m.Vertices)
+	center := bounds.GetCenter()
+	size := pointLength(subPoints(bounds.Max, bounds.Min))
+
+	minH, maxH := math.Inf(1), math.Inf(-1)
+	for _, p := range m.Vertices {
+		h := dotPoints(subPoints(p, center), axis)
+		minH, maxH = math.Min(minH, h), math.Max(maxH, h)
+	}
+	height := math.Max(maxH-minH, 1e-12)
+
+	uvs := make([]TextureCoord, len(m.Indices))
+	for t := 0; t < triCount; t++ {
+		var corner [3]TextureCoord
+		var onAxis [3]bool
+		for k := 0; k < 3; k++ {
+			d := subPoints(m.Vertices[m.Indices[t*3+k]], center)
+			h := dotPoints(d, axis)
+			x, y := dotPoints(d, e1), dotPoints(d, e2)
+
+			u := math.Atan2(y, x) / (2 * math.Pi)
+			if u < 0 {
+				u++
+			}
+			corner[k].U = u
+			if spherical {
+				length := pointLength(d)
+				if length > 1e-12 {
+					corner[k].V = math.Acos(clampFloat(h/length, -1, 1)) / math.Pi
+				}
+				onAxis[k] = length <= 1e-12 || math.Abs(h)/length > 1-1e-9
+			} else {
+				corner[k].V = (maxH - h) / height
+				onAxis[k] = math.Hypot(x, y) <= 1e-9*size
+			}
+		}
+
+		maxU := math.Max(corner[0].U, math.Max(corner[1].U, corner[2].U))
+		for k := range corner {
+			if maxU-corner[k].U > 0.5 {
+				corner[k].U++
+			}
+		}
+		for k := range corner {
+			if onAxis[k] {
+				corner[k].U = (corner[(k+1)%3].U + corner[(k+2)%3].U) / 2
+			}
+		}
+		copy(uvs[t*3:t*3+3], corner[:])
+	}
+	m.setCornerUVs(uvs)
+}
+
+// ============================================================================
+// AUTOMATIC UNWRAPPING
+// ============================================================================
+
+// UnwrapSettings controls automatic unwrapping
+type UnwrapSettings struct {
+	MaxChartAngle float64 // Largest angle between a chart's faces and its first face, under 90 degrees
+	Resolution    int     // Texture size the charts are padded for, in texels
+	Padding       int     // Gutter around each chart in texels
+}
+
+// DefaultUnwrapSettings returns settings for a 512x512 texture
+func DefaultUnwrapSettings() UnwrapSettings {
+	return UnwrapSettings{
+		MaxChartAngle: math.Pi / 3,
+		Resolution:    512,
+		Padding:       2,
+	}
+}
+
+// AutoUnwrap replaces the mesh's UVs with an automatic unwrap, splitting the
+// vertices on chart borders
+func (m *Mesh) AutoUnwrap(settings UnwrapSettings) error {
+	uvs, err := UnwrapUVs(m, settings)
+	if err != nil {
+		return err
+	}
+	m.setCornerUVs(uvs)
+	return nil
+}
+
+// UnwrapUVs cuts the mesh into charts and lays them out without overlaps in
+// [0, 1]. It returns one UV per index, so the result can also be used as
+// mesh.LightmapUVs.
+//
+// A chart grows from its largest unassigned triangle across shared edges
+// (vertices are matched by position) to triangles facing within
+// MaxChartAngle of the first one, and is projected onto the plane of that
+// first triangle; a triangle whose projection would overlap the chart is left
+// to a later chart. All charts share one texel density.
+func UnwrapUVs(mesh *Mesh, settings UnwrapSettings) ([]TextureCoord, error) {
+	if settings.MaxChartAngle <= 0 || settings.MaxChartAngle >= math.Pi/2 {
+		return nil, fmt.Errorf("max chart angle must be between 0 and 90 degrees, got %g radians", settings.MaxChartAngle)
+	}
+	if settings.Resolution < 1 || settings.Padding < 0 {
+		return nil, fmt.Errorf("invalid unwrap resolution %d or padding %d", settings.Resolution, settings.Padding)
+	}
+	triCount := len(mesh.Indices) / 3
+	if triCount == 0 {
+		return nil, fmt.Errorf("mesh has no triangles")
+	}
+
+	normals := make([]Point, triCount)
+	areas := make([]float64, triCount)
+	totalArea := 0.0
+	for t := 0; t < triCount; t++ {
+		normals[t] = mesh.faceNormal(t)
+		a, b, c := mesh.triangleCorners(t)
+		areas[t] = pointLength(crossPoints(subPoints(b, a), subPoints(c, a))) / 2
+		totalArea += areas[t]
+	}
+
+	// Triangles across each welded edge
+	edges := make(map[[2]Point][]int)
+	for t := 0; t < triCount; t++ {
+		for k := 0; k < 3; k++ {
+			key := unwrapEdgeKey(mesh.Vertices[mesh.Indices[t*3+k]], mesh.Vertices[mesh.Indices[t*3+(k+1)%3]])
+			edges[key] = append(edges[key], t)
+		}
+	}
+
+	// Overlap grid cells: about two triangles across, but few enough that a
+	// huge triangle does not touch too many
+	bounds := NewAABBFromPoints(mesh.Vertices)
+	diagonal := pointLength(subPoints(bounds.Max, bounds.Min))
+	cell := math.Max(2*math.Sqrt(totalArea/float64(triCount)), diagonal/256)
+	if cell <= 0 {
+		cell = 1
+	}
+
+	seeds := make([]int, triCount)
+	for i := range seeds {
+		seeds[i] = i
+	}
+	sort.SliceStable(seeds, func(i, j int) bool { return areas[seeds[i]] > areas[seeds[j]] })
+
+	cosMax := math.Cos(settings.MaxChartAngle)
+	chartOf := make([]int, triCount)
+	for i := range chartOf {
+		chartOf[i] = -1
+	}
+	flat := make([][3][2]float64, triCount) // Triangles in their chart's plane
+	var charts [][]int
+
+	for _, seed := range seeds {
+		if chartOf[seed] >= 0 {
+			continue
+		}
+		id := len(charts)
+		axis := normals[seed]
+		if axis == (Point{}) {
+			axis = Point{Y: 1}
+		}
+		right, up := viewBasis(axis)
+		grid := make(map[[2]int][]int)
+
+		project := func(t int) [3][2]float64 {
+			var tri [3][2]float64
+			for k := 0; k < 3; k++ {
+				p := mesh.Vertices[mesh.Indices[t*3+k]]
+				tri[k] = [2]float64{dotPoints(p, right), dotPoints(p, up)}
+			}
+			return tri
+		}
+		cells := func(tri [3][2]float64, visit func(key [2]int) bool) {
+			x0 := int(math.Floor(math.Min(tri[0][0], math.Min(tri[1][0], tri[2][0])) / cell))
+			x1 := int(math.Floor(math.Max(tri[0][0], math.Max(tri[1][0], tri[2][0])) / cell))
+			y0 := int(math.Floor(math.Min(tri[0][1], math.Min(tri[1][1], tri[2][1])) / cell))
+			y1 := int(math.Floor(math.Max(tri[0][1], math.Max(tri[1][1], tri[2][1])) / cell))
+			for x := x0; x <= x1; x++ {
+				for y := y0; y <= y1; y++ {
+					if !visit([2]int{x, y}) {
+						return
+					}
+				}
+			}
+		}
+		add := func(t int, tri [3][2]float64) {
+			chartOf[t] = id
+			flat[t] = tri
+			cells(tri, func(key [2]int) bool {
+				grid[key] = append(grid[key], t)
+				return true
+			})
+		}
+		overlaps := func(tri [3][2]float64) bool {
+			hit := false
+			cells(tri, func(key [2]int) bool {
+				for _, other := range grid[key] {
+					if trianglesOverlap2D(tri, flat[other], 1e-7*cell) {
+						hit = true
+						return false
+					}
+				}
+				return true
+			})
+			return hit
+		}
+
+		add(seed, project(seed))
+		members := []int{seed}
+		for queue := []int{seed}; len(queue) > 0; {
+			t := queue[0]
+			queue = queue[1:]
+			for k := 0; k < 3; k++ {
+				key := unwrapEdgeKey(mesh.Vertices[mesh.Indices[t*3+k]], mesh.Vertices[mesh.Indices[t*3+(k+1)%3]])
+				for _, n := range edges[key] {
+					if chartOf[n] >= 0 {
+						continue
+					}
+					if normals[n] != (Point{}) && dotPoints(normals[n], axis) < cosMax {
+						continue
+					}
+					tri := project(n)
+					if overlaps(tri) {
+						continue
+					}
+					add(n, tri)
+					members = append(members, n)
+					queue = append(queue, n)
+				}
+			}
+		}
+		charts = append(charts, members)
+	}
+
+	// Turn each chart to its smallest bounding rectangle, corner at 0, 0
+	sizes := make([][2]float64, len(charts))
+	area := 0.0
+	for c, members := range charts {
+		points := make([][2]float64, 0, len(members)*3)
+		for _, t := range members {
+			points = append(points, flat[t][:]...)
+		}
+		cos, sin := minAreaRectangle(points)
+
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		for _, t := range members {
+			for k := range flat[t] {
+				x, y := flat[t][k][0], flat[t][k][1]
+				flat[t][k] = [2]float64{x*cos + y*sin, y*cos - x*sin}
+				minX, maxX = math.Min(minX, flat[t][k][0]), math.Max(maxX, flat[t][k][0])
+				minY, maxY = math.Min(minY, flat[t][k][1]), math.Max(maxY, flat[t][k][1])
+			}
+		}
+		for _, t := range members {
+			for k := range flat[t] {
+				flat[t][k][0] -= minX
+				flat[t][k][1] -= minY
+			}
+		}
+		sizes[c] = [2]float64{maxX - minX, maxY - minY}
+		area += sizes[c][0] * sizes[c][1]
+	}
+
+	scale, origins, ok := packCharts(sizes, area, settings.Resolution, settings.Padding)
+	if !ok {
+		return nil, fmt.Errorf("%d charts do not fit a %dx%d texture", len(charts), settings.Resolution, settings.Resolution)
+	}
+
+	uvs := make([]TextureCoord, triCount*3)
+	res := float64(settings.Resolution)
+	for t := 0; t < triCount; t++ {
+		origin := origins[chartOf[t]]
+		for k := 0; k < 3; k++ {
+			uvs[t*3+k] = TextureCoord{
+				U: (float64(origin[0]) + flat[t][k][0]*scale) / res,
+				V: (float64(origin[1]) + flat[t][k][1]*scale) / res,
+			}
+		}
+	}
+	return uvs, nil
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// setCornerUVs gives index c the UV uvs[c], duplicating vertices whose
+// corners disagree. Tangents follow the new UVs; meshlets are dropped once
+// vertices are added.
+func (m *Mesh) setCornerUVs(uvs []TextureCoord) {
+	vertexCount := len(m.Vertices)
+	hasNormals := m.HasNormals()
+	hasTangents := m.HasTangents()
+	hasColors := m.HasColors()
+
+	m.UVs = make([]TextureCoord, vertexCount)
+	assigned := make([]bool, vertexCount)
+	splits := make(map[int][]int) // Original vertex -> duplicates
+
+	sameUV := func(a, b TextureCoord) bool {
+		return math.Abs(a.U-b.U) < 1e-9 && math.Abs(a.V-b.V) < 1e-9
+	}
+
+	for c, uv := range uvs {
+		v := m.Indices[c]
+		if !assigned[v] {
+			m.UVs[v] = uv
+			assigned[v] = true
+			continue
+		}
+		if sameUV(m.UVs[v], uv) {
+			continue
+		}
+
+		target := -1
+		for _, dup := range splits[v] {
+			if sameUV(m.UVs[dup], uv) {
+				target = dup
+				break
+			}
+		}
+		if target < 0 {
+			target = len(m.Vertices)
+			m.Vertices = append(m.Vertices, m.Vertices[v])
+			m.UVs = append(m.UVs, uv)
+			if hasNormals {
+				m.Normals = append(m.Normals, m.Normals[v])
+			}
+			if hasColors {
+				m.Colors = append(m.Colors, m.Colors[v])
+			}
+			splits[v] = append(splits[v], target)
+		}
+		m.Indices[c] = target
+	}
+
+	if len(m.Vertices) != vertexCount {
+		m.Meshlets = nil
+	}
+	if hasTangents {
+		// Cannot fail: every vertex has a UV now
+		_ = m.GenerateTangents()
+	}
+}
+
+// faceNormal returns the unit normal of triangle t, zero if it is degenerate
+func (m *Mesh) faceNormal(t int) Point {
+	a, b, c := m.triangleCorners(t)
+	n := crossPoints(subPoints(b, a), subPoints(c, a))
+	if length := pointLength(n); length > 1e-12 {
+		return scalePoint(n, 1/length)
+	}
+	return Point{}
+}
+
+// triangleCorners returns the corner positions of triangle t
+func (m *Mesh) triangleCorners(t int) (Point, Point, Point) {
+	return m.Vertices[m.Indices[t*3]], m.Vertices[m.Indices[t*3+1]], m.Vertices[m.Indices[t*3+2]]
+}
+
+// viewBasis returns the right and up directions of a view looking along
+// -normal, keeping up as close to +Y as it can (-Z when looking down)
+func viewBasis(normal Point) (Point, Point) {
+	normal = normalizePoint(normal)
+	if pointLength(normal) < 0.5 {
+		normal = Point{Z: 1}
+	}
+	up := Point{Y: 1}
+	if math.Abs(normal.Y) > 0.99 {
+		up = Point{Z: -math.Copysign(1, normal.Y)}
+	}
+	up = normalizePoint(subPoints(up, scalePoint(normal, dotPoints(up, normal))))
+	return crossPoints(up, normal), up
+}
+
+// axisBasis returns two directions perpendicular to a unit axis; around Y
+// they are X and Z
+func axisBasis(axis Point) (Point, Point) {
+	helper := Point{X: 1}
+	if math.Abs(axis.X) > 0.9 {
+		helper = Point{Z: 1}
+	}
+	e1 := normalizePoint(subPoints(helper, scalePoint(axis, dotPoints(helper, axis))))
+	return e1, crossPoints(e1, axis)
+}
+
+// fitUVs scales and offsets coordinates to fill [0, 1] in U and V
+func fitUVs(uvs []TextureCoord) {
+	minU, minV := math.Inf(1), math.Inf(1)
+	maxU, maxV := math.Inf(-1), math.Inf(-1)
+	for _, uv := range uvs {
+		minU, maxU = math.Min(minU, uv.U), math.Max(maxU, uv.U)
+		minV, maxV = math.Min(minV, uv.V), math.Max(maxV, uv.V)
+	}
+	spanU, spanV := math.Max(maxU-minU, 1e-12), math.Max(maxV-minV, 1e-12)
+	for i := range uvs {
+		uvs[i] = TextureCoord{U: (uvs[i].U - minU) / spanU, V: (uvs[i].V - minV) / spanV}
+	}
+}
+
+// unwrapEdgeKey identifies an edge by its welded end points, in either
+// direction
+func unwrapEdgeKey(a, b Point) [2]Point {
+	a, b = weldKey(a), weldKey(b)
+	if b.X < a.X || (b.X == a.X && (b.Y < a.Y || (b.Y == a.Y && b.Z < a.Z))) {
+		a, b = b, a
+	}
+	return [2]Point{a, b}
+}
+
+// trianglesOverlap2D reports whether two triangles overlap by more than eps,
+// so triangles that only share an edge or a corner do not
+func trianglesOverlap2D(a, b [3][2]float64, eps float64) bool {
+	for _, tri := range [2][3][2]float64{a, b} {
+		for k := 0; k < 3; k++ {
+			dx, dy := tri[(k+1)%3][0]-tri[k][0], tri[(k+1)%3][1]-tri[k][1]
+			length := math.Hypot(dx, dy)
+			if length < 1e-15 {
+				continue
+			}
+			nx, ny := -dy/length, dx/length
+
+			minA, maxA := math.Inf(1), math.Inf(-1)
+			minB, maxB := math.Inf(1), math.Inf(-1)
+			for i := 0; i < 3; i++ {
+				pa := a[i][0]*nx + a[i][1]*ny
+				pb := b[i][0]*nx + b[i][1]*ny
+				minA, maxA = math.Min(minA, pa), math.Max(maxA, pa)
+				minB, maxB = math.Min(minB, pb), math.Max(maxB, pb)
+			}
+			if maxA <= minB+eps || maxB <= minA+eps {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// minAreaRectangle returns the rotation (cosine and sine) that turns the
+// points' smallest-area bounding rectangle axis-aligned. The rectangle has a
+// side along an edge of the convex hull, so only those are tried.
+func minAreaRectangle(points [][2]float64) (float64, float64) {
+	hull := convexHull2D(points)
+	bestCos, bestSin, bestArea := 1.0, 0.0, math.Inf(1)
+	for i := range hull {
+		dx, dy := hull[(i+1)%len(hull)][0]-hull[i][0], hull[(i+1)%len(hull)][1]-hull[i][1]
+		length := math.Hypot(dx, dy)
+		if length < 1e-15 {
+			continue
+		}
+		cos, sin := dx/length, dy/length
+
+		minX, minY := math.Inf(1), math.Inf(1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		for _, p := range hull {
+			x, y := p[0]*cos+p[1]*sin, p[1]*cos-p[0]*sin
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+		if area := (maxX - minX) * (maxY - minY); area < bestArea-1e-12 {
+			bestCos, bestSin, bestArea = cos, sin, area
+		}
+	}
+	return bestCos, bestSin
+}
+
+// convexHull2D returns the convex hull of points counter-clockwise (Andrew's
+// monotone chain)
+func convexHull2D(points [][2]float64) [][2]float64 {
+	sorted := append([][2]float64(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i][0] != sorted[j][0] {
+			return sorted[i][0] < sorted[j][0]
+		}
+		return sorted[i][1] < sorted[j][1]
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+
+	cross := func(o, a, b [2]float64) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+	hull := make([][2]float64, 0, 2*len(sorted))
+	for _, p := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
@@ -0,0 +1,712 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// ============================================================================
+// VOXEL WORLD
+// ============================================================================
+// A voxel world is a grid of unit blocks stored in cubic chunks. The world is
+// defined by a generator function plus the edits made to it; loaded chunks
+// are a cache of that content around the camera. Update loads the chunks
+// within LoadRadius of the camera, nearest first, and drops those beyond
+// UnloadRadius. Edited chunks are kept aside when unloaded so edits survive.
+//
+// Every chunk is drawn as one mesh built by greedy meshing: for each axis
+// direction and each slice of the chunk, the visible block faces form a 2D
+// mask, and runs of faces of the same block type are merged into the largest
+// rectangles that fit. A flat floor of a chunk becomes a single quad instead
+// of ChunkSize² of them. Faces between two chunks are culled against the
+// neighbour's content, so editing a block on a chunk border remeshes both.
+// ============================================================================
+
+// BlockType is the content of one voxel; BlockAir is empty
+type BlockType uint8
+
+const (
+	BlockAir BlockType = iota
+	BlockStone
+	BlockDirt
+	BlockGrass
+	BlockSand
+	BlockWood
+	BlockLeaves
+	BlockSnow
+
+	blockTypeCount
+)
+
+// blockColors are the diffuse colors of DefaultBlockMaterials
+var blockColors = [blockTypeCount]Color{
+	BlockStone:  {R: 128, G: 128, B: 128},
+	BlockDirt:   {R: 134, G: 96, B: 67},
+	BlockGrass:  {R: 95, G: 159, B: 53},
+	BlockSand:   {R: 219, G: 207, B: 163},
+	BlockWood:   {R: 102, G: 81, B: 51},
+	BlockLeaves: {R: 60, G: 120, B: 40},
+	BlockSnow:   {R: 240, G: 245, B: 250},
+}
+
+// DefaultBlockMaterials returns a matte material per block type, indexed by
+// BlockType. The entry for BlockAir is nil.
+func DefaultBlockMaterials() []IMaterial {
+	materials := make([]IMaterial, blockTypeCount)
+	for b := BlockStone; b < blockTypeCount; b++ {
+		material := NewMaterial()
+		material.DiffuseColor = blockColors[b]
+		material.SpecularStrength = 0.1
+		materials[b] = &material
+	}
+	return materials
+}
+
+// VoxelWorldSettings controls chunk size and streaming
+type VoxelWorldSettings struct {
+	ChunkSize         int     // Blocks along each chunk edge
+	HeightChunks      int     // Chunks stacked from y = 0; blocks above or below are air
+	BlockSize         float64 // World size of a block
+	LoadRadius        int     // Chunks loaded around the camera along X and Z
+	UnloadRadius      int     // Chunks farther than this along X or Z are unloaded (at least LoadRadius)
+	MaxLoadsPerUpdate int     // Chunks generated per Update (0 = no limit)
+}
+
+// DefaultVoxelWorldSettings returns settings for a 64 block tall world
+// streamed 4 chunks around the camera
+func DefaultVoxelWorldSettings() VoxelWorldSettings {
+	return VoxelWorldSettings{
+		ChunkSize:         16,
+		HeightChunks:      4,
+		BlockSize:         1.0,
+		LoadRadius:        4,
+		UnloadRadius:      5,
+		MaxLoadsPerUpdate: 8,
+	}
+}
+
+// VoxelGenerator returns the original block at a block position
+type VoxelGenerator func(x, y, z int) BlockType
+
+// ChunkCoord identifies a chunk; chunk (0, 0, 0) holds blocks 0 to ChunkSize-1
+// along each axis
+type ChunkCoord struct {
+	X, Y, Z int
+}
+
+// VoxelWorld is a chunked block world streamed around the camera
+type VoxelWorld struct {
+	Settings  VoxelWorldSettings
+	Generator VoxelGenerator
+	Materials []IMaterial // Indexed by BlockType
+
+	chunks map[ChunkCoord]*voxelChunk
+	saved  map[ChunkCoord][]BlockType // Edited chunks that were unloaded
+}
+
+// voxelChunk is a loaded chunk
+type voxelChunk struct {
+	Coord  ChunkCoord
+	blocks []BlockType // ChunkSize³ blocks, x fastest then z; nil if all air
+	solid  int         // Non-air blocks
+	edited bool        // Differs from the generator
+
+	mesh  *Mesh // Rebuilt on first use after a change
+	dirty bool
+}
+
+// NewVoxelWorld creates an empty world; Update loads chunks around the camera.
+// A nil generator makes a world of air.
+func NewVoxelWorld(settings VoxelWorldSettings, generator VoxelGenerator) *VoxelWorld {
+	settings.ChunkSize = maxInt(settings.ChunkSize, 1)
+	settings.HeightChunks = maxInt(settings.HeightChunks, 1)
+	settings.LoadRadius = maxInt(settings.LoadRadius, 0)
+	settings.UnloadRadius = maxInt(settings.UnloadRadius, settings.LoadRadius)
+	if settings.BlockSize <= 0 {
+		settings.BlockSize = 1
+	}
+	if generator == nil {
+		generator = func(x, y, z int) BlockType { return BlockAir }
+	}
+
+	return &VoxelWorld{
+		Settings:  settings,
+		Generator: generator,
+		Materials: DefaultBlockMaterials(),
+		chunks:    make(map[ChunkCoord]*voxelChunk),
+		saved:     make(map[ChunkCoord][]BlockType),
+	}
+}
+
+// VoxelTerrainGenerator returns rolling hills of fractal noise: grass over
+// dirt over stone, sand near baseHeight and snow on the peaks. Hills span
+// about 32 blocks.
+func VoxelTerrainGenerator(seed int64, baseHeight, amplitude int) VoxelGenerator {
+	noise := terrainNoise(seed, 4)
+	return func(x, y, z int) BlockType {
+		height := baseHeight + int(noise(float64(x)/128, float64(z)/128)*float64(amplitude))
+		switch {
+		case y >= height:
+			return BlockAir
+		case y < height-4:
+			return BlockStone
+		case y < height-1:
+			return BlockDirt
+		case height <= baseHeight+amplitude/4:
+			return BlockSand
+		case height >= baseHeight+amplitude*3/4:
+			return BlockSnow
+		}
+		return BlockGrass
+	}
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// chunkOf returns the chunk holding a block and the block's index in it
+func (w *VoxelWorld) chunkOf(x, y, z int) (ChunkCoord, int) {
+	n := w.Settings.ChunkSize
+	coord := ChunkCoord{X: floorDiv(x, n), Y: floorDiv(y, n), Z: floorDiv(z, n)}
+	lx, ly, lz := x-coord.X*n, y-coord.Y*n, z-coord.Z*n
+	return coord, (ly*n+lz)*n + lx
+}
+
+// inWorld reports whether a chunk lies within the world's height
+func (w *VoxelWorld) inWorld(coord ChunkCoord) bool {
+	return coord.Y >= 0 && coord.Y < w.Settings.HeightChunks
+}
+
+// GetBlock returns the block at a block position, whether or not its chunk
+// is loaded
+func (w *VoxelWorld) GetBlock(x, y, z int) BlockType {
+	coord, index := w.chunkOf(x, y, z)
+	if !w.inWorld(coord) {
+		return BlockAir
+	}
+	if chunk, ok := w.chunks[coord]; ok {
+		if chunk.blocks == nil {
+			return BlockAir
+		}
+		return chunk.blocks[index]
+	}
+	if blocks, ok := w.saved[coord]; ok {
+		if blocks == nil {
+			return BlockAir
+		}
+		return blocks[index]
+	}
+	return w.Generator(x, y, z)
+}
+
+// loadedBlock returns the block at a block position, or air if its chunk is
+// not loaded
+func (w *VoxelWorld) loadedBlock(x, y, z int) BlockType {
+	coord, index := w.chunkOf(x, y, z)
+	if chunk, ok := w.chunks[coord]; ok && chunk.blocks != nil {
+		return chunk.blocks[index]
+	}
+	return BlockAir
+}
+
+// SetBlock changes the block at a block position, loading its chunk if
+// needed, and marks the chunks whose meshes show it for remeshing. It
+// returns false for positions above or below the world.
+func (w *VoxelWorld) SetBlock(x, y, z int, block BlockType) bool {
+	coord, index := w.chunkOf(x, y, z)
+	if !w.inWorld(coord) {
+		return false
+	}
+	chunk := w.loadChunk(coord)
+
+	old := BlockAir
+	if chunk.blocks != nil {
+		old = chunk.blocks[index]
+	}
+	if old == block {
+		return true
+	}
+	if chunk.blocks == nil {
+		n := w.Settings.ChunkSize
+		chunk.blocks = make([]BlockType, n*n*n)
+	}
+	chunk.blocks[index] = block
+	if old == BlockAir {
+		chunk.solid++
+	} else if block == BlockAir {
+		chunk.solid--
+	}
+	chunk.edited = true
+	chunk.dirty = true
+
+	// Blocks on a chunk face also show in the neighbour's mesh
+	n := w.Settings.ChunkSize
+	local := [3]int{x - coord.X*n, y - coord.Y*n, z - coord.Z*n}
+	for axis := 0; axis < 3; axis++ {
+		for _, side := range []int{-1, 1} {
+			if (side < 0 && local[axis] != 0) || (side > 0 && local[axis] != n-1) {
+				continue
+			}
+			neighbour := coord
+			switch axis {
+			case 0:
+				neighbour.X += side
+			case 1:
+				neighbour.Y += side
+			case 2:
+				neighbour.Z += side
+			}
+			if c, ok := w.chunks[neighbour]; ok {
+				c.dirty = true
+			}
+		}
+	}
+	return true
+}
+
+// loadChunk returns a loaded chunk, generating it or restoring its edits
+func (w *VoxelWorld) loadChunk(coord ChunkCoord) *voxelChunk {
+	if chunk, ok := w.chunks[coord]; ok {
+		return chunk
+	}
+
+	chunk := &voxelChunk{Coord: coord, dirty: true}
+	if blocks, ok := w.saved[coord]; ok {
+		chunk.blocks = blocks
+		chunk.edited = true
+		delete(w.saved, coord)
+	} else {
+		n := w.Settings.ChunkSize
+		blocks := make([]BlockType, n*n*n)
+		i := 0
+		for y := 0; y < n; y++ {
+			for z := 0; z < n; z++ {
+				for x := 0; x < n; x++ {
+					blocks[i] = w.Generator(coord.X*n+x, coord.Y*n+y, coord.Z*n+z)
+					i++
+				}
+			}
+		}
+		chunk.blocks = blocks
+	}
+
+	for _, b := range chunk.blocks {
+		if b != BlockAir {
+			chunk.solid++
+		}
+	}
+	if chunk.solid == 0 {
+		chunk.blocks = nil
+	}
+	w.chunks[coord] = chunk
+	return chunk
+}
+
+// unloadChunk drops a chunk, keeping its blocks if it was edited
+func (w *VoxelWorld) unloadChunk(coord ChunkCoord) {
+	chunk, ok := w.chunks[coord]
+	if !ok {
+		return
+	}
+	if chunk.edited {
+		w.saved[coord] = chunk.blocks
+	}
+	delete(w.chunks, coord)
+}
+
+// LoadedChunks returns the number of loaded chunks
+func (w *VoxelWorld) LoadedChunks() int {
+	return len(w.chunks)
+}
+
+// IsChunkLoaded reports whether a chunk is loaded
+func (w *VoxelWorld) IsChunkLoaded(coord ChunkCoord) bool {
+	_, ok := w.chunks[coord]
+	return ok
+}
+
+// Update loads and unloads chunks around a camera position in the world's
+// local space. It reports whether any chunk was loaded or unloaded, in which
+// case the world's bounds may have changed and spatial structures holding
+// it should be rebuilt.
+func (w *VoxelWorld) Update(cameraPos Point) bool {
+	chunkWidth := float64(w.Settings.ChunkSize) * w.Settings.BlockSize
+	cx := int(math.Floor(cameraPos.X / chunkWidth))
+	cz := int(math.Floor(cameraPos.Z / chunkWidth))
+	changed := false
+
+	for coord := range w.chunks {
+		if absInt(coord.X-cx) > w.Settings.UnloadRadius || absInt(coord.Z-cz) > w.Settings.UnloadRadius {
+			w.unloadChunk(coord)
+			changed = true
+		}
+	}
+
+	// Load the missing columns nearest the camera first
+	radius := w.Settings.LoadRadius
+	var missing []ChunkCoord
+	for z := cz - radius; z <= cz+radius; z++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			for y := 0; y < w.Settings.HeightChunks; y++ {
+				coord := ChunkCoord{X: x, Y: y, Z: z}
+				if _, ok := w.chunks[coord]; !ok {
+					missing = append(missing, coord)
+				}
+			}
+		}
+	}
+	distance := func(c ChunkCoord) int {
+		dx, dz := c.X-cx, c.Z-cz
+		return dx*dx + dz*dz
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		return distance(missing[i]) < distance(missing[j])
+	})
+	if limit := w.Settings.MaxLoadsPerUpdate; limit > 0 && len(missing) > limit {
+		missing = missing[:limit]
+	}
+
+	for _, coord := range missing {
+		w.loadChunk(coord)
+		changed = true
+	}
+	return changed
+}
+
+// VisibleMeshes returns the meshes of the loaded chunks that have blocks,
+// remeshing chunks changed since they were last drawn. With Bounds it makes
+// the world a MeshProvider.
+func (w *VoxelWorld) VisibleMeshes() []*Mesh {
+	coords := make([]ChunkCoord, 0, len(w.chunks))
+	for coord, chunk := range w.chunks {
+		if chunk.solid > 0 {
+			coords = append(coords, coord)
+		}
+	}
+	// Map order is random; keep draws stable from frame to frame
+	sort.Slice(coords, func(i, j int) bool {
+		a, b := coords[i], coords[j]
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.X < b.X
+	})
+
+	meshes := make([]*Mesh, 0, len(coords))
+	for _, coord := range coords {
+		chunk := w.chunks[coord]
+		if chunk.dirty || chunk.mesh == nil {
+			chunk.mesh = w.buildChunkMesh(chunk)
+			chunk.dirty = false
+		}
+		if len(chunk.mesh.Indices) > 0 {
+			meshes = append(meshes, chunk.mesh)
+		}
+	}
+	return meshes
+}
+
+// chunkBounds returns the local-space box of a chunk
+func (w *VoxelWorld) chunkBounds(coord ChunkCoord) *AABB {
+	width := float64(w.Settings.ChunkSize) * w.Settings.BlockSize
+	lo := Point{X: float64(coord.X) * width, Y: float64(coord.Y) * width, Z: float64(coord.Z) * width}
+	return NewAABB(lo, Point{X: lo.X + width, Y: lo.Y + width, Z: lo.Z + width})
+}
+
+// Bounds returns the local-space box of the loaded chunks that have blocks,
+// or an empty box at the origin if there are none
+func (w *VoxelWorld) Bounds() *AABB {
+	if bounds := w.solidBounds(); bounds != nil {
+		return bounds
+	}
+	return NewAABB(Point{}, Point{})
+}
+
+// solidBounds returns the box of the loaded chunks that have blocks, or nil
+func (w *VoxelWorld) solidBounds() *AABB {
+	var bounds *AABB
+	for coord, chunk := range w.chunks {
+		if chunk.solid == 0 {
+			continue
+		}
+		if bounds == nil {
+			bounds = w.chunkBounds(coord)
+		} else {
+			bounds = bounds.Merge(w.chunkBounds(coord))
+		}
+	}
+	return bounds
+}
+
+// buildChunkMesh greedy-meshes a chunk. Each quad has its own four vertices
+// with flat normals, UVs counted in blocks so textures tile per block, and
+// the material of its block type in FaceMaterials.
+func (w *VoxelWorld) buildChunkMesh(chunk *voxelChunk) *Mesh {
+	n := w.Settings.ChunkSize
+	size := w.Settings.BlockSize
+	mesh := NewMesh()
+	if chunk.solid == 0 {
+		return mesh
+	}
+
+	origin := [3]int{chunk.Coord.X * n, chunk.Coord.Y * n, chunk.Coord.Z * n}
+	block := func(p [3]int) BlockType {
+		if p[0] >= 0 && p[0] < n && p[1] >= 0 && p[1] < n && p[2] >= 0 && p[2] < n {
+			return chunk.blocks[(p[1]*n+p[2])*n+p[0]]
+		}
+		return w.GetBlock(origin[0]+p[0], origin[1]+p[1], origin[2]+p[2])
+	}
+
+	mask := make([]BlockType, n*n)
+	for d := 0; d < 3; d++ {
+		u, v := (d+1)%3, (d+2)%3
+		for _, side := range []int{-1, 1} {
+			normal := [3]float64{}
+			normal[d] = float64(side)
+
+			for k := 0; k < n; k++ {
+				// Faces of this slice not covered by the next block over
+				for j := 0; j < n; j++ {
+					for i := 0; i < n; i++ {
+						var p [3]int
+						p[d], p[u], p[v] = k, i, j
+						b := block(p)
+						p[d] += side
+						if b != BlockAir && block(p) != BlockAir {
+							b = BlockAir
+						}
+						mask[j*n+i] = b
+					}
+				}
+
+				// Merge runs of the same block into rectangles
+				for j := 0; j < n; j++ {
+					for i := 0; i < n; {
+						b := mask[j*n+i]
+						if b == BlockAir {
+							i++
+							continue
+						}
+						width := 1
+						for i+width < n && mask[j*n+i+width] == b {
+							width++
+						}
+						height := 1
+					grow:
+						for j+height < n {
+							for x := i; x < i+width; x++ {
+								if mask[(j+height)*n+x] != b {
+									break grow
+								}
+							}
+							height++
+						}
+						for y := j; y < j+height; y++ {
+							for x := i; x < i+width; x++ {
+								mask[y*n+x] = BlockAir
+							}
+						}
+
+						plane := k
+						if side > 0 {
+							plane++
+						}
+						w.addGreedyQuad(mesh, d, side, normal, origin, plane, i, j, width, height, size, b)
+						i += width
+					}
+				}
+			}
+		}
+	}
+
+	mesh.Material = mesh.MaterialForFace(0)
+	return mesh
+}
+
+// addGreedyQuad adds a quad on the plane at block coordinate plane along
+// axis d, covering width blocks along the next axis and height along the one
+// after, facing side
+func (w *VoxelWorld) addGreedyQuad(mesh *Mesh, d, side int, normal [3]float64, origin [3]int, plane, i, j, width, height int, size float64, block BlockType) {
+	u, v := (d+1)%3, (d+2)%3
+	corner := func(du, dv int) Point {
+		var c [3]float64
+		c[d] = float64(origin[d] + plane)
+		c[u] = float64(origin[u] + i + du)
+		c[v] = float64(origin[v] + j + dv)
+		return Point{X: c[0] * size, Y: c[1] * size, Z: c[2] * size}
+	}
+
+	first := len(mesh.Vertices)
+	for _, c := range [4][2]int{{0, 0}, {width, 0}, {width, height}, {0, height}} {
+		p := corner(c[0], c[1])
+		mesh.AddVertexWithUV(p.X, p.Y, p.Z, float64(c[0]), float64(c[1]))
+		mesh.Normals = append(mesh.Normals, Point{X: normal[0], Y: normal[1], Z: normal[2]})
+	}
+
+	// The u and v axes cross to +d, so counter-clockwise faces +d
+	if side > 0 {
+		mesh.AddTriangleIndices(first, first+1, first+2)
+		mesh.AddTriangleIndices(first, first+2, first+3)
+	} else {
+		mesh.AddTriangleIndices(first, first+2, first+1)
+		mesh.AddTriangleIndices(first, first+3, first+2)
+	}
+
+	var material IMaterial
+	if int(block) < len(w.Materials) {
+		material = w.Materials[block]
+	}
+	mesh.FaceMaterials = append(mesh.FaceMaterials, material, material)
+}
+
+// VoxelHit is the result of a block raycast
+type VoxelHit struct {
+	Hit      bool
+	Block    BlockType
+	X, Y, Z  int     // Block position
+	Normal   Point   // Outward normal of the face the ray entered through
+	Distance float64 // In units of the ray direction's length
+}
+
+// Adjacent returns the block position in front of the hit face, where a
+// block placed against it goes
+func (h VoxelHit) Adjacent() (x, y, z int) {
+	return h.X + int(h.Normal.X), h.Y + int(h.Normal.Y), h.Z + int(h.Normal.Z)
+}
+
+// Raycast walks a local-space ray through the loaded blocks with a 3D DDA
+// and returns the first solid block. The direction need not be normalized;
+// distances are in units of its length. A ray starting inside a block hits
+// it at distance 0 with a normal facing back along the ray.
+func (w *VoxelWorld) Raycast(ray Ray, maxDistance float64) VoxelHit {
+	size := w.Settings.BlockSize
+	origin := [3]float64{ray.Origin.X / size, ray.Origin.Y / size, ray.Origin.Z / size}
+	dir := [3]float64{ray.Direction.X / size, ray.Direction.Y / size, ray.Direction.Z / size}
+
+	// Clip the ray to the loaded blocks, in block units
+	bounds := w.solidBounds()
+	if bounds == nil || (dir == [3]float64{}) {
+		return VoxelHit{}
+	}
+	lo := [3]float64{bounds.Min.X / size, bounds.Min.Y / size, bounds.Min.Z / size}
+	hi := [3]float64{bounds.Max.X / size, bounds.Max.Y / size, bounds.Max.Z / size}
+	enter, exit := 0.0, maxDistance
+	enterAxis := -1
+	for a := 0; a < 3; a++ {
+		if dir[a] == 0 {
+			if origin[a] < lo[a] || origin[a] > hi[a] {
+				return VoxelHit{}
+			}
+			continue
+		}
+		t0, t1 := (lo[a]-origin[a])/dir[a], (hi[a]-origin[a])/dir[a]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > enter {
+			enter, enterAxis = t0, a
+		}
+		exit = math.Min(exit, t1)
+	}
+	if enter > exit {
+		return VoxelHit{}
+	}
+
+	var cell, step [3]int
+	var tMax, tDelta [3]float64
+	for a := 0; a < 3; a++ {
+		p := origin[a] + dir[a]*enter
+		cell[a] = clampInt(int(math.Floor(p)), int(math.Round(lo[a])), int(math.Round(hi[a]))-1)
+		switch {
+		case dir[a] > 0:
+			step[a] = 1
+			tMax[a] = (float64(cell[a]+1) - origin[a]) / dir[a]
+			tDelta[a] = 1 / dir[a]
+		case dir[a] < 0:
+			step[a] = -1
+			tMax[a] = (float64(cell[a]) - origin[a]) / dir[a]
+			tDelta[a] = -1 / dir[a]
+		default:
+			tMax[a] = math.Inf(1)
+			tDelta[a] = math.Inf(1)
+		}
+	}
+
+	// The face the ray enters the first cell through; from inside a block,
+	// the face along the ray's main axis
+	axis := enterAxis
+	if axis < 0 {
+		axis = 0
+		for a := 1; a < 3; a++ {
+			if math.Abs(dir[a]) > math.Abs(dir[axis]) {
+				axis = a
+			}
+		}
+	}
+
+	t := enter
+	for t <= exit {
+		if b := w.loadedBlock(cell[0], cell[1], cell[2]); b != BlockAir {
+			var normal [3]float64
+			normal[axis] = float64(-step[axis])
+			return VoxelHit{
+				Hit:      true,
+				Block:    b,
+				X:        cell[0],
+				Y:        cell[1],
+				Z:        cell[2],
+				Normal:   Point{X: normal[0], Y: normal[1], Z: normal[2]},
+				Distance: t,
+			}
+		}
+
+		axis = 0
+		if tMax[1] < tMax[axis] {
+			axis = 1
+		}
+		if tMax[2] < tMax[axis] {
+			axis = 2
+		}
+		t = tMax[axis]
+		cell[axis] += step[axis]
+		tMax[axis] += tDelta[axis]
+	}
+	return VoxelHit{}
+}
+
+// CreateVoxelWorld adds a voxel world to the scene; Scene.UpdateVoxelWorlds
+// streams its chunks
+func (s *Scene) CreateVoxelWorld(name string, world *VoxelWorld) *SceneNode {
+	node := NewSceneNode(name)
+	node.AddTag("voxel_world")
+	node.Object = world
+	s.AddNode(node)
+	return node
+}
+
+// UpdateVoxelWorlds streams the chunks of every voxel world in the scene
+// around the camera. It reports whether any world loaded or unloaded chunks,
+// so a BVH or octree built over the scene can be rebuilt.
+func (s *Scene) UpdateVoxelWorlds() bool {
+	if s.Camera == nil {
+		return false
+	}
+	changed := false
+	for _, node := range s.FindNodesByTag("voxel_world") {
+		if world, ok := node.Object.(*VoxelWorld); ok {
+			if world.Update(node.Transform.InverseTransformPoint(s.Camera.GetPosition())) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -31,6 +31,8 @@ type InputState struct {
 	SlowDown bool
 	Reset    bool
 	Quit     bool
+
+	ToggleHeatmap bool // Show lights-per-tile (terminal renderer)
 }
 
 // NewSilentInputManager creates a new silent input manager
@@ -128,6 +130,8 @@ func (sim *SilentInputManager) GetInputState() InputState {
 		SlowDown: sim.keys['-'] || sim.keys['_'],
 		Reset:    sim.keys['r'] || sim.keys['R'],
 		Quit:     sim.keys['x'] || sim.keys['X'],
+
+		ToggleHeatmap: sim.keys['h'] || sim.keys['H'],
 	}
 }
 